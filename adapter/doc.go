@@ -7,6 +7,7 @@
 // This package includes adapters for:
 //   - GoSkills: Custom Go-based skills and tools
 //   - MCP (Model Context Protocol): Standardized tool communication
+//   - Langfuse: Tracing of graph runs, nodes and LLM calls
 //
 // # Core Concepts
 //
@@ -19,6 +19,7 @@ type SkillTool struct {
 	description string
 	scriptMap   map[string]string
 	skillPath   string
+	schema      map[string]any
 }
 
 var _ tools.Tool = &SkillTool{}
@@ -31,6 +32,15 @@ func (t *SkillTool) Description() string {
 	return t.description
 }
 
+// Schema returns the JSON schema of the parameters of the tool, which the
+// prebuilt agents pass to the model with the definition of the tool
+func (t *SkillTool) Schema() map[string]any {
+	if t.schema == nil {
+		return map[string]any{"type": "object"}
+	}
+	return t.schema
+}
+
 func (t *SkillTool) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]any{
 		"name":        t.name,
@@ -42,15 +52,33 @@ func (t *SkillTool) MarshalJSON() ([]byte, error) {
 
 func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 	// input is the JSON string of arguments
-	// We need to parse it based on the tool name, similar to goskills runner.go
+	return t.run(func(params any) error {
+		return json.Unmarshal([]byte(input), params)
+	}, input == "")
+}
+
+// CallStructured implements the prebuilt.StructuredTool interface, running the
+// tool with the decoded arguments of a tool call
+func (t *SkillTool) CallStructured(ctx context.Context, args map[string]any) (string, error) {
+	return t.run(func(params any) error {
+		data, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, params)
+	}, len(args) == 0)
+}
 
+// run runs the tool with the arguments decode stores in the parameters of the
+// tool, similar to goskills runner.go. noArgs reports that the call has none.
+func (t *SkillTool) run(decode func(params any) error, noArgs bool) (string, error) {
 	switch t.name {
 	case "run_shell_code":
 		var params struct {
 			Code string         `json:"code"`
 			Args map[string]any `json:"args"`
 		}
-		if err := json.Unmarshal([]byte(input), &params); err != nil {
+		if err := decode(&params); err != nil {
 			return "", fmt.Errorf("failed to unmarshal run_shell_code arguments: %w", err)
 		}
 		shellTool := tool.ShellTool{}
@@ -61,7 +89,7 @@ func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 			ScriptPath string   `json:"scriptPath"`
 			Args       []string `json:"args"`
 		}
-		if err := json.Unmarshal([]byte(input), &params); err != nil {
+		if err := decode(&params); err != nil {
 			return "", fmt.Errorf("failed to unmarshal run_shell_script arguments: %w", err)
 		}
 		return tool.RunShellScript(params.ScriptPath, params.Args)
@@ -71,7 +99,7 @@ func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 			Code string         `json:"code"`
 			Args map[string]any `json:"args"`
 		}
-		if err := json.Unmarshal([]byte(input), &params); err != nil {
+		if err := decode(&params); err != nil {
 			return "", fmt.Errorf("failed to unmarshal run_python_code arguments: %w", err)
 		}
 		pythonTool := tool.PythonTool{}
@@ -82,7 +110,7 @@ func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 			ScriptPath string   `json:"scriptPath"`
 			Args       []string `json:"args"`
 		}
-		if err := json.Unmarshal([]byte(input), &params); err != nil {
+		if err := decode(&params); err != nil {
 			return "", fmt.Errorf("failed to unmarshal run_python_script arguments: %w", err)
 		}
 		return tool.RunPythonScript(params.ScriptPath, params.Args)
@@ -91,7 +119,7 @@ func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 		var params struct {
 			FilePath string `json:"filePath"`
 		}
-		if err := json.Unmarshal([]byte(input), &params); err != nil {
+		if err := decode(&params); err != nil {
 			return "", fmt.Errorf("failed to unmarshal read_file arguments: %w", err)
 		}
 		path := params.FilePath
@@ -108,7 +136,7 @@ func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 			FilePath string `json:"filePath"`
 			Content  string `json:"content"`
 		}
-		if err := json.Unmarshal([]byte(input), &params); err != nil {
+		if err := decode(&params); err != nil {
 			return "", fmt.Errorf("failed to unmarshal write_file arguments: %w", err)
 		}
 		err := tool.WriteFile(params.FilePath, params.Content)
@@ -121,7 +149,7 @@ func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 		var params struct {
 			Query string `json:"query"`
 		}
-		if err := json.Unmarshal([]byte(input), &params); err != nil {
+		if err := decode(&params); err != nil {
 			return "", fmt.Errorf("failed to unmarshal wikipedia_search arguments: %w", err)
 		}
 		return tool.WikipediaSearch(params.Query)
@@ -130,7 +158,7 @@ func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 		var params struct {
 			Query string `json:"query"`
 		}
-		if err := json.Unmarshal([]byte(input), &params); err != nil {
+		if err := decode(&params); err != nil {
 			return "", fmt.Errorf("failed to unmarshal tavily_search arguments: %w", err)
 		}
 		return tool.TavilySearch(params.Query)
@@ -139,7 +167,7 @@ func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 		var params struct {
 			URL string `json:"url"`
 		}
-		if err := json.Unmarshal([]byte(input), &params); err != nil {
+		if err := decode(&params); err != nil {
 			return "", fmt.Errorf("failed to unmarshal web_fetch arguments: %w", err)
 		}
 		return tool.WebFetch(params.URL)
@@ -149,8 +177,8 @@ func (t *SkillTool) Call(ctx context.Context, input string) (string, error) {
 			var params struct {
 				Args []string `json:"args"`
 			}
-			if input != "" {
-				if err := json.Unmarshal([]byte(input), &params); err != nil {
+			if !noArgs {
+				if err := decode(&params); err != nil {
 					return "", fmt.Errorf("failed to unmarshal script arguments: %w", err)
 				}
 			}
@@ -174,26 +202,40 @@ func SkillsToTools(skill *goskills.SkillPackage) ([]tools.Tool, error) {
 			continue
 		}
 
-		// Create a description that includes the arguments schema if possible,
-		// but langchaingo tools usually just have a text description.
-		// We can append the JSON schema of parameters to the description to help the LLM.
-		desc := t.Function.Description
-		// Note: Parameters schema is available via t.Function.Parameters if needed,
-		// but langchaingo's tools.Tool interface doesn't have a Schema method.
-		// The schema would need to be handled separately if function calling support is required.
-		_ = t.Function.Parameters // Acknowledge parameters exist but aren't used here
+		schema, err := parameterSchema(t.Function.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the parameters of tool %s: %w", t.Function.Name, err)
+		}
 
 		result = append(result, &SkillTool{
 			name:        t.Function.Name,
-			description: desc,
+			description: t.Function.Description,
 			scriptMap:   scriptMap,
 			skillPath:   skill.Path,
+			schema:      schema,
 		})
 	}
 
 	return result, nil
 }
 
+// parameterSchema returns the parameters of a tool definition, such as a
+// jsonschema.Definition, as a JSON schema map
+func parameterSchema(parameters any) (map[string]any, error) {
+	if parameters == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, err
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
 // MCPToTools converts MCP tools to langchaingo tools.
 // Note: goskills also supports MCP. We can add a helper for that too if needed,
 // but the user specifically asked for "Skills封装".
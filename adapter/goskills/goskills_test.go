@@ -223,6 +223,24 @@ func TestSkillsToTools(t *testing.T) {
 		var _ func(*goskills.SkillPackage) ([]tools.Tool, error) = SkillsToTools
 		// This will compile if the function exists with the correct signature
 	})
+
+	t.Run("parameter_schemas", func(t *testing.T) {
+		result, err := SkillsToTools(&goskills.SkillPackage{Path: t.TempDir()})
+		require.NoError(t, err)
+		require.NotEmpty(t, result)
+
+		for _, tl := range result {
+			if tl.Name() != "run_shell_code" {
+				continue
+			}
+			schema := tl.(*SkillTool).Schema()
+			assert.Equal(t, "object", schema["type"])
+			assert.Contains(t, schema["properties"], "code")
+			assert.Equal(t, []any{"code"}, schema["required"])
+			return
+		}
+		t.Fatal("run_shell_code tool not found")
+	})
 }
 
 // TestSkillTool_ImplementsInterface verifies SkillTool implements tools.Tool
@@ -237,6 +255,11 @@ func TestSkillTool_ImplementsInterface(t *testing.T) {
 	assert.Equal(t, "test description", tool.Description())
 }
 
+func TestSkillTool_Schema(t *testing.T) {
+	tool := &SkillTool{name: "test"}
+	assert.Equal(t, map[string]any{"type": "object"}, tool.Schema())
+}
+
 // TestSkillTool_Call_EdgeCases tests various edge cases
 func TestSkillTool_Call_EdgeCases(t *testing.T) {
 	tests := []struct {
@@ -288,3 +311,21 @@ func TestSkillTool_Call_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestSkillTool_CallStructured_ReadFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.txt")
+	err := os.WriteFile(testFile, []byte("Structured content"), 0644)
+	require.NoError(t, err)
+
+	tool := &SkillTool{
+		name: "read_file",
+	}
+
+	result, err := tool.CallStructured(context.Background(), map[string]any{"filePath": testFile})
+	assert.NoError(t, err)
+	assert.Equal(t, "Structured content", result)
+
+	_, err = tool.CallStructured(context.Background(), map[string]any{"filePath": 42})
+	assert.Error(t, err)
+}
@@ -0,0 +1,180 @@
+package langfuse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ingestion event types
+const (
+	eventTraceCreate      = "trace-create"
+	eventSpanCreate       = "span-create"
+	eventSpanUpdate       = "span-update"
+	eventGenerationCreate = "generation-create"
+	eventGenerationUpdate = "generation-update"
+)
+
+// ingestionEvent is one event of a batch posted to the ingestion API
+type ingestionEvent struct {
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+	Body      any    `json:"body"`
+}
+
+type ingestionRequest struct {
+	Batch []ingestionEvent `json:"batch"`
+}
+
+// traceBody creates or updates a trace, keyed by ID
+type traceBody struct {
+	ID        string         `json:"id"`
+	Timestamp string         `json:"timestamp,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	SessionID string         `json:"sessionId,omitempty"`
+	Input     any            `json:"input,omitempty"`
+	Output    any            `json:"output,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	Tags      []string       `json:"tags,omitempty"`
+}
+
+// observationBody creates or updates a span or a generation, keyed by ID
+type observationBody struct {
+	ID                  string         `json:"id"`
+	TraceID             string         `json:"traceId"`
+	ParentObservationID string         `json:"parentObservationId,omitempty"`
+	Name                string         `json:"name,omitempty"`
+	StartTime           string         `json:"startTime,omitempty"`
+	EndTime             string         `json:"endTime,omitempty"`
+	Input               any            `json:"input,omitempty"`
+	Output              any            `json:"output,omitempty"`
+	Metadata            map[string]any `json:"metadata,omitempty"`
+	Level               string         `json:"level,omitempty"`
+	StatusMessage       string         `json:"statusMessage,omitempty"`
+	Model               string         `json:"model,omitempty"`
+	Usage               *usage         `json:"usage,omitempty"`
+}
+
+// usage is the token usage of a generation
+type usage struct {
+	Input  int    `json:"input,omitempty"`
+	Output int    `json:"output,omitempty"`
+	Total  int    `json:"total,omitempty"`
+	Unit   string `json:"unit,omitempty"`
+}
+
+// enqueue adds an event to the next batch, and wakes the flusher up once the
+// batch is full
+func (h *Handler) enqueue(eventType string, body any) {
+	h.mu.Lock()
+	h.queue = append(h.queue, ingestionEvent{
+		ID:        uuid.NewString(),
+		Timestamp: h.timestamp(),
+		Type:      eventType,
+		Body:      body,
+	})
+	full := len(h.queue) >= h.config.BatchSize
+	h.mu.Unlock()
+
+	if full {
+		select {
+		case h.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush posts the queued events to the ingestion API. Batches still failing after
+// the retries are dropped, and the error of the first is returned.
+func (h *Handler) Flush(ctx context.Context) error {
+	h.flushMu.Lock()
+	defer h.flushMu.Unlock()
+
+	var firstErr error
+	for {
+		h.mu.Lock()
+		n := min(len(h.queue), h.config.BatchSize)
+		batch := h.queue[:n:n]
+		h.queue = h.queue[n:]
+		h.mu.Unlock()
+		if n == 0 {
+			return firstErr
+		}
+		if err := h.send(ctx, batch); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+}
+
+// send posts batch, retrying on network errors and 5xx responses
+func (h *Handler) send(ctx context.Context, batch []ingestionEvent) error {
+	payload, err := json.Marshal(ingestionRequest{Batch: batch})
+	if err != nil {
+		return fmt.Errorf("failed to encode %d langfuse events: %w", len(batch), err)
+	}
+
+	backoff := h.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := h.post(ctx, payload)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= h.config.MaxRetries {
+			return fmt.Errorf("failed to send %d langfuse events: %w", len(batch), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to send %d langfuse events: %w", len(batch), ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one request, and reports whether a failure may be retried
+func (h *Handler) post(ctx context.Context, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.config.Host+"/api/public/ingestion", bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(h.config.PublicKey, h.config.SecretKey)
+
+	resp, err := h.config.HTTPClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 500:
+		return true, fmt.Errorf("langfuse returned %s: %s", resp.Status, body)
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("langfuse returned %s: %s", resp.Status, body)
+	case resp.StatusCode == http.StatusMultiStatus:
+		// Events Langfuse rejected are reported individually and not retried
+		var result struct {
+			Errors []struct {
+				ID      string `json:"id"`
+				Status  int    `json:"status"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		if json.Unmarshal(body, &result) == nil {
+			for _, e := range result.Errors {
+				log.Printf("warning: langfuse rejected event %s with status %d: %s", e.ID, e.Status, e.Message)
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,42 @@
+// Package langfuse sends graph runs to Langfuse (https://langfuse.com), so that
+// teams using it with LangChain in Python can follow their Go graphs there too.
+//
+// # Basic Usage
+//
+//	import (
+//		"github.com/smallnest/langgraphgo/adapter/langfuse"
+//		"github.com/smallnest/langgraphgo/graph"
+//	)
+//
+//	handler := langfuse.NewHandler(langfuse.Config{
+//		Host:      "https://cloud.langfuse.com",
+//		PublicKey: os.Getenv("LANGFUSE_PUBLIC_KEY"),
+//		SecretKey: os.Getenv("LANGFUSE_SECRET_KEY"),
+//	})
+//	defer handler.Shutdown(context.Background())
+//
+//	result, err := runnable.InvokeWithConfig(ctx, input, &graph.Config{
+//		Callbacks: []graph.CallbackHandler{handler},
+//		Metadata:  map[string]any{"thread_id": threadID},
+//	})
+//
+// # Mapping
+//
+// The handler posts the events of the ingestion API, /api/public/ingestion:
+//
+//   - a top-level run is a trace named after the graph, or Config.RunName, whose
+//     session is the run's "thread_id" metadata
+//   - each node execution is a span of the trace, from the start of its step until
+//     it returns
+//   - the tool calls and retrievals reported by a node are spans, and its LLM calls
+//     generations, children of the node's span. Generations record the token
+//     usage when the response is an *llms.ContentResponse whose generation info
+//     has PromptTokens, CompletionTokens or TotalTokens.
+//   - runs reported with a parentRunID, such as the items of a batch, are spans of
+//     the trace of their parent
+//
+// Events are sent in batches every Config.FlushInterval and whenever a batch is
+// full. Batches failing with a network error or a 5xx response are retried, and
+// those still failing are dropped with a warning. Shutdown sends the events still
+// queued.
+package langfuse
@@ -0,0 +1,484 @@
+package langfuse
+
+import (
+	"context"
+	"errors"
+	"log"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// DefaultHost is the Langfuse cloud host events are sent to by default
+const DefaultHost = "https://cloud.langfuse.com"
+
+// Config configures a Handler
+type Config struct {
+	// Host is the base URL of the Langfuse server, DefaultHost by default
+	Host string
+
+	// PublicKey and SecretKey are the API keys of the Langfuse project
+	PublicKey string
+	SecretKey string
+
+	// FlushInterval is how often queued events are sent, 5 seconds by default
+	FlushInterval time.Duration
+
+	// BatchSize is the maximum number of events per request, 100 by default.
+	// A full batch is sent without waiting for the flush interval.
+	BatchSize int
+
+	// MaxRetries is the number of times a batch is sent again after a network
+	// error or a 5xx response, 3 by default. RetryBackoff is the wait before the
+	// first retry, 500ms by default, and doubles for each one. A negative
+	// MaxRetries disables retries.
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// HTTPClient sends the requests, a client with a 10 second timeout by default
+	HTTPClient *http.Client
+}
+
+// ErrShutdown is returned by Shutdown when the handler was already shut down
+var ErrShutdown = errors.New("langfuse handler already shut down")
+
+// observation is an open trace, span or generation of a run or call
+type observation struct {
+	id      string
+	traceID string
+	update  string // event type updating the observation, empty for traces
+}
+
+// nodeKey identifies the execution of a node in a run
+type nodeKey struct {
+	runID string
+	node  string
+}
+
+// Handler is a graph.CallbackHandler sending graph runs to Langfuse: each top-level
+// run becomes a trace, its nodes, tool calls, retrievals and nested runs spans, and
+// its LLM calls generations with their token usage. Events are queued and sent in
+// batches in the background; call Shutdown to send the last ones.
+type Handler struct {
+	config Config
+	now    func() time.Time
+
+	mu    sync.Mutex
+	queue []ingestionEvent
+	open  map[string]observation  // by runID
+	nodes map[nodeKey]observation // open node spans, moved to open when they complete
+
+	flushMu  sync.Mutex
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+var (
+	_ graph.GraphCallbackHandler     = (*Handler)(nil)
+	_ graph.StepStartCallbackHandler = (*Handler)(nil)
+)
+
+// NewHandler returns a handler sending events to the Langfuse server of config,
+// and starts flushing them in the background.
+//
+// Example:
+//
+//	handler := langfuse.NewHandler(langfuse.Config{
+//	    PublicKey: os.Getenv("LANGFUSE_PUBLIC_KEY"),
+//	    SecretKey: os.Getenv("LANGFUSE_SECRET_KEY"),
+//	})
+//	defer handler.Shutdown(context.Background())
+//
+//	result, err := runnable.InvokeWithConfig(ctx, input, &graph.Config{
+//	    Callbacks: []graph.CallbackHandler{handler},
+//	})
+func NewHandler(config Config) *Handler {
+	if config.Host == "" {
+		config.Host = DefaultHost
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 500 * time.Millisecond
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	h := &Handler{
+		config: config,
+		now:    time.Now,
+		open:   make(map[string]observation),
+		nodes:  make(map[nodeKey]observation),
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// run flushes the queue every flush interval and whenever a batch is full, until
+// Shutdown
+func (h *Handler) run() {
+	defer close(h.done)
+	ticker := time.NewTicker(h.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+		case <-h.wake:
+		}
+		if err := h.Flush(context.Background()); err != nil {
+			log.Printf("warning: %v", err)
+		}
+	}
+}
+
+// Shutdown stops the background flushing and sends the queued events, waiting
+// until they are sent or ctx is done.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	stopped := false
+	h.stopOnce.Do(func() {
+		close(h.stop)
+		stopped = true
+	})
+	if !stopped {
+		return ErrShutdown
+	}
+
+	select {
+	case <-h.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return h.Flush(ctx)
+}
+
+func (h *Handler) timestamp() string {
+	return h.now().UTC().Format(time.RFC3339Nano)
+}
+
+// parent returns the observation a span reported with parentRunID belongs to: the
+// node running on ctx, else the run or call parentRunID. The caller holds h.mu.
+func (h *Handler) parent(ctx context.Context, parentRunID *string) (observation, bool) {
+	if node, ok := graph.NodeNameFromContext(ctx); ok {
+		if config, ok := graph.ConfigFromContext(ctx); ok {
+			if obs, ok := h.nodes[nodeKey{runID: config.RunID, node: node}]; ok {
+				return obs, true
+			}
+		}
+	}
+	if parentRunID != nil {
+		obs, ok := h.open[*parentRunID]
+		return obs, ok
+	}
+	return observation{}, false
+}
+
+// startObservation opens a span or generation for the call runID, child of its
+// parent, and returns false when it has none, so there is no trace to add it to
+func (h *Handler) startObservation(ctx context.Context, create, update string, serialized map[string]any, runID string, parentRunID *string, input any, metadata map[string]any) bool {
+	h.mu.Lock()
+	parent, ok := h.parent(ctx, parentRunID)
+	if !ok {
+		h.mu.Unlock()
+		return false
+	}
+	obs := observation{id: uuid.NewString(), traceID: parent.traceID, update: update}
+	h.open[runID] = obs
+	h.mu.Unlock()
+
+	body := &observationBody{
+		ID:        obs.id,
+		TraceID:   obs.traceID,
+		Name:      nameOf(serialized, "span"),
+		StartTime: h.timestamp(),
+		Input:     input,
+		Metadata:  metadata,
+	}
+	if parent.update != "" {
+		body.ParentObservationID = parent.id
+	}
+	if create == eventGenerationCreate {
+		body.Name = nameOf(serialized, "llm")
+		body.Model, _ = serialized["model"].(string)
+	}
+	h.enqueue(create, body)
+	return true
+}
+
+// endObservation closes the span or generation of runID with output, or err
+func (h *Handler) endObservation(runID string, output any, err error, tokens *usage) {
+	h.mu.Lock()
+	obs, ok := h.open[runID]
+	delete(h.open, runID)
+	h.mu.Unlock()
+	if !ok || obs.update == "" {
+		return
+	}
+
+	body := &observationBody{
+		ID:      obs.id,
+		TraceID: obs.traceID,
+		EndTime: h.timestamp(),
+		Output:  output,
+		Usage:   tokens,
+	}
+	if err != nil {
+		body.Level = "ERROR"
+		body.StatusMessage = err.Error()
+	}
+	h.enqueue(obs.update, body)
+}
+
+func nameOf(serialized map[string]any, fallback string) string {
+	if name, _ := serialized["name"].(string); name != "" {
+		return name
+	}
+	return fallback
+}
+
+// OnChainStart creates the trace of a top-level run, or the span of a run nested
+// in another
+func (h *Handler) OnChainStart(ctx context.Context, serialized map[string]any, inputs map[string]any, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	if h.startObservation(ctx, eventSpanCreate, eventSpanUpdate, serialized, runID, parentRunID, inputs, metadata) {
+		return
+	}
+
+	h.mu.Lock()
+	h.open[runID] = observation{id: runID, traceID: runID}
+	h.mu.Unlock()
+
+	body := &traceBody{
+		ID:        runID,
+		Timestamp: h.timestamp(),
+		Name:      nameOf(serialized, "graph"),
+		Input:     inputs,
+		Metadata:  metadata,
+		Tags:      tags,
+	}
+	body.SessionID, _ = metadata["thread_id"].(string)
+	h.enqueue(eventTraceCreate, body)
+}
+
+// OnChainEnd records the output of a run
+func (h *Handler) OnChainEnd(ctx context.Context, outputs map[string]any, runID string) {
+	h.endRun(runID, outputs, nil)
+}
+
+// OnChainError records the error of a run, on its nodes that didn't complete too
+func (h *Handler) OnChainError(ctx context.Context, err error, runID string) {
+	h.endRun(runID, map[string]any{"error": err.Error()}, err)
+}
+
+// endRun closes the node spans of runID still open, then the run's trace or span
+func (h *Handler) endRun(runID string, output any, err error) {
+	h.mu.Lock()
+	var nodes []observation
+	for key, obs := range h.nodes {
+		if key.runID == runID {
+			nodes = append(nodes, obs)
+			delete(h.nodes, key)
+		}
+	}
+	obs, ok := h.open[runID]
+	h.mu.Unlock()
+
+	for _, node := range nodes {
+		body := &observationBody{ID: node.id, TraceID: node.traceID, EndTime: h.timestamp()}
+		if err != nil {
+			body.Level = "ERROR"
+			body.StatusMessage = err.Error()
+		}
+		h.enqueue(eventSpanUpdate, body)
+	}
+	if !ok {
+		return
+	}
+	if obs.update != "" {
+		h.endObservation(runID, output, err, nil)
+		return
+	}
+
+	h.mu.Lock()
+	delete(h.open, runID)
+	h.mu.Unlock()
+	h.enqueue(eventTraceCreate, &traceBody{ID: obs.id, Output: output})
+}
+
+// OnGraphStepStart opens the spans of the nodes of a step
+func (h *Handler) OnGraphStepStart(ctx context.Context, nodes []string, state any) {
+	config, ok := graph.ConfigFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	run, ok := h.open[config.RunID]
+	if !ok {
+		h.mu.Unlock()
+		return
+	}
+	var bodies []*observationBody
+	for _, node := range nodes {
+		obs := observation{id: uuid.NewString(), traceID: run.traceID, update: eventSpanUpdate}
+		h.nodes[nodeKey{runID: config.RunID, node: node}] = obs
+		body := &observationBody{
+			ID:        obs.id,
+			TraceID:   obs.traceID,
+			Name:      node,
+			StartTime: h.timestamp(),
+			Input:     state,
+		}
+		if run.update != "" {
+			body.ParentObservationID = run.id
+		}
+		bodies = append(bodies, body)
+	}
+	h.mu.Unlock()
+
+	for _, body := range bodies {
+		h.enqueue(eventSpanCreate, body)
+	}
+}
+
+// OnGraphStep is called after each step; the nodes of the step have reported
+// their results already
+func (h *Handler) OnGraphStep(ctx context.Context, stepNode string, state any) {}
+
+// claimNode returns the span of the node whose completion the graph reports as a
+// tool run with runID, and false if the tool run is not a node of a run
+func (h *Handler) claimNode(ctx context.Context, serialized map[string]any, runID string, parentRunID *string) (observation, bool) {
+	name, _ := serialized["name"].(string)
+	if parentRunID == nil || name == "" {
+		return observation{}, false
+	}
+	if node, ok := graph.NodeNameFromContext(ctx); ok && node == name {
+		// A call made by the node itself
+		return observation{}, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := nodeKey{runID: *parentRunID, node: name}
+	obs, ok := h.nodes[key]
+	if !ok {
+		return observation{}, false
+	}
+	delete(h.nodes, key)
+	h.open[runID] = obs
+	return obs, true
+}
+
+// OnToolStart opens the span of a tool call. The graph reports the completion of
+// each node as a tool run too, which closes the node's span instead.
+func (h *Handler) OnToolStart(ctx context.Context, serialized map[string]any, inputStr string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	if obs, ok := h.claimNode(ctx, serialized, runID, parentRunID); ok {
+		body := &observationBody{ID: obs.id, TraceID: obs.traceID, Metadata: metadata}
+		if description, _ := serialized["description"].(string); description != "" {
+			body.Metadata = mergeMetadata(metadata, "description", description)
+		}
+		h.enqueue(eventSpanUpdate, body)
+		return
+	}
+	h.startObservation(ctx, eventSpanCreate, eventSpanUpdate, serialized, runID, parentRunID, inputStr, metadata)
+}
+
+// OnToolEnd closes the span of a tool call, or of the node it reports
+func (h *Handler) OnToolEnd(ctx context.Context, output string, runID string) {
+	h.endObservation(runID, output, nil, nil)
+}
+
+// OnToolError closes the span of a tool call with its error
+func (h *Handler) OnToolError(ctx context.Context, err error, runID string) {
+	h.endObservation(runID, nil, err, nil)
+}
+
+// OnLLMStart opens the generation of an LLM call. The model name is read from the
+// "model" key of serialized.
+func (h *Handler) OnLLMStart(ctx context.Context, serialized map[string]any, prompts []string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	h.startObservation(ctx, eventGenerationCreate, eventGenerationUpdate, serialized, runID, parentRunID, prompts, metadata)
+}
+
+// OnLLMEnd closes the generation of an LLM call with its output and token usage,
+// when response is an *llms.ContentResponse reporting them
+func (h *Handler) OnLLMEnd(ctx context.Context, response any, runID string) {
+	output := response
+	var tokens *usage
+	if resp, ok := response.(*llms.ContentResponse); ok && len(resp.Choices) > 0 {
+		output = resp.Choices[0].Content
+		tokens = usageOf(resp.Choices[0].GenerationInfo)
+	}
+	h.endObservation(runID, output, nil, tokens)
+}
+
+// OnLLMError closes the generation of an LLM call with its error
+func (h *Handler) OnLLMError(ctx context.Context, err error, runID string) {
+	h.endObservation(runID, nil, err, nil)
+}
+
+// OnRetrieverStart opens the span of a retrieval
+func (h *Handler) OnRetrieverStart(ctx context.Context, serialized map[string]any, query string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	h.startObservation(ctx, eventSpanCreate, eventSpanUpdate, serialized, runID, parentRunID, query, metadata)
+}
+
+// OnRetrieverEnd closes the span of a retrieval with its documents
+func (h *Handler) OnRetrieverEnd(ctx context.Context, documents []any, runID string) {
+	h.endObservation(runID, documents, nil, nil)
+}
+
+// OnRetrieverError closes the span of a retrieval with its error
+func (h *Handler) OnRetrieverError(ctx context.Context, err error, runID string) {
+	h.endObservation(runID, nil, err, nil)
+}
+
+// usageOf reads the token counts langchaingo models report in the generation info
+// of a choice, and returns nil when there are none
+func usageOf(info map[string]any) *usage {
+	count := func(key string) int {
+		switch v := info[key].(type) {
+		case int:
+			return v
+		case int64:
+			return int(v)
+		case float64:
+			return int(v)
+		}
+		return 0
+	}
+	tokens := &usage{
+		Input:  count("PromptTokens"),
+		Output: count("CompletionTokens"),
+		Total:  count("TotalTokens"),
+		Unit:   "TOKENS",
+	}
+	if tokens.Input == 0 && tokens.Output == 0 && tokens.Total == 0 {
+		return nil
+	}
+	return tokens
+}
+
+func mergeMetadata(metadata map[string]any, key string, value any) map[string]any {
+	merged := make(map[string]any, len(metadata)+1)
+	maps.Copy(merged, metadata)
+	merged[key] = value
+	return merged
+}
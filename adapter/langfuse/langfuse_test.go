@@ -0,0 +1,196 @@
+package langfuse
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ingestionServer is a fake ingestion API failing the first failures requests
+// with a 503, and recording the events of the others
+type ingestionServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	failures int
+	requests int
+	events   []map[string]any
+}
+
+func newIngestionServer(t *testing.T, failures int) *ingestionServer {
+	s := &ingestionServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "pk", user)
+		assert.Equal(t, "sk", pass)
+		assert.Equal(t, "/api/public/ingestion", r.URL.Path)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		if s.requests <= s.failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var req struct {
+			Batch []map[string]any `json:"batch"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		s.events = append(s.events, req.Batch...)
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = w.Write([]byte(`{"successes":[],"errors":[]}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestHandler(server *ingestionServer) *Handler {
+	return NewHandler(Config{
+		Host:          server.URL,
+		PublicKey:     "pk",
+		SecretKey:     "sk",
+		FlushInterval: time.Hour,
+		RetryBackoff:  time.Millisecond,
+	})
+}
+
+// newChatGraph builds a graph whose chat node reports an LLM call
+func newChatGraph(t *testing.T) *graph.StateRunnable[map[string]any] {
+	t.Helper()
+	g := graph.NewStateGraph[map[string]any]()
+	g.AddNode("chat", "Answers the user", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		config, _ := graph.ConfigFromContext(ctx)
+		for _, cb := range config.Callbacks {
+			cb.OnLLMStart(ctx, map[string]any{"name": "openai", "model": "gpt-4o"}, []string{"Hi"}, "llm-1", &config.RunID, nil, nil)
+			cb.OnLLMEnd(ctx, &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+				Content:        "Hello",
+				GenerationInfo: map[string]any{"PromptTokens": 10, "CompletionTokens": 5, "TotalTokens": 15},
+			}}}, "llm-1")
+		}
+		return map[string]any{"answer": "Hello"}, nil
+	})
+	g.AddEdge("chat", graph.END)
+	g.SetEntryPoint("chat")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	server := newIngestionServer(t, 1)
+	handler := newTestHandler(server)
+
+	_, err := newChatGraph(t).InvokeWithConfig(context.Background(), map[string]any{"question": "Hi"}, &graph.Config{
+		RunID:     "run-1",
+		RunName:   "chat",
+		Callbacks: []graph.CallbackHandler{handler},
+		Metadata:  map[string]any{"thread_id": "thread-1"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, handler.Shutdown(context.Background()))
+	assert.ErrorIs(t, handler.Shutdown(context.Background()), ErrShutdown)
+
+	// The batch was retried after the 503
+	assert.Equal(t, 2, server.requests)
+
+	var types []string
+	for _, event := range server.events {
+		types = append(types, event["type"].(string))
+		assert.NotEmpty(t, event["id"])
+		assert.NotEmpty(t, event["timestamp"])
+	}
+	require.Equal(t, []string{
+		"trace-create",
+		"span-create",
+		"generation-create",
+		"generation-update",
+		"span-update",
+		"span-update",
+		"trace-create",
+	}, types)
+
+	body := func(i int) map[string]any { return server.events[i]["body"].(map[string]any) }
+
+	trace := body(0)
+	assert.Equal(t, "run-1", trace["id"])
+	assert.Equal(t, "chat", trace["name"])
+	assert.Equal(t, "thread-1", trace["sessionId"])
+	assert.Equal(t, map[string]any{"question": "Hi"}, trace["input"])
+
+	node := body(1)
+	assert.Equal(t, "run-1", node["traceId"])
+	assert.Equal(t, "chat", node["name"])
+	assert.NotContains(t, node, "parentObservationId")
+	assert.NotEmpty(t, node["startTime"])
+
+	generation := body(2)
+	assert.Equal(t, "run-1", generation["traceId"])
+	assert.Equal(t, node["id"], generation["parentObservationId"])
+	assert.Equal(t, "openai", generation["name"])
+	assert.Equal(t, "gpt-4o", generation["model"])
+	assert.Equal(t, []any{"Hi"}, generation["input"])
+
+	generationEnd := body(3)
+	assert.Equal(t, generation["id"], generationEnd["id"])
+	assert.Equal(t, "Hello", generationEnd["output"])
+	assert.Equal(t, map[string]any{"input": 10.0, "output": 5.0, "total": 15.0, "unit": "TOKENS"}, generationEnd["usage"])
+	assert.NotEmpty(t, generationEnd["endTime"])
+
+	assert.Equal(t, map[string]any{"description": "Answers the user", "thread_id": "thread-1"}, body(4)["metadata"])
+	nodeEnd := body(5)
+	assert.Equal(t, node["id"], nodeEnd["id"])
+	assert.NotEmpty(t, nodeEnd["endTime"])
+	assert.NotEmpty(t, nodeEnd["output"])
+
+	assert.Equal(t, "run-1", body(6)["id"])
+	assert.NotEmpty(t, body(6)["output"])
+}
+
+func TestHandler_Error(t *testing.T) {
+	t.Parallel()
+	server := newIngestionServer(t, 0)
+	handler := newTestHandler(server)
+
+	g := graph.NewStateGraph[map[string]any]()
+	g.AddNode("fail", "fail", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return nil, assert.AnError
+	})
+	g.AddEdge("fail", graph.END)
+	g.SetEntryPoint("fail")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	_, err = runnable.InvokeWithConfig(context.Background(), map[string]any{}, &graph.Config{Callbacks: []graph.CallbackHandler{handler}})
+	require.Error(t, err)
+	require.NoError(t, handler.Shutdown(context.Background()))
+
+	require.Len(t, server.events, 4)
+	nodeEnd := server.events[2]["body"].(map[string]any)
+	assert.Equal(t, "span-update", server.events[2]["type"])
+	assert.Equal(t, "ERROR", nodeEnd["level"])
+	assert.Contains(t, nodeEnd["statusMessage"], assert.AnError.Error())
+	assert.Contains(t, server.events[3]["body"].(map[string]any)["output"], "error")
+}
+
+func TestHandler_ClientError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	handler := NewHandler(Config{Host: server.URL, FlushInterval: time.Hour, RetryBackoff: time.Millisecond})
+
+	handler.OnChainStart(context.Background(), nil, nil, "run-1", nil, nil, nil)
+	err := handler.Shutdown(context.Background())
+	assert.ErrorContains(t, err, "401")
+}
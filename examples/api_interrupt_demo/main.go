@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store"
 	"github.com/smallnest/langgraphgo/store/file"
 )
 
@@ -272,14 +273,14 @@ func (s *Server) HandleChat(w http.ResponseWriter, r *http.Request) {
 		threadID = fmt.Sprintf("session_%d", time.Now().UnixNano())
 	}
 
-	// Check if this thread has any checkpoints (to detect if we're resuming)
-	checkpoints, err := s.Store.List(ctx, threadID)
+	// Check if this thread has any checkpoints (to detect if we're resuming).
+	// Only the latest is needed, so fetch just that one.
+	checkpoints, err := store.ListPage(ctx, s.Store, threadID, store.ListOptions{Limit: 1, Descending: true})
 	isResuming := false
 	var latestCP *graph.Checkpoint
 
 	if err == nil && len(checkpoints) > 0 {
-		// Find the latest checkpoint
-		latestCP = checkpoints[len(checkpoints)-1]
+		latestCP = checkpoints[0]
 		// Check if the latest checkpoint has interrupt metadata
 		if event, ok := latestCP.Metadata["event"].(string); ok && event == "step" {
 			// The checkpoint was saved after a step completed
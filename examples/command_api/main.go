@@ -8,16 +8,47 @@ import (
 	"github.com/smallnest/langgraphgo/graph"
 )
 
+// anySchema adapts a MapSchema to StateGraph[any], whose nodes may return a *graph.Command
+type anySchema struct {
+	*graph.MapSchema
+}
+
+func (s anySchema) Init() any {
+	return s.MapSchema.Init()
+}
+
+func (s anySchema) Update(current, new any) (any, error) {
+	currentMap, _ := current.(map[string]any)
+	newMap, ok := new.(map[string]any)
+	if !ok {
+		return current, nil
+	}
+	return s.MapSchema.Update(currentMap, newMap)
+}
+
 func main() {
 	// Create a new state graph
 	g := graph.NewStateGraph[any]()
 
+	// "checks" collects the results of the fan-out branches
+	schema := graph.NewMapSchema()
+	schema.RegisterReducer("checks", graph.AppendReducer)
+	g.SetSchema(anySchema{schema})
+
 	g.AddNode("router", "router", func(ctx context.Context, state any) (any, error) {
 		m := state.(map[string]any)
 		val := m["value"].(int)
-		if val > 10 {
+		switch {
+		case val < 0:
+			// Goto END: apply the update and stop, skipping every other node
+			return &graph.Command{
+				Goto:   graph.END,
+				Update: map[string]any{"path": "rejected"},
+			}, nil
+		case val > 10:
+			// Goto []string: fan out to several nodes, merged by the reducers
 			return &graph.Command{
-				Goto:   "end_high",
+				Goto:   []string{"check_range", "check_parity"},
 				Update: map[string]any{"path": "high"},
 			}, nil
 		}
@@ -33,15 +64,24 @@ func main() {
 		return map[string]any{"value": val * 2}, nil
 	})
 
-	g.AddNode("end_high", "end_high", func(ctx context.Context, state any) (any, error) {
+	g.AddNode("check_range", "check_range", func(ctx context.Context, state any) (any, error) {
 		m := state.(map[string]any)
-		val := m["value"].(int)
-		return map[string]any{"value": val + 100}, nil
+		return map[string]any{"checks": []any{fmt.Sprintf("range ok: %v <= 100", m["value"])}}, nil
+	})
+
+	g.AddNode("check_parity", "check_parity", func(ctx context.Context, state any) (any, error) {
+		m := state.(map[string]any)
+		parity := "odd"
+		if m["value"].(int)%2 == 0 {
+			parity = "even"
+		}
+		return map[string]any{"checks": []any{"parity: " + parity}}, nil
 	})
 
 	g.SetEntryPoint("router")
 	g.AddEdge("process", graph.END)
-	g.AddEdge("end_high", graph.END)
+	g.AddEdge("check_range", graph.END)
+	g.AddEdge("check_parity", graph.END)
 
 	runnable, err := g.Compile()
 	if err != nil {
@@ -50,11 +90,25 @@ func main() {
 
 	// Test 1: Normal path
 	fmt.Println("--- Test 1: Normal Path ---")
-	res1, _ := runnable.Invoke(context.Background(), map[string]any{"value": 5})
+	res1, err := runnable.Invoke(context.Background(), map[string]any{"value": 5})
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Printf("Result (value=5): %v\n", res1)
 
-	// Test 2: High path
-	fmt.Println("\n--- Test 2: High Path ---")
-	res2, _ := runnable.Invoke(context.Background(), map[string]any{"value": 15})
+	// Test 2: High path fans out to both checks
+	fmt.Println("\n--- Test 2: High Path (fan-out) ---")
+	res2, err := runnable.Invoke(context.Background(), map[string]any{"value": 15})
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Printf("Result (value=15): %v\n", res2)
+
+	// Test 3: Negative values end the run immediately
+	fmt.Println("\n--- Test 3: Rejected (Goto END) ---")
+	res3, err := runnable.Invoke(context.Background(), map[string]any{"value": -1})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Result (value=-1): %v\n", res3)
 }
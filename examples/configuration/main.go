@@ -14,12 +14,9 @@ func main() {
 
 	g.AddNode("process", "process", func(ctx context.Context, state map[string]any) (map[string]any, error) {
 		// Access configuration from context
-		config := graph.GetConfig(ctx)
 		limit := 5 // Default
-		if config != nil && config.Configurable != nil {
-			if val, ok := config.Configurable["limit"].(int); ok {
-				limit = val
-			}
+		if val, ok := graph.ConfigValue[int](ctx, "limit"); ok {
+			limit = val
 		}
 
 		fmt.Printf("Processing with limit: %d\n", limit)
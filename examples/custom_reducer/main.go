@@ -15,6 +15,10 @@ func main() {
 	schema := graph.NewMapSchema()
 	// Using generic AppendReducer
 	schema.RegisterReducer("tags", graph.AppendReducer)
+	// Labels are a set: UniqueAppendReducer drops values that are already present
+	schema.RegisterReducer("labels", graph.UniqueAppendReducer)
+	// Scores from parallel branches are added up
+	schema.RegisterReducer("score", graph.SumReducer)
 	g.SetSchema(schema)
 
 	g.AddNode("start", "start", func(ctx context.Context, state map[string]any) (map[string]any, error) {
@@ -22,11 +26,11 @@ func main() {
 	})
 
 	g.AddNode("tagger_a", "tagger_a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
-		return map[string]any{"tags": []string{"A"}}, nil
+		return map[string]any{"tags": []string{"A"}, "labels": []string{"reviewed", "a"}, "score": 2}, nil
 	})
 
 	g.AddNode("tagger_b", "tagger_b", func(ctx context.Context, state map[string]any) (map[string]any, error) {
-		return map[string]any{"tags": []string{"B"}}, nil
+		return map[string]any{"tags": []string{"B"}, "labels": []string{"reviewed", "b"}, "score": 3}, nil
 	})
 
 	// Parallel execution for taggers
@@ -48,4 +52,6 @@ func main() {
 	}
 
 	fmt.Printf("Result tags: %v\n", res["tags"])
+	fmt.Printf("Result labels: %v\n", res["labels"])
+	fmt.Printf("Result score: %v\n", res["score"])
 }
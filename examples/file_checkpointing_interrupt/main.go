@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/smallnest/langgraphgo/graph"
+)
+
+func main() {
+	// Create a temporary directory for checkpoints
+	checkpointDir := "./checkpoints_interrupt"
+	if err := os.MkdirAll(checkpointDir, 0755); err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(checkpointDir) // Cleanup after run
+
+	fmt.Printf("Using checkpoint directory: %s\n", checkpointDir)
+
+	// Initialize FileCheckpointStore
+	store, err := graph.NewFileCheckpointStore(checkpointDir)
+	if err != nil {
+		log.Fatalf("Failed to create checkpoint store: %v", err)
+	}
+
+	// Define a setup function to create the graph logic. Each phase builds a fresh
+	// graph to simulate a process restart between the interrupt and the resume.
+	createGraph := func() *graph.CheckpointableStateGraph[map[string]any] {
+		g := graph.NewCheckpointableStateGraph[map[string]any]()
+		// The schema merges the resume input into the checkpointed state
+		g.SetSchema(graph.NewMapSchema())
+
+		g.AddNode("draft", "draft", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			fmt.Println("  [EXEC] Drafting refund")
+			return map[string]any{"refund": 120}, nil
+		})
+
+		g.AddNode("approve", "approve", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			fmt.Println("  [EXEC] Asking for approval")
+			// Pause mid-node and ask a human. On resume, Interrupt returns the answer.
+			answer, err := graph.Interrupt(ctx, fmt.Sprintf("Approve refund of $%v?", state["refund"]))
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("  [EXEC] Human answered: %v\n", answer)
+			return map[string]any{"approved": answer == "yes"}, nil
+		})
+
+		g.AddNode("notify", "notify", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			fmt.Printf("  [EXEC] Notifying customer (approved=%v)\n", state["approved"])
+			return map[string]any{"notified": true}, nil
+		})
+
+		g.AddEdge("draft", "approve")
+		g.AddEdge("approve", "notify")
+		g.AddEdge("notify", graph.END)
+		g.SetEntryPoint("draft")
+		return g
+	}
+
+	// define common config
+	threadID := "refund_thread"
+	baseConfig := graph.CheckpointConfig{
+		Store:    store,
+		AutoSave: true,
+	}
+	ctx := context.Background()
+
+	// ---------------------------------------------------------
+	// PHASE 1: Run until the approve node interrupts itself
+	// ---------------------------------------------------------
+	fmt.Println("\n--- PHASE 1: Running until the in-node interrupt ---")
+
+	g1 := createGraph()
+	g1.SetCheckpointConfig(baseConfig)
+	runnable1, err := g1.CompileCheckpointable()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = runnable1.InvokeWithConfig(ctx, map[string]any{"customer": "alice"}, graph.WithThreadID(threadID))
+	var interrupt *graph.GraphInterrupt
+	if !errors.As(err, &interrupt) {
+		log.Fatalf("Expected an interrupt in Phase 1, got: %v", err)
+	}
+	fmt.Printf("  [INFO] Interrupted at node %q with question: %v\n", interrupt.Node, interrupt.InterruptValue)
+
+	// The checkpoint records which node raised the interrupt
+	latestCP, err := store.GetLatestByThread(ctx, threadID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("  [INFO] Checkpoint: Node=%s, Event=%v, Version=%d\n", latestCP.NodeName, latestCP.Metadata["event"], latestCP.Version)
+
+	// ---------------------------------------------------------
+	// PHASE 2: Resume with the human's answer
+	// ---------------------------------------------------------
+	fmt.Println("\n--- PHASE 2: Resuming with the human's answer ---")
+
+	g2 := createGraph()
+	g2.SetCheckpointConfig(baseConfig)
+	runnable2, err := g2.CompileCheckpointable()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// With the thread_id set, the runnable loads the latest checkpoint and re-enters
+	// the interrupted node. ResumeValue becomes the return value of graph.Interrupt.
+	config := graph.WithThreadID(threadID)
+	config.ResumeValue = "yes"
+
+	res, err := runnable2.InvokeWithConfig(ctx, map[string]any{}, config)
+	if err != nil {
+		log.Fatalf("Execution failed in Phase 2: %v", err)
+	}
+
+	fmt.Printf("  [INFO] Final Result: %v\n", res)
+
+	if res["approved"] == true && res["notified"] == true && res["customer"] == "alice" {
+		fmt.Println("  [SUCCESS] Graph resumed inside the approve node and completed.")
+	} else {
+		fmt.Println("  [FAILURE] Final state missing steps.")
+	}
+}
@@ -2,14 +2,20 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store/bolt"
 )
 
 func main() {
+	useBolt := flag.Bool("bolt", false, "store checkpoints in a bbolt database instead of JSON files")
+	flag.Parse()
+
 	// Create a temporary directory for checkpoints
 	checkpointDir := "./checkpoints_resume"
 	if err := os.MkdirAll(checkpointDir, 0755); err != nil {
@@ -19,10 +25,21 @@ func main() {
 
 	fmt.Printf("Using checkpoint directory: %s\n", checkpointDir)
 
-	// Initialize FileCheckpointStore
-	store, err := graph.NewFileCheckpointStore(checkpointDir)
-	if err != nil {
-		log.Fatalf("Failed to create checkpoint store: %v", err)
+	// Initialize FileCheckpointStore, or the bbolt store as a drop-in replacement
+	var store graph.CheckpointStore
+	if *useBolt {
+		boltStore, err := bolt.NewBoltCheckpointStore(filepath.Join(checkpointDir, "checkpoints.db"))
+		if err != nil {
+			log.Fatalf("Failed to create checkpoint store: %v", err)
+		}
+		defer boltStore.Close()
+		store = boltStore
+	} else {
+		fileStore, err := graph.NewFileCheckpointStore(checkpointDir)
+		if err != nil {
+			log.Fatalf("Failed to create checkpoint store: %v", err)
+		}
+		store = fileStore
 	}
 
 	// Define a simplified setup function to create the graph logic
@@ -104,31 +121,18 @@ func main() {
 	// ---------------------------------------------------------
 	fmt.Println("\n--- PHASE 2: Resuming from checkpoint ---")
 
-	// 1. List checkpoints to find the latest state
-	checkpoints, err := store.List(ctx, threadID)
+	// Inspect the latest checkpoint of the thread
+	snapshot, err := runnable1.GetState(ctx, graph.WithThreadID(threadID))
 	if err != nil {
 		log.Fatal(err)
 	}
+	fmt.Printf("  [INFO] Latest checkpoint: ID=%s, Next=%v\n", snapshot.Config.Configurable["checkpoint_id"], snapshot.Metadata["next_nodes"])
+	fmt.Printf("  [INFO] State at checkpoint: %v\n", snapshot.Values)
 
-	if len(checkpoints) == 0 {
-		log.Fatal("No checkpoints found!")
-	}
-
-	// Sort by version (List implementation handles this but good to be sure or verify)
-	// Get the latest checkpoint
-	latestCP := checkpoints[len(checkpoints)-1]
-	fmt.Printf("  [INFO] Resuming from checkpoint: ID=%s, Node=%s, Version=%d\n", latestCP.ID, latestCP.NodeName, latestCP.Version)
-	fmt.Printf("  [INFO] State at checkpoint: %v\n", latestCP.State)
-
-	// 2. Prepare for resume
-	// We need to know where to resume FROM.
-	// Since we interrupted AFTER step 2, we want to proceed to step 3.
-	// Or, more accurately, we start execution.
-	// We must pass the LAST state as initial state.
-	// And we must tell the graph where to begin execution using `ResumeFrom`.
-	// Since we interrupted after `step2`, the next logical step strictly defined by the graph is `step3`.
-	// `ResumeFrom` overrides the entry point.
-
+	// A fresh runnable (e.g. after a process restart) resumes the thread with
+	// ResumeLatest. It loads the latest checkpoint and continues with the nodes
+	// that were scheduled after the breakpoint, so there is no need to work out
+	// ResumeFrom or pass the checkpointed state by hand.
 	g2 := createGraph()
 	g2.SetCheckpointConfig(baseConfig)
 	runnable2, err := g2.CompileCheckpointable()
@@ -136,25 +140,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	config2 := &graph.Config{
-		Configurable: map[string]any{"thread_id": threadID},
-		ResumeFrom:   []string{"step3"}, // Start directly at step 3
-	}
-
-	// Use the state from the checkpoint
-	// The state in checkpoint is generic 'any'. We cast it to map[string]any.
-	resumedState, ok := latestCP.State.(map[string]any)
-	if !ok {
-		// Try to handle map[string]any from JSON unmarshal
-		if m, ok := latestCP.State.(map[string]any); ok {
-			resumedState = m
-		} else {
-			log.Fatalf("Failed to cast checkpoint state to map[string]any: %T", latestCP.State)
-		}
-	}
-
-	// Invoke
-	res2, err := runnable2.InvokeWithConfig(ctx, resumedState, config2)
+	res2, err := runnable2.ResumeLatest(ctx, threadID)
 	if err != nil {
 		log.Fatalf("Execution failed in Phase 2: %v", err)
 	}
@@ -168,4 +154,17 @@ func main() {
 	} else {
 		fmt.Println("  [FAILURE] Final state missing steps.")
 	}
+
+	// Show what the resumed run changed since the interruption
+	fmt.Println("\n--- State changes since the interruption ---")
+	latest, err := store.GetLatestByThread(ctx, threadID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	interruptedID, _ := snapshot.Config.Configurable["checkpoint_id"].(string)
+	diff, err := graph.DiffStoredCheckpoints(ctx, store, interruptedID, latest.ID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(diff)
 }
@@ -59,6 +59,7 @@ func main() {
 		}
 		return state, nil
 	})
+	g.AddEdge("stream", graph.END)
 	g.SetEntryPoint("stream")
 	compile, _ := g.Compile()
 	answer := ""
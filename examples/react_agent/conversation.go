@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/prebuilt"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// scriptedTurns is a 30-turn conversation mixing facts to remember with
+// calculations, ending with questions about its beginning
+var scriptedTurns = []string{
+	"Hi! My name is Ada and I'm planning a bakery.",
+	"I want to bake 12 trays of 24 croissants a day. How many croissants is that?",
+	"Each croissant sells for 2.5 euros. What is the daily revenue?",
+	"The bakery will be called 'Le Petit Four'.",
+	"Rent is 1800 euros a month. What is that per day over 30 days?",
+	"Flour costs 0.8 euros per kilogram and I need 40 kilograms a day. What does flour cost daily?",
+	"Butter costs 9 euros per kilogram and I need 12 kilograms a day. What is the daily butter cost?",
+	"Add the daily flour and butter costs.",
+	"I'll open at 6 in the morning, every day but Monday.",
+	"How many days will I be open in a 4-week month?",
+	"My assistant, Louis, will earn 110 euros a day. What is that over 24 days?",
+	"Remember that my favourite pastry is the pain au chocolat.",
+	"If I sell 150 pains au chocolat a day at 2.8 euros, what do they bring in?",
+	"What is 288 * 2.5 + 150 * 2.8?",
+	"Electricity is about 35 euros a day. Multiply that by 24.",
+	"The oven cost 14000 euros. Over 36 months, what is that per month?",
+	"I want to keep 15 percent of revenue as a margin. What is 0.15 * 1140?",
+	"A wholesale client, the Hotel Lumière, wants 80 croissants a day.",
+	"At a wholesale price of 1.9 euros, what do the 80 croissants bring in?",
+	"How much is that over 24 days?",
+	"Packaging costs 0.12 euros per item. What is it for 518 items?",
+	"I'm thinking of adding baguettes at 1.2 euros each.",
+	"If I sell 200 baguettes a day, what do they bring in?",
+	"What is 1140 + 152 + 240?",
+	"Suppose a slow day sells only 60 percent of that. What is 1532 * 0.6?",
+	"Insurance is 95 euros a month. What is that per open day over 24 days?",
+	"What is the difference between 1532 and 919.2?",
+	"I'd like to hire a second assistant at the same pay as Louis.",
+	"What would both assistants cost together over 24 days?",
+	"To wrap up: what's my name, my bakery's name, the name of my assistant, my favourite pastry, and my wholesale client?",
+}
+
+// runConversation plays the scripted conversation with the agent, summarizing
+// the older messages once the history grows past 12 messages. The agent appends
+// to the "messages" it is invoked with, so the summarization runs between the
+// turns, on the state the agent returned.
+func runConversation(ctx context.Context, model llms.Model, agent *graph.StateRunnable[map[string]any]) error {
+	summarize := prebuilt.NewSummarizationNode(model, prebuilt.SummarizationOptions{
+		MaxMessages: 12,
+		KeepTurns:   3,
+	})
+
+	state := map[string]any{"messages": []llms.MessageContent{}}
+	for i, turn := range scriptedTurns {
+		fmt.Printf("\n[%d] User: %s\n", i+1, turn)
+		messages := state["messages"].([]llms.MessageContent)
+		state["messages"] = append(messages, llms.TextParts(llms.ChatMessageTypeHuman, turn))
+		// Every turn gets the full iteration budget of the agent
+		delete(state, "iteration_count")
+
+		res, err := agent.Invoke(ctx, state)
+		if err != nil {
+			return err
+		}
+		state = res
+
+		messages = state["messages"].([]llms.MessageContent)
+		if text, ok := messages[len(messages)-1].Parts[0].(llms.TextContent); ok {
+			fmt.Printf("[%d] Agent: %s\n", i+1, text.Text)
+		}
+
+		update, err := summarize(ctx, state)
+		if err != nil {
+			return err
+		}
+		if update != nil {
+			maps.Copy(state, update)
+			fmt.Printf("    (summarized the history down to %d messages)\n", len(state["messages"].([]llms.MessageContent)))
+		}
+	}
+
+	if summary, ok := state[prebuilt.DefaultSummaryKey].(string); ok {
+		fmt.Printf("\nFinal summary:\n%s\n", summary)
+	}
+	return nil
+}
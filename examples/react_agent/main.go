@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"strconv"
@@ -63,6 +64,9 @@ func (t CalculatorTool) Call(ctx context.Context, input string) (string, error)
 }
 
 func main() {
+	conversation := flag.Bool("conversation", false, "play a 30-turn scripted conversation, summarizing its older messages")
+	flag.Parse()
+
 	llm, err := openai.New()
 	if err != nil {
 		log.Fatal(err)
@@ -79,6 +83,13 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if *conversation {
+		if err := runConversation(context.Background(), llm, agent); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Execute
 	query := "What is 25 * 4?"
 	fmt.Printf("User: %s\n", query)
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/prebuilt"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// CalculatorTool is a simple tool for demonstration
+type CalculatorTool struct{}
+
+func (t CalculatorTool) Name() string {
+	return "calculator"
+}
+
+func (t CalculatorTool) Description() string {
+	return "Useful for performing basic arithmetic operations. Input should be a string like '2 + 2' or '5 * 10'."
+}
+
+func (t CalculatorTool) Call(ctx context.Context, input string) (string, error) {
+	parts := strings.Fields(input)
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid input format, expected 'a op b'")
+	}
+
+	a, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return "", err
+	}
+	b, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return "", err
+	}
+
+	switch parts[1] {
+	case "+":
+		return fmt.Sprintf("%g", a+b), nil
+	case "-":
+		return fmt.Sprintf("%g", a-b), nil
+	case "*":
+		return fmt.Sprintf("%g", a*b), nil
+	case "/":
+		if b == 0 {
+			return "", fmt.Errorf("division by zero")
+		}
+		return fmt.Sprintf("%g", a/b), nil
+	default:
+		return "", fmt.Errorf("unknown operator: %s", parts[1])
+	}
+}
+
+func main() {
+	llm, err := openai.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	runnable, err := newAgent(llm, []tools.Tool{CalculatorTool{}})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The reviewer approves the first computation and denies the second one
+	ask(runnable, "approved", "What is 1234 * 5678?", func(call prebuilt.PendingToolCall) prebuilt.ToolCallDecision {
+		return prebuilt.ToolCallDecision{Approved: true}
+	})
+	ask(runnable, "denied", "What is 2 to the power of 10? Use the calculator.", func(call prebuilt.PendingToolCall) prebuilt.ToolCallDecision {
+		return prebuilt.ToolCallDecision{Reason: "the calculator is reserved for the accounting team"}
+	})
+}
+
+// newAgent returns a ReAct agent whose tool calls wait for a human approval
+func newAgent(llm llms.Model, inputTools []tools.Tool) (*graph.CheckpointableRunnable[map[string]any], error) {
+	var toolDefs []llms.Tool
+	for _, t := range inputTools {
+		toolDefs = append(toolDefs, llms.Tool{
+			Type: "function",
+			Function: &llms.FunctionDefinition{
+				Name:        t.Name(),
+				Description: t.Description(),
+				Parameters: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"input": map[string]any{"type": "string", "description": "The input to the tool"},
+					},
+					"required": []string{"input"},
+				},
+			},
+		})
+	}
+
+	// Every node returns the messages it adds, which the reducer appends
+	schema := graph.NewMapSchema()
+	schema.RegisterReducer("messages", graph.AppendReducer)
+
+	workflow := graph.NewCheckpointableStateGraph[map[string]any]()
+	workflow.SetSchema(schema)
+
+	workflow.AddNode("agent", "Call the model", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		messages, _ := state["messages"].([]llms.MessageContent)
+		resp, err := llm.GenerateContent(ctx, messages, llms.WithTools(toolDefs))
+		if err != nil {
+			return nil, err
+		}
+		choice := resp.Choices[0]
+		msg := llms.MessageContent{Role: llms.ChatMessageTypeAI}
+		if choice.Content != "" {
+			msg.Parts = append(msg.Parts, llms.TextContent{Text: choice.Content})
+		}
+		for _, tc := range choice.ToolCalls {
+			msg.Parts = append(msg.Parts, tc)
+		}
+		return map[string]any{"messages": []llms.MessageContent{msg}}, nil
+	})
+	workflow.AddNode("approve", "Wait for a human approval", prebuilt.NewHumanApprovalNode(prebuilt.HumanApprovalOptions{
+		Tools: []string{"calculator"},
+	}))
+	workflow.AddNode("tools", "Execute the approved tool calls", prebuilt.ToolNodeMap(prebuilt.NewToolExecutor(inputTools)))
+
+	workflow.SetEntryPoint("agent")
+	workflow.AddConditionalEdge("agent", prebuilt.ToolsCondition("approve", graph.END))
+	workflow.AddConditionalEdge("approve", prebuilt.ApprovalCondition("tools", "agent"))
+	workflow.AddEdge("tools", "agent")
+
+	return workflow.CompileCheckpointable()
+}
+
+// ask runs the agent on question in thread, resuming it with the decisions of
+// review until it answers
+func ask(runnable *graph.CheckpointableRunnable[map[string]any], thread, question string, review func(prebuilt.PendingToolCall) prebuilt.ToolCallDecision) {
+	ctx := context.Background()
+	fmt.Printf("\n=== %s ===\n", question)
+
+	state, err := runnable.InvokeWithConfig(ctx, map[string]any{
+		"messages": []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, question)},
+	}, graph.WithThreadID(thread))
+
+	var interrupt *graph.GraphInterrupt
+	for errors.As(err, &interrupt) {
+		request := interrupt.InterruptValue.(prebuilt.ApprovalRequest)
+		decision := prebuilt.ApprovalDecision{ToolCalls: map[string]prebuilt.ToolCallDecision{}}
+		for _, call := range request.ToolCalls {
+			d := review(call)
+			fmt.Printf("[Approval] %s(%s) approved: %v %s\n", call.Name, call.Arguments, d.Approved, d.Reason)
+			decision.ToolCalls[call.ID] = d
+		}
+		state, err = runnable.InvokeCommand(ctx, &graph.Command{Resume: decision}, graph.WithThreadID(thread))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	messages := state["messages"].([]llms.MessageContent)
+	for _, msg := range messages[1:] {
+		for _, part := range msg.Parts {
+			switch p := part.(type) {
+			case llms.TextContent:
+				fmt.Printf("[%s] %s\n", msg.Role, p.Text)
+			case llms.ToolCallResponse:
+				fmt.Printf("[%s] %s: %s\n", msg.Role, p.Name, p.Content)
+			}
+		}
+	}
+}
@@ -0,0 +1,541 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// asciiWrapWidth is the maximum width of a node name before it is wrapped
+	asciiWrapWidth = 16
+
+	// asciiBoxGap is the horizontal space between boxes in the same layer
+	asciiBoxGap = 4
+)
+
+// asciiNode is a box (or, for dummy nodes, a vertical line segment) in the ASCII layout
+type asciiNode struct {
+	lines []string
+	width int
+	dummy bool
+
+	// conditional marks dummy nodes on a conditional edge and nodes with an unmapped router
+	conditional bool
+
+	// order is the tie-breaker when sorting nodes within a layer
+	order string
+
+	layer int
+	x     int
+}
+
+func (n *asciiNode) center() int {
+	return n.x + n.width/2
+}
+
+// asciiEdge connects two nodes in the ASCII layout
+type asciiEdge struct {
+	from, to    int
+	conditional bool
+}
+
+// DrawASCII generates an ASCII boxes-and-arrows representation of the graph for
+// terminal debugging. Nodes are laid out top to bottom in topological order, with
+// nodes of the same depth side by side. Conditional edges are drawn with dotted
+// lines (":" and "."), nodes with an unmapped conditional router carry a "(?)" marker
+// on their bottom border, and edges that close a cycle are listed next to the layer
+// of their source node. Long node names are wrapped.
+func (ge *Exporter[S]) DrawASCII() string {
+	if ge.graph.entryPoint == "" && !ge.graph.hasConditionalEntry() {
+		return "No entry point set\n"
+	}
+
+	nodes, edges := ge.asciiGraph()
+	forward, back := asciiBreakCycles(len(nodes), edges)
+	asciiAssignLayers(nodes, forward)
+	forward = asciiInsertDummies(&nodes, forward)
+	layers := asciiOrderLayers(nodes, forward)
+	asciiPlace(nodes, layers)
+
+	var sb strings.Builder
+	for i, layer := range layers {
+		asciiDrawLayer(&sb, nodes, layer, back, forward)
+		if i < len(layers)-1 {
+			asciiDrawConnectors(&sb, nodes, forward, i)
+		}
+	}
+	return sb.String()
+}
+
+// asciiGraph collects the boxes and edges to draw: START, the graph's nodes, and END
+// if any edge targets it. Node 0 is always START.
+func (ge *Exporter[S]) asciiGraph() ([]*asciiNode, []asciiEdge) {
+	g := ge.graph
+	index := make(map[string]int)
+	var nodes []*asciiNode
+
+	add := func(name string) {
+		lines := wrapASCIILabel(name, asciiWrapWidth)
+		width := 0
+		for _, line := range lines {
+			width = max(width, len([]rune(line)))
+		}
+		// Odd widths keep the centers of stacked boxes aligned
+		width += 4
+		if width%2 == 0 {
+			width++
+		}
+		_, routed := g.conditionalEdges[name]
+		_, mapped := g.conditionalPathMaps[name]
+		index[name] = len(nodes)
+		nodes = append(nodes, &asciiNode{
+			lines:       lines,
+			width:       width,
+			conditional: routed && !mapped,
+			order:       name,
+		})
+	}
+
+	add(START)
+	for _, name := range sortedKeys(g.nodes) {
+		add(name)
+	}
+	if g.referencesEnd() {
+		add(END)
+		nodes[len(nodes)-1].conditional = false
+	}
+	// START is only routed by a conditional entry
+	_, mapped := g.conditionalPathMaps[START]
+	nodes[0].conditional = g.hasConditionalEntry() && !mapped
+
+	var edges []asciiEdge
+	seen := make(map[[2]int]bool)
+	connect := func(from, to string, conditional bool) {
+		f, ok1 := index[from]
+		t, ok2 := index[to]
+		if !ok1 || !ok2 || seen[[2]int{f, t}] {
+			return
+		}
+		seen[[2]int{f, t}] = true
+		edges = append(edges, asciiEdge{from: f, to: t, conditional: conditional})
+	}
+
+	if !g.hasConditionalEntry() {
+		connect(START, g.entryPoint, false)
+	}
+	for _, edge := range g.edges {
+		connect(edge.From, edge.To, false)
+	}
+	for _, from := range sortedKeys(g.conditionalPathMaps) {
+		pathMap := g.conditionalPathMaps[from]
+		for _, key := range sortedKeys(pathMap) {
+			connect(from, pathMap[key], true)
+		}
+	}
+	return nodes, edges
+}
+
+// asciiBreakCycles splits edges into forward edges, which form a DAG, and back edges,
+// which close a cycle found by a depth-first search from START.
+func asciiBreakCycles(n int, edges []asciiEdge) (forward []asciiEdge, back []asciiEdge) {
+	outgoing := make([][]int, n)
+	for i, edge := range edges {
+		outgoing[edge.from] = append(outgoing[edge.from], i)
+	}
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	visitState := make([]int, n)
+	isBack := make([]bool, len(edges))
+
+	var visit func(int)
+	visit = func(v int) {
+		visitState[v] = onStack
+		for _, i := range outgoing[v] {
+			switch visitState[edges[i].to] {
+			case onStack:
+				isBack[i] = true
+			case unvisited:
+				visit(edges[i].to)
+			}
+		}
+		visitState[v] = done
+	}
+	for v := 0; v < n; v++ {
+		if visitState[v] == unvisited {
+			visit(v)
+		}
+	}
+
+	for i, edge := range edges {
+		if isBack[i] {
+			back = append(back, edge)
+		} else {
+			forward = append(forward, edge)
+		}
+	}
+	return forward, back
+}
+
+// asciiAssignLayers places every node one layer below its deepest predecessor.
+// START is alone in the first layer and END, if present, alone in the last.
+func asciiAssignLayers(nodes []*asciiNode, forward []asciiEdge) {
+	indegree := make([]int, len(nodes))
+	for _, edge := range forward {
+		indegree[edge.to]++
+	}
+
+	for i, node := range nodes {
+		node.layer = 1
+		if i == 0 {
+			node.layer = 0
+		}
+	}
+
+	queue := make([]int, 0, len(nodes))
+	for i := range nodes {
+		if indegree[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, edge := range forward {
+			if edge.from != v {
+				continue
+			}
+			nodes[edge.to].layer = max(nodes[edge.to].layer, nodes[v].layer+1)
+			indegree[edge.to]--
+			if indegree[edge.to] == 0 {
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+
+	if end := nodes[len(nodes)-1]; end.order == END {
+		deepest := 0
+		for _, node := range nodes[:len(nodes)-1] {
+			deepest = max(deepest, node.layer)
+		}
+		end.layer = deepest + 1
+	}
+}
+
+// asciiInsertDummies replaces edges spanning several layers with chains of dummy
+// nodes, so that every edge connects adjacent layers.
+func asciiInsertDummies(nodes *[]*asciiNode, forward []asciiEdge) []asciiEdge {
+	var result []asciiEdge
+	for _, edge := range forward {
+		from := edge.from
+		for layer := (*nodes)[edge.from].layer + 1; layer < (*nodes)[edge.to].layer; layer++ {
+			*nodes = append(*nodes, &asciiNode{
+				width:       1,
+				dummy:       true,
+				conditional: edge.conditional,
+				order:       (*nodes)[edge.from].order,
+				layer:       layer,
+			})
+			dummy := len(*nodes) - 1
+			result = append(result, asciiEdge{from: from, to: dummy, conditional: edge.conditional})
+			from = dummy
+		}
+		result = append(result, asciiEdge{from: from, to: edge.to, conditional: edge.conditional})
+	}
+	return result
+}
+
+// asciiOrderLayers groups nodes by layer and orders each layer by the average
+// position of its predecessors to reduce edge crossings.
+func asciiOrderLayers(nodes []*asciiNode, forward []asciiEdge) [][]int {
+	depth := 0
+	for _, node := range nodes {
+		depth = max(depth, node.layer)
+	}
+	layers := make([][]int, depth+1)
+	for i, node := range nodes {
+		layers[node.layer] = append(layers[node.layer], i)
+	}
+
+	position := make([]float64, len(nodes))
+	for l, layer := range layers {
+		barycenter := make(map[int]float64)
+		for _, v := range layer {
+			sum, count := 0.0, 0
+			for _, edge := range forward {
+				if edge.to == v {
+					sum += position[edge.from]
+					count++
+				}
+			}
+			if count > 0 {
+				barycenter[v] = sum / float64(count)
+			}
+		}
+
+		sort.SliceStable(layer, func(i, j int) bool {
+			a, b := layer[i], layer[j]
+			if l > 0 && barycenter[a] != barycenter[b] {
+				return barycenter[a] < barycenter[b]
+			}
+			return nodes[a].order < nodes[b].order
+		})
+		for i, v := range layer {
+			position[v] = float64(i)
+		}
+	}
+	return layers
+}
+
+// asciiPlace assigns horizontal positions, centering every layer on the widest one
+func asciiPlace(nodes []*asciiNode, layers [][]int) {
+	widths := make([]int, len(layers))
+	widest := 0
+	for l, layer := range layers {
+		for i, v := range layer {
+			if i > 0 {
+				widths[l] += asciiBoxGap
+			}
+			widths[l] += nodes[v].width
+		}
+		widest = max(widest, widths[l])
+	}
+
+	for l, layer := range layers {
+		x := (widest - widths[l]) / 2
+		for _, v := range layer {
+			nodes[v].x = x
+			x += nodes[v].width + asciiBoxGap
+		}
+	}
+}
+
+// asciiDrawLayer draws the boxes of one layer, followed on the right by the back
+// edges leaving it.
+func asciiDrawLayer(sb *strings.Builder, nodes []*asciiNode, layer []int, back, forward []asciiEdge) {
+	height := 0
+	width := 0
+	for _, v := range layer {
+		height = max(height, len(nodes[v].lines)+2)
+		width = max(width, nodes[v].x+nodes[v].width)
+	}
+
+	grid := newASCIIGrid(height, width)
+	for _, v := range layer {
+		node := nodes[v]
+		if node.dummy {
+			for row := 0; row < height; row++ {
+				grid.set(row, node.x, asciiVertical(node.conditional))
+			}
+			continue
+		}
+
+		bottom := len(node.lines) + 1
+		grid.text(0, node.x, "+"+strings.Repeat("-", node.width-2)+"+")
+		for i, line := range node.lines {
+			pad := node.width - 4 - len([]rune(line))
+			grid.text(i+1, node.x, "| "+strings.Repeat(" ", pad/2)+line+strings.Repeat(" ", pad-pad/2)+" |")
+		}
+		border := strings.Repeat("-", node.width-2)
+		if node.conditional {
+			marker := "(?)"
+			if len(border) < len(marker) {
+				marker = "?"
+			}
+			left := (len(border) - len(marker)) / 2
+			border = border[:left] + marker + border[left+len(marker):]
+		}
+		grid.text(bottom, node.x, "+"+border+"+")
+
+		// Continue outgoing edges below boxes shorter than the layer
+		for _, edge := range forward {
+			if edge.from == v {
+				for row := bottom + 1; row < height; row++ {
+					grid.set(row, node.center(), asciiVertical(edge.conditional))
+				}
+			}
+		}
+	}
+
+	var notes []string
+	for _, edge := range back {
+		if nodes[edge.from].layer == nodes[layer[0]].layer {
+			arrow := "-->"
+			if edge.conditional {
+				arrow = "-.->"
+			}
+			notes = append(notes, fmt.Sprintf("^ %s %s %s", nodes[edge.from].order, arrow, nodes[edge.to].order))
+		}
+	}
+	for row, line := range grid.lines() {
+		if row < len(notes) {
+			line = fmt.Sprintf("%-*s  %s", width, line, notes[row])
+		}
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	for _, note := range notes[min(len(notes), height):] {
+		sb.WriteString(strings.Repeat(" ", width+2) + note + "\n")
+	}
+}
+
+// asciiDrawConnectors draws the edges from a layer to the next one. Each source with
+// targets that are not straight below it gets its own horizontal bus row.
+func asciiDrawConnectors(sb *strings.Builder, nodes []*asciiNode, forward []asciiEdge, layer int) {
+	var sources []int
+	targets := make(map[int][]asciiEdge)
+	width := 0
+	for _, edge := range forward {
+		if nodes[edge.from].layer != layer {
+			continue
+		}
+		if _, ok := targets[edge.from]; !ok {
+			sources = append(sources, edge.from)
+		}
+		targets[edge.from] = append(targets[edge.from], edge)
+		width = max(width, nodes[edge.from].center()+1, nodes[edge.to].center()+1)
+	}
+	sort.Slice(sources, func(i, j int) bool { return nodes[sources[i]].x < nodes[sources[j]].x })
+
+	busRow := make(map[int]int)
+	rows := 1
+	for _, source := range sources {
+		for _, edge := range targets[source] {
+			if nodes[edge.to].center() != nodes[source].center() {
+				busRow[source] = rows
+				rows++
+				break
+			}
+		}
+	}
+	arrowRow := rows
+	rows++
+
+	grid := newASCIIGrid(rows, width)
+	for _, source := range sources {
+		cs := nodes[source].center()
+		for _, edge := range targets[source] {
+			ct := nodes[edge.to].center()
+			vertical := asciiVertical(edge.conditional)
+
+			bus, ok := busRow[source]
+			if !ok {
+				bus = arrowRow
+			}
+			for row := 0; row < bus; row++ {
+				grid.set(row, cs, vertical)
+			}
+			if ok {
+				horizontal := '-'
+				if edge.conditional {
+					horizontal = '.'
+				}
+				for c := min(cs, ct); c <= max(cs, ct); c++ {
+					grid.set(bus, c, horizontal)
+				}
+				grid.set(bus, cs, '+')
+				grid.set(bus, ct, '+')
+				for row := bus + 1; row < arrowRow; row++ {
+					grid.set(row, ct, vertical)
+				}
+			}
+
+			if nodes[edge.to].dummy {
+				grid.set(arrowRow, ct, vertical)
+			} else {
+				grid.force(arrowRow, ct, 'v')
+			}
+		}
+	}
+
+	for _, line := range grid.lines() {
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+}
+
+// asciiVertical returns the character for a vertical segment of an edge
+func asciiVertical(conditional bool) rune {
+	if conditional {
+		return ':'
+	}
+	return '|'
+}
+
+// asciiGrid is a character canvas that joins crossing lines with '+'
+type asciiGrid [][]rune
+
+func newASCIIGrid(rows, cols int) asciiGrid {
+	grid := make(asciiGrid, rows)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", cols))
+	}
+	return grid
+}
+
+func (g asciiGrid) set(row, col int, r rune) {
+	if row < 0 || row >= len(g) || col < 0 || col >= len(g[row]) {
+		return
+	}
+	switch existing := g[row][col]; {
+	case existing == ' ' || existing == r:
+		g[row][col] = r
+	case existing == 'v' || existing == '+':
+		// Arrowheads and joints take precedence
+	default:
+		g[row][col] = '+'
+	}
+}
+
+func (g asciiGrid) force(row, col int, r rune) {
+	if row >= 0 && row < len(g) && col >= 0 && col < len(g[row]) {
+		g[row][col] = r
+	}
+}
+
+func (g asciiGrid) text(row, col int, s string) {
+	for i, r := range []rune(s) {
+		g.force(row, col+i, r)
+	}
+}
+
+func (g asciiGrid) lines() []string {
+	lines := make([]string, len(g))
+	for i, row := range g {
+		lines[i] = string(row)
+	}
+	return lines
+}
+
+// wrapASCIILabel splits name into lines of at most width runes, breaking at spaces
+// where possible
+func wrapASCIILabel(name string, width int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(name) {
+		for len([]rune(word)) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
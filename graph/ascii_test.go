@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newASCIITestGraph(nodes ...string) *StateGraph[map[string]any] {
+	g := NewStateGraph[map[string]any]()
+	for _, name := range nodes {
+		g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) { return state, nil })
+	}
+	g.SetEntryPoint(nodes[0])
+	return g
+}
+
+// lineOf returns the index of the first line of ascii containing s
+func lineOf(t *testing.T, ascii string, s string) int {
+	t.Helper()
+	for i, line := range strings.Split(ascii, "\n") {
+		if strings.Contains(line, s) {
+			return i
+		}
+	}
+	t.Fatalf("%q not found in:\n%s", s, ascii)
+	return -1
+}
+
+func TestDrawASCII_Linear(t *testing.T) {
+	g := newASCIITestGraph("fetch", "process", "save")
+	g.AddEdge("fetch", "process")
+	g.AddEdge("process", "save")
+	g.AddEdge("save", END)
+
+	ascii := NewExporter(g).DrawASCII()
+	assertGolden(t, "ascii_linear.golden", ascii)
+
+	start := lineOf(t, ascii, "| START ")
+	fetch := lineOf(t, ascii, "| fetch ")
+	process := lineOf(t, ascii, "| process ")
+	save := lineOf(t, ascii, "| save ")
+	end := lineOf(t, ascii, "| END ")
+	assert.True(t, start < fetch && fetch < process && process < save && save < end, "nodes out of order:\n%s", ascii)
+	assert.Equal(t, 5, strings.Count(ascii, "v"), "expected one arrowhead per edge:\n%s", ascii)
+}
+
+func TestDrawASCII_Diamond(t *testing.T) {
+	g := newASCIITestGraph("split", "left", "right", "join")
+	g.AddEdge("split", "left")
+	g.AddEdge("split", "right")
+	g.AddEdge("left", "join")
+	g.AddEdge("right", "join")
+	g.AddEdge("join", END)
+
+	ascii := NewExporter(g).DrawASCII()
+	assertGolden(t, "ascii_diamond.golden", ascii)
+
+	left := lineOf(t, ascii, "| left ")
+	assert.Equal(t, left, lineOf(t, ascii, "| right "), "branches should share a layer:\n%s", ascii)
+	assert.Less(t, lineOf(t, ascii, "| split "), left)
+	assert.Greater(t, lineOf(t, ascii, "| join "), left)
+}
+
+func TestDrawASCII_Cycle(t *testing.T) {
+	g := newASCIITestGraph("agent", "tools")
+	g.AddConditionalEdgeWithMapping("agent", func(ctx context.Context, state map[string]any) string { return "end" }, map[string]string{
+		"continue": "tools",
+		"end":      END,
+	})
+	g.AddEdge("tools", "agent")
+
+	ascii := NewExporter(g).DrawASCII()
+	assertGolden(t, "ascii_cycle.golden", ascii)
+
+	assert.Less(t, lineOf(t, ascii, "| agent "), lineOf(t, ascii, "| tools "))
+	assert.Contains(t, ascii, "^ tools --> agent")
+	assert.Contains(t, ascii, ":", "conditional edges should be dotted")
+}
+
+func TestDrawASCII_Markers(t *testing.T) {
+	g := newASCIITestGraph("a node with a very long descriptive name", "router")
+	g.AddEdge("a node with a very long descriptive name", "router")
+	g.AddConditionalEdge("router", func(ctx context.Context, state map[string]any) string { return END })
+
+	ascii := NewExporter(g).DrawASCII()
+	assertGolden(t, "ascii_markers.golden", ascii)
+
+	for _, line := range strings.Split(ascii, "\n") {
+		assert.LessOrEqual(t, len(line), asciiWrapWidth+5, "line not wrapped: %q", line)
+	}
+	assert.Contains(t, ascii, "| descriptive name  |")
+	assert.Contains(t, ascii, "+---(?)---+")
+}
+
+func TestWrapASCIILabel(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"short", []string{"short"}},
+		{"two words here", []string{"two words", "here"}},
+		{"averyveryverylongword", []string{"averyveryv", "erylongwor", "d"}},
+		{"", []string{""}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, wrapASCIILabel(tt.name, 10), tt.name)
+	}
+}
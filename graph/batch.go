@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// BatchOption configures Batch.
+type BatchOption func(*batchOptions)
+
+// batchOptions collects the settings applied by BatchOption values.
+type batchOptions struct {
+	concurrency int
+	failFast    bool
+	config      *Config
+}
+
+// WithBatchConcurrency limits how many inputs Batch invokes at the same time.
+// Values less than 1 use the default, GOMAXPROCS.
+func WithBatchConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithBatchFailFast stops the batch at the first failed input: running invocations
+// are cancelled through their context and inputs not started yet are skipped, with
+// their errors set to the context error. By default every input runs and all
+// errors are collected.
+func WithBatchFailFast() BatchOption {
+	return func(o *batchOptions) {
+		o.failFast = true
+	}
+}
+
+// WithBatchConfig sets the config used for every invocation of the batch.
+func WithBatchConfig(config *Config) BatchOption {
+	return func(o *batchOptions) {
+		o.config = config
+	}
+}
+
+// Batch invokes the graph once per input, running up to the configured number of
+// invocations concurrently. Outputs and errors are returned in input order; errs[i]
+// is nil when inputs[i] succeeded.
+//
+// Callbacks from WithBatchConfig see the batch as a chain run of its own, and each
+// invocation as a child run with a distinct runID whose parentRunID is the batch run.
+//
+// Example:
+//
+//	outputs, errs := runnable.Batch(ctx, inputs, graph.WithBatchConcurrency(8))
+func (r *StateRunnable[S]) Batch(ctx context.Context, inputs []S, opts ...BatchOption) ([]S, []error) {
+	options := &batchOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	concurrency := options.concurrency
+	if concurrency < 1 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+
+	outputs := make([]S, len(inputs))
+	errs := make([]error, len(inputs))
+
+	batchRunID := generateRunID()
+	var callbacks []CallbackHandler
+	if options.config != nil {
+		callbacks = options.config.Callbacks
+		for _, cb := range callbacks {
+			cb.OnChainStart(ctx, map[string]any{"name": "batch", "type": "chain"}, map[string]any{"inputs": len(inputs)}, batchRunID, nil, options.config.Tags, options.config.Metadata)
+		}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	itemCtx := withParentRunID(runCtx, batchRunID)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := range inputs {
+		select {
+		case sem <- struct{}{}:
+		case <-runCtx.Done():
+		}
+		if err := runCtx.Err(); err != nil {
+			for j := i; j < len(inputs); j++ {
+				errs[j] = err
+			}
+			break
+		}
+
+		idx := i
+		SafeGo(&wg, func() {
+			defer func() { <-sem }()
+			outputs[idx], errs[idx] = r.InvokeWithConfig(itemCtx, inputs[idx], options.config)
+			if errs[idx] != nil && options.failFast {
+				cancel()
+			}
+		}, func(panicVal any) {
+			errs[idx] = fmt.Errorf("panic in batch item %d: %v", idx, panicVal)
+			if options.failFast {
+				cancel()
+			}
+		})
+	}
+	wg.Wait()
+
+	var firstErr error
+	for _, err := range errs {
+		if err != nil {
+			firstErr = err
+			break
+		}
+	}
+	for _, cb := range callbacks {
+		if firstErr != nil {
+			cb.OnChainError(ctx, firstErr, batchRunID)
+			continue
+		}
+		cb.OnChainEnd(ctx, map[string]any{"outputs": len(outputs)}, batchRunID)
+	}
+
+	return outputs, errs
+}
@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBatchItem = errors.New("bad input")
+
+type batchState struct {
+	N int
+}
+
+func newBatchRunnable(t testing.TB, node func(ctx context.Context, s batchState) (batchState, error)) *StateRunnable[batchState] {
+	g := NewStateGraph[batchState]()
+	g.AddNode("double", "double", node)
+	g.SetEntryPoint("double")
+	g.AddEdge("double", END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+// chainRecorder records the chain runs reported to it
+type chainRecorder struct {
+	NoOpCallbackHandler
+	mu      sync.Mutex
+	parents map[string]*string
+}
+
+func (c *chainRecorder) OnChainStart(ctx context.Context, serialized map[string]any, inputs map[string]any, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parents[runID] = parentRunID
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+
+	inputs := make([]batchState, 20)
+	for i := range inputs {
+		inputs[i] = batchState{N: i}
+	}
+
+	t.Run("PreservesOrder", func(t *testing.T) {
+		runnable := newBatchRunnable(t, func(ctx context.Context, s batchState) (batchState, error) {
+			// Finish later inputs first
+			time.Sleep(time.Duration(20-s.N) * time.Millisecond)
+			return batchState{N: s.N * 2}, nil
+		})
+
+		outputs, errs := runnable.Batch(ctx, inputs, WithBatchConcurrency(20))
+		require.Len(t, outputs, len(inputs))
+		require.Len(t, errs, len(inputs))
+		for i := range inputs {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, i*2, outputs[i].N)
+		}
+	})
+
+	t.Run("ConcurrencyLimit", func(t *testing.T) {
+		var inFlight, maxInFlight atomic.Int32
+		runnable := newBatchRunnable(t, func(ctx context.Context, s batchState) (batchState, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				seen := maxInFlight.Load()
+				if n <= seen || maxInFlight.CompareAndSwap(seen, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return s, nil
+		})
+
+		_, errs := runnable.Batch(ctx, inputs, WithBatchConcurrency(3))
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+		assert.Equal(t, int32(3), maxInFlight.Load())
+	})
+
+	t.Run("CollectsAllErrors", func(t *testing.T) {
+		var calls atomic.Int32
+		runnable := newBatchRunnable(t, func(ctx context.Context, s batchState) (batchState, error) {
+			calls.Add(1)
+			if s.N%5 == 0 {
+				return s, errBatchItem
+			}
+			return batchState{N: s.N * 2}, nil
+		})
+
+		outputs, errs := runnable.Batch(ctx, inputs, WithBatchConcurrency(4))
+		assert.Equal(t, int32(len(inputs)), calls.Load())
+		for i := range inputs {
+			if i%5 == 0 {
+				assert.ErrorIs(t, errs[i], errBatchItem)
+				continue
+			}
+			assert.NoError(t, errs[i])
+			assert.Equal(t, i*2, outputs[i].N)
+		}
+	})
+
+	t.Run("FailFast", func(t *testing.T) {
+		var calls atomic.Int32
+		runnable := newBatchRunnable(t, func(ctx context.Context, s batchState) (batchState, error) {
+			calls.Add(1)
+			if s.N == 0 {
+				return s, errBatchItem
+			}
+			return s, nil
+		})
+
+		_, errs := runnable.Batch(ctx, inputs, WithBatchConcurrency(1), WithBatchFailFast())
+		assert.Equal(t, int32(1), calls.Load())
+		assert.ErrorIs(t, errs[0], errBatchItem)
+		for _, err := range errs[1:] {
+			assert.ErrorIs(t, err, context.Canceled)
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		runnable := newBatchRunnable(t, func(ctx context.Context, s batchState) (batchState, error) {
+			return s, nil
+		})
+		runnable.graph.stateMerger = func(ctx context.Context, current batchState, newStates []batchState) (batchState, error) {
+			panic("merge exploded")
+		}
+
+		_, errs := runnable.Batch(ctx, inputs[:2])
+		assert.ErrorContains(t, errs[0], "panic in batch item 0: merge exploded")
+		assert.ErrorContains(t, errs[1], "panic in batch item 1: merge exploded")
+	})
+
+	t.Run("RunIDs", func(t *testing.T) {
+		runnable := newBatchRunnable(t, func(ctx context.Context, s batchState) (batchState, error) {
+			return s, nil
+		})
+		recorder := &chainRecorder{parents: map[string]*string{}}
+
+		_, errs := runnable.Batch(ctx, inputs[:5], WithBatchConfig(&Config{Callbacks: []CallbackHandler{recorder}}))
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+
+		// One run for the batch and one per input
+		require.Len(t, recorder.parents, 6)
+		var batchRunID string
+		for runID, parent := range recorder.parents {
+			if parent == nil {
+				batchRunID = runID
+			}
+		}
+		require.NotEmpty(t, batchRunID)
+		for runID, parent := range recorder.parents {
+			if runID == batchRunID {
+				continue
+			}
+			require.NotNil(t, parent)
+			assert.Equal(t, batchRunID, *parent)
+		}
+	})
+}
+
+func BenchmarkBatch(b *testing.B) {
+	runnable := newBatchRunnable(b, func(ctx context.Context, s batchState) (batchState, error) {
+		// Stand-in for an I/O-bound node such as an LLM call
+		time.Sleep(time.Millisecond)
+		return batchState{N: s.N * 2}, nil
+	})
+	ctx := context.Background()
+
+	inputs := make([]batchState, 64)
+	for i := range inputs {
+		inputs[i] = batchState{N: i}
+	}
+
+	b.Run("Loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, input := range inputs {
+				if _, err := runnable.Invoke(ctx, input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	for _, workers := range []int{1, 8} {
+		b.Run(fmt.Sprintf("Batch%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, errs := runnable.Batch(ctx, inputs, WithBatchConcurrency(workers)); errs[0] != nil {
+					b.Fatal(errs[0])
+				}
+			}
+		})
+	}
+}
@@ -96,12 +96,24 @@ func (pl *ProgressListener) OnNodeEvent(_ context.Context, event NodeEvent, node
 		emoji := "❌"
 		message = fmt.Sprintf("%s %s failed: %v", emoji, nodeName, err)
 
+	case NodeEventRetry:
+		emoji := "🔁"
+		message = fmt.Sprintf("%s Retrying %s after error: %v", emoji, nodeName, err)
+
+	case NodeEventCacheHit:
+		emoji := "⚡"
+		message = fmt.Sprintf("%s %s served from cache", emoji, nodeName)
+
 	case NodeEventProgress:
 		if hasCustom {
 			message = fmt.Sprintf("%s %s (in progress)", pl.prefix, customStep)
 		} else {
 			message = fmt.Sprintf("%s %s (in progress)", pl.prefix, nodeName)
 		}
+
+	case EventToken, EventCustom:
+		// Streamed chunks and custom events are not progress steps
+		return
 	}
 
 	if pl.showTiming {
@@ -164,7 +176,7 @@ func (ll *LoggingListener) WithState(enabled bool) *LoggingListener {
 }
 
 // OnNodeEvent implements the NodeListener[map[string]any] interface
-func (ll *LoggingListener) OnNodeEvent(_ context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {
+func (ll *LoggingListener) OnNodeEvent(ctx context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {
 	var level LogLevel
 	var prefix string
 
@@ -181,6 +193,18 @@ func (ll *LoggingListener) OnNodeEvent(_ context.Context, event NodeEvent, nodeN
 	case NodeEventError:
 		level = LogLevelError
 		prefix = "ERROR"
+	case NodeEventRetry:
+		level = LogLevelWarn
+		prefix = "RETRY"
+	case NodeEventCacheHit:
+		level = LogLevelInfo
+		prefix = "CACHE_HIT"
+	case EventToken:
+		level = LogLevelDebug
+		prefix = "TOKEN"
+	case EventCustom:
+		level = LogLevelInfo
+		prefix = "CUSTOM"
 	}
 
 	if level < ll.logLevel {
@@ -189,6 +213,13 @@ func (ll *LoggingListener) OnNodeEvent(_ context.Context, event NodeEvent, nodeN
 
 	message := fmt.Sprintf("%s %s", prefix, nodeName)
 
+	if chunk, ok := TokenFromContext(ctx); ok && event == EventToken {
+		message = fmt.Sprintf("%s %q", message, chunk)
+	}
+	if custom, ok := CustomEventFromContext(ctx); ok && event == EventCustom {
+		message = fmt.Sprintf("%s %s: %v", message, custom.Name, custom.Payload)
+	}
+
 	if err != nil {
 		message = fmt.Sprintf("%s: %v", message, err)
 	}
@@ -206,6 +237,8 @@ type MetricsListener struct {
 	nodeExecutions  map[string]int
 	nodeDurations   map[string][]time.Duration
 	nodeErrors      map[string]int
+	nodeRetries     map[string]int
+	nodeCacheHits   map[string]int
 	totalExecutions int
 	startTimes      map[string]time.Time
 }
@@ -216,6 +249,8 @@ func NewMetricsListener() *MetricsListener {
 		nodeExecutions: make(map[string]int),
 		nodeDurations:  make(map[string][]time.Duration),
 		nodeErrors:     make(map[string]int),
+		nodeRetries:    make(map[string]int),
+		nodeCacheHits:  make(map[string]int),
 		startTimes:     make(map[string]time.Time),
 	}
 }
@@ -245,6 +280,10 @@ func (ml *MetricsListener) OnNodeEvent(_ context.Context, event NodeEvent, nodeN
 			ml.nodeDurations[nodeName] = append(ml.nodeDurations[nodeName], duration)
 			delete(ml.startTimes, nodeName)
 		}
+	case NodeEventRetry:
+		ml.nodeRetries[nodeName]++
+	case NodeEventCacheHit:
+		ml.nodeCacheHits[nodeName]++
 	case NodeEventProgress:
 		// Progress events are tracked but don't affect timing metrics
 	}
@@ -270,6 +309,26 @@ func (ml *MetricsListener) GetNodeErrors() map[string]int {
 	return result
 }
 
+// GetNodeRetries returns the number of retries for each node
+func (ml *MetricsListener) GetNodeRetries() map[string]int {
+	ml.mutex.RLock()
+	defer ml.mutex.RUnlock()
+
+	result := make(map[string]int)
+	maps.Copy(result, ml.nodeRetries)
+	return result
+}
+
+// GetNodeCacheHits returns the number of cache hits for each node
+func (ml *MetricsListener) GetNodeCacheHits() map[string]int {
+	ml.mutex.RLock()
+	defer ml.mutex.RUnlock()
+
+	result := make(map[string]int)
+	maps.Copy(result, ml.nodeCacheHits)
+	return result
+}
+
 // GetNodeAverageDuration returns the average duration for each node
 func (ml *MetricsListener) GetNodeAverageDuration() map[string]time.Duration {
 	ml.mutex.RLock()
@@ -333,6 +392,14 @@ func (ml *MetricsListener) PrintSummary(writer io.Writer) {
 			fmt.Fprintf(writer, "  %s: %d errors\n", nodeName, count)
 		}
 	}
+
+	if len(ml.nodeRetries) > 0 {
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "Retries:")
+		for nodeName, count := range ml.nodeRetries {
+			fmt.Fprintf(writer, "  %s: %d retries\n", nodeName, count)
+		}
+	}
 }
 
 // Reset clears all collected metrics
@@ -343,6 +410,8 @@ func (ml *MetricsListener) Reset() {
 	ml.nodeExecutions = make(map[string]int)
 	ml.nodeDurations = make(map[string][]time.Duration)
 	ml.nodeErrors = make(map[string]int)
+	ml.nodeRetries = make(map[string]int)
+	ml.nodeCacheHits = make(map[string]int)
 	ml.startTimes = make(map[string]time.Time)
 	ml.totalExecutions = 0
 }
@@ -412,12 +481,22 @@ func (cl *ChatListener) OnNodeEvent(_ context.Context, event NodeEvent, nodeName
 	case NodeEventError:
 		message = fmt.Sprintf("❌ Error in %s: %v", nodeName, err)
 
+	case NodeEventRetry:
+		message = fmt.Sprintf("🔁 Retrying %s: %v", nodeName, err)
+
+	case NodeEventCacheHit:
+		message = fmt.Sprintf("⚡ %s answered from cache", nodeName)
+
 	case NodeEventProgress:
 		if hasCustom {
 			message = fmt.Sprintf("⏳ %s...", customMessage)
 		} else {
 			message = fmt.Sprintf("⏳ %s in progress...", nodeName)
 		}
+
+	case EventToken, EventCustom:
+		// Streamed chunks and custom events are not status messages
+		return
 	}
 
 	if cl.showTime {
@@ -0,0 +1,160 @@
+package graph
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Cache stores node outputs so that deterministic nodes can skip re-execution.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the cached value for key and whether it was found and not expired
+	Get(ctx context.Context, key string) (any, bool)
+
+	// Set stores value under key. A ttl of zero or less means the entry never expires.
+	Set(ctx context.Context, key string, value any, ttl time.Duration)
+}
+
+// nodeCachePolicy describes how a node's output is cached
+type nodeCachePolicy struct {
+	keyFunc func(state any) string
+	ttl     time.Duration
+}
+
+// cacheEntry is a value stored in MemoryCache
+type cacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+// MemoryCache is the default in-memory Cache implementation
+type MemoryCache struct {
+	entries map[string]cacheEntry
+	mutex   sync.RWMutex
+}
+
+// NewMemoryCache creates a new in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Get implements the Cache interface
+func (c *MemoryCache) Get(_ context.Context, key string) (any, bool) {
+	c.mutex.RLock()
+	entry, ok := c.entries[key]
+	c.mutex.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.mutex.Lock()
+		delete(c.entries, key)
+		c.mutex.Unlock()
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// Set implements the Cache interface
+func (c *MemoryCache) Set(_ context.Context, key string, value any, ttl time.Duration) {
+	entry := cacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[key] = entry
+}
+
+// Len returns the number of entries in the cache, including expired entries not yet evicted
+func (c *MemoryCache) Len() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return len(c.entries)
+}
+
+// Clear removes all entries from the cache
+func (c *MemoryCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+// SetNodeCachePolicy enables result caching for the named node.
+// keyFunc derives the cache key from the input state; nodes whose input maps to
+// the same key within ttl are not executed again and their cached output is merged
+// into the state instead. A ttl of zero or less caches results indefinitely.
+// If no cache was set with SetCache, an in-memory cache is used.
+//
+// Example:
+//
+//	g.SetNodeCachePolicy("embed", func(state any) string {
+//	    return state.(map[string]any)["document_id"].(string)
+//	}, 10*time.Minute)
+func (g *StateGraph[S]) SetNodeCachePolicy(nodeName string, keyFunc func(state any) string, ttl time.Duration) {
+	if g.cache == nil {
+		g.cache = NewMemoryCache()
+	}
+	g.nodeCachePolicies[nodeName] = &nodeCachePolicy{
+		keyFunc: keyFunc,
+		ttl:     ttl,
+	}
+}
+
+// SetCache sets the cache backend used for node result caching.
+func (g *StateGraph[S]) SetCache(cache Cache) {
+	g.cache = cache
+}
+
+// GetCache returns the cache backend used for node result caching.
+func (g *StateGraph[S]) GetCache() Cache {
+	return g.cache
+}
+
+// executeNodeCached serves the node result from the cache when possible,
+// otherwise it executes the node and caches a successful result.
+func (r *StateRunnable[S]) executeNodeCached(ctx context.Context, node TypedNode[S], state S, config *Config) (S, error) {
+	policy, ok := r.graph.nodeCachePolicies[node.Name]
+	if !ok || r.graph.cache == nil || policy.keyFunc == nil {
+		return r.executeNodeWithRetry(ctx, node, state, config)
+	}
+
+	key := node.Name + ":" + policy.keyFunc(state)
+
+	if cached, found := r.graph.cache.Get(ctx, key); found {
+		if res, ok := cached.(S); ok {
+			res = cloneCachedValue(res)
+			if r.nodeNotifier != nil {
+				r.nodeNotifier(ctx, NodeEventCacheHit, node.Name, res, nil)
+			}
+			return res, nil
+		}
+	}
+
+	res, err := r.executeNodeWithRetry(ctx, node, state, config)
+	if err != nil {
+		return res, err
+	}
+
+	r.graph.cache.Set(ctx, key, cloneCachedValue(res), policy.ttl)
+	return res, nil
+}
+
+// cloneCachedValue makes a shallow copy of map states so that later in-place
+// mutations of the graph state don't leak into the cache.
+func cloneCachedValue[S any](value S) S {
+	if m, ok := any(value).(map[string]any); ok {
+		if cloned, ok := any(maps.Clone(m)).(S); ok {
+			return cloned
+		}
+	}
+	return value
+}
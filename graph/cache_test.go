@@ -0,0 +1,156 @@
+package graph_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/graph"
+)
+
+func TestMemoryCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cache := graph.NewMemoryCache()
+
+	cache.Set(ctx, "forever", 1, 0)
+	cache.Set(ctx, "short", 2, 5*time.Millisecond)
+
+	if v, ok := cache.Get(ctx, "forever"); !ok || v != 1 {
+		t.Errorf("Expected cached value 1, got %v (found=%v)", v, ok)
+	}
+	if _, ok := cache.Get(ctx, "missing"); ok {
+		t.Error("Expected miss for unknown key")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if _, ok := cache.Get(ctx, "short"); ok {
+		t.Error("Expected expired entry to miss")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Expected expired entry to be evicted, got %d entries", cache.Len())
+	}
+
+	cache.Clear()
+	if cache.Len() != 0 {
+		t.Errorf("Expected empty cache after Clear, got %d entries", cache.Len())
+	}
+}
+
+func TestNodeCache(t *testing.T) {
+	t.Parallel()
+
+	newGraph := func(calls *int32, ttl time.Duration) *graph.ListenableStateGraph[map[string]any] {
+		g := graph.NewListenableStateGraph[map[string]any]()
+		g.AddNode("embed", "Embed documents", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			n := atomic.AddInt32(calls, 1)
+			return map[string]any{
+				"doc":       state["doc"],
+				"embedding": fmt.Sprintf("vec-%v-%d", state["doc"], n),
+			}, nil
+		})
+		g.AddEdge("embed", graph.END)
+		g.SetEntryPoint("embed")
+		g.SetNodeCachePolicy("embed", func(state any) string {
+			return fmt.Sprint(state.(map[string]any)["doc"])
+		}, ttl)
+		return g
+	}
+
+	t.Run("HitAcrossInvokes", func(t *testing.T) {
+		var calls int32
+		g := newGraph(&calls, time.Minute)
+
+		metrics := graph.NewMetricsListener()
+		g.AddGlobalListener(metrics)
+
+		runnable, err := g.CompileListenable()
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+
+		first, err := runnable.Invoke(context.Background(), map[string]any{"doc": "a"})
+		if err != nil {
+			t.Fatalf("First invoke failed: %v", err)
+		}
+		second, err := runnable.Invoke(context.Background(), map[string]any{"doc": "a"})
+		if err != nil {
+			t.Fatalf("Second invoke failed: %v", err)
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("Expected node to run once, got %d", got)
+		}
+		if first["embedding"] != second["embedding"] {
+			t.Errorf("Expected cached output %v, got %v", first["embedding"], second["embedding"])
+		}
+		if got := metrics.GetNodeCacheHits()["embed"]; got != 1 {
+			t.Errorf("Expected 1 cache hit event, got %d", got)
+		}
+	})
+
+	t.Run("MissOnDifferentKey", func(t *testing.T) {
+		var calls int32
+		g := newGraph(&calls, time.Minute)
+
+		runnable, err := g.Compile()
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+
+		for _, doc := range []string{"a", "b", "a"} {
+			if _, err := runnable.Invoke(context.Background(), map[string]any{"doc": doc}); err != nil {
+				t.Fatalf("Invoke failed: %v", err)
+			}
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 2 {
+			t.Errorf("Expected node to run twice, got %d", got)
+		}
+	})
+
+	t.Run("ExpiresAfterTTL", func(t *testing.T) {
+		var calls int32
+		g := newGraph(&calls, 5*time.Millisecond)
+
+		runnable, err := g.Compile()
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+
+		if _, err := runnable.Invoke(context.Background(), map[string]any{"doc": "a"}); err != nil {
+			t.Fatalf("Invoke failed: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if _, err := runnable.Invoke(context.Background(), map[string]any{"doc": "a"}); err != nil {
+			t.Fatalf("Invoke failed: %v", err)
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 2 {
+			t.Errorf("Expected node to run again after TTL, got %d calls", got)
+		}
+	})
+
+	t.Run("CustomBackend", func(t *testing.T) {
+		var calls int32
+		g := newGraph(&calls, 0)
+		cache := graph.NewMemoryCache()
+		g.SetCache(cache)
+
+		runnable, err := g.Compile()
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+
+		if _, err := runnable.Invoke(context.Background(), map[string]any{"doc": "a"}); err != nil {
+			t.Fatalf("Invoke failed: %v", err)
+		}
+		if cache.Len() != 1 {
+			t.Errorf("Expected result stored in custom cache, got %d entries", cache.Len())
+		}
+	})
+}
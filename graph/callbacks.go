@@ -36,10 +36,48 @@ type GraphCallbackHandler interface {
 	OnGraphStep(ctx context.Context, stepNode string, state any)
 }
 
+// StepStartCallbackHandler extends CallbackHandler with the start of graph steps
+type StepStartCallbackHandler interface {
+	CallbackHandler
+	// OnGraphStepStart is called before the nodes of a step execute, with a copy of
+	// the state they receive
+	OnGraphStepStart(ctx context.Context, nodes []string, state any)
+}
+
+// RetryCallbackHandler extends CallbackHandler with node retry events
+type RetryCallbackHandler interface {
+	CallbackHandler
+	// OnNodeRetry is called before a failed node is retried.
+	// attempt is the 1-based number of the attempt that failed.
+	OnNodeRetry(ctx context.Context, nodeName string, attempt int, err error)
+}
+
+// NodeWriteCallbackHandler extends CallbackHandler with the results of the nodes
+// of parallel steps
+type NodeWriteCallbackHandler interface {
+	CallbackHandler
+	// OnNodeWrite is called as soon as a node of a step running several nodes
+	// returns its result, before the results of the step are merged. Nodes whose
+	// saved result is reused on resume are reported again.
+	OnNodeWrite(ctx context.Context, nodeName string, result any)
+}
+
+// TokenCallbackHandler extends CallbackHandler with the chunks LLM calls stream
+type TokenCallbackHandler interface {
+	CallbackHandler
+	// OnToken is called for each chunk of text a node streams with StreamingFunc
+	// or EmitToken
+	OnToken(ctx context.Context, nodeName string, chunk string)
+}
+
 // Config represents configuration for graph invocation
 // This matches Python's config dict pattern
 type Config struct {
-	// Callbacks to be invoked during execution
+	// Callbacks to be invoked during execution: OnChainStart with the initial state
+	// and the run's RunID, OnToolStart and OnToolEnd with the result of each node,
+	// OnGraphStep for GraphCallbackHandler implementations after each step is
+	// merged, then OnChainEnd with the final state, or OnChainError when the run
+	// fails
 	Callbacks []CallbackHandler `json:"callbacks"`
 
 	// Metadata to attach to the execution
@@ -51,16 +89,23 @@ type Config struct {
 	// Configurable parameters for the execution
 	Configurable map[string]any `json:"configurable"`
 
-	// RunName for this execution
+	// RunName for this execution, reported as the name of the graph's chain
+	// callbacks and as "run_name" in its node callbacks
 	RunName string `json:"run_name"`
 
+	// RunID identifies the execution in callbacks. It is generated when empty, and
+	// the config returned by ConfigFromContext during the execution always has it set.
+	RunID string `json:"run_id"`
+
 	// Timeout for the execution
 	Timeout *time.Duration `json:"timeout"`
 
-	// InterruptBefore nodes to stop before execution
+	// InterruptBefore nodes to stop before execution.
+	// When non-nil, it replaces the breakpoints set at compile time; an empty slice disables them.
 	InterruptBefore []string `json:"interrupt_before"`
 
-	// InterruptAfter nodes to stop after execution
+	// InterruptAfter nodes to stop after execution.
+	// When non-nil, it replaces the breakpoints set at compile time; an empty slice disables them.
 	InterruptAfter []string `json:"interrupt_after"`
 
 	// ResumeFrom nodes to start execution from (bypassing entry point)
@@ -68,6 +113,15 @@ type Config struct {
 
 	// ResumeValue provides the value to return from an Interrupt() call when resuming
 	ResumeValue any `json:"resume_value"`
+
+	// Trace, when set, records the nodes that run, see ExecutionTrace
+	Trace *ExecutionTrace `json:"-"`
+
+	// resumeNodes are the nodes that raised the interrupt being resumed, which
+	// ResumeValue is for, and resumeSuccessors the nodes scheduled by the other
+	// nodes of the interrupted step, which run after the resumed step
+	resumeNodes      []string
+	resumeSuccessors []string
 }
 
 // NoOpCallbackHandler provides a no-op implementation of CallbackHandler
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cancelAfterStep cancels the run once the given node's step has completed
+type cancelAfterStep struct {
+	NoOpCallbackHandler
+	node   string
+	cancel context.CancelFunc
+}
+
+func (c *cancelAfterStep) OnGraphStep(ctx context.Context, stepNode string, state any) {
+	if stepNode == c.node {
+		c.cancel()
+	}
+}
+
+func TestCancellationBetweenSteps(t *testing.T) {
+	var mutex sync.Mutex
+	var ran []string
+
+	checkpoints := NewMemoryCheckpointStore()
+	g := NewCheckpointableStateGraphWithConfig[int](CheckpointConfig{Store: checkpoints, AutoSave: true})
+	for i := 1; i <= 5; i++ {
+		name := fmt.Sprintf("n%d", i)
+		g.AddNode(name, name, func(ctx context.Context, state int) (int, error) {
+			mutex.Lock()
+			ran = append(ran, name)
+			mutex.Unlock()
+			return state + 1, nil
+		})
+		if i == 1 {
+			g.SetEntryPoint(name)
+		} else {
+			g.AddEdge(fmt.Sprintf("n%d", i-1), name)
+		}
+	}
+	g.AddEdge("n5", END)
+
+	runnable, err := g.CompileCheckpointable()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := WithThreadID("cancelled")
+	config.Callbacks = []CallbackHandler{&cancelAfterStep{node: "n2", cancel: cancel}}
+
+	res, err := runnable.InvokeWithConfig(ctx, 0, config)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "run cancelled before node n3")
+	assert.Equal(t, 2, res, "the state of the last completed step is returned")
+	assert.Equal(t, []string{"n1", "n2"}, ran, "the next node never runs")
+
+	saved, err := checkpoints.ListByThread(context.Background(), "cancelled")
+	require.NoError(t, err)
+	require.NotEmpty(t, saved)
+	latest := saved[len(saved)-1]
+	assert.Equal(t, "n2", latest.NodeName)
+	assert.Equal(t, 2, latest.State)
+}
+
+func TestCancellationDuringStep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var merged bool
+	g := NewStateGraph[[]string]()
+	g.SetStateMerger(func(ctx context.Context, current []string, updates [][]string) ([]string, error) {
+		merged = true
+		return slices.Concat(append([][]string{current}, updates...)...), nil
+	})
+	g.AddNode("draft", "draft", func(ctx context.Context, state []string) ([]string, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+	g.AddNode("recover", "recover", func(ctx context.Context, state []string) ([]string, error) {
+		t.Error("the error edge was followed after the run was cancelled")
+		return nil, nil
+	})
+	g.SetEntryPoint("draft")
+	g.AddEdge("draft", END)
+	g.AddErrorEdge("draft", "recover")
+	g.AddEdge("recover", END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	res, err := runnable.Invoke(ctx, []string{"input"})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "run cancelled before merging node draft")
+	assert.Equal(t, []string{"input"}, res)
+	assert.False(t, merged, "the cancelled step is not merged")
+}
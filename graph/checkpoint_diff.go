@@ -0,0 +1,247 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of a StateChange
+type ChangeKind string
+
+const (
+	// ChangeAdded is a value present only in the later checkpoint
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved is a value present only in the earlier checkpoint
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeChanged is a value present in both checkpoints with different contents
+	ChangeChanged ChangeKind = "changed"
+)
+
+// StateChange is a difference between the states of two checkpoints
+type StateChange struct {
+	// Path locates the value in the state, such as "user.name" or "messages[2]".
+	// It is empty when the states themselves differ and aren't maps or slices.
+	Path string
+
+	Kind   ChangeKind
+	Before any // Value in the earlier checkpoint, nil when added
+	After  any // Value in the later checkpoint, nil when removed
+}
+
+// CheckpointDiff is the difference between the states of two checkpoints,
+// returned by DiffCheckpoints
+type CheckpointDiff struct {
+	From *Checkpoint
+	To   *Checkpoint
+
+	// Changes are ordered by map key and slice index. Nested maps and slices are
+	// compared element by element, so a change deep in the state is reported at
+	// its own path.
+	Changes []StateChange
+}
+
+// Empty reports whether the states are equal
+func (d *CheckpointDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// Added returns the changes of values present only in the later checkpoint
+func (d *CheckpointDiff) Added() []StateChange {
+	return d.ofKind(ChangeAdded)
+}
+
+// Removed returns the changes of values present only in the earlier checkpoint
+func (d *CheckpointDiff) Removed() []StateChange {
+	return d.ofKind(ChangeRemoved)
+}
+
+// Changed returns the changes of values present in both checkpoints
+func (d *CheckpointDiff) Changed() []StateChange {
+	return d.ofKind(ChangeChanged)
+}
+
+func (d *CheckpointDiff) ofKind(kind ChangeKind) []StateChange {
+	var changes []StateChange
+	for _, change := range d.Changes {
+		if change.Kind == kind {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// String renders the diff a line per change, "+" for added, "-" for removed and
+// "~" for changed values, after a header naming the checkpoints:
+//
+//	cp-1 (version 1, node step1) -> cp-2 (version 2, node step2)
+//	+ step2: "done"
+//	~ count: 1 -> 2
+func (d *CheckpointDiff) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s -> %s\n", describeCheckpoint(d.From), describeCheckpoint(d.To))
+	if d.Empty() {
+		sb.WriteString("  no state changes\n")
+		return sb.String()
+	}
+
+	for _, change := range d.Changes {
+		path := change.Path
+		if path == "" {
+			path = "(state)"
+		}
+		switch change.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(&sb, "+ %s: %s\n", path, formatDiffValue(change.After))
+		case ChangeRemoved:
+			fmt.Fprintf(&sb, "- %s: %s\n", path, formatDiffValue(change.Before))
+		default:
+			fmt.Fprintf(&sb, "~ %s: %s -> %s\n", path, formatDiffValue(change.Before), formatDiffValue(change.After))
+		}
+	}
+	return sb.String()
+}
+
+func describeCheckpoint(cp *Checkpoint) string {
+	if cp == nil {
+		return "(none)"
+	}
+	return fmt.Sprintf("%s (version %d, node %s)", cp.ID, cp.Version, cp.NodeName)
+}
+
+func formatDiffValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// DiffCheckpoints compares the state of checkpoint a with that of a later
+// checkpoint b, usually of the same thread. States are compared in their JSON
+// form, so a state that a store decoded with float64 numbers or as a generic map
+// equals the state that was saved.
+//
+// Example:
+//
+//	diff := graph.DiffCheckpoints(before, after)
+//	for _, change := range diff.Changed() {
+//	    fmt.Printf("%s: %v -> %v\n", change.Path, change.Before, change.After)
+//	}
+func DiffCheckpoints(a, b *Checkpoint) *CheckpointDiff {
+	diff := &CheckpointDiff{From: a, To: b}
+
+	var before, after any
+	if a != nil {
+		before = normalizeDiffValue(a.State)
+	}
+	if b != nil {
+		after = normalizeDiffValue(b.State)
+	}
+	diffValues(&diff.Changes, "", before, after)
+	return diff
+}
+
+// DiffStoredCheckpoints loads two checkpoints from s and compares their states.
+//
+// Example:
+//
+//	diff, err := graph.DiffStoredCheckpoints(ctx, store, fromID, toID)
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Print(diff)
+func DiffStoredCheckpoints(ctx context.Context, s CheckpointStore, fromID, toID string) (*CheckpointDiff, error) {
+	from, err := s.Load(ctx, fromID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint %s: %w", fromID, err)
+	}
+	to, err := s.Load(ctx, toID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint %s: %w", toID, err)
+	}
+	return DiffCheckpoints(from, to), nil
+}
+
+// normalizeDiffValue converts v to the maps, slices and scalars of its JSON form.
+// Values that can't be encoded are compared as they are.
+func normalizeDiffValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return v
+	}
+	return normalized
+}
+
+func diffValues(changes *[]StateChange, path string, before, after any) {
+	switch b := before.(type) {
+	case map[string]any:
+		if a, ok := after.(map[string]any); ok {
+			diffMaps(changes, path, b, a)
+			return
+		}
+	case []any:
+		if a, ok := after.([]any); ok {
+			diffSlices(changes, path, b, a)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, StateChange{Path: path, Kind: ChangeChanged, Before: before, After: after})
+	}
+}
+
+func diffMaps(changes *[]StateChange, path string, before, after map[string]any) {
+	keys := slices.Collect(maps.Keys(before))
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		keyPath := joinDiffPath(path, key)
+		b, inBefore := before[key]
+		a, inAfter := after[key]
+		switch {
+		case !inAfter:
+			*changes = append(*changes, StateChange{Path: keyPath, Kind: ChangeRemoved, Before: b})
+		case !inBefore:
+			*changes = append(*changes, StateChange{Path: keyPath, Kind: ChangeAdded, After: a})
+		default:
+			diffValues(changes, keyPath, b, a)
+		}
+	}
+}
+
+func diffSlices(changes *[]StateChange, path string, before, after []any) {
+	for i := range max(len(before), len(after)) {
+		indexPath := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(after):
+			*changes = append(*changes, StateChange{Path: indexPath, Kind: ChangeRemoved, Before: before[i]})
+		case i >= len(before):
+			*changes = append(*changes, StateChange{Path: indexPath, Kind: ChangeAdded, After: after[i]})
+		default:
+			diffValues(changes, indexPath, before[i], after[i])
+		}
+	}
+}
+
+func joinDiffPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/store/file"
+)
+
+func TestDiffCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	before := &Checkpoint{
+		ID:       "cp-1",
+		NodeName: "step1",
+		Version:  1,
+		State: map[string]any{
+			"count":    1,
+			"obsolete": true,
+			"user":     map[string]any{"name": "ada", "roles": []any{"admin", "dev"}},
+			"messages": []any{"hi"},
+		},
+	}
+	after := &Checkpoint{
+		ID:       "cp-2",
+		NodeName: "step2",
+		Version:  2,
+		State: map[string]any{
+			"count":    2,
+			"step2":    "done",
+			"user":     map[string]any{"name": "ada", "roles": []any{"admin", "ops"}},
+			"messages": []any{"hi", map[string]any{"role": "ai"}},
+		},
+	}
+
+	diff := DiffCheckpoints(before, after)
+	want := []StateChange{
+		{Path: "count", Kind: ChangeChanged, Before: float64(1), After: float64(2)},
+		{Path: "messages[1]", Kind: ChangeAdded, After: map[string]any{"role": "ai"}},
+		{Path: "obsolete", Kind: ChangeRemoved, Before: true},
+		{Path: "step2", Kind: ChangeAdded, After: "done"},
+		{Path: "user.roles[1]", Kind: ChangeChanged, Before: "dev", After: "ops"},
+	}
+	if !reflect.DeepEqual(diff.Changes, want) {
+		t.Fatalf("Expected changes %v, got %v", want, diff.Changes)
+	}
+	if len(diff.Added()) != 2 || len(diff.Removed()) != 1 || len(diff.Changed()) != 2 {
+		t.Errorf("Unexpected grouping: added %v, removed %v, changed %v", diff.Added(), diff.Removed(), diff.Changed())
+	}
+
+	wantString := `cp-1 (version 1, node step1) -> cp-2 (version 2, node step2)
+~ count: 1 -> 2
++ messages[1]: {"role":"ai"}
+- obsolete: true
++ step2: "done"
+~ user.roles[1]: "dev" -> "ops"
+`
+	if got := diff.String(); got != wantString {
+		t.Errorf("Expected\n%s\ngot\n%s", wantString, got)
+	}
+}
+
+func TestDiffCheckpoints_Equal(t *testing.T) {
+	t.Parallel()
+
+	type profile struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	// A typed state and the generic map a JSON store loads it as are equal
+	a := &Checkpoint{ID: "a", State: map[string]any{"profile": profile{Name: "ada", Age: 36}}}
+	b := &Checkpoint{ID: "b", State: map[string]any{"profile": map[string]any{"name": "ada", "age": float64(36)}}}
+
+	diff := DiffCheckpoints(a, b)
+	if !diff.Empty() {
+		t.Errorf("Expected no changes, got %v", diff.Changes)
+	}
+	if !strings.Contains(diff.String(), "no state changes") {
+		t.Errorf("Expected the rendering to say there are no changes, got %q", diff.String())
+	}
+
+	// States that aren't maps are compared as a whole
+	diff = DiffCheckpoints(&Checkpoint{State: "draft"}, &Checkpoint{State: "final"})
+	if len(diff.Changes) != 1 || diff.Changes[0].Path != "" || !strings.Contains(diff.String(), `~ (state): "draft" -> "final"`) {
+		t.Errorf("Expected the state to change as a whole, got %v", diff.Changes)
+	}
+}
+
+func TestDiffStoredCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s, err := file.NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	for _, cp := range []*Checkpoint{
+		{ID: "cp-1", Version: 1, State: map[string]any{"step1": "done"}},
+		{ID: "cp-2", Version: 2, State: map[string]any{"step1": "done", "step2": "done"}},
+	} {
+		if err := s.Save(ctx, cp); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	diff, err := DiffStoredCheckpoints(ctx, s, "cp-1", "cp-2")
+	if err != nil {
+		t.Fatalf("DiffStoredCheckpoints failed: %v", err)
+	}
+	want := []StateChange{{Path: "step2", Kind: ChangeAdded, After: "done"}}
+	if !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("Expected changes %v, got %v", want, diff.Changes)
+	}
+
+	if _, err := DiffStoredCheckpoints(ctx, s, "cp-1", "missing"); err == nil {
+		t.Error("Expected an error for a missing checkpoint")
+	}
+}
@@ -0,0 +1,36 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+// ErrCheckpointVetoed is returned by manual saves when CheckpointConfig.BeforeSave
+// rejects the checkpoint
+var ErrCheckpointVetoed = errors.New("checkpoint save vetoed")
+
+// checkpointHooks are the BeforeSave and AfterSave hooks of a CheckpointConfig
+type checkpointHooks struct {
+	before func(*Checkpoint) error
+	after  func(*Checkpoint)
+}
+
+// save saves checkpoint in s between the hooks. A veto of BeforeSave is returned
+// wrapped in ErrCheckpointVetoed, and nothing is saved.
+func (h checkpointHooks) save(ctx context.Context, s store.CheckpointStore, checkpoint *store.Checkpoint) error {
+	if h.before != nil {
+		if err := h.before(checkpoint); err != nil {
+			return fmt.Errorf("%w: checkpoint %s: %w", ErrCheckpointVetoed, checkpoint.ID, err)
+		}
+	}
+	if err := s.Save(ctx, checkpoint); err != nil {
+		return err
+	}
+	if h.after != nil {
+		h.after(checkpoint)
+	}
+	return nil
+}
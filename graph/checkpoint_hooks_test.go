@@ -0,0 +1,151 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redactAPIKey is a BeforeSave hook removing the api_key field of map states
+func redactAPIKey(cp *graph.Checkpoint) error {
+	delete(cp.State.(map[string]any), "api_key")
+	return nil
+}
+
+func TestCheckpointHooks_Redact(t *testing.T) {
+	t.Parallel()
+
+	for _, async := range []bool{false, true} {
+		t.Run(map[bool]string{false: "Sync", true: "Async"}[async], func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			checkpoints := graph.NewMemoryCheckpointStore()
+
+			var mu sync.Mutex
+			var audited []string
+			g := graph.NewCheckpointableStateGraph[map[string]any]()
+			g.SetSchema(graph.NewMapSchema())
+			g.AddNode("login", "login", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				return map[string]any{"api_key": "sk-secret", "user": "ada"}, nil
+			})
+			g.AddNode("call", "call", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				// The node still sees the key the hook removed from the checkpoint
+				return map[string]any{"called_with": state["api_key"]}, nil
+			})
+			g.AddEdge("login", "call")
+			g.AddEdge("call", graph.END)
+			g.SetEntryPoint("login")
+			g.SetCheckpointConfig(graph.CheckpointConfig{
+				Store:      checkpoints,
+				AutoSave:   true,
+				Async:      async,
+				BeforeSave: redactAPIKey,
+				AfterSave: func(cp *graph.Checkpoint) {
+					mu.Lock()
+					defer mu.Unlock()
+					audited = append(audited, cp.ID)
+				},
+			})
+
+			runnable, err := g.CompileCheckpointable()
+			require.NoError(t, err)
+			result, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread"))
+			require.NoError(t, err)
+			assert.Equal(t, "sk-secret", result["api_key"])
+			assert.Equal(t, "sk-secret", result["called_with"])
+
+			saved, err := checkpoints.ListByThread(ctx, "thread")
+			require.NoError(t, err)
+			require.Len(t, saved, 2)
+			var ids []string
+			for _, cp := range saved {
+				state := cp.State.(map[string]any)
+				assert.NotContains(t, state, "api_key", "checkpoint of %s", cp.NodeName)
+				assert.Equal(t, "ada", state["user"])
+				ids = append(ids, cp.ID)
+			}
+			assert.Equal(t, ids, audited, "AfterSave is called with every saved checkpoint")
+
+			// Manual saves run the hooks too, on a copy of the caller's state
+			state := map[string]any{"api_key": "sk-other"}
+			require.NoError(t, runnable.SaveCheckpoint(ctx, "manual", state))
+			assert.Equal(t, "sk-other", state["api_key"])
+			config, err := runnable.UpdateState(ctx, graph.WithThreadID("thread"), "review", map[string]any{"api_key": "sk-update"})
+			require.NoError(t, err)
+			updated, err := checkpoints.Load(ctx, config.Configurable["checkpoint_id"].(string))
+			require.NoError(t, err)
+			assert.NotContains(t, updated.State, "api_key")
+			assert.Len(t, audited, 4)
+		})
+	}
+}
+
+func TestCheckpointHooks_Veto(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	checkpoints := graph.NewMemoryCheckpointStore()
+	errSecret := errors.New("state holds a secret")
+
+	g := graph.NewCheckpointableStateGraph[map[string]any]()
+	g.SetSchema(graph.NewMapSchema())
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"last": name}, nil
+		})
+	}
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", graph.END)
+	g.SetEntryPoint("a")
+
+	var skipped []error
+	g.GetListenableNode("b").AddListener(graph.NodeListenerFunc[map[string]any](
+		func(ctx context.Context, event graph.NodeEvent, nodeName string, state map[string]any, err error) {
+			if event == graph.NodeEventCheckpointSkipped {
+				skipped = append(skipped, err)
+			}
+		}))
+
+	var audited []string
+	g.SetCheckpointConfig(graph.CheckpointConfig{
+		Store:    checkpoints,
+		AutoSave: true,
+		BeforeSave: func(cp *graph.Checkpoint) error {
+			if cp.NodeName == "b" || cp.NodeName == "manual" {
+				return errSecret
+			}
+			return nil
+		},
+		AfterSave: func(cp *graph.Checkpoint) { audited = append(audited, cp.NodeName) },
+	})
+
+	runnable, err := g.CompileCheckpointable()
+	require.NoError(t, err)
+	result, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread"))
+	require.NoError(t, err, "a veto doesn't fail the run")
+	assert.Equal(t, "c", result["last"])
+
+	saved, err := checkpoints.ListByThread(ctx, "thread")
+	require.NoError(t, err)
+	var savedNodes []string
+	for _, cp := range saved {
+		savedNodes = append(savedNodes, cp.NodeName)
+	}
+	assert.Equal(t, []string{"a", "c"}, savedNodes)
+	assert.Equal(t, []int{1, 2}, []int{saved[0].Version, saved[1].Version}, "skipped checkpoints take no version")
+	assert.Equal(t, []string{"a", "c"}, audited)
+
+	require.Len(t, skipped, 1)
+	assert.ErrorIs(t, skipped[0], graph.ErrCheckpointVetoed)
+	assert.ErrorIs(t, skipped[0], errSecret)
+
+	err = runnable.SaveCheckpoint(ctx, "manual", map[string]any{})
+	assert.ErrorIs(t, err, graph.ErrCheckpointVetoed)
+	assert.ErrorIs(t, err, errSecret)
+	assert.Equal(t, []string{"a", "c"}, audited)
+}
@@ -0,0 +1,101 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+type checkpointNamespaceKey struct{}
+
+// withCheckpointNamespace marks the context of a checkpointed run with its
+// checkpoint namespace, so that the checkpointed graphs its nodes invoke save
+// under a child namespace
+func withCheckpointNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, checkpointNamespaceKey{}, namespace)
+}
+
+// CheckpointNamespaceFromContext returns the checkpoint namespace of the
+// checkpointed run executing the node of ctx, see store.NamespaceMetadataKey
+func CheckpointNamespaceFromContext(ctx context.Context) (string, bool) {
+	namespace, ok := ctx.Value(checkpointNamespaceKey{}).(string)
+	return namespace, ok
+}
+
+// WithCheckpointNamespace returns a config for thread threadID in a checkpoint
+// namespace other than the root one, for reading or resuming the checkpoints of a
+// nested graph from outside its parent.
+//
+// Example:
+//
+//	snapshot, err := executor.GetState(ctx, graph.WithCheckpointNamespace("thread-1", "root/executor"))
+func WithCheckpointNamespace(threadID, namespace string) *Config {
+	config := WithThreadID(threadID)
+	config.Configurable[store.NamespaceMetadataKey] = namespace
+	return config
+}
+
+// configNamespace returns the checkpoint namespace config selects, the root one
+// if it sets none
+func configNamespace(config *Config) string {
+	if config != nil {
+		if namespace, ok := config.Configurable[store.NamespaceMetadataKey].(string); ok && namespace != "" {
+			return namespace
+		}
+	}
+	return store.RootNamespace
+}
+
+// runNamespace returns the checkpoint namespace of a run started on ctx with
+// config. A run started by a node of another checkpointed run is nested: its
+// namespace is the child namespace of that node.
+func runNamespace(ctx context.Context, config *Config) (namespace string, nested bool) {
+	if parent, ok := CheckpointNamespaceFromContext(ctx); ok {
+		if node, ok := NodeNameFromContext(ctx); ok {
+			return store.ChildNamespace(parent, node), true
+		}
+	}
+	return configNamespace(config), false
+}
+
+// nestedRunConfig prepares config, a copy the caller may modify, for a run nested
+// in a node of another checkpointed run. The checkpoint listeners of the parent
+// run are removed so they don't save the nested graph's steps, and when the node
+// passed on the config of the parent run, the parent's resume settings are
+// cleared: the nested graph resumes from the checkpoints of its own namespace.
+func nestedRunConfig(ctx context.Context, config *Config, original *Config) {
+	config.Callbacks = slices.DeleteFunc(slices.Clone(config.Callbacks), func(cb CallbackHandler) bool {
+		_, ok := cb.(checkpointCallback)
+		return ok
+	})
+	if parent, ok := ConfigFromContext(ctx); ok && parent == original {
+		config.ResumeFrom = nil
+		config.ResumeValue = nil
+	}
+	config.resumeNodes = nil
+	config.resumeSuccessors = nil
+}
+
+// nestedInterrupt turns the GraphInterrupt of a nested run into the NodeInterrupt
+// of the parent node that started it, which interrupts the parent run too.
+// Resuming the parent runs the node again, and the nested graph resumes from the
+// checkpoints of its namespace, answering its Interrupt() with the parent's
+// resume value.
+func nestedInterrupt(err error) error {
+	var interrupt *GraphInterrupt
+	if errors.As(err, &interrupt) {
+		return &NodeInterrupt{Value: interrupt.InterruptValue}
+	}
+	return err
+}
+
+// checkpointCallback is implemented by the checkpoint listeners of every state type
+type checkpointCallback interface {
+	checkpointNamespace() string
+}
+
+func (cl *CheckpointListener[S]) checkpointNamespace() string {
+	return cl.namespace
+}
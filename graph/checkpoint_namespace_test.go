@@ -0,0 +1,150 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNamespaceGraphs builds a parent graph start -> executor -> finish whose
+// executor node invokes a child graph plan -> approve with the parent's config.
+// The approve node asks for approval with Interrupt.
+func newNamespaceGraphs(t *testing.T, checkpoints store.CheckpointStore) (parent *graph.CheckpointableRunnable[map[string]any], planned *int) {
+	t.Helper()
+	planned = new(int)
+
+	child := graph.NewCheckpointableStateGraph[map[string]any]()
+	child.SetSchema(graph.NewMapSchema())
+	child.AddNode("plan", "plan", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		*planned++
+		return map[string]any{"plan": "refund"}, nil
+	})
+	child.AddNode("approve", "approve", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		answer, err := graph.Interrupt(ctx, "approve the "+state["plan"].(string)+"?")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"approved": answer}, nil
+	})
+	child.SetEntryPoint("plan")
+	child.AddEdge("plan", "approve")
+	child.AddEdge("approve", graph.END)
+	child.SetCheckpointConfig(graph.CheckpointConfig{Store: checkpoints, AutoSave: true})
+	executor, err := child.CompileCheckpointable()
+	require.NoError(t, err)
+
+	g := graph.NewCheckpointableStateGraph[map[string]any]()
+	g.SetSchema(graph.NewMapSchema())
+	g.AddNode("start", "start", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"started": true}, nil
+	})
+	g.AddNode("executor", "executor", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return executor.InvokeWithConfig(ctx, state, graph.GetConfig(ctx))
+	})
+	g.AddNode("finish", "finish", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"finished": state["approved"]}, nil
+	})
+	g.SetEntryPoint("start")
+	g.AddEdge("start", "executor")
+	g.AddEdge("executor", "finish")
+	g.AddEdge("finish", graph.END)
+	g.SetCheckpointConfig(graph.CheckpointConfig{Store: checkpoints, AutoSave: true})
+	parent, err = g.CompileCheckpointable()
+	require.NoError(t, err)
+	return parent, planned
+}
+
+func namespaceNodes(t *testing.T, checkpoints store.CheckpointStore, namespace string) []string {
+	t.Helper()
+	saved, err := store.ListByNamespace(context.Background(), checkpoints, "thread", namespace)
+	require.NoError(t, err)
+	var nodes []string
+	for _, cp := range saved {
+		nodes = append(nodes, cp.NodeName)
+	}
+	return nodes
+}
+
+func TestCheckpointNamespaces(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	checkpoints := graph.NewMemoryCheckpointStore()
+	parent, planned := newNamespaceGraphs(t, checkpoints)
+
+	// The interrupt of the child interrupts the executor node of the parent
+	_, err := parent.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread"))
+	var interrupt *graph.GraphInterrupt
+	require.ErrorAs(t, err, &interrupt)
+	assert.Equal(t, "executor", interrupt.Node)
+	assert.Equal(t, "approve the refund?", interrupt.InterruptValue)
+
+	assert.Equal(t, []string{"start", "executor"}, namespaceNodes(t, checkpoints, store.RootNamespace))
+	assert.Equal(t, []string{"plan", "approve"}, namespaceNodes(t, checkpoints, "root/executor"))
+
+	// Resuming the parent resumes the child at its own interrupt
+	result, err := parent.InvokeCommand(ctx, &graph.Command{Resume: "yes"}, graph.WithThreadID("thread"))
+	require.NoError(t, err)
+	assert.Equal(t, "yes", result["finished"])
+	assert.Equal(t, 1, *planned, "the child resumes after its plan node")
+
+	assert.Equal(t, []string{"start", "executor", "executor", "finish"}, namespaceNodes(t, checkpoints, store.RootNamespace))
+	assert.Equal(t, []string{"plan", "approve", "approve"}, namespaceNodes(t, checkpoints, "root/executor"))
+
+	latest, err := store.GetLatestByNamespace(ctx, checkpoints, "thread", "root/executor")
+	require.NoError(t, err)
+	assert.Equal(t, "yes", latest.State.(map[string]any)["approved"])
+
+	// The child's checkpoints are read from outside with their namespace
+	snapshot, err := parent.GetState(ctx, graph.WithCheckpointNamespace("thread", "root/executor"))
+	require.NoError(t, err)
+	assert.Equal(t, latest.ID, snapshot.Config.Configurable["checkpoint_id"])
+	assert.Equal(t, "root/executor", snapshot.Config.Configurable[store.NamespaceMetadataKey])
+	snapshot, err = parent.GetState(ctx, graph.WithThreadID("thread"))
+	require.NoError(t, err)
+	assert.Equal(t, "finish", snapshot.Next[0])
+}
+
+func TestCheckpointNamespaces_MaxCheckpoints(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	checkpoints := graph.NewMemoryCheckpointStore()
+
+	child := graph.NewCheckpointableStateGraph[map[string]any]()
+	for _, name := range []string{"c1", "c2", "c3"} {
+		child.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		})
+	}
+	child.SetEntryPoint("c1")
+	child.AddEdge("c1", "c2")
+	child.AddEdge("c2", "c3")
+	child.AddEdge("c3", graph.END)
+	child.SetCheckpointConfig(graph.CheckpointConfig{Store: checkpoints, AutoSave: true, MaxCheckpoints: 1})
+	nested, err := child.CompileCheckpointable()
+	require.NoError(t, err)
+
+	g := graph.NewCheckpointableStateGraph[map[string]any]()
+	g.AddNode("start", "start", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return state, nil
+	})
+	g.AddNode("nested", "nested", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return nested.InvokeWithConfig(ctx, state, graph.GetConfig(ctx))
+	})
+	g.SetEntryPoint("start")
+	g.AddEdge("start", "nested")
+	g.AddEdge("nested", graph.END)
+	g.SetCheckpointConfig(graph.CheckpointConfig{Store: checkpoints, AutoSave: true})
+	runnable, err := g.CompileCheckpointable()
+	require.NoError(t, err)
+
+	_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread"))
+	require.NoError(t, err)
+
+	// The child's limit applies to its own namespace only
+	assert.Equal(t, []string{"start", "nested"}, namespaceNodes(t, checkpoints, store.RootNamespace))
+	assert.Equal(t, []string{"c3"}, namespaceNodes(t, checkpoints, "root/nested"))
+}
@@ -0,0 +1,123 @@
+package graph_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/bolt"
+	"github.com/smallnest/langgraphgo/store/file"
+	"github.com/smallnest/langgraphgo/store/memory"
+	"github.com/smallnest/langgraphgo/store/postgres"
+	"github.com/smallnest/langgraphgo/store/redis"
+	"github.com/smallnest/langgraphgo/store/sqlite"
+)
+
+// Every backend is a CheckpointStore under both names
+var (
+	_ graph.CheckpointStore = (*memory.MemoryCheckpointStore)(nil)
+	_ graph.CheckpointStore = (*file.FileCheckpointStore)(nil)
+	_ graph.CheckpointStore = (*redis.RedisCheckpointStore)(nil)
+	_ graph.CheckpointStore = (*postgres.PostgresCheckpointStore)(nil)
+	_ graph.CheckpointStore = (*sqlite.SqliteCheckpointStore)(nil)
+	_ graph.CheckpointStore = (*bolt.BoltCheckpointStore)(nil)
+	_ store.CheckpointStore = graph.NewMemoryCheckpointStore()
+)
+
+// saveWithGraphTypes is written against the graph names, like code that predates
+// the store package
+func saveWithGraphTypes(ctx context.Context, s graph.CheckpointStore, cp *graph.Checkpoint) error {
+	return s.Save(ctx, cp)
+}
+
+// latestWithStoreTypes is written against the store names
+func latestWithStoreTypes(ctx context.Context, s store.CheckpointStore, threadID string) (*store.Checkpoint, error) {
+	return s.GetLatestByThread(ctx, threadID)
+}
+
+func TestCheckpointTypes_Interchangeable(t *testing.T) {
+	t.Parallel()
+
+	backends := map[string]func(t *testing.T) store.CheckpointStore{
+		"Memory": func(t *testing.T) store.CheckpointStore {
+			return memory.NewMemoryCheckpointStore()
+		},
+		"File": func(t *testing.T) store.CheckpointStore {
+			s, err := file.NewFileCheckpointStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("Failed to create file store: %v", err)
+			}
+			return s
+		},
+		"Redis": func(t *testing.T) store.CheckpointStore {
+			return redis.NewRedisCheckpointStore(redis.RedisOptions{Addr: miniredis.RunT(t).Addr()})
+		},
+		"Sqlite": func(t *testing.T) store.CheckpointStore {
+			s, err := sqlite.NewSqliteCheckpointStore(sqlite.SqliteOptions{Path: filepath.Join(t.TempDir(), "checkpoints.db")})
+			if err != nil {
+				t.Fatalf("Failed to create sqlite store: %v", err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+		"Bolt": func(t *testing.T) store.CheckpointStore {
+			s, err := bolt.NewBoltCheckpointStore(filepath.Join(t.TempDir(), "checkpoints.db"))
+			if err != nil {
+				t.Fatalf("Failed to create bolt store: %v", err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+	}
+
+	for name, newStore := range backends {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			s := newStore(t)
+
+			g := graph.NewCheckpointableStateGraph[map[string]any]()
+			g.AddNode("a", "a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				return map[string]any{"step": "a"}, nil
+			})
+			g.AddEdge("a", graph.END)
+			g.SetEntryPoint("a")
+			g.SetCheckpointConfig(graph.CheckpointConfig{Store: s, AutoSave: true})
+
+			runnable, err := g.CompileCheckpointable()
+			if err != nil {
+				t.Fatalf("Failed to compile: %v", err)
+			}
+			if _, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread")); err != nil {
+				t.Fatalf("Execution failed: %v", err)
+			}
+
+			// A checkpoint saved by the graph is read with the store names, and one
+			// saved with the graph names is read back by the graph
+			latest, err := latestWithStoreTypes(ctx, s, "thread")
+			if err != nil {
+				t.Fatalf("GetLatestByThread failed: %v", err)
+			}
+			if latest.NodeName != "a" {
+				t.Errorf("Expected the checkpoint of node a, got %s", latest.NodeName)
+			}
+
+			next := *latest
+			next.ID = latest.ID + "-next"
+			next.Version = latest.Version + 1
+			if err := saveWithGraphTypes(ctx, s, &next); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+			snapshot, err := runnable.GetState(ctx, graph.WithThreadID("thread"))
+			if err != nil {
+				t.Fatalf("GetState failed: %v", err)
+			}
+			if snapshot.Config.Configurable["checkpoint_id"] != next.ID {
+				t.Errorf("Expected the state of %s, got %v", next.ID, snapshot.Config.Configurable["checkpoint_id"])
+			}
+		})
+	}
+}
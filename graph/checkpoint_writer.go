@@ -0,0 +1,223 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// QueueFullPolicy chooses what an asynchronous checkpoint writer does when its
+// queue is full, see CheckpointConfig.Async
+type QueueFullPolicy int
+
+const (
+	// QueueFullBlock makes the run wait until the writer has saved a queued checkpoint
+	QueueFullBlock QueueFullPolicy = iota
+
+	// QueueFullDropOldest discards the oldest checkpoint the run queued, notifying
+	// the node's listeners with NodeEventCheckpointDropped, so the run never waits
+	// for the store. The checkpoints of other runs are never dropped: a run with
+	// none queued adds its checkpoint to the full queue.
+	QueueFullDropOldest
+)
+
+// defaultAsyncQueueSize is the queue size of asynchronous checkpointing when
+// CheckpointConfig.AsyncQueueSize is not set
+const defaultAsyncQueueSize = 64
+
+// ErrCheckpointDropped is the error of the NodeEventCheckpointDropped events
+// reporting the checkpoints that QueueFullDropOldest discards
+var ErrCheckpointDropped = errors.New("checkpoint dropped: the checkpoint queue is full")
+
+// checkpointWrite saves a queued checkpoint
+type checkpointWrite struct {
+	checkpointID string
+	nodeName     string
+	save         func() error
+
+	// batch is the batch of the run that queued the write
+	batch *checkpointBatch
+
+	// dropped reports the write when the queue-full policy drops it
+	dropped func()
+}
+
+// checkpointBatch tracks the writes one run queued, so the run waits only for
+// its own checkpoints and returns only their failures. Its fields are guarded by
+// the mutex of the writer.
+type checkpointBatch struct {
+	// pending counts the writes queued or being saved, and idle is closed when it
+	// drops to zero; nil while nothing is pending
+	pending int
+	idle    chan struct{}
+
+	// err collects the failures not returned yet
+	err error
+}
+
+// checkpointWriter saves checkpoints in a background goroutine, in the order
+// they were queued. The goroutine starts with the first queued checkpoint and
+// exits once the queue is empty, so an idle writer holds no resources.
+type checkpointWriter struct {
+	mu      sync.Mutex
+	notFull *sync.Cond
+	queue   []checkpointWrite
+	size    int
+	policy  QueueFullPolicy
+
+	// done is closed when the running goroutine exits; nil while none runs
+	done chan struct{}
+
+	// failed are the batches with failures not returned yet, and unowned is the
+	// batch of the writes queued without one
+	failed  map[*checkpointBatch]struct{}
+	unowned checkpointBatch
+}
+
+func newCheckpointWriter(size int, policy QueueFullPolicy) *checkpointWriter {
+	if size <= 0 {
+		size = defaultAsyncQueueSize
+	}
+	w := &checkpointWriter{size: size, policy: policy, failed: make(map[*checkpointBatch]struct{})}
+	w.notFull = sync.NewCond(&w.mu)
+	return w
+}
+
+// enqueue queues a write, applying the queue-full policy
+func (w *checkpointWriter) enqueue(write checkpointWrite) {
+	if write.batch == nil {
+		write.batch = &w.unowned
+	}
+
+	w.mu.Lock()
+	var dropped *checkpointWrite
+	for len(w.queue) >= w.size {
+		if w.policy == QueueFullDropOldest {
+			i := slices.IndexFunc(w.queue, func(queued checkpointWrite) bool { return queued.batch == write.batch })
+			if i >= 0 {
+				oldest := w.queue[i]
+				dropped = &oldest
+				w.queue = slices.Delete(w.queue, i, i+1)
+				w.finish(dropped.batch, nil)
+			}
+			break
+		}
+		w.notFull.Wait()
+	}
+	w.queue = append(w.queue, write)
+	write.batch.pending++
+	if write.batch.idle == nil {
+		write.batch.idle = make(chan struct{})
+	}
+
+	if w.done == nil {
+		w.done = make(chan struct{})
+		go w.run(w.done)
+	}
+	w.mu.Unlock()
+
+	// Listeners are notified outside the lock, so they may use the runnable
+	if dropped != nil && dropped.dropped != nil {
+		dropped.dropped()
+	}
+}
+
+// finish records that a write of batch is done, failing with err if not nil.
+// The writer's mutex must be held.
+func (w *checkpointWriter) finish(batch *checkpointBatch, err error) {
+	if err != nil {
+		batch.err = errors.Join(batch.err, err)
+		w.failed[batch] = struct{}{}
+	}
+	batch.pending--
+	if batch.pending == 0 {
+		close(batch.idle)
+		batch.idle = nil
+	}
+}
+
+func (w *checkpointWriter) run(done chan struct{}) {
+	for {
+		w.mu.Lock()
+		if len(w.queue) == 0 {
+			w.done = nil
+			close(done)
+			w.mu.Unlock()
+			return
+		}
+		write := w.queue[0]
+		w.queue = w.queue[1:]
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		err := w.save(write)
+		w.mu.Lock()
+		w.finish(write.batch, err)
+		w.mu.Unlock()
+	}
+}
+
+// save runs a write, turning a panic of the store into an error so the writer
+// goes on with the checkpoints queued after it
+func (w *checkpointWriter) save(write checkpointWrite) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("checkpoint writer panicked saving checkpoint %s: %v", write.checkpointID, r)
+		}
+	}()
+	if err := write.save(); err != nil {
+		return fmt.Errorf("failed to save checkpoint %s: %w", write.checkpointID, err)
+	}
+	return nil
+}
+
+// flush waits until every queued checkpoint is saved and returns the failures
+// not returned yet, by flush or by flushBatch
+func (w *checkpointWriter) flush(ctx context.Context) error {
+	for {
+		w.mu.Lock()
+		done := w.done
+		if done == nil {
+			var err error
+			for batch := range w.failed {
+				err = errors.Join(err, batch.err)
+				batch.err = nil
+			}
+			clear(w.failed)
+			w.mu.Unlock()
+			return err
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// flushBatch waits until the checkpoints of batch are saved and returns their
+// failures not returned yet
+func (w *checkpointWriter) flushBatch(ctx context.Context, batch *checkpointBatch) error {
+	for {
+		w.mu.Lock()
+		idle := batch.idle
+		if idle == nil {
+			err := batch.err
+			batch.err = nil
+			delete(w.failed, batch)
+			w.mu.Unlock()
+			return err
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
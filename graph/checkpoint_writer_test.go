@@ -0,0 +1,296 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatedStore holds every Save until release is closed, and reports the start of
+// each Save on started
+type gatedStore struct {
+	CheckpointStore
+	started chan string
+	release chan struct{}
+	saves   atomic.Int32
+
+	// panicAt makes the Save with that 1-based number panic
+	panicAt int32
+}
+
+func newGatedStore() *gatedStore {
+	return &gatedStore{
+		CheckpointStore: NewMemoryCheckpointStore(),
+		started:         make(chan string, 100),
+		release:         make(chan struct{}),
+	}
+}
+
+func (s *gatedStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	n := s.saves.Add(1)
+	s.started <- checkpoint.NodeName
+	<-s.release
+	if n == s.panicAt {
+		panic("store crashed")
+	}
+	return s.CheckpointStore.Save(ctx, checkpoint)
+}
+
+// newAsyncGraph builds a chain of nodes that record their runs in the returned
+// slice. before, if set, is called as each node starts.
+func newAsyncGraph(t *testing.T, config CheckpointConfig, before func(node string), nodes ...string) (*CheckpointableRunnable[map[string]any], *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var ran []string
+
+	g := NewCheckpointableStateGraphWithConfig[map[string]any](config)
+	g.SetSchema(NewMapSchema())
+	for i, name := range nodes {
+		g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			if before != nil {
+				before(name)
+			}
+			mu.Lock()
+			ran = append(ran, name)
+			mu.Unlock()
+			// Changes the state in place, which queued checkpoints must not see
+			state["last"] = name
+			return state, nil
+		})
+		if i == 0 {
+			g.SetEntryPoint(name)
+		} else {
+			g.AddEdge(nodes[i-1], name)
+		}
+	}
+	g.AddEdge(nodes[len(nodes)-1], END)
+
+	runnable, err := g.CompileCheckpointable()
+	require.NoError(t, err)
+	return runnable, &ran
+}
+
+func threadNodes(t *testing.T, s CheckpointStore, threadID string) []string {
+	t.Helper()
+	checkpoints, err := s.ListByThread(context.Background(), threadID)
+	require.NoError(t, err)
+	var nodes []string
+	for i, cp := range checkpoints {
+		assert.Equal(t, i+1, cp.Version, "versions increase in the order of the steps")
+		assert.Equal(t, cp.NodeName, cp.State.(map[string]any)["last"], "the state is the one of its step")
+		nodes = append(nodes, cp.NodeName)
+	}
+	return nodes
+}
+
+func TestAsyncCheckpointing(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("DoesNotBlockSteps", func(t *testing.T) {
+		t.Parallel()
+		store := newGatedStore()
+		runnable, ran := newAsyncGraph(t, CheckpointConfig{Store: store, AutoSave: true, Async: true}, nil, "a", "b", "c")
+
+		done := make(chan error)
+		go func() {
+			_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("thread"))
+			done <- err
+		}()
+
+		// Every node runs while the first checkpoint is still being saved
+		assert.Equal(t, "a", <-store.started)
+		assert.Eventually(t, func() bool {
+			runnable.listener.writer.mu.Lock()
+			defer runnable.listener.writer.mu.Unlock()
+			return len(runnable.listener.writer.queue) == 2
+		}, time.Second, time.Millisecond)
+
+		select {
+		case <-done:
+			t.Fatal("Expected the invocation to wait for the queued checkpoints")
+		default:
+		}
+		close(store.release)
+		require.NoError(t, <-done)
+
+		assert.Equal(t, []string{"a", "b", "c"}, *ran)
+		assert.Equal(t, []string{"a", "b", "c"}, threadNodes(t, store, "thread"))
+	})
+
+	t.Run("ConcurrentThreads", func(t *testing.T) {
+		t.Parallel()
+		store := NewMemoryCheckpointStore()
+		runnable, _ := newAsyncGraph(t, CheckpointConfig{Store: store, AutoSave: true, Async: true, AsyncQueueSize: 2}, nil, "a", "b", "c", "d")
+
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID(fmt.Sprintf("thread-%d", i)))
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		for i := range 8 {
+			assert.Equal(t, []string{"a", "b", "c", "d"}, threadNodes(t, store, fmt.Sprintf("thread-%d", i)))
+		}
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		t.Parallel()
+		store := newGatedStore()
+		savingA := make(chan struct{})
+		runnable, _ := newAsyncGraph(t, CheckpointConfig{
+			Store:          store,
+			AutoSave:       true,
+			Async:          true,
+			AsyncQueueSize: 1,
+			AsyncQueueFull: QueueFullDropOldest,
+		}, func(node string) {
+			if node == "b" {
+				<-savingA
+			}
+		}, "a", "b", "c")
+		var mu sync.Mutex
+		var dropped []error
+		runnable.GetGraph().GetListenableNode("b").AddListener(NodeListenerFunc[map[string]any](
+			func(ctx context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {
+				if event == NodeEventCheckpointDropped {
+					mu.Lock()
+					dropped = append(dropped, err)
+					mu.Unlock()
+				}
+			}))
+
+		// The checkpoint of a is being saved, b waits in the queue, and c replaces b
+		go func() {
+			assert.Equal(t, "a", <-store.started)
+			close(savingA)
+			assert.Eventually(t, func() bool {
+				runnable.listener.writer.mu.Lock()
+				defer runnable.listener.writer.mu.Unlock()
+				queue := runnable.listener.writer.queue
+				return len(queue) == 1 && queue[0].nodeName == "c"
+			}, time.Second, time.Millisecond)
+			close(store.release)
+		}()
+		_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("thread"))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"a", "c"}, threadNodes(t, store, "thread"))
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, dropped, 1, "the listeners of b are told its checkpoint was dropped")
+		assert.ErrorIs(t, dropped[0], ErrCheckpointDropped)
+	})
+
+	t.Run("FailingThread", func(t *testing.T) {
+		t.Parallel()
+		store := newGatedStore()
+		store.panicAt = 1
+		close(store.release)
+		runnable, _ := newAsyncGraph(t, CheckpointConfig{Store: store, AutoSave: true, Async: true}, nil, "a")
+
+		// The failure of the first thread is reported to it only
+		_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("failing"))
+		assert.ErrorContains(t, err, "store crashed")
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("healthy"))
+		assert.NoError(t, err)
+		assert.NoError(t, runnable.Flush(ctx))
+		assert.Equal(t, []string{"a"}, threadNodes(t, store, "healthy"))
+	})
+
+	t.Run("WriterPanics", func(t *testing.T) {
+		t.Parallel()
+		store := newGatedStore()
+		store.panicAt = 2
+		close(store.release)
+		runnable, _ := newAsyncGraph(t, CheckpointConfig{Store: store, AutoSave: true, Async: true}, nil, "a", "b", "c")
+
+		_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("thread"))
+		assert.ErrorContains(t, err, "checkpoint writer panicked")
+		assert.ErrorContains(t, err, "store crashed")
+
+		// The writer goes on with the checkpoints after the one it failed to save,
+		// and the error is reported once
+		checkpoints, err := store.ListByThread(ctx, "thread")
+		require.NoError(t, err)
+		require.Len(t, checkpoints, 2)
+		assert.Equal(t, "c", checkpoints[1].NodeName)
+		assert.Equal(t, 2, checkpoints[1].Version)
+		assert.NoError(t, runnable.Flush(ctx))
+	})
+}
+
+func TestCheckpointWriter_Flush(t *testing.T) {
+	t.Parallel()
+
+	w := newCheckpointWriter(0, QueueFullBlock)
+	assert.NoError(t, w.flush(context.Background()), "nothing was queued")
+
+	release := make(chan struct{})
+	w.enqueue(checkpointWrite{checkpointID: "cp-1", save: func() error {
+		<-release
+		return errors.New("disk full")
+	}})
+	w.enqueue(checkpointWrite{checkpointID: "cp-2", save: func() error { return nil }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.flush(ctx), context.DeadlineExceeded)
+
+	close(release)
+	err := w.flush(context.Background())
+	assert.ErrorContains(t, err, "failed to save checkpoint cp-1: disk full")
+	assert.NotContains(t, err.Error(), "cp-2")
+	assert.NoError(t, w.flush(context.Background()), "errors are returned once")
+}
+
+func TestCheckpointWriter_Batches(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var saved []string
+	save := func(id string) func() error {
+		return func() error {
+			mu.Lock()
+			saved = append(saved, id)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	w := newCheckpointWriter(1, QueueFullDropOldest)
+	first, second := &checkpointBatch{}, &checkpointBatch{}
+	saving, release := make(chan struct{}), make(chan struct{})
+	w.enqueue(checkpointWrite{checkpointID: "first-1", batch: first, save: func() error {
+		close(saving)
+		<-release
+		return errors.New("disk full")
+	}})
+	<-saving
+
+	// A full queue drops the oldest write of the same batch only
+	var dropped []string
+	w.enqueue(checkpointWrite{checkpointID: "first-2", batch: first, save: save("first-2"), dropped: func() { dropped = append(dropped, "first-2") }})
+	w.enqueue(checkpointWrite{checkpointID: "second-1", batch: second, save: save("second-1"), dropped: func() { dropped = append(dropped, "second-1") }})
+	w.enqueue(checkpointWrite{checkpointID: "first-3", batch: first, save: save("first-3")})
+	assert.Equal(t, []string{"first-2"}, dropped)
+
+	close(release)
+	assert.NoError(t, w.flushBatch(ctx, second), "the failures of other batches are not returned")
+	assert.ErrorContains(t, w.flushBatch(ctx, first), "failed to save checkpoint first-1: disk full")
+	assert.NoError(t, w.flush(ctx), "errors are returned once")
+	assert.Equal(t, []string{"second-1", "first-3"}, saved)
+}
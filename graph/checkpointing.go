@@ -2,7 +2,10 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,18 +14,36 @@ import (
 	"github.com/smallnest/langgraphgo/store/memory"
 )
 
+// The checkpoint types are declared in the store package, which every store
+// implements. They are aliased here so that graphs can be checkpointed without
+// importing store, and code written against either package is interchangeable.
+
 // Checkpoint is an alias for store.Checkpoint
 type Checkpoint = store.Checkpoint
 
 // CheckpointStore is an alias for store.CheckpointStore
 type CheckpointStore = store.CheckpointStore
 
-// NewMemoryCheckpointStore creates a new in-memory checkpoint store
+// ListOptions is an alias for store.ListOptions
+type ListOptions = store.ListOptions
+
+// ThreadInfo is an alias for store.ThreadInfo
+type ThreadInfo = store.ThreadInfo
+
+// ListThreadsOptions is an alias for store.ListThreadsOptions
+type ListThreadsOptions = store.ListThreadsOptions
+
+// RetentionPolicy is an alias for store.RetentionPolicy
+type RetentionPolicy = store.RetentionPolicy
+
+// NewMemoryCheckpointStore creates a new in-memory checkpoint store, the same as
+// memory.NewMemoryCheckpointStore
 func NewMemoryCheckpointStore() store.CheckpointStore {
 	return memory.NewMemoryCheckpointStore()
 }
 
-// NewFileCheckpointStore creates a new file-based checkpoint store
+// NewFileCheckpointStore creates a new file-based checkpoint store, the same as
+// file.NewFileCheckpointStore
 func NewFileCheckpointStore(path string) (store.CheckpointStore, error) {
 	return file.NewFileCheckpointStore(path)
 }
@@ -35,11 +56,76 @@ type CheckpointConfig struct {
 	// AutoSave enables automatic checkpointing after each node
 	AutoSave bool
 
+	// SavePending, with AutoSave, also saves a pending checkpoint before each step,
+	// with the state its nodes receive and "pending_nodes" metadata naming them.
+	// After a crash during the step, resuming the thread re-runs exactly those
+	// nodes on that state. Pending checkpoints count towards MaxCheckpoints and
+	// Retention like the others.
+	SavePending bool
+
+	// SaveWrites, with AutoSave, saves the result of each node of a step running
+	// several nodes as soon as it returns, as a pending write of the checkpoint the
+	// step started from (see store.PendingWrite). When a node of the step fails,
+	// resuming the thread from that checkpoint reuses the saved results and runs
+	// only the nodes without one. Steps start from the checkpoint of the previous
+	// step, so the first step of a run has one only with SavePending. The store
+	// must implement store.WriteStore, such as the memory and file stores.
+	SaveWrites bool
+
 	// SaveInterval specifies how often to save (when AutoSave is false)
 	SaveInterval time.Duration
 
-	// MaxCheckpoints limits the number of checkpoints to keep
+	// MaxCheckpoints limits the number of checkpoints to keep, per checkpoint
+	// namespace on a thread
 	MaxCheckpoints int
+
+	// Metadata is added to the metadata of every automatically saved checkpoint,
+	// for finding them with store.ListByMetadata. Keys the graph sets, such as
+	// execution_id and thread_id, take precedence.
+	Metadata map[string]any
+
+	// Retention prunes the checkpoints of the run's namespace on the thread, or of
+	// the execution when there is no thread, after each automatic save. The latest
+	// checkpoint is always kept.
+	Retention RetentionPolicy
+
+	// BeforeSave, if set, is called with every checkpoint before it is saved,
+	// automatically or by SaveCheckpoint and UpdateState, for example to redact
+	// secrets from its state. It may change the checkpoint, whose state is a copy
+	// the run doesn't see. An error vetoes the save without failing the run: an
+	// automatic save skips the checkpoint and notifies the node's listeners with
+	// NodeEventCheckpointSkipped, and a manual save returns the error wrapped in
+	// ErrCheckpointVetoed.
+	BeforeSave func(*Checkpoint) error
+
+	// AfterSave, if set, is called with every checkpoint once the store has saved
+	// it, for example to write an audit log entry
+	AfterSave func(*Checkpoint)
+
+	// Async, with AutoSave, saves checkpoints in a background goroutine, so runs
+	// don't wait for the store after each step. Checkpoints are saved in the order
+	// of their steps, so versions keep increasing. The invocation methods of
+	// CheckpointableRunnable wait for the queued checkpoints before returning, and
+	// return the errors saving them; Flush does the same for other callers.
+	Async bool
+
+	// AsyncQueueSize bounds the number of checkpoints waiting to be saved when
+	// Async is set, 64 if zero
+	AsyncQueueSize int
+
+	// AsyncQueueFull chooses whether a run waits for a full queue, the default, or
+	// drops the oldest checkpoint it queued
+	AsyncQueueFull QueueFullPolicy
+
+	// Serializer, if set, encodes checkpoint states before they are saved and
+	// decodes them into the graph's state type when they are loaded, so that
+	// registered types survive the round trip through stores that persist JSON
+	// (see RegisterType). Without it states are saved as they are, and stores that
+	// persist JSON load map states with their values decoded as JSON, so graphs
+	// with struct states need a Serializer to resume from them. Every checkpoint
+	// records the state type of its graph, and loading it into a graph with
+	// another state type fails with ErrStateTypeMismatch.
+	Serializer Serializer
 }
 
 // DefaultCheckpointConfig returns a default checkpoint configuration
@@ -52,13 +138,58 @@ func DefaultCheckpointConfig() CheckpointConfig {
 	}
 }
 
+// Values of the "status" metadata of automatically saved checkpoints
+const (
+	// CheckpointStatusPending marks a checkpoint saved before the nodes of a step
+	// ran, with the state they received (see CheckpointConfig.SavePending)
+	CheckpointStatusPending = "pending"
+
+	// CheckpointStatusCompleted marks a checkpoint saved after a step completed or
+	// was interrupted
+	CheckpointStatusCompleted = "completed"
+)
+
+// IsPendingCheckpoint reports whether checkpoint was saved before the nodes of its
+// step ran, so that they may not have completed
+func IsPendingCheckpoint(checkpoint *Checkpoint) bool {
+	status, _ := checkpoint.Metadata["status"].(string)
+	return status == CheckpointStatusPending
+}
+
 // CheckpointListener automatically creates checkpoints during execution
 type CheckpointListener[S any] struct {
 	store          store.CheckpointStore
 	executionID    string
 	threadID       string
 	autoSave       bool
+	savePending    bool
+	saveWrites     bool
 	maxCheckpoints int
+	retention      store.RetentionPolicy
+	metadata       map[string]any
+
+	// fingerprint is the Fingerprint of the graph, saved with every checkpoint
+	fingerprint string
+
+	// namespace is the checkpoint namespace of the run on the thread
+	namespace string
+
+	// hooks run around every save, and notify reports the checkpoints they veto
+	hooks  checkpointHooks
+	notify func(ctx context.Context, event NodeEvent, nodeName string, state S, err error)
+
+	// writer saves checkpoints in the background when CheckpointConfig.Async is
+	// set. States are copied by clone before they are queued or given to hooks.
+	writer *checkpointWriter
+	clone  func(S) S
+
+	// batch tracks the checkpoints the run queued on writer, shared by the copies
+	// of the listener for a run
+	batch *checkpointBatch
+
+	// step is the checkpoint the current step started from, shared by the copies
+	// of the listener for a run
+	step *stepWrites
 }
 
 // OnGraphStep is called after a step in the graph has completed and the state has been merged.
@@ -70,6 +201,16 @@ func (cl *CheckpointListener[S]) OnGraphStep(ctx context.Context, nodeName strin
 	}
 }
 
+// OnGraphStepStart is called before the nodes of a step execute, and saves a
+// pending checkpoint of the state they receive when SavePending is set.
+func (cl *CheckpointListener[S]) OnGraphStepStart(ctx context.Context, nodes []string, state any) {
+	if cl.autoSave && cl.savePending {
+		if s, ok := state.(S); ok {
+			cl.saveCheckpoint(withPendingNodes(ctx, nodes), stepNodeName(nodes), s)
+		}
+	}
+}
+
 // Implement other methods of CallbackHandler as no-ops
 func (cl *CheckpointListener[S]) OnChainStart(context.Context, map[string]any, map[string]any, string, *string, []string, map[string]any) {
 }
@@ -89,51 +230,147 @@ func (cl *CheckpointListener[S]) OnRetrieverEnd(context.Context, []any, string)
 func (cl *CheckpointListener[S]) OnRetrieverError(context.Context, error, string) {}
 
 func (cl *CheckpointListener[S]) saveCheckpoint(ctx context.Context, nodeName string, state S) {
-	// Get current version from existing checkpoints
-	checkpoints, err := cl.store.List(ctx, cl.executionID)
-	version := 1
-	if err == nil && len(checkpoints) > 0 {
-		// Get the latest version
-		latest := checkpoints[len(checkpoints)-1]
-		version = latest.Version + 1
-	}
-
-	metadata := map[string]any{
-		"execution_id": cl.executionID,
-		"event":        "step",
+	metadata := maps.Clone(cl.metadata)
+	if metadata == nil {
+		metadata = make(map[string]any)
 	}
+	metadata["execution_id"] = cl.executionID
+	metadata["event"] = "step"
+	metadata["status"] = CheckpointStatusCompleted
+	metadata[fingerprintMetadataKey] = cl.fingerprint
+	metadata[stateTypeMetadataKey] = stateTypeName[S]()
 	if cl.threadID != "" {
 		metadata["thread_id"] = cl.threadID
 	}
+	if cl.namespace != "" {
+		metadata[store.NamespaceMetadataKey] = cl.namespace
+	}
+	if interrupt, ok := interruptFromContext(ctx); ok {
+		first := interrupt.interrupts[0]
+		metadata["event"] = "interrupt"
+		metadata["interrupt_node"] = first.Node
+		if first.Value != nil {
+			metadata["interrupt_value"] = first.Value
+		}
+		if len(interrupt.interrupts) > 1 {
+			nodes := make([]string, len(interrupt.interrupts))
+			for i, ni := range interrupt.interrupts {
+				nodes[i] = ni.Node
+			}
+			metadata["interrupt_nodes"] = nodes
+		}
+		if len(interrupt.successors) > 0 {
+			metadata["interrupt_successors"] = interrupt.successors
+		}
+	}
+	if pending, ok := pendingNodesFromContext(ctx); ok {
+		metadata["event"] = "pending"
+		metadata["status"] = CheckpointStatusPending
+		metadata["pending_nodes"] = pending
+	}
+	if next, ok := nextNodesFromContext(ctx); ok {
+		metadata["next_nodes"] = next
+	}
+	if entry, ok := entryNodesFromContext(ctx); ok {
+		metadata["entry_nodes"] = entry
+	}
 
 	checkpoint := &store.Checkpoint{
 		ID:        generateCheckpointID(),
 		NodeName:  nodeName,
 		State:     state,
 		Timestamp: time.Now(),
-		Version:   version,
 		Metadata:  metadata,
 	}
 
-	// Save checkpoint synchronously
-	_ = cl.store.Save(ctx, checkpoint)
+	// The steps after this checkpoint key their pending writes to it
+	var superseded string
+	if cl.step != nil {
+		superseded = cl.step.swap(checkpoint.ID)
+	}
+
+	// The run goes on changing its state while the checkpoint waits in the queue,
+	// and BeforeSave may change the state it is given
+	if cl.writer != nil || cl.hooks.before != nil {
+		checkpoint.State = cl.clone(state)
+	}
+
+	// The step has completed, so persist it even if the run is being cancelled,
+	// or has returned by the time the queued checkpoint is saved
+	if cl.writer == nil {
+		// Save checkpoint synchronously
+		_ = cl.persist(context.WithoutCancel(ctx), checkpoint, superseded)
+		return
+	}
+	cl.writer.enqueue(checkpointWrite{
+		checkpointID: checkpoint.ID,
+		nodeName:     nodeName,
+		save:         func() error { return cl.persist(context.WithoutCancel(ctx), checkpoint, superseded) },
+		batch:        cl.batch,
+		dropped:      func() { cl.notifyDropped(ctx, nodeName, checkpoint.State, checkpoint.ID) },
+	})
+}
+
+// notifyDropped reports the queued checkpoint of state that the writer dropped
+func (cl *CheckpointListener[S]) notifyDropped(ctx context.Context, nodeName string, state any, checkpointID string) {
+	if cl.notify != nil {
+		s, _ := state.(S)
+		cl.notify(ctx, NodeEventCheckpointDropped, nodeName, s, fmt.Errorf("checkpoint %s: %w", checkpointID, ErrCheckpointDropped))
+	}
+}
+
+// persist versions and saves checkpoint, then removes the pending writes of the
+// checkpoint it supersedes and the checkpoints that MaxCheckpoints and Retention
+// expire
+func (cl *CheckpointListener[S]) persist(ctx context.Context, checkpoint *store.Checkpoint, superseded string) error {
+	// Get current version from the latest checkpoint. Versions follow the thread
+	// when one is set, so resumed and forked threads keep increasing across executions.
+	checkpoint.Version = 1
+	if cl.threadID != "" {
+		if latest, err := cl.store.GetLatestByThread(ctx, cl.threadID); err == nil {
+			checkpoint.Version = latest.Version + 1
+		}
+	} else if checkpoints, err := store.ListPage(ctx, cl.store, cl.executionID, store.ListOptions{Limit: 1, Descending: true}); err == nil && len(checkpoints) > 0 {
+		checkpoint.Version = checkpoints[0].Version + 1
+	}
+
+	if err := cl.hooks.save(ctx, cl.store, checkpoint); err != nil {
+		if errors.Is(err, ErrCheckpointVetoed) {
+			if cl.notify != nil {
+				state, _ := checkpoint.State.(S)
+				cl.notify(ctx, NodeEventCheckpointSkipped, checkpoint.NodeName, state, err)
+			}
+			return nil
+		}
+		return err
+	}
+	cl.deleteSupersededWrites(ctx, superseded)
 
 	// Cleanup old checkpoints if MaxCheckpoints is set
 	if cl.maxCheckpoints > 0 {
 		cl.cleanupOldCheckpoints(ctx)
 	}
+
+	if !cl.retention.IsZero() {
+		cl.pruneCheckpoints(ctx)
+	}
+	return nil
+}
+
+// pruneCheckpoints deletes the checkpoints the retention policy expires
+func (cl *CheckpointListener[S]) pruneCheckpoints(ctx context.Context) {
+	checkpoints, err := cl.listOwnCheckpoints(ctx)
+	if err != nil {
+		return
+	}
+	for _, cp := range cl.retention.Expired(checkpoints, time.Now()) {
+		_ = cl.store.Delete(ctx, cp.ID)
+	}
 }
 
 // cleanupOldCheckpoints removes oldest checkpoints exceeding the max limit
 func (cl *CheckpointListener[S]) cleanupOldCheckpoints(ctx context.Context) {
-	// List checkpoints for this thread/execution
-	var checkpoints []*store.Checkpoint
-	var err error
-	if cl.threadID != "" {
-		checkpoints, err = cl.store.ListByThread(ctx, cl.threadID)
-	} else {
-		checkpoints, err = cl.store.List(ctx, cl.executionID)
-	}
+	checkpoints, err := cl.listOwnCheckpoints(ctx)
 	if err != nil || len(checkpoints) <= cl.maxCheckpoints {
 		return
 	}
@@ -147,6 +384,15 @@ func (cl *CheckpointListener[S]) cleanupOldCheckpoints(ctx context.Context) {
 	}
 }
 
+// listOwnCheckpoints lists the checkpoints MaxCheckpoints and Retention apply to:
+// those of the run's namespace on its thread, or of its execution without a thread
+func (cl *CheckpointListener[S]) listOwnCheckpoints(ctx context.Context) ([]*store.Checkpoint, error) {
+	if cl.threadID == "" {
+		return cl.store.List(ctx, cl.executionID)
+	}
+	return store.ListByNamespace(ctx, cl.store, cl.threadID, cl.namespace)
+}
+
 // CallbackHandler implementation for CheckpointListener is removed because CallbackHandler is untyped/legacy.
 // We rely on NodeListener[S].
 
@@ -174,13 +420,21 @@ func NewCheckpointableStateGraphWithConfig[S any](config CheckpointConfig) *Chec
 	}
 }
 
-// CompileCheckpointable compiles the graph into a checkpointable runnable
-func (g *CheckpointableStateGraph[S]) CompileCheckpointable() (*CheckpointableRunnable[S], error) {
-	listenableRunnable, err := g.CompileListenable()
+// CompileCheckpointable compiles the graph into a checkpointable runnable. With
+// AutoSave enabled, it pings the store (see store.Ping), so that a store that can't
+// be reached fails here rather than on the first save of a run.
+func (g *CheckpointableStateGraph[S]) CompileCheckpointable(opts ...CompileOption) (*CheckpointableRunnable[S], error) {
+	listenableRunnable, err := g.CompileListenable(opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	if g.config.AutoSave && g.config.Store != nil {
+		if err := store.Ping(context.Background(), g.config.Store); err != nil {
+			return nil, fmt.Errorf("checkpointing is enabled but CheckpointConfig.Store failed its health check: %w", err)
+		}
+	}
+
 	return NewCheckpointableRunnable(listenableRunnable, g.config), nil
 }
 
@@ -205,6 +459,9 @@ type CheckpointableRunnable[S any] struct {
 // NewCheckpointableRunnable creates a new checkpointable runnable from a listenable runnable
 func NewCheckpointableRunnable[S any](runnable *ListenableRunnable[S], config CheckpointConfig) *CheckpointableRunnable[S] {
 	executionID := generateExecutionID()
+	if config.Serializer != nil && config.Store != nil {
+		config.Store = newSerializingStore[S](config.Store, config.Serializer)
+	}
 	cr := &CheckpointableRunnable[S]{
 		runnable:    runnable,
 		config:      config,
@@ -218,6 +475,15 @@ func NewCheckpointableRunnable[S any](runnable *ListenableRunnable[S], config Ch
 		threadID:       "",
 		autoSave:       true,
 		maxCheckpoints: cr.config.MaxCheckpoints,
+		retention:      cr.config.Retention,
+		metadata:       cr.config.Metadata,
+		fingerprint:    runnable.Fingerprint(),
+		hooks:          checkpointHooks{before: config.BeforeSave, after: config.AfterSave},
+		notify:         runnable.runnable.nodeNotifier,
+		clone:          runnable.runnable.cloneState,
+	}
+	if config.Async {
+		cr.listener.writer = newCheckpointWriter(config.AsyncQueueSize, config.AsyncQueueFull)
 	}
 
 	// The listener will be added to config callbacks during invocation.
@@ -230,8 +496,14 @@ func (cr *CheckpointableRunnable[S]) Invoke(ctx context.Context, initialState S)
 	return cr.InvokeWithConfig(ctx, initialState, nil)
 }
 
-// InvokeWithConfig executes the graph with checkpointing support and config
+// InvokeWithConfig executes the graph with checkpointing support and config.
+// If initialState is a *Command with Resume set, the thread in config is resumed
+// with that value instead (see InvokeCommand).
 func (cr *CheckpointableRunnable[S]) InvokeWithConfig(ctx context.Context, initialState S, config *Config) (S, error) {
+	if cmd, ok := any(initialState).(*Command); ok && cmd.Resume != nil {
+		return cr.InvokeCommand(ctx, cmd, config)
+	}
+
 	// Extract thread_id from config if present
 	var threadID string
 	if config != nil && config.Configurable != nil {
@@ -240,50 +512,285 @@ func (cr *CheckpointableRunnable[S]) InvokeWithConfig(ctx context.Context, initi
 		}
 	}
 
+	// The config is copied so the caller's config is not modified by resuming
+	// or by adding the checkpoint listener
+	namespace, nested := runNamespace(ctx, config)
+	original := config
+	if config == nil {
+		config = &Config{}
+	} else {
+		runConfig := *config
+		config = &runConfig
+	}
+	if nested {
+		nestedRunConfig(ctx, config, original)
+	}
+	ctx = withCheckpointNamespace(ctx, namespace)
+	listener := cr.runListener(threadID, namespace)
+
 	// Auto-resume: if thread_id is provided, try to load the latest checkpoint
 	// and merge its state with the provided initialState (which may be just new input)
 	if threadID != "" {
 		// Only auto-resume if ResumeFrom is not explicitly set (manual control takes precedence)
-		if config == nil || config.ResumeFrom == nil {
-			if latestCP, err := cr.getLatestCheckpoint(ctx, threadID); err == nil && latestCP != nil {
+		if config.ResumeFrom == nil {
+			latestCP, err := cr.getLatestCheckpoint(ctx, threadID, namespace)
+			if errors.Is(err, ErrStateTypeMismatch) {
+				var zero S
+				return zero, err
+			}
+			if err == nil && latestCP != nil {
 				// Found existing checkpoint - this is a resume
-				checkpointState, ok := latestCP.State.(S)
-				if ok {
-					// Merge checkpoint state with new input using Schema
-					initialState = cr.mergeStates(ctx, checkpointState, initialState)
-
-					// Check if the checkpoint is at END (completed execution)
-					// Note: NodeName is empty when checkpoint is created at END or via other means
-					if latestCP.NodeName == "" || latestCP.NodeName == END {
-						// Graph has completed - just return the merged state
-						// No need to re-execute anything
-						return initialState, nil
-					}
+				if err := cr.checkFingerprint(threadID, latestCP); err != nil {
+					var zero S
+					return zero, err
+				}
+				checkpointState, err := cr.checkpointState(latestCP)
+				if err != nil {
+					var zero S
+					return zero, err
+				}
+				// Merge checkpoint state with new input using Schema
+				initialState = cr.mergeStates(ctx, checkpointState, initialState)
+
+				// Check if the checkpoint is at END (completed execution)
+				// Note: NodeName is empty when checkpoint is created at END or via other means
+				if latestCP.NodeName == "" || latestCP.NodeName == END {
+					// Graph has completed - just return the merged state
+					// No need to re-execute anything
+					return initialState, nil
+				}
 
-					// For incomplete checkpoints (interrupted), set ResumeFrom to continue
-					// The graph will continue execution from the checkpoint node
-					if config == nil {
-						config = &Config{}
+				// For incomplete checkpoints (interrupted), set ResumeFrom to continue
+				// The graph will continue execution from the checkpoint node, or
+				// re-run the nodes of a pending step
+				config.ResumeFrom = []string{latestCP.NodeName}
+				resumeInterrupt(config, latestCP)
+				if pending, ok := metadataNodes(latestCP.Metadata["pending_nodes"]); ok {
+					config.ResumeFrom = pending
+					if ctx, err = cr.resumeWrites(ctx, listener, latestCP); err != nil {
+						var zero S
+						return zero, err
 					}
-					config.ResumeFrom = []string{latestCP.NodeName}
 				}
+				ctx = withCheckpointEntryNodes(ctx, latestCP)
 			}
 		}
 	}
 
-	// Update checkpoint listener with thread_id
-	if cr.listener != nil {
-		cr.listener.threadID = threadID
-		cr.listener.autoSave = cr.config.AutoSave
+	// Add the listener to config callbacks
+	config.Callbacks = append(slices.Clone(config.Callbacks), listener)
+
+	return cr.invoke(ctx, listener, initialState, config, nested)
+}
+
+// InvokeCommand resumes the thread identified by config's thread_id from its latest
+// checkpoint. cmd.Update, if set, is merged into the checkpointed state, and
+// cmd.Resume is returned by the Interrupt() call that paused the thread.
+// Execution continues from the node that raised the interrupt, or from the nodes
+// scheduled after the checkpointed step when the run stopped at a breakpoint.
+//
+// Example:
+//
+//	result, err := runnable.InvokeCommand(ctx, &graph.Command{Resume: "approved"}, graph.WithThreadID("t1"))
+func (cr *CheckpointableRunnable[S]) InvokeCommand(ctx context.Context, cmd *Command, config *Config) (S, error) {
+	var zero S
+
+	var threadID string
+	if config != nil && config.Configurable != nil {
+		threadID, _ = config.Configurable["thread_id"].(string)
+	}
+	if threadID == "" {
+		return zero, fmt.Errorf("resuming with a Command requires a thread_id")
 	}
+	namespace, nested := runNamespace(ctx, config)
 
-	// Add the listener to config callbacks
-	if config == nil {
-		config = &Config{}
+	latestCP, err := cr.getLatestCheckpoint(ctx, threadID, namespace)
+	if err != nil {
+		return zero, fmt.Errorf("failed to load checkpoint for thread %s: %w", threadID, err)
+	}
+	if latestCP == nil {
+		return zero, fmt.Errorf("no checkpoints found for thread %s", threadID)
+	}
+	if err := cr.checkFingerprint(threadID, latestCP); err != nil {
+		return zero, err
+	}
+
+	state, err := cr.checkpointState(latestCP)
+	if err != nil {
+		return zero, err
+	}
+	if cmd.Update != nil {
+		update, ok := cmd.Update.(S)
+		if !ok {
+			return zero, fmt.Errorf("command update has type %T, expected %T", cmd.Update, zero)
+		}
+		state = cr.mergeStates(ctx, state, update)
+	}
+
+	resumeFrom := checkpointResumeNodes(latestCP)
+	if len(resumeFrom) == 0 {
+		return zero, fmt.Errorf("thread %s has no pending nodes to resume", threadID)
+	}
+
+	resumeConfig := *config
+	if nested {
+		nestedRunConfig(ctx, &resumeConfig, config)
+	}
+	resumeConfig.ResumeFrom = resumeFrom
+	resumeConfig.ResumeValue = cmd.Resume
+	resumeInterrupt(&resumeConfig, latestCP)
+
+	listener := cr.runListener(threadID, namespace)
+	resumeConfig.Callbacks = append(slices.Clone(resumeConfig.Callbacks), listener)
+	if ctx, err = cr.resumeWrites(ctx, listener, latestCP); err != nil {
+		return zero, err
+	}
+
+	ctx = withCheckpointNamespace(ctx, namespace)
+	return cr.invoke(withCheckpointEntryNodes(ctx, latestCP), listener, state, &resumeConfig, nested)
+}
+
+// invoke runs the graph with the checkpoint listener of the run and, with Async
+// checkpointing, waits for the checkpoints of the run to be saved, returning the
+// errors saving them with the run's. Runs on other threads neither wait for them
+// nor get their errors. The interrupt of a nested run interrupts the node of the
+// parent run that started it.
+func (cr *CheckpointableRunnable[S]) invoke(ctx context.Context, listener *CheckpointListener[S], state S, config *Config, nested bool) (S, error) {
+	result, err := cr.runnable.InvokeWithConfig(ctx, state, config)
+	if nested {
+		err = nestedInterrupt(err)
+	}
+	// The steps have completed, so wait for their checkpoints even if the run is
+	// being cancelled
+	if cr.listener.writer != nil {
+		if flushErr := cr.listener.writer.flushBatch(context.WithoutCancel(ctx), listener.batch); flushErr != nil {
+			err = errors.Join(err, flushErr)
+		}
+	}
+	return result, err
+}
+
+// Flush waits until the checkpoints that Async checkpointing queued are saved, and
+// returns the errors saving them that neither the previous Flush nor the runs
+// that queued them returned. It returns nil at once
+// without Async. Invocations flush before returning, so Flush is for waiting on
+// the checkpoints of runs in progress on other goroutines, for example before
+// shutting down.
+func (cr *CheckpointableRunnable[S]) Flush(ctx context.Context) error {
+	if cr.listener.writer == nil {
+		return nil
+	}
+	return cr.listener.writer.flush(ctx)
+}
+
+// runListener returns a copy of the checkpoint listener for a run on threadID in
+// namespace, so concurrent runs on different threads don't overwrite each other's
+// settings
+func (cr *CheckpointableRunnable[S]) runListener(threadID, namespace string) *CheckpointListener[S] {
+	listener := *cr.listener
+	listener.threadID = threadID
+	if threadID != "" {
+		listener.namespace = namespace
+	}
+	listener.autoSave = cr.config.AutoSave
+	listener.savePending = cr.config.SavePending
+	listener.saveWrites = cr.config.SaveWrites
+	listener.step = &stepWrites{}
+	listener.batch = &checkpointBatch{}
+	return &listener
+}
+
+// checkpointResumeNodes returns the nodes to run when resuming from checkpoint:
+// the node that raised an interrupt, otherwise the nodes scheduled after the step.
+// Checkpoints saved without that metadata fall back to re-running NodeName.
+func checkpointResumeNodes(checkpoint *store.Checkpoint) []string {
+	if nodes, ok := recordedResumeNodes(checkpoint); ok {
+		return nodes
+	}
+
+	if checkpoint.NodeName == "" || checkpoint.NodeName == END {
+		return nil
+	}
+	return []string{checkpoint.NodeName}
+}
+
+// recordedResumeNodes returns the nodes checkpoint records to resume at, the nodes
+// that raised an interrupt, the nodes of a pending step or the nodes scheduled
+// after the step, and whether it records any
+func recordedResumeNodes(checkpoint *store.Checkpoint) ([]string, bool) {
+	if nodes, ok := interruptNodes(checkpoint); ok {
+		return nodes, true
+	}
+	if pending, ok := metadataNodes(checkpoint.Metadata["pending_nodes"]); ok {
+		return pending, true
+	}
+	return metadataNodes(checkpoint.Metadata["next_nodes"])
+}
+
+// interruptNodes returns the nodes that raised the interrupt checkpoint was saved
+// for, the first one first, and whether it was saved for an interrupt
+func interruptNodes(checkpoint *store.Checkpoint) ([]string, bool) {
+	if nodes, ok := metadataNodes(checkpoint.Metadata["interrupt_nodes"]); ok && len(nodes) > 0 {
+		return nodes, true
+	}
+	if node, ok := checkpoint.Metadata["interrupt_node"].(string); ok && node != "" {
+		return []string{node}, true
+	}
+	return nil, false
+}
+
+// resumeInterrupt prepares config to resume the interrupt checkpoint was saved
+// for, if any: the resume value is for the node that raised it, the other nodes
+// that raised an interrupt in the step run again with it, and the nodes the rest
+// of the step led to run after them
+func resumeInterrupt(config *Config, checkpoint *store.Checkpoint) {
+	nodes, ok := interruptNodes(checkpoint)
+	if !ok {
+		return
+	}
+	if slices.Equal(config.ResumeFrom, nodes[:1]) {
+		config.ResumeFrom = nodes
+	}
+	config.resumeNodes = nodes[:1]
+	config.resumeSuccessors, _ = metadataNodes(checkpoint.Metadata["interrupt_successors"])
+}
+
+// metadataNodes returns the nodes, other than END, of a list of nodes in checkpoint
+// metadata, and whether value is such a list
+func metadataNodes(value any) ([]string, bool) {
+	switch list := value.(type) {
+	case []string:
+		return slices.DeleteFunc(slices.Clone(list), func(n string) bool { return n == END }), true
+	case []any:
+		// Stores that round-trip metadata through JSON decode lists as []any
+		var nodes []string
+		for _, n := range list {
+			if name, ok := n.(string); ok && name != END {
+				nodes = append(nodes, name)
+			}
+		}
+		return nodes, true
 	}
-	config.Callbacks = append(config.Callbacks, cr.listener)
+	return nil, false
+}
 
-	return cr.runnable.InvokeWithConfig(ctx, initialState, config)
+// withCheckpointEntryNodes carries the entry recorded in checkpoint over to the run
+// resuming from it, so its checkpoints keep recording the entry the thread started with
+func withCheckpointEntryNodes(ctx context.Context, checkpoint *store.Checkpoint) context.Context {
+	switch entry := checkpoint.Metadata["entry_nodes"].(type) {
+	case []string:
+		return withEntryNodes(ctx, entry)
+	case []any:
+		// Stores that round-trip metadata through JSON decode lists as []any
+		var nodes []string
+		for _, n := range entry {
+			if name, ok := n.(string); ok {
+				nodes = append(nodes, name)
+			}
+		}
+		return withEntryNodes(ctx, nodes)
+	}
+	return ctx
 }
 
 // Stream executes the graph with checkpointing and streaming support
@@ -301,36 +808,10 @@ type StateSnapshot struct {
 	ParentID  string
 }
 
-// getLatestCheckpoint retrieves the latest checkpoint for a given thread_id.
-// It first tries to use the optimized GetLatestByThread method, and falls back
-// to the List method for stores that don't implement it.
-func (cr *CheckpointableRunnable[S]) getLatestCheckpoint(ctx context.Context, threadID string) (*store.Checkpoint, error) {
-	// Try to use the optimized GetLatestByThread method first
-	if latestGetter, ok := cr.config.Store.(interface {
-		GetLatestByThread(ctx context.Context, threadID string) (*store.Checkpoint, error)
-	}); ok {
-		return latestGetter.GetLatestByThread(ctx, threadID)
-	}
-
-	// Fallback to List method for stores that don't implement GetLatestByThread
-	checkpoints, err := cr.config.Store.List(ctx, threadID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
-	}
-
-	if len(checkpoints) == 0 {
-		return nil, fmt.Errorf("no checkpoints found for thread %s", threadID)
-	}
-
-	// Get the latest checkpoint (highest version)
-	latest := checkpoints[0]
-	for _, cp := range checkpoints {
-		if cp.Version > latest.Version {
-			latest = cp
-		}
-	}
-
-	return latest, nil
+// getLatestCheckpoint retrieves the latest checkpoint of a thread_id in a
+// checkpoint namespace, skipping those of the graphs nested in its nodes
+func (cr *CheckpointableRunnable[S]) getLatestCheckpoint(ctx context.Context, threadID, namespace string) (*store.Checkpoint, error) {
+	return store.GetLatestByNamespace(ctx, cr.config.Store, threadID, namespace)
 }
 
 // mergeStates merges the checkpoint state with new input using the graph's Schema.
@@ -377,32 +858,9 @@ func (cr *CheckpointableRunnable[S]) GetState(ctx context.Context, config *Confi
 	if checkpointID != "" {
 		checkpoint, err = cr.config.Store.Load(ctx, checkpointID)
 	} else if threadID != "" {
-		// Try to use the optimized GetLatestByThread method first
-		if latestGetter, ok := cr.config.Store.(interface {
-			GetLatestByThread(ctx context.Context, threadID string) (*store.Checkpoint, error)
-		}); ok {
-			checkpoint, err = latestGetter.GetLatestByThread(ctx, threadID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get latest checkpoint by thread: %w", err)
-			}
-		} else {
-			// Fallback to List method for stores that don't implement GetLatestByThread
-			checkpoints, err := cr.config.Store.List(ctx, threadID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to list checkpoints: %w", err)
-			}
-
-			if len(checkpoints) == 0 {
-				return nil, fmt.Errorf("no checkpoints found for thread %s", threadID)
-			}
-
-			// Get the latest checkpoint (highest version)
-			checkpoint = checkpoints[0]
-			for _, cp := range checkpoints {
-				if cp.Version > checkpoint.Version {
-					checkpoint = cp
-				}
-			}
+		checkpoint, err = cr.getLatestCheckpoint(ctx, threadID, configNamespace(config))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest checkpoint by thread: %w", err)
 		}
 	}
 
@@ -419,15 +877,17 @@ func (cr *CheckpointableRunnable[S]) GetState(ctx context.Context, config *Confi
 	if checkpoint.NodeName == "" {
 		next = []string{}
 	}
+	configurable := map[string]any{
+		"thread_id":     threadID,
+		"checkpoint_id": checkpoint.ID,
+	}
+	if namespace := store.Namespace(checkpoint); namespace != store.RootNamespace {
+		configurable[store.NamespaceMetadataKey] = namespace
+	}
 	return &StateSnapshot{
-		Values: checkpoint.State,
-		Next:   next,
-		Config: Config{
-			Configurable: map[string]any{
-				"thread_id":     threadID,
-				"checkpoint_id": checkpoint.ID,
-			},
-		},
+		Values:    checkpoint.State,
+		Next:      next,
+		Config:    Config{Configurable: configurable},
 		Metadata:  checkpoint.Metadata,
 		CreatedAt: checkpoint.Timestamp,
 	}, nil
@@ -446,6 +906,9 @@ func (cr *CheckpointableRunnable[S]) SaveCheckpoint(ctx context.Context, nodeNam
 		}
 	}
 
+	if cr.listener.hooks.before != nil {
+		state = cr.listener.clone(state)
+	}
 	checkpoint := &store.Checkpoint{
 		ID:        generateCheckpointID(),
 		NodeName:  nodeName,
@@ -453,13 +916,15 @@ func (cr *CheckpointableRunnable[S]) SaveCheckpoint(ctx context.Context, nodeNam
 		Timestamp: time.Now(),
 		Version:   version,
 		Metadata: map[string]any{
-			"execution_id": cr.executionID,
-			"source":       "manual_save",
-			"saved_by":     nodeName,
+			"execution_id":         cr.executionID,
+			"source":               "manual_save",
+			"saved_by":             nodeName,
+			fingerprintMetadataKey: cr.listener.fingerprint,
+			stateTypeMetadataKey:   stateTypeName[S](),
 		},
 	}
 
-	return cr.config.Store.Save(ctx, checkpoint)
+	return cr.listener.hooks.save(ctx, cr.config.Store, checkpoint)
 }
 
 // ListCheckpoints lists all checkpoints for the current execution
@@ -520,6 +985,9 @@ func (cr *CheckpointableRunnable[S]) UpdateState(ctx context.Context, config *Co
 		// Default: Replace
 		newState = values
 	}
+	if cr.listener.hooks.before != nil {
+		newState = cr.listener.clone(newState)
+	}
 
 	// Get max version
 	checkpoints, _ := cr.config.Store.List(ctx, threadID)
@@ -538,20 +1006,50 @@ func (cr *CheckpointableRunnable[S]) UpdateState(ctx context.Context, config *Co
 		Timestamp: time.Now(),
 		Version:   version,
 		Metadata: map[string]any{
-			"execution_id": threadID,
-			"source":       "update_state",
-			"updated_by":   asNode,
+			"execution_id":             threadID,
+			"thread_id":                threadID,
+			"source":                   "update_state",
+			"updated_by":               asNode,
+			fingerprintMetadataKey:     cr.listener.fingerprint,
+			stateTypeMetadataKey:       stateTypeName[S](),
+			store.NamespaceMetadataKey: configNamespace(config),
 		},
 	}
 
-	if err := cr.config.Store.Save(ctx, checkpoint); err != nil {
+	if err := cr.listener.hooks.save(ctx, cr.config.Store, checkpoint); err != nil {
 		return nil, err
 	}
 
+	configurable := map[string]any{
+		"thread_id":     threadID,
+		"checkpoint_id": checkpoint.ID,
+	}
+	if namespace := configNamespace(config); namespace != store.RootNamespace {
+		configurable[store.NamespaceMetadataKey] = namespace
+	}
+	return &Config{Configurable: configurable}, nil
+}
+
+// ForkThread copies the checkpoints of sourceThreadID up to and including checkpointID
+// into newThreadID, leaving the source thread untouched. Invoking with the returned
+// config resumes the new thread from the forked checkpoint, which allows replaying
+// "what if" scenarios; use UpdateState on the returned config to modify the state first.
+//
+// Example:
+//
+//	forkConfig, err := runnable.ForkThread(ctx, "thread-a", checkpointID, "thread-b")
+//	forkConfig, err = runnable.UpdateState(ctx, forkConfig, "review", map[string]any{"approved": false})
+//	result, err := runnable.InvokeWithConfig(ctx, nil, graph.WithThreadID("thread-b"))
+func (cr *CheckpointableRunnable[S]) ForkThread(ctx context.Context, sourceThreadID, checkpointID, newThreadID string) (*Config, error) {
+	forked, err := store.ForkThread(ctx, cr.config.Store, sourceThreadID, checkpointID, newThreadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork thread: %w", err)
+	}
+
 	return &Config{
 		Configurable: map[string]any{
-			"thread_id":     threadID,
-			"checkpoint_id": checkpoint.ID,
+			"thread_id":     newThreadID,
+			"checkpoint_id": forked[len(forked)-1].ID,
 		},
 	}, nil
 }
@@ -595,6 +1093,11 @@ func (cr *CheckpointableRunnable[S]) GetGraph() *ListenableStateGraph[S] {
 	return cr.runnable.GetListenableGraph()
 }
 
+// CompileReport returns the warnings found when the graph was compiled.
+func (cr *CheckpointableRunnable[S]) CompileReport() *CompileReport {
+	return cr.runnable.CompileReport()
+}
+
 // Helper functions
 func generateExecutionID() string {
 	return fmt.Sprintf("exec_%d", time.Now().UnixNano())
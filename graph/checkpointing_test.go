@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/smallnest/langgraphgo/graph"
 	st "github.com/smallnest/langgraphgo/store"
 	"github.com/smallnest/langgraphgo/store/redis"
@@ -1059,6 +1060,73 @@ func TestAutoResume_InterruptAndResume(t *testing.T) {
 	}
 }
 
+// TestAutoResume_ConditionalEntry tests that a resumed thread keeps the entry
+// chosen by its first run, even when the new input would route differently.
+func TestAutoResume_ConditionalEntry(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewCheckpointableStateGraph[map[string]any]()
+
+	executionCount := map[string]int{}
+	for _, name := range []string{"quick_node", "full_pipeline", "finish"} {
+		g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			executionCount[name]++
+			state[name] = "done"
+			return state, nil
+		})
+	}
+	g.SetConditionalEntryPoint(func(ctx context.Context, state map[string]any) string {
+		mode, _ := state["mode"].(string)
+		return mode
+	}, map[string]string{
+		"quick": "quick_node",
+		"full":  "full_pipeline",
+	})
+	g.AddEdge("quick_node", "finish")
+	g.AddEdge("full_pipeline", "finish")
+	g.AddEdge("finish", graph.END)
+
+	runnable, err := g.CompileCheckpointable()
+	if err != nil {
+		t.Fatalf("Failed to compile: %v", err)
+	}
+	runnable.SetExecutionID("test_conditional_entry")
+
+	ctx := context.Background()
+	threadID := "test-thread-conditional-entry"
+
+	config1 := graph.WithThreadID(threadID)
+	config1.InterruptAfter = []string{"quick_node"}
+	_, err = runnable.InvokeWithConfig(ctx, map[string]any{"mode": "quick"}, config1)
+	if _, ok := err.(*graph.GraphInterrupt); !ok {
+		t.Fatalf("Phase 1 expected a GraphInterrupt, got: %v", err)
+	}
+
+	result, err := runnable.InvokeWithConfig(ctx, map[string]any{"mode": "full"}, graph.WithThreadID(threadID))
+	if err != nil {
+		t.Fatalf("Phase 2 execution failed: %v", err)
+	}
+	if result["finish"] != "done" {
+		t.Errorf("Phase 2 should complete finish: %v", result)
+	}
+	if executionCount["full_pipeline"] != 0 {
+		t.Errorf("Resume should not re-route to full_pipeline: %v", executionCount)
+	}
+
+	checkpoints, err := runnable.ListCheckpoints(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list checkpoints: %v", err)
+	}
+	if len(checkpoints) < 2 {
+		t.Fatalf("Expected checkpoints from both phases, got %d", len(checkpoints))
+	}
+	for _, cp := range checkpoints {
+		if entry, _ := cp.Metadata["entry_nodes"].([]string); len(entry) != 1 || entry[0] != "quick_node" {
+			t.Errorf("Checkpoint %s should record entry quick_node, got %v", cp.NodeName, cp.Metadata["entry_nodes"])
+		}
+	}
+}
+
 // TestAutoResume_MergeStates tests that state merging works correctly
 // when resuming with new input.
 func TestAutoResume_MergeStates(t *testing.T) {
@@ -1114,6 +1182,99 @@ func TestAutoResume_MergeStates(t *testing.T) {
 	}
 }
 
+// TestCheckpointableRunnable_ForkThread tests forking a thread from an earlier checkpoint
+// and continuing the fork with a modified state.
+func TestCheckpointableRunnable_ForkThread(t *testing.T) {
+	t.Parallel()
+
+	stores := map[string]func(t *testing.T) graph.CheckpointStore{
+		"Memory": func(t *testing.T) graph.CheckpointStore {
+			return graph.NewMemoryCheckpointStore()
+		},
+		"File": func(t *testing.T) graph.CheckpointStore {
+			s, err := graph.NewFileCheckpointStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("Failed to create file store: %v", err)
+			}
+			return s
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config := graph.DefaultCheckpointConfig()
+			config.Store = newStore(t)
+			config.MaxCheckpoints = 0
+			g := graph.NewCheckpointableStateGraphWithConfig[map[string]any](config)
+			g.SetSchema(graph.NewMapSchema())
+
+			executions := map[string]int{}
+			for _, step := range []string{"step1", "step2", "step3"} {
+				g.AddNode(step, step, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+					executions[step]++
+					return map[string]any{step: "done"}, nil
+				})
+			}
+			g.AddEdge("step1", "step2")
+			g.AddEdge("step2", "step3")
+			g.AddEdge("step3", graph.END)
+			g.SetEntryPoint("step1")
+
+			runnable, err := g.CompileCheckpointable()
+			if err != nil {
+				t.Fatalf("Failed to compile: %v", err)
+			}
+
+			ctx := context.Background()
+			if _, err := runnable.InvokeWithConfig(ctx, map[string]any{"input": "a"}, graph.WithThreadID("thread-a")); err != nil {
+				t.Fatalf("Execution on thread-a failed: %v", err)
+			}
+
+			original, err := config.Store.ListByThread(ctx, "thread-a")
+			if err != nil || len(original) != 3 {
+				t.Fatalf("Expected 3 checkpoints on thread-a, got %d (err=%v)", len(original), err)
+			}
+
+			forkConfig, err := runnable.ForkThread(ctx, "thread-a", original[1].ID, "thread-b")
+			if err != nil {
+				t.Fatalf("ForkThread failed: %v", err)
+			}
+			if forkConfig.Configurable["thread_id"] != "thread-b" {
+				t.Errorf("Expected config for thread-b, got %v", forkConfig.Configurable)
+			}
+
+			if _, err := runnable.UpdateState(ctx, forkConfig, "step2", map[string]any{"what_if": "yes"}); err != nil {
+				t.Fatalf("UpdateState on fork failed: %v", err)
+			}
+
+			result, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread-b"))
+			if err != nil {
+				t.Fatalf("Execution on thread-b failed: %v", err)
+			}
+
+			if result["what_if"] != "yes" || result["step1"] != "done" || result["step3"] != "done" {
+				t.Errorf("Unexpected forked result: %v", result)
+			}
+			if executions["step1"] != 1 {
+				t.Errorf("step1 should not run again on the fork, ran %d times", executions["step1"])
+			}
+
+			after, err := config.Store.ListByThread(ctx, "thread-a")
+			if err != nil || len(after) != len(original) {
+				t.Fatalf("Expected thread-a to keep %d checkpoints, got %d (err=%v)", len(original), len(after), err)
+			}
+			for i, cp := range after {
+				state := cp.State.(map[string]any)
+				if cp.ID != original[i].ID || state["what_if"] != nil {
+					t.Errorf("thread-a checkpoint %d was modified: %+v", i, cp)
+				}
+			}
+		})
+	}
+}
+
 // TestWithThreadID tests the WithThreadID helper function
 func TestWithThreadID(t *testing.T) {
 	t.Parallel()
@@ -1386,3 +1547,252 @@ func TestMaxCheckpoints_AutoCleanup_RedisStore(t *testing.T) {
 		t.Errorf("Expected latest checkpoint by thread to be step5")
 	}
 }
+
+// TestRetentionPolicy_AutoSave tests that the retention policy prunes each thread
+// after automatic saves, on every store
+func TestRetentionPolicy_AutoSave(t *testing.T) {
+	t.Parallel()
+
+	stores := map[string]func(t *testing.T) st.CheckpointStore{
+		"Memory": func(t *testing.T) st.CheckpointStore {
+			return graph.NewMemoryCheckpointStore()
+		},
+		"File": func(t *testing.T) st.CheckpointStore {
+			store, err := graph.NewFileCheckpointStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("Failed to create file store: %v", err)
+			}
+			return store
+		},
+		"Redis": func(t *testing.T) st.CheckpointStore {
+			mr := miniredis.RunT(t)
+			return redis.NewRedisCheckpointStore(redis.RedisOptions{Addr: mr.Addr()})
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			store := newStore(t)
+
+			g := graph.NewCheckpointableStateGraph[map[string]any]()
+			for i := 1; i <= 4; i++ {
+				nodeName := fmt.Sprintf("step%d", i)
+				g.AddNode(nodeName, nodeName, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+					state[nodeName] = "done"
+					return state, nil
+				})
+				if i > 1 {
+					g.AddEdge(fmt.Sprintf("step%d", i-1), nodeName)
+				}
+			}
+			g.AddEdge("step4", graph.END)
+			g.SetEntryPoint("step1")
+			g.SetCheckpointConfig(graph.CheckpointConfig{
+				Store:     store,
+				AutoSave:  true,
+				Retention: graph.RetentionPolicy{MaxPerThread: 2},
+			})
+
+			runnable, err := g.CompileCheckpointable()
+			if err != nil {
+				t.Fatalf("Failed to compile: %v", err)
+			}
+
+			for _, threadID := range []string{"thread-1", "thread-2"} {
+				if _, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID(threadID)); err != nil {
+					t.Fatalf("Execution failed: %v", err)
+				}
+			}
+
+			for threadID, want := range map[string][]int{"thread-1": {3, 4}, "thread-2": {3, 4}} {
+				checkpoints, err := store.ListByThread(ctx, threadID)
+				if err != nil {
+					t.Fatalf("Failed to list checkpoints: %v", err)
+				}
+				var versions []int
+				for _, cp := range checkpoints {
+					versions = append(versions, cp.Version)
+				}
+				if !slices.Equal(versions, want) {
+					t.Errorf("Expected %s versions %v, got %v", threadID, want, versions)
+				}
+			}
+
+			latest, err := store.GetLatestByThread(ctx, "thread-1")
+			if err != nil {
+				t.Fatalf("Failed to get latest checkpoint: %v", err)
+			}
+			if latest.NodeName != "step4" {
+				t.Errorf("Expected latest checkpoint to be step4, got %s", latest.NodeName)
+			}
+		})
+	}
+}
+
+// TestCheckpointConfig_Metadata tests that configured metadata is stamped on
+// automatically saved checkpoints without overriding the graph's own keys
+func TestCheckpointConfig_Metadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := graph.NewMemoryCheckpointStore()
+
+	g := graph.NewCheckpointableStateGraph[map[string]any]()
+	g.AddNode("a", "a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return state, nil
+	})
+	g.AddNode("b", "b", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return state, nil
+	})
+	g.AddEdge("a", "b")
+	g.AddEdge("b", graph.END)
+	g.SetEntryPoint("a")
+	g.SetCheckpointConfig(graph.CheckpointConfig{
+		Store:    store,
+		AutoSave: true,
+		Metadata: map[string]any{"user_id": 42, "graph_name": "onboarding", "thread_id": "ignored"},
+	})
+
+	runnable, err := g.CompileCheckpointable()
+	if err != nil {
+		t.Fatalf("Failed to compile: %v", err)
+	}
+	for _, threadID := range []string{"thread-1", "thread-2"} {
+		if _, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID(threadID)); err != nil {
+			t.Fatalf("Execution failed: %v", err)
+		}
+	}
+
+	checkpoints, err := st.ListByMetadata(ctx, store, map[string]any{"user_id": 42, "graph_name": "onboarding"},
+		st.ListOptions{Limit: 1, Descending: true})
+	if err != nil {
+		t.Fatalf("ListByMetadata failed: %v", err)
+	}
+	if len(checkpoints) != 1 {
+		t.Fatalf("Expected 1 checkpoint, got %d", len(checkpoints))
+	}
+	latest := checkpoints[0]
+	if latest.Metadata["thread_id"] != "thread-2" || latest.NodeName != "b" {
+		t.Errorf("Expected the latest checkpoint to be node b of thread-2, got node %s of %v", latest.NodeName, latest.Metadata["thread_id"])
+	}
+
+	all, err := st.ListByMetadata(ctx, store, map[string]any{"graph_name": "onboarding"}, st.ListOptions{})
+	if err != nil {
+		t.Fatalf("ListByMetadata failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("Expected every auto-saved checkpoint to carry the metadata, got %d", len(all))
+	}
+}
+
+func TestCheckpointConfig_SavePending(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := graph.NewMemoryCheckpointStore()
+
+	var runs []string
+	crash := true
+	g := graph.NewCheckpointableStateGraph[map[string]any]()
+	g.SetSchema(graph.NewMapSchema())
+	g.AddNode("a", "a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		runs = append(runs, "a")
+		return map[string]any{"a": "done"}, nil
+	})
+	g.AddNode("b", "b", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		runs = append(runs, "b")
+		// Changes made before the crash must not leak into the pending checkpoint
+		state["partial"] = true
+		if crash {
+			return nil, fmt.Errorf("crashed")
+		}
+		return map[string]any{"b": "done"}, nil
+	})
+	g.AddNode("c", "c", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		runs = append(runs, "c")
+		return map[string]any{"c": "done"}, nil
+	})
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", graph.END)
+	g.SetEntryPoint("a")
+	g.SetCheckpointConfig(graph.CheckpointConfig{Store: store, AutoSave: true, SavePending: true})
+
+	runnable, err := g.CompileCheckpointable()
+	if err != nil {
+		t.Fatalf("Failed to compile: %v", err)
+	}
+	if _, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread")); err == nil {
+		t.Fatal("Expected node b to fail")
+	}
+
+	checkpoints, err := store.ListByThread(ctx, "thread")
+	if err != nil {
+		t.Fatalf("ListByThread failed: %v", err)
+	}
+	var saved []string
+	for _, cp := range checkpoints {
+		saved = append(saved, fmt.Sprintf("%s:%s", cp.NodeName, cp.Metadata["status"]))
+	}
+	if want := []string{"a:pending", "a:completed", "b:pending"}; !slices.Equal(saved, want) {
+		t.Fatalf("Expected checkpoints %v, got %v", want, saved)
+	}
+	pending := checkpoints[len(checkpoints)-1]
+	if !graph.IsPendingCheckpoint(pending) || graph.IsPendingCheckpoint(checkpoints[1]) {
+		t.Error("Expected only the checkpoints saved before a step to be pending")
+	}
+	if state := pending.State.(map[string]any); state["a"] != "done" || state["partial"] != nil {
+		t.Errorf("Expected the pending checkpoint to hold the input of node b, got %v", state)
+	}
+
+	// Recovery re-runs the node that crashed, on the state it received
+	crash = false
+	res, err := runnable.ResumeLatest(ctx, "thread")
+	if err != nil {
+		t.Fatalf("ResumeLatest failed: %v", err)
+	}
+	if want := []string{"a", "b", "b", "c"}; !slices.Equal(runs, want) {
+		t.Errorf("Expected nodes %v to run, got %v", want, runs)
+	}
+	if res["b"] != "done" || res["c"] != "done" {
+		t.Errorf("Expected the thread to complete, got %v", res)
+	}
+}
+
+func TestCompileCheckpointable_PingsStore(t *testing.T) {
+	t.Parallel()
+
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	unreachable := redis.NewRedisCheckpointStore(redis.RedisOptions{Addr: addr})
+	defer unreachable.Close()
+	mr.Close()
+
+	newGraph := func(config graph.CheckpointConfig) *graph.CheckpointableStateGraph[map[string]any] {
+		g := graph.NewCheckpointableStateGraphWithConfig[map[string]any](config)
+		g.AddNode("a", "a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		})
+		g.AddEdge("a", graph.END)
+		g.SetEntryPoint("a")
+		return g
+	}
+
+	_, err := newGraph(graph.CheckpointConfig{Store: unreachable, AutoSave: true}).CompileCheckpointable()
+	if err == nil {
+		t.Fatal("Expected compiling with an unreachable store to fail")
+	}
+	for _, want := range []string{"CheckpointConfig.Store", "redis checkpoint store at " + addr} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to contain %q, got %q", want, err)
+		}
+	}
+
+	// Without AutoSave the store is only used on demand
+	if _, err := newGraph(graph.CheckpointConfig{Store: unreachable}).CompileCheckpointable(); err != nil {
+		t.Errorf("Expected compiling without AutoSave to succeed, got %v", err)
+	}
+}
@@ -8,7 +8,17 @@ type Command struct {
 	Update any
 
 	// Goto specifies the next node(s) to execute.
-	// If set, it overrides the graph's edges.
-	// Can be a single string (node name) or []string.
+	// If set, it overrides the edges of the node that returned the Command;
+	// other nodes in the same step keep following their own edges.
+	// Can be a single string (node name) or []string to fan out to several
+	// nodes, whose results are merged by the schema's reducers. END (alone or
+	// in the list) terminates the branch after Update is applied.
+	// Targets are checked at runtime: unknown names fail with ErrNodeNotFound.
 	Goto any
+
+	// Resume is the value returned by the Interrupt() call that paused a thread.
+	// Passing a Command with Resume set as the initial state of a checkpointable
+	// runnable continues the thread from its latest checkpoint instead of starting
+	// a new run (see CheckpointableRunnable.InvokeCommand).
+	Resume any
 }
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,3 +75,106 @@ func TestCommandGoto(t *testing.T) {
 	// Expected: 0 + 1 (A) + 100 (C) = 101. B is skipped.
 	assert.Equal(t, 101, mRes["count"])
 }
+
+func newCommandCountGraph() *StateGraph[any] {
+	g := NewStateGraph[any]()
+	schema := NewMapSchema()
+	schema.RegisterReducer("count", sumCountReducer)
+	g.SetSchema(&mapSchemaAdapterForAny{MapSchema: schema})
+	return g
+}
+
+// sumCountReducer adds integer counts.
+func sumCountReducer(curr, new any) (any, error) {
+	if curr == nil {
+		return new, nil
+	}
+	return curr.(int) + new.(int), nil
+}
+
+func TestCommandGotoEnd(t *testing.T) {
+	g := newCommandCountGraph()
+
+	g.AddNode("A", "A", func(ctx context.Context, state any) (any, error) {
+		return &Command{
+			Update: map[string]any{"count": 1},
+			Goto:   END,
+		}, nil
+	})
+	g.AddNode("B", "B", func(ctx context.Context, state any) (any, error) {
+		t.Error("B should not run after Goto END")
+		return map[string]any{"count": 10}, nil
+	})
+	g.SetEntryPoint("A")
+	g.AddEdge("A", "B")
+	g.AddEdge("B", END)
+
+	runnable, err := g.Compile()
+	assert.NoError(t, err)
+
+	res, err := runnable.Invoke(context.Background(), map[string]any{"count": 0})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.(map[string]any)["count"])
+}
+
+func TestCommandGotoFanOut(t *testing.T) {
+	g := newCommandCountGraph()
+
+	g.AddNode("router", "router", func(ctx context.Context, state any) (any, error) {
+		return &Command{
+			Update: map[string]any{"count": 1},
+			Goto:   []string{"B", "C", END},
+		}, nil
+	})
+	g.AddNode("B", "B", func(ctx context.Context, state any) (any, error) {
+		return map[string]any{"count": 10}, nil
+	})
+	g.AddNode("C", "C", func(ctx context.Context, state any) (any, error) {
+		return &Command{Update: map[string]any{"count": 100}, Goto: END}, nil
+	})
+	g.AddNode("D", "D", func(ctx context.Context, state any) (any, error) {
+		return map[string]any{"count": 1000}, nil
+	})
+	g.SetEntryPoint("router")
+	g.AddEdge("B", "D")
+	g.AddEdge("C", "D") // Overridden by C's Goto END; B still follows its edge
+	g.AddEdge("D", END)
+
+	runnable, err := g.Compile()
+	assert.NoError(t, err)
+
+	res, err := runnable.Invoke(context.Background(), map[string]any{"count": 0})
+	assert.NoError(t, err)
+	// 1 (router) + 10 (B) + 100 (C) + 1000 (D, once)
+	assert.Equal(t, 1111, res.(map[string]any)["count"])
+}
+
+func TestCommandGotoInvalidTarget(t *testing.T) {
+	g := newCommandCountGraph()
+
+	g.AddNode("A", "A", func(ctx context.Context, state any) (any, error) {
+		return &Command{Goto: []string{"B", "missing"}}, nil
+	})
+	g.AddNode("B", "B", func(ctx context.Context, state any) (any, error) {
+		return map[string]any{"count": 10}, nil
+	})
+	g.SetEntryPoint("A")
+	g.AddEdge("B", END)
+
+	runnable, err := g.Compile()
+	assert.NoError(t, err)
+
+	_, err = runnable.Invoke(context.Background(), map[string]any{"count": 0})
+	assert.True(t, errors.Is(err, ErrNodeNotFound), "expected ErrNodeNotFound, got %v", err)
+	assert.Contains(t, err.Error(), "missing")
+
+	g.AddNode("C", "C", func(ctx context.Context, state any) (any, error) {
+		return &Command{Goto: 42}, nil
+	})
+	g.SetEntryPoint("C")
+	runnable, err = g.Compile()
+	assert.NoError(t, err)
+
+	_, err = runnable.Invoke(context.Background(), map[string]any{"count": 0})
+	assert.ErrorContains(t, err, "unsupported Command.Goto type int")
+}
@@ -0,0 +1,199 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CompileOption configures how a graph is validated and compiled. The same options
+// are accepted by Compile, CompileListenable, CompileCheckpointable and
+// CompileStreaming, so a graph behaves the same whichever way it is compiled.
+//
+// Example:
+//
+//	runnable, err := g.Compile(
+//	    graph.WithMaxSteps(25),
+//	    graph.WithInterruptBeforeNodes("tools"),
+//	    graph.WithStrictValidation(),
+//	)
+type CompileOption func(*CompileOptions)
+
+// CompileOptions holds compile-time settings of a graph.
+//
+// Breakpoints set here are a property of the compiled runnable: every invoke pauses
+// before or after those nodes. A per-invoke Config overrides them: when
+// Config.InterruptBefore (or InterruptAfter) is non-nil it replaces the compiled list,
+// so an explicitly empty slice disables the compiled breakpoints for that call, while
+// a nil slice keeps them. Breakpoints don't fire for the nodes a run resumes from
+// (Config.ResumeFrom) in its first step, so resuming after a breakpoint continues
+// instead of pausing at the same place again.
+type CompileOptions struct {
+	// InterruptBefore nodes to stop before execution
+	InterruptBefore []string
+
+	// InterruptAfter nodes to stop after execution
+	InterruptAfter []string
+
+	// AllowDeadEnds allows nodes without outgoing edges, for intentionally partial
+	// graphs or nodes that always route with a Command
+	AllowDeadEnds bool
+
+	// StrictValidation also fails compilation on the warnings of the CompileReport,
+	// such as cycles not declared with MarkCycle
+	StrictValidation bool
+
+	// MaxSteps limits the number of steps of a single invoke, so a run caught in a
+	// cycle fails with ErrMaxStepsExceeded instead of running until its context is
+	// cancelled. Zero means no limit.
+	MaxSteps int
+
+	// ParallelBranches limits how many nodes of the same step run concurrently.
+	// Zero means no limit; 1 runs the nodes of a step one at a time.
+	ParallelBranches int
+
+	// StrictResume fails resuming a thread whose latest checkpoint was saved by a
+	// graph with a different Fingerprint, instead of logging a warning
+	StrictResume bool
+
+	// PanicPropagation lets panics in node functions crash the process, instead of
+	// failing the node with a NodePanicError
+	PanicPropagation bool
+
+	// Stats collects the NodeStats of every node, read with Stats
+	Stats bool
+
+	// stateCloner is the func(S) S set with WithStateCloner
+	stateCloner any
+}
+
+// WithAllowDeadEnds allows nodes without outgoing edges, for intentionally partial graphs
+// or nodes that always route with a Command.
+func WithAllowDeadEnds() CompileOption {
+	return func(o *CompileOptions) {
+		o.AllowDeadEnds = true
+	}
+}
+
+// WithInterruptBeforeNodes sets the nodes to stop before, see CompileOptions. It is
+// the compile-time counterpart of the WithInterruptBefore Config helper.
+func WithInterruptBeforeNodes(nodes ...string) CompileOption {
+	return func(o *CompileOptions) {
+		o.InterruptBefore = nodes
+	}
+}
+
+// WithInterruptAfterNodes sets the nodes to stop after, see CompileOptions. It is the
+// compile-time counterpart of the WithInterruptAfter Config helper.
+func WithInterruptAfterNodes(nodes ...string) CompileOption {
+	return func(o *CompileOptions) {
+		o.InterruptAfter = nodes
+	}
+}
+
+// WithStrictValidation makes compilation fail on the warnings of the CompileReport,
+// reported as ValidationIssues wrapping ErrUnmarkedCycle.
+func WithStrictValidation() CompileOption {
+	return func(o *CompileOptions) {
+		o.StrictValidation = true
+	}
+}
+
+// WithMaxSteps limits the number of steps of a single invoke.
+func WithMaxSteps(steps int) CompileOption {
+	return func(o *CompileOptions) {
+		o.MaxSteps = steps
+	}
+}
+
+// WithParallelBranches limits how many nodes of the same step run concurrently.
+func WithParallelBranches(n int) CompileOption {
+	return func(o *CompileOptions) {
+		o.ParallelBranches = n
+	}
+}
+
+// WithStrictResume makes resuming a thread fail with ErrGraphChanged when its latest
+// checkpoint was saved by a graph with a different Fingerprint.
+func WithStrictResume() CompileOption {
+	return func(o *CompileOptions) {
+		o.StrictResume = true
+	}
+}
+
+// WithPanicPropagation lets panics in node functions crash the process, for example
+// to debug them in development. By default a panicking node fails with a
+// NodePanicError, so retries, error edges and checkpoints still apply.
+func WithPanicPropagation() CompileOption {
+	return func(o *CompileOptions) {
+		o.PanicPropagation = true
+	}
+}
+
+// WithCompileOptions applies every setting of opts, so the same CompileOptions can be
+// passed to CompileListenable, CompileCheckpointable and CompileStreaming.
+//
+// Example:
+//
+//	runnable, err := g.CompileCheckpointable(graph.WithCompileOptions(graph.CompileOptions{
+//	    InterruptBefore: []string{"tools"},
+//	}))
+func WithCompileOptions(opts CompileOptions) CompileOption {
+	return func(o *CompileOptions) {
+		// The state cloner can only be set with WithStateCloner, so it is kept
+		stateCloner := o.stateCloner
+		*o = opts
+		o.stateCloner = stateCloner
+	}
+}
+
+// WithStateCloner sets the function that copies the initial state of every invoke,
+// so that concurrent runs never share mutable values. By default states that are
+// maps or slices, directly or in an interface such as any, are deep-copied and other
+// states are passed as is; supply a cloner for states such as structs with slice or
+// map fields, or one that returns its argument to skip copying. The type of S must match the graph's state
+// type, otherwise Compile fails.
+//
+// Example:
+//
+//	runnable, err := g.Compile(graph.WithStateCloner(func(s MyState) MyState {
+//	    s.Messages = slices.Clone(s.Messages)
+//	    return s
+//	}))
+func WithStateCloner[S any](clone func(S) S) CompileOption {
+	return func(o *CompileOptions) {
+		o.stateCloner = clone
+	}
+}
+
+// newCompileOptions applies opts to the default compile options.
+func newCompileOptions(opts []CompileOption) *CompileOptions {
+	options := &CompileOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// stateClonerFor returns the state cloner set in options for state type S, or nil
+func stateClonerFor[S any](options *CompileOptions) (func(S) S, error) {
+	if options.stateCloner == nil {
+		return nil, nil
+	}
+	clone, ok := options.stateCloner.(func(S) S)
+	if !ok {
+		stateType := reflect.TypeFor[S]()
+		return nil, fmt.Errorf("state cloner has type %T, expected func(%v) %v", options.stateCloner, stateType, stateType)
+	}
+	return clone, nil
+}
+
+// CompileWithOptions validates and compiles the state graph with the given options.
+//
+// Example:
+//
+//	runnable, err := g.CompileWithOptions(graph.CompileOptions{
+//	    InterruptBefore: []string{"tools"},
+//	})
+func (g *StateGraph[S]) CompileWithOptions(opts CompileOptions) (*StateRunnable[S], error) {
+	return g.Compile(WithCompileOptions(opts))
+}
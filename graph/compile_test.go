@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type intInvoker interface {
+	Invoke(ctx context.Context, state int) (int, error)
+}
+
+// compileVariants builds the same graph with each compile path. define sets up the
+// graph, adding nodes with addNode so listenable graphs track them.
+var compileVariants = []struct {
+	name    string
+	compile func(define func(g *StateGraph[int], addNode func(string, NodeFunc[int])), opts ...CompileOption) (intInvoker, error)
+}{
+	{"Compile", func(define func(*StateGraph[int], func(string, NodeFunc[int])), opts ...CompileOption) (intInvoker, error) {
+		g := NewStateGraph[int]()
+		define(g, func(name string, fn NodeFunc[int]) { g.AddNode(name, name, fn) })
+		return g.Compile(opts...)
+	}},
+	{"CompileListenable", func(define func(*StateGraph[int], func(string, NodeFunc[int])), opts ...CompileOption) (intInvoker, error) {
+		g := NewListenableStateGraph[int]()
+		define(g.StateGraph, func(name string, fn NodeFunc[int]) { g.AddNode(name, name, fn) })
+		return g.CompileListenable(opts...)
+	}},
+	{"CompileCheckpointable", func(define func(*StateGraph[int], func(string, NodeFunc[int])), opts ...CompileOption) (intInvoker, error) {
+		g := NewCheckpointableStateGraph[int]()
+		define(g.StateGraph, func(name string, fn NodeFunc[int]) { g.AddNode(name, name, fn) })
+		return g.CompileCheckpointable(opts...)
+	}},
+}
+
+func defineLoop(g *StateGraph[int], addNode func(string, NodeFunc[int])) {
+	addNode("inc", func(ctx context.Context, state int) (int, error) {
+		return state + 1, nil
+	})
+	g.SetEntryPoint("inc")
+	g.AddConditionalEdgeWithMapping("inc", func(ctx context.Context, state int) string {
+		if state < 100 {
+			return "again"
+		}
+		return "done"
+	}, map[string]string{"again": "inc", "done": END})
+}
+
+func TestCompileOptions(t *testing.T) {
+	for _, variant := range compileVariants {
+		t.Run(variant.name, func(t *testing.T) {
+			t.Run("MaxSteps", func(t *testing.T) {
+				runnable, err := variant.compile(defineLoop, WithMaxSteps(5))
+				require.NoError(t, err)
+
+				res, err := runnable.Invoke(context.Background(), 0)
+				assert.ErrorIs(t, err, ErrMaxStepsExceeded)
+				assert.Equal(t, 5, res, "the state after the last step is returned")
+
+				runnable, err = variant.compile(defineLoop)
+				require.NoError(t, err)
+				res, err = runnable.Invoke(context.Background(), 0)
+				require.NoError(t, err)
+				assert.Equal(t, 100, res)
+			})
+
+			t.Run("InterruptBeforeNodes", func(t *testing.T) {
+				runnable, err := variant.compile(func(g *StateGraph[int], addNode func(string, NodeFunc[int])) {
+					addNode("a", func(ctx context.Context, state int) (int, error) { return state + 1, nil })
+					addNode("b", func(ctx context.Context, state int) (int, error) { return state * 10, nil })
+					g.SetEntryPoint("a")
+					g.AddEdge("a", "b")
+					g.AddEdge("b", END)
+				}, WithInterruptBeforeNodes("b"))
+				require.NoError(t, err)
+
+				res, err := runnable.Invoke(context.Background(), 1)
+				var interrupt *GraphInterrupt
+				require.ErrorAs(t, err, &interrupt)
+				assert.Equal(t, "b", interrupt.Node)
+				assert.Equal(t, 2, res)
+			})
+
+			t.Run("StrictValidation", func(t *testing.T) {
+				_, err := variant.compile(defineLoop, WithStrictValidation())
+				assert.ErrorIs(t, err, ErrUnmarkedCycle)
+				assert.ErrorContains(t, err, "cycle not declared with MarkCycle: inc")
+
+				_, err = variant.compile(func(g *StateGraph[int], addNode func(string, NodeFunc[int])) {
+					defineLoop(g, addNode)
+					g.MarkCycle("inc")
+				}, WithStrictValidation())
+				assert.NoError(t, err)
+			})
+
+			t.Run("ParallelBranches", func(t *testing.T) {
+				var running, maxRunning atomic.Int32
+				define := func(g *StateGraph[int], addNode func(string, NodeFunc[int])) {
+					addNode("fork", func(ctx context.Context, state int) (int, error) { return state, nil })
+					g.SetEntryPoint("fork")
+					for _, name := range []string{"a", "b", "c", "d"} {
+						addNode(name, func(ctx context.Context, state int) (int, error) {
+							n := running.Add(1)
+							for {
+								m := maxRunning.Load()
+								if n <= m || maxRunning.CompareAndSwap(m, n) {
+									break
+								}
+							}
+							time.Sleep(10 * time.Millisecond)
+							running.Add(-1)
+							return state, nil
+						})
+						g.AddEdge("fork", name)
+						g.AddEdge(name, END)
+					}
+				}
+
+				runnable, err := variant.compile(define, WithParallelBranches(2))
+				require.NoError(t, err)
+				_, err = runnable.Invoke(context.Background(), 0)
+				require.NoError(t, err)
+				assert.Equal(t, int32(2), maxRunning.Load())
+
+				maxRunning.Store(0)
+				runnable, err = variant.compile(define, WithParallelBranches(1))
+				require.NoError(t, err)
+				_, err = runnable.Invoke(context.Background(), 0)
+				require.NoError(t, err)
+				assert.Equal(t, int32(1), maxRunning.Load())
+			})
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConcurrentGraph builds a graph whose first node modifies its state in place,
+// as many map-based nodes do, followed by a fan-out and a join
+func newConcurrentGraph() *ListenableStateGraph[map[string]any] {
+	g := NewListenableStateGraph[map[string]any]()
+	g.SetSchema(NewMapSchema())
+	g.AddNode("start", "start", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		id, _ := ConfigValue[int](ctx, "id")
+		state["id"] = id
+		state["trail"] = append(state["trail"].([]string), "start")
+		return state, nil
+	})
+	for _, name := range []string{"a", "b"} {
+		g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{name: state["id"]}, nil
+		})
+	}
+	g.AddNode("join", "join", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"sum": state["a"].(int) + state["b"].(int)}, nil
+	})
+	g.SetEntryPoint("start")
+	g.AddEdge("start", "a")
+	g.AddEdge("start", "b")
+	g.AddEdge("a", "join")
+	g.AddEdge("b", "join")
+	g.AddEdge("join", END)
+	return g
+}
+
+// TestConcurrentInvoke runs many invokes on one runnable with the same input map;
+// run with -race to check that they don't share mutable state.
+func TestConcurrentInvoke(t *testing.T) {
+	g := newConcurrentGraph()
+	for _, node := range g.listenableNodes {
+		node.AddListener(NodeListenerFunc[map[string]any](func(ctx context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {}))
+	}
+	runnable, err := g.CompileListenable()
+	require.NoError(t, err)
+	runnable.runnable.SetTracer(NewTracer())
+
+	// The spare capacity makes the appends of runs sharing the slice overwrite each other
+	trail := make([]string, 1, 8)
+	trail[0] = "input"
+	input := map[string]any{"trail": trail}
+
+	const runs = 100
+	results := make([]map[string]any, runs)
+	errs := make([]error, runs)
+	var wg sync.WaitGroup
+	for i := range runs {
+		wg.Go(func() {
+			config := &Config{Configurable: map[string]any{"id": i}}
+			results[i], errs[i] = runnable.InvokeWithConfig(context.Background(), input, config)
+		})
+	}
+	wg.Wait()
+
+	for i := range runs {
+		require.NoError(t, errs[i])
+		assert.Equal(t, i, results[i]["id"])
+		assert.Equal(t, 2*i, results[i]["sum"])
+		assert.Equal(t, []string{"input", "start"}, results[i]["trail"])
+	}
+	assert.Equal(t, []string{"input", ""}, trail[:2], "the input is not modified")
+	assert.NotEmpty(t, runnable.runnable.GetTracer().GetSpans())
+}
+
+func TestConcurrentCheckpointableInvoke(t *testing.T) {
+	g := NewCheckpointableStateGraph[map[string]any]()
+	g.AddNode("step", "step", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		state["done"] = true
+		return state, nil
+	})
+	g.SetEntryPoint("step")
+	g.AddEdge("step", END)
+
+	runnable, err := g.CompileCheckpointable()
+	require.NoError(t, err)
+
+	config := &Config{Tags: []string{"shared"}}
+	threads := make([]string, 20)
+	var wg sync.WaitGroup
+	for i := range threads {
+		threads[i] = fmt.Sprintf("thread-%d", i)
+		wg.Go(func() {
+			runConfig := *config
+			runConfig.Configurable = map[string]any{"thread_id": threads[i]}
+			_, err := runnable.InvokeWithConfig(context.Background(), map[string]any{}, &runConfig)
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+
+	// Every thread's checkpoint is saved with its own thread_id
+	for _, threadID := range threads {
+		checkpoints, err := g.config.Store.ListByThread(context.Background(), threadID)
+		require.NoError(t, err)
+		require.Len(t, checkpoints, 1, threadID)
+		assert.Equal(t, threadID, checkpoints[0].Metadata["thread_id"])
+	}
+
+	// The caller's config is not modified by InvokeWithConfig
+	_, err = runnable.InvokeWithConfig(context.Background(), map[string]any{}, config)
+	require.NoError(t, err)
+	assert.Empty(t, config.Callbacks)
+	assert.Nil(t, config.ResumeFrom)
+}
+
+func TestCompileSnapshot(t *testing.T) {
+	g := NewStateGraph[int]()
+	g.AddNode("a", "a", func(ctx context.Context, state int) (int, error) {
+		return state + 1, nil
+	})
+	g.SetEntryPoint("a")
+	g.AddEdge("a", END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	// Changes to the graph after Compile don't affect the runnable
+	g.AddNode("b", "b", func(ctx context.Context, state int) (int, error) {
+		return state * 10, nil
+	})
+	g.edges = nil
+	g.AddEdge("a", "b")
+	g.AddEdge("b", END)
+
+	res, err := runnable.Invoke(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res)
+}
+
+func TestWithStateCloner(t *testing.T) {
+	type clonedState struct {
+		Items []string
+	}
+
+	g := NewStateGraph[clonedState]()
+	g.AddNode("add", "add", func(ctx context.Context, state clonedState) (clonedState, error) {
+		state.Items[0] = "changed"
+		return state, nil
+	})
+	g.SetEntryPoint("add")
+	g.AddEdge("add", END)
+
+	cloned := 0
+	runnable, err := g.Compile(WithStateCloner(func(s clonedState) clonedState {
+		cloned++
+		s.Items = slices.Clone(s.Items)
+		return s
+	}))
+	require.NoError(t, err)
+
+	input := clonedState{Items: []string{"original"}}
+	res, err := runnable.Invoke(context.Background(), input)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"changed"}, res.Items)
+	assert.Equal(t, []string{"original"}, input.Items)
+	assert.Equal(t, 1, cloned)
+
+	_, err = g.Compile(WithStateCloner(func(s int) int { return s }))
+	assert.ErrorContains(t, err, "state cloner has type func(int) int")
+}
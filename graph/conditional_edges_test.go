@@ -2,6 +2,7 @@ package graph_test
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -337,3 +338,105 @@ func TestConditionalEdges_ChainedConditions(t *testing.T) {
 		t.Errorf("Expected result -10, got %v", result)
 	}
 }
+
+func TestConditionalEdgeWithMapping(t *testing.T) {
+	t.Parallel()
+
+	buildGraph := func(pathMap map[string]string) *graph.StateGraph[map[string]any] {
+		g := graph.NewStateGraph[map[string]any]()
+		g.AddNode("review", "review", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		})
+		g.AddNode("publish", "publish", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			state["status"] = "published"
+			return state, nil
+		})
+		g.AddNode("revise", "revise", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			state["status"] = "revised"
+			return state, nil
+		})
+		g.AddConditionalEdgeWithMapping("review", func(ctx context.Context, state map[string]any) string {
+			return state["decision"].(string)
+		}, pathMap)
+		g.AddEdge("publish", graph.END)
+		g.AddEdge("revise", graph.END)
+		g.SetEntryPoint("review")
+		return g
+	}
+
+	t.Run("RoutesByKey", func(t *testing.T) {
+		g := buildGraph(map[string]string{"approve": "publish", "reject": "revise"})
+		runnable, err := g.Compile()
+		if err != nil {
+			t.Fatalf("Failed to compile graph: %v", err)
+		}
+
+		for decision, want := range map[string]string{"approve": "published", "reject": "revised"} {
+			result, err := runnable.Invoke(context.Background(), map[string]any{"decision": decision})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result["status"] != want {
+				t.Errorf("decision %s: expected status %s, got %v", decision, want, result["status"])
+			}
+		}
+	})
+
+	t.Run("UnknownKeyFailsInvoke", func(t *testing.T) {
+		g := buildGraph(map[string]string{"approve": "publish", "reject": "revise"})
+		runnable, err := g.Compile()
+		if err != nil {
+			t.Fatalf("Failed to compile graph: %v", err)
+		}
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{"decision": "maybe"})
+		if !errors.Is(err, graph.ErrUnknownPathKey) {
+			t.Errorf("Expected ErrUnknownPathKey, got %v", err)
+		}
+	})
+
+	t.Run("UnknownTargetFailsCompile", func(t *testing.T) {
+		g := buildGraph(map[string]string{"approve": "publsh", "reject": "revise"})
+		_, err := g.Compile()
+		if !errors.Is(err, graph.ErrNodeNotFound) {
+			t.Fatalf("Expected ErrNodeNotFound, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "review -> publsh") {
+			t.Errorf("Expected error to name the edge, got %v", err)
+		}
+	})
+
+	t.Run("MappedTargetsAreReachable", func(t *testing.T) {
+		g := buildGraph(map[string]string{"approve": "publish", "reject": "revise"})
+		g.AddNode("orphan", "orphan", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		})
+		g.AddEdge("orphan", graph.END)
+
+		_, err := g.Compile()
+		if !errors.Is(err, graph.ErrUnreachableNode) {
+			t.Fatalf("Expected ErrUnreachableNode, got %v", err)
+		}
+		if strings.Contains(err.Error(), "publish") || strings.Contains(err.Error(), "revise") {
+			t.Errorf("Mapped targets should be reachable, got %v", err)
+		}
+	})
+
+	t.Run("ExporterLabelsKeys", func(t *testing.T) {
+		g := buildGraph(map[string]string{"approve": "publish", "reject": graph.END})
+		exporter := graph.NewExporter(g)
+
+		mermaid := exporter.DrawMermaid()
+		if !strings.Contains(mermaid, "review -.->|approve| publish") || !strings.Contains(mermaid, "review -.->|reject| END") {
+			t.Errorf("Expected labeled conditional edges in Mermaid output, got:\n%s", mermaid)
+		}
+		if !strings.Contains(mermaid, `END(["END"])`) {
+			t.Errorf("Expected END node in Mermaid output, got:\n%s", mermaid)
+		}
+
+		dot := exporter.DrawDOT()
+		if !strings.Contains(dot, `review -> publish [style=dashed, label="approve"];`) {
+			t.Errorf("Expected labeled conditional edge in DOT output, got:\n%s", dot)
+		}
+	})
+}
@@ -2,9 +2,12 @@ package graph
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRuntimeConfiguration(t *testing.T) {
@@ -40,10 +43,10 @@ func TestRuntimeConfiguration(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "gpt-4", result["result"])
 
-	// Test without config
+	// Test without config: the run still has a config, carrying only its run ID
 	result, err = runnable.Invoke(context.Background(), nil)
 	assert.NoError(t, err)
-	assert.Equal(t, "no config", result["result"])
+	assert.Equal(t, "key not found", result["result"])
 }
 
 func TestStateGraph_RuntimeConfiguration(t *testing.T) {
@@ -77,3 +80,338 @@ func TestStateGraph_RuntimeConfiguration(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "secret-123", result["result"])
 }
+
+func TestConfigValue(t *testing.T) {
+	ctx := context.Background()
+
+	type reading struct {
+		User     string
+		HasModel bool
+		Limit    int
+	}
+	read := func(ctx context.Context) reading {
+		user, _ := ConfigValue[string](ctx, "user_id")
+		_, hasModel := ConfigValue[string](ctx, "model")
+		limit, _ := ConfigValue[int](ctx, "limit")
+		return reading{User: user, HasModel: hasModel, Limit: limit}
+	}
+	// limit is stored as a string, so ConfigValue[int] reports false
+	config := &Config{Configurable: map[string]any{"user_id": "u-42", "limit": "10"}}
+
+	t.Run("StateRunnable", func(t *testing.T) {
+		var got reading
+		g := NewStateGraph[map[string]any]()
+		g.AddNode("reader", "reader", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			got = read(ctx)
+			fromCtx, ok := ConfigFromContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, config.Configurable, fromCtx.Configurable)
+			assert.NotEmpty(t, fromCtx.RunID)
+			return state, nil
+		})
+		g.SetEntryPoint("reader")
+		g.AddEdge("reader", END)
+
+		runnable, err := g.Compile()
+		assert.NoError(t, err)
+
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, config)
+		assert.NoError(t, err)
+		assert.Equal(t, reading{User: "u-42"}, got)
+	})
+
+	t.Run("CheckpointableRunnable", func(t *testing.T) {
+		var got reading
+		g := NewCheckpointableStateGraph[map[string]any]()
+		g.AddNode("reader", "reader", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			got = read(ctx)
+			return state, nil
+		})
+		g.SetEntryPoint("reader")
+		g.AddEdge("reader", END)
+
+		runnable, err := g.CompileCheckpointable()
+		assert.NoError(t, err)
+
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, config)
+		assert.NoError(t, err)
+		assert.Equal(t, reading{User: "u-42"}, got)
+	})
+
+	t.Run("NoConfig", func(t *testing.T) {
+		_, ok := ConfigFromContext(ctx)
+		assert.False(t, ok)
+		_, ok = ConfigFromContext(WithConfig(ctx, nil))
+		assert.False(t, ok)
+		assert.Equal(t, reading{}, read(ctx))
+	})
+}
+
+// runLabelRecorder records the run ID, tags and metadata of chain and node callbacks
+type runLabelRecorder struct {
+	NoOpCallbackHandler
+	mu          sync.Mutex
+	chainRunID  string
+	chainName   any
+	toolParents []string
+	toolTags    map[string][]string
+	toolRunName map[string]any
+	retryRunIDs []string
+}
+
+func (c *runLabelRecorder) OnChainStart(ctx context.Context, serialized map[string]any, inputs map[string]any, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	c.chainRunID = runID
+	c.chainName = serialized["name"]
+}
+
+func (c *runLabelRecorder) OnToolStart(ctx context.Context, serialized map[string]any, inputStr string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := serialized["name"].(string)
+	c.toolParents = append(c.toolParents, *parentRunID)
+	c.toolTags[name] = tags
+	c.toolRunName[name] = serialized["run_name"]
+}
+
+func (c *runLabelRecorder) OnNodeRetry(ctx context.Context, nodeName string, attempt int, err error) {
+	config, _ := ConfigFromContext(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryRunIDs = append(c.retryRunIDs, config.RunID)
+}
+
+func TestRunLabels(t *testing.T) {
+	attempts := 0
+	g := NewListenableStateGraph[map[string]any]()
+	g.SetSchema(NewMapSchema())
+	g.AddNodeWithOptions("draft", "draft", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("transient")
+		}
+		return map[string]any{"draft": true}, nil
+	}, WithTags("llm"), WithRetry(NodeRetryPolicy{MaxAttempts: 2}))
+	review := g.AddNode("review", "review", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"reviewed": true}, nil
+	})
+	g.SetEntryPoint("draft")
+	g.AddEdge("draft", "review")
+	g.AddEdge("review", END)
+
+	var listenerConfigs []*Config
+	review.AddListener(NodeListenerFunc[map[string]any](func(ctx context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {
+		config, _ := ConfigFromContext(ctx)
+		listenerConfigs = append(listenerConfigs, config)
+	}))
+
+	runnable, err := g.CompileListenable()
+	require.NoError(t, err)
+
+	t.Run("Config", func(t *testing.T) {
+		listenerConfigs = nil
+		attempts = 0
+		recorder := &runLabelRecorder{toolTags: map[string][]string{}, toolRunName: map[string]any{}}
+		config := &Config{
+			Callbacks: []CallbackHandler{recorder},
+			Tags:      []string{"tenant:acme"},
+			Metadata:  map[string]any{"cost_center": "support"},
+			RunName:   "weekly-report",
+		}
+		_, err := runnable.InvokeWithConfig(context.Background(), map[string]any{}, config)
+		require.NoError(t, err)
+		assert.Empty(t, config.RunID, "the caller's config is not modified")
+
+		assert.NotEmpty(t, recorder.chainRunID)
+		assert.Equal(t, "weekly-report", recorder.chainName)
+		assert.Equal(t, []string{"tenant:acme", "llm"}, recorder.toolTags["draft"])
+		assert.Equal(t, []string{"tenant:acme"}, recorder.toolTags["review"])
+		assert.Equal(t, "weekly-report", recorder.toolRunName["review"])
+		assert.Equal(t, []string{recorder.chainRunID, recorder.chainRunID}, recorder.toolParents)
+		assert.Equal(t, []string{recorder.chainRunID}, recorder.retryRunIDs)
+
+		require.Len(t, listenerConfigs, 2)
+		for _, listenerConfig := range listenerConfigs {
+			assert.Equal(t, recorder.chainRunID, listenerConfig.RunID)
+			assert.Equal(t, []string{"tenant:acme"}, listenerConfig.Tags)
+			assert.Equal(t, "support", listenerConfig.Metadata["cost_center"])
+			assert.Equal(t, "weekly-report", listenerConfig.RunName)
+		}
+	})
+
+	t.Run("RunID", func(t *testing.T) {
+		listenerConfigs = nil
+		attempts = 0
+		recorder := &runLabelRecorder{toolTags: map[string][]string{}, toolRunName: map[string]any{}}
+		_, err := runnable.InvokeWithConfig(context.Background(), map[string]any{}, &Config{
+			Callbacks: []CallbackHandler{recorder},
+			RunID:     "run-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "run-1", recorder.chainRunID)
+		assert.Equal(t, "graph", recorder.chainName)
+		assert.Nil(t, recorder.toolRunName["review"])
+	})
+
+	t.Run("NoConfig", func(t *testing.T) {
+		listenerConfigs = nil
+		attempts = 1
+		_, err := runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		require.NotEmpty(t, listenerConfigs)
+		assert.NotEmpty(t, listenerConfigs[0].RunID, "runs without a config get a run ID")
+	})
+}
+
+// recordingCallbacks records the chain and step callbacks of a run in order
+type recordingCallbacks struct {
+	NoOpCallbackHandler
+	mu     sync.Mutex
+	calls  []string
+	runIDs []string
+	inputs map[string]any
+	output map[string]any
+}
+
+func (c *recordingCallbacks) record(call, runID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, call)
+	if runID != "" {
+		c.runIDs = append(c.runIDs, runID)
+	}
+}
+
+func (c *recordingCallbacks) OnChainStart(ctx context.Context, serialized map[string]any, inputs map[string]any, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	c.inputs = inputs
+	c.record("chain_start", runID)
+}
+
+func (c *recordingCallbacks) OnChainEnd(ctx context.Context, outputs map[string]any, runID string) {
+	c.output = outputs
+	c.record("chain_end", runID)
+}
+
+func (c *recordingCallbacks) OnChainError(ctx context.Context, err error, runID string) {
+	c.record("chain_error: "+err.Error(), runID)
+}
+
+func (c *recordingCallbacks) OnGraphStep(ctx context.Context, stepNode string, state any) {
+	c.record("step "+stepNode, "")
+}
+
+func TestConfigCallbacks(t *testing.T) {
+	t.Parallel()
+	newGraph := func(failing string) *StateRunnable[map[string]any] {
+		g := NewStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		for _, name := range []string{"a", "b", "c"} {
+			g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				if name == failing {
+					return nil, errors.New(name + " failed")
+				}
+				return map[string]any{name: true}, nil
+			})
+		}
+		g.SetEntryPoint("a")
+		g.AddEdge("a", "b")
+		g.AddEdge("b", "c")
+		g.AddEdge("c", END)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		return runnable
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+		callbacks := &recordingCallbacks{}
+		config := &Config{Callbacks: []CallbackHandler{callbacks}}
+		_, err := newGraph("").InvokeWithConfig(context.Background(), map[string]any{"input": 1}, config)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"chain_start", "step a", "step b", "step c", "chain_end"}, callbacks.calls)
+		assert.Equal(t, map[string]any{"input": 1}, callbacks.inputs)
+		assert.Equal(t, map[string]any{"input": 1, "a": true, "b": true, "c": true}, callbacks.output)
+		require.Len(t, callbacks.runIDs, 2)
+		assert.NotEmpty(t, callbacks.runIDs[0])
+		assert.Equal(t, callbacks.runIDs[0], callbacks.runIDs[1])
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+		callbacks := &recordingCallbacks{}
+		config := &Config{Callbacks: []CallbackHandler{callbacks}}
+		_, err := newGraph("c").InvokeWithConfig(context.Background(), map[string]any{}, config)
+		require.Error(t, err)
+
+		assert.Equal(t, []string{"chain_start", "step a", "step b", "chain_error: error in node c: c failed"}, callbacks.calls)
+		assert.Equal(t, callbacks.runIDs[0], callbacks.runIDs[1])
+	})
+}
+
+// runTree records the parent of every chain and node run reported to it
+type runTree struct {
+	NoOpCallbackHandler
+	mu      sync.Mutex
+	parents map[string]*string // parent runID by runID
+	nodes   map[string]string  // runID by node name
+}
+
+func (r *runTree) OnChainStart(ctx context.Context, serialized map[string]any, inputs map[string]any, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parents[runID] = parentRunID
+}
+
+func (r *runTree) OnToolStart(ctx context.Context, serialized map[string]any, inputStr string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parents[runID] = parentRunID
+	r.nodes[serialized["name"].(string)] = runID
+}
+
+func TestRunIDs(t *testing.T) {
+	t.Parallel()
+	var innerRunID, innerNodeRunID, outerRunID, outerNodeRunID string
+
+	inner := NewStateGraph[map[string]any]()
+	inner.AddNode("leaf", "leaf", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		innerRunID, _ = RunIDFromContext(ctx)
+		innerNodeRunID, _ = NodeRunIDFromContext(ctx)
+		return state, nil
+	})
+	inner.AddEdge("leaf", END)
+	inner.SetEntryPoint("leaf")
+	innerRunnable, err := inner.Compile()
+	require.NoError(t, err)
+
+	outer := NewStateGraph[map[string]any]()
+	outer.AddNode("root", "root", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		outerRunID, _ = RunIDFromContext(ctx)
+		outerNodeRunID, _ = NodeRunIDFromContext(ctx)
+		// Without a config, the nested run inherits the callbacks of the node
+		return innerRunnable.Invoke(ctx, state)
+	})
+	outer.AddEdge("root", END)
+	outer.SetEntryPoint("root")
+	runnable, err := outer.Compile()
+	require.NoError(t, err)
+
+	tree := &runTree{parents: map[string]*string{}, nodes: map[string]string{}}
+	_, err = runnable.InvokeWithConfig(context.Background(), map[string]any{}, &Config{Callbacks: []CallbackHandler{tree}})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, outerRunID)
+	assert.Nil(t, tree.parents[outerRunID])
+	assert.Equal(t, outerNodeRunID, tree.nodes["root"])
+	assert.Equal(t, &outerRunID, tree.parents[outerNodeRunID])
+
+	assert.NotEqual(t, outerRunID, innerRunID)
+	assert.Equal(t, &outerNodeRunID, tree.parents[innerRunID])
+	assert.Equal(t, innerNodeRunID, tree.nodes["leaf"])
+	assert.Equal(t, &innerRunID, tree.parents[innerNodeRunID])
+
+	_, ok := RunIDFromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = NodeRunIDFromContext(context.Background())
+	assert.False(t, ok)
+}
@@ -1,16 +1,198 @@
 package graph
 
-import "context"
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+)
 
 type resumeValueKey struct{}
 
+type runScopeKey struct{}
+
+type interruptNodeKey struct{}
+
+type nextNodesKey struct{}
+
+type pendingNodesKey struct{}
+
+type entryNodesKey struct{}
+
+type parentRunIDKey struct{}
+
+type nodeRunIDKey struct{}
+
+type stepIndexKey struct{}
+
+type attemptKey struct{}
+
+// runScopes numbers the runs, so that a resume value applies to one run only
+var runScopes atomic.Uint64
+
+// resumeValue holds the value that answers the first Interrupt() call made by one
+// of nodes in the run numbered scope, or by any node of that run when nodes is
+// empty. A value without a scope, as set with WithResumeValue, answers the first
+// call of any node.
+type resumeValue struct {
+	value any
+	scope uint64
+	nodes []string
+	used  *atomic.Bool
+}
+
 // WithResumeValue adds a resume value to the context.
-// This value will be returned by Interrupt() when re-executing a node.
+// This value will be returned by the first Interrupt() call when re-executing a node;
+// later Interrupt() calls pause execution again.
 func WithResumeValue(ctx context.Context, value any) context.Context {
-	return context.WithValue(ctx, resumeValueKey{}, value)
+	return context.WithValue(ctx, resumeValueKey{}, &resumeValue{value: value, used: new(atomic.Bool)})
 }
 
 // GetResumeValue retrieves the resume value from the context.
 func GetResumeValue(ctx context.Context) any {
-	return ctx.Value(resumeValueKey{})
+	if rv, ok := ctx.Value(resumeValueKey{}).(*resumeValue); ok {
+		return rv.value
+	}
+	return nil
+}
+
+// withRunScope marks ctx as the context of a new run, which the resume values
+// scoped to other runs don't apply to
+func withRunScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, runScopeKey{}, runScopes.Add(1))
+}
+
+// withScopedResumeValue adds a resume value answering the first Interrupt() call
+// made by one of nodes, or by any node when nodes is empty, in the run of ctx.
+// used is shared with the value it is handed over from, if any.
+func withScopedResumeValue(ctx context.Context, value any, nodes []string, used *atomic.Bool) context.Context {
+	scope, _ := ctx.Value(runScopeKey{}).(uint64)
+	return context.WithValue(ctx, resumeValueKey{}, &resumeValue{value: value, scope: scope, nodes: nodes, used: used})
+}
+
+// appliesTo reports whether rv answers the Interrupt() calls made on ctx
+func (rv *resumeValue) appliesTo(ctx context.Context) bool {
+	if rv.scope == 0 {
+		return true
+	}
+	if scope, _ := ctx.Value(runScopeKey{}).(uint64); scope != rv.scope {
+		return false
+	}
+	if len(rv.nodes) == 0 {
+		return true
+	}
+	node, _ := NodeNameFromContext(ctx)
+	return slices.Contains(rv.nodes, node)
+}
+
+// consumeResumeValue returns the resume value unless it is for another node, or
+// an earlier Interrupt() call already used it
+func consumeResumeValue(ctx context.Context) (any, bool) {
+	rv, ok := ctx.Value(resumeValueKey{}).(*resumeValue)
+	if !ok || rv.value == nil || !rv.appliesTo(ctx) || !rv.used.CompareAndSwap(false, true) {
+		return nil, false
+	}
+	return rv.value, true
+}
+
+// stepInterrupt describes a step interrupted by its nodes for the checkpoint saved
+type stepInterrupt struct {
+	// interrupts are the interrupts raised by the nodes of the step, in step order
+	interrupts []*NodeInterrupt
+
+	// successors are the nodes scheduled by the other nodes of the step, which run
+	// after the interrupted nodes when the step is resumed
+	successors []string
+}
+
+// withInterrupt marks the context passed to OnGraphStep when the step was
+// interrupted, so checkpoints can record the nodes and value of the interrupt.
+func withInterrupt(ctx context.Context, interrupt *stepInterrupt) context.Context {
+	return context.WithValue(ctx, interruptNodeKey{}, interrupt)
+}
+
+// interruptFromContext returns the interrupt of the current step, if any
+func interruptFromContext(ctx context.Context) (*stepInterrupt, bool) {
+	interrupt, ok := ctx.Value(interruptNodeKey{}).(*stepInterrupt)
+	return interrupt, ok
+}
+
+// withNextNodes marks the context passed to OnGraphStep with the nodes scheduled
+// to run after the step, so checkpoints can record where to continue.
+func withNextNodes(ctx context.Context, nodes []string) context.Context {
+	return context.WithValue(ctx, nextNodesKey{}, nodes)
+}
+
+// nextNodesFromContext returns the nodes scheduled after the current step, if known
+func nextNodesFromContext(ctx context.Context) ([]string, bool) {
+	nodes, ok := ctx.Value(nextNodesKey{}).([]string)
+	return nodes, ok
+}
+
+// withPendingNodes marks the context of a checkpoint saved before the given nodes
+// run, so the checkpoint records them as pending.
+func withPendingNodes(ctx context.Context, nodes []string) context.Context {
+	return context.WithValue(ctx, pendingNodesKey{}, nodes)
+}
+
+// pendingNodesFromContext returns the nodes about to run, if the checkpoint is pending
+func pendingNodesFromContext(ctx context.Context) ([]string, bool) {
+	nodes, ok := ctx.Value(pendingNodesKey{}).([]string)
+	return nodes, ok
+}
+
+// withEntryNodes marks the context of a run with the nodes it started with, so
+// checkpoints can record which entry a conditional entry point chose.
+func withEntryNodes(ctx context.Context, nodes []string) context.Context {
+	return context.WithValue(ctx, entryNodesKey{}, nodes)
+}
+
+// entryNodesFromContext returns the nodes the current run started with, if known
+func entryNodesFromContext(ctx context.Context) ([]string, bool) {
+	nodes, ok := ctx.Value(entryNodesKey{}).([]string)
+	return nodes, ok
+}
+
+// withParentRunID marks the context of a graph invocation with the runID of the
+// run that started it, such as a batch or the node invoking a nested graph, so
+// callbacks can link the two.
+func withParentRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, parentRunIDKey{}, runID)
+}
+
+// withNodeRunID marks the context of a node execution with the runID its result
+// is reported with, which is the parent of the graphs and tools the node invokes
+func withNodeRunID(ctx context.Context, runID string) context.Context {
+	return withParentRunID(context.WithValue(ctx, nodeRunIDKey{}, runID), runID)
+}
+
+// parentRunIDFromContext returns the parent runID for OnChainStart, or nil
+func parentRunIDFromContext(ctx context.Context) *string {
+	if runID, ok := ctx.Value(parentRunIDKey{}).(string); ok {
+		return &runID
+	}
+	return nil
+}
+
+// withStepIndex marks the context of the nodes of a step with the step's index in
+// the run, starting at 1, so node events can report it
+func withStepIndex(ctx context.Context, step int) context.Context {
+	return context.WithValue(ctx, stepIndexKey{}, step)
+}
+
+// stepIndexFromContext returns the index of the step running on ctx, if known
+func stepIndexFromContext(ctx context.Context) (int, bool) {
+	step, ok := ctx.Value(stepIndexKey{}).(int)
+	return step, ok
+}
+
+// withAttempt marks the context of a node execution with its attempt number,
+// starting at 1, so node events can report it
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// attemptFromContext returns the attempt number of the node execution on ctx, if known
+func attemptFromContext(ctx context.Context) (int, bool) {
+	attempt, ok := ctx.Value(attemptKey{}).(int)
+	return attempt, ok
 }
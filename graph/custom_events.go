@@ -0,0 +1,56 @@
+package graph
+
+import "context"
+
+type customEmitterKey struct{}
+
+type customEventKey struct{}
+
+// CustomEvent is an event a node published with Emit
+type CustomEvent struct {
+	// Name identifies the kind of event, such as "source_found"
+	Name string
+
+	// Payload is the data of the event
+	Payload any
+}
+
+// customEmitter publishes a custom event for the node whose context holds it
+type customEmitter func(event CustomEvent)
+
+// Emit publishes an EventCustom event of the node running on ctx to the listeners
+// of the runnable, for the progress a node wants to report before it completes.
+// Listeners receive it with CustomEventFromContext, and streams and
+// EventListeners in the Metadata "name" and "payload" keys of the event. It does
+// nothing outside of a graph run, or when the runnable has no listeners.
+//
+// Example:
+//
+//	for _, url := range sources {
+//	    graph.Emit(ctx, "source_found", url)
+//	}
+func Emit(ctx context.Context, name string, payload any) {
+	if emit, ok := ctx.Value(customEmitterKey{}).(customEmitter); ok && emit != nil {
+		emit(CustomEvent{Name: name, Payload: payload})
+	}
+}
+
+// CustomEventFromContext returns the event published with Emit. It is set in the
+// context passed to node listeners for EventCustom events.
+func CustomEventFromContext(ctx context.Context) (CustomEvent, bool) {
+	event, ok := ctx.Value(customEventKey{}).(CustomEvent)
+	return event, ok
+}
+
+// withCustomEmitter makes the events node publishes on ctx with Emit reach the
+// listeners of the runnable
+func (r *StateRunnable[S]) withCustomEmitter(ctx context.Context, nodeName string, state S) context.Context {
+	if r.nodeNotifier == nil {
+		return ctx
+	}
+
+	nodeCtx := ctx
+	return context.WithValue(ctx, customEmitterKey{}, customEmitter(func(event CustomEvent) {
+		r.nodeNotifier(context.WithValue(nodeCtx, customEventKey{}, event), EventCustom, nodeName, state, nil)
+	}))
+}
@@ -0,0 +1,93 @@
+package graph_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResearchGraph() *graph.StateGraph[map[string]any] {
+	g := graph.NewStateGraph[map[string]any]()
+	g.AddNode("researcher", "researcher", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		graph.Emit(ctx, "source_found", "https://example.com/a")
+		graph.Emit(ctx, "source_found", "https://example.com/b")
+		return map[string]any{"sources": 2}, nil
+	})
+	g.AddEdge("researcher", graph.END)
+	g.SetEntryPoint("researcher")
+	return g
+}
+
+func TestEmit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Ordering", func(t *testing.T) {
+		t.Parallel()
+		runnable, err := newResearchGraph().Compile()
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		var events []string
+		runnable.AddListener(graph.NodeListenerFunc[map[string]any](func(ctx context.Context, event graph.NodeEvent, nodeName string, _ map[string]any, _ error) {
+			mu.Lock()
+			defer mu.Unlock()
+			entry := nodeName + ":" + string(event)
+			if custom, ok := graph.CustomEventFromContext(ctx); ok {
+				entry += ":" + custom.Name + "=" + custom.Payload.(string)
+			}
+			events = append(events, entry)
+		}))
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"researcher:start",
+			"researcher:custom:source_found=https://example.com/a",
+			"researcher:custom:source_found=https://example.com/b",
+			"researcher:complete",
+		}, events)
+	})
+
+	t.Run("ListenableGraph", func(t *testing.T) {
+		t.Parallel()
+		g := graph.NewListenableStateGraph[map[string]any]()
+		g.AddNode("researcher", "researcher", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			graph.Emit(ctx, "source_found", "https://example.com/a")
+			return state, nil
+		})
+		g.AddEdge("researcher", graph.END)
+		g.SetEntryPoint("researcher")
+
+		var events []graph.StreamEvent[map[string]any]
+		g.AddGlobalListenerFiltered(graph.EventListenerFunc[map[string]any](func(_ context.Context, event *graph.StreamEvent[map[string]any]) {
+			events = append(events, *event)
+		}), graph.WithEvents(graph.EventCustom))
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "researcher", events[0].NodeName)
+		assert.Equal(t, 1, events[0].StepIndex)
+		assert.Equal(t, "source_found", events[0].Metadata["name"])
+		assert.Equal(t, "https://example.com/a", events[0].Metadata["payload"])
+	})
+
+	t.Run("NoListeners", func(t *testing.T) {
+		t.Parallel()
+		runnable, err := newResearchGraph().Compile()
+		require.NoError(t, err)
+
+		result, err := runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result["sources"])
+
+		// Outside of a run, Emit does nothing
+		graph.Emit(context.Background(), "source_found", "https://example.com")
+	})
+}
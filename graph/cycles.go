@@ -0,0 +1,159 @@
+package graph
+
+import "slices"
+
+// CompileReport lists warnings found while compiling a graph. Unlike validation
+// issues they don't prevent compilation.
+type CompileReport struct {
+	// Cycles are the groups of nodes that can reach each other through edges, each
+	// sorted by name, that were not declared with MarkCycle. A cycle that was not
+	// intended, such as one caused by a typo in AddEdge, can keep a run going until
+	// its context is cancelled.
+	Cycles [][]string
+}
+
+// HasWarnings reports whether the report contains any warning.
+func (r *CompileReport) HasWarnings() bool {
+	return r != nil && len(r.Cycles) > 0
+}
+
+// MarkCycle declares that the edges between the given nodes form an intentional
+// cycle, such as an agent loop, so that it is not reported in the CompileReport.
+// A detected cycle is only suppressed when every edge in it is between nodes of the
+// same MarkCycle call.
+//
+// Example:
+//
+//	g.AddConditionalEdgeWithMapping("agent", shouldContinue, map[string]string{"continue": "tools", "end": graph.END})
+//	g.AddEdge("tools", "agent")
+//	g.MarkCycle("agent", "tools")
+func (g *StateGraph[S]) MarkCycle(nodes ...string) {
+	g.markedCycles = append(g.markedCycles, slices.Clone(nodes))
+}
+
+// CompileReport returns the warnings found when the graph was compiled.
+func (r *StateRunnable[S]) CompileReport() *CompileReport {
+	return r.report
+}
+
+// compileReport builds the report of a graph that passed validation
+func (g *StateGraph[S]) compileReport() *CompileReport {
+	return &CompileReport{Cycles: g.unmarkedCycles()}
+}
+
+// edgeTargets returns the possible successors of every node. Conditional edges are
+// handled conservatively: every target of a path map is a possible successor, while
+// unmapped routers can't be followed.
+func (g *StateGraph[S]) edgeTargets() map[string][]string {
+	targets := make(map[string][]string)
+	add := func(from, to string) {
+		if to == END || slices.Contains(targets[from], to) {
+			return
+		}
+		targets[from] = append(targets[from], to)
+	}
+
+	for _, edge := range g.edges {
+		add(edge.From, edge.To)
+	}
+	for _, from := range sortedKeys(g.conditionalPathMaps) {
+		pathMap := g.conditionalPathMaps[from]
+		for _, key := range sortedKeys(pathMap) {
+			add(from, pathMap[key])
+		}
+	}
+	for _, from := range sortedKeys(g.errorEdges) {
+		add(from, g.errorEdges[from])
+	}
+	return targets
+}
+
+// unmarkedCycles returns the strongly connected components of the graph that form
+// a cycle not fully covered by MarkCycle, in order of their first node
+func (g *StateGraph[S]) unmarkedCycles() [][]string {
+	targets := g.edgeTargets()
+
+	var cycles [][]string
+	for _, component := range stronglyConnected(sortedKeys(g.nodes), targets) {
+		isCycle := len(component) > 1 || slices.Contains(targets[component[0]], component[0])
+		if !isCycle {
+			continue
+		}
+
+		marked := true
+		for _, from := range component {
+			for _, to := range targets[from] {
+				if slices.Contains(component, to) && !g.cycleEdgeMarked(from, to) {
+					marked = false
+				}
+			}
+		}
+		if !marked {
+			cycles = append(cycles, component)
+		}
+	}
+
+	slices.SortFunc(cycles, func(a, b []string) int {
+		return slices.Compare(a, b)
+	})
+	return cycles
+}
+
+// cycleEdgeMarked reports whether a single MarkCycle call contains both ends of an edge
+func (g *StateGraph[S]) cycleEdgeMarked(from, to string) bool {
+	for _, nodes := range g.markedCycles {
+		if slices.Contains(nodes, from) && slices.Contains(nodes, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// stronglyConnected returns the strongly connected components of the graph given
+// by nodes and targets, using Tarjan's algorithm. Each component is sorted.
+func stronglyConnected(nodes []string, targets map[string][]string) [][]string {
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(node string)
+	visit = func(node string) {
+		index[node] = len(index)
+		lowLink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range targets[node] {
+			if _, seen := index[next]; !seen {
+				visit(next)
+				lowLink[node] = min(lowLink[node], lowLink[next])
+			} else if onStack[next] {
+				lowLink[node] = min(lowLink[node], index[next])
+			}
+		}
+
+		if lowLink[node] == index[node] {
+			var component []string
+			for {
+				last := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[last] = false
+				component = append(component, last)
+				if last == node {
+					break
+				}
+			}
+			slices.Sort(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, seen := index[node]; !seen {
+			visit(node)
+		}
+	}
+	return components
+}
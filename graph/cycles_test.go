@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileReportCycles(t *testing.T) {
+	noop := func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return state, nil
+	}
+	shouldContinue := func(ctx context.Context, state map[string]any) string {
+		return "end"
+	}
+	newAgentGraph := func() *StateGraph[map[string]any] {
+		g := NewStateGraph[map[string]any]()
+		for _, name := range []string{"agent", "tools", "a", "b", "c"} {
+			g.AddNode(name, name, noop)
+		}
+		g.SetEntryPoint("agent")
+		g.AddConditionalEdgeWithMapping("agent", shouldContinue, map[string]string{
+			"continue": "tools",
+			"end":      "a",
+		})
+		g.AddEdge("tools", "agent")
+		g.AddEdge("a", "b")
+		g.AddEdge("b", "c")
+		g.AddEdge("c", END)
+		return g
+	}
+
+	t.Run("ReportsCycles", func(t *testing.T) {
+		g := newAgentGraph()
+		// Typo: b should have gone to c only
+		g.AddEdge("b", "a")
+		g.AddNode("poll", "poll", noop)
+		g.AddEdge("c", "poll")
+		g.AddEdge("poll", "poll")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err, "cycles are warnings, not errors")
+		report := runnable.CompileReport()
+		assert.True(t, report.HasWarnings())
+		assert.Equal(t, [][]string{{"a", "b"}, {"agent", "tools"}, {"poll"}}, report.Cycles)
+	})
+
+	t.Run("MarkCycle", func(t *testing.T) {
+		g := newAgentGraph()
+		g.MarkCycle("agent", "tools")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		assert.False(t, runnable.CompileReport().HasWarnings())
+
+		// An unmarked edge joining the marked loop is still reported
+		g.AddEdge("a", "agent")
+		runnable, err = g.Compile(WithAllowDeadEnds())
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"a", "agent", "tools"}}, runnable.CompileReport().Cycles)
+	})
+
+	t.Run("ErrorEdges", func(t *testing.T) {
+		g := newAgentGraph()
+		g.MarkCycle("agent", "tools")
+		g.AddErrorEdge("c", "b")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"b", "c"}}, runnable.CompileReport().Cycles)
+	})
+
+	t.Run("UnknownNode", func(t *testing.T) {
+		g := newAgentGraph()
+		g.MarkCycle("agent", "missing")
+
+		_, err := g.Compile()
+		assert.ErrorIs(t, err, ErrNodeNotFound)
+	})
+
+	t.Run("ListenableRunnable", func(t *testing.T) {
+		g := NewListenableStateGraph[map[string]any]()
+		g.AddNode("a", "a", noop)
+		g.AddNode("b", "b", noop)
+		g.SetEntryPoint("a")
+		g.AddEdge("a", "b")
+		g.AddConditionalEdgeWithMapping("b", shouldContinue, map[string]string{"continue": "a", "end": END})
+
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"a", "b"}}, runnable.CompileReport().Cycles)
+	})
+}
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrDuplicateNode is reported when a deferred node has the name of another node.
+var ErrDuplicateNode = errors.New("duplicate node name")
+
+type deferredRunKey struct{}
+
+// deferredRun is the outcome of the run a deferred node executes after
+type deferredRun struct {
+	err error
+}
+
+// AddDeferredNode adds a node that runs once the graph has finished, whether the run
+// ended normally, failed, was interrupted or was cancelled, for example to flush
+// metrics, close resources or write an audit record. Deferred nodes have no edges;
+// they run one after the other in the order they were added, with the final state
+// of the run, or the state of the last completed step when it failed. For
+// map[string]any states the error the run ended with is set in ErrorStateKey, and
+// RunErrorFromContext returns it for any state type.
+//
+// Deferred nodes run with a context that is not cancelled with the run's, so they
+// can clean up after a cancelled run. Their updates are merged into the state
+// returned by a successful run, but are not checkpointed. An error from a deferred
+// node is returned only when the run itself succeeded, so it never masks the
+// run's error.
+//
+// Example:
+//
+//	g.AddDeferredNode("audit", "Write the audit record", func(ctx context.Context, state State) (State, error) {
+//	    return state, audit.Write(ctx, state.RequestID, graph.RunErrorFromContext(ctx))
+//	})
+func (g *StateGraph[S]) AddDeferredNode(name string, description string, fn func(ctx context.Context, state S) (S, error)) {
+	node := TypedNode[S]{Name: name, Description: description, Function: fn}
+	if i := slices.IndexFunc(g.deferredNodes, func(n TypedNode[S]) bool { return n.Name == name }); i >= 0 {
+		g.deferredNodes[i] = node
+		return
+	}
+	g.deferredNodes = append(g.deferredNodes, node)
+}
+
+// AddDeferredNode adds a deferred node with listener capabilities; see
+// StateGraph.AddDeferredNode. Listeners can tell its events apart with IsDeferredNode.
+func (g *ListenableStateGraph[S]) AddDeferredNode(name string, description string, fn func(ctx context.Context, state S) (S, error)) *ListenableNode[S] {
+	g.StateGraph.AddDeferredNode(name, description, fn)
+	listenableNode := NewListenableNode(TypedNode[S]{Name: name, Description: description, Function: fn})
+	g.listenableNodes[name] = listenableNode
+	return listenableNode
+}
+
+// IsDeferredNode reports whether the node being executed is a deferred node: it is
+// set in the context passed to deferred nodes, their middleware and their listeners.
+func IsDeferredNode(ctx context.Context) bool {
+	_, ok := ctx.Value(deferredRunKey{}).(*deferredRun)
+	return ok
+}
+
+// RunErrorFromContext returns the error the run ended with, in the context of a
+// deferred node. It is nil when the run succeeded or outside of deferred nodes.
+func RunErrorFromContext(ctx context.Context) error {
+	if run, ok := ctx.Value(deferredRunKey{}).(*deferredRun); ok {
+		return run.err
+	}
+	return nil
+}
+
+// runDeferred runs the deferred nodes after a run that returned result and runErr;
+// last is the state of the run's last completed step.
+func (r *StateRunnable[S]) runDeferred(ctx context.Context, config *Config, last, result S, runErr error) (S, error) {
+	ctx = context.WithValue(context.WithoutCancel(ctx), deferredRunKey{}, &deferredRun{err: runErr})
+
+	state := result
+	if runErr != nil {
+		state = withStateValues(last, map[string]any{ErrorStateKey: runErr.Error()})
+	}
+
+	for _, node := range r.graph.deferredNodes {
+		res, err := r.runNode(ctx, node, state)
+		if err == nil {
+			var merged S
+			if merged, err = r.mergeState(ctx, state, []S{res}, []string{node.Name}); err == nil {
+				state = merged
+			}
+		}
+		if err != nil && runErr == nil {
+			err = fmt.Errorf("error in deferred node %s: %w", node.Name, err)
+			r.notifyChainError(ctx, config, config.RunID, err)
+			return state, err
+		}
+	}
+
+	if runErr != nil {
+		return result, runErr
+	}
+	return state, nil
+}
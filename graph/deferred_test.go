@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferredNodes(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type finalized struct {
+		state    map[string]any
+		runErr   error
+		deferred bool
+		ctxErr   error
+	}
+	newGraph := func(work NodeFunc[map[string]any], got *[]finalized) *ListenableStateGraph[map[string]any] {
+		g := NewListenableStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		g.AddNode("work", "work", work)
+		g.AddNode("after", "after", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"after": true}, nil
+		})
+		g.SetEntryPoint("work")
+		g.AddEdge("work", "after")
+		g.AddEdge("after", END)
+		g.AddDeferredNode("finalize", "Flush metrics", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			*got = append(*got, finalized{state: state, runErr: RunErrorFromContext(ctx), deferred: IsDeferredNode(ctx), ctxErr: ctx.Err()})
+			return map[string]any{"finalized": true}, nil
+		})
+		return g
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		var got []finalized
+		runnable, err := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			assert.False(t, IsDeferredNode(ctx))
+			return map[string]any{"work": true}, nil
+		}, &got).CompileListenable()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"work": true, "after": true, "finalized": true}, res)
+		require.Len(t, got, 1)
+		assert.True(t, got[0].deferred)
+		assert.NoError(t, got[0].runErr)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		var got []finalized
+		g := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, errBoom
+		}, &got)
+		g.AddDeferredNode("close", "Close resources", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, errors.New("close failed")
+		})
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{"input": 1})
+		require.ErrorIs(t, err, errBoom, "a failing deferred node doesn't mask the run's error")
+		require.Len(t, got, 1)
+		assert.ErrorIs(t, got[0].runErr, errBoom)
+		assert.Equal(t, 1, got[0].state["input"], "the last completed state is passed")
+		assert.Contains(t, got[0].state[ErrorStateKey], "boom")
+	})
+
+	t.Run("ErrorWithoutSchema", func(t *testing.T) {
+		// Without a schema a result replaces the state, so the zero result of the
+		// failed node must not reach the deferred nodes
+		g := NewStateGraph[map[string]any]()
+		g.AddNode("a", "a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"input": state["input"], "a": true}, nil
+		})
+		g.AddNode("b", "b", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, errBoom
+		})
+		g.SetEntryPoint("a")
+		g.AddEdge("a", "b")
+		g.AddEdge("b", END)
+		var seen map[string]any
+		g.AddDeferredNode("finalize", "Flush metrics", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			seen = state
+			return state, nil
+		})
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{"input": 1})
+		require.ErrorIs(t, err, errBoom)
+		assert.Equal(t, 1, seen["input"], "the input survives the failure")
+		assert.Equal(t, true, seen["a"], "the earlier node output survives the failure")
+		assert.Contains(t, seen[ErrorStateKey], "boom")
+	})
+
+	t.Run("DeferredError", func(t *testing.T) {
+		var got []finalized
+		g := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"work": true}, nil
+		}, &got)
+		g.AddDeferredNode("close", "Close resources", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			panic("close failed")
+		})
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(context.Background(), map[string]any{})
+		require.ErrorIs(t, err, ErrNodePanic)
+		assert.ErrorContains(t, err, "error in deferred node close")
+		assert.Equal(t, true, res["finalized"], "earlier deferred nodes are merged")
+	})
+
+	t.Run("Interrupt", func(t *testing.T) {
+		var got []finalized
+		g := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"work": true}, nil
+		}, &got)
+		runnable, err := g.CompileListenable(WithInterruptAfterNodes("work"))
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		var interrupt *GraphInterrupt
+		require.ErrorAs(t, err, &interrupt)
+		require.Len(t, got, 1)
+		assert.Equal(t, true, got[0].state["work"])
+		assert.ErrorAs(t, got[0].runErr, &interrupt)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		var got []finalized
+		ctx, cancel := context.WithCancel(context.Background())
+		runnable, err := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			cancel()
+			return nil, ctx.Err()
+		}, &got).CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(ctx, map[string]any{})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Len(t, got, 1)
+		assert.NoError(t, got[0].ctxErr, "deferred nodes are not cancelled with the run")
+	})
+
+	t.Run("Listeners", func(t *testing.T) {
+		var got []finalized
+		g := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"work": true}, nil
+		}, &got)
+
+		var mu sync.Mutex
+		deferredEvents := map[string][]NodeEvent{}
+		g.AddGlobalListener(NodeListenerFunc[map[string]any](func(ctx context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {
+			if IsDeferredNode(ctx) {
+				mu.Lock()
+				deferredEvents[nodeName] = append(deferredEvents[nodeName], event)
+				mu.Unlock()
+			}
+		}))
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]NodeEvent{"finalize": {NodeEventStart, NodeEventComplete}}, deferredEvents)
+	})
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		var got []finalized
+		g := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		}, &got)
+		g.AddDeferredNode("after", "after", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		})
+		_, err := g.CompileListenable()
+		assert.ErrorIs(t, err, ErrDuplicateNode)
+	})
+}
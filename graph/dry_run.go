@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Markers appended to the plan returned by DryRun when it stops early.
+const (
+	// DryRunDynamic marks a node without outgoing edges, whose successors are only
+	// known at runtime from the Command it returns
+	DryRunDynamic = "<dynamic>"
+
+	// DryRunCycle marks a step the plan has already visited. Since nodes are not run
+	// the state does not change, so following the cycle would repeat forever.
+	DryRunCycle = "<cycle>"
+)
+
+// DryRun returns the nodes that would run for initialState, in execution order,
+// without running them. Static edges are followed and conditional edge routers are
+// evaluated against the initial state, which suits routers that are cheap and free
+// of side effects, as they should be. Nodes that run in parallel in the same step are
+// listed in lexical order.
+//
+// The plan stops with DryRunDynamic after a node that has no outgoing edges, and with
+// DryRunCycle when a step would repeat. Errors from routing, such as an unknown path
+// map key, are returned with the plan so far.
+//
+// Example:
+//
+//	plan, err := runnable.DryRun(ctx, initialState)
+//	// plan: [plan research write <dynamic>]
+func (r *StateRunnable[S]) DryRun(ctx context.Context, initialState S) ([]string, error) {
+	state, err := r.initState(initialState)
+	if err != nil {
+		return nil, err
+	}
+
+	currentNodes, err := r.entryNodes(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []string
+	visited := make(map[string]bool)
+	for {
+		activeNodes := slices.DeleteFunc(slices.Clone(currentNodes), func(node string) bool {
+			return node == END
+		})
+		if len(activeNodes) == 0 {
+			return plan, nil
+		}
+		slices.Sort(activeNodes)
+
+		step := strings.Join(activeNodes, "\x00")
+		if visited[step] {
+			return append(plan, DryRunCycle), nil
+		}
+		visited[step] = true
+
+		for _, node := range activeNodes {
+			if _, ok := r.graph.nodes[node]; !ok {
+				return plan, fmt.Errorf("%w: %s", ErrNodeNotFound, node)
+			}
+		}
+		plan = append(plan, activeNodes...)
+
+		for _, node := range activeNodes {
+			if !r.graph.hasOutgoingEdges(node) {
+				return append(plan, DryRunDynamic), nil
+			}
+		}
+
+		currentNodes, err = r.determineNextNodes(ctx, activeNodes, state, nil)
+		if err != nil {
+			return plan, err
+		}
+	}
+}
+
+// hasOutgoingEdges reports whether node has a static or conditional outgoing edge
+func (g *StateGraph[S]) hasOutgoingEdges(node string) bool {
+	if _, ok := g.conditionalEdges[node]; ok {
+		return true
+	}
+	return slices.ContainsFunc(g.edges, func(edge Edge) bool {
+		return edge.From == node
+	})
+}
@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	ran := map[string]bool{}
+	node := func(name string) func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			ran[name] = true
+			return state, nil
+		}
+	}
+
+	t.Run("StaticAndConditional", func(t *testing.T) {
+		g := NewStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		for _, name := range []string{"plan", "search", "scrape", "write", "review"} {
+			g.AddNode(name, name, node(name))
+		}
+		g.SetEntryPoint("plan")
+		g.AddEdge("plan", "search")
+		g.AddEdge("plan", "scrape")
+		g.AddEdge("search", "write")
+		g.AddEdge("scrape", "write")
+		g.AddConditionalEdge("write", func(ctx context.Context, state map[string]any) string {
+			if state["review"] == true {
+				return "review"
+			}
+			return END
+		})
+		g.AddEdge("review", END)
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		plan, err := runnable.DryRun(ctx, map[string]any{"review": true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plan", "scrape", "search", "write", "review"}, plan)
+
+		plan, err = runnable.DryRun(ctx, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plan", "scrape", "search", "write"}, plan)
+		assert.Empty(t, ran, "nodes must not run")
+	})
+
+	t.Run("StopsAtDynamicNode", func(t *testing.T) {
+		g := NewStateGraph[map[string]any]()
+		g.AddNode("plan", "plan", node("plan"))
+		g.AddNode("router", "returns a Command", node("router"))
+		g.SetEntryPoint("plan")
+		g.AddEdge("plan", "router")
+
+		runnable, err := g.Compile(WithAllowDeadEnds())
+		require.NoError(t, err)
+
+		plan, err := runnable.DryRun(ctx, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plan", "router", DryRunDynamic}, plan)
+	})
+
+	t.Run("StopsAtCycle", func(t *testing.T) {
+		g := NewStateGraph[map[string]any]()
+		g.AddNode("agent", "agent", node("agent"))
+		g.AddNode("tools", "tools", node("tools"))
+		g.SetEntryPoint("agent")
+		g.AddConditionalEdge("agent", func(ctx context.Context, state map[string]any) string {
+			return "tools"
+		})
+		g.AddEdge("tools", "agent")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		plan, err := runnable.DryRun(ctx, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"agent", "tools", DryRunCycle}, plan)
+	})
+
+	t.Run("UnknownPathKey", func(t *testing.T) {
+		g := NewStateGraph[map[string]any]()
+		g.AddNode("a", "a", node("a"))
+		g.AddNode("b", "b", node("b"))
+		g.SetEntryPoint("a")
+		g.AddConditionalEdgeWithMapping("a", func(ctx context.Context, state map[string]any) string {
+			return "missing"
+		}, map[string]string{"next": "b"})
+		g.AddEdge("b", END)
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		plan, err := runnable.DryRun(ctx, map[string]any{})
+		assert.ErrorIs(t, err, ErrUnknownPathKey)
+		assert.Equal(t, []string{"a"}, plan)
+	})
+}
@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"context"
+	"maps"
+)
+
+// State keys written by the error handler into map[string]any states. They are
+// namespaced so they don't collide with the keys of the graph.
+const (
+	// ErrorStateKey holds the message of the error that routed execution to the error handler
+	ErrorStateKey = "__error_message__"
+
+	// ErrorNodeStateKey holds the name of the node that failed
+	ErrorNodeStateKey = "__error_node__"
+
+	// ErrorEdgeStateKey holds the failure that followed an error edge, as a
+	// map[string]any with "message" and "node" entries. It is removed once the
+	// recovery node succeeds.
+	ErrorEdgeStateKey = "__error__"
+)
+
+// NodeError describes a node failure handled by the graph's error handler.
+type NodeError struct {
+	// Node is the name of the node that failed
+	Node string
+
+	// Err is the error the node failed with, as reported by the runnable
+	Err error
+}
+
+// Error implements the error interface.
+func (e *NodeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the node's error.
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+type nodeErrorKey struct{}
+
+// NodeErrorFromContext returns the failure that routed execution to the error
+// handler. It is only set in the context of the handler node, and is the way to
+// read the error for state types other than map[string]any.
+func NodeErrorFromContext(ctx context.Context) (*NodeError, bool) {
+	nodeErr, ok := ctx.Value(nodeErrorKey{}).(*NodeError)
+	return nodeErr, ok
+}
+
+// SetErrorHandler routes execution to the named node when a node returns an error,
+// instead of failing the run. For map[string]any states, the error message and the
+// failing node are written to ErrorStateKey and ErrorNodeStateKey; the handler can
+// also read them with NodeErrorFromContext. The handler receives the state the
+// failed node received, with the updates from nodes that succeeded in the same
+// step, whose outgoing edges are not followed; the result of the failed node is
+// discarded.
+//
+// Errors returned by the handler itself, interrupts, and errors from nodes added
+// with WithFailFast still fail the run.
+//
+// Example:
+//
+//	g.AddNode("apologize", "Recover from failures", apologize)
+//	g.AddEdge("apologize", graph.END)
+//	g.SetErrorHandler("apologize")
+func (g *StateGraph[S]) SetErrorHandler(nodeName string) {
+	g.errorHandler = nodeName
+}
+
+// WithFailFast returns a NodeOption that makes errors from the node fail the run
+// even when the graph has an error handler.
+func WithFailFast() NodeOption {
+	return func(o *nodeOptions) {
+		o.failFast = true
+	}
+}
+
+// AddErrorEdge routes failures of the from node to the to node, which takes
+// precedence over SetErrorHandler and WithFailFast for that node. Other nodes keep
+// their usual error handling. The recovery node receives the state the failed node
+// received, without its result. For map[string]any states, it finds the failure
+// under ErrorEdgeStateKey, which is cleared after it succeeds; it can also
+// use NodeErrorFromContext.
+//
+// Example:
+//
+//	g.AddErrorEdge("call_api", "use_cache")
+func (g *StateGraph[S]) AddErrorEdge(from, to string) {
+	g.errorEdges[from] = to
+}
+
+// handleNodeError decides where a failure of node is routed: the target of its error
+// edge, or the graph's error handler. It returns the state to pass to that node, its
+// name, and whether the error was handled.
+func (r *StateRunnable[S]) handleNodeError(state S, node string, err error) (S, *NodeError, string, bool) {
+	nodeErr := &NodeError{Node: node, Err: err}
+
+	if target, ok := r.graph.errorEdges[node]; ok {
+		return withStateValues(state, map[string]any{
+			ErrorEdgeStateKey: map[string]any{"message": err.Error(), "node": node},
+		}), nodeErr, target, true
+	}
+
+	handler := r.graph.errorHandler
+	if handler == "" || node == handler || r.graph.failFastNodes[node] {
+		return state, nil, "", false
+	}
+	return withStateValues(state, map[string]any{
+		ErrorStateKey:     err.Error(),
+		ErrorNodeStateKey: node,
+	}), nodeErr, handler, true
+}
+
+// clearErrorEdgeState removes ErrorEdgeStateKey after the recovery node succeeded
+func clearErrorEdgeState[S any](state S) S {
+	m, ok := any(state).(map[string]any)
+	if !ok {
+		return state
+	}
+	if _, found := m[ErrorEdgeStateKey]; !found {
+		return state
+	}
+	cleared := maps.Clone(m)
+	delete(cleared, ErrorEdgeStateKey)
+	if s, ok := any(cleared).(S); ok {
+		return s
+	}
+	return state
+}
+
+// withStateValues sets values in a copy of state when it is a map[string]any,
+// bypassing reducers; other state types are returned unchanged.
+func withStateValues[S any](state S, values map[string]any) S {
+	m, ok := any(state).(map[string]any)
+	if !ok {
+		return state
+	}
+	updated := make(map[string]any, len(m)+len(values))
+	maps.Copy(updated, m)
+	maps.Copy(updated, values)
+	if s, ok := any(updated).(S); ok {
+		return s
+	}
+	return state
+}
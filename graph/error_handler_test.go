@@ -0,0 +1,312 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errToolFailed = errors.New("tool failed")
+
+func newErrorHandlerGraph(handler func(ctx context.Context, state map[string]any) (map[string]any, error)) (*StateGraph[map[string]any], *int) {
+	g := NewStateGraph[map[string]any]()
+	g.SetSchema(NewMapSchema())
+
+	handlerCalls := 0
+	g.AddNode("plan", "plan", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"plan": "call tool"}, nil
+	})
+	g.AddNode("tool", "tool", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return nil, errToolFailed
+	})
+	g.AddNode("answer", "answer", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"reply": "done"}, nil
+	})
+	g.AddNode("handle_error", "handle_error", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		handlerCalls++
+		return handler(ctx, state)
+	})
+	g.SetEntryPoint("plan")
+	g.AddEdge("plan", "tool")
+	g.AddEdge("tool", "answer")
+	g.AddEdge("answer", END)
+	g.AddEdge("handle_error", END)
+	g.SetErrorHandler("handle_error")
+	return g, &handlerCalls
+}
+
+func TestErrorHandler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("RoutesToHandler", func(t *testing.T) {
+		g, calls := newErrorHandlerGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			nodeErr, ok := NodeErrorFromContext(ctx)
+			require.True(t, ok)
+			assert.Equal(t, "tool", nodeErr.Node)
+			assert.ErrorIs(t, nodeErr, errToolFailed)
+			return map[string]any{"reply": "sorry, " + state[ErrorNodeStateKey].(string) + " failed"}, nil
+		})
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(ctx, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, "sorry, tool failed", res["reply"])
+		assert.Equal(t, "error in node tool: tool failed", res[ErrorStateKey])
+		assert.Equal(t, "tool", res[ErrorNodeStateKey])
+		assert.Equal(t, "call tool", res["plan"])
+	})
+
+	t.Run("FailFastNode", func(t *testing.T) {
+		g, calls := newErrorHandlerGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		})
+		g.AddNodeWithOptions("tool", "tool", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, errToolFailed
+		}, WithFailFast())
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(ctx, map[string]any{})
+		assert.ErrorIs(t, err, errToolFailed)
+		assert.Equal(t, 0, *calls)
+	})
+
+	t.Run("HandlerFails", func(t *testing.T) {
+		errHandler := errors.New("handler failed")
+		g, calls := newErrorHandlerGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, errHandler
+		})
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(ctx, map[string]any{})
+		assert.ErrorIs(t, err, errHandler)
+		assert.Equal(t, 1, *calls, "a failing handler must not be re-entered")
+	})
+
+	t.Run("TypedState", func(t *testing.T) {
+		type state struct {
+			Reply string
+		}
+
+		g := NewStateGraph[state]()
+		g.AddNode("work", "work", func(ctx context.Context, s state) (state, error) {
+			return s, errToolFailed
+		})
+		g.AddNode("recover", "recover", func(ctx context.Context, s state) (state, error) {
+			nodeErr, ok := NodeErrorFromContext(ctx)
+			if !ok {
+				return s, errors.New("missing node error")
+			}
+			return state{Reply: nodeErr.Error()}, nil
+		})
+		g.SetEntryPoint("work")
+		g.AddEdge("work", END)
+		g.AddEdge("recover", END)
+		g.SetErrorHandler("recover")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(ctx, state{})
+		require.NoError(t, err)
+		assert.Equal(t, "error in node work: tool failed", res.Reply)
+	})
+
+	t.Run("KeepsStateWithoutSchema", func(t *testing.T) {
+		// Without a schema a result replaces the state, so the zero result of the failed
+		// node must not reach the handler
+		g := NewStateGraph[map[string]any]()
+		g.AddNode("plan", "plan", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"question": state["question"], "plan": "call tool"}, nil
+		})
+		g.AddNode("tool", "tool", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, errToolFailed
+		})
+		var seen map[string]any
+		g.AddNode("handle_error", "handle_error", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			seen = state
+			return state, nil
+		})
+		g.SetEntryPoint("plan")
+		g.AddEdge("plan", "tool")
+		g.AddEdge("tool", END)
+		g.AddEdge("handle_error", END)
+		g.SetErrorHandler("handle_error")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(ctx, map[string]any{"question": "2+2?"})
+		require.NoError(t, err)
+		assert.Equal(t, "2+2?", seen["question"])
+		assert.Equal(t, "call tool", seen["plan"])
+		assert.Equal(t, "tool", seen[ErrorNodeStateKey])
+	})
+
+	t.Run("KeepsTypedState", func(t *testing.T) {
+		type state struct {
+			Question, Plan, Reply string
+		}
+
+		g := NewStateGraph[state]()
+		g.AddNode("plan", "plan", func(ctx context.Context, s state) (state, error) {
+			s.Plan = "call tool"
+			return s, nil
+		})
+		g.AddNode("work", "work", func(ctx context.Context, s state) (state, error) {
+			return state{}, errToolFailed
+		})
+		g.AddNode("recover", "recover", func(ctx context.Context, s state) (state, error) {
+			s.Reply = "sorry"
+			return s, nil
+		})
+		g.SetEntryPoint("plan")
+		g.AddEdge("plan", "work")
+		g.AddEdge("work", END)
+		g.AddEdge("recover", END)
+		g.SetErrorHandler("recover")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(ctx, state{Question: "2+2?"})
+		require.NoError(t, err)
+		assert.Equal(t, state{Question: "2+2?", Plan: "call tool", Reply: "sorry"}, res)
+	})
+
+	t.Run("UnknownHandler", func(t *testing.T) {
+		g, _ := newErrorHandlerGraph(nil)
+		g.SetErrorHandler("missing")
+
+		_, err := g.Compile()
+		assert.ErrorIs(t, err, ErrNodeNotFound)
+	})
+}
+
+func TestErrorEdge(t *testing.T) {
+	ctx := context.Background()
+
+	newGraph := func() *StateGraph[map[string]any] {
+		g := NewStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		g.AddNode("fetch", "fetch", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			if state["fail"] == "fetch" {
+				return nil, errToolFailed
+			}
+			return map[string]any{"data": "live"}, nil
+		})
+		g.AddNode("use_cache", "use_cache", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			failure := state[ErrorEdgeStateKey].(map[string]any)
+			return map[string]any{"data": "cached", "recovered_from": failure["node"], "reason": failure["message"]}, nil
+		})
+		g.AddNode("report", "report", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			if state["fail"] == "report" {
+				return nil, errToolFailed
+			}
+			_, pending := state[ErrorEdgeStateKey]
+			return map[string]any{"error_pending": pending}, nil
+		})
+		g.SetEntryPoint("fetch")
+		g.AddEdge("fetch", "report")
+		g.AddEdge("use_cache", "report")
+		g.AddEdge("report", END)
+		g.AddErrorEdge("fetch", "use_cache")
+		return g
+	}
+
+	t.Run("RoutesAndClears", func(t *testing.T) {
+		runnable, err := newGraph().Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(ctx, map[string]any{"fail": "fetch"})
+		require.NoError(t, err)
+		assert.Equal(t, "cached", res["data"])
+		assert.Equal(t, "fetch", res["recovered_from"])
+		assert.Equal(t, "error in node fetch: tool failed", res["reason"])
+		assert.Equal(t, false, res["error_pending"], "error is cleared after the recovery node succeeds")
+		assert.NotContains(t, res, ErrorEdgeStateKey)
+	})
+
+	t.Run("OtherNodesFailFast", func(t *testing.T) {
+		runnable, err := newGraph().Compile()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(ctx, map[string]any{"fail": "report"})
+		assert.ErrorIs(t, err, errToolFailed)
+	})
+
+	t.Run("TakesPrecedenceOverHandler", func(t *testing.T) {
+		g := newGraph()
+		g.AddNode("handle_error", "handle_error", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"handled": true}, nil
+		})
+		g.AddEdge("handle_error", END)
+		g.SetErrorHandler("handle_error")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(ctx, map[string]any{"fail": "fetch"})
+		require.NoError(t, err)
+		assert.Equal(t, "cached", res["data"])
+		assert.NotContains(t, res, "handled")
+	})
+
+	t.Run("KeepsStateWithoutSchema", func(t *testing.T) {
+		g := NewStateGraph[map[string]any]()
+		g.AddNode("plan", "plan", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"question": state["question"], "plan": "fetch"}, nil
+		})
+		g.AddNode("fetch", "fetch", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, errToolFailed
+		})
+		var seen map[string]any
+		g.AddNode("use_cache", "use_cache", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			seen = state
+			return map[string]any{"question": state["question"], "plan": state["plan"], "data": "cached"}, nil
+		})
+		g.SetEntryPoint("plan")
+		g.AddEdge("plan", "fetch")
+		g.AddEdge("fetch", END)
+		g.AddEdge("use_cache", END)
+		g.AddErrorEdge("fetch", "use_cache")
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(ctx, map[string]any{"question": "news?"})
+		require.NoError(t, err)
+		assert.Equal(t, "news?", seen["question"], "the recovery node receives the state the failed node received")
+		assert.Equal(t, "fetch", seen["plan"])
+		assert.Equal(t, "fetch", seen[ErrorEdgeStateKey].(map[string]any)["node"])
+		assert.Equal(t, map[string]any{"question": "news?", "plan": "fetch", "data": "cached"}, res)
+	})
+
+	t.Run("UnknownTarget", func(t *testing.T) {
+		g := newGraph()
+		g.AddErrorEdge("report", "missing")
+
+		_, err := g.Compile()
+		assert.ErrorIs(t, err, ErrNodeNotFound)
+		assert.ErrorContains(t, err, "missing (edge report -> missing)")
+	})
+
+	t.Run("Visualization", func(t *testing.T) {
+		exporter := NewExporter(newGraph())
+
+		mermaid := exporter.DrawMermaid()
+		assert.Contains(t, mermaid, "    fetch -.->|error| use_cache\n    linkStyle 4 stroke:red,color:red\n")
+
+		dot := exporter.DrawDOT()
+		assert.Contains(t, dot, `fetch -> use_cache [style=dashed, color=red, fontcolor=red, label="error"];`)
+	})
+}
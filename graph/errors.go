@@ -1,6 +1,9 @@
 package graph
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // NodeInterrupt is returned when a node requests an interrupt (e.g. waiting for human input).
 type NodeInterrupt struct {
@@ -13,3 +16,44 @@ type NodeInterrupt struct {
 func (e *NodeInterrupt) Error() string {
 	return fmt.Sprintf("interrupt at node %s: %v", e.Node, e.Value)
 }
+
+// NodePanicError is the error a node fails with when its function panics. It can be
+// retried and routed by error edges like any other node error; errors.Is reports
+// it as ErrNodePanic. The stack trace is kept for logging, but is not part of the
+// message.
+type NodePanicError struct {
+	// Node is the name of the node that panicked
+	Node string
+	// Value is the value the node panicked with
+	Value any
+	// Stack is the stack trace of the panic
+	Stack []byte
+}
+
+func (e *NodePanicError) Error() string {
+	return fmt.Sprintf("panic in node %s: %v", e.Node, e.Value)
+}
+
+// Is reports whether target is ErrNodePanic.
+func (e *NodePanicError) Is(target error) bool {
+	return target == ErrNodePanic
+}
+
+// Unwrap returns the value the node panicked with when it is an error.
+func (e *NodePanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// RendererNotFoundError is returned by Exporter.RenderImage when none of the external
+// renderers it supports is installed. Callers can fall back to a text format.
+type RendererNotFoundError struct {
+	// Format is the requested image format
+	Format string
+	// Renderers lists the executables that were looked up in PATH
+	Renderers []string
+}
+
+func (e *RendererNotFoundError) Error() string {
+	return fmt.Sprintf("no renderer available for %s: install one of %s", e.Format, strings.Join(e.Renderers, ", "))
+}
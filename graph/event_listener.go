@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"context"
+	"time"
+)
+
+// EventListener is a listener receiving node events as StreamEvents, with the
+// details NodeListener doesn't carry: the Duration of completed and failed nodes,
+// the StepIndex of the step they ran in, and Metadata such as the attempt number
+// of the node execution. The event must not be modified, as it is shared with the
+// other listeners.
+//
+// Nodes and runnables take NodeListeners; an EventListener is added with
+// AsNodeListener, or as an EventListenerFunc. Listeners implementing both
+// interfaces are notified through OnEvent.
+type EventListener[S any] interface {
+	// OnEvent is called when a node event occurs
+	OnEvent(ctx context.Context, event *StreamEvent[S])
+}
+
+// EventListenerFunc is a function adapter for EventListener, which is a
+// NodeListener too
+type EventListenerFunc[S any] func(ctx context.Context, event *StreamEvent[S])
+
+// OnEvent implements the EventListener interface
+func (f EventListenerFunc[S]) OnEvent(ctx context.Context, event *StreamEvent[S]) {
+	f(ctx, event)
+}
+
+// OnNodeEvent implements the NodeListener interface, for callers notifying the
+// listener directly
+func (f EventListenerFunc[S]) OnNodeEvent(ctx context.Context, event NodeEvent, nodeName string, state S, err error) {
+	f(ctx, newStreamEvent(ctx, event, nodeName, state, err))
+}
+
+// eventListenerAdapter is the NodeListener of an EventListener
+type eventListenerAdapter[S any] struct {
+	listener EventListener[S]
+}
+
+// AsNodeListener returns listener as a NodeListener, which can be added to nodes
+// and runnables, and removed with RemoveListenerByFunc and RemoveGlobalListener
+// by passing an adapter of the same listener.
+//
+// Example:
+//
+//	g.AddGlobalListener(graph.AsNodeListener[State](timings))
+func AsNodeListener[S any](listener EventListener[S]) NodeListener[S] {
+	return eventListenerAdapter[S]{listener: listener}
+}
+
+// OnEvent implements the EventListener interface
+func (a eventListenerAdapter[S]) OnEvent(ctx context.Context, event *StreamEvent[S]) {
+	a.listener.OnEvent(ctx, event)
+}
+
+// OnNodeEvent implements the NodeListener interface
+func (a eventListenerAdapter[S]) OnNodeEvent(ctx context.Context, event NodeEvent, nodeName string, state S, err error) {
+	a.listener.OnEvent(ctx, newStreamEvent(ctx, event, nodeName, state, err))
+}
+
+// newStreamEvent returns the StreamEvent of a node event notified on ctx, with the
+// step index, attempt number, token and custom event found in ctx
+func newStreamEvent[S any](ctx context.Context, event NodeEvent, nodeName string, state S, err error) *StreamEvent[S] {
+	streamEvent := &StreamEvent[S]{
+		Timestamp: time.Now(),
+		NodeName:  nodeName,
+		Event:     event,
+		State:     state,
+		Error:     err,
+		Metadata:  make(map[string]any),
+	}
+	if step, ok := stepIndexFromContext(ctx); ok {
+		streamEvent.StepIndex = step
+	}
+	if attempt, ok := attemptFromContext(ctx); ok {
+		streamEvent.Metadata["attempt"] = attempt
+	}
+	if chunk, ok := TokenFromContext(ctx); ok && event == EventToken {
+		streamEvent.Metadata["token"] = chunk
+	}
+	if custom, ok := CustomEventFromContext(ctx); ok && event == EventCustom {
+		streamEvent.Metadata["name"] = custom.Name
+		streamEvent.Metadata["payload"] = custom.Payload
+	}
+	return streamEvent
+}
+
+// deliver notifies listener of event, through OnEvent when it is an EventListener
+func deliver[S any](ctx context.Context, listener NodeListener[S], event *StreamEvent[S]) {
+	if el, ok := listener.(EventListener[S]); ok {
+		el.OnEvent(ctx, event)
+		return
+	}
+	listener.OnNodeEvent(ctx, event.Event, event.NodeName, event.State, event.Error)
+}
@@ -0,0 +1,131 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timingRecorder is an EventListener recording the events it receives
+type timingRecorder struct {
+	mu     sync.Mutex
+	events []graph.StreamEvent[map[string]any]
+}
+
+func (r *timingRecorder) OnEvent(_ context.Context, event *graph.StreamEvent[map[string]any]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+}
+
+// find returns the event of node, failing the test if it is missing
+func (r *timingRecorder) find(t *testing.T, node string, event graph.NodeEvent) graph.StreamEvent[map[string]any] {
+	t.Helper()
+	for _, e := range r.events {
+		if e.NodeName == node && e.Event == event {
+			return e
+		}
+	}
+	require.Failf(t, "missing event", "%s:%s", node, event)
+	return graph.StreamEvent[map[string]any]{}
+}
+
+func newTimedPipeline() *graph.ListenableStateGraph[map[string]any] {
+	g := graph.NewListenableStateGraph[map[string]any]()
+	attempts := 0
+	g.AddNode("fetch", "fetch", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		time.Sleep(5 * time.Millisecond)
+		if attempts++; attempts == 1 {
+			return nil, errors.New("unavailable")
+		}
+		return state, nil
+	})
+	g.SetNodeRetryPolicy("fetch", graph.NodeRetryPolicy{MaxAttempts: 2})
+	g.AddNode("answer", "answer", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return state, nil
+	})
+	g.AddEdge("fetch", "answer")
+	g.AddEdge("answer", graph.END)
+	g.SetEntryPoint("fetch")
+	return g
+}
+
+func TestEventListener(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Details", func(t *testing.T) {
+		t.Parallel()
+		g := newTimedPipeline()
+		recorder := &timingRecorder{}
+		g.AddGlobalListener(graph.AsNodeListener[map[string]any](recorder))
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+
+		failed := recorder.find(t, "fetch", graph.NodeEventError)
+		assert.GreaterOrEqual(t, failed.Duration, 5*time.Millisecond)
+		assert.Equal(t, 1, failed.Metadata["attempt"])
+		retry := recorder.find(t, "fetch", graph.NodeEventRetry)
+		assert.Equal(t, 1, retry.Metadata["attempt"])
+
+		fetched := recorder.find(t, "fetch", graph.NodeEventComplete)
+		assert.GreaterOrEqual(t, fetched.Duration, 5*time.Millisecond)
+		assert.Equal(t, 2, fetched.Metadata["attempt"])
+		assert.Equal(t, 1, fetched.StepIndex)
+
+		answered := recorder.find(t, "answer", graph.NodeEventComplete)
+		assert.Equal(t, 2, answered.StepIndex)
+		assert.Equal(t, 1, answered.Metadata["attempt"])
+		assert.Zero(t, recorder.find(t, "answer", graph.NodeEventStart).Duration)
+
+		g.RemoveGlobalListener(graph.AsNodeListener[map[string]any](recorder))
+		assert.Empty(t, g.GetListenableNode("fetch").GetListeners())
+	})
+
+	t.Run("FuncAndNodeListener", func(t *testing.T) {
+		t.Parallel()
+		runnable, err := newTimedPipeline().StateGraph.Compile()
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		var steps []int
+		runnable.AddListener(graph.EventListenerFunc[map[string]any](func(_ context.Context, event *graph.StreamEvent[map[string]any]) {
+			mu.Lock()
+			defer mu.Unlock()
+			steps = append(steps, event.StepIndex)
+		}), graph.WithEvents(graph.NodeEventComplete))
+		// Listeners of the old signature keep receiving the events
+		plain := &eventRecorder{}
+		runnable.AddListener(plain)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, steps)
+		assert.Contains(t, plain.events, "answer:complete")
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		t.Parallel()
+		runnable, err := newTimedPipeline().CompileListenable()
+		require.NoError(t, err)
+
+		var completed []graph.StreamEvent[map[string]any]
+		for event := range runnable.Stream(context.Background(), map[string]any{}) {
+			if event.Event == graph.NodeEventComplete {
+				completed = append(completed, event)
+			}
+		}
+		require.Len(t, completed, 2)
+		assert.Equal(t, "fetch", completed[0].NodeName)
+		assert.GreaterOrEqual(t, completed[0].Duration, 5*time.Millisecond)
+		assert.Equal(t, 2, completed[1].StepIndex)
+	})
+}
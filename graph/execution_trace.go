@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ExecutionTrace records the nodes that ran during an invoke, for inspecting a run
+// without registering listeners. Set it in Config.Trace, or use WithTrace, and read
+// Entries once the invoke returns.
+//
+// Example:
+//
+//	trace := &graph.ExecutionTrace{}
+//	result, err := runnable.InvokeWithConfig(ctx, state, graph.WithTrace(trace))
+//	for _, entry := range trace.Entries {
+//	    fmt.Printf("%d %s %v %v\n", entry.Step, entry.Node, entry.Duration, entry.StateDeltaKeys)
+//	}
+type ExecutionTrace struct {
+	// Entries are ordered by step, and within a step in the order in which their
+	// results are merged: by priority (see AddEdgeWithPriority), then by node name
+	Entries []TraceEntry
+
+	mutex sync.Mutex
+}
+
+// TraceEntry describes one execution of a node in an ExecutionTrace.
+type TraceEntry struct {
+	// Node is the name of the node
+	Node string
+
+	// Step is the number of the step the node ran in, starting at 1
+	Step int
+
+	// Start is when the node started
+	Start time.Time
+
+	// Duration is how long the node took, including retries
+	Duration time.Duration
+
+	// Error is the error returned by the node, if any
+	Error error
+
+	// StateDeltaKeys are the map keys or struct fields of the node's result that
+	// differ from its input state, in sorted order. Missing keys and zero fields are
+	// not reported, since partial updates leave them unchanged.
+	StateDeltaKeys []string
+}
+
+// WithTrace creates a Config that records the run into trace.
+//
+// Example:
+//
+//	trace := &graph.ExecutionTrace{}
+//	result, err := runnable.InvokeWithConfig(ctx, state, graph.WithTrace(trace))
+func WithTrace(trace *ExecutionTrace) *Config {
+	return &Config{Trace: trace}
+}
+
+// record appends the entries of a step in order
+func (t *ExecutionTrace) record(entries []TraceEntry) {
+	entries = slices.DeleteFunc(entries, func(entry TraceEntry) bool {
+		return entry.Node == ""
+	})
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.Entries = append(t.Entries, entries...)
+}
+
+// stateDeltaKeys returns the keys or exported fields of result that are set and
+// differ from state. Other kinds of state have no keys.
+func stateDeltaKeys(state, result any) []string {
+	before := reflect.Indirect(reflect.ValueOf(state))
+	after := reflect.Indirect(reflect.ValueOf(result))
+	if !after.IsValid() {
+		return nil
+	}
+
+	var keys []string
+	switch after.Kind() {
+	case reflect.Map:
+		iter := after.MapRange()
+		for iter.Next() {
+			var old reflect.Value
+			if before.IsValid() && before.Kind() == reflect.Map && before.Type() == after.Type() {
+				old = before.MapIndex(iter.Key())
+			}
+			if !old.IsValid() || !reflect.DeepEqual(old.Interface(), iter.Value().Interface()) {
+				keys = append(keys, fmt.Sprint(iter.Key().Interface()))
+			}
+		}
+	case reflect.Struct:
+		sameType := before.IsValid() && before.Type() == after.Type()
+		for i := range after.NumField() {
+			field := after.Type().Field(i)
+			value := after.Field(i)
+			if !field.IsExported() || value.IsZero() {
+				continue
+			}
+			if !sameType || !reflect.DeepEqual(before.Field(i).Interface(), value.Interface()) {
+				keys = append(keys, field.Name)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
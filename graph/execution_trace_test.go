@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutionTrace(t *testing.T) {
+	t.Run("MapState", func(t *testing.T) {
+		g := NewStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		g.AddNode("plan", "plan", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			time.Sleep(time.Millisecond)
+			return map[string]any{"plan": "search", "query": state["query"]}, nil
+		})
+		g.AddNode("search", "search", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"results": []string{"a"}}, nil
+		})
+		g.AddNode("browse", "browse", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"pages": 2}, nil
+		})
+		g.SetEntryPoint("plan")
+		g.AddEdge("plan", "search")
+		g.AddEdge("plan", "browse")
+		g.AddEdge("search", END)
+		g.AddEdge("browse", END)
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		trace := &ExecutionTrace{}
+		_, err = runnable.InvokeWithConfig(context.Background(), map[string]any{"query": "go"}, WithTrace(trace))
+		require.NoError(t, err)
+
+		require.Len(t, trace.Entries, 3)
+		assert.Equal(t, "plan", trace.Entries[0].Node)
+		assert.Equal(t, 1, trace.Entries[0].Step)
+		assert.Equal(t, []string{"plan"}, trace.Entries[0].StateDeltaKeys, "query is unchanged")
+		assert.GreaterOrEqual(t, trace.Entries[0].Duration, time.Millisecond)
+		assert.False(t, trace.Entries[0].Start.IsZero())
+
+		assert.Equal(t, "browse", trace.Entries[1].Node, "nodes of a step are in merge order")
+		assert.Equal(t, 2, trace.Entries[1].Step)
+		assert.Equal(t, []string{"pages"}, trace.Entries[1].StateDeltaKeys)
+		assert.Equal(t, "search", trace.Entries[2].Node)
+		assert.Equal(t, []string{"results"}, trace.Entries[2].StateDeltaKeys)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		g := NewCheckpointableStateGraph[traceState]()
+		g.AddNode("ok", "ok", func(ctx context.Context, state traceState) (traceState, error) {
+			state.Count++
+			return state, nil
+		})
+		g.AddNode("fail", "fail", func(ctx context.Context, state traceState) (traceState, error) {
+			return state, errBoom
+		})
+		g.SetEntryPoint("ok")
+		g.AddEdge("ok", "fail")
+		g.AddEdge("fail", END)
+
+		runnable, err := g.CompileCheckpointable()
+		require.NoError(t, err)
+
+		trace := &ExecutionTrace{}
+		config := WithThreadID("trace")
+		config.Trace = trace
+		_, err = runnable.InvokeWithConfig(context.Background(), traceState{Name: "t"}, config)
+		require.ErrorIs(t, err, errBoom)
+
+		require.Len(t, trace.Entries, 2)
+		assert.Equal(t, []string{"Count"}, trace.Entries[0].StateDeltaKeys)
+		assert.NoError(t, trace.Entries[0].Error)
+		assert.Equal(t, "fail", trace.Entries[1].Node)
+		assert.ErrorIs(t, trace.Entries[1].Error, errBoom)
+		assert.Empty(t, trace.Entries[1].StateDeltaKeys)
+	})
+}
+
+type traceState struct {
+	Name  string
+	Count int
+	notes []string
+}
+
+func TestStateDeltaKeys(t *testing.T) {
+	assert.Equal(t, []string{"b", "c"}, stateDeltaKeys(
+		map[string]any{"a": 1, "b": 2},
+		map[string]any{"a": 1, "b": 3, "c": []int{1}},
+	))
+	assert.Equal(t, []string{"Count"}, stateDeltaKeys(
+		traceState{Name: "x"},
+		&traceState{Name: "x", Count: 1, notes: []string{"unexported"}},
+	))
+	assert.Equal(t, []string{"Name"}, stateDeltaKeys(nil, traceState{Name: "x"}))
+	assert.Nil(t, stateDeltaKeys(1, 2))
+	assert.Nil(t, stateDeltaKeys(map[string]any{}, (*traceState)(nil)))
+}
@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+// ErrGraphChanged is returned with WithStrictResume when a thread is resumed from a
+// checkpoint saved by a graph with a different Fingerprint.
+var ErrGraphChanged = errors.New("graph changed since the checkpoint was saved")
+
+// fingerprintMetadataKey is the checkpoint metadata holding the fingerprint of the
+// graph that saved the checkpoint
+const fingerprintMetadataKey = "graph_fingerprint"
+
+// Fingerprint returns a hash of the graph's definition: its node names and
+// descriptions, entry point, edges, conditional edge path maps, error routing,
+// deferred nodes and state schema keys. It is the same for graphs built the same
+// way, so it identifies the topology that checkpoints were saved with. Node
+// functions, routers and reducers are code and are not part of it.
+//
+// Example:
+//
+//	if runnable.Fingerprint() != deployedFingerprint {
+//	    log.Println("graph definition changed")
+//	}
+func (r *StateRunnable[S]) Fingerprint() string {
+	return r.graph.fingerprint()
+}
+
+// Fingerprint returns a hash of the graph's definition; see StateRunnable.Fingerprint.
+func (lr *ListenableRunnable[S]) Fingerprint() string {
+	return lr.runnable.Fingerprint()
+}
+
+// Fingerprint returns a hash of the graph's definition; see StateRunnable.Fingerprint.
+// It is saved in the metadata of every checkpoint, and resuming a thread whose
+// latest checkpoint has another fingerprint logs a warning, or fails with
+// ErrGraphChanged when the graph was compiled with WithStrictResume.
+func (cr *CheckpointableRunnable[S]) Fingerprint() string {
+	return cr.runnable.Fingerprint()
+}
+
+// fingerprint hashes a canonical description of the graph, one sorted line per element
+func (g *StateGraph[S]) fingerprint() string {
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	add("entry %q", g.entryPoint)
+	for _, name := range sortedKeys(g.nodes) {
+		add("node %q %q", name, g.nodes[name].Description)
+	}
+	for _, edge := range g.edges {
+		if edge.Priority != 0 {
+			add("edge %q %q %d", edge.From, edge.To, edge.Priority)
+			continue
+		}
+		add("edge %q %q", edge.From, edge.To)
+	}
+	for _, from := range sortedKeys(g.conditionalEdges) {
+		pathMap, ok := g.conditionalPathMaps[from]
+		if !ok {
+			add("conditional %q", from)
+			continue
+		}
+		for _, key := range sortedKeys(pathMap) {
+			add("conditional %q %q %q", from, key, pathMap[key])
+		}
+	}
+	for _, from := range sortedKeys(g.errorEdges) {
+		add("error edge %q %q", from, g.errorEdges[from])
+	}
+	if g.errorHandler != "" {
+		add("error handler %q", g.errorHandler)
+	}
+	for i, node := range g.deferredNodes {
+		add("deferred %d %q %q", i, node.Name, node.Description)
+	}
+	for _, key := range schemaKeys[S](g.Schema) {
+		add("schema %q", key)
+	}
+	slices.Sort(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// schemaKeys returns the state keys the graph declares: the keys with reducers,
+// defaults or requirements of a map schema, and the exported fields of struct states
+func schemaKeys[S any](schema StateSchema[S]) []string {
+	var keys []string
+	var mapSchema *MapSchema
+	switch s := any(schema).(type) {
+	case *MapSchema:
+		mapSchema = s
+	case interface{ MapSchema() *MapSchema }:
+		mapSchema = s.MapSchema()
+	}
+	if mapSchema != nil {
+		keys = append(keys, sortedKeys(mapSchema.Reducers)...)
+		keys = append(keys, sortedKeys(mapSchema.Defaults)...)
+		keys = append(keys, mapSchema.Required...)
+	}
+
+	t := reflect.TypeFor[S]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		for _, field := range reflect.VisibleFields(t) {
+			if field.IsExported() && !field.Anonymous {
+				keys = append(keys, field.Name)
+			}
+		}
+	}
+
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// checkFingerprint compares the fingerprint saved in checkpoint with the graph's.
+// A mismatch is logged, or returned as ErrGraphChanged with WithStrictResume.
+// Checkpoints saved without a fingerprint are accepted.
+func (cr *CheckpointableRunnable[S]) checkFingerprint(threadID string, checkpoint *store.Checkpoint) error {
+	saved, _ := checkpoint.Metadata[fingerprintMetadataKey].(string)
+	if saved == "" || saved == cr.listener.fingerprint {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: thread %s was saved by graph %s, resuming with graph %s", ErrGraphChanged, threadID, saved, cr.listener.fingerprint)
+	if cr.runnable.runnable.strictResume {
+		return err
+	}
+	log.Printf("warning: %v", err)
+	return nil
+}
@@ -0,0 +1,124 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFingerprintGraph(extra func(g *StateGraph[map[string]any])) *StateGraph[map[string]any] {
+	g := NewStateGraph[map[string]any]()
+	schema := NewMapSchema()
+	schema.RegisterReducer("messages", AppendReducer)
+	g.SetSchema(schema)
+	noop := func(ctx context.Context, state map[string]any) (map[string]any, error) { return state, nil }
+	g.AddNode("agent", "Call the model", noop)
+	g.AddNode("tools", "Run tools", noop)
+	g.SetEntryPoint("agent")
+	g.AddConditionalEdgeWithMapping("agent", func(ctx context.Context, state map[string]any) string {
+		return "done"
+	}, map[string]string{"tools": "tools", "done": END})
+	g.AddEdge("tools", "agent")
+	if extra != nil {
+		extra(g)
+	}
+	return g
+}
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := func(extra func(g *StateGraph[map[string]any])) string {
+		runnable, err := newFingerprintGraph(extra).Compile()
+		require.NoError(t, err)
+		return runnable.Fingerprint()
+	}
+
+	base := fingerprint(nil)
+	assert.Len(t, base, 64)
+	assert.Equal(t, base, fingerprint(nil), "the same graph built twice has the same fingerprint")
+
+	noop := func(ctx context.Context, state map[string]any) (map[string]any, error) { return state, nil }
+	changes := map[string]func(g *StateGraph[map[string]any]){
+		"Edge": func(g *StateGraph[map[string]any]) {
+			g.AddNode("log", "Log", noop)
+			g.AddEdge("tools", "log")
+			g.AddEdge("log", END)
+		},
+		"Description": func(g *StateGraph[map[string]any]) {
+			g.AddNode("tools", "Run the tools", noop)
+		},
+		"PathMap": func(g *StateGraph[map[string]any]) {
+			g.AddConditionalEdgeWithMapping("agent", func(ctx context.Context, state map[string]any) string {
+				return "done"
+			}, map[string]string{"tool": "tools", "done": END})
+		},
+		"SchemaKey": func(g *StateGraph[map[string]any]) {
+			g.Schema.(*MapSchema).RegisterRequired("question")
+		},
+	}
+	for name, change := range changes {
+		assert.NotEqual(t, base, fingerprint(change), name)
+	}
+
+	// Functions are not part of the fingerprint
+	assert.Equal(t, base, fingerprint(func(g *StateGraph[map[string]any]) {
+		require.NoError(t, g.ReplaceNode("tools", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, nil
+		}))
+	}))
+
+	type state struct{ Query string }
+	structFingerprint := func() string {
+		g := NewStateGraph[state]()
+		g.AddNode("a", "a", func(ctx context.Context, s state) (state, error) { return s, nil })
+		g.SetEntryPoint("a")
+		g.AddEdge("a", END)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		return runnable.Fingerprint()
+	}
+	assert.NotEqual(t, structFingerprint(), fingerprint(nil))
+	assert.Contains(t, schemaKeys[state](nil), "Query")
+}
+
+func TestFingerprintResume(t *testing.T) {
+	checkpoints := NewMemoryCheckpointStore()
+	build := func(description string, opts ...CompileOption) *CheckpointableRunnable[map[string]any] {
+		g := NewCheckpointableStateGraphWithConfig[map[string]any](CheckpointConfig{Store: checkpoints, AutoSave: true})
+		g.SetSchema(NewMapSchema())
+		g.AddNode("ask", description, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"asked": true}, nil
+		})
+		g.AddNode("answer", "answer", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"answered": true}, nil
+		})
+		g.SetEntryPoint("ask")
+		g.AddEdge("ask", "answer")
+		g.AddEdge("answer", END)
+		runnable, err := g.CompileCheckpointable(append(opts, WithInterruptAfterNodes("ask"))...)
+		require.NoError(t, err)
+		return runnable
+	}
+
+	original := build("ask")
+	_, err := original.InvokeWithConfig(context.Background(), map[string]any{}, WithThreadID("t1"))
+	var interrupt *GraphInterrupt
+	require.ErrorAs(t, err, &interrupt)
+
+	latest, err := checkpoints.GetLatestByThread(context.Background(), "t1")
+	require.NoError(t, err)
+	assert.Equal(t, original.Fingerprint(), latest.Metadata[fingerprintMetadataKey])
+
+	_, err = build("ask the user", WithStrictResume()).InvokeWithConfig(context.Background(), map[string]any{}, WithThreadID("t1"))
+	assert.ErrorIs(t, err, ErrGraphChanged)
+
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+	_, err = build("ask the user").InvokeWithConfig(context.Background(), map[string]any{}, WithThreadID("t1"))
+	assert.NoError(t, err)
+	assert.Contains(t, logs.String(), "graph changed since the checkpoint was saved: thread t1")
+}
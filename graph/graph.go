@@ -9,6 +9,11 @@ import (
 // END is a special constant used to represent the end node in the graph.
 const END = "END"
 
+// START is a special constant used to represent the start of the graph.
+// AddEdge(START, node) is the same as SetEntryPoint(node), and a conditional edge
+// from START chooses the entry node from the initial state.
+const START = "START"
+
 var (
 	// ErrEntryPointNotSet is returned when the entry point of the graph is not set.
 	ErrEntryPointNotSet = errors.New("entry point not set")
@@ -18,6 +23,24 @@ var (
 
 	// ErrNoOutgoingEdge is returned when no outgoing edge is found for a node.
 	ErrNoOutgoingEdge = errors.New("no outgoing edge found for node")
+
+	// ErrUnknownPathKey is returned when a conditional router returns a key that is not in its path map.
+	ErrUnknownPathKey = errors.New("conditional edge returned unknown path key")
+
+	// ErrEdgeNotFound is returned by RemoveEdge when the graph has no such edge.
+	ErrEdgeNotFound = errors.New("edge not found")
+
+	// ErrNodeHasEdges is returned by RemoveNode when edges still reference the node.
+	ErrNodeHasEdges = errors.New("node is referenced by edges")
+
+	// ErrRemoveEntryPoint is returned by RemoveNode for the entry point node.
+	ErrRemoveEntryPoint = errors.New("cannot remove the entry point")
+
+	// ErrMaxStepsExceeded is returned when an invoke reaches the limit set with WithMaxSteps.
+	ErrMaxStepsExceeded = errors.New("max steps exceeded")
+
+	// ErrNodePanic is wrapped by the NodePanicError a node fails with when it panics.
+	ErrNodePanic = errors.New("node panicked")
 )
 
 // GraphInterrupt is returned when execution is interrupted by configuration or dynamic interrupt
@@ -39,10 +62,23 @@ func (e *GraphInterrupt) Error() string {
 	return fmt.Sprintf("graph interrupted at node %s", e.Node)
 }
 
-// Interrupt pauses execution and waits for input.
-// If resuming, it returns the value provided in the resume command.
+// Interrupt pauses execution from inside a node and waits for input.
+// On first execution it returns a *NodeInterrupt carrying value; the node should return
+// that error, which aborts the invoke with a *GraphInterrupt whose InterruptValue is value.
+// When the run is resumed with Config.ResumeValue, the node runs again and the first
+// Interrupt call returns the resume value instead. Later calls in the same run pause
+// again, so a node can ask several questions in sequence. The value is for the node
+// that raised the interrupt being resumed: the other nodes of the run, such as the
+// ones that interrupted in the same step, and the graphs they invoke pause again.
+//
+// Example:
+//
+//	answer, err := graph.Interrupt(ctx, "Approve the refund?")
+//	if err != nil {
+//	    return state, err
+//	}
 func Interrupt(ctx context.Context, value any) (any, error) {
-	if resumeVal := GetResumeValue(ctx); resumeVal != nil {
+	if resumeVal, ok := consumeResumeValue(ctx); ok {
 		return resumeVal, nil
 	}
 	return nil, &NodeInterrupt{Value: value}
@@ -55,6 +91,9 @@ type Edge struct {
 
 	// To is the name of the node to which the edge points.
 	To string
+
+	// Priority orders To among the nodes of its step, see AddEdgeWithPriority.
+	Priority int
 }
 
 // RetryPolicy defines how to handle node failures
@@ -113,7 +113,7 @@ func TestStateGraph(t *testing.T) {
 				g.SetEntryPoint("node1")
 				return g
 			},
-			expectedError: fmt.Errorf("%w: node2", graph.ErrNodeNotFound),
+			expectedError: graph.ErrNodeNotFound,
 		},
 		{
 			name: "No outgoing edge",
@@ -125,7 +125,7 @@ func TestStateGraph(t *testing.T) {
 				g.SetEntryPoint("node1")
 				return g
 			},
-			expectedError: fmt.Errorf("%w: node1", graph.ErrNoOutgoingEdge),
+			expectedError: graph.ErrNoOutgoingEdge,
 		},
 		{
 			name: "Error in node function",
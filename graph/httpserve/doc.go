@@ -0,0 +1,21 @@
+// Package httpserve exposes graph runs over HTTP, streaming the events of the
+// nodes to clients as they happen.
+//
+// NewSSEHandler runs a graph per request and streams its events as server-sent
+// events, ending with the final state:
+//
+//	handler := httpserve.NewSSEHandler(runnable, func(r *http.Request) (map[string]any, *graph.Config, error) {
+//		var state map[string]any
+//		err := json.NewDecoder(r.Body).Decode(&state)
+//		return state, nil, err
+//	}, httpserve.WithHeartbeat(10*time.Second))
+//	http.Handle("/run", handler)
+//
+// Each node event is sent as an SSE message named after the event, such as
+// "start", "complete" or "token", with an Event encoded as JSON for data. The
+// last message is named "end" and holds a Result.
+//
+// NewWebSocketHandler runs a checkpointable graph per WebSocket connection for
+// human-in-the-loop flows, sending the same events as JSON frames and waiting for
+// the client to answer the interrupts of the run with a "resume" frame.
+package httpserve
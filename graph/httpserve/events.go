@@ -0,0 +1,191 @@
+package httpserve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/smallnest/langgraphgo/graph"
+)
+
+// EventEnd is the name of the last message of a run, holding its Result
+const EventEnd = "end"
+
+// Event is the data of the message of a node event
+type Event[S any] struct {
+	Timestamp  time.Time       `json:"timestamp"`
+	Node       string          `json:"node"`
+	Event      graph.NodeEvent `json:"event"`
+	Step       int             `json:"step,omitempty"`
+	DurationMS int64           `json:"duration_ms,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Metadata   map[string]any  `json:"metadata,omitempty"`
+	State      S               `json:"state"`
+}
+
+// Result is the data of the EventEnd message of a run
+type Result[S any] struct {
+	// State is the final state of the run
+	State S `json:"state"`
+
+	// Error is the error the run failed with, if any
+	Error string `json:"error,omitempty"`
+
+	// Interrupt is set when the run stopped on an interrupt
+	Interrupt *Interrupt `json:"interrupt,omitempty"`
+}
+
+// Interrupt describes the interrupt a run stopped on
+type Interrupt struct {
+	Node      string   `json:"node"`
+	Value     any      `json:"value,omitempty"`
+	NextNodes []string `json:"next_nodes,omitempty"`
+}
+
+// message is an encoded event sent to a client
+type message struct {
+	name string
+	data []byte
+}
+
+// newMessage encodes the message of a node event. The state is encoded at once,
+// as the next nodes may change it.
+func newMessage[S any](event *graph.StreamEvent[S]) (message, error) {
+	data := Event[S]{
+		Timestamp:  event.Timestamp,
+		Node:       event.NodeName,
+		Event:      event.Event,
+		Step:       event.StepIndex,
+		DurationMS: event.Duration.Milliseconds(),
+		Metadata:   event.Metadata,
+		State:      event.State,
+	}
+	if event.Error != nil {
+		data.Error = event.Error.Error()
+	}
+	encoded, err := json.Marshal(data)
+	return message{name: string(event.Event), data: encoded}, err
+}
+
+// newResultMessage encodes the EventEnd message of a run that returned state and
+// err
+func newResultMessage[S any](state S, err error) message {
+	result := Result[S]{State: state}
+	if err != nil {
+		result.Error = err.Error()
+		var interrupt *graph.GraphInterrupt
+		if errors.As(err, &interrupt) {
+			result.Interrupt = &Interrupt{Node: interrupt.Node, Value: interrupt.InterruptValue, NextNodes: interrupt.NextNodes}
+		}
+	}
+	data, encodeErr := json.Marshal(result)
+	if encodeErr != nil {
+		var zero S
+		data, _ = json.Marshal(Result[S]{State: zero, Error: encodeErr.Error()})
+	}
+	return message{name: EventEnd, data: data}
+}
+
+type streamKey struct{}
+
+// stream passes the messages of the events of a run to the request serving it
+type stream struct {
+	messages chan message
+	done     <-chan struct{}
+}
+
+func newStream(ctx context.Context) *stream {
+	return &stream{messages: make(chan message, 64), done: ctx.Done()}
+}
+
+// send passes msg to the request, unless the run is over
+func (s *stream) send(msg message) {
+	select {
+	case s.messages <- msg:
+	case <-s.done:
+	}
+}
+
+// drain returns the messages sent before the run returned
+func (s *stream) drain() []message {
+	var pending []message
+	for {
+		select {
+		case msg := <-s.messages:
+			pending = append(pending, msg)
+		default:
+			return pending
+		}
+	}
+}
+
+// streamListener returns the listener passing the events of every run of a
+// runnable to the stream in its context, so concurrent requests only receive
+// the events of their own run
+func streamListener[S any]() graph.NodeListener[S] {
+	return graph.EventListenerFunc[S](func(ctx context.Context, event *graph.StreamEvent[S]) {
+		s, ok := ctx.Value(streamKey{}).(*stream)
+		if !ok {
+			return
+		}
+		if msg, err := newMessage(event); err == nil {
+			s.send(msg)
+		}
+	})
+}
+
+// Option configures a handler
+type Option func(*options)
+
+type options struct {
+	heartbeat   time.Duration
+	maxDuration time.Duration
+	checkOrigin func(r *http.Request) bool
+}
+
+// DefaultHeartbeat is the interval of the heartbeats of a handler
+const DefaultHeartbeat = 15 * time.Second
+
+func newOptions(opts []Option) options {
+	o := options{heartbeat: DefaultHeartbeat}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithHeartbeat sets the interval of the heartbeats sent while the nodes are
+// quiet, which keep proxies from closing the connection. Zero disables them.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(o *options) {
+		o.heartbeat = interval
+	}
+}
+
+// WithMaxDuration cancels the runs lasting longer than d, which end with an
+// error. Zero, the default, doesn't limit them.
+func WithMaxDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.maxDuration = d
+	}
+}
+
+// runContext returns the context of a run for a request with ctx
+func (o options) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.maxDuration > 0 {
+		return context.WithTimeout(ctx, o.maxDuration)
+	}
+	return context.WithCancel(ctx)
+}
+
+// heartbeats returns the channel of the heartbeats of a request, nil when they
+// are disabled, and the function stopping them
+func (o options) heartbeats() (<-chan time.Time, func()) {
+	if o.heartbeat <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(o.heartbeat)
+	return ticker.C, ticker.Stop
+}
@@ -0,0 +1,104 @@
+package httpserve
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/smallnest/langgraphgo/graph"
+)
+
+// Decoder returns the initial state and config of the run of a request. The
+// config may be nil.
+type Decoder[S any] func(r *http.Request) (S, *graph.Config, error)
+
+// SSEHandler is an http.Handler running a graph per request and streaming its
+// events as server-sent events
+type SSEHandler[S any] struct {
+	runnable *graph.StateRunnable[S]
+	decode   Decoder[S]
+	options  options
+}
+
+// NewSSEHandler creates a handler running runnable with the state and config
+// decode returns for each request, which fails with status 400 when decode
+// fails. The run is cancelled when the client disconnects.
+//
+// It adds a listener to runnable, so it must not be called while runnable runs.
+func NewSSEHandler[S any](runnable *graph.StateRunnable[S], decode Decoder[S], opts ...Option) *SSEHandler[S] {
+	runnable.AddListener(streamListener[S]())
+	return &SSEHandler[S]{
+		runnable: runnable,
+		decode:   decode,
+		options:  newOptions(opts),
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *SSEHandler[S]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	state, config, err := h.decode(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := h.options.runContext(r.Context())
+	defer cancel()
+	s := newStream(ctx)
+	handle := h.runnable.InvokeAsyncWithConfig(context.WithValue(ctx, streamKey{}, s), state, config)
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeats, stop := h.options.heartbeats()
+	defer stop()
+	for {
+		select {
+		case msg := <-s.messages:
+			if writeSSE(w, msg) != nil {
+				return
+			}
+		case <-heartbeats:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-handle.Done():
+			for _, msg := range s.drain() {
+				if writeSSE(w, msg) != nil {
+					return
+				}
+			}
+			final, err := handle.Result()
+			_ = writeSSE(w, newResultMessage(final, err))
+			flusher.Flush()
+			return
+		case <-ctx.Done():
+			if r.Context().Err() == nil {
+				// The run exceeded its maximum duration
+				var zero S
+				_ = writeSSE(w, newResultMessage(zero, fmt.Errorf("run exceeded the maximum duration of %s: %w", h.options.maxDuration, ctx.Err())))
+				flusher.Flush()
+			}
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeSSE writes msg as a server-sent event. The data of the messages is JSON,
+// which holds no newlines.
+func writeSSE(w io.Writer, msg message) error {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.name, msg.data)
+	return err
+}
@@ -0,0 +1,183 @@
+package httpserve_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/graph/httpserve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseMessage struct {
+	name string
+	data string
+}
+
+// readSSE returns the messages of an SSE stream and the number of heartbeats
+func readSSE(t *testing.T, resp *http.Response) ([]sseMessage, int) {
+	t.Helper()
+	var messages []sseMessage
+	var current sseMessage
+	heartbeats := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.name != "" {
+				messages = append(messages, current)
+			}
+			current = sseMessage{}
+		case strings.HasPrefix(line, ":"):
+			heartbeats++
+		case strings.HasPrefix(line, "event: "):
+			current.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return messages, heartbeats
+}
+
+func decodeTopic(r *http.Request) (map[string]any, *graph.Config, error) {
+	return map[string]any{"topic": r.URL.Query().Get("topic")}, nil, nil
+}
+
+func newPipeline(t *testing.T, delay time.Duration) *graph.StateRunnable[map[string]any] {
+	t.Helper()
+	g := graph.NewStateGraph[map[string]any]()
+	for i, name := range []string{"research", "write", "review"} {
+		g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			graph.EmitToken(ctx, name)
+			next := maps.Clone(state)
+			next[name] = i + 1
+			return next, nil
+		})
+	}
+	g.SetEntryPoint("research")
+	g.AddEdge("research", "write")
+	g.AddEdge("write", "review")
+	g.AddEdge("review", graph.END)
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestSSEHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Stream", func(t *testing.T) {
+		t.Parallel()
+		server := httptest.NewServer(httpserve.NewSSEHandler(newPipeline(t, 30*time.Millisecond), decodeTopic,
+			httpserve.WithHeartbeat(10*time.Millisecond)))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "?topic=go")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		messages, heartbeats := readSSE(t, resp)
+		var names []string
+		for _, msg := range messages {
+			names = append(names, msg.name)
+		}
+		assert.Equal(t, []string{
+			"start", "token", "complete",
+			"start", "token", "complete",
+			"start", "token", "complete",
+			"end",
+		}, names)
+		assert.Positive(t, heartbeats)
+
+		var event httpserve.Event[map[string]any]
+		require.NoError(t, json.Unmarshal([]byte(messages[5].data), &event))
+		assert.Equal(t, "write", event.Node)
+		assert.Equal(t, graph.NodeEventComplete, event.Event)
+		assert.Equal(t, 2, event.Step)
+		assert.Equal(t, float64(2), event.State["write"])
+
+		var result httpserve.Result[map[string]any]
+		require.NoError(t, json.Unmarshal([]byte(messages[9].data), &result))
+		assert.Empty(t, result.Error)
+		assert.Equal(t, map[string]any{"topic": "go", "research": float64(1), "write": float64(2), "review": float64(3)}, result.State)
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		t.Parallel()
+		cancelled := make(chan struct{})
+		g := graph.NewStateGraph[map[string]any]()
+		g.AddNode("wait", "wait", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		})
+		g.SetEntryPoint("wait")
+		g.AddEdge("wait", graph.END)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		server := httptest.NewServer(httpserve.NewSSEHandler(runnable, decodeTopic))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "event: start\n", line)
+		cancel()
+
+		select {
+		case <-cancelled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the run wasn't cancelled when the client disconnected")
+		}
+	})
+
+	t.Run("MaxDuration", func(t *testing.T) {
+		t.Parallel()
+		server := httptest.NewServer(httpserve.NewSSEHandler(newPipeline(t, time.Second), decodeTopic,
+			httpserve.WithMaxDuration(50*time.Millisecond)))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		messages, _ := readSSE(t, resp)
+		require.NotEmpty(t, messages)
+		last := messages[len(messages)-1]
+		assert.Equal(t, httpserve.EventEnd, last.name)
+		var result httpserve.Result[map[string]any]
+		require.NoError(t, json.Unmarshal([]byte(last.data), &result))
+		assert.Contains(t, result.Error, "deadline exceeded")
+	})
+
+	t.Run("InvalidRequest", func(t *testing.T) {
+		t.Parallel()
+		handler := httpserve.NewSSEHandler(newPipeline(t, 0), func(r *http.Request) (map[string]any, *graph.Config, error) {
+			var state map[string]any
+			err := json.NewDecoder(r.Body).Decode(&state)
+			return state, nil, err
+		})
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{")))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
@@ -0,0 +1,278 @@
+package httpserve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/smallnest/langgraphgo/graph"
+)
+
+// Types of the frames of a WebSocket session besides the node events, which are
+// named after the event like the SSE messages
+const (
+	// FrameSession is the first frame of a session, holding its thread ID
+	FrameSession = "session"
+
+	// FrameInterrupt is sent when the run stops on an interrupt, with an
+	// Interrupt for data; the session then waits for a FrameResume frame
+	FrameInterrupt = "interrupt"
+
+	// FrameResume is sent by the client to answer an interrupt, with the value
+	// the Interrupt call returns for data
+	FrameResume = "resume"
+)
+
+// Frame is a JSON message of a WebSocket session
+type Frame struct {
+	Type     string          `json:"type"`
+	ThreadID string          `json:"thread_id,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// WebSocketHandler is an http.Handler running a checkpointable graph per
+// WebSocket connection, for human-in-the-loop flows: the events of the run are
+// streamed to the client, and an interrupt waits for the client to resume the
+// run with its answer.
+//
+// A session goes as follows:
+//
+//	server: {"type": "session", "thread_id": "..."}
+//	server: {"type": "start", "data": {"node": "draft", ...}}
+//	...
+//	server: {"type": "interrupt", "data": {"node": "review", "value": "Approve?"}}
+//	client: {"type": "resume", "data": true}
+//	...
+//	server: {"type": "end", "data": {"state": {...}}}
+//
+// The thread is checkpointed, so when the connection drops during an interrupt,
+// a new connection with the same thread_id is sent the interrupt again and can
+// resume the run.
+type WebSocketHandler[S any] struct {
+	runnable *graph.CheckpointableRunnable[S]
+	decode   Decoder[S]
+	upgrader websocket.Upgrader
+	options  options
+}
+
+// NewWebSocketHandler creates a handler running runnable with the state and
+// config decode returns for each connection. The thread_id of the config
+// identifies the session, and a new one is generated when it is not set. The
+// run is cancelled when the client disconnects, or when the session lasts
+// longer than WithMaxDuration. Heartbeats are sent as ping frames.
+//
+// It adds a listener to runnable, so it must not be called while runnable runs.
+func NewWebSocketHandler[S any](runnable *graph.CheckpointableRunnable[S], decode Decoder[S], opts ...Option) *WebSocketHandler[S] {
+	runnable.AddListener(streamListener[S]())
+	o := newOptions(opts)
+	return &WebSocketHandler[S]{
+		runnable: runnable,
+		decode:   decode,
+		upgrader: websocket.Upgrader{CheckOrigin: o.checkOrigin},
+		options:  o,
+	}
+}
+
+// WithCheckOrigin sets the function accepting the origins of WebSocket
+// connections. By default, only connections from the host of the handler are
+// accepted.
+func WithCheckOrigin(check func(r *http.Request) bool) Option {
+	return func(o *options) {
+		o.checkOrigin = check
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *WebSocketHandler[S]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	state, config, err := h.decode(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	config, threadID := sessionConfig(config)
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has replied with the error
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := h.options.runContext(r.Context())
+	defer cancel()
+	resumes := readResumes(conn, cancel)
+	if conn.WriteJSON(Frame{Type: FrameSession, ThreadID: threadID}) != nil {
+		return
+	}
+
+	run := func(ctx context.Context) (S, error) {
+		return h.runnable.InvokeWithConfig(ctx, state, config)
+	}
+	if interrupt := h.pendingInterrupt(ctx, config); interrupt != nil {
+		run = h.waitResume(ctx, conn, resumes, config, interrupt)
+	}
+	for run != nil {
+		final, err := h.stream(ctx, conn, run)
+		if ctx.Err() != nil {
+			return
+		}
+		var interrupt *graph.GraphInterrupt
+		if errors.As(err, &interrupt) {
+			run = h.waitResume(ctx, conn, resumes, config, &Interrupt{
+				Node:      interrupt.Node,
+				Value:     interrupt.InterruptValue,
+				NextNodes: interrupt.NextNodes,
+			})
+			continue
+		}
+		msg := newResultMessage(final, err)
+		if writeFrame(conn, msg) == nil {
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		}
+		return
+	}
+}
+
+// runResult is the result of a run of a session
+type runResult[S any] struct {
+	state S
+	err   error
+}
+
+// stream runs run, sending the events of the nodes to conn, and returns its
+// result
+func (h *WebSocketHandler[S]) stream(ctx context.Context, conn *websocket.Conn, run func(context.Context) (S, error)) (S, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s := newStream(ctx)
+	done := make(chan runResult[S], 1)
+	go func() {
+		var result runResult[S]
+		defer func() {
+			if p := recover(); p != nil {
+				result.err = fmt.Errorf("panic in session run: %v", p)
+			}
+			done <- result
+		}()
+		result.state, result.err = run(context.WithValue(ctx, streamKey{}, s))
+	}()
+
+	heartbeats, stop := h.options.heartbeats()
+	defer stop()
+	for {
+		select {
+		case msg := <-s.messages:
+			if writeFrame(conn, msg) != nil {
+				cancel()
+			}
+		case <-heartbeats:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.options.heartbeat)) != nil {
+				cancel()
+			}
+		case result := <-done:
+			for _, msg := range s.drain() {
+				if writeFrame(conn, msg) != nil {
+					break
+				}
+			}
+			return result.state, result.err
+		}
+	}
+}
+
+// waitResume sends interrupt to the client and waits for its answer. It returns
+// the run resuming the thread with the answer, or nil when the session ended
+// first, which leaves the thread to be resumed by another connection.
+func (h *WebSocketHandler[S]) waitResume(ctx context.Context, conn *websocket.Conn, resumes <-chan any, config *graph.Config, interrupt *Interrupt) func(context.Context) (S, error) {
+	data, err := json.Marshal(interrupt)
+	if err != nil {
+		data, _ = json.Marshal(Interrupt{Node: interrupt.Node, NextNodes: interrupt.NextNodes})
+	}
+	if conn.WriteJSON(Frame{Type: FrameInterrupt, Data: data}) != nil {
+		return nil
+	}
+
+	select {
+	case value := <-resumes:
+		return func(ctx context.Context) (S, error) {
+			return h.runnable.InvokeCommand(ctx, &graph.Command{Resume: value}, config)
+		}
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// pendingInterrupt returns the interrupt the thread of config stopped on, nil
+// when it isn't interrupted
+func (h *WebSocketHandler[S]) pendingInterrupt(ctx context.Context, config *graph.Config) *Interrupt {
+	snapshot, err := h.runnable.GetState(ctx, config)
+	if err != nil {
+		return nil
+	}
+	if event, _ := snapshot.Metadata["event"].(string); event != "interrupt" {
+		return nil
+	}
+	node, _ := snapshot.Metadata["interrupt_node"].(string)
+	return &Interrupt{Node: node, Value: snapshot.Metadata["interrupt_value"], NextNodes: []string{node}}
+}
+
+// readResumes reads the frames of the client in a goroutine, and returns the
+// channel of the values of its resume frames. Other frames are ignored. The
+// session is cancelled when the connection is closed.
+func readResumes(conn *websocket.Conn, cancel context.CancelFunc) <-chan any {
+	resumes := make(chan any, 1)
+	go func() {
+		defer cancel()
+		for {
+			var frame Frame
+			if err := conn.ReadJSON(&frame); err != nil {
+				var syntaxErr *json.SyntaxError
+				if errors.As(err, &syntaxErr) {
+					continue
+				}
+				return
+			}
+			if frame.Type != FrameResume {
+				continue
+			}
+			var value any
+			if len(frame.Data) > 0 && json.Unmarshal(frame.Data, &value) != nil {
+				continue
+			}
+			select {
+			case resumes <- value:
+			default:
+			}
+		}
+	}()
+	return resumes
+}
+
+// sessionConfig returns a copy of config with a thread_id, and the thread_id
+func sessionConfig(config *graph.Config) (*graph.Config, string) {
+	session := graph.Config{}
+	if config != nil {
+		session = *config
+	}
+	session.Configurable = maps.Clone(session.Configurable)
+	if session.Configurable == nil {
+		session.Configurable = make(map[string]any)
+	}
+	threadID, _ := session.Configurable["thread_id"].(string)
+	if threadID == "" {
+		threadID = uuid.NewString()
+		session.Configurable["thread_id"] = threadID
+	}
+	return &session, threadID
+}
+
+// writeFrame sends msg to conn
+func writeFrame(conn *websocket.Conn, msg message) error {
+	return conn.WriteJSON(Frame{Type: msg.name, Data: msg.data})
+}
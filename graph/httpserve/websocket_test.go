@@ -0,0 +1,134 @@
+package httpserve_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/graph/httpserve"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReviewServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	g := graph.NewCheckpointableStateGraph[map[string]any]()
+	g.AddNode("draft", "draft", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		next := maps.Clone(state)
+		next["draft"] = "hello"
+		return next, nil
+	})
+	g.AddNode("review", "review", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		answer, err := graph.Interrupt(ctx, "Approve the draft?")
+		if err != nil {
+			return state, err
+		}
+		next := maps.Clone(state)
+		next["approved"] = answer
+		return next, nil
+	})
+	g.SetEntryPoint("draft")
+	g.AddEdge("draft", "review")
+	g.AddEdge("review", graph.END)
+	runnable, err := g.CompileCheckpointable()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(httpserve.NewWebSocketHandler(runnable, func(r *http.Request) (map[string]any, *graph.Config, error) {
+		var config *graph.Config
+		if threadID := r.URL.Query().Get("thread_id"); threadID != "" {
+			config = graph.WithThreadID(threadID)
+		}
+		return map[string]any{}, config, nil
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dial(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+query, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readUntil reads frames until one of type frameType, and returns the types of
+// the frames read and the last frame
+func readUntil(t *testing.T, conn *websocket.Conn, frameType string) ([]string, httpserve.Frame) {
+	t.Helper()
+	var types []string
+	for {
+		var frame httpserve.Frame
+		require.NoError(t, conn.ReadJSON(&frame))
+		types = append(types, frame.Type)
+		if frame.Type == frameType {
+			return types, frame
+		}
+	}
+}
+
+func resume(t *testing.T, conn *websocket.Conn, value any) {
+	t.Helper()
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteJSON(httpserve.Frame{Type: httpserve.FrameResume, Data: data}))
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Session", func(t *testing.T) {
+		t.Parallel()
+		conn := dial(t, newReviewServer(t), "")
+
+		var session httpserve.Frame
+		require.NoError(t, conn.ReadJSON(&session))
+		assert.Equal(t, httpserve.FrameSession, session.Type)
+		assert.NotEmpty(t, session.ThreadID)
+
+		types, frame := readUntil(t, conn, httpserve.FrameInterrupt)
+		assert.Equal(t, []string{"start", "complete", "start", "error", httpserve.FrameInterrupt}, types)
+		var interrupt httpserve.Interrupt
+		require.NoError(t, json.Unmarshal(frame.Data, &interrupt))
+		assert.Equal(t, httpserve.Interrupt{Node: "review", Value: "Approve the draft?", NextNodes: []string{"review"}}, interrupt)
+
+		resume(t, conn, "yes")
+		types, frame = readUntil(t, conn, httpserve.EventEnd)
+		assert.Equal(t, []string{"start", "complete", httpserve.EventEnd}, types)
+		var result httpserve.Result[map[string]any]
+		require.NoError(t, json.Unmarshal(frame.Data, &result))
+		assert.Empty(t, result.Error)
+		assert.Equal(t, map[string]any{"draft": "hello", "approved": "yes"}, result.State)
+	})
+
+	t.Run("Reconnect", func(t *testing.T) {
+		t.Parallel()
+		server := newReviewServer(t)
+		threadID := fmt.Sprintf("?thread_id=%s", t.Name())
+
+		first := dial(t, server, threadID)
+		readUntil(t, first, httpserve.FrameInterrupt)
+		require.NoError(t, first.Close())
+
+		second := dial(t, server, threadID)
+		types, frame := readUntil(t, second, httpserve.FrameInterrupt)
+		assert.Equal(t, []string{httpserve.FrameSession, httpserve.FrameInterrupt}, types)
+		var interrupt httpserve.Interrupt
+		require.NoError(t, json.Unmarshal(frame.Data, &interrupt))
+		assert.Equal(t, "review", interrupt.Node)
+		assert.Equal(t, "Approve the draft?", interrupt.Value)
+
+		resume(t, second, false)
+		_, frame = readUntil(t, second, httpserve.EventEnd)
+		var result httpserve.Result[map[string]any]
+		require.NoError(t, json.Unmarshal(frame.Data, &result))
+		assert.Equal(t, map[string]any{"draft": "hello", "approved": false}, result.State)
+	})
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"context"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -72,3 +73,358 @@ func TestGraphInterrupt(t *testing.T) {
 		assert.Equal(t, "StartAB", res["value"])
 	})
 }
+
+func TestInterrupt_InNode(t *testing.T) {
+	checkpoints := NewMemoryCheckpointStore()
+	g := NewCheckpointableStateGraphWithConfig[map[string]any](CheckpointConfig{
+		Store:    checkpoints,
+		AutoSave: true,
+	})
+	g.SetSchema(NewMapSchema())
+
+	executions := map[string]int{}
+	g.AddNode("prepare", "prepare", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		executions["prepare"]++
+		return map[string]any{"order": "#42"}, nil
+	})
+	g.AddNode("review", "review", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		executions["review"]++
+		if state["approved"] == nil {
+			approved, err := Interrupt(ctx, "Approve order "+state["order"].(string)+"?")
+			if err != nil {
+				return nil, err
+			}
+			state = map[string]any{"approved": approved}
+		}
+		// State returned with an interrupt is kept, so the first answer survives
+		note, err := Interrupt(ctx, "Any note for the customer?")
+		if err != nil {
+			return state, err
+		}
+		return map[string]any{"note": note}, nil
+	})
+	g.SetEntryPoint("prepare")
+	g.AddEdge("prepare", "review")
+	g.AddEdge("review", END)
+
+	runnable, err := g.CompileCheckpointable()
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	var interrupt *GraphInterrupt
+
+	// First run pauses inside review
+	_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("order-42"))
+	assert.ErrorAs(t, err, &interrupt)
+	assert.Equal(t, "review", interrupt.Node)
+	assert.Equal(t, "Approve order #42?", interrupt.InterruptValue)
+
+	latest, err := checkpoints.GetLatestByThread(ctx, "order-42")
+	assert.NoError(t, err)
+	assert.Equal(t, "review", latest.NodeName)
+	assert.Equal(t, "interrupt", latest.Metadata["event"])
+	assert.Equal(t, "review", latest.Metadata["interrupt_node"])
+	assert.Equal(t, "Approve order #42?", latest.Metadata["interrupt_value"])
+
+	// The resume value answers only the first Interrupt call, so the node pauses again
+	config := WithThreadID("order-42")
+	config.ResumeValue = true
+	_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, config)
+	assert.ErrorAs(t, err, &interrupt)
+	assert.Equal(t, "review", interrupt.Node)
+	assert.Equal(t, "Any note for the customer?", interrupt.InterruptValue)
+
+	config = WithThreadID("order-42")
+	config.ResumeValue = "thanks"
+	res, err := runnable.InvokeWithConfig(ctx, map[string]any{}, config)
+	assert.NoError(t, err)
+	assert.Equal(t, true, res["approved"])
+	assert.Equal(t, "thanks", res["note"])
+	assert.Equal(t, "#42", res["order"])
+
+	assert.Equal(t, 1, executions["prepare"], "nodes before the interrupt must not run again")
+	assert.Equal(t, 3, executions["review"])
+}
+
+func TestStaticBreakpoints(t *testing.T) {
+	newGraph := func() *ListenableStateGraph[map[string]any] {
+		g := NewListenableStateGraph[map[string]any]()
+		for _, name := range []string{"A", "B", "C"} {
+			g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				state["value"] = state["value"].(string) + name
+				return state, nil
+			})
+		}
+		g.SetEntryPoint("A")
+		g.AddEdge("A", "B")
+		g.AddEdge("B", "C")
+		g.AddEdge("C", END)
+		return g
+	}
+	start := func() map[string]any { return map[string]any{"value": "Start"} }
+
+	runnable, err := newGraph().CompileWithOptions(CompileOptions{
+		InterruptBefore: []string{"B"},
+		InterruptAfter:  []string{"C"},
+	})
+	assert.NoError(t, err)
+
+	t.Run("CompiledBreakpoints", func(t *testing.T) {
+		res, err := runnable.Invoke(context.Background(), start())
+		var interrupt *GraphInterrupt
+		assert.ErrorAs(t, err, &interrupt)
+		assert.Equal(t, "B", interrupt.Node)
+		assert.Equal(t, "StartA", res["value"])
+	})
+
+	t.Run("NilConfigListsKeepCompiledBreakpoints", func(t *testing.T) {
+		_, err := runnable.InvokeWithConfig(context.Background(), start(), &Config{Tags: []string{"test"}})
+		var interrupt *GraphInterrupt
+		assert.ErrorAs(t, err, &interrupt)
+		assert.Equal(t, "B", interrupt.Node)
+	})
+
+	t.Run("ConfigOverridesCompiledBreakpoints", func(t *testing.T) {
+		res, err := runnable.InvokeWithConfig(context.Background(), start(), &Config{InterruptBefore: []string{"C"}})
+		var interrupt *GraphInterrupt
+		assert.ErrorAs(t, err, &interrupt)
+		assert.Equal(t, "C", interrupt.Node)
+		assert.Equal(t, "StartAB", res["value"])
+	})
+
+	t.Run("EmptyConfigListDisablesCompiledBreakpoints", func(t *testing.T) {
+		res, err := runnable.InvokeWithConfig(context.Background(), start(), &Config{
+			InterruptBefore: []string{},
+			InterruptAfter:  []string{},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "StartABC", res["value"])
+	})
+
+	t.Run("ResumeSkipsBreakpointOfResumedNode", func(t *testing.T) {
+		res, err := runnable.InvokeWithConfig(context.Background(), map[string]any{"value": "StartA"}, &Config{
+			ResumeFrom: []string{"B"},
+		})
+		var interrupt *GraphInterrupt
+		assert.ErrorAs(t, err, &interrupt, "the InterruptAfter breakpoint on C must still fire")
+		assert.Equal(t, "C", interrupt.Node)
+		assert.Equal(t, "StartABC", res["value"])
+	})
+
+	t.Run("Listenable", func(t *testing.T) {
+		listenable, err := newGraph().CompileListenable(WithCompileOptions(CompileOptions{InterruptBefore: []string{"C"}}))
+		assert.NoError(t, err)
+
+		_, err = listenable.Invoke(context.Background(), start())
+		var interrupt *GraphInterrupt
+		assert.ErrorAs(t, err, &interrupt)
+		assert.Equal(t, "C", interrupt.Node)
+	})
+}
+
+func TestCommandResume(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("InNodeInterrupt", func(t *testing.T) {
+		g := NewCheckpointableStateGraph[any]()
+		g.SetSchema(&mapSchemaAdapterForAny{MapSchema: NewMapSchema()})
+
+		g.AddNode("ask", "ask", func(ctx context.Context, state any) (any, error) {
+			answer, err := Interrupt(ctx, "Proceed?")
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"answer": answer}, nil
+		})
+		g.SetEntryPoint("ask")
+		g.AddEdge("ask", END)
+
+		runnable, err := g.CompileCheckpointable()
+		assert.NoError(t, err)
+
+		var interrupt *GraphInterrupt
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{"user": "ada"}, WithThreadID("t1"))
+		assert.ErrorAs(t, err, &interrupt)
+		assert.Equal(t, "Proceed?", interrupt.InterruptValue)
+
+		res, err := runnable.InvokeWithConfig(ctx, &Command{
+			Resume: "yes",
+			Update: map[string]any{"note": "checked"},
+		}, WithThreadID("t1"))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"user": "ada", "note": "checked", "answer": "yes"}, res)
+	})
+
+	t.Run("Breakpoint", func(t *testing.T) {
+		g := NewCheckpointableStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+
+		executions := map[string]int{}
+		for _, name := range []string{"step1", "step2", "step3"} {
+			g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				executions[name]++
+				return map[string]any{name: "done", "resume": GetResumeValue(ctx)}, nil
+			})
+		}
+		g.SetEntryPoint("step1")
+		g.AddEdge("step1", "step2")
+		g.AddEdge("step2", "step3")
+		g.AddEdge("step3", END)
+
+		runnable, err := g.CompileCheckpointable(WithCompileOptions(CompileOptions{InterruptAfter: []string{"step2"}}))
+		assert.NoError(t, err)
+
+		var interrupt *GraphInterrupt
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("t2"))
+		assert.ErrorAs(t, err, &interrupt)
+
+		// Continues with the node scheduled after the breakpoint instead of re-running step2
+		res, err := runnable.InvokeCommand(ctx, &Command{Resume: "go"}, WithThreadID("t2"))
+		assert.NoError(t, err)
+		assert.Equal(t, "done", res["step3"])
+		assert.Equal(t, "go", res["resume"])
+		assert.Equal(t, map[string]int{"step1": 1, "step2": 1, "step3": 1}, executions)
+
+		// The thread has finished, so there is nothing left to resume
+		_, err = runnable.InvokeCommand(ctx, &Command{Resume: "again"}, WithThreadID("t2"))
+		assert.ErrorContains(t, err, "no pending nodes")
+	})
+
+	t.Run("RequiresThread", func(t *testing.T) {
+		g := NewCheckpointableStateGraph[map[string]any]()
+		g.AddNode("a", "a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		})
+		g.SetEntryPoint("a")
+		g.AddEdge("a", END)
+
+		runnable, err := g.CompileCheckpointable()
+		assert.NoError(t, err)
+
+		_, err = runnable.InvokeCommand(ctx, &Command{Resume: true}, nil)
+		assert.ErrorContains(t, err, "thread_id")
+
+		_, err = runnable.InvokeCommand(ctx, &Command{Resume: true}, WithThreadID("unknown"))
+		assert.Error(t, err)
+	})
+}
+
+func TestInterrupt_ParallelNodes(t *testing.T) {
+	ctx := context.Background()
+
+	// newGraph fans start out to nodes a and b, and b out to c, counting the runs
+	newGraph := func(t *testing.T, bInterrupts bool) (*CheckpointableRunnable[map[string]any], map[string]int) {
+		g := NewCheckpointableStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+
+		var mu sync.Mutex
+		executions := map[string]int{}
+		node := func(name string, interrupts bool) func(context.Context, map[string]any) (map[string]any, error) {
+			return func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				mu.Lock()
+				executions[name]++
+				mu.Unlock()
+				if !interrupts {
+					return map[string]any{name: "done"}, nil
+				}
+				answer, err := Interrupt(ctx, "question of "+name)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{name: answer}, nil
+			}
+		}
+		g.AddNode("start", "start", node("start", false))
+		g.AddNode("a", "a", node("a", true))
+		g.AddNode("b", "b", node("b", bInterrupts))
+		g.AddNode("c", "c", node("c", false))
+		g.SetEntryPoint("start")
+		g.AddEdge("start", "a")
+		g.AddEdge("start", "b")
+		g.AddEdge("a", END)
+		g.AddEdge("b", "c")
+		g.AddEdge("c", END)
+
+		runnable, err := g.CompileCheckpointable()
+		assert.NoError(t, err)
+		return runnable, executions
+	}
+
+	t.Run("Both interrupt", func(t *testing.T) {
+		runnable, executions := newGraph(t, true)
+
+		var interrupt *GraphInterrupt
+		_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("both"))
+		assert.ErrorAs(t, err, &interrupt)
+		assert.Equal(t, "a", interrupt.Node)
+		assert.Equal(t, []string{"a", "b"}, interrupt.NextNodes)
+
+		// The answer is for a: b runs again with it but pauses with its own question
+		_, err = runnable.InvokeCommand(ctx, &Command{Resume: "yes"}, WithThreadID("both"))
+		assert.ErrorAs(t, err, &interrupt)
+		assert.Equal(t, "b", interrupt.Node)
+		assert.Equal(t, "question of b", interrupt.InterruptValue)
+
+		res, err := runnable.InvokeCommand(ctx, &Command{Resume: "no"}, WithThreadID("both"))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"start": "done", "a": "yes", "b": "no", "c": "done"}, res)
+		assert.Equal(t, map[string]int{"start": 1, "a": 2, "b": 3, "c": 1}, executions)
+	})
+
+	t.Run("Sibling with a successor", func(t *testing.T) {
+		runnable, executions := newGraph(t, false)
+
+		var interrupt *GraphInterrupt
+		_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("sibling"))
+		assert.ErrorAs(t, err, &interrupt)
+		assert.Equal(t, "a", interrupt.Node)
+
+		// The node b led to runs after a resumes, though b doesn't run again
+		res, err := runnable.InvokeCommand(ctx, &Command{Resume: "yes"}, WithThreadID("sibling"))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"start": "done", "a": "yes", "b": "done", "c": "done"}, res)
+		assert.Equal(t, map[string]int{"start": 1, "a": 2, "b": 1, "c": 1}, executions)
+	})
+
+	t.Run("Nested graph", func(t *testing.T) {
+		// A graph the resumed node invokes before asking doesn't take the answer
+		inner := NewStateGraph[map[string]any]()
+		inner.AddNode("peek", "peek", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			answer, err := Interrupt(ctx, "inner question")
+			if err != nil {
+				return map[string]any{"inner": "unanswered"}, nil
+			}
+			return map[string]any{"inner": answer}, nil
+		})
+		inner.SetEntryPoint("peek")
+		inner.AddEdge("peek", END)
+		nested, err := inner.Compile()
+		assert.NoError(t, err)
+
+		g := NewCheckpointableStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		g.AddNode("a", "a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			res, err := nested.Invoke(ctx, map[string]any{})
+			if err != nil {
+				return nil, err
+			}
+			answer, err := Interrupt(ctx, "outer question")
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"inner": res["inner"], "outer": answer}, nil
+		})
+		g.SetEntryPoint("a")
+		g.AddEdge("a", END)
+		runnable, err := g.CompileCheckpointable()
+		assert.NoError(t, err)
+
+		var interrupt *GraphInterrupt
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("nested"))
+		assert.ErrorAs(t, err, &interrupt)
+
+		res, err := runnable.InvokeCommand(ctx, &Command{Resume: "yes"}, WithThreadID("nested"))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"inner": "unanswered", "outer": "yes"}, res)
+	})
+}
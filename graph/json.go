@@ -0,0 +1,226 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// ErrUnregisteredNode is returned by FromJSON when the registry has no function for a node.
+var ErrUnregisteredNode = errors.New("no function registered for node")
+
+// ErrUnboundRouter is reported when a conditional edge has no router, such as the
+// conditional edges of a graph built with FromJSON before BindRouter is called.
+var ErrUnboundRouter = errors.New("conditional edge has no router")
+
+// customReducer is the name exported for reducers that are not one of the named reducers
+const customReducer = "custom"
+
+// GraphDefinition is the JSON description of a graph's structure, written by
+// Exporter.ToJSON and read by FromJSON. Functions are not part of it: node
+// functions are bound by name when importing, and routers with BindRouter.
+type GraphDefinition struct {
+	EntryPoint       string                      `json:"entry_point,omitempty"`
+	Nodes            []NodeDefinition            `json:"nodes"`
+	Edges            []EdgeDefinition            `json:"edges"`
+	ConditionalEdges []ConditionalEdgeDefinition `json:"conditional_edges,omitempty"`
+	ErrorEdges       []EdgeDefinition            `json:"error_edges,omitempty"`
+	ErrorHandler     string                      `json:"error_handler,omitempty"`
+
+	// Reducers maps state keys to the name of their reducer (see NewSchemaFromStruct
+	// for the names), or "custom" for other reducers
+	Reducers map[string]string `json:"reducers,omitempty"`
+	Required []string          `json:"required,omitempty"`
+}
+
+// NodeDefinition describes a node of a GraphDefinition.
+type NodeDefinition struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// EdgeDefinition describes a static or error edge of a GraphDefinition. An edge from
+// START sets the entry point.
+type EdgeDefinition struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// ConditionalEdgeDefinition describes a conditional edge of a GraphDefinition. Routes
+// is its path map, and is empty for routers returning node names.
+type ConditionalEdgeDefinition struct {
+	From   string            `json:"from"`
+	Routes map[string]string `json:"routes,omitempty"`
+}
+
+// ToJSON returns the JSON GraphDefinition of the graph: its entry point, nodes with
+// their descriptions, tags and metadata, edges, conditional edge path maps, error
+// routing, and the reducer names and required keys of a MapSchema. Node functions,
+// routers and subgraphs are not exported.
+func (ge *Exporter[S]) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(ge.graph.definition(), "", "  ")
+}
+
+// definition returns the GraphDefinition of the graph
+func (g *StateGraph[S]) definition() GraphDefinition {
+	def := GraphDefinition{
+		EntryPoint:   g.entryPoint,
+		Nodes:        []NodeDefinition{},
+		Edges:        []EdgeDefinition{},
+		ErrorHandler: g.errorHandler,
+	}
+	for _, name := range sortedKeys(g.nodes) {
+		node := g.nodes[name]
+		def.Nodes = append(def.Nodes, NodeDefinition{
+			Name:        name,
+			Description: node.Description,
+			Tags:        node.Tags,
+			Metadata:    node.Metadata,
+		})
+	}
+	for _, edge := range g.edges {
+		def.Edges = append(def.Edges, EdgeDefinition{From: edge.From, To: edge.To, Priority: edge.Priority})
+	}
+	for _, from := range sortedKeys(g.conditionalEdges) {
+		def.ConditionalEdges = append(def.ConditionalEdges, ConditionalEdgeDefinition{From: from, Routes: g.conditionalPathMaps[from]})
+	}
+	for _, from := range sortedKeys(g.errorEdges) {
+		def.ErrorEdges = append(def.ErrorEdges, EdgeDefinition{From: from, To: g.errorEdges[from]})
+	}
+
+	if schema, ok := any(g.Schema).(*MapSchema); ok {
+		for _, key := range sortedKeys(schema.Reducers) {
+			if def.Reducers == nil {
+				def.Reducers = make(map[string]string)
+			}
+			def.Reducers[key] = reducerName(schema.Reducers[key])
+		}
+		def.Required = slices.Clone(schema.Required)
+	}
+	return def
+}
+
+// reducerName returns the name of a named reducer, or "custom"
+func reducerName(reducer Reducer) string {
+	pointer := reflect.ValueOf(reducer).Pointer()
+	for _, name := range sortedKeys(namedReducers) {
+		if reflect.ValueOf(namedReducers[name]).Pointer() == pointer {
+			return name
+		}
+	}
+	return customReducer
+}
+
+// FromJSON builds a graph from a GraphDefinition written by Exporter.ToJSON, or by
+// hand or a planner in the same format. Node functions are looked up by name in
+// registry, and a node without one fails with ErrUnregisteredNode.
+//
+// Conditional edges are restored with their path maps but without routers, which
+// must be bound with BindRouter before the graph compiles. For map[string]any
+// states the named reducers and required keys are set on a new MapSchema; keys
+// with a "custom" reducer are left for the caller to register again.
+//
+// Example:
+//
+//	g, err := graph.FromJSON(data, map[string]graph.NodeFunc[map[string]any]{
+//	    "research": research,
+//	    "write":    write,
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//	runnable, err := g.Compile()
+func FromJSON[S any](data []byte, registry map[string]NodeFunc[S]) (*StateGraph[S], error) {
+	var def GraphDefinition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("failed to parse graph definition: %w", err)
+	}
+
+	g := NewStateGraph[S]()
+	for _, node := range def.Nodes {
+		if node.Name == START || node.Name == END {
+			continue
+		}
+		fn, ok := registry[node.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnregisteredNode, node.Name)
+		}
+		var opts []NodeOption
+		if len(node.Tags) > 0 {
+			opts = append(opts, WithTags(node.Tags...))
+		}
+		if len(node.Metadata) > 0 {
+			opts = append(opts, WithMetadata(node.Metadata))
+		}
+		g.AddNodeWithOptions(node.Name, node.Description, fn, opts...)
+	}
+
+	if def.EntryPoint != "" {
+		g.SetEntryPoint(def.EntryPoint)
+	}
+	for _, edge := range def.Edges {
+		g.AddEdgeWithPriority(edge.From, edge.To, edge.Priority)
+	}
+	for _, edge := range def.ConditionalEdges {
+		if len(edge.Routes) > 0 {
+			g.AddConditionalEdgeWithMapping(edge.From, nil, edge.Routes)
+		} else {
+			g.AddConditionalEdge(edge.From, nil)
+		}
+	}
+	for _, edge := range def.ErrorEdges {
+		g.AddErrorEdge(edge.From, edge.To)
+	}
+	if def.ErrorHandler != "" {
+		g.SetErrorHandler(def.ErrorHandler)
+	}
+
+	if len(def.Reducers) > 0 || len(def.Required) > 0 {
+		schema := NewMapSchema()
+		for _, key := range sortedKeys(def.Reducers) {
+			name := def.Reducers[key]
+			if name == customReducer {
+				continue
+			}
+			reducer, ok := namedReducers[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown reducer %q for state key %s", name, key)
+			}
+			schema.RegisterReducer(key, reducer)
+		}
+		for _, key := range def.Required {
+			schema.RegisterRequired(key)
+		}
+		if s, ok := any(schema).(StateSchema[S]); ok {
+			g.SetSchema(s)
+		}
+	}
+
+	return g, nil
+}
+
+// BindRouter sets the router of the conditional edge from the from node, keeping
+// its path map. It binds the routers of graphs built with FromJSON, and returns
+// ErrEdgeNotFound when the node has no conditional edge.
+//
+// Example:
+//
+//	err := g.BindRouter("review", func(ctx context.Context, state map[string]any) string {
+//	    if state["approved"] == true {
+//	        return "approve"
+//	    }
+//	    return "reject"
+//	})
+func (g *StateGraph[S]) BindRouter(from string, router func(ctx context.Context, state S) string) error {
+	if _, ok := g.conditionalEdges[from]; !ok {
+		return fmt.Errorf("%w: no conditional edge from %s", ErrEdgeNotFound, from)
+	}
+	g.conditionalEdges[from] = router
+	return nil
+}
@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJSONGraph() (*StateGraph[map[string]any], map[string]NodeFunc[map[string]any]) {
+	registry := map[string]NodeFunc[map[string]any]{
+		"plan": func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"steps": []any{"plan"}}, nil
+		},
+		"fetch": func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"steps": []any{"fetch"}}, nil
+		},
+		"write": func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"steps": []any{"write"}, "draft": "done"}, nil
+		},
+		"apologize": func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"draft": "sorry"}, nil
+		},
+	}
+
+	g := NewStateGraph[map[string]any]()
+	schema := NewMapSchema()
+	schema.RegisterReducer("steps", AppendReducer)
+	schema.RegisterReducer("seen", func(current, new any) (any, error) { return new, nil })
+	schema.RegisterRequired("draft")
+	g.SetSchema(schema)
+	g.AddNodeWithOptions("plan", "Plan the answer", registry["plan"], WithTags("llm"))
+	g.AddNodeWithOptions("fetch", "Fetch sources", registry["fetch"], WithMetadata(map[string]any{"timeout": "5s"}))
+	g.AddNode("write", "Write the answer", registry["write"])
+	g.AddNode("apologize", "Recover from failures", registry["apologize"])
+	g.SetEntryPoint("plan")
+	g.AddConditionalEdgeWithMapping("plan", func(ctx context.Context, state map[string]any) string {
+		return "research"
+	}, map[string]string{"research": "fetch", "direct": "write"})
+	g.AddEdgeWithPriority("fetch", "write", 5)
+	g.AddEdge("write", END)
+	g.AddEdge("apologize", END)
+	g.AddErrorEdge("fetch", "apologize")
+	return g, registry
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	original, registry := newJSONGraph()
+	data, err := NewExporter(original).ToJSON()
+	require.NoError(t, err)
+
+	var def GraphDefinition
+	require.NoError(t, json.Unmarshal(data, &def))
+	assert.Equal(t, "plan", def.EntryPoint)
+	assert.Equal(t, []EdgeDefinition{{From: "fetch", To: "write", Priority: 5}, {From: "write", To: END}, {From: "apologize", To: END}}, def.Edges)
+	assert.Equal(t, []ConditionalEdgeDefinition{{From: "plan", Routes: map[string]string{"research": "fetch", "direct": "write"}}}, def.ConditionalEdges)
+	assert.Equal(t, map[string]string{"steps": "append", "seen": "custom"}, def.Reducers)
+	assert.Equal(t, []string{"draft"}, def.Required)
+
+	restored, err := FromJSON(data, registry)
+	require.NoError(t, err)
+	// Custom reducers are registered again by the caller
+	restored.Schema.(*MapSchema).RegisterReducer("seen", func(current, new any) (any, error) { return new, nil })
+
+	// Routers are not exported, so the graph doesn't compile until they are bound
+	_, err = restored.Compile()
+	assert.ErrorIs(t, err, ErrUnboundRouter)
+	assert.ErrorIs(t, restored.BindRouter("write", nil), ErrEdgeNotFound)
+	require.NoError(t, restored.BindRouter("plan", func(ctx context.Context, state map[string]any) string {
+		return "research"
+	}))
+
+	again, err := NewExporter(restored).ToJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(again))
+	assert.Equal(t, NewExporter(original).DrawMermaid(), NewExporter(restored).DrawMermaid())
+	assert.Equal(t, original.fingerprint(), restored.fingerprint())
+
+	runnable, err := restored.Compile()
+	require.NoError(t, err)
+	res, err := runnable.Invoke(context.Background(), map[string]any{"draft": ""})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"plan", "fetch", "write"}, res["steps"])
+	assert.Equal(t, "done", res["draft"])
+}
+
+func TestFromJSON(t *testing.T) {
+	noop := func(ctx context.Context, state []string) ([]string, error) {
+		name, _ := NodeNameFromContext(ctx)
+		return append(state, name), nil
+	}
+
+	t.Run("PlanFormat", func(t *testing.T) {
+		// The planning agent's plans use the same node and edge format
+		plan := `{
+			"nodes": [{"name": "research", "type": "process"}, {"name": "summarize", "type": "process"}],
+			"edges": [
+				{"from": "START", "to": "research"},
+				{"from": "research", "to": "summarize"},
+				{"from": "summarize", "to": "END"}
+			]
+		}`
+		g, err := FromJSON([]byte(plan), map[string]NodeFunc[[]string]{"research": noop, "summarize": noop})
+		require.NoError(t, err)
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		res, err := runnable.Invoke(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"research", "summarize"}, res)
+	})
+
+	t.Run("UnregisteredNode", func(t *testing.T) {
+		_, err := FromJSON([]byte(`{"nodes": [{"name": "research"}], "edges": []}`), map[string]NodeFunc[[]string]{})
+		assert.ErrorIs(t, err, ErrUnregisteredNode)
+		assert.ErrorContains(t, err, "research")
+	})
+
+	t.Run("UnknownReducer", func(t *testing.T) {
+		_, err := FromJSON([]byte(`{"nodes": [], "edges": [], "reducers": {"steps": "shuffle"}}`), map[string]NodeFunc[map[string]any]{})
+		assert.ErrorContains(t, err, `unknown reducer "shuffle" for state key steps`)
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		_, err := FromJSON([]byte(`{`), map[string]NodeFunc[[]string]{})
+		assert.ErrorContains(t, err, "failed to parse graph definition")
+	})
+}
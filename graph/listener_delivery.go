@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Delivery is how the events of the nodes are delivered to a listener
+type Delivery string
+
+const (
+	// DeliveryFanOut notifies the listener in a goroutine of its own, which the
+	// node waits for along with the other fan-out listeners. It is the default.
+	DeliveryFanOut Delivery = "fan_out"
+
+	// DeliverySync notifies the listener inline, in the goroutine of the node.
+	// It suits cheap listeners, sparing them a goroutine per event.
+	DeliverySync Delivery = "sync"
+
+	// DeliveryAsync queues the events of the listener, which receives them in
+	// order from a goroutine of its own without slowing the nodes down. The events
+	// arriving while the queue is full are dropped, and counted in the Dropped
+	// field of the ListenerInfo of the listener.
+	DeliveryAsync Delivery = "async"
+)
+
+// DefaultListenerQueueSize is the size of the queue of DeliveryAsync listeners
+const DefaultListenerQueueSize = 256
+
+// WithDelivery returns a ListenerOption setting how the events are delivered to
+// the listener.
+//
+// Example:
+//
+//	node.AddListenerFiltered(exporter, graph.WithDelivery(graph.DeliveryAsync), graph.WithQueueSize(1024))
+func WithDelivery(delivery Delivery) ListenerOption {
+	return func(o *listenerOptions) {
+		o.delivery = delivery
+	}
+}
+
+// WithQueueSize returns a ListenerOption setting the size of the queue of a
+// DeliveryAsync listener, DefaultListenerQueueSize by default
+func WithQueueSize(size int) ListenerOption {
+	return func(o *listenerOptions) {
+		if size > 0 {
+			o.queueSize = size
+		}
+	}
+}
+
+// newListenerWrapper returns the wrapper registering listener with o. Without a
+// filter in o, it has the filter of the listener SubscribeEvents returned. The
+// wrapper of a DeliveryAsync listener has a queue, which the nodes it is added
+// to share so that the listener receives their events in order.
+func newListenerWrapper[S any](listener NodeListener[S], o listenerOptions) listenerWrapper[S] {
+	filter := o.filter
+	if filter == nil {
+		listener, filter = splitFilter(listener)
+	}
+	wrapper := listenerWrapper[S]{
+		listener:  listener,
+		filter:    filter,
+		delivery:  o.delivery,
+		maxPanics: o.maxPanics,
+		health:    &listenerHealth{},
+	}
+	if o.delivery == DeliveryAsync {
+		wrapper.queue = newListenerQueue[S](o.queueSize)
+	}
+	return wrapper
+}
+
+// queuedEvent is an event of node waiting in the queue of a listener
+type queuedEvent[S any] struct {
+	ctx     context.Context
+	event   *StreamEvent[S]
+	node    *ListenableNode[S]
+	wrapper listenerWrapper[S]
+}
+
+// listenerQueue delivers events to a DeliveryAsync listener from a goroutine,
+// started with the first event and stopped when the listener is removed from
+// the last of its nodes
+type listenerQueue[S any] struct {
+	events  chan queuedEvent[S]
+	start   sync.Once
+	dropped atomic.Int64
+
+	mutex  sync.RWMutex
+	refs   int
+	closed bool
+}
+
+func newListenerQueue[S any](size int) *listenerQueue[S] {
+	return &listenerQueue[S]{events: make(chan queuedEvent[S], size)}
+}
+
+// push queues the event of node, or drops it when the queue is full
+func (q *listenerQueue[S]) push(queued queuedEvent[S]) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+	if q.closed {
+		return
+	}
+	q.start.Do(func() { go q.run() })
+	select {
+	case q.events <- queued:
+	default:
+		q.dropped.Add(1)
+	}
+}
+
+func (q *listenerQueue[S]) run() {
+	for queued := range q.events {
+		queued.node.deliverTo(queued.ctx, queued.wrapper, queued.event)
+	}
+}
+
+// retain records that the queue was added to a node; a nil queue is ignored
+func (q *listenerQueue[S]) retain() {
+	if q == nil {
+		return
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.refs++
+}
+
+// release records that the queue was removed from a node, and closes it once it
+// was removed from all of them, after the queued events were delivered
+func (q *listenerQueue[S]) release() {
+	if q == nil {
+		return
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.refs--; q.refs == 0 && !q.closed {
+		q.closed = true
+		close(q.events)
+	}
+}
+
+// droppedCount returns the number of events dropped as the queue was full
+func (q *listenerQueue[S]) droppedCount() int64 {
+	if q == nil {
+		return 0
+	}
+	return q.dropped.Load()
+}
+
+// ListenerInfo describes a listener of a node
+type ListenerInfo[S any] struct {
+	// ID is the ID of the listener on the node
+	ID string
+
+	// Listener is the listener
+	Listener NodeListener[S]
+
+	// Delivery is how the events are delivered to the listener
+	Delivery Delivery
+
+	// Dropped is the number of events a DeliveryAsync listener dropped as its
+	// queue was full. The queue is shared by the nodes the listener was added to
+	// together, such as with AddGlobalListenerFiltered, so the count is too.
+	Dropped int64
+}
+
+// GetListenerInfo returns the descriptions of the current listeners, in the
+// order of GetListeners
+func (ln *ListenableNode[S]) GetListenerInfo() []ListenerInfo[S] {
+	ln.mutex.RLock()
+	defer ln.mutex.RUnlock()
+
+	infos := make([]ListenerInfo[S], len(ln.listeners))
+	for i, wrapper := range ln.listeners {
+		infos[i] = ListenerInfo[S]{
+			ID:       wrapper.id,
+			Listener: wrapper.listener,
+			Delivery: wrapper.delivery,
+			Dropped:  wrapper.queue.droppedCount(),
+		}
+	}
+	return infos
+}
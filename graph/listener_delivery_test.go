@@ -0,0 +1,128 @@
+package graph_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenerDelivery(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Sync", func(t *testing.T) {
+		t.Parallel()
+		g := newThreeStepGraph()
+		recorder := &eventRecorder{}
+		g.AddGlobalListenerFiltered(recorder, graph.WithDelivery(graph.DeliverySync))
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"a:start", "a:complete", "b:start", "b:complete", "c:start", "c:complete",
+		}, recorder.events)
+		assert.Equal(t, graph.DeliverySync, g.GetListenableNode("a").GetListenerInfo()[0].Delivery)
+	})
+
+	t.Run("Async", func(t *testing.T) {
+		t.Parallel()
+		g := newThreeStepGraph()
+		recorder := &eventRecorder{}
+		g.AddGlobalListenerFiltered(recorder, graph.WithDelivery(graph.DeliveryAsync))
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+
+		// The nodes share the queue, so the events arrive in order
+		assert.Eventually(t, func() bool {
+			recorder.mu.Lock()
+			defer recorder.mu.Unlock()
+			return len(recorder.events) == 6
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, []string{
+			"a:start", "a:complete", "b:start", "b:complete", "c:start", "c:complete",
+		}, recorder.events)
+	})
+
+	t.Run("AsyncDropsOnFullQueue", func(t *testing.T) {
+		t.Parallel()
+		node := graph.NewListenableNode(graph.TypedNode[string]{Name: "node"})
+		release := make(chan struct{})
+		var delivered atomic.Int64
+		id := node.AddListenerFiltered(graph.NodeListenerFunc[string](func(ctx context.Context, event graph.NodeEvent, nodeName string, state string, err error) {
+			<-release
+			delivered.Add(1)
+		}), graph.WithDelivery(graph.DeliveryAsync), graph.WithQueueSize(2))
+
+		// The blocked listener doesn't hold up the node
+		for i := range 10 {
+			node.NotifyListeners(context.Background(), graph.NodeEventProgress, fmt.Sprint(i), nil)
+		}
+		info := node.GetListenerInfo()
+		require.Len(t, info, 1)
+		assert.Equal(t, id, info[0].ID)
+		assert.Equal(t, graph.DeliveryAsync, info[0].Delivery)
+		assert.GreaterOrEqual(t, info[0].Dropped, int64(7))
+
+		close(release)
+		assert.Eventually(t, func() bool {
+			return delivered.Load()+node.GetListenerInfo()[0].Dropped == 10
+		}, time.Second, time.Millisecond)
+		node.RemoveListener(id)
+		assert.Empty(t, node.GetListenerInfo())
+	})
+}
+
+// newThreeStepGraph returns a listenable graph running nodes a, b and c in turn
+func newThreeStepGraph() *graph.ListenableStateGraph[map[string]any] {
+	g := graph.NewListenableStateGraph[map[string]any]()
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		})
+	}
+	g.SetEntryPoint("a")
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", graph.END)
+	return g
+}
+
+// BenchmarkListenerDelivery notifies three cheap listeners of 1000 node events
+// with each delivery
+func BenchmarkListenerDelivery(b *testing.B) {
+	for _, delivery := range []graph.Delivery{graph.DeliveryFanOut, graph.DeliverySync, graph.DeliveryAsync} {
+		b.Run(string(delivery), func(b *testing.B) {
+			node := graph.NewListenableNode(graph.TypedNode[map[string]any]{Name: "node"})
+			var count atomic.Int64
+			var ids []string
+			for range 3 {
+				ids = append(ids, node.AddListenerFiltered(graph.NodeListenerFunc[map[string]any](func(context.Context, graph.NodeEvent, string, map[string]any, error) {
+					count.Add(1)
+				}), graph.WithDelivery(delivery), graph.WithQueueSize(4096)))
+			}
+			ctx := context.Background()
+			state := map[string]any{}
+
+			b.ReportAllocs()
+			for b.Loop() {
+				for range 1000 {
+					node.NotifyListeners(ctx, graph.NodeEventProgress, state, nil)
+				}
+			}
+			b.StopTimer()
+			for _, id := range ids {
+				node.RemoveListener(id)
+			}
+		})
+	}
+}
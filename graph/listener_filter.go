@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"context"
+	"slices"
+)
+
+// ListenerOption restricts the events a listener added with AddListenerFiltered
+// or AddGlobalListenerFiltered receives, or sets how they are delivered
+type ListenerOption func(*listenerOptions)
+
+// listenerOptions are the options of the registration of a listener
+type listenerOptions struct {
+	filter    *listenerFilter // nil delivers everything
+	delivery  Delivery
+	queueSize int
+	maxPanics int
+}
+
+// listenerFilter selects the events delivered to a listener. Empty lists select
+// everything.
+type listenerFilter struct {
+	events []NodeEvent
+	nodes  []string
+}
+
+// WithEvents returns a ListenerOption delivering only the given events
+func WithEvents(events ...NodeEvent) ListenerOption {
+	return func(o *listenerOptions) {
+		o.selects().events = append(o.filter.events, events...)
+	}
+}
+
+// WithNodes returns a ListenerOption delivering only the events of the named nodes
+func WithNodes(nodes ...string) ListenerOption {
+	return func(o *listenerOptions) {
+		o.selects().nodes = append(o.filter.nodes, nodes...)
+	}
+}
+
+// selects returns the filter of the options, creating it if needed
+func (o *listenerOptions) selects() *listenerFilter {
+	if o.filter == nil {
+		o.filter = &listenerFilter{}
+	}
+	return o.filter
+}
+
+func newListenerOptions(opts []ListenerOption) listenerOptions {
+	o := listenerOptions{delivery: DeliveryFanOut, queueSize: DefaultListenerQueueSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// accepts reports whether event of node passes the filter; a nil filter accepts
+// everything
+func (f *listenerFilter) accepts(event NodeEvent, node string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.events) > 0 && !slices.Contains(f.events, event) {
+		return false
+	}
+	return f.selectsNode(node)
+}
+
+// selectsNode reports whether the filter passes the events of node
+func (f *listenerFilter) selectsNode(node string) bool {
+	return f == nil || len(f.nodes) == 0 || slices.Contains(f.nodes, node)
+}
+
+// filteredListener is a listener receiving only the events its filter accepts
+type filteredListener[S any] struct {
+	listener NodeListener[S]
+	filter   *listenerFilter
+}
+
+// OnNodeEvent implements the NodeListener interface
+func (fl *filteredListener[S]) OnNodeEvent(ctx context.Context, event NodeEvent, nodeName string, state S, err error) {
+	if fl.filter.accepts(event, nodeName) {
+		fl.listener.OnNodeEvent(ctx, event, nodeName, state, err)
+	}
+}
+
+// OnEvent implements the EventListener interface, so an EventListener passed to
+// SubscribeEvents keeps receiving the details of the events
+func (fl *filteredListener[S]) OnEvent(ctx context.Context, event *StreamEvent[S]) {
+	if fl.filter.accepts(event.Event, event.NodeName) {
+		deliver(ctx, fl.listener, event)
+	}
+}
+
+// SubscribeEvents returns a listener passing only the given events on to
+// listener. Nodes don't notify it of the other events at all, so subscribing a
+// token-streaming UI to EventToken spares it the start and complete events.
+//
+// Example:
+//
+//	g.AddGlobalListener(graph.SubscribeEvents(ui, graph.EventToken))
+func SubscribeEvents[S any](listener NodeListener[S], events ...NodeEvent) NodeListener[S] {
+	return &filteredListener[S]{listener: listener, filter: &listenerFilter{events: events}}
+}
+
+// splitFilter returns listener with the filter of the listener SubscribeEvents
+// returned, which nodes apply before notifying it, or no filter
+func splitFilter[S any](listener NodeListener[S]) (NodeListener[S], *listenerFilter) {
+	if fl, ok := listener.(*filteredListener[S]); ok {
+		return fl, fl.filter
+	}
+	return listener, nil
+}
+
+// AddListenerFiltered adds a listener receiving only the events selected by opts
+// and returns its ID.
+//
+// Example:
+//
+//	id := node.AddListenerFiltered(ui, graph.WithEvents(graph.EventToken))
+func (ln *ListenableNode[S]) AddListenerFiltered(listener NodeListener[S], opts ...ListenerOption) string {
+	o := newListenerOptions(opts)
+	return ln.addListener(newListenerWrapper(listener, o))
+}
+
+// AddGlobalListenerFiltered adds a listener to the nodes of the graph receiving
+// only the events selected by opts. With WithNodes, it is only added to the
+// named nodes. The listener can be removed with RemoveGlobalListener.
+func (g *ListenableStateGraph[S]) AddGlobalListenerFiltered(listener NodeListener[S], opts ...ListenerOption) {
+	o := newListenerOptions(opts)
+	wrapper := newListenerWrapper(listener, o)
+	for name, node := range g.listenableNodes {
+		if wrapper.filter.selectsNode(name) {
+			node.addListener(wrapper)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package graph_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventRecorder records the events it receives as node:event
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *eventRecorder) OnNodeEvent(_ context.Context, event graph.NodeEvent, nodeName string, _ map[string]any, _ error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, nodeName+":"+string(event))
+}
+
+// newStreamingPipeline builds a graph retrieve -> generate -> END whose generate
+// node streams two tokens
+func newStreamingPipeline() *graph.ListenableStateGraph[map[string]any] {
+	g := graph.NewListenableStateGraph[map[string]any]()
+	g.AddNode("retrieve", "retrieve", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		graph.EmitToken(ctx, "ignored")
+		return state, nil
+	})
+	g.AddNode("generate", "generate", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		graph.EmitToken(ctx, "Hel")
+		graph.EmitToken(ctx, "lo")
+		return state, nil
+	})
+	g.AddEdge("retrieve", "generate")
+	g.AddEdge("generate", graph.END)
+	g.SetEntryPoint("retrieve")
+	return g
+}
+
+func invokePipeline(t *testing.T, g *graph.ListenableStateGraph[map[string]any]) {
+	t.Helper()
+	runnable, err := g.CompileListenable()
+	require.NoError(t, err)
+	_, err = runnable.Invoke(context.Background(), map[string]any{})
+	require.NoError(t, err)
+}
+
+func TestListenerFilters(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AddListenerFiltered", func(t *testing.T) {
+		t.Parallel()
+		g := newStreamingPipeline()
+		tokens, all := &eventRecorder{}, &eventRecorder{}
+		id := g.GetListenableNode("generate").AddListenerFiltered(tokens, graph.WithEvents(graph.EventToken))
+		assert.NotEmpty(t, id)
+		g.GetListenableNode("generate").AddListener(all)
+
+		invokePipeline(t, g)
+		assert.Equal(t, []string{"generate:token", "generate:token"}, tokens.events)
+		assert.Equal(t, []string{"generate:start", "generate:token", "generate:token", "generate:complete"}, all.events)
+	})
+
+	t.Run("AddGlobalListenerFiltered", func(t *testing.T) {
+		t.Parallel()
+		g := newStreamingPipeline()
+		tokens, completions := &eventRecorder{}, &eventRecorder{}
+		g.AddGlobalListenerFiltered(tokens, graph.WithEvents(graph.EventToken), graph.WithNodes("generate"))
+		g.AddGlobalListenerFiltered(completions, graph.WithEvents(graph.NodeEventComplete))
+
+		invokePipeline(t, g)
+		assert.Equal(t, []string{"generate:token", "generate:token"}, tokens.events)
+		assert.Equal(t, []string{"retrieve:complete", "generate:complete"}, completions.events)
+		assert.Len(t, g.GetListenableNode("retrieve").GetListeners(), 1)
+	})
+
+	t.Run("SubscribeEvents", func(t *testing.T) {
+		t.Parallel()
+		g := newStreamingPipeline()
+		tokens := &eventRecorder{}
+		subscribed := graph.SubscribeEvents[map[string]any](tokens, graph.EventToken)
+		g.AddGlobalListener(subscribed)
+
+		invokePipeline(t, g)
+		assert.Equal(t, []string{"retrieve:token", "generate:token", "generate:token"}, tokens.events)
+
+		// Called directly, the subscribed listener filters too
+		subscribed.OnNodeEvent(context.Background(), graph.NodeEventStart, "generate", nil, nil)
+		assert.Len(t, tokens.events, 3)
+
+		g.RemoveGlobalListener(subscribed)
+		assert.Empty(t, g.GetListenableNode("generate").GetListeners())
+	})
+
+	t.Run("RemoveGlobalListener", func(t *testing.T) {
+		t.Parallel()
+		g := newStreamingPipeline()
+		tokens := &eventRecorder{}
+		g.AddGlobalListenerFiltered(tokens, graph.WithEvents(graph.EventToken))
+		g.RemoveGlobalListener(tokens)
+
+		invokePipeline(t, g)
+		assert.Empty(t, tokens.events)
+	})
+}
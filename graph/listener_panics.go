@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"context"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/smallnest/langgraphgo/log"
+)
+
+// ListenerPanic describes a panic of a listener, which the node recovered from
+type ListenerPanic struct {
+	// Node is the name of the node of the event
+	Node string
+
+	// Event is the event the listener was notified of
+	Event NodeEvent
+
+	// Listener is the listener that panicked
+	Listener any
+
+	// Value is the value the listener panicked with
+	Value any
+
+	// Stack is the stack trace of the panic
+	Stack []byte
+}
+
+// ListenerPanicHandler handles the panics of listeners. The node and its other
+// listeners carry on once it returns.
+type ListenerPanicHandler func(ctx context.Context, p ListenerPanic)
+
+// LogListenerPanic is the default ListenerPanicHandler, logging the panic with
+// its stack trace at the error level
+func LogListenerPanic(_ context.Context, p ListenerPanic) {
+	log.Error("listener %T panicked on %s event of node %s: %v\n%s", p.Listener, p.Event, p.Node, p.Value, p.Stack)
+}
+
+// WithMaxPanics returns a ListenerOption disabling the listener after n
+// consecutive panics, with a warning. By default, listeners keep being notified
+// however often they panic.
+func WithMaxPanics(n int) ListenerOption {
+	return func(o *listenerOptions) {
+		o.maxPanics = n
+	}
+}
+
+// listenerHealth tracks the panics of a listener
+type listenerHealth struct {
+	consecutive atomic.Int64
+	disabled    atomic.Bool
+}
+
+// SetListenerPanicHandler sets the handler of the panics of the listeners of the
+// node, LogListenerPanic by default
+func (ln *ListenableNode[S]) SetListenerPanicHandler(handler ListenerPanicHandler) {
+	ln.mutex.Lock()
+	defer ln.mutex.Unlock()
+	ln.panicHandler = handler
+}
+
+// ListenerPanics returns the number of panics of the listeners of the node
+func (ln *ListenableNode[S]) ListenerPanics() int64 {
+	return ln.panics.Load()
+}
+
+// SetListenerPanicHandler sets the handler of the panics of the listeners of all
+// the nodes of the graph, including those added later
+func (g *ListenableStateGraph[S]) SetListenerPanicHandler(handler ListenerPanicHandler) {
+	g.panicHandler = handler
+	for _, node := range g.listenableNodes {
+		node.SetListenerPanicHandler(handler)
+	}
+}
+
+// deliverTo delivers event to the listener of wrapper, passing its panics to
+// the panic handler of the node
+func (ln *ListenableNode[S]) deliverTo(ctx context.Context, wrapper listenerWrapper[S], event *StreamEvent[S]) {
+	defer func() {
+		if r := recover(); r != nil {
+			ln.listenerPanicked(ctx, wrapper, event, r, debug.Stack())
+		}
+	}()
+	deliver(ctx, wrapper.listener, event)
+	wrapper.health.consecutive.Store(0)
+}
+
+// listenerPanicked counts and handles the panic of the listener of wrapper, and
+// disables the listener when it panicked too many times in a row
+func (ln *ListenableNode[S]) listenerPanicked(ctx context.Context, wrapper listenerWrapper[S], event *StreamEvent[S], value any, stack []byte) {
+	ln.panics.Add(1)
+
+	ln.mutex.RLock()
+	handler := ln.panicHandler
+	ln.mutex.RUnlock()
+	if handler == nil {
+		handler = LogListenerPanic
+	}
+	func() {
+		// A panicking handler must not take the node down either
+		defer func() { _ = recover() }()
+		handler(ctx, ListenerPanic{
+			Node:     event.NodeName,
+			Event:    event.Event,
+			Listener: wrapper.listener,
+			Value:    value,
+			Stack:    stack,
+		})
+	}()
+
+	if wrapper.maxPanics <= 0 {
+		return
+	}
+	if wrapper.health.consecutive.Add(1) >= int64(wrapper.maxPanics) && wrapper.health.disabled.CompareAndSwap(false, true) {
+		log.Warn("listener %T disabled after %d consecutive panics", wrapper.listener, wrapper.maxPanics)
+	}
+}
@@ -0,0 +1,75 @@
+package graph_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panicCollector struct {
+	mu     sync.Mutex
+	panics []graph.ListenerPanic
+}
+
+func (c *panicCollector) handle(_ context.Context, p graph.ListenerPanic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.panics = append(c.panics, p)
+}
+
+func TestListenerPanics(t *testing.T) {
+	t.Parallel()
+
+	panicking := graph.NodeListenerFunc[map[string]any](func(ctx context.Context, event graph.NodeEvent, nodeName string, state map[string]any, err error) {
+		panic("metrics backend is down")
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		t.Parallel()
+		g := newThreeStepGraph()
+		collector := &panicCollector{}
+		g.SetListenerPanicHandler(collector.handle)
+		recorder := &eventRecorder{}
+		g.AddGlobalListenerFiltered(recorder, graph.WithDelivery(graph.DeliverySync))
+		g.AddGlobalListener(panicking)
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		result, err := runnable.Invoke(context.Background(), map[string]any{"ok": true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"ok": true}, result)
+		assert.Len(t, recorder.events, 6)
+
+		require.Len(t, collector.panics, 6)
+		first := collector.panics[0]
+		assert.Equal(t, "a", first.Node)
+		assert.Equal(t, graph.NodeEventStart, first.Event)
+		assert.Equal(t, "metrics backend is down", first.Value)
+		assert.Contains(t, string(first.Stack), "TestListenerPanics")
+		assert.Equal(t, int64(2), g.GetListenableNode("b").ListenerPanics())
+	})
+
+	t.Run("MaxPanics", func(t *testing.T) {
+		t.Parallel()
+		g := newThreeStepGraph()
+		collector := &panicCollector{}
+		g.SetListenerPanicHandler(collector.handle)
+		g.AddGlobalListenerFiltered(panicking, graph.WithMaxPanics(3))
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		for range 2 {
+			_, err = runnable.Invoke(context.Background(), map[string]any{})
+			require.NoError(t, err)
+		}
+		// The nodes share the count, so the listener was disabled in node b
+		assert.Len(t, collector.panics, 3)
+		assert.Equal(t, int64(2), g.GetListenableNode("a").ListenerPanics())
+		assert.Equal(t, int64(1), g.GetListenableNode("b").ListenerPanics())
+		assert.Zero(t, g.GetListenableNode("c").ListenerPanics())
+	})
+}
@@ -3,8 +3,10 @@ package graph
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,6 +26,21 @@ const (
 	// NodeEventError indicates a node encountered an error
 	NodeEventError NodeEvent = "error"
 
+	// NodeEventRetry indicates a failed node is about to be retried
+	NodeEventRetry NodeEvent = "retry"
+
+	// NodeEventCacheHit indicates a node's output was served from the cache instead of executing it
+	NodeEventCacheHit NodeEvent = "cache_hit"
+
+	// NodeEventCheckpointSkipped indicates CheckpointConfig.BeforeSave vetoed the
+	// checkpoint saved after a node, which is passed as the error
+	NodeEventCheckpointSkipped NodeEvent = "checkpoint_skipped"
+
+	// NodeEventCheckpointDropped indicates the queue of CheckpointConfig.Async was
+	// full and QueueFullDropOldest discarded a checkpoint of the run, which is
+	// reported with ErrCheckpointDropped
+	NodeEventCheckpointDropped NodeEvent = "checkpoint_dropped"
+
 	// EventChainStart indicates the graph execution has started
 	EventChainStart NodeEvent = "chain_start"
 
@@ -80,17 +97,31 @@ type StreamEvent[S any] struct {
 	// Error contains any error that occurred (if Event is NodeEventError)
 	Error error
 
-	// Metadata contains additional event-specific data
+	// Metadata contains additional event-specific data: the "attempt" number of
+	// the node execution, starting at 1, the "token" of EventToken events, and
+	// the "name" and "payload" of EventCustom events
 	Metadata map[string]any
 
-	// Duration is how long the node took (only for Complete events)
+	// Duration is how long the node took (only for Complete and Error events)
 	Duration time.Duration
+
+	// StepIndex is the index of the step of the run the node ran in, starting at
+	// 1, and 0 for the events of nodes outside of the steps, such as deferred nodes
+	StepIndex int
 }
 
 // listenerWrapper wraps a listener with a unique ID for comparison
 type listenerWrapper[S any] struct {
 	id       string
 	listener NodeListener[S]
+	filter   *listenerFilter // events delivered to listener, all when nil
+	delivery Delivery
+	queue    *listenerQueue[S] // queue of a DeliveryAsync listener, shared by its nodes
+
+	// maxPanics is the number of consecutive panics disabling the listener, none
+	// when zero, and health its panics, shared by its nodes
+	maxPanics int
+	health    *listenerHealth
 }
 
 // ListenableNode extends TypedNode with listener capabilities
@@ -99,6 +130,9 @@ type ListenableNode[S any] struct {
 	listeners []listenerWrapper[S]
 	mutex     sync.RWMutex
 	nextID    int64
+
+	panicHandler ListenerPanicHandler
+	panics       atomic.Int64
 }
 
 // NewListenableNode creates a new listenable node from a regular typed node
@@ -112,32 +146,26 @@ func NewListenableNode[S any](node TypedNode[S]) *ListenableNode[S] {
 
 // AddListener adds a listener to the node and returns the listenable node for chaining
 func (ln *ListenableNode[S]) AddListener(listener NodeListener[S]) *ListenableNode[S] {
-	ln.mutex.Lock()
-	defer ln.mutex.Unlock()
-
-	id := fmt.Sprintf("listener_%d", ln.nextID)
-	ln.nextID++
-
-	ln.listeners = append(ln.listeners, listenerWrapper[S]{
-		id:       id,
-		listener: listener,
-	})
+	ln.addListener(newListenerWrapper(listener, newListenerOptions(nil)))
 	return ln
 }
 
 // AddListenerWithID adds a listener to the node and returns its ID
 func (ln *ListenableNode[S]) AddListenerWithID(listener NodeListener[S]) string {
+	return ln.addListener(newListenerWrapper(listener, newListenerOptions(nil)))
+}
+
+// addListener adds the listener of wrapper and returns its ID
+func (ln *ListenableNode[S]) addListener(wrapper listenerWrapper[S]) string {
 	ln.mutex.Lock()
 	defer ln.mutex.Unlock()
 
-	id := fmt.Sprintf("listener_%d", ln.nextID)
+	wrapper.id = fmt.Sprintf("listener_%d", ln.nextID)
 	ln.nextID++
+	wrapper.queue.retain()
 
-	ln.listeners = append(ln.listeners, listenerWrapper[S]{
-		id:       id,
-		listener: listener,
-	})
-	return id
+	ln.listeners = append(ln.listeners, wrapper)
+	return wrapper.id
 }
 
 // RemoveListener removes a listener from the node by ID
@@ -148,6 +176,7 @@ func (ln *ListenableNode[S]) RemoveListener(listenerID string) {
 	for i, lw := range ln.listeners {
 		if lw.id == listenerID {
 			ln.listeners = append(ln.listeners[:i], ln.listeners[i+1:]...)
+			lw.queue.release()
 			break
 		}
 	}
@@ -162,6 +191,7 @@ func (ln *ListenableNode[S]) RemoveListenerByFunc(listener NodeListener[S]) {
 		// Use reflect.DeepEqual for proper interface value comparison
 		if reflect.DeepEqual(lw.listener, listener) {
 			ln.listeners = append(ln.listeners[:i], ln.listeners[i+1:]...)
+			lw.queue.release()
 			break
 		}
 	}
@@ -169,49 +199,80 @@ func (ln *ListenableNode[S]) RemoveListenerByFunc(listener NodeListener[S]) {
 
 // NotifyListeners notifies all listeners of an event
 func (ln *ListenableNode[S]) NotifyListeners(ctx context.Context, event NodeEvent, state S, err error) {
+	ln.notify(ctx, event, ln.Name, state, err)
+}
+
+// notify notifies all listeners of an event of the node nodeName
+func (ln *ListenableNode[S]) notify(ctx context.Context, event NodeEvent, nodeName string, state S, err error) {
+	ln.notifyTimed(ctx, event, nodeName, state, err, 0)
+}
+
+// notifyTimed notifies all listeners of an event of the node nodeName, which ran
+// for duration when it completed or failed
+func (ln *ListenableNode[S]) notifyTimed(ctx context.Context, event NodeEvent, nodeName string, state S, err error, duration time.Duration) {
+	// Filter before fanning out, so listeners don't cost a goroutine for the
+	// events they didn't subscribe to
 	ln.mutex.RLock()
-	wrappers := make([]listenerWrapper[S], len(ln.listeners))
-	copy(wrappers, ln.listeners)
+	wrappers := make([]listenerWrapper[S], 0, len(ln.listeners))
+	for _, wrapper := range ln.listeners {
+		if wrapper.filter.accepts(event, nodeName) && !wrapper.health.disabled.Load() {
+			wrappers = append(wrappers, wrapper)
+		}
+	}
 	ln.mutex.RUnlock()
+	if len(wrappers) == 0 {
+		return
+	}
+	streamEvent := newStreamEvent(ctx, event, nodeName, state, err)
+	streamEvent.Duration = duration
 
-	// Use WaitGroup to synchronize listener notifications
+	// Notify the fan-out listeners in separate goroutines, and wait for them
+	// while the synchronous listeners run inline
 	var wg sync.WaitGroup
-
-	// Notify listeners in separate goroutines to avoid blocking execution
 	for _, wrapper := range wrappers {
-		wg.Add(1)
-		go func(l NodeListener[S]) {
-			defer wg.Done()
-
-			// Protect against panics in listeners
-			defer func() {
-				if r := recover(); r != nil {
-					// Panic recovered, but not logged to avoid dependencies
-					_ = r // Acknowledge the panic was caught
-				}
-			}()
-
-			l.OnNodeEvent(ctx, event, ln.Name, state, err)
-		}(wrapper.listener)
+		switch wrapper.delivery {
+		case DeliverySync:
+		case DeliveryAsync:
+			wrapper.queue.push(queuedEvent[S]{ctx: ctx, event: streamEvent, node: ln, wrapper: wrapper})
+		default:
+			wg.Go(func() {
+				ln.deliverTo(ctx, wrapper, streamEvent)
+			})
+		}
+	}
+	for _, wrapper := range wrappers {
+		if wrapper.delivery == DeliverySync {
+			ln.deliverTo(ctx, wrapper, streamEvent)
+		}
 	}
-
-	// Wait for all listener notifications to complete
 	wg.Wait()
 }
 
 // Execute runs the node function with listener notifications
 func (ln *ListenableNode[S]) Execute(ctx context.Context, state S) (S, error) {
+	return ln.execute(ctx, state, ln.Function)
+}
+
+// execute runs fn in place of the node function, notifying the node's listeners
+func (ln *ListenableNode[S]) execute(ctx context.Context, state S, fn NodeFunc[S]) (S, error) {
+	return ln.executeAs(ctx, ln.Name, state, fn)
+}
+
+// executeAs runs fn, notifying the listeners of the events of the node nodeName
+func (ln *ListenableNode[S]) executeAs(ctx context.Context, nodeName string, state S, fn NodeFunc[S]) (S, error) {
 	// Notify start
-	ln.NotifyListeners(ctx, NodeEventStart, state, nil)
+	ln.notify(ctx, NodeEventStart, nodeName, state, nil)
 
 	// Execute the node function
-	result, err := ln.Function(ctx, state)
+	start := time.Now()
+	result, err := fn(ctx, state)
+	duration := time.Since(start)
 
 	// Notify completion or error
 	if err != nil {
-		ln.NotifyListeners(ctx, NodeEventError, state, err)
+		ln.notifyTimed(ctx, NodeEventError, nodeName, state, err, duration)
 	} else {
-		ln.NotifyListeners(ctx, NodeEventComplete, result, nil)
+		ln.notifyTimed(ctx, NodeEventComplete, nodeName, result, nil, duration)
 	}
 
 	return result, err
@@ -245,6 +306,7 @@ func (ln *ListenableNode[S]) GetListenerIDs() []string {
 type ListenableStateGraph[S any] struct {
 	*StateGraph[S]
 	listenableNodes map[string]*ListenableNode[S]
+	panicHandler    ListenerPanicHandler
 }
 
 // NewListenableStateGraph creates a new typed state graph with listener support
@@ -264,6 +326,7 @@ func (g *ListenableStateGraph[S]) AddNode(name string, description string, fn fu
 	}
 
 	listenableNode := NewListenableNode(node)
+	listenableNode.panicHandler = g.panicHandler
 
 	// Add to both the base graph and our listenable nodes map
 	g.StateGraph.AddNode(name, description, fn)
@@ -272,6 +335,15 @@ func (g *ListenableStateGraph[S]) AddNode(name string, description string, fn fu
 	return listenableNode
 }
 
+// AddNodeWithOptions adds a listenable node and applies the given options to it
+func (g *ListenableStateGraph[S]) AddNodeWithOptions(name string, description string, fn func(ctx context.Context, state S) (S, error), opts ...NodeOption) *ListenableNode[S] {
+	node := g.AddNode(name, description, fn)
+	g.applyNodeOptions(name, opts)
+	node.Tags = g.nodes[name].Tags
+	node.Metadata = g.nodes[name].Metadata
+	return node
+}
+
 // GetListenableNode returns the listenable node by name
 func (g *ListenableStateGraph[S]) GetListenableNode(name string) *ListenableNode[S] {
 	return g.listenableNodes[name]
@@ -306,30 +378,31 @@ type ListenableRunnable[S any] struct {
 }
 
 // CompileListenable creates a runnable with listener support
-func (g *ListenableStateGraph[S]) CompileListenable() (*ListenableRunnable[S], error) {
-	if g.entryPoint == "" {
-		return nil, ErrEntryPointNotSet
-	}
-
-	runnable, err := g.StateGraph.Compile()
+func (g *ListenableStateGraph[S]) CompileListenable(opts ...CompileOption) (*ListenableRunnable[S], error) {
+	runnable, err := g.StateGraph.Compile(opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	// Configure the runnable to use our listenable nodes
-	nodes := g.listenableNodes
-	runnable.nodeRunner = func(ctx context.Context, nodeName string, state S) (S, error) {
+	// Configure the runnable to use our listenable nodes, as of compile time
+	nodes := maps.Clone(g.listenableNodes)
+	runnable.nodeRunner = func(ctx context.Context, nodeName string, state S, fn NodeFunc[S]) (S, error) {
 		node, ok := nodes[nodeName]
 		if !ok {
 			var zero S
 			return zero, fmt.Errorf("%w: %s", ErrNodeNotFound, nodeName)
 		}
-		return node.Execute(ctx, state)
+		return node.execute(ctx, state, fn)
+	}
+	runnable.nodeNotifier = func(ctx context.Context, event NodeEvent, nodeName string, state S, err error) {
+		if node, ok := nodes[nodeName]; ok {
+			node.NotifyListeners(ctx, event, state, err)
+		}
 	}
 
 	return &ListenableRunnable[S]{
 		graph:           g,
-		listenableNodes: g.listenableNodes,
+		listenableNodes: nodes,
 		runnable:        runnable,
 	}, nil
 }
@@ -347,49 +420,97 @@ func (lr *ListenableRunnable[S]) InvokeWithConfig(ctx context.Context, initialSt
 	return lr.runnable.InvokeWithConfig(ctx, initialState, config)
 }
 
-// Stream executes the graph with listener notifications and streams events
+// Stream executes the graph with listener notifications and streams events. It
+// is StreamWithConfig without a config, discarding the error of the run, which
+// the EventChainEnd event carries too.
 func (lr *ListenableRunnable[S]) Stream(ctx context.Context, initialState S) <-chan StreamEvent[S] {
-	eventChan := make(chan StreamEvent[S], 100) // Buffered channel
-
-	// Create a streaming listener
-	// Using DefaultStreamConfig from streaming.go
-	streamListener := NewStreamingListener(eventChan, DefaultStreamConfig())
+	events, _ := lr.StreamWithConfig(ctx, initialState, nil)
+	return events
+}
+
+// StreamWithConfig executes the graph with config and streams the events of the
+// run, from EventChainStart to EventChainEnd with the final state. The events
+// channel is closed when the run ends, after the errors channel received its
+// error, if any.
+//
+// The events are those of this run only: concurrent Stream calls on the runnable
+// don't see each other's events, which are told apart by the RunID of their
+// config. A RunID is generated when config has none.
+//
+// Example:
+//
+//	events, errs := runnable.StreamWithConfig(ctx, state, config)
+//	for event := range events {
+//	    fmt.Println(event.NodeName, event.Event)
+//	}
+//	if err := <-errs; err != nil {
+//	    return err
+//	}
+func (lr *ListenableRunnable[S]) StreamWithConfig(ctx context.Context, initialState S, config *Config) (<-chan StreamEvent[S], <-chan error) {
+	streamConfig := DefaultStreamConfig()
+	eventChan := make(chan StreamEvent[S], streamConfig.BufferSize)
+	errChan := make(chan error, 1)
+
+	config = runConfig(config)
+	streamListener := NewStreamingListener(eventChan, streamConfig)
+	listener := &runListener[S]{runID: config.RunID, listener: streamListener}
+	lr.graph.AddGlobalListener(listener)
 
-	// Add the listener to all nodes
-	lr.graph.AddGlobalListener(streamListener)
-
-	// Start execution in a goroutine
 	go func() {
 		defer func() {
-			// Clean up: remove the listener and close the channel
-			// We remove the listener first to stop new events
-			lr.graph.RemoveGlobalListener(streamListener)
-			// Close the listener (sets internal flag)
+			// Remove the listener first to stop new events, then close the channels
+			lr.graph.RemoveGlobalListener(listener)
 			streamListener.Close()
-			// Close the channel
+			close(errChan)
 			close(eventChan)
 		}()
 
-		// Send chain start event
 		eventChan <- StreamEvent[S]{
 			Timestamp: time.Now(),
 			Event:     EventChainStart,
 			State:     initialState,
 		}
 
-		// Execute the graph
-		_, err := lr.runnable.Invoke(ctx, initialState)
+		result, err := lr.InvokeWithConfig(ctx, initialState, config)
+		if err != nil {
+			errChan <- err
+		}
 
-		// Send chain end event
 		eventChan <- StreamEvent[S]{
 			Timestamp: time.Now(),
 			Event:     EventChainEnd,
-			State:     initialState, // Note: This should be the final state
+			State:     result,
 			Error:     err,
 		}
 	}()
 
-	return eventChan
+	return eventChan, errChan
+}
+
+// runListener passes on to listener the events of the run with runID only
+type runListener[S any] struct {
+	runID    string
+	listener NodeListener[S]
+}
+
+// accepts reports whether ctx is the context of an event of the run
+func (rl *runListener[S]) accepts(ctx context.Context) bool {
+	runID, _ := RunIDFromContext(ctx)
+	return runID == rl.runID
+}
+
+// OnNodeEvent implements the NodeListener interface
+func (rl *runListener[S]) OnNodeEvent(ctx context.Context, event NodeEvent, nodeName string, state S, err error) {
+	if rl.accepts(ctx) {
+		rl.listener.OnNodeEvent(ctx, event, nodeName, state, err)
+	}
+}
+
+// OnEvent implements the EventListener interface
+func (rl *runListener[S]) OnEvent(ctx context.Context, event *StreamEvent[S]) {
+	if rl.accepts(ctx) {
+		deliver(ctx, rl.listener, event)
+	}
 }
 
 // SetTracer sets a tracer for the underlying runnable
@@ -419,6 +540,11 @@ func (lr *ListenableRunnable[S]) GetGraph() *Exporter[S] {
 	return NewExporter[S](regularGraph)
 }
 
+// CompileReport returns the warnings found when the graph was compiled.
+func (lr *ListenableRunnable[S]) CompileReport() *CompileReport {
+	return lr.runnable.CompileReport()
+}
+
 // GetListenableGraph returns the underlying ListenableStateGraph
 func (lr *ListenableRunnable[S]) GetListenableGraph() *ListenableStateGraph[S] {
 	return lr.graph
@@ -2,12 +2,15 @@ package graph_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -509,3 +512,69 @@ func BenchmarkListenableRunnable_Invoke(b *testing.B) {
 		_, _ = runnable.Invoke(ctx, map[string]any{"test": "test"})
 	}
 }
+
+func TestListenableRunnable_StreamWithConfig(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewListenableStateGraph[map[string]any]()
+	g.AddNode("greet", "greet", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		name, _ := state["name"].(string)
+		if name == "" {
+			return nil, errors.New("no name")
+		}
+		for range 3 {
+			time.Sleep(5 * time.Millisecond)
+			graph.EmitToken(ctx, name)
+		}
+		return map[string]any{"greeting": "hello " + name}, nil
+	})
+	g.AddEdge("greet", graph.END)
+	g.SetEntryPoint("greet")
+	runnable, err := g.CompileListenable()
+	require.NoError(t, err)
+
+	// Two concurrent streams only receive the events of their own run
+	names := []string{"alice", "bob"}
+	streamed := make([][]graph.StreamEvent[map[string]any], len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Go(func() {
+			events, errs := runnable.StreamWithConfig(context.Background(), map[string]any{"name": name}, &graph.Config{RunID: "run-" + name})
+			for event := range events {
+				streamed[i] = append(streamed[i], event)
+			}
+			assert.NoError(t, <-errs)
+		})
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		var kinds []graph.NodeEvent
+		for _, event := range streamed[i] {
+			kinds = append(kinds, event.Event)
+			if event.Event == graph.EventToken {
+				assert.Equal(t, name, event.Metadata["token"])
+			}
+		}
+		assert.Equal(t, []graph.NodeEvent{
+			graph.EventChainStart,
+			graph.NodeEventStart,
+			graph.EventToken, graph.EventToken, graph.EventToken,
+			graph.NodeEventComplete,
+			graph.EventChainEnd,
+		}, kinds)
+		assert.Equal(t, "hello "+name, streamed[i][len(streamed[i])-1].State["greeting"])
+	}
+
+	// The listener of a stream is removed when it ends
+	assert.Empty(t, runnable.GetListenableGraph().GetListenableNode("greet").GetListeners())
+
+	// The error of the run is sent on the errors channel
+	events, errs := runnable.StreamWithConfig(context.Background(), map[string]any{}, nil)
+	var last graph.StreamEvent[map[string]any]
+	for event := range events {
+		last = event
+	}
+	assert.EqualError(t, <-errs, last.Error.Error())
+	assert.Equal(t, graph.EventChainEnd, last.Event)
+}
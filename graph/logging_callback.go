@@ -0,0 +1,240 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smallnest/langgraphgo/log"
+)
+
+// LoggingCallbackHandler logs graph runs to a log.Logger as lines of structured
+// fields, such as
+//
+//	event=node_complete run_id=run-1 node=chat duration_ms=12
+//
+// It is both a CallbackHandler, logging the runs and the tool, LLM and
+// retriever calls reported to the Config callbacks, and a NodeListener, logging
+// node events; it can be used as either or both.
+type LoggingCallbackHandler struct {
+	logger log.Logger
+	level  log.LogLevel
+	tokens bool
+
+	mutex sync.Mutex
+	// starts are the start times of the open runs and calls by runID, and of the
+	// running nodes by run ID and node name
+	starts map[string]time.Time
+}
+
+// NewLoggingCallbackHandler creates a handler logging the events of at least level
+// to logger: errors at LogLevelError, retries at LogLevelWarn, completions and
+// custom events at LogLevelInfo, and starts, cache hits and tokens at
+// LogLevelDebug. Tokens are
+// only logged when enabled with WithTokens.
+func NewLoggingCallbackHandler(logger log.Logger, level log.LogLevel) *LoggingCallbackHandler {
+	return &LoggingCallbackHandler{
+		logger: logger,
+		level:  level,
+		starts: make(map[string]time.Time),
+	}
+}
+
+// WithTokens enables or disables logging the tokens streamed by nodes
+func (h *LoggingCallbackHandler) WithTokens(enabled bool) *LoggingCallbackHandler {
+	h.tokens = enabled
+	return h
+}
+
+// logField is a field of a log line
+type logField struct {
+	key   string
+	value any
+}
+
+// log writes the line of event with fields at level
+func (h *LoggingCallbackHandler) log(level log.LogLevel, event string, fields ...logField) {
+	if level < h.level {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("event=")
+	b.WriteString(event)
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		value := fmt.Sprint(f.value)
+		if value == "" {
+			continue
+		}
+		if strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(" ")
+		b.WriteString(f.key)
+		b.WriteString("=")
+		b.WriteString(value)
+	}
+
+	line := b.String()
+	switch level {
+	case log.LogLevelDebug:
+		h.logger.Debug("%s", line)
+	case log.LogLevelInfo:
+		h.logger.Info("%s", line)
+	case log.LogLevelWarn:
+		h.logger.Warn("%s", line)
+	default:
+		h.logger.Error("%s", line)
+	}
+}
+
+// start records the start of key
+func (h *LoggingCallbackHandler) start(key string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.starts[key] = time.Now()
+}
+
+// end returns the duration of key in milliseconds, and nil if its start is unknown
+func (h *LoggingCallbackHandler) end(key string) any {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	start, ok := h.starts[key]
+	if !ok {
+		return nil
+	}
+	delete(h.starts, key)
+	return time.Since(start).Milliseconds()
+}
+
+func parentField(parentRunID *string) logField {
+	if parentRunID == nil {
+		return logField{"parent_run_id", nil}
+	}
+	return logField{"parent_run_id", *parentRunID}
+}
+
+func nameField(key string, serialized map[string]any) logField {
+	name, _ := serialized["name"].(string)
+	return logField{key, name}
+}
+
+// durationField returns the duration_ms field of key, which is left out when the
+// start of key is unknown
+func (h *LoggingCallbackHandler) durationField(key string) logField {
+	return logField{"duration_ms", h.end(key)}
+}
+
+// OnChainStart logs the start of a run
+func (h *LoggingCallbackHandler) OnChainStart(ctx context.Context, serialized map[string]any, inputs map[string]any, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	h.start(runID)
+	h.log(log.LogLevelInfo, "chain_start", logField{"run_id", runID}, parentField(parentRunID), nameField("name", serialized))
+}
+
+// OnChainEnd logs the end of a run
+func (h *LoggingCallbackHandler) OnChainEnd(ctx context.Context, outputs map[string]any, runID string) {
+	h.log(log.LogLevelInfo, "chain_end", logField{"run_id", runID}, h.durationField(runID))
+}
+
+// OnChainError logs the failure of a run
+func (h *LoggingCallbackHandler) OnChainError(ctx context.Context, err error, runID string) {
+	h.log(log.LogLevelError, "chain_error", logField{"run_id", runID}, h.durationField(runID), logField{"error", err})
+}
+
+// OnToolStart logs the start of a tool call, or the result of a node
+func (h *LoggingCallbackHandler) OnToolStart(ctx context.Context, serialized map[string]any, inputStr string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	h.start(runID)
+	h.log(log.LogLevelDebug, "tool_start", logField{"run_id", runID}, parentField(parentRunID), nameField("tool", serialized))
+}
+
+// OnToolEnd logs the end of a tool call
+func (h *LoggingCallbackHandler) OnToolEnd(ctx context.Context, output string, runID string) {
+	h.log(log.LogLevelInfo, "tool_end", logField{"run_id", runID}, h.durationField(runID))
+}
+
+// OnToolError logs the failure of a tool call
+func (h *LoggingCallbackHandler) OnToolError(ctx context.Context, err error, runID string) {
+	h.log(log.LogLevelError, "tool_error", logField{"run_id", runID}, h.durationField(runID), logField{"error", err})
+}
+
+// OnLLMStart logs the start of an LLM call
+func (h *LoggingCallbackHandler) OnLLMStart(ctx context.Context, serialized map[string]any, prompts []string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	h.start(runID)
+	h.log(log.LogLevelDebug, "llm_start", logField{"run_id", runID}, parentField(parentRunID), nameField("model", serialized), logField{"prompts", len(prompts)})
+}
+
+// OnLLMEnd logs the end of an LLM call
+func (h *LoggingCallbackHandler) OnLLMEnd(ctx context.Context, response any, runID string) {
+	h.log(log.LogLevelInfo, "llm_end", logField{"run_id", runID}, h.durationField(runID))
+}
+
+// OnLLMError logs the failure of an LLM call
+func (h *LoggingCallbackHandler) OnLLMError(ctx context.Context, err error, runID string) {
+	h.log(log.LogLevelError, "llm_error", logField{"run_id", runID}, h.durationField(runID), logField{"error", err})
+}
+
+// OnRetrieverStart logs the start of a retrieval
+func (h *LoggingCallbackHandler) OnRetrieverStart(ctx context.Context, serialized map[string]any, query string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	h.start(runID)
+	h.log(log.LogLevelDebug, "retriever_start", logField{"run_id", runID}, parentField(parentRunID), nameField("retriever", serialized))
+}
+
+// OnRetrieverEnd logs the end of a retrieval
+func (h *LoggingCallbackHandler) OnRetrieverEnd(ctx context.Context, documents []any, runID string) {
+	h.log(log.LogLevelInfo, "retriever_end", logField{"run_id", runID}, h.durationField(runID), logField{"documents", len(documents)})
+}
+
+// OnRetrieverError logs the failure of a retrieval
+func (h *LoggingCallbackHandler) OnRetrieverError(ctx context.Context, err error, runID string) {
+	h.log(log.LogLevelError, "retriever_error", logField{"run_id", runID}, h.durationField(runID), logField{"error", err})
+}
+
+// OnNodeEvent implements the NodeListener[map[string]any] interface
+func (h *LoggingCallbackHandler) OnNodeEvent(ctx context.Context, event NodeEvent, nodeName string, _ map[string]any, err error) {
+	var runID string
+	if config, ok := ConfigFromContext(ctx); ok {
+		runID = config.RunID
+	}
+	key := runID + "\x00" + nodeName
+	run, node := logField{"run_id", runID}, logField{"node", nodeName}
+
+	switch event {
+	case NodeEventStart:
+		h.start(key)
+		h.log(log.LogLevelDebug, "node_start", run, node)
+	case NodeEventComplete:
+		h.log(log.LogLevelInfo, "node_complete", run, node, h.durationField(key))
+	case NodeEventError:
+		h.log(log.LogLevelError, "node_error", run, node, h.durationField(key), logField{"error", err})
+	case NodeEventRetry:
+		h.log(log.LogLevelWarn, "node_retry", run, node, logField{"error", err})
+	case NodeEventCacheHit:
+		h.log(log.LogLevelDebug, "node_cache_hit", run, node)
+	case NodeEventCheckpointSkipped:
+		h.log(log.LogLevelWarn, "checkpoint_skipped", run, node, logField{"error", err})
+	case NodeEventCheckpointDropped:
+		h.log(log.LogLevelWarn, "checkpoint_dropped", run, node, logField{"error", err})
+	case EventToken:
+		if !h.tokens {
+			return
+		}
+		chunk, _ := TokenFromContext(ctx)
+		h.log(log.LogLevelDebug, "token", run, node, logField{"chunk", chunk})
+	case EventCustom:
+		custom, _ := CustomEventFromContext(ctx)
+		h.log(log.LogLevelInfo, "custom", run, node, logField{"name", custom.Name}, logField{"payload", custom.Payload})
+	default:
+		h.log(log.LogLevelDebug, "node_"+string(event), run, node, logField{"error", err})
+	}
+}
+
+var (
+	_ CallbackHandler              = (*LoggingCallbackHandler)(nil)
+	_ NodeListener[map[string]any] = (*LoggingCallbackHandler)(nil)
+)
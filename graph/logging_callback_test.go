@@ -0,0 +1,112 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineLogger records the lines logged to it with their level
+type lineLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *lineLogger) record(level, format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, v...))
+}
+
+func (l *lineLogger) Debug(format string, v ...any) { l.record("DEBUG", format, v...) }
+func (l *lineLogger) Info(format string, v ...any)  { l.record("INFO", format, v...) }
+func (l *lineLogger) Warn(format string, v ...any)  { l.record("WARN", format, v...) }
+func (l *lineLogger) Error(format string, v ...any) { l.record("ERROR", format, v...) }
+
+// events returns the event field of the lines, with their level
+func (l *lineLogger) events() []string {
+	var events []string
+	for _, line := range l.lines {
+		level, rest, _ := strings.Cut(line, " ")
+		event, _, _ := strings.Cut(strings.TrimPrefix(rest, "event="), " ")
+		events = append(events, level+" "+event)
+	}
+	return events
+}
+
+func runLoggedGraph(t *testing.T, handler *graph.LoggingCallbackHandler, fail bool) error {
+	t.Helper()
+	g := graph.NewListenableStateGraph[map[string]any]()
+	g.AddNode("chat", "chat", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		graph.EmitToken(ctx, "Hi there")
+		if fail {
+			return nil, errors.New("model unavailable")
+		}
+		return state, nil
+	})
+	g.AddEdge("chat", graph.END)
+	g.SetEntryPoint("chat")
+	g.AddGlobalListener(handler)
+	runnable, err := g.CompileListenable()
+	require.NoError(t, err)
+
+	_, err = runnable.InvokeWithConfig(context.Background(), map[string]any{}, &graph.Config{
+		RunID:     "run-1",
+		Callbacks: []graph.CallbackHandler{handler},
+	})
+	return err
+}
+
+func TestLoggingCallbackHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Debug", func(t *testing.T) {
+		t.Parallel()
+		logger := &lineLogger{}
+		require.NoError(t, runLoggedGraph(t, graph.NewLoggingCallbackHandler(logger, log.LogLevelDebug).WithTokens(true), false))
+
+		assert.Equal(t, []string{
+			"INFO chain_start",
+			"DEBUG node_start",
+			"DEBUG token",
+			"INFO node_complete",
+			"DEBUG tool_start",
+			"INFO tool_end",
+			"INFO chain_end",
+		}, logger.events())
+		assert.Equal(t, "INFO event=chain_start run_id=run-1 name=graph", logger.lines[0])
+		assert.Equal(t, `DEBUG event=token run_id=run-1 node=chat chunk="Hi there"`, logger.lines[2])
+		assert.Regexp(t, `^INFO event=node_complete run_id=run-1 node=chat duration_ms=\d+$`, logger.lines[3])
+		assert.Regexp(t, `^DEBUG event=tool_start run_id=\S+ parent_run_id=run-1 tool=chat$`, logger.lines[4])
+	})
+
+	t.Run("Info", func(t *testing.T) {
+		t.Parallel()
+		logger := &lineLogger{}
+		err := runLoggedGraph(t, graph.NewLoggingCallbackHandler(logger, log.LogLevelInfo), true)
+		require.Error(t, err)
+
+		assert.Equal(t, []string{
+			"INFO chain_start",
+			"ERROR node_error",
+			"ERROR chain_error",
+		}, logger.events())
+		assert.Regexp(t, `^ERROR event=node_error run_id=run-1 node=chat duration_ms=\d+ error="model unavailable"$`, logger.lines[1])
+		assert.Regexp(t, `^ERROR event=chain_error run_id=run-1 duration_ms=\d+ error="error in node chat: model unavailable"$`, logger.lines[2])
+	})
+
+	t.Run("TokensDisabled", func(t *testing.T) {
+		t.Parallel()
+		logger := &lineLogger{}
+		require.NoError(t, runLoggedGraph(t, graph.NewLoggingCallbackHandler(logger, log.LogLevelDebug), false))
+		assert.NotContains(t, logger.events(), "DEBUG token")
+	})
+}
@@ -0,0 +1,39 @@
+package graph
+
+import "context"
+
+// NodeFunc is the signature of a node function.
+type NodeFunc[S any] func(ctx context.Context, state S) (S, error)
+
+// Middleware wraps the execution of every node of a graph. It receives the next
+// function in the chain and returns a function that may inspect or change the
+// state passed to it, short-circuit by not calling next, change the returned
+// state, or wrap the returned error. The node being run is available through
+// NodeNameFromContext.
+type Middleware[S any] func(next NodeFunc[S]) NodeFunc[S]
+
+// Use adds middleware that wraps every node of the graph. Middleware is applied in
+// the order it was added, the first one being the outermost, and is fixed when the
+// graph is compiled. It runs once per attempt when a node is retried, and inside
+// node listeners, which observe the state returned by the middleware chain.
+//
+// Example:
+//
+//	g.Use(func(next graph.NodeFunc[State]) graph.NodeFunc[State] {
+//	    return func(ctx context.Context, state State) (State, error) {
+//	        name, _ := graph.NodeNameFromContext(ctx)
+//	        log.Printf("running %s", name)
+//	        return next(ctx, state)
+//	    }
+//	})
+func (g *StateGraph[S]) Use(middleware ...Middleware[S]) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// applyMiddleware wraps fn with the middleware, the first one being the outermost
+func applyMiddleware[S any](fn NodeFunc[S], middleware []Middleware[S]) NodeFunc[S] {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		fn = middleware[i](fn)
+	}
+	return fn
+}
@@ -0,0 +1,136 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMiddleware appends "<name>:<node>" to calls before running the node
+func recordingMiddleware(name string, mu *sync.Mutex, calls *[]string) Middleware[map[string]any] {
+	return func(next NodeFunc[map[string]any]) NodeFunc[map[string]any] {
+		return func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			node, _ := NodeNameFromContext(ctx)
+			mu.Lock()
+			*calls = append(*calls, name+":"+node)
+			mu.Unlock()
+			return next(ctx, state)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	newGraph := func() *StateGraph[map[string]any] {
+		g := NewStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		g.AddNode("a", "a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"a": "done"}, nil
+		})
+		g.AddNode("b", "b", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"b": "done"}, nil
+		})
+		g.SetEntryPoint("a")
+		g.AddEdge("a", "b")
+		g.AddEdge("b", END)
+		return g
+	}
+
+	t.Run("OrderAndNodeName", func(t *testing.T) {
+		var mu sync.Mutex
+		var calls []string
+		g := newGraph()
+		g.Use(recordingMiddleware("outer", &mu, &calls), recordingMiddleware("inner", &mu, &calls))
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		// Middleware added after Compile does not affect the runnable
+		g.Use(recordingMiddleware("late", &mu, &calls))
+
+		_, err = runnable.Invoke(ctx, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer:a", "inner:a", "outer:b", "inner:b"}, calls)
+	})
+
+	t.Run("ShortCircuitAndModify", func(t *testing.T) {
+		g := newGraph()
+		g.Use(func(next NodeFunc[map[string]any]) NodeFunc[map[string]any] {
+			return func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				if node, _ := NodeNameFromContext(ctx); node == "a" {
+					return map[string]any{"a": "skipped"}, nil
+				}
+				result, err := next(ctx, state)
+				result["sanitized"] = true
+				return result, err
+			}
+		})
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(ctx, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": "skipped", "b": "done", "sanitized": true}, res)
+	})
+
+	t.Run("WrapErrors", func(t *testing.T) {
+		errDenied := errors.New("denied")
+		g := newGraph()
+		g.Use(func(next NodeFunc[map[string]any]) NodeFunc[map[string]any] {
+			return func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				node, _ := NodeNameFromContext(ctx)
+				return nil, fmt.Errorf("auth check for %s: %w", node, errDenied)
+			}
+		})
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(ctx, map[string]any{})
+		assert.ErrorIs(t, err, errDenied)
+		assert.ErrorContains(t, err, "auth check for a")
+	})
+
+	t.Run("ListenableRunnable", func(t *testing.T) {
+		var mu sync.Mutex
+		var calls []string
+		g := NewListenableStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		node := g.AddNode("a", "a", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"a": "done"}, nil
+		})
+		g.SetEntryPoint("a")
+		g.AddEdge("a", END)
+		g.Use(recordingMiddleware("mw", &mu, &calls))
+		g.Use(func(next NodeFunc[map[string]any]) NodeFunc[map[string]any] {
+			return func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				result, err := next(ctx, state)
+				result["sanitized"] = true
+				return result, err
+			}
+		})
+
+		var completed map[string]any
+		node.AddListener(NodeListenerFunc[map[string]any](func(ctx context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {
+			if event == NodeEventComplete {
+				completed = state
+			}
+		}))
+
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(ctx, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mw:a"}, calls)
+		assert.Equal(t, true, res["sanitized"])
+		assert.Equal(t, true, completed["sanitized"], "listeners observe the middleware result")
+	})
+}
@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// RemoveNode removes a node from the graph before it is compiled, for example to
+// prune the nodes of a template graph that a feature flag or a plan didn't select.
+// The entry point can't be removed; set another one first.
+//
+// Edges that start or end at the node, conditional routes to it and its error edges
+// would be left dangling. Without force RemoveNode returns an error wrapping
+// ErrNodeHasEdges that lists them; with force they are removed along with the node.
+// The node's own settings, such as its retry policy or subgraph, are always removed.
+//
+// Example:
+//
+//	if !plan.Uses("review") {
+//	    g.AddEdge("draft", "publish")
+//	    if err := g.RemoveNode("review", true); err != nil {
+//	        return err
+//	    }
+//	}
+func (g *StateGraph[S]) RemoveNode(name string, force bool) error {
+	if _, ok := g.nodes[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	if name == g.entryPoint {
+		return fmt.Errorf("%w: %s", ErrRemoveEntryPoint, name)
+	}
+	if refs := g.nodeReferences(name); len(refs) > 0 && !force {
+		return fmt.Errorf("%w: %s (%s)", ErrNodeHasEdges, name, strings.Join(refs, ", "))
+	}
+
+	g.edges = slices.DeleteFunc(g.edges, func(edge Edge) bool {
+		return edge.From == name || edge.To == name
+	})
+	delete(g.conditionalEdges, name)
+	delete(g.conditionalPathMaps, name)
+	for from, pathMap := range g.conditionalPathMaps {
+		if !slices.Contains(slices.Collect(maps.Values(pathMap)), name) {
+			continue
+		}
+		// Path maps may be shared with compiled runnables, so they are replaced
+		pruned := maps.Clone(pathMap)
+		maps.DeleteFunc(pruned, func(key, to string) bool { return to == name })
+		g.conditionalPathMaps[from] = pruned
+	}
+	delete(g.errorEdges, name)
+	maps.DeleteFunc(g.errorEdges, func(from, to string) bool { return to == name })
+	if g.errorHandler == name {
+		g.errorHandler = ""
+	}
+
+	delete(g.nodes, name)
+	delete(g.nodeRetryPolicies, name)
+	delete(g.nodeCachePolicies, name)
+	delete(g.failFastNodes, name)
+	delete(g.subgraphs, name)
+	for i, nodes := range g.markedCycles {
+		g.markedCycles[i] = slices.DeleteFunc(slices.Clone(nodes), func(n string) bool { return n == name })
+	}
+	return nil
+}
+
+// nodeReferences describes the edges and routes that reference node, in a stable order
+func (g *StateGraph[S]) nodeReferences(node string) []string {
+	var refs []string
+	for _, edge := range g.edges {
+		if edge.From == node || edge.To == node {
+			refs = append(refs, fmt.Sprintf("edge %s -> %s", edge.From, edge.To))
+		}
+	}
+	for _, from := range sortedKeys(g.conditionalEdges) {
+		if from == node {
+			refs = append(refs, fmt.Sprintf("conditional edge from %s", from))
+			continue
+		}
+		pathMap := g.conditionalPathMaps[from]
+		for _, key := range sortedKeys(pathMap) {
+			if pathMap[key] == node {
+				refs = append(refs, fmt.Sprintf("conditional edge %s -> %s (%s)", from, node, key))
+			}
+		}
+	}
+	for _, from := range sortedKeys(g.errorEdges) {
+		if from == node || g.errorEdges[from] == node {
+			refs = append(refs, fmt.Sprintf("error edge %s -> %s", from, g.errorEdges[from]))
+		}
+	}
+	if g.errorHandler == node {
+		refs = append(refs, "error handler")
+	}
+	return refs
+}
+
+// RemoveEdge removes the static edge between from and to. Removing the edge from
+// START unsets the entry point. It returns an error wrapping ErrEdgeNotFound when
+// there is no such edge; conditional edges are replaced with AddConditionalEdge.
+func (g *StateGraph[S]) RemoveEdge(from, to string) error {
+	if from == START {
+		if g.entryPoint != to || to == "" {
+			return fmt.Errorf("%w: %s -> %s", ErrEdgeNotFound, from, to)
+		}
+		g.entryPoint = ""
+		return nil
+	}
+
+	n := len(g.edges)
+	g.edges = slices.DeleteFunc(g.edges, func(edge Edge) bool {
+		return edge.From == from && edge.To == to
+	})
+	if len(g.edges) == n {
+		return fmt.Errorf("%w: %s -> %s", ErrEdgeNotFound, from, to)
+	}
+	return nil
+}
+
+// ReplaceNode replaces the function of an existing node. Its edges, description and
+// options such as retry policies and tags are kept.
+//
+// Example:
+//
+//	if flags.MockLLM {
+//	    if err := g.ReplaceNode("agent", mockAgent); err != nil {
+//	        return err
+//	    }
+//	}
+func (g *StateGraph[S]) ReplaceNode(name string, fn func(ctx context.Context, state S) (S, error)) error {
+	node, ok := g.nodes[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, name)
+	}
+	node.Function = fn
+	g.nodes[name] = node
+	return nil
+}
+
+// RemoveNode removes a node and its listeners; see StateGraph.RemoveNode.
+func (g *ListenableStateGraph[S]) RemoveNode(name string, force bool) error {
+	if err := g.StateGraph.RemoveNode(name, force); err != nil {
+		return err
+	}
+	delete(g.listenableNodes, name)
+	return nil
+}
+
+// ReplaceNode replaces the function of a node and keeps its listeners; see
+// StateGraph.ReplaceNode.
+func (g *ListenableStateGraph[S]) ReplaceNode(name string, fn func(ctx context.Context, state S) (S, error)) error {
+	if err := g.StateGraph.ReplaceNode(name, fn); err != nil {
+		return err
+	}
+	if node, ok := g.listenableNodes[name]; ok {
+		node.Function = fn
+	}
+	return nil
+}
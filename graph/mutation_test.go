@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMutationGraph() *StateGraph[[]string] {
+	g := NewStateGraph[[]string]()
+	for _, name := range []string{"plan", "review", "publish"} {
+		g.AddNode(name, name, func(ctx context.Context, state []string) ([]string, error) {
+			return append(state, name), nil
+		})
+	}
+	g.SetEntryPoint("plan")
+	g.AddEdge("plan", "review")
+	g.AddEdge("review", "publish")
+	g.AddEdge("publish", END)
+	return g
+}
+
+func TestRemoveNode(t *testing.T) {
+	t.Run("EntryPoint", func(t *testing.T) {
+		g := newMutationGraph()
+		assert.ErrorIs(t, g.RemoveNode("plan", true), ErrRemoveEntryPoint)
+		assert.Contains(t, g.nodes, "plan")
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		g := newMutationGraph()
+		assert.ErrorIs(t, g.RemoveNode("missing", true), ErrNodeNotFound)
+	})
+
+	t.Run("DanglingEdges", func(t *testing.T) {
+		g := newMutationGraph()
+		err := g.RemoveNode("review", false)
+		assert.ErrorIs(t, err, ErrNodeHasEdges)
+		assert.EqualError(t, err, "node is referenced by edges: review (edge plan -> review, edge review -> publish)")
+		assert.Contains(t, g.nodes, "review")
+		assert.Len(t, g.edges, 3)
+	})
+
+	t.Run("Force", func(t *testing.T) {
+		g := newMutationGraph()
+		g.AddConditionalEdgeWithMapping("publish", func(ctx context.Context, state []string) string {
+			return "done"
+		}, map[string]string{"done": END, "again": "review"})
+		g.SetNodeRetryPolicy("review", NodeRetryPolicy{MaxAttempts: 2})
+		g.MarkCycle("review", "publish")
+
+		require.NoError(t, g.RemoveNode("review", true))
+		assert.NotContains(t, g.nodes, "review")
+		assert.NotContains(t, g.nodeRetryPolicies, "review")
+		assert.Equal(t, []Edge{{From: "publish", To: END}}, g.edges)
+		assert.Equal(t, map[string]string{"done": END}, g.conditionalPathMaps["publish"])
+		assert.Equal(t, [][]string{{"publish"}}, g.markedCycles)
+
+		g.AddEdge("plan", "publish")
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		res, err := runnable.Invoke(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plan", "publish"}, res)
+	})
+
+	t.Run("Listenable", func(t *testing.T) {
+		g := NewListenableStateGraph[[]string]()
+		g.AddNode("a", "a", func(ctx context.Context, state []string) ([]string, error) { return state, nil })
+		g.AddNode("b", "b", func(ctx context.Context, state []string) ([]string, error) { return state, nil })
+		g.SetEntryPoint("a")
+		g.AddEdge("a", "b")
+
+		require.NoError(t, g.RemoveNode("b", true))
+		assert.Nil(t, g.GetListenableNode("b"))
+		assert.Empty(t, g.edges)
+	})
+}
+
+func TestRemoveEdge(t *testing.T) {
+	g := newMutationGraph()
+	require.NoError(t, g.RemoveEdge("review", "publish"))
+	assert.Len(t, g.edges, 2)
+	assert.ErrorIs(t, g.RemoveEdge("review", "publish"), ErrEdgeNotFound)
+
+	assert.ErrorIs(t, g.RemoveEdge(START, "review"), ErrEdgeNotFound)
+	require.NoError(t, g.RemoveEdge(START, "plan"))
+	assert.Empty(t, g.entryPoint)
+}
+
+func TestReplaceNode(t *testing.T) {
+	g := newMutationGraph()
+	g.applyNodeOptions("review", []NodeOption{WithTags("llm")})
+
+	require.NoError(t, g.ReplaceNode("review", func(ctx context.Context, state []string) ([]string, error) {
+		return append(state, "mock review"), nil
+	}))
+	assert.Equal(t, []string{"llm"}, g.nodes["review"].Tags)
+	assert.Equal(t, "review", g.nodes["review"].Description)
+	assert.ErrorIs(t, g.ReplaceNode("missing", nil), ErrNodeNotFound)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	res, err := runnable.Invoke(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"plan", "mock review", "publish"}, res)
+}
@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"context"
+	"maps"
+	"slices"
+)
+
+type nodeNameKey struct{}
+
+type nodeTagsKey struct{}
+
+// WithTags returns a NodeOption that labels the node with tags, such as "llm",
+// "tool" or "io". Tags are added to the tags of node callbacks, are available to
+// middleware and listeners through NodeTagsFromContext, and become classes in the
+// exporter output.
+func WithTags(tags ...string) NodeOption {
+	return func(o *nodeOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithMetadata returns a NodeOption that attaches metadata to the node. It is merged
+// over Config.Metadata in node callbacks and shown in exporter tooltips.
+func WithMetadata(metadata map[string]any) NodeOption {
+	return func(o *nodeOptions) {
+		if o.metadata == nil {
+			o.metadata = make(map[string]any, len(metadata))
+		}
+		maps.Copy(o.metadata, metadata)
+	}
+}
+
+// NodeNameFromContext returns the name of the node being executed. It is set in
+// the context passed to middleware, node functions and node listeners.
+func NodeNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(nodeNameKey{}).(string)
+	return name, ok
+}
+
+// NodeRunIDFromContext returns the runID of the node being executed, which
+// callbacks receive with the node's result. Calls made by the node, such as to
+// tools, should be reported with it as their parentRunID; graphs invoked by the
+// node already are.
+func NodeRunIDFromContext(ctx context.Context) (string, bool) {
+	runID, ok := ctx.Value(nodeRunIDKey{}).(string)
+	return runID, ok
+}
+
+// NodeTagsFromContext returns the tags of the node being executed, as set with
+// WithTags.
+func NodeTagsFromContext(ctx context.Context) []string {
+	tags, _ := ctx.Value(nodeTagsKey{}).([]string)
+	return tags
+}
+
+// withNodeInfo marks the context of a node execution with the node's name and tags
+func withNodeInfo[S any](ctx context.Context, node TypedNode[S]) context.Context {
+	ctx = context.WithValue(ctx, nodeNameKey{}, node.Name)
+	if len(node.Tags) > 0 {
+		ctx = context.WithValue(ctx, nodeTagsKey{}, node.Tags)
+	}
+	return ctx
+}
+
+// nodeCallbackLabels returns the tags and metadata reported to callbacks for node:
+// those of the config followed by the node's own
+func nodeCallbackLabels[S any](node TypedNode[S], config *Config) ([]string, map[string]any) {
+	if len(node.Tags) == 0 && len(node.Metadata) == 0 {
+		return config.Tags, config.Metadata
+	}
+
+	tags := slices.Concat(config.Tags, node.Tags)
+	metadata := make(map[string]any, len(config.Metadata)+len(node.Metadata))
+	maps.Copy(metadata, config.Metadata)
+	maps.Copy(metadata, node.Metadata)
+	return tags, metadata
+}
@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolCallRecorder records the tags and metadata of node callbacks
+type toolCallRecorder struct {
+	NoOpCallbackHandler
+	mu         sync.Mutex
+	serialized map[string]map[string]any
+	tags       map[string][]string
+	metadata   map[string]map[string]any
+}
+
+func (c *toolCallRecorder) OnToolStart(ctx context.Context, serialized map[string]any, inputStr string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := serialized["name"].(string)
+	c.serialized[name] = serialized
+	c.tags[name] = tags
+	c.metadata[name] = metadata
+}
+
+func newTaggedGraph() *ListenableStateGraph[map[string]any] {
+	g := NewListenableStateGraph[map[string]any]()
+	g.SetSchema(NewMapSchema())
+	g.AddNodeWithOptions("call_model", "Ask the model", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"answer": "42"}, nil
+	}, WithTags("llm"), WithMetadata(map[string]any{"model": "gpt-4o"}))
+	g.AddNodeWithOptions("save", "Write the answer", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"saved": true}, nil
+	}, WithTags("io", "tool"))
+	g.AddNode("plain", "No labels", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{}, nil
+	})
+	g.SetEntryPoint("call_model")
+	g.AddEdge("call_model", "save")
+	g.AddEdge("save", "plain")
+	g.AddEdge("plain", END)
+	return g
+}
+
+func TestNodeTags(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Callbacks", func(t *testing.T) {
+		runnable, err := newTaggedGraph().Compile()
+		require.NoError(t, err)
+
+		recorder := &toolCallRecorder{
+			serialized: map[string]map[string]any{},
+			tags:       map[string][]string{},
+			metadata:   map[string]map[string]any{},
+		}
+		config := &Config{
+			Callbacks: []CallbackHandler{recorder},
+			Tags:      []string{"run"},
+			Metadata:  map[string]any{"user": "ada", "model": "default"},
+		}
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, config)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"run", "llm"}, recorder.tags["call_model"])
+		assert.Equal(t, map[string]any{"user": "ada", "model": "gpt-4o"}, recorder.metadata["call_model"])
+		assert.Equal(t, []string{"llm"}, recorder.serialized["call_model"]["tags"])
+		assert.Equal(t, []string{"run", "io", "tool"}, recorder.tags["save"])
+		assert.Equal(t, []string{"run"}, recorder.tags["plain"])
+		assert.NotContains(t, recorder.serialized["plain"], "tags")
+		assert.Equal(t, map[string]any{"user": "ada", "model": "default"}, config.Metadata, "config metadata is not modified")
+	})
+
+	t.Run("Listeners", func(t *testing.T) {
+		g := newTaggedGraph()
+		var mu sync.Mutex
+		byTag := map[string][]string{}
+		g.AddGlobalListener(NodeListenerFunc[map[string]any](func(ctx context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {
+			if event != NodeEventComplete {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, tag := range NodeTagsFromContext(ctx) {
+				byTag[tag] = append(byTag[tag], nodeName)
+			}
+		}))
+
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+		_, err = runnable.Invoke(ctx, map[string]any{})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string][]string{"llm": {"call_model"}, "io": {"save"}, "tool": {"save"}}, byTag)
+		assert.Equal(t, []string{"io", "tool"}, g.GetListenableNode("save").Tags)
+	})
+
+	t.Run("Exporter", func(t *testing.T) {
+		exporter := NewExporter(newTaggedGraph().StateGraph)
+
+		mermaid := exporter.DrawMermaid()
+		assert.Contains(t, mermaid, "    class save io\n    class call_model llm\n    class save tool\n")
+
+		dot := exporter.DrawDOT()
+		assert.Contains(t, dot, `call_model [label="call_model", tooltip="Ask the model\ntags: llm\nmodel: gpt-4o", class="llm", style=filled, fillcolor=lightblue];`)
+		assert.Contains(t, dot, `save [label="save", tooltip="Write the answer\ntags: io, tool", class="io tool"];`)
+		assert.Contains(t, dot, `plain [label="plain", tooltip="No labels"];`)
+	})
+}
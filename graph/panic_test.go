@@ -0,0 +1,106 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodePanicRecovery(t *testing.T) {
+	t.Run("Error", func(t *testing.T) {
+		g := NewStateGraph[map[string]any]()
+		g.AddNode("parse", "parse", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			_ = state["metadata"].(map[string]any)
+			return state, nil
+		})
+		g.SetEntryPoint("parse")
+		g.AddEdge("parse", END)
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.ErrorIs(t, err, ErrNodePanic)
+		var panicErr *NodePanicError
+		require.ErrorAs(t, err, &panicErr)
+		assert.Equal(t, "parse", panicErr.Node)
+		assert.Contains(t, string(panicErr.Stack), "panic_test.go")
+		var typeErr interface{ RuntimeError() }
+		assert.ErrorAs(t, err, &typeErr, "the runtime error is unwrapped")
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		attempts := 0
+		g := NewStateGraph[int]()
+		g.AddNode("flaky", "flaky", func(ctx context.Context, state int) (int, error) {
+			attempts++
+			if attempts == 1 {
+				panic("first attempt")
+			}
+			return state + 1, nil
+		})
+		g.SetNodeRetryPolicy("flaky", NodeRetryPolicy{
+			MaxAttempts: 2,
+			RetryIf:     func(err error) bool { return errors.Is(err, ErrNodePanic) },
+		})
+		g.SetEntryPoint("flaky")
+		g.AddEdge("flaky", END)
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, res)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("ErrorEdge", func(t *testing.T) {
+		g := NewListenableStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		node := g.AddNode("crash", "crash", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			panic(errors.New("boom"))
+		})
+		g.AddNode("recover", "recover", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			nodeErr, _ := NodeErrorFromContext(ctx)
+			return map[string]any{"recovered": errors.Is(nodeErr, ErrNodePanic)}, nil
+		})
+		g.SetEntryPoint("crash")
+		g.AddEdge("crash", END)
+		g.AddErrorEdge("crash", "recover")
+		g.AddEdge("recover", END)
+
+		var events []NodeEvent
+		node.AddListener(NodeListenerFunc[map[string]any](func(ctx context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {
+			events = append(events, event)
+		}))
+
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, true, res["recovered"])
+		assert.Equal(t, []NodeEvent{NodeEventStart, NodeEventError}, events)
+	})
+
+	t.Run("Propagation", func(t *testing.T) {
+		g := NewStateGraph[int]()
+		g.AddNode("crash", "crash", func(ctx context.Context, state int) (int, error) {
+			panic("boom")
+		})
+		g.SetEntryPoint("crash")
+		g.AddEdge("crash", END)
+
+		runnable, err := g.Compile(WithPanicPropagation())
+		require.NoError(t, err)
+
+		// Running the node directly, the panic reaches the caller
+		assert.PanicsWithValue(t, "boom", func() {
+			_, _ = runnable.runNode(context.Background(), runnable.graph.nodes["crash"], 0)
+		})
+	})
+}
@@ -2,7 +2,11 @@ package graph
 
 import (
 	"context"
+	"encoding/json"
 	"maps"
+	"math/rand/v2"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -125,3 +129,125 @@ func TestParallelExecution_FanOut(t *testing.T) {
 	assert.True(t, hasB, "Node B should be visited")
 	assert.True(t, hasC, "Node C should be visited")
 }
+
+func TestParallelExecution_DeterministicMergeOrder(t *testing.T) {
+	g := NewStateGraph[map[string]any]()
+	schema := NewMapSchema()
+	schema.RegisterReducer("messages", AppendReducer)
+	schema.RegisterReducer("last_writer", OverwriteReducer)
+	g.SetSchema(schema)
+
+	branches := []string{"worker_c", "worker_a", "worker_d", "worker_b", "worker_e"}
+
+	g.AddNode("start", "start", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"messages": []string{"start"}}, nil
+	})
+	for _, name := range branches {
+		g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			// Finish in a random order
+			time.Sleep(time.Duration(rand.IntN(500)) * time.Microsecond)
+			return map[string]any{
+				"messages":    []string{name + ":1", name + ":2"},
+				"last_writer": name,
+			}, nil
+		})
+		g.AddEdge("start", name)
+		g.AddEdge(name, "join")
+	}
+	g.AddNode("join", "join", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"messages": []string{"join"}}, nil
+	})
+	g.AddEdge("join", END)
+	g.SetEntryPoint("start")
+
+	runnable, err := g.Compile()
+	assert.NoError(t, err)
+
+	var first []byte
+	for i := 0; i < 100; i++ {
+		res, err := runnable.Invoke(context.Background(), map[string]any{})
+		if !assert.NoError(t, err) {
+			return
+		}
+		got, err := json.Marshal(res)
+		assert.NoError(t, err)
+
+		if first == nil {
+			first = got
+			// Updates are merged in lexical order of node name, not declaration order
+			assert.Equal(t, []string{
+				"start",
+				"worker_a:1", "worker_a:2",
+				"worker_b:1", "worker_b:2",
+				"worker_c:1", "worker_c:2",
+				"worker_d:1", "worker_d:2",
+				"worker_e:1", "worker_e:2",
+				"join",
+			}, res["messages"])
+			assert.Equal(t, "worker_e", res["last_writer"])
+			continue
+		}
+		if string(got) != string(first) {
+			t.Fatalf("run %d produced a different final state:\n%s\nwant:\n%s", i, got, first)
+		}
+	}
+}
+
+func TestParallelExecution_EdgePriority(t *testing.T) {
+	var mutex sync.Mutex
+	newGraph := func(started *[]string) *StateGraph[map[string]any] {
+		g := NewStateGraph[map[string]any]()
+		schema := NewMapSchema()
+		schema.RegisterReducer("source", OverwriteReducer)
+		g.SetSchema(schema)
+
+		g.AddNode("plan", "plan", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, nil
+		})
+		for _, name := range []string{"fetch_cache", "fetch_live", "fetch_archive"} {
+			g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				mutex.Lock()
+				*started = append(*started, name)
+				mutex.Unlock()
+				time.Sleep(time.Duration(rand.IntN(500)) * time.Microsecond)
+				return map[string]any{"source": name}, nil
+			})
+			g.AddEdge(name, END)
+		}
+		g.SetEntryPoint("plan")
+		g.AddEdgeWithPriority("plan", "fetch_cache", 10)
+		g.AddEdge("plan", "fetch_live")
+		g.AddEdgeWithPriority("plan", "fetch_archive", 20)
+		return g
+	}
+
+	var started []string
+	runnable, err := newGraph(&started).Compile()
+	assert.NoError(t, err)
+	for range 20 {
+		res, err := runnable.Invoke(context.Background(), map[string]any{})
+		assert.NoError(t, err)
+		// Merged archive, cache, then live, so the live data wins
+		assert.Equal(t, "fetch_live", res["source"])
+	}
+
+	// One branch at a time, nodes also run in priority order
+	started = nil
+	runnable, err = newGraph(&started).Compile(WithParallelBranches(1))
+	assert.NoError(t, err)
+	_, err = runnable.Invoke(context.Background(), map[string]any{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fetch_archive", "fetch_cache", "fetch_live"}, started)
+
+	// Without priorities, nodes are merged in name order
+	g := newGraph(&started)
+	g.edges = slices.DeleteFunc(g.edges, func(edge Edge) bool { return edge.From == "plan" })
+	for _, name := range []string{"fetch_cache", "fetch_live", "fetch_archive"} {
+		g.AddEdge("plan", name)
+	}
+	runnable, err = g.Compile()
+	assert.NoError(t, err)
+	res, err := runnable.Invoke(context.Background(), map[string]any{})
+	assert.NoError(t, err)
+	assert.Equal(t, "fetch_live", res["source"])
+}
@@ -0,0 +1,136 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+type completedWritesKey struct{}
+
+// withCompletedWrites passes the saved results of the nodes of a resumed step to
+// the run started on ctx, which reuses them instead of running those nodes
+func withCompletedWrites[S any](ctx context.Context, completed map[string]S) context.Context {
+	return context.WithValue(ctx, completedWritesKey{}, completed)
+}
+
+// takeCompletedWrites returns the results withCompletedWrites passed on ctx, and
+// a context without them, so the graphs the nodes of the run invoke don't see them
+func takeCompletedWrites[S any](ctx context.Context) (context.Context, map[string]S) {
+	completed, ok := ctx.Value(completedWritesKey{}).(map[string]S)
+	if !ok {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, completedWritesKey{}, nil), completed
+}
+
+// stepWrites tracks the checkpoint the current step of a run started from, which
+// the pending writes of its nodes are keyed to
+type stepWrites struct {
+	mu           sync.Mutex
+	checkpointID string
+}
+
+// swap makes checkpointID the key of the writes of the next step, and returns
+// the key of the previous one
+func (w *stepWrites) swap(checkpointID string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	previous := w.checkpointID
+	w.checkpointID = checkpointID
+	return previous
+}
+
+func (w *stepWrites) current() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.checkpointID
+}
+
+// OnNodeWrite saves the result of a node of a parallel step as a pending write of
+// the checkpoint the step started from, when SaveWrites is set. The first step
+// of a run that didn't resume from a checkpoint has none to key its writes to,
+// unless SavePending saved one.
+func (cl *CheckpointListener[S]) OnNodeWrite(ctx context.Context, nodeName string, result any) {
+	if !cl.autoSave || !cl.saveWrites || cl.step == nil {
+		return
+	}
+	state, ok := result.(S)
+	if !ok {
+		return
+	}
+	checkpointID := cl.step.current()
+	if checkpointID == "" {
+		return
+	}
+
+	write := &store.PendingWrite{
+		CheckpointID: checkpointID,
+		Node:         nodeName,
+		Value:        cl.clone(state),
+		Timestamp:    time.Now(),
+	}
+	// The node has completed, so persist its result even if the run is being
+	// cancelled, or has returned by the time the queued write is saved
+	save := func() error {
+		if err := store.SaveWrite(context.WithoutCancel(ctx), cl.store, write); err != nil {
+			return fmt.Errorf("failed to save pending write of node %s: %w", nodeName, err)
+		}
+		return nil
+	}
+
+	if cl.writer == nil {
+		if err := save(); err != nil {
+			log.Printf("warning: %v", err)
+		}
+		return
+	}
+	cl.writer.enqueue(checkpointWrite{
+		checkpointID: checkpointID,
+		nodeName:     nodeName,
+		save:         save,
+		batch:        cl.batch,
+		dropped:      func() { cl.notifyDropped(ctx, nodeName, write.Value, checkpointID) },
+	})
+}
+
+// deleteSupersededWrites removes the pending writes of the step before the one
+// checkpoint was saved for, whose results checkpoint includes
+func (cl *CheckpointListener[S]) deleteSupersededWrites(ctx context.Context, superseded string) {
+	if !cl.saveWrites || superseded == "" {
+		return
+	}
+	if err := store.DeleteWrites(ctx, cl.store, superseded); err != nil {
+		log.Printf("warning: failed to delete the pending writes of checkpoint %s: %v", superseded, err)
+	}
+}
+
+// resumeWrites prepares a run resuming the step of checkpoint with listener: the
+// nodes of the step with a pending write reuse its result instead of running
+// again, and the nodes that run key their writes to checkpoint too. Writes whose
+// value doesn't have the state type S are ignored, so their nodes run again.
+func (cr *CheckpointableRunnable[S]) resumeWrites(ctx context.Context, listener *CheckpointListener[S], checkpoint *store.Checkpoint) (context.Context, error) {
+	if !cr.config.SaveWrites {
+		return ctx, nil
+	}
+	listener.step.swap(checkpoint.ID)
+
+	writes, err := store.ListWrites(ctx, cr.config.Store, checkpoint.ID)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to load the pending writes of checkpoint %s: %w", checkpoint.ID, err)
+	}
+	completed := make(map[string]S, len(writes))
+	for _, write := range writes {
+		if state, ok := write.Value.(S); ok {
+			completed[write.Node] = state
+		}
+	}
+	if len(completed) == 0 {
+		return ctx, nil
+	}
+	return withCompletedWrites(ctx, completed), nil
+}
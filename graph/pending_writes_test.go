@@ -0,0 +1,117 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFanOutGraph builds a graph start -> a, b, c, d -> END whose branch c fails
+// on its first run, and counts the runs of each branch
+func newFanOutGraph(t *testing.T, config graph.CheckpointConfig) (*graph.CheckpointableRunnable[map[string]any], map[string]int) {
+	t.Helper()
+	var mu sync.Mutex
+	runs := make(map[string]int)
+
+	g := graph.NewCheckpointableStateGraph[map[string]any]()
+	g.SetSchema(graph.NewMapSchema())
+	g.AddNode("start", "start", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{"started": true}, nil
+	})
+	for _, name := range []string{"a", "b", "c", "d"} {
+		g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			mu.Lock()
+			runs[name]++
+			failed := name == "c" && runs[name] == 1
+			mu.Unlock()
+			if failed {
+				return nil, errors.New("branch c failed")
+			}
+			return map[string]any{name: "done"}, nil
+		})
+		g.AddEdge("start", name)
+		g.AddEdge(name, graph.END)
+	}
+	g.SetEntryPoint("start")
+	g.SetCheckpointConfig(config)
+	runnable, err := g.CompileCheckpointable()
+	require.NoError(t, err)
+	return runnable, runs
+}
+
+func TestPendingWrites(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("ResumeLatest", func(t *testing.T) {
+		t.Parallel()
+		checkpoints := graph.NewMemoryCheckpointStore()
+		runnable, runs := newFanOutGraph(t, graph.CheckpointConfig{Store: checkpoints, AutoSave: true, SaveWrites: true})
+
+		_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread"))
+		require.ErrorContains(t, err, "branch c failed")
+
+		// The branches that completed saved their results for the checkpoint of start
+		latest, err := checkpoints.GetLatestByThread(ctx, "thread")
+		require.NoError(t, err)
+		assert.Equal(t, "start", latest.NodeName)
+		writes, err := store.ListWrites(ctx, checkpoints, latest.ID)
+		require.NoError(t, err)
+		var nodes []string
+		for _, write := range writes {
+			nodes = append(nodes, write.Node)
+		}
+		assert.Equal(t, []string{"a", "b", "d"}, nodes)
+
+		// Resuming runs only the failed branch and merges the saved results
+		result, err := runnable.ResumeLatest(ctx, "thread")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"started": true, "a": "done", "b": "done", "c": "done", "d": "done"}, result)
+		assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 2, "d": 1}, runs)
+
+		// The completed step supersedes the writes
+		writes, err = store.ListWrites(ctx, checkpoints, latest.ID)
+		require.NoError(t, err)
+		assert.Empty(t, writes)
+	})
+
+	t.Run("PendingCheckpointInFileStore", func(t *testing.T) {
+		t.Parallel()
+		checkpoints, err := graph.NewFileCheckpointStore(t.TempDir())
+		require.NoError(t, err)
+		runnable, runs := newFanOutGraph(t, graph.CheckpointConfig{
+			Store:       checkpoints,
+			AutoSave:    true,
+			SavePending: true,
+			SaveWrites:  true,
+			Serializer:  graph.JSONSerializer,
+		})
+
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread"))
+		require.ErrorContains(t, err, "branch c failed")
+
+		// Auto-resume re-runs the pending step, reusing the saved results
+		result, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread"))
+		require.NoError(t, err)
+		assert.Equal(t, "done", result["c"])
+		assert.Equal(t, "done", result["a"])
+		assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 2, "d": 1}, runs)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		runnable, runs := newFanOutGraph(t, graph.CheckpointConfig{Store: graph.NewMemoryCheckpointStore(), AutoSave: true})
+
+		_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, graph.WithThreadID("thread"))
+		require.Error(t, err)
+		_, err = runnable.ResumeLatest(ctx, "thread")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"a": 2, "b": 2, "c": 2, "d": 2}, runs)
+	})
+}
@@ -0,0 +1,271 @@
+package graph
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sync"
+	"time"
+)
+
+// DefaultMaxRecordedStateSize is the size of the JSON encoding of a state above
+// which a RecordingListener doesn't keep its snapshot
+const DefaultMaxRecordedStateSize = 64 << 10
+
+// RecordedEvent is a node event captured by a RecordingListener, with a snapshot
+// of the state taken when the event occurred
+type RecordedEvent struct {
+	Timestamp time.Time      `json:"timestamp"`
+	NodeName  string         `json:"node"`
+	Event     NodeEvent      `json:"event"`
+	StepIndex int            `json:"step,omitempty"`
+	Duration  time.Duration  `json:"duration,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+
+	// State is the JSON encoding of the state, which is a copy unaffected by the
+	// later changes of the run. It is empty when the state was Truncated.
+	State json.RawMessage `json:"state,omitempty"`
+
+	// StateSize is the size of the JSON encoding of the state
+	StateSize int `json:"state_size"`
+
+	// Truncated reports that the state was larger than the size cap of the
+	// listener, or couldn't be encoded, and wasn't kept
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DecodeState returns the state of the event decoded from its snapshot, nil when
+// it was truncated
+func (e *RecordedEvent) DecodeState() (map[string]any, error) {
+	if len(e.State) == 0 {
+		return nil, nil
+	}
+	var state map[string]any
+	if err := json.Unmarshal(e.State, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode the state of %s %s: %w", e.NodeName, e.Event, err)
+	}
+	return state, nil
+}
+
+// RecordingListener records every node event it receives, so a run can be
+// inspected and replayed after the fact. Events are kept in memory, or written
+// as JSON lines to a writer set with WithWriter, such as a file, and read back
+// with LoadRecording.
+//
+// Example:
+//
+//	recorder := graph.NewRecordingListener().WithWriter(file)
+//	g.AddGlobalListener(recorder)
+//	...
+//	recording, _ := graph.LoadRecording(file)
+//	recording.Replay(os.Stdout, 10)
+type RecordingListener struct {
+	mutex        sync.Mutex
+	events       []RecordedEvent
+	maxStateSize int
+	encoder      *json.Encoder
+	err          error
+}
+
+// NewRecordingListener creates a listener recording events in memory, with the
+// states of at most DefaultMaxRecordedStateSize bytes
+func NewRecordingListener() *RecordingListener {
+	return &RecordingListener{maxStateSize: DefaultMaxRecordedStateSize}
+}
+
+// WithMaxStateSize sets the size of the JSON encoding of a state above which its
+// snapshot is replaced by the truncation marker. Zero or less keeps every state.
+func (rl *RecordingListener) WithMaxStateSize(size int) *RecordingListener {
+	rl.maxStateSize = size
+	return rl
+}
+
+// WithWriter writes the events to w as JSON lines instead of keeping them in
+// memory. The first write error is returned by Err, and stops the recording.
+func (rl *RecordingListener) WithWriter(w io.Writer) *RecordingListener {
+	rl.encoder = json.NewEncoder(w)
+	return rl
+}
+
+// OnEvent implements the EventListener interface
+func (rl *RecordingListener) OnEvent(_ context.Context, event *StreamEvent[map[string]any]) {
+	recorded := RecordedEvent{
+		Timestamp: event.Timestamp,
+		NodeName:  event.NodeName,
+		Event:     event.Event,
+		StepIndex: event.StepIndex,
+		Duration:  event.Duration,
+	}
+	if event.Error != nil {
+		recorded.Error = event.Error.Error()
+	}
+	if len(event.Metadata) > 0 {
+		recorded.Metadata = maps.Clone(event.Metadata)
+	}
+	if data, err := json.Marshal(event.State); err != nil {
+		recorded.Truncated = true
+	} else {
+		recorded.StateSize = len(data)
+		if rl.maxStateSize > 0 && len(data) > rl.maxStateSize {
+			recorded.Truncated = true
+		} else {
+			recorded.State = data
+		}
+	}
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if rl.encoder == nil {
+		rl.events = append(rl.events, recorded)
+		return
+	}
+	if rl.err == nil {
+		if err := rl.encoder.Encode(recorded); err != nil {
+			rl.err = fmt.Errorf("failed to write recorded event: %w", err)
+		}
+	}
+}
+
+// OnNodeEvent implements the NodeListener[map[string]any] interface
+func (rl *RecordingListener) OnNodeEvent(ctx context.Context, event NodeEvent, nodeName string, state map[string]any, err error) {
+	rl.OnEvent(ctx, newStreamEvent(ctx, event, nodeName, state, err))
+}
+
+// Events returns a copy of the events recorded in memory
+func (rl *RecordingListener) Events() []RecordedEvent {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	return append([]RecordedEvent(nil), rl.events...)
+}
+
+// Err returns the error that stopped writing the events, if any
+func (rl *RecordingListener) Err() error {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	return rl.err
+}
+
+// LoadRecording reads the events a RecordingListener wrote with WithWriter into
+// a listener holding them in memory
+func LoadRecording(r io.Reader) (*RecordingListener, error) {
+	rl := NewRecordingListener()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event RecordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("failed to read recorded event on line %d: %w", line, err)
+		}
+		rl.events = append(rl.events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return rl, nil
+}
+
+// Replay prints the recorded events to w like a WriterListener, waiting between
+// them for the time that separated them divided by speed: 1 replays the run in
+// real time, 10 ten times faster, and zero or less prints them at once.
+func (rl *RecordingListener) Replay(w io.Writer, speed float64) error {
+	printer := NewWriterListener(w, WriterListenerOptions{})
+	var current time.Time
+	printer.now = func() time.Time { return current }
+	return rl.replay(context.Background(), speed, func(ctx context.Context, event *StreamEvent[map[string]any]) {
+		current = event.Timestamp
+		printer.OnNodeEvent(ctx, event.Event, event.NodeName, event.State, event.Error)
+	})
+}
+
+// ReplayTo re-emits the recorded events to listener, at the pace of Replay. The
+// states are decoded from their snapshots, and are nil when they were truncated.
+func (rl *RecordingListener) ReplayTo(ctx context.Context, listener NodeListener[map[string]any], speed float64) error {
+	return rl.replay(ctx, speed, func(ctx context.Context, event *StreamEvent[map[string]any]) {
+		deliver(ctx, listener, event)
+	})
+}
+
+// replay passes the recorded events to emit, with the context the listeners of
+// the run received them with
+func (rl *RecordingListener) replay(ctx context.Context, speed float64, emit func(context.Context, *StreamEvent[map[string]any])) error {
+	var previous time.Time
+	for _, recorded := range rl.Events() {
+		if speed > 0 && !previous.IsZero() {
+			if delay := time.Duration(float64(recorded.Timestamp.Sub(previous)) / speed); delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		previous = recorded.Timestamp
+
+		state, err := recorded.DecodeState()
+		if err != nil {
+			return err
+		}
+		event := &StreamEvent[map[string]any]{
+			Timestamp: recorded.Timestamp,
+			NodeName:  recorded.NodeName,
+			Event:     recorded.Event,
+			State:     state,
+			Metadata:  maps.Clone(recorded.Metadata),
+			Duration:  recorded.Duration,
+			StepIndex: recorded.StepIndex,
+		}
+		if event.Metadata == nil {
+			event.Metadata = make(map[string]any)
+		}
+		if recorded.Error != "" {
+			event.Error = errors.New(recorded.Error)
+		}
+		emit(recordedEventContext(ctx, event), event)
+	}
+	return nil
+}
+
+// recordedEventContext returns ctx with the token or custom event of event, for
+// the listeners reading them with TokenFromContext and CustomEventFromContext
+func recordedEventContext(ctx context.Context, event *StreamEvent[map[string]any]) context.Context {
+	switch event.Event {
+	case EventToken:
+		if chunk, ok := event.Metadata["token"].(string); ok {
+			return context.WithValue(ctx, tokenKey{}, chunk)
+		}
+	case EventCustom:
+		name, _ := event.Metadata["name"].(string)
+		return context.WithValue(ctx, customEventKey{}, CustomEvent{Name: name, Payload: event.Metadata["payload"]})
+	}
+	return ctx
+}
+
+// DiffRecordedStates returns the differences between the states of two recorded
+// events, like DiffCheckpoints does for checkpoints. A truncated state compares
+// as empty.
+func DiffRecordedStates(from, to RecordedEvent) ([]StateChange, error) {
+	before, err := from.DecodeState()
+	if err != nil {
+		return nil, err
+	}
+	after, err := to.DecodeState()
+	if err != nil {
+		return nil, err
+	}
+	var changes []StateChange
+	diffValues(&changes, "", before, after)
+	return changes, nil
+}
+
+var (
+	_ NodeListener[map[string]any]  = (*RecordingListener)(nil)
+	_ EventListener[map[string]any] = (*RecordingListener)(nil)
+)
@@ -0,0 +1,114 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRecordedGraph(notes []string) *graph.ListenableStateGraph[map[string]any] {
+	g := graph.NewListenableStateGraph[map[string]any]()
+	g.AddNode("write", "write", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		graph.EmitToken(ctx, "draft")
+		return map[string]any{"notes": notes, "draft": strings.Repeat("x", 200)}, nil
+	})
+	g.AddEdge("write", graph.END)
+	g.SetEntryPoint("write")
+	return g
+}
+
+func TestRecordingListener(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Snapshots", func(t *testing.T) {
+		t.Parallel()
+		notes := []string{"first"}
+		g := newRecordedGraph(notes)
+		recorder := graph.NewRecordingListener()
+		g.AddGlobalListener(recorder)
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{"topic": "go"})
+		require.NoError(t, err)
+		notes[0] = "changed after the run"
+
+		events := recorder.Events()
+		require.Len(t, events, 3)
+		assert.Equal(t, []graph.NodeEvent{graph.NodeEventStart, graph.EventToken, graph.NodeEventComplete},
+			[]graph.NodeEvent{events[0].Event, events[1].Event, events[2].Event})
+		assert.Equal(t, 1, events[2].StepIndex)
+		assert.Equal(t, "draft", events[1].Metadata["token"])
+
+		state, err := events[2].DecodeState()
+		require.NoError(t, err)
+		assert.Equal(t, []any{"first"}, state["notes"])
+
+		changes, err := graph.DiffRecordedStates(events[0], events[2])
+		require.NoError(t, err)
+		var paths []string
+		for _, change := range changes {
+			paths = append(paths, change.Path+":"+string(change.Kind))
+		}
+		assert.Equal(t, []string{"draft:added", "notes:added", "topic:removed"}, paths)
+	})
+
+	t.Run("Truncation", func(t *testing.T) {
+		t.Parallel()
+		g := newRecordedGraph(nil)
+		recorder := graph.NewRecordingListener().WithMaxStateSize(100)
+		g.AddGlobalListener(recorder)
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+
+		events := recorder.Events()
+		require.Len(t, events, 3)
+		assert.False(t, events[0].Truncated)
+		assert.True(t, events[2].Truncated)
+		assert.Greater(t, events[2].StateSize, 100)
+		assert.Empty(t, events[2].State)
+		state, err := events[2].DecodeState()
+		require.NoError(t, err)
+		assert.Nil(t, state)
+	})
+
+	t.Run("FileAndReplay", func(t *testing.T) {
+		t.Parallel()
+		g := newRecordedGraph([]string{"a"})
+		var log bytes.Buffer
+		recorder := graph.NewRecordingListener().WithWriter(&log)
+		g.AddGlobalListener(recorder)
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		require.NoError(t, recorder.Err())
+		assert.Empty(t, recorder.Events())
+		assert.Equal(t, 3, strings.Count(log.String(), "\n"))
+
+		recording, err := graph.LoadRecording(&log)
+		require.NoError(t, err)
+		require.Len(t, recording.Events(), 3)
+
+		var printed bytes.Buffer
+		require.NoError(t, recording.Replay(&printed, 0))
+		lines := strings.Split(strings.TrimSpace(printed.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Contains(t, lines[0], "▶ write started")
+		assert.Contains(t, lines[1], "💬 write: draft")
+		assert.Contains(t, lines[2], "✔ write completed")
+
+		replayed := &eventRecorder{}
+		require.NoError(t, recording.ReplayTo(context.Background(), replayed, 1000))
+		assert.Equal(t, []string{"write:start", "write:token", "write:complete"}, replayed.events)
+	})
+}
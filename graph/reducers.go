@@ -0,0 +1,262 @@
+package graph
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"reflect"
+)
+
+// MergeMapReducer deep-merges map[string]any values. Keys in new overwrite keys in
+// current, except when both values are maps, which are merged recursively.
+// Neither input is modified.
+func MergeMapReducer(current, new any) (any, error) {
+	if new == nil {
+		return current, nil
+	}
+	newMap, ok := new.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("MergeMapReducer: new value is %T, expected map[string]any", new)
+	}
+	if current == nil {
+		return deepMergeMaps(nil, newMap), nil
+	}
+	currMap, ok := current.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("MergeMapReducer: current value is %T, expected map[string]any", current)
+	}
+	return deepMergeMaps(currMap, newMap), nil
+}
+
+func deepMergeMaps(current, new map[string]any) map[string]any {
+	result := make(map[string]any, len(current)+len(new))
+	maps.Copy(result, current)
+	for k, v := range new {
+		if nv, ok := v.(map[string]any); ok {
+			cv, _ := result[k].(map[string]any)
+			result[k] = deepMergeMaps(cv, nv)
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// SumReducer adds the new value to the current one. Both values must be integers
+// or floats. Values of the same type keep that type; mixing a float with an integer
+// yields float64, and mixing integer types yields int64 (or uint64 when both are unsigned).
+func SumReducer(current, new any) (any, error) {
+	if current == nil || new == nil {
+		return nonNilNumber("SumReducer", current, new)
+	}
+
+	cv, nv := reflect.ValueOf(current), reflect.ValueOf(new)
+	ck, nk := numberKind(cv), numberKind(nv)
+	if ck == notNumber || nk == notNumber {
+		return nil, fmt.Errorf("SumReducer: cannot add %T to %T", new, current)
+	}
+
+	if cv.Type() == nv.Type() {
+		result := reflect.New(cv.Type()).Elem()
+		switch ck {
+		case signedNumber:
+			result.SetInt(cv.Int() + nv.Int())
+		case unsignedNumber:
+			result.SetUint(cv.Uint() + nv.Uint())
+		case floatNumber:
+			result.SetFloat(cv.Float() + nv.Float())
+		}
+		return result.Interface(), nil
+	}
+
+	switch {
+	case ck == floatNumber || nk == floatNumber:
+		return toFloat64(cv) + toFloat64(nv), nil
+	case ck == unsignedNumber && nk == unsignedNumber:
+		return cv.Uint() + nv.Uint(), nil
+	default:
+		return toInt64(cv) + toInt64(nv), nil
+	}
+}
+
+// MaxReducer keeps the larger of the current and new values. Numbers of any type
+// are compared by value and strings lexicographically; the winning value is
+// returned unchanged.
+func MaxReducer(current, new any) (any, error) {
+	return compareReducer("MaxReducer", current, new, func(c int) bool { return c < 0 })
+}
+
+// MinReducer keeps the smaller of the current and new values. Numbers of any type
+// are compared by value and strings lexicographically; the winning value is
+// returned unchanged.
+func MinReducer(current, new any) (any, error) {
+	return compareReducer("MinReducer", current, new, func(c int) bool { return c > 0 })
+}
+
+// LastNReducer returns a reducer that appends like AppendReducer and keeps only the
+// last n elements, which bounds histories such as chat messages.
+//
+// Example:
+//
+//	schema.RegisterReducer("messages", graph.LastNReducer(20))
+func LastNReducer(n int) Reducer {
+	return func(current, new any) (any, error) {
+		if n <= 0 {
+			return nil, fmt.Errorf("LastNReducer: n must be positive, got %d", n)
+		}
+		merged, err := AppendReducer(current, new)
+		if err != nil {
+			return nil, fmt.Errorf("LastNReducer: %w", err)
+		}
+
+		v := reflect.ValueOf(merged)
+		if v.Len() <= n {
+			return merged, nil
+		}
+		// Copy so the dropped elements don't stay reachable through the backing array
+		result := reflect.MakeSlice(v.Type(), n, n)
+		reflect.Copy(result, v.Slice(v.Len()-n, v.Len()))
+		return result.Interface(), nil
+	}
+}
+
+// UniqueAppendReducer appends like AppendReducer but skips elements that are
+// already present, keeping the first occurrence of each value.
+func UniqueAppendReducer(current, new any) (any, error) {
+	merged, err := AppendReducer(current, new)
+	if err != nil {
+		return nil, fmt.Errorf("UniqueAppendReducer: %w", err)
+	}
+
+	v := reflect.ValueOf(merged)
+	result := reflect.MakeSlice(v.Type(), 0, v.Len())
+	seen := make(map[any]bool)
+	for i := 0; i < v.Len(); i++ {
+		// Value.Comparable checks the dynamic values of interfaces, which a struct
+		// whose interface fields hold slices or maps can't be a map key with
+		elem := v.Index(i)
+		if key := elem.Interface(); key != nil && elem.Comparable() {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		} else if containsDeepEqual(result, elem) {
+			continue
+		}
+		result = reflect.Append(result, elem)
+	}
+	return result.Interface(), nil
+}
+
+func containsDeepEqual(slice, elem reflect.Value) bool {
+	for i := 0; i < slice.Len(); i++ {
+		if reflect.DeepEqual(slice.Index(i).Interface(), elem.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+type numberClass int
+
+const (
+	notNumber numberClass = iota
+	signedNumber
+	unsignedNumber
+	floatNumber
+)
+
+func numberKind(v reflect.Value) numberClass {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return signedNumber
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return unsignedNumber
+	case reflect.Float32, reflect.Float64:
+		return floatNumber
+	default:
+		return notNumber
+	}
+}
+
+func toFloat64(v reflect.Value) float64 {
+	switch numberKind(v) {
+	case signedNumber:
+		return float64(v.Int())
+	case unsignedNumber:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func toInt64(v reflect.Value) int64 {
+	if numberKind(v) == unsignedNumber {
+		return int64(v.Uint())
+	}
+	return v.Int()
+}
+
+// nonNilNumber handles a nil operand: the other value is returned if it is a number
+func nonNilNumber(name string, current, new any) (any, error) {
+	value := new
+	if value == nil {
+		value = current
+	}
+	if value != nil && numberKind(reflect.ValueOf(value)) == notNumber {
+		return nil, fmt.Errorf("%s: %T is not a number", name, value)
+	}
+	return value, nil
+}
+
+// compareReducer returns new when replace(compare(current, new)) holds, current otherwise
+func compareReducer(name string, current, new any, replace func(int) bool) (any, error) {
+	if current == nil || new == nil {
+		value := new
+		if value == nil {
+			value = current
+		}
+		if _, ok := value.(string); ok || value == nil {
+			return value, nil
+		}
+		if numberKind(reflect.ValueOf(value)) == notNumber {
+			return nil, fmt.Errorf("%s: %T is neither a number nor a string", name, value)
+		}
+		return value, nil
+	}
+
+	c, err := compareValues(current, new)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if replace(c) {
+		return new, nil
+	}
+	return current, nil
+}
+
+// compareValues compares two numbers or two strings, returning -1, 0 or 1
+func compareValues(a, b any) (int, error) {
+	if as, ok := a.(string); ok {
+		bs, ok := b.(string)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+		}
+		return cmp.Compare(as, bs), nil
+	}
+
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	ak, bk := numberKind(av), numberKind(bv)
+	if ak == notNumber || bk == notNumber {
+		return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+	}
+
+	// Compare integers exactly when possible to avoid float rounding
+	if ak != floatNumber && bk != floatNumber && ak == bk {
+		if ak == signedNumber {
+			return cmp.Compare(av.Int(), bv.Int()), nil
+		}
+		return cmp.Compare(av.Uint(), bv.Uint()), nil
+	}
+	return cmp.Compare(toFloat64(av), toFloat64(bv)), nil
+}
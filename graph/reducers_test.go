@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reducerCase struct {
+	name    string
+	current any
+	new     any
+	want    any
+	wantErr string
+}
+
+func runReducerCases(t *testing.T, reducer Reducer, cases []reducerCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := reducer(tc.current, tc.new)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestMergeMapReducer(t *testing.T) {
+	runReducerCases(t, MergeMapReducer, []reducerCase{
+		{
+			name:    "nil current",
+			current: nil,
+			new:     map[string]any{"a": 1},
+			want:    map[string]any{"a": 1},
+		},
+		{
+			name:    "nil new",
+			current: map[string]any{"a": 1},
+			new:     nil,
+			want:    map[string]any{"a": 1},
+		},
+		{
+			name:    "deep merge",
+			current: map[string]any{"user": map[string]any{"name": "ada", "lang": "go"}, "n": 1},
+			new:     map[string]any{"user": map[string]any{"lang": "rust", "age": 36}, "m": 2},
+			want: map[string]any{
+				"user": map[string]any{"name": "ada", "lang": "rust", "age": 36},
+				"n":    1,
+				"m":    2,
+			},
+		},
+		{
+			name:    "non-map overwrites nested map",
+			current: map[string]any{"user": map[string]any{"name": "ada"}},
+			new:     map[string]any{"user": "anonymous"},
+			want:    map[string]any{"user": "anonymous"},
+		},
+		{
+			name:    "current not a map",
+			current: []int{1},
+			new:     map[string]any{"a": 1},
+			wantErr: "current value is []int",
+		},
+		{
+			name:    "new not a map",
+			current: map[string]any{},
+			new:     "a",
+			wantErr: "new value is string",
+		},
+	})
+
+	t.Run("does not mutate inputs", func(t *testing.T) {
+		current := map[string]any{"nested": map[string]any{"a": 1}}
+		_, err := MergeMapReducer(current, map[string]any{"nested": map[string]any{"b": 2}})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"nested": map[string]any{"a": 1}}, current)
+	})
+}
+
+func TestSumReducer(t *testing.T) {
+	runReducerCases(t, SumReducer, []reducerCase{
+		{name: "nil current", current: nil, new: 3, want: 3},
+		{name: "nil new", current: 3, new: nil, want: 3},
+		{name: "ints", current: 1, new: 2, want: 3},
+		{name: "floats", current: 1.5, new: 2.25, want: 3.75},
+		{name: "same named type", current: int32(1), new: int32(2), want: int32(3)},
+		{name: "int and float", current: 1, new: 0.5, want: 1.5},
+		{name: "mixed int types", current: 1, new: int64(2), want: int64(3)},
+		{name: "unsigned types", current: uint8(1), new: uint16(2), want: uint64(3)},
+		{name: "string", current: 1, new: "2", wantErr: "cannot add string to int"},
+		{name: "nil current with string", current: nil, new: "2", wantErr: "string is not a number"},
+	})
+}
+
+func TestMaxReducer(t *testing.T) {
+	runReducerCases(t, MaxReducer, []reducerCase{
+		{name: "nil current", current: nil, new: 3, want: 3},
+		{name: "nil new", current: 3, new: nil, want: 3},
+		{name: "new larger", current: 1, new: 2, want: 2},
+		{name: "current larger", current: 5, new: 2, want: 5},
+		{name: "mixed numbers", current: 2, new: 2.5, want: 2.5},
+		{name: "strings", current: "apple", new: "banana", want: "banana"},
+		{name: "string and number", current: "a", new: 1, wantErr: "cannot compare string with int"},
+		{name: "unsupported type", current: nil, new: []int{1}, wantErr: "neither a number nor a string"},
+	})
+}
+
+func TestMinReducer(t *testing.T) {
+	runReducerCases(t, MinReducer, []reducerCase{
+		{name: "nil current", current: nil, new: 3, want: 3},
+		{name: "new smaller", current: 2, new: 1, want: 1},
+		{name: "current smaller", current: 2, new: 5, want: 2},
+		{name: "mixed numbers", current: uint(3), new: -1, want: -1},
+		{name: "strings", current: "apple", new: "banana", want: "apple"},
+		{name: "bool", current: true, new: false, wantErr: "cannot compare bool with bool"},
+	})
+}
+
+func TestLastNReducer(t *testing.T) {
+	runReducerCases(t, LastNReducer(3), []reducerCase{
+		{name: "nil current", current: nil, new: []string{"a"}, want: []string{"a"}},
+		{name: "single element", current: []string{"a"}, new: "b", want: []string{"a", "b"}},
+		{name: "trims oldest", current: []string{"a", "b"}, new: []string{"c", "d"}, want: []string{"b", "c", "d"}},
+		{name: "mixed types", current: []string{"a", "b"}, new: []int{1, 2}, want: []any{"b", 1, 2}},
+		{name: "current not a slice", current: "a", new: "b", wantErr: "not a slice"},
+	})
+
+	_, err := LastNReducer(0)(nil, []int{1})
+	assert.ErrorContains(t, err, "n must be positive")
+}
+
+func TestUniqueAppendReducer(t *testing.T) {
+	runReducerCases(t, UniqueAppendReducer, []reducerCase{
+		{name: "nil current", current: nil, new: []string{"a", "a"}, want: []string{"a"}},
+		{name: "skips existing", current: []string{"a", "b"}, new: []string{"b", "c"}, want: []string{"a", "b", "c"}},
+		{name: "single element", current: []int{1}, new: 1, want: []int{1}},
+		{name: "mixed types", current: []any{1, "1"}, new: []any{"1", 2}, want: []any{1, "1", 2}},
+		{
+			name:    "uncomparable elements",
+			current: []any{map[string]any{"id": 1}},
+			new:     []any{map[string]any{"id": 1}, map[string]any{"id": 2}},
+			want:    []any{map[string]any{"id": 1}, map[string]any{"id": 2}},
+		},
+		{
+			name:    "interface fields holding slices",
+			current: []taggedValue{{Tag: "a", Value: []int{1}}},
+			new:     []taggedValue{{Tag: "a", Value: []int{1}}, {Tag: "b", Value: 2}, {Tag: "b", Value: 2}},
+			want:    []taggedValue{{Tag: "a", Value: []int{1}}, {Tag: "b", Value: 2}},
+		},
+		{name: "current not a slice", current: 1, new: 2, wantErr: "not a slice"},
+	})
+}
+
+// taggedValue is comparable as a type, but not when Value holds a slice
+type taggedValue struct {
+	Tag   string
+	Value any
+}
@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// lookPath finds renderer executables; it is a variable so tests can replace it
+var lookPath = exec.LookPath
+
+// RenderImage renders the graph as an image in the given format ("png" or "svg")
+// and writes it to w. It shells out to Graphviz (dot) when installed and falls back
+// to mermaid-cli (mmdc). If neither is found in PATH, a *RendererNotFoundError is
+// returned so callers can fall back to DrawMermaid or DrawDOT. Cancelling ctx
+// kills the renderer, and nothing is written to w unless rendering succeeds.
+//
+// Example:
+//
+//	var buf bytes.Buffer
+//	if err := graph.NewExporter(g).RenderImage(ctx, "svg", &buf); err != nil {
+//	    var notFound *graph.RendererNotFoundError
+//	    if errors.As(err, &notFound) {
+//	        buf.WriteString(exporter.DrawMermaid())
+//	    }
+//	}
+func (ge *Exporter[S]) RenderImage(ctx context.Context, format string, w io.Writer) error {
+	format = strings.ToLower(format)
+	if format != "png" && format != "svg" {
+		return fmt.Errorf("unsupported image format %q: expected png or svg", format)
+	}
+
+	if dot, err := lookPath("dot"); err == nil {
+		return renderDOT(ctx, dot, ge.DrawDOT(), format, w)
+	}
+	if mmdc, err := lookPath("mmdc"); err == nil {
+		return renderMermaid(ctx, mmdc, ge.DrawMermaid(), format, w)
+	}
+
+	return &RendererNotFoundError{Format: format, Renderers: []string{"dot", "mmdc"}}
+}
+
+// renderDOT pipes the DOT source through Graphviz, buffering the image so a
+// failed run writes nothing to w
+func renderDOT(ctx context.Context, dot string, source string, format string, w io.Writer) error {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, dot, "-T"+format)
+	cmd.Stdin = strings.NewReader(source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("dot failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	_, err := stdout.WriteTo(w)
+	return err
+}
+
+// renderMermaid renders the Mermaid source with mermaid-cli, which only works with files
+func renderMermaid(ctx context.Context, mmdc string, source string, format string, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "langgraphgo-render-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "graph.mmd")
+	output := filepath.Join(dir, "graph."+format)
+	if err := os.WriteFile(input, []byte(source), 0600); err != nil {
+		return fmt.Errorf("failed to write mermaid source: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, mmdc, "-i", input, "-o", output)
+	cmd.Stdout = io.Discard
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("mmdc failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		return fmt.Errorf("failed to open rendered image: %w", err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRenderGraph() *StateGraph[map[string]any] {
+	g := NewStateGraph[map[string]any]()
+	g.AddNode("A", "A", func(ctx context.Context, state map[string]any) (map[string]any, error) { return state, nil })
+	g.SetEntryPoint("A")
+	g.AddEdge("A", END)
+	return g
+}
+
+// withRenderers makes only the given fake renderer scripts visible to RenderImage
+func withRenderers(t *testing.T, scripts map[string]string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake renderers are shell scripts")
+	}
+
+	dir := t.TempDir()
+	for name, script := range scripts {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0700))
+	}
+
+	original := lookPath
+	lookPath = func(file string) (string, error) {
+		path := filepath.Join(dir, file)
+		if _, err := os.Stat(path); err != nil {
+			return "", exec.ErrNotFound
+		}
+		return path, nil
+	}
+	t.Cleanup(func() { lookPath = original })
+}
+
+func TestRenderImage(t *testing.T) {
+	t.Run("NoRenderer", func(t *testing.T) {
+		withRenderers(t, nil)
+
+		err := NewExporter(newRenderGraph()).RenderImage(context.Background(), "svg", &bytes.Buffer{})
+		var notFound *RendererNotFoundError
+		require.True(t, errors.As(err, &notFound), "expected RendererNotFoundError, got %v", err)
+		assert.Equal(t, "svg", notFound.Format)
+		assert.Equal(t, []string{"dot", "mmdc"}, notFound.Renderers)
+	})
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		err := NewExporter(newRenderGraph()).RenderImage(context.Background(), "gif", &bytes.Buffer{})
+		assert.ErrorContains(t, err, "unsupported image format")
+	})
+
+	t.Run("Graphviz", func(t *testing.T) {
+		// The fake dot echoes its format flag followed by the DOT source
+		withRenderers(t, map[string]string{"dot": `echo "$1"; cat`})
+
+		var buf bytes.Buffer
+		require.NoError(t, NewExporter(newRenderGraph()).RenderImage(context.Background(), "PNG", &buf))
+		assert.Contains(t, buf.String(), "-Tpng")
+		assert.Contains(t, buf.String(), "digraph G {")
+	})
+
+	t.Run("MermaidFallback", func(t *testing.T) {
+		// The fake mmdc copies its -i input to its -o output
+		withRenderers(t, map[string]string{"mmdc": `cp "$2" "$4"`})
+
+		var buf bytes.Buffer
+		require.NoError(t, NewExporter(newRenderGraph()).RenderImage(context.Background(), "svg", &buf))
+		assert.Contains(t, buf.String(), "flowchart TD")
+	})
+
+	t.Run("RendererFailure", func(t *testing.T) {
+		// The fake dot writes part of an image before failing
+		withRenderers(t, map[string]string{"dot": `echo "<svg"; echo "syntax error" >&2; exit 1`})
+
+		var buf bytes.Buffer
+		err := NewExporter(newRenderGraph()).RenderImage(context.Background(), "svg", &buf)
+		assert.ErrorContains(t, err, "syntax error")
+		assert.Empty(t, buf.String(), "a failed render writes nothing")
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		withRenderers(t, map[string]string{"dot": `exec sleep 10`})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := NewExporter(newRenderGraph()).RenderImage(ctx, "svg", &bytes.Buffer{})
+		assert.ErrorContains(t, err, "dot failed")
+		assert.Less(t, time.Since(start), 5*time.Second, "the hung renderer is killed")
+	})
+}
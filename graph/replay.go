@@ -0,0 +1,269 @@
+package graph
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+// ErrReplayMismatch is returned when replaying a node whose input state was not recorded.
+var ErrReplayMismatch = errors.New("no recording matches the node input")
+
+// ReplayMismatchPolicy decides what a replayer does with a node whose input state
+// doesn't match any recording.
+type ReplayMismatchPolicy int
+
+const (
+	// ReplayMismatchError fails the node with ErrReplayMismatch
+	ReplayMismatchError ReplayMismatchPolicy = iota
+
+	// ReplayMismatchLive runs the node function
+	ReplayMismatchLive
+)
+
+// recordingEvent is the "event" metadata of the checkpoints saved by a recorder
+const recordingEvent = "recording"
+
+// WithRecorder returns a copy of the runnable that records the result of every
+// successful node run into checkpoints, under the given fixture name, keyed by the
+// node and a hash of its input state. A runnable from WithReplayer can then replay
+// the recording, for example to run a graph with LLM nodes deterministically in
+// tests. Recordings are added to those already in the fixture; clear the fixture
+// with checkpoints.Clear to record it again. States must be JSON serializable.
+//
+// Example:
+//
+//	// Record once against the real model
+//	recorder := runnable.WithRecorder(fileStore, "refund-flow")
+//	_, err := recorder.Invoke(ctx, input)
+//
+//	// Replay in CI
+//	replayer := runnable.WithReplayer(fileStore, "refund-flow", graph.ReplayMismatchError)
+//	result, err := replayer.Invoke(ctx, input)
+func (r *StateRunnable[S]) WithRecorder(checkpoints store.CheckpointStore, fixture string) *StateRunnable[S] {
+	return r.withInnerMiddleware(newRecorder[S](checkpoints, fixture).middleware)
+}
+
+// WithReplayer returns a copy of the runnable that replays the node results recorded
+// with WithRecorder under the given fixture name: a node whose name and input state
+// match a recording returns the recorded result without running. onMismatch decides
+// what happens to other nodes. When a node and input were recorded several times,
+// the latest recording is used.
+func (r *StateRunnable[S]) WithReplayer(checkpoints store.CheckpointStore, fixture string, onMismatch ReplayMismatchPolicy) *StateRunnable[S] {
+	return r.withInnerMiddleware(newReplayer[S](checkpoints, fixture, onMismatch).middleware)
+}
+
+// withInnerMiddleware returns a copy of the runnable with m wrapping the node
+// functions inside the graph's middleware
+func (r *StateRunnable[S]) withInnerMiddleware(m Middleware[S]) *StateRunnable[S] {
+	copied := *r
+	copied.middleware = append(slices.Clone(r.middleware), m)
+	return &copied
+}
+
+// WithRecorder returns a copy of the runnable that records node results; see
+// StateRunnable.WithRecorder.
+func (lr *ListenableRunnable[S]) WithRecorder(checkpoints store.CheckpointStore, fixture string) *ListenableRunnable[S] {
+	return &ListenableRunnable[S]{
+		graph:           lr.graph,
+		listenableNodes: lr.listenableNodes,
+		runnable:        lr.runnable.WithRecorder(checkpoints, fixture),
+	}
+}
+
+// WithReplayer returns a copy of the runnable that replays recorded node results;
+// see StateRunnable.WithReplayer.
+func (lr *ListenableRunnable[S]) WithReplayer(checkpoints store.CheckpointStore, fixture string, onMismatch ReplayMismatchPolicy) *ListenableRunnable[S] {
+	return &ListenableRunnable[S]{
+		graph:           lr.graph,
+		listenableNodes: lr.listenableNodes,
+		runnable:        lr.runnable.WithReplayer(checkpoints, fixture, onMismatch),
+	}
+}
+
+// WithRecorder returns a copy of the runnable that records node results; see
+// StateRunnable.WithRecorder. The recordings are separate from the runnable's own
+// checkpoints, and should use a different store or fixture name than its threads.
+func (cr *CheckpointableRunnable[S]) WithRecorder(checkpoints store.CheckpointStore, fixture string) *CheckpointableRunnable[S] {
+	return &CheckpointableRunnable[S]{
+		runnable:    cr.runnable.WithRecorder(checkpoints, fixture),
+		config:      cr.config,
+		executionID: cr.executionID,
+		listener:    cr.listener,
+	}
+}
+
+// WithReplayer returns a copy of the runnable that replays recorded node results;
+// see StateRunnable.WithReplayer.
+func (cr *CheckpointableRunnable[S]) WithReplayer(checkpoints store.CheckpointStore, fixture string, onMismatch ReplayMismatchPolicy) *CheckpointableRunnable[S] {
+	return &CheckpointableRunnable[S]{
+		runnable:    cr.runnable.WithReplayer(checkpoints, fixture, onMismatch),
+		config:      cr.config,
+		executionID: cr.executionID,
+		listener:    cr.listener,
+	}
+}
+
+// recorder saves node results as checkpoints of a fixture
+type recorder[S any] struct {
+	checkpoints store.CheckpointStore
+	fixture     string
+
+	mutex   sync.Mutex
+	version int
+	loaded  bool
+}
+
+func newRecorder[S any](checkpoints store.CheckpointStore, fixture string) *recorder[S] {
+	return &recorder[S]{checkpoints: checkpoints, fixture: fixture}
+}
+
+func (rec *recorder[S]) middleware(next NodeFunc[S]) NodeFunc[S] {
+	return func(ctx context.Context, state S) (S, error) {
+		node, _ := NodeNameFromContext(ctx)
+		// Hash the input first, since the node may modify it in place
+		hash, err := replayHash(node, state)
+		if err != nil {
+			var zero S
+			return zero, err
+		}
+
+		result, err := next(ctx, state)
+		if err != nil {
+			return result, err
+		}
+
+		if err := rec.save(ctx, node, hash, result); err != nil {
+			return result, fmt.Errorf("failed to record node %s: %w", node, err)
+		}
+		return result, nil
+	}
+}
+
+func (rec *recorder[S]) save(ctx context.Context, node, hash string, result S) error {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	// Versions continue after the recordings already in the fixture
+	if !rec.loaded {
+		existing, err := rec.checkpoints.ListByThread(ctx, rec.fixture)
+		if err != nil {
+			return err
+		}
+		for _, cp := range existing {
+			rec.version = max(rec.version, cp.Version)
+		}
+		rec.loaded = true
+	}
+	rec.version++
+
+	return rec.checkpoints.Save(ctx, &store.Checkpoint{
+		ID:        generateCheckpointID(),
+		NodeName:  node,
+		State:     deepCopyValue(reflect.ValueOf(&result).Elem()).Interface(),
+		Timestamp: time.Now(),
+		Version:   rec.version,
+		Metadata: map[string]any{
+			"execution_id": rec.fixture,
+			"thread_id":    rec.fixture,
+			"event":        recordingEvent,
+			"input_hash":   hash,
+		},
+	})
+}
+
+// replayer answers node runs from the checkpoints of a fixture
+type replayer[S any] struct {
+	checkpoints store.CheckpointStore
+	fixture     string
+	onMismatch  ReplayMismatchPolicy
+
+	load       sync.Once
+	recordings map[string]*store.Checkpoint
+	loadErr    error
+}
+
+func newReplayer[S any](checkpoints store.CheckpointStore, fixture string, onMismatch ReplayMismatchPolicy) *replayer[S] {
+	return &replayer[S]{checkpoints: checkpoints, fixture: fixture, onMismatch: onMismatch}
+}
+
+func (rep *replayer[S]) middleware(next NodeFunc[S]) NodeFunc[S] {
+	return func(ctx context.Context, state S) (S, error) {
+		var zero S
+		rep.load.Do(func() { rep.loadErr = rep.loadRecordings(ctx) })
+		if rep.loadErr != nil {
+			return zero, fmt.Errorf("failed to load fixture %s: %w", rep.fixture, rep.loadErr)
+		}
+
+		node, _ := NodeNameFromContext(ctx)
+		hash, err := replayHash(node, state)
+		if err != nil {
+			return zero, err
+		}
+
+		cp, ok := rep.recordings[node+":"+hash]
+		if !ok {
+			if rep.onMismatch == ReplayMismatchLive {
+				return next(ctx, state)
+			}
+			return zero, fmt.Errorf("%w: node %s in fixture %s", ErrReplayMismatch, node, rep.fixture)
+		}
+		return recordedState[S](cp.State)
+	}
+}
+
+func (rep *replayer[S]) loadRecordings(ctx context.Context) error {
+	checkpoints, err := rep.checkpoints.ListByThread(ctx, rep.fixture)
+	if err != nil {
+		return err
+	}
+
+	// Checkpoints are sorted by version, so later recordings replace earlier ones
+	rep.recordings = make(map[string]*store.Checkpoint)
+	for _, cp := range checkpoints {
+		if event, _ := cp.Metadata["event"].(string); event != recordingEvent {
+			continue
+		}
+		hash, _ := cp.Metadata["input_hash"].(string)
+		rep.recordings[cp.NodeName+":"+hash] = cp
+	}
+	return nil
+}
+
+// recordedState converts a recorded state to S. Stores that keep values in memory
+// return it as is, and stores that serialize it return JSON-decoded values.
+func recordedState[S any](value any) (S, error) {
+	if state, ok := value.(S); ok {
+		return deepCopyValue(reflect.ValueOf(&state).Elem()).Interface().(S), nil
+	}
+
+	var state S
+	data, err := json.Marshal(value)
+	if err != nil {
+		return state, fmt.Errorf("failed to decode recorded state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to decode recorded state: %w", err)
+	}
+	return state, nil
+}
+
+// replayHash identifies the input of a node run by the node name and a hash of
+// the JSON encoding of its state
+func replayHash(node string, state any) (string, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash the input of node %s: %w", node, err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
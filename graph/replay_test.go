@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type replayState struct {
+	Question string
+	Answer   string
+	Summary  string
+}
+
+func TestRecordReplay(t *testing.T) {
+	calls := 0
+	g := NewStateGraph[replayState]()
+	g.AddNode("llm", "llm", func(ctx context.Context, state replayState) (replayState, error) {
+		calls++
+		state.Answer = fmt.Sprintf("answer %d to %s", calls, state.Question)
+		return state, nil
+	})
+	g.AddNode("summarize", "summarize", func(ctx context.Context, state replayState) (replayState, error) {
+		state.Summary = "summary of " + state.Answer
+		return state, nil
+	})
+	g.SetEntryPoint("llm")
+	g.AddEdge("llm", "summarize")
+	g.AddEdge("summarize", END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	stores := map[string]func(t *testing.T) store.CheckpointStore{
+		"Memory": func(t *testing.T) store.CheckpointStore { return NewMemoryCheckpointStore() },
+		"File": func(t *testing.T) store.CheckpointStore {
+			st, err := file.NewFileCheckpointStore(t.TempDir())
+			require.NoError(t, err)
+			return st
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			calls = 0
+			fixtures := newStore(t)
+
+			recorded, err := runnable.WithRecorder(fixtures, "qa").Invoke(context.Background(), replayState{Question: "why"})
+			require.NoError(t, err)
+			assert.Equal(t, "summary of answer 1 to why", recorded.Summary)
+			assert.Equal(t, 1, calls)
+
+			replayer := runnable.WithReplayer(fixtures, "qa", ReplayMismatchError)
+			replayed, err := replayer.Invoke(context.Background(), replayState{Question: "why"})
+			require.NoError(t, err)
+			assert.Equal(t, recorded, replayed)
+			assert.Equal(t, 1, calls, "recorded nodes don't run")
+
+			_, err = replayer.Invoke(context.Background(), replayState{Question: "how"})
+			assert.ErrorIs(t, err, ErrReplayMismatch)
+			assert.ErrorContains(t, err, "node llm in fixture qa")
+
+			live, err := runnable.WithReplayer(fixtures, "qa", ReplayMismatchLive).Invoke(context.Background(), replayState{Question: "how"})
+			require.NoError(t, err)
+			assert.Equal(t, "summary of answer 2 to how", live.Summary)
+			assert.Equal(t, 2, calls)
+
+			plain, err := runnable.Invoke(context.Background(), replayState{Question: "why"})
+			require.NoError(t, err)
+			assert.Equal(t, "answer 3 to why", plain.Answer, "the original runnable is unchanged")
+		})
+	}
+}
+
+func TestRecordReplayMapState(t *testing.T) {
+	g := NewStateGraph[map[string]any]()
+	g.SetSchema(NewMapSchema())
+	g.AddNode("tags", "tags", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		// Modifying the input in place must not change the recorded hash or result
+		state["tags"] = []any{"a", "b"}
+		return state, nil
+	})
+	g.SetEntryPoint("tags")
+	g.AddEdge("tags", END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	fixtures := NewMemoryCheckpointStore()
+
+	_, err = runnable.WithRecorder(fixtures, "tags").Invoke(context.Background(), map[string]any{"id": 1})
+	require.NoError(t, err)
+
+	replayed, err := runnable.WithReplayer(fixtures, "tags", ReplayMismatchError).Invoke(context.Background(), map[string]any{"id": 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": 1, "tags": []any{"a", "b"}}, replayed)
+}
@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+// ResumeOption configures ResumeLatest.
+type ResumeOption func(*resumeOptions)
+
+// resumeOptions collects the settings applied by ResumeOption values.
+type resumeOptions struct {
+	config *Config
+	update any
+	value  any
+	nodes  []string
+}
+
+// WithResumeConfig sets the config of the resumed run, such as its callbacks and
+// breakpoints. Its thread_id is replaced by the thread being resumed.
+func WithResumeConfig(config *Config) ResumeOption {
+	return func(o *resumeOptions) {
+		o.config = config
+	}
+}
+
+// WithResumeUpdate merges update, which must have the graph's state type, into the
+// checkpointed state before resuming, like Command.Update.
+func WithResumeUpdate(update any) ResumeOption {
+	return func(o *resumeOptions) {
+		o.update = update
+	}
+}
+
+// WithResumeAnswer sets the value returned by the Interrupt() call that paused the
+// thread, like Command.Resume.
+func WithResumeAnswer(value any) ResumeOption {
+	return func(o *resumeOptions) {
+		o.value = value
+	}
+}
+
+// WithResumeNodes resumes at nodes instead of the nodes the checkpoint leads to.
+func WithResumeNodes(nodes ...string) ResumeOption {
+	return func(o *resumeOptions) {
+		o.nodes = nodes
+	}
+}
+
+// ResumeLatest continues threadID from its latest checkpoint and returns the final
+// state. It runs the node that raised an interrupt, or re-runs the nodes of a
+// pending checkpoint (see CheckpointConfig.SavePending) on the state they
+// received, otherwise the nodes the checkpoint recorded as scheduled after its
+// step. Checkpoints saved without
+// that record continue with the nodes the graph's edges lead to from the
+// checkpointed node, evaluated on the checkpointed state. A thread whose latest
+// checkpoint is at END returns its state without running any node.
+//
+// It returns an error wrapping ErrNodeNotFound when a node to resume at, or the
+// checkpointed node it is derived from, is no longer in the graph.
+//
+// Example:
+//
+//	// After a restart, finish the thread that was interrupted
+//	result, err := runnable.ResumeLatest(ctx, "thread-1")
+//
+//	// Answer the Interrupt() that paused it
+//	result, err = runnable.ResumeLatest(ctx, "thread-1", graph.WithResumeAnswer("approved"))
+func (cr *CheckpointableRunnable[S]) ResumeLatest(ctx context.Context, threadID string, opts ...ResumeOption) (S, error) {
+	var zero S
+	options := &resumeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if threadID == "" {
+		return zero, fmt.Errorf("resuming requires a thread_id")
+	}
+
+	namespace, nested := runNamespace(ctx, options.config)
+	latestCP, err := cr.getLatestCheckpoint(ctx, threadID, namespace)
+	if err != nil {
+		return zero, fmt.Errorf("failed to load checkpoint for thread %s: %w", threadID, err)
+	}
+	if latestCP == nil {
+		return zero, fmt.Errorf("no checkpoints found for thread %s", threadID)
+	}
+	if err := cr.checkFingerprint(threadID, latestCP); err != nil {
+		return zero, err
+	}
+
+	state, err := cr.checkpointState(latestCP)
+	if err != nil {
+		return zero, err
+	}
+	if options.update != nil {
+		update, ok := options.update.(S)
+		if !ok {
+			return zero, fmt.Errorf("resume update has type %T, expected %T", options.update, zero)
+		}
+		state = cr.mergeStates(ctx, state, update)
+	}
+
+	resumeFrom, err := cr.resumeNodes(ctx, latestCP, state, options.nodes)
+	if err != nil {
+		return zero, fmt.Errorf("cannot resume thread %s: %w", threadID, err)
+	}
+	if len(resumeFrom) == 0 {
+		// The thread has finished
+		return state, nil
+	}
+
+	var resumeConfig Config
+	if options.config != nil {
+		resumeConfig = *options.config
+	}
+	if nested {
+		nestedRunConfig(ctx, &resumeConfig, options.config)
+	}
+	resumeConfig.Configurable = maps.Clone(resumeConfig.Configurable)
+	if resumeConfig.Configurable == nil {
+		resumeConfig.Configurable = make(map[string]any)
+	}
+	resumeConfig.Configurable["thread_id"] = threadID
+	resumeConfig.ResumeFrom = resumeFrom
+	resumeConfig.ResumeValue = options.value
+	if options.nodes == nil {
+		resumeInterrupt(&resumeConfig, latestCP)
+	}
+	listener := cr.runListener(threadID, namespace)
+	resumeConfig.Callbacks = append(slices.Clone(resumeConfig.Callbacks), listener)
+
+	// Nodes chosen with WithResumeNodes may not be the step the writes were saved for
+	if options.nodes == nil {
+		if ctx, err = cr.resumeWrites(ctx, listener, latestCP); err != nil {
+			return zero, err
+		}
+	}
+
+	ctx = withCheckpointNamespace(ctx, namespace)
+	return cr.invoke(withCheckpointEntryNodes(ctx, latestCP), listener, state, &resumeConfig, nested)
+}
+
+// resumeNodes returns the nodes to resume checkpoint at: override if set, the
+// nodes the checkpoint recorded, or those the edges of its node lead to
+func (cr *CheckpointableRunnable[S]) resumeNodes(ctx context.Context, checkpoint *store.Checkpoint, state S, override []string) ([]string, error) {
+	nodes := override
+	if nodes == nil {
+		recorded, ok := recordedResumeNodes(checkpoint)
+		switch {
+		case ok:
+			nodes = recorded
+		case checkpoint.NodeName == "" || checkpoint.NodeName == END:
+			return nil, nil
+		default:
+			if _, exists := cr.runnable.graph.nodes[checkpoint.NodeName]; !exists {
+				return nil, fmt.Errorf("%w: checkpointed node %q", ErrNodeNotFound, checkpoint.NodeName)
+			}
+			next, err := cr.runnable.runnable.determineNextNodes(ctx, []string{checkpoint.NodeName}, state, nil)
+			if err != nil {
+				return nil, err
+			}
+			nodes = slices.DeleteFunc(next, func(n string) bool { return n == END })
+		}
+	}
+
+	for _, node := range nodes {
+		if _, exists := cr.runnable.graph.nodes[node]; !exists {
+			return nil, fmt.Errorf("%w: %q to resume at", ErrNodeNotFound, node)
+		}
+	}
+	return nodes, nil
+}
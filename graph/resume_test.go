@@ -88,3 +88,142 @@ func TestGraphResume(t *testing.T) {
 		assert.Equal(t, "StartABC", res2["value"])
 	})
 }
+
+func TestResumeLatest(t *testing.T) {
+	ctx := context.Background()
+
+	newGraph := func(checkpoints CheckpointStore) *CheckpointableStateGraph[map[string]any] {
+		g := NewCheckpointableStateGraphWithConfig[map[string]any](CheckpointConfig{Store: checkpoints, AutoSave: true})
+		g.SetSchema(NewMapSchema())
+		for _, name := range []string{"A", "B", "C", "D"} {
+			g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				path, _ := state["path"].(string)
+				return map[string]any{"path": path + name}, nil
+			})
+		}
+		g.SetEntryPoint("A")
+		g.AddEdge("A", "B")
+		g.AddConditionalEdge("B", func(ctx context.Context, state map[string]any) string {
+			if state["skip"] == true {
+				return "D"
+			}
+			return "C"
+		})
+		g.AddEdge("C", "D")
+		g.AddEdge("D", END)
+		return g
+	}
+
+	// save stores a checkpoint that, like those of older versions, records no next nodes
+	save := func(t *testing.T, checkpoints CheckpointStore, threadID, node string, state map[string]any) {
+		t.Helper()
+		assert.NoError(t, checkpoints.Save(ctx, &Checkpoint{
+			ID:       threadID + "-" + node,
+			NodeName: node,
+			State:    state,
+			Metadata: map[string]any{"thread_id": threadID},
+			Version:  1,
+		}))
+	}
+
+	t.Run("AfterBreakpoint", func(t *testing.T) {
+		runnable, err := newGraph(NewMemoryCheckpointStore()).CompileCheckpointable(WithInterruptAfterNodes("B"))
+		assert.NoError(t, err)
+
+		var interrupt *GraphInterrupt
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("t1"))
+		assert.ErrorAs(t, err, &interrupt)
+
+		res, err := runnable.ResumeLatest(ctx, "t1")
+		assert.NoError(t, err)
+		assert.Equal(t, "ABCD", res["path"])
+	})
+
+	t.Run("FollowsEdgesOfCheckpointedNode", func(t *testing.T) {
+		checkpoints := NewMemoryCheckpointStore()
+		runnable, err := newGraph(checkpoints).CompileCheckpointable()
+		assert.NoError(t, err)
+
+		save(t, checkpoints, "static", "A", map[string]any{"path": "A"})
+		res, err := runnable.ResumeLatest(ctx, "static")
+		assert.NoError(t, err)
+		assert.Equal(t, "ABCD", res["path"])
+
+		save(t, checkpoints, "conditional", "B", map[string]any{"path": "AB", "skip": true})
+		res, err = runnable.ResumeLatest(ctx, "conditional")
+		assert.NoError(t, err)
+		assert.Equal(t, "ABD", res["path"])
+	})
+
+	t.Run("Finished", func(t *testing.T) {
+		checkpoints := NewMemoryCheckpointStore()
+		runnable, err := newGraph(checkpoints).CompileCheckpointable()
+		assert.NoError(t, err)
+
+		save(t, checkpoints, "done", "D", map[string]any{"path": "ABCD"})
+		res, err := runnable.ResumeLatest(ctx, "done")
+		assert.NoError(t, err)
+		assert.Equal(t, "ABCD", res["path"])
+	})
+
+	t.Run("NodeNoLongerInGraph", func(t *testing.T) {
+		checkpoints := NewMemoryCheckpointStore()
+		runnable, err := newGraph(checkpoints).CompileCheckpointable()
+		assert.NoError(t, err)
+
+		save(t, checkpoints, "renamed", "review", map[string]any{"path": "A"})
+		_, err = runnable.ResumeLatest(ctx, "renamed")
+		assert.ErrorIs(t, err, ErrNodeNotFound)
+		assert.ErrorContains(t, err, `"review"`)
+
+		_, err = runnable.ResumeLatest(ctx, "missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		checkpoints := NewMemoryCheckpointStore()
+		runnable, err := newGraph(checkpoints).CompileCheckpointable()
+		assert.NoError(t, err)
+
+		save(t, checkpoints, "t2", "A", map[string]any{"path": "A"})
+		res, err := runnable.ResumeLatest(ctx, "t2", WithResumeUpdate(map[string]any{"skip": true}))
+		assert.NoError(t, err)
+		assert.Equal(t, "ABD", res["path"])
+
+		save(t, checkpoints, "t3", "A", map[string]any{"path": "A"})
+		res, err = runnable.ResumeLatest(ctx, "t3", WithResumeNodes("D"))
+		assert.NoError(t, err)
+		assert.Equal(t, "AD", res["path"])
+
+		_, err = runnable.ResumeLatest(ctx, "t3", WithResumeNodes("E"))
+		assert.ErrorIs(t, err, ErrNodeNotFound)
+
+		_, err = runnable.ResumeLatest(ctx, "t3", WithResumeUpdate("not a map"))
+		assert.ErrorContains(t, err, "resume update has type string")
+	})
+
+	t.Run("Answer", func(t *testing.T) {
+		g := NewCheckpointableStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		g.AddNode("ask", "ask", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			answer, err := Interrupt(ctx, "Proceed?")
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"answer": answer}, nil
+		})
+		g.SetEntryPoint("ask")
+		g.AddEdge("ask", END)
+
+		runnable, err := g.CompileCheckpointable()
+		assert.NoError(t, err)
+
+		var interrupt *GraphInterrupt
+		_, err = runnable.InvokeWithConfig(ctx, map[string]any{}, WithThreadID("t4"))
+		assert.ErrorAs(t, err, &interrupt)
+
+		res, err := runnable.ResumeLatest(ctx, "t4", WithResumeAnswer("yes"))
+		assert.NoError(t, err)
+		assert.Equal(t, "yes", res["answer"])
+	})
+}
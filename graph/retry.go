@@ -31,6 +31,51 @@ func DefaultRetryConfig() *RetryConfig {
 	}
 }
 
+// NodeRetryPolicy configures how the runnable retries a single node when its function fails.
+type NodeRetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+
+	// Backoff returns the delay to wait after the given failed attempt (1-based).
+	// A nil Backoff retries immediately.
+	Backoff func(attempt int) time.Duration
+
+	// RetryIf reports whether err should trigger a retry. A nil RetryIf retries all errors.
+	RetryIf func(err error) bool
+}
+
+// WithRetry returns a NodeOption that retries the node according to policy.
+func WithRetry(policy NodeRetryPolicy) NodeOption {
+	return func(o *nodeOptions) {
+		o.retryPolicy = &policy
+	}
+}
+
+// FixedDelay returns a backoff function that waits the same delay before every retry.
+func FixedDelay(delay time.Duration) func(attempt int) time.Duration {
+	return func(_ int) time.Duration {
+		return delay
+	}
+}
+
+// ExponentialDelay returns a backoff function that doubles the delay after each failed
+// attempt, starting at initial. If maxDelay is positive the delay is capped at maxDelay.
+func ExponentialDelay(initial, maxDelay time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := initial
+		for i := 1; i < attempt; i++ {
+			delay *= 2
+			if maxDelay > 0 && delay >= maxDelay {
+				return maxDelay
+			}
+		}
+		if maxDelay > 0 && delay > maxDelay {
+			return maxDelay
+		}
+		return delay
+	}
+}
+
 // RetryNode wraps a node with retry logic
 type RetryNode[S any] struct {
 	node   TypedNode[S]
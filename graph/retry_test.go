@@ -3,6 +3,7 @@ package graph_test
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -558,3 +559,167 @@ func TestExponentialBackoffRetry(t *testing.T) {
 		}
 	})
 }
+
+type retryRecorder struct {
+	graph.NoOpCallbackHandler
+	mu       sync.Mutex
+	attempts []int
+}
+
+func (r *retryRecorder) OnNodeRetry(_ context.Context, _ string, attempt int, _ error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, attempt)
+}
+
+func TestNodeRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	errRateLimited := errors.New("rate limited")
+
+	t.Run("RetriesUntilSuccess", func(t *testing.T) {
+		g := graph.NewListenableStateGraph[map[string]any]()
+		callCount := int32(0)
+
+		g.AddNodeWithOptions("llm", "llm", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			if atomic.AddInt32(&callCount, 1) < 3 {
+				return nil, errRateLimited
+			}
+			return map[string]any{"value": successResult}, nil
+		}, graph.WithRetry(graph.NodeRetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     graph.FixedDelay(time.Millisecond),
+		}))
+		g.AddEdge("llm", graph.END)
+		g.SetEntryPoint("llm")
+
+		metrics := graph.NewMetricsListener()
+		g.AddGlobalListener(metrics)
+
+		runnable, err := g.CompileListenable()
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+
+		recorder := &retryRecorder{}
+		result, err := runnable.InvokeWithConfig(context.Background(), map[string]any{}, &graph.Config{
+			Callbacks: []graph.CallbackHandler{recorder},
+		})
+		if err != nil {
+			t.Fatalf("Execution failed: %v", err)
+		}
+		if result["value"] != successResult {
+			t.Errorf("Expected success, got %v", result)
+		}
+		if got := atomic.LoadInt32(&callCount); got != 3 {
+			t.Errorf("Expected 3 calls, got %d", got)
+		}
+		if got := metrics.GetNodeRetries()["llm"]; got != 2 {
+			t.Errorf("Expected 2 retry events, got %d", got)
+		}
+		if len(recorder.attempts) != 2 || recorder.attempts[0] != 1 || recorder.attempts[1] != 2 {
+			t.Errorf("Expected retry callbacks for attempts [1 2], got %v", recorder.attempts)
+		}
+	})
+
+	t.Run("RetryIfFiltersErrors", func(t *testing.T) {
+		g := graph.NewStateGraph[map[string]any]()
+		callCount := int32(0)
+		errFatal := errors.New("invalid request")
+
+		g.AddNodeWithOptions("llm", "llm", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			atomic.AddInt32(&callCount, 1)
+			return nil, errFatal
+		}, graph.WithRetry(graph.NodeRetryPolicy{
+			MaxAttempts: 5,
+			RetryIf: func(err error) bool {
+				return errors.Is(err, errRateLimited)
+			},
+		}))
+		g.AddEdge("llm", graph.END)
+		g.SetEntryPoint("llm")
+
+		runnable, err := g.Compile()
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		if !errors.Is(err, errFatal) {
+			t.Errorf("Expected fatal error, got %v", err)
+		}
+		if got := atomic.LoadInt32(&callCount); got != 1 {
+			t.Errorf("Expected 1 call for non-retryable error, got %d", got)
+		}
+	})
+
+	t.Run("GivesUpAfterMaxAttempts", func(t *testing.T) {
+		g := graph.NewStateGraph[map[string]any]()
+		callCount := int32(0)
+
+		g.AddNode("llm", "llm", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			atomic.AddInt32(&callCount, 1)
+			return nil, errRateLimited
+		})
+		g.SetNodeRetryPolicy("llm", graph.NodeRetryPolicy{MaxAttempts: 4})
+		g.AddEdge("llm", graph.END)
+		g.SetEntryPoint("llm")
+
+		runnable, err := g.Compile()
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		if !errors.Is(err, errRateLimited) {
+			t.Errorf("Expected rate limit error, got %v", err)
+		}
+		if got := atomic.LoadInt32(&callCount); got != 4 {
+			t.Errorf("Expected 4 calls, got %d", got)
+		}
+	})
+
+	t.Run("BackoffRespectsContext", func(t *testing.T) {
+		g := graph.NewStateGraph[map[string]any]()
+
+		g.AddNodeWithOptions("llm", "llm", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, errRateLimited
+		}, graph.WithRetry(graph.NodeRetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     graph.FixedDelay(time.Second),
+		}))
+		g.AddEdge("llm", graph.END)
+		g.SetEntryPoint("llm")
+
+		runnable, err := g.Compile()
+		if err != nil {
+			t.Fatalf("Failed to compile: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err = runnable.Invoke(ctx, map[string]any{})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context deadline exceeded, got %v", err)
+		}
+	})
+}
+
+func TestExponentialDelay(t *testing.T) {
+	t.Parallel()
+
+	backoff := graph.ExponentialDelay(10*time.Millisecond, 50*time.Millisecond)
+	expected := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		50 * time.Millisecond,
+		50 * time.Millisecond,
+	}
+	for i, want := range expected {
+		if got := backoff(i + 1); got != want {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+}
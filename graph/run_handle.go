@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+)
+
+// RunHandle tracks a graph invocation started with InvokeAsync.
+type RunHandle[S any] struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	result S
+	err    error
+}
+
+// startRun runs invoke in a goroutine with a cancellable context derived from ctx
+func startRun[S any](ctx context.Context, invoke func(ctx context.Context) (S, error)) *RunHandle[S] {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &RunHandle[S]{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		defer func() {
+			if p := recover(); p != nil {
+				h.err = fmt.Errorf("panic in async run: %v", p)
+			}
+		}()
+		h.result, h.err = invoke(ctx)
+	}()
+	return h
+}
+
+// Done returns a channel that is closed when the run has finished.
+func (h *RunHandle[S]) Done() <-chan struct{} {
+	return h.done
+}
+
+// Result waits for the run to finish and returns its final state and error.
+// A cancelled run returns an error wrapping context.Canceled.
+func (h *RunHandle[S]) Result() (S, error) {
+	<-h.done
+	return h.result, h.err
+}
+
+// Cancel cancels the context of the run, so nodes and LLM calls that observe it
+// abort. It does not wait for the run to finish; use Done or Result for that.
+// Cancelling a finished run has no effect.
+func (h *RunHandle[S]) Cancel() {
+	h.cancel()
+}
+
+// InvokeAsync starts the graph in a new goroutine and returns a handle to wait for
+// or cancel the run.
+//
+// Example:
+//
+//	handle := runnable.InvokeAsync(ctx, state)
+//	select {
+//	case <-handle.Done():
+//	case <-time.After(time.Minute):
+//	    handle.Cancel()
+//	}
+//	result, err := handle.Result()
+func (r *StateRunnable[S]) InvokeAsync(ctx context.Context, initialState S) *RunHandle[S] {
+	return r.InvokeAsyncWithConfig(ctx, initialState, nil)
+}
+
+// InvokeAsyncWithConfig is InvokeAsync with a config.
+func (r *StateRunnable[S]) InvokeAsyncWithConfig(ctx context.Context, initialState S, config *Config) *RunHandle[S] {
+	return startRun(ctx, func(ctx context.Context) (S, error) {
+		return r.InvokeWithConfig(ctx, initialState, config)
+	})
+}
+
+// InvokeAsync starts the graph in a new goroutine and returns a handle to wait for
+// or cancel the run.
+func (lr *ListenableRunnable[S]) InvokeAsync(ctx context.Context, initialState S) *RunHandle[S] {
+	return lr.InvokeAsyncWithConfig(ctx, initialState, nil)
+}
+
+// InvokeAsyncWithConfig is InvokeAsync with a config.
+func (lr *ListenableRunnable[S]) InvokeAsyncWithConfig(ctx context.Context, initialState S, config *Config) *RunHandle[S] {
+	return startRun(ctx, func(ctx context.Context) (S, error) {
+		return lr.InvokeWithConfig(ctx, initialState, config)
+	})
+}
+
+// InvokeAsync starts the graph in a new goroutine and returns a handle to wait for
+// or cancel the run. Checkpoints of the steps that completed before a Cancel are
+// kept, so a cancelled run can be resumed from its thread.
+func (cr *CheckpointableRunnable[S]) InvokeAsync(ctx context.Context, initialState S) *RunHandle[S] {
+	return cr.InvokeAsyncWithConfig(ctx, initialState, nil)
+}
+
+// InvokeAsyncWithConfig is InvokeAsync with a config.
+func (cr *CheckpointableRunnable[S]) InvokeAsyncWithConfig(ctx context.Context, initialState S, config *Config) *RunHandle[S] {
+	return startRun(ctx, func(ctx context.Context) (S, error) {
+		return cr.InvokeWithConfig(ctx, initialState, config)
+	})
+}
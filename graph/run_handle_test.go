@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contextCheckingStore rejects saves with a done context, like network-backed stores
+type contextCheckingStore struct {
+	store.CheckpointStore
+}
+
+func (s *contextCheckingStore) Save(ctx context.Context, checkpoint *store.Checkpoint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.CheckpointStore.Save(ctx, checkpoint)
+}
+
+func TestInvokeAsync(t *testing.T) {
+	ctx := context.Background()
+
+	newGraph := func(second func(ctx context.Context, state map[string]any) (map[string]any, error)) *StateGraph[map[string]any] {
+		g := NewStateGraph[map[string]any]()
+		g.SetSchema(NewMapSchema())
+		g.AddNode("first", "first", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"first": true}, nil
+		})
+		g.AddNode("second", "second", second)
+		g.SetEntryPoint("first")
+		g.AddEdge("first", "second")
+		g.AddEdge("second", END)
+		return g
+	}
+
+	t.Run("Result", func(t *testing.T) {
+		runnable, err := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"second": true}, nil
+		}).Compile()
+		require.NoError(t, err)
+
+		handle := runnable.InvokeAsync(ctx, map[string]any{})
+		<-handle.Done()
+		res, err := handle.Result()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"first": true, "second": true}, res)
+
+		// Cancelling a finished run keeps its result
+		handle.Cancel()
+		res, err = handle.Result()
+		require.NoError(t, err)
+		assert.Equal(t, true, res["second"])
+	})
+
+	t.Run("CancelAbortsNode", func(t *testing.T) {
+		started := make(chan struct{})
+		runnable, err := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).Compile()
+		require.NoError(t, err)
+
+		handle := runnable.InvokeAsync(ctx, map[string]any{})
+		<-started
+		handle.Cancel()
+
+		select {
+		case <-handle.Done():
+		case <-time.After(time.Second):
+			t.Fatal("run did not stop after Cancel")
+		}
+		_, err = handle.Result()
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("CheckpointKeptOnCancel", func(t *testing.T) {
+		checkpoints := &contextCheckingStore{CheckpointStore: NewMemoryCheckpointStore()}
+		g := NewCheckpointableStateGraphWithConfig[map[string]any](CheckpointConfig{Store: checkpoints, AutoSave: true})
+		g.SetSchema(NewMapSchema())
+
+		var handle *RunHandle[map[string]any]
+		ready := make(chan struct{})
+		g.AddNode("first", "first", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			// Cancel before the step is checkpointed
+			<-ready
+			handle.Cancel()
+			return map[string]any{"first": true}, nil
+		})
+		g.AddNode("second", "second", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return nil, ctx.Err()
+		})
+		g.SetEntryPoint("first")
+		g.AddEdge("first", "second")
+		g.AddEdge("second", END)
+
+		runnable, err := g.CompileCheckpointable()
+		require.NoError(t, err)
+
+		handle = runnable.InvokeAsyncWithConfig(ctx, map[string]any{}, WithThreadID("cancelled"))
+		close(ready)
+		_, err = handle.Result()
+		assert.ErrorIs(t, err, context.Canceled)
+
+		latest, err := checkpoints.GetLatestByThread(ctx, "cancelled")
+		require.NoError(t, err)
+		assert.Equal(t, "first", latest.NodeName)
+		assert.Equal(t, []string{"second"}, latest.Metadata["next_nodes"])
+		assert.Equal(t, true, latest.State.(map[string]any)["first"])
+	})
+
+	t.Run("CancelRacesCompletion", func(t *testing.T) {
+		runnable, err := newGraph(func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return map[string]any{"second": true}, nil
+		}).Compile()
+		require.NoError(t, err)
+
+		for range 50 {
+			handle := runnable.InvokeAsync(ctx, map[string]any{})
+			var wg sync.WaitGroup
+			for range 4 {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					handle.Cancel()
+				}()
+				go func() {
+					defer wg.Done()
+					res, err := handle.Result()
+					if err == nil {
+						assert.Equal(t, true, res["second"])
+					}
+				}()
+			}
+			wg.Wait()
+			<-handle.Done()
+		}
+	})
+}
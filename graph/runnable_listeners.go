@@ -0,0 +1,83 @@
+package graph
+
+import "context"
+
+// AddListener adds a listener notified of the events of all the nodes of the
+// runnable, and returns its ID. Unlike the listeners of a ListenableStateGraph,
+// it observes runnables compiled from a plain StateGraph too, such as the agents
+// of the prebuilt package. opts filter the events and set their delivery like
+// with AddListenerFiltered.
+//
+// The first call installs the listeners on the runnable, and must not run
+// concurrently with an invoke of it; until then, nodes run without any listener
+// overhead. Runnables returned by WithTracer share the listeners.
+//
+// Example:
+//
+//	agent, _ := prebuilt.CreateAgentMap(model, tools, 0)
+//	agent.AddListener(graph.NewLoggingListener())
+func (r *StateRunnable[S]) AddListener(listener NodeListener[S], opts ...ListenerOption) string {
+	return r.runnableListeners().addListener(newListenerWrapper(listener, newListenerOptions(opts)))
+}
+
+// RemoveListener removes a listener added with AddListener by ID
+func (r *StateRunnable[S]) RemoveListener(listenerID string) {
+	r.listenersMu.Lock()
+	listeners := r.listeners
+	r.listenersMu.Unlock()
+	if listeners != nil {
+		listeners.RemoveListener(listenerID)
+	}
+}
+
+// runnableListeners returns the listeners of the runnable, installing them around
+// its node runner and notifier on the first call
+func (r *StateRunnable[S]) runnableListeners() *ListenableNode[S] {
+	r.listenersMu.Lock()
+	defer r.listenersMu.Unlock()
+	if r.listeners != nil {
+		return r.listeners
+	}
+
+	listeners := NewListenableNode(TypedNode[S]{})
+	runner, notifier := r.nodeRunner, r.nodeNotifier
+	r.nodeRunner = func(ctx context.Context, nodeName string, state S, fn NodeFunc[S]) (S, error) {
+		observed := func(ctx context.Context, state S) (S, error) {
+			return listeners.executeAs(ctx, nodeName, state, fn)
+		}
+		if runner != nil {
+			return runner(ctx, nodeName, state, observed)
+		}
+		return observed(ctx, state)
+	}
+	r.nodeNotifier = func(ctx context.Context, event NodeEvent, nodeName string, state S, err error) {
+		if notifier != nil {
+			notifier(ctx, event, nodeName, state, err)
+		}
+		listeners.notify(ctx, event, nodeName, state, err)
+	}
+	r.listeners = listeners
+	return listeners
+}
+
+// AddListener adds a listener notified of the events of all the nodes of the
+// runnable, like StateRunnable.AddListener, and returns its ID
+func (lr *ListenableRunnable[S]) AddListener(listener NodeListener[S], opts ...ListenerOption) string {
+	return lr.runnable.AddListener(listener, opts...)
+}
+
+// RemoveListener removes a listener added with AddListener by ID
+func (lr *ListenableRunnable[S]) RemoveListener(listenerID string) {
+	lr.runnable.RemoveListener(listenerID)
+}
+
+// AddListener adds a listener notified of the events of all the nodes of the
+// runnable, like StateRunnable.AddListener, and returns its ID
+func (cr *CheckpointableRunnable[S]) AddListener(listener NodeListener[S], opts ...ListenerOption) string {
+	return cr.runnable.AddListener(listener, opts...)
+}
+
+// RemoveListener removes a listener added with AddListener by ID
+func (cr *CheckpointableRunnable[S]) RemoveListener(listenerID string) {
+	cr.runnable.RemoveListener(listenerID)
+}
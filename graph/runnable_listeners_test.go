@@ -0,0 +1,89 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateRunnableListeners(t *testing.T) {
+	t.Parallel()
+
+	newFlakyGraph := func() *graph.StateGraph[map[string]any] {
+		g := graph.NewStateGraph[map[string]any]()
+		attempts := 0
+		g.AddNode("fetch", "fetch", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			if attempts++; attempts == 1 {
+				return nil, errors.New("unavailable")
+			}
+			return state, nil
+		})
+		g.SetNodeRetryPolicy("fetch", graph.NodeRetryPolicy{MaxAttempts: 2})
+		g.AddNode("answer", "answer", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			graph.EmitToken(ctx, "42")
+			return state, nil
+		})
+		g.AddEdge("fetch", "answer")
+		g.AddEdge("answer", graph.END)
+		g.SetEntryPoint("fetch")
+		return g
+	}
+
+	t.Run("PlainStateGraph", func(t *testing.T) {
+		t.Parallel()
+		runnable, err := newFlakyGraph().Compile()
+		require.NoError(t, err)
+
+		recorder := &eventRecorder{}
+		id := runnable.AddListener(recorder)
+		assert.NotEmpty(t, id)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"fetch:start", "fetch:error", "fetch:retry", "fetch:start", "fetch:complete",
+			"answer:start", "answer:token", "answer:complete",
+		}, recorder.events)
+	})
+
+	t.Run("Filtered", func(t *testing.T) {
+		t.Parallel()
+		runnable, err := newFlakyGraph().Compile()
+		require.NoError(t, err)
+
+		completions, tokens := &eventRecorder{}, &eventRecorder{}
+		runnable.AddListener(completions, graph.WithEvents(graph.NodeEventComplete))
+		runnable.AddListener(graph.SubscribeEvents[map[string]any](tokens, graph.EventToken))
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fetch:complete", "answer:complete"}, completions.events)
+		assert.Equal(t, []string{"answer:token"}, tokens.events)
+	})
+
+	t.Run("RemoveListener", func(t *testing.T) {
+		t.Parallel()
+		g := graph.NewStateGraph[map[string]any]()
+		g.AddNode("only", "only", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return state, nil
+		})
+		g.AddEdge("only", graph.END)
+		g.SetEntryPoint("only")
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		kept, removed := &eventRecorder{}, &eventRecorder{}
+		runnable.AddListener(kept)
+		runnable.RemoveListener(runnable.AddListener(removed))
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"only:start", "only:complete"}, kept.events)
+		assert.Empty(t, removed.events)
+	})
+
+}
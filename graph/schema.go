@@ -1,12 +1,22 @@
 package graph
 
 import (
+	"errors"
 	"fmt"
 	"maps"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 // StateSchema defines the structure and update logic for the graph state with type safety.
+//
+// When several nodes run in the same step (fan-out), they execute concurrently but
+// Update is called with their results one at a time, in lexical order of node name.
+// Reducers such as AppendReducer therefore see a deterministic order: after a step
+// running "b" and "a", the update from "a" is merged before the one from "b",
+// regardless of which finished first or how the edges were declared.
 type StateSchema[S any] interface {
 	// Init returns the initial state.
 	Init() S
@@ -208,10 +218,20 @@ func MinIntMerge(current, new reflect.Value) reflect.Value {
 // It takes the current value and the new value, and returns the merged value.
 type Reducer func(current, new any) (any, error)
 
+// ErrMissingRequiredKey is reported when a key registered with MapSchema.RegisterRequired
+// is missing from the state after a node's update has been merged.
+var ErrMissingRequiredKey = errors.New("missing required state key")
+
 // MapSchema implements StateSchema for map[string]any.
 // It allows defining reducers for specific keys.
 type MapSchema struct {
 	Reducers map[string]Reducer
+
+	// Defaults are applied to the initial state for keys it doesn't set
+	Defaults map[string]any
+
+	// Required keys must be present (and non-nil) after every merge
+	Required []string
 }
 
 // NewMapSchema creates a new MapSchema.
@@ -226,6 +246,94 @@ func (s *MapSchema) RegisterReducer(key string, reducer Reducer) {
 	s.Reducers[key] = reducer
 }
 
+// RegisterDefault sets the value of key in the initial state when the input doesn't
+// provide it, before the entry point runs. Maps and slices are copied for every run,
+// so nodes can modify them in place.
+//
+// Example:
+//
+//	schema.RegisterDefault("metadata", map[string]any{})
+func (s *MapSchema) RegisterDefault(key string, value any) {
+	if s.Defaults == nil {
+		s.Defaults = make(map[string]any)
+	}
+	s.Defaults[key] = value
+}
+
+// RegisterRequired marks key as required. The state is checked after each node's
+// update is merged, and the run fails with ErrMissingRequiredKey, naming the node,
+// if the key is missing or nil.
+func (s *MapSchema) RegisterRequired(key string) {
+	if !slices.Contains(s.Required, key) {
+		s.Required = append(s.Required, key)
+	}
+}
+
+// ApplyDefaults returns state with the registered defaults set for missing or nil keys.
+func (s *MapSchema) ApplyDefaults(state map[string]any) map[string]any {
+	if len(s.Defaults) == 0 {
+		return state
+	}
+
+	result := make(map[string]any, len(state)+len(s.Defaults))
+	maps.Copy(result, state)
+	for k, v := range s.Defaults {
+		if result[k] == nil && v != nil {
+			result[k] = deepCopyValue(reflect.ValueOf(v)).Interface()
+		}
+	}
+	return result
+}
+
+// ValidateRequired returns an error wrapping ErrMissingRequiredKey if a required
+// key is missing or nil in state.
+func (s *MapSchema) ValidateRequired(state map[string]any) error {
+	var missing []string
+	for _, key := range s.Required {
+		if state[key] == nil {
+			missing = append(missing, strconv.Quote(key))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingRequiredKey, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// deepCopyValue copies maps and slices recursively; other values are returned as is
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(deepCopyValue(v.Elem()))
+		return copied
+	default:
+		return v
+	}
+}
+
 // Init returns an empty map.
 func (s *MapSchema) Init() map[string]any {
 	return make(map[string]any)
@@ -0,0 +1,195 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// namedReducers maps reducer names usable in `langgraph` struct tags to reducers.
+var namedReducers = map[string]Reducer{
+	"overwrite":     OverwriteReducer,
+	"append":        AppendReducer,
+	"unique_append": UniqueAppendReducer,
+	"add_messages":  AddMessages,
+	"merge":         MergeMapReducer,
+	"sum":           SumReducer,
+	"max":           MaxReducer,
+	"min":           MinReducer,
+}
+
+// StructTagSchema implements StateSchema for struct states whose fields declare
+// their reducers with `langgraph` struct tags. Create it with NewSchemaFromStruct.
+type StructTagSchema[S any] struct {
+	InitialValue S
+	fields       []tagField
+}
+
+// tagField is a struct field managed by a StructTagSchema
+type tagField struct {
+	index   []int
+	name    string
+	key     string
+	reducer Reducer
+	tagged  bool
+}
+
+// NewSchemaFromStruct builds a schema for the struct type S from its field tags.
+// Each exported field is merged with the reducer named by its `langgraph` tag, or
+// overwritten when it has none. Fields of embedded structs are included as if they
+// were declared on S.
+//
+// Supported reducers are overwrite, append, unique_append, add_messages, merge, sum,
+// max, min and last_n:N (see the functions of the same names in this package).
+// Zero-valued fields of an update are treated as unset and leave the state unchanged.
+//
+// Example:
+//
+//	type State struct {
+//	    Messages []llms.MessageContent `langgraph:"reducer=add_messages"`
+//	    Steps    []string              `langgraph:"reducer=last_n:10"`
+//	    Tokens   int                   `langgraph:"reducer=sum"`
+//	    Answer   string
+//	}
+//
+//	schema, err := graph.NewSchemaFromStruct[State]()
+func NewSchemaFromStruct[S any]() (*StructTagSchema[S], error) {
+	t := reflect.TypeFor[S]()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("schema from struct: %s is not a struct type", t)
+	}
+
+	var fields []tagField
+	if err := collectTagFields(t, nil, &fields); err != nil {
+		return nil, err
+	}
+	return &StructTagSchema[S]{fields: fields}, nil
+}
+
+func collectTagFields(t reflect.Type, index []int, fields *[]tagField) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(slices.Clone(index), i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("langgraph") == "" {
+			if err := collectTagFields(field.Type, fieldIndex, fields); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		reducer, tagged, err := parseReducerTag(field.Tag.Get("langgraph"))
+		if err != nil {
+			return fmt.Errorf("schema from struct: field %s: %w", field.Name, err)
+		}
+		*fields = append(*fields, tagField{
+			index:   fieldIndex,
+			name:    field.Name,
+			key:     stateKey(field),
+			reducer: reducer,
+			tagged:  tagged,
+		})
+	}
+	return nil
+}
+
+// parseReducerTag parses a `langgraph:"reducer=name"` tag value
+func parseReducerTag(tag string) (Reducer, bool, error) {
+	if tag == "" {
+		return OverwriteReducer, false, nil
+	}
+
+	var reducer Reducer
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		if key != "reducer" {
+			return nil, false, fmt.Errorf("unknown langgraph tag option %q", key)
+		}
+
+		if n, ok := strings.CutPrefix(value, "last_n:"); ok {
+			size, err := strconv.Atoi(n)
+			if err != nil || size <= 0 {
+				return nil, false, fmt.Errorf("invalid last_n size %q", n)
+			}
+			reducer = LastNReducer(size)
+			continue
+		}
+
+		named, ok := namedReducers[value]
+		if !ok {
+			return nil, false, fmt.Errorf("unknown reducer %q", value)
+		}
+		reducer = named
+	}
+	return reducer, true, nil
+}
+
+// stateKey is the map key of a field: its json name if it has one, otherwise its Go name
+func stateKey(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+// Init returns the initial state.
+func (s *StructTagSchema[S]) Init() S {
+	return s.InitialValue
+}
+
+// Update merges the non-zero fields of new into current using each field's reducer.
+func (s *StructTagSchema[S]) Update(current, new S) (S, error) {
+	currentVal := reflect.ValueOf(&current).Elem()
+	newVal := reflect.ValueOf(new)
+
+	for _, field := range s.fields {
+		newField := newVal.FieldByIndex(field.index)
+		if newField.IsZero() {
+			continue
+		}
+		currentField := currentVal.FieldByIndex(field.index)
+
+		var curr any
+		if !currentField.IsZero() {
+			curr = currentField.Interface()
+		}
+		merged, err := field.reducer(curr, newField.Interface())
+		if err != nil {
+			var zero S
+			return zero, fmt.Errorf("failed to reduce field %s: %w", field.name, err)
+		}
+
+		mergedVal := reflect.ValueOf(merged)
+		switch {
+		case !mergedVal.IsValid():
+			currentField.SetZero()
+		case mergedVal.Type().AssignableTo(currentField.Type()):
+			currentField.Set(mergedVal)
+		case numberKind(mergedVal) != notNumber && numberKind(currentField) != notNumber:
+			// Reducers such as SumReducer may widen mixed numeric types
+			currentField.Set(mergedVal.Convert(currentField.Type()))
+		default:
+			var zero S
+			return zero, fmt.Errorf("reducer for field %s returned %T, expected %s", field.name, merged, currentField.Type())
+		}
+	}
+
+	return current, nil
+}
+
+// MapSchema returns a MapSchema with the same reducers, keyed by each field's json
+// name (or Go name), for graphs that keep the state as map[string]any.
+func (s *StructTagSchema[S]) MapSchema() *MapSchema {
+	schema := NewMapSchema()
+	for _, field := range s.fields {
+		if field.tagged {
+			schema.RegisterReducer(field.key, field.reducer)
+		}
+	}
+	return schema
+}
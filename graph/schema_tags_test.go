@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tagSchemaBase struct {
+	Logs []string `langgraph:"reducer=append"`
+}
+
+type tagSchemaState struct {
+	tagSchemaBase
+	Steps   []string       `json:"steps" langgraph:"reducer=last_n:2"`
+	Tokens  int            `json:"tokens" langgraph:"reducer=sum"`
+	Best    float64        `langgraph:"reducer=max"`
+	Meta    map[string]any `langgraph:"reducer=merge"`
+	Answer  string
+	private int
+}
+
+func TestNewSchemaFromStruct(t *testing.T) {
+	schema, err := NewSchemaFromStruct[tagSchemaState]()
+	require.NoError(t, err)
+
+	state := tagSchemaState{
+		tagSchemaBase: tagSchemaBase{Logs: []string{"start"}},
+		Steps:         []string{"a"},
+		Tokens:        10,
+		Best:          0.5,
+		Meta:          map[string]any{"model": "gpt"},
+		Answer:        "draft",
+	}
+
+	state, err = schema.Update(state, tagSchemaState{
+		tagSchemaBase: tagSchemaBase{Logs: []string{"search"}},
+		Steps:         []string{"b", "c"},
+		Tokens:        5,
+		Best:          0.25,
+		Meta:          map[string]any{"temperature": 0.2},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"start", "search"}, state.Logs)
+	assert.Equal(t, []string{"b", "c"}, state.Steps)
+	assert.Equal(t, 15, state.Tokens)
+	assert.Equal(t, 0.5, state.Best)
+	assert.Equal(t, map[string]any{"model": "gpt", "temperature": 0.2}, state.Meta)
+	// Zero-valued fields in the update leave the state unchanged
+	assert.Equal(t, "draft", state.Answer)
+
+	state, err = schema.Update(state, tagSchemaState{Answer: "final"})
+	require.NoError(t, err)
+	assert.Equal(t, "final", state.Answer)
+	assert.Equal(t, 15, state.Tokens)
+}
+
+func TestNewSchemaFromStruct_Errors(t *testing.T) {
+	type unknownReducer struct {
+		Items []string `langgraph:"reducer=concat"`
+	}
+	_, err := NewSchemaFromStruct[unknownReducer]()
+	assert.ErrorContains(t, err, `field Items: unknown reducer "concat"`)
+
+	type badSize struct {
+		Items []string `langgraph:"reducer=last_n:0"`
+	}
+	_, err = NewSchemaFromStruct[badSize]()
+	assert.ErrorContains(t, err, "invalid last_n size")
+
+	type unknownOption struct {
+		Items []string `langgraph:"reduce=append"`
+	}
+	_, err = NewSchemaFromStruct[unknownOption]()
+	assert.ErrorContains(t, err, `unknown langgraph tag option "reduce"`)
+
+	type embeddedError struct {
+		unknownReducer
+	}
+	_, err = NewSchemaFromStruct[embeddedError]()
+	assert.ErrorContains(t, err, "field Items")
+
+	_, err = NewSchemaFromStruct[map[string]any]()
+	assert.ErrorContains(t, err, "not a struct type")
+
+	type mismatch struct {
+		Items []string `langgraph:"reducer=sum"`
+	}
+	schema, err := NewSchemaFromStruct[mismatch]()
+	require.NoError(t, err)
+	_, err = schema.Update(mismatch{Items: []string{"a"}}, mismatch{Items: []string{"b"}})
+	assert.ErrorContains(t, err, "failed to reduce field Items")
+}
+
+func TestNewSchemaFromStruct_MapSchema(t *testing.T) {
+	schema, err := NewSchemaFromStruct[tagSchemaState]()
+	require.NoError(t, err)
+
+	mapSchema := schema.MapSchema()
+	assert.ElementsMatch(t, []string{"Logs", "steps", "tokens", "Best", "Meta"}, sortedKeys(mapSchema.Reducers))
+
+	merged, err := mapSchema.Update(map[string]any{"tokens": 1, "Answer": "a"}, map[string]any{"tokens": 2, "Answer": "b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"tokens": 3, "Answer": "b"}, merged)
+}
+
+func TestNewSchemaFromStruct_Graph(t *testing.T) {
+	type state struct {
+		Visited []string `langgraph:"reducer=append"`
+		Count   int      `langgraph:"reducer=sum"`
+	}
+
+	schema, err := NewSchemaFromStruct[state]()
+	require.NoError(t, err)
+
+	g := NewStateGraph[state]()
+	g.SetSchema(schema)
+	for _, name := range []string{"a", "b", "c"} {
+		g.AddNode(name, name, func(ctx context.Context, s state) (state, error) {
+			return state{Visited: []string{name}, Count: 1}, nil
+		})
+	}
+	g.SetEntryPoint("a")
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("b", END)
+	g.AddEdge("c", END)
+
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	res, err := runnable.Invoke(context.Background(), state{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Count)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, res.Visited)
+}
@@ -616,3 +616,79 @@ func TestMapSchema_Update_Integration(t *testing.T) {
 	state3 := newState3
 	assert.Equal(t, []string{"hello", "world", "!"}, state3["messages"])
 }
+
+func TestMapSchema_DefaultsAndRequired(t *testing.T) {
+	newGraph := func(schema *MapSchema, price func(state map[string]any) map[string]any) *StateGraph[map[string]any] {
+		g := NewStateGraph[map[string]any]()
+		g.SetSchema(schema)
+		g.AddNode("fetch", "fetch", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			// Writes into the default map in place
+			state["metadata"].(map[string]any)["current_price"] = 101.5
+			return map[string]any{"metadata": state["metadata"]}, nil
+		})
+		g.AddNode("price", "price", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			return price(state), nil
+		})
+		g.SetEntryPoint("fetch")
+		g.AddEdge("fetch", "price")
+		g.AddEdge("price", END)
+		return g
+	}
+
+	t.Run("InitialStateInjection", func(t *testing.T) {
+		schema := NewMapSchema()
+		schema.RegisterDefault("metadata", map[string]any{})
+		schema.RegisterDefault("symbol", "AAPL")
+
+		runnable, err := newGraph(schema, func(state map[string]any) map[string]any {
+			return map[string]any{"price": state["metadata"].(map[string]any)["current_price"]}
+		}).Compile()
+		assert.NoError(t, err)
+
+		res, err := runnable.Invoke(context.Background(), map[string]any{"symbol": "MSFT"})
+		assert.NoError(t, err)
+		assert.Equal(t, 101.5, res["price"])
+		assert.Equal(t, "MSFT", res["symbol"], "input takes precedence over defaults")
+
+		// Defaults are copied per run, so in-place writes don't leak into them
+		assert.Empty(t, schema.Defaults["metadata"])
+		res, err = runnable.Invoke(context.Background(), map[string]any{})
+		assert.NoError(t, err)
+		assert.Equal(t, "AAPL", res["symbol"])
+	})
+
+	t.Run("MidRunValidation", func(t *testing.T) {
+		schema := NewMapSchema()
+		schema.RegisterDefault("metadata", map[string]any{})
+		schema.RegisterRequired("metadata")
+		schema.RegisterRequired("metadata")
+		assert.Equal(t, []string{"metadata"}, schema.Required)
+
+		runnable, err := newGraph(schema, func(state map[string]any) map[string]any {
+			return map[string]any{"metadata": nil}
+		}).Compile()
+		assert.NoError(t, err)
+
+		_, err = runnable.Invoke(context.Background(), map[string]any{})
+		assert.ErrorIs(t, err, ErrMissingRequiredKey)
+		assert.ErrorContains(t, err, `after node price: missing required state key: "metadata"`)
+	})
+
+	t.Run("ValidateRequired", func(t *testing.T) {
+		schema := NewMapSchema()
+		schema.RegisterRequired("a")
+		schema.RegisterRequired("b")
+
+		assert.NoError(t, schema.ValidateRequired(map[string]any{"a": 1, "b": 2}))
+		err := schema.ValidateRequired(map[string]any{"a": 1, "b": nil})
+		assert.ErrorIs(t, err, ErrMissingRequiredKey)
+		assert.ErrorContains(t, err, `"b"`)
+	})
+
+	t.Run("OptIn", func(t *testing.T) {
+		schema := NewMapSchema()
+		state := map[string]any{"metadata": nil}
+		assert.Equal(t, state, schema.ApplyDefaults(state))
+		assert.NoError(t, schema.ValidateRequired(state))
+	})
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// TypedSchema implements StateSchema for struct states with type-safe per-field
+// reducers, registered with RegisterFieldReducer. Nodes return partial updates: a
+// value of S in which only the fields to change are set. Registered fields are merged
+// with their reducer and other fields are overwritten, in both cases only when the
+// update's field is non-zero.
+//
+// Example:
+//
+//	type State struct {
+//	    Messages []llms.MessageContent
+//	    Scores   map[string]float64
+//	    Answer   string
+//	}
+//
+//	schema := graph.NewTypedSchema(State{})
+//	graph.RegisterFieldReducer(schema,
+//	    func(s State) []llms.MessageContent { return s.Messages },
+//	    func(s State, m []llms.MessageContent) State { s.Messages = m; return s },
+//	    graph.AppendSlice[llms.MessageContent],
+//	)
+//	g.SetSchema(schema)
+//
+//	// A node then only returns what it adds
+//	return State{Messages: []llms.MessageContent{reply}}, nil
+type TypedSchema[S any] struct {
+	InitialValue S
+	fields       []func(merged, current, update S) S
+}
+
+// NewTypedSchema creates a TypedSchema with the given initial state.
+func NewTypedSchema[S any](initial S) *TypedSchema[S] {
+	return &TypedSchema[S]{InitialValue: initial}
+}
+
+// RegisterFieldReducer sets how the field read by get and written by set is merged.
+// It is a function rather than a method because Go methods can't introduce the
+// field's type parameter F.
+func RegisterFieldReducer[S, F any](schema *TypedSchema[S], get func(S) F, set func(S, F) S, reducer func(current, new F) F) {
+	schema.fields = append(schema.fields, func(merged, current, update S) S {
+		value := get(update)
+		if reflect.ValueOf(&value).Elem().IsZero() {
+			return merged
+		}
+		return set(merged, reducer(get(current), value))
+	})
+}
+
+// Init returns the initial state.
+func (s *TypedSchema[S]) Init() S {
+	return s.InitialValue
+}
+
+// Update merges the non-zero fields of update into current.
+func (s *TypedSchema[S]) Update(current, update S) (S, error) {
+	merged, err := DefaultStructMerge(current, update)
+	if err != nil {
+		return merged, err
+	}
+	for _, field := range s.fields {
+		merged = field(merged, current, update)
+	}
+	return merged, nil
+}
+
+// AppendSlice is a field reducer that appends new to current. The result never
+// shares its backing array with current, so states merged from parallel branches
+// don't overwrite each other's elements.
+func AppendSlice[E any](current, new []E) []E {
+	return slices.Concat(current, new)
+}
+
+// MergeMaps is a field reducer that returns a copy of current with the entries of new
+// added, replacing existing keys.
+func MergeMaps[K comparable, V any](current, new map[K]V) map[K]V {
+	merged := make(map[K]V, len(current)+len(new))
+	maps.Copy(merged, current)
+	maps.Copy(merged, new)
+	return merged
+}
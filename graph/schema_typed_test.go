@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedSchemaState struct {
+	Messages []string
+	Scores   map[string]int
+	Steps    int
+	Answer   string
+}
+
+func newTypedSchemaForTest() *TypedSchema[typedSchemaState] {
+	schema := NewTypedSchema(typedSchemaState{Answer: "none"})
+	RegisterFieldReducer(schema,
+		func(s typedSchemaState) []string { return s.Messages },
+		func(s typedSchemaState, messages []string) typedSchemaState { s.Messages = messages; return s },
+		AppendSlice[string],
+	)
+	RegisterFieldReducer(schema,
+		func(s typedSchemaState) map[string]int { return s.Scores },
+		func(s typedSchemaState, scores map[string]int) typedSchemaState { s.Scores = scores; return s },
+		MergeMaps[string, int],
+	)
+	RegisterFieldReducer(schema,
+		func(s typedSchemaState) int { return s.Steps },
+		func(s typedSchemaState, steps int) typedSchemaState { s.Steps = steps; return s },
+		func(current, new int) int { return current + new },
+	)
+	return schema
+}
+
+func TestTypedSchema(t *testing.T) {
+	t.Run("Update", func(t *testing.T) {
+		schema := newTypedSchemaForTest()
+		current := typedSchemaState{
+			Messages: []string{"hi"},
+			Scores:   map[string]int{"a": 1},
+			Steps:    1,
+			Answer:   "draft",
+		}
+
+		merged, err := schema.Update(current, typedSchemaState{
+			Messages: []string{"hello"},
+			Scores:   map[string]int{"b": 2},
+			Steps:    1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, typedSchemaState{
+			Messages: []string{"hi", "hello"},
+			Scores:   map[string]int{"a": 1, "b": 2},
+			Steps:    2,
+			Answer:   "draft",
+		}, merged)
+		assert.Equal(t, map[string]int{"a": 1}, current.Scores, "current is not modified")
+
+		// Zero fields leave the state unchanged; other fields are overwritten
+		merged, err = schema.Update(merged, typedSchemaState{Answer: "final"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hi", "hello"}, merged.Messages)
+		assert.Equal(t, 2, merged.Steps)
+		assert.Equal(t, "final", merged.Answer)
+	})
+
+	t.Run("PartialUpdatesInGraph", func(t *testing.T) {
+		g := NewStateGraph[typedSchemaState]()
+		g.SetSchema(newTypedSchemaForTest())
+		g.AddNode("plan", "plan", func(ctx context.Context, s typedSchemaState) (typedSchemaState, error) {
+			return typedSchemaState{Messages: []string{"plan"}, Steps: 1}, nil
+		})
+		g.AddNode("search", "search", func(ctx context.Context, s typedSchemaState) (typedSchemaState, error) {
+			return typedSchemaState{Messages: []string{"search"}, Scores: map[string]int{"search": 3}, Steps: 1}, nil
+		})
+		g.AddNode("browse", "browse", func(ctx context.Context, s typedSchemaState) (typedSchemaState, error) {
+			return typedSchemaState{Messages: []string{"browse"}, Scores: map[string]int{"browse": 5}, Steps: 1}, nil
+		})
+		g.AddNode("answer", "answer", func(ctx context.Context, s typedSchemaState) (typedSchemaState, error) {
+			return typedSchemaState{Answer: "done", Steps: 1}, nil
+		})
+		g.SetEntryPoint("plan")
+		g.AddEdge("plan", "search")
+		g.AddEdge("plan", "browse")
+		g.AddEdge("search", "answer")
+		g.AddEdge("browse", "answer")
+		g.AddEdge("answer", END)
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(context.Background(), typedSchemaState{Messages: []string{"question"}})
+		require.NoError(t, err)
+		assert.Equal(t, typedSchemaState{
+			Messages: []string{"question", "plan", "browse", "search"},
+			Scores:   map[string]int{"search": 3, "browse": 5},
+			Steps:    4,
+			Answer:   "done",
+		}, res)
+	})
+}
+
+func TestAppendSlice(t *testing.T) {
+	current := make([]int, 1, 4)
+	a := AppendSlice(current, []int{2})
+	b := AppendSlice(current, []int{3})
+	assert.Equal(t, []int{0, 2}, a)
+	assert.Equal(t, []int{0, 3}, b, "results don't share the backing array of current")
+}
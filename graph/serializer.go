@@ -0,0 +1,474 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"sync"
+
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// serializerMetadataKey is the checkpoint metadata naming the Serializer of a
+// serialized state
+const serializerMetadataKey = "state_serializer"
+
+// Serializer converts checkpoint states to bytes and back, see
+// CheckpointConfig.Serializer. Unmarshal decodes into state, a pointer to the
+// graph's state type.
+type Serializer interface {
+	// Name identifies the serializer in the metadata of the checkpoints it wrote
+	Name() string
+	Marshal(state any) ([]byte, error)
+	Unmarshal(data []byte, state any) error
+}
+
+var (
+	// JSONSerializer encodes states as JSON. The values of map states whose types
+	// were registered with RegisterType are tagged with their type name, so they
+	// decode to the same types.
+	JSONSerializer Serializer = jsonSerializer{}
+
+	// GobSerializer encodes states with encoding/gob, which keeps the concrete
+	// types of interface values registered with RegisterType.
+	GobSerializer Serializer = gobSerializer{}
+
+	// MsgpackSerializer encodes states as MessagePack, using the json tags of
+	// structs. Like JSONSerializer it tags the registered values of map states.
+	// Registered types with custom JSON marshaling, such as llms.MessageContent,
+	// are stored as their JSON.
+	MsgpackSerializer Serializer = msgpackSerializer{}
+)
+
+// serializers are the serializers checkpoints are decoded with when they were
+// written by another serializer than the configured one
+var serializers = map[string]Serializer{
+	JSONSerializer.Name():    JSONSerializer,
+	GobSerializer.Name():     GobSerializer,
+	MsgpackSerializer.Name(): MsgpackSerializer,
+}
+
+var (
+	typesMu     sync.RWMutex
+	typesByName = map[string]reflect.Type{}
+	typeNames   = map[reflect.Type]string{}
+)
+
+func init() {
+	// Message histories and the generic containers of map states
+	_ = RegisterType[[]llms.MessageContent]("llms.MessageContents")
+	_ = RegisterType[llms.MessageContent]("llms.MessageContent")
+	_ = RegisterType[llms.TextContent]("llms.TextContent")
+	_ = RegisterType[llms.ImageURLContent]("llms.ImageURLContent")
+	_ = RegisterType[llms.BinaryContent]("llms.BinaryContent")
+	_ = RegisterType[llms.ToolCall]("llms.ToolCall")
+	_ = RegisterType[llms.ToolCallResponse]("llms.ToolCallResponse")
+	gob.Register([]any{})
+	gob.Register(map[string]any{})
+}
+
+// RegisterType registers T under name, so that values of type T in checkpoint
+// states keep their type when saved with a Serializer and loaded again, in
+// particular values of map states and of interface fields. The message and content
+// part types of langchaingo are registered already. Registering a type again under
+// the same name does nothing, and it is an error to use a name or type twice.
+//
+// Example:
+//
+//	func init() {
+//	    if err := graph.RegisterType[Document]("myapp.Document"); err != nil {
+//	        panic(err)
+//	    }
+//	}
+func RegisterType[T any](name string) error {
+	t := reflect.TypeFor[T]()
+
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if existing, ok := typesByName[name]; ok {
+		if existing == t {
+			return nil
+		}
+		return fmt.Errorf("type name %s is already registered for %v", name, existing)
+	}
+	if existing, ok := typeNames[t]; ok {
+		return fmt.Errorf("type %v is already registered as %s", t, existing)
+	}
+	typesByName[name] = t
+	typeNames[t] = name
+
+	var zero T
+	gob.RegisterName(name, zero)
+	registerMsgpackJSON(t)
+	return nil
+}
+
+// registeredName returns the name v's type was registered under
+func registeredName(v any) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	name, ok := typeNames[reflect.TypeOf(v)]
+	return name, ok
+}
+
+// registeredType returns the type registered under name
+func registeredType(name string) (reflect.Type, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	t, ok := typesByName[name]
+	return t, ok
+}
+
+// registerMsgpackJSON makes msgpack encode values of t as their JSON when t has
+// custom JSON marshaling, which msgpack would otherwise bypass
+func registerMsgpackJSON(t reflect.Type) {
+	if !t.Implements(reflect.TypeFor[json.Marshaler]()) || !reflect.PointerTo(t).Implements(reflect.TypeFor[json.Unmarshaler]()) {
+		return
+	}
+	msgpack.Register(reflect.Zero(t).Interface(),
+		func(enc *msgpack.Encoder, v reflect.Value) error {
+			data, err := json.Marshal(v.Interface())
+			if err != nil {
+				return err
+			}
+			return enc.EncodeBytes(data)
+		},
+		func(dec *msgpack.Decoder, v reflect.Value) error {
+			data, err := dec.DecodeBytes()
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, v.Addr().Interface())
+		})
+}
+
+// typedValue is a value of a map state tagged with its registered type name
+type typedValue[V any] struct {
+	Type  string `json:"_type" msgpack:"_type"`
+	Value V      `json:"_value" msgpack:"_value"`
+}
+
+// tagValues returns state with the registered values of a map state replaced by
+// typed values. Other states are returned as they are.
+func tagValues(state any) any {
+	m, ok := state.(map[string]any)
+	if !ok {
+		return state
+	}
+	tagged := make(map[string]any, len(m))
+	for key, value := range m {
+		if name, ok := registeredName(value); ok {
+			tagged[key] = typedValue[any]{Type: name, Value: value}
+			continue
+		}
+		tagged[key] = value
+	}
+	return tagged
+}
+
+// untagValues decodes the entries of a map state into *target, decoding typed
+// values into their registered types with decode
+func untagValues[R any](entries map[string]R, target *map[string]any, decode func(data R, v any) error) error {
+	state := make(map[string]any, len(entries))
+	for key, data := range entries {
+		var tagged typedValue[R]
+		if err := decode(data, &tagged); err == nil && tagged.Type != "" {
+			if t, ok := registeredType(tagged.Type); ok {
+				value := reflect.New(t)
+				if err := decode(tagged.Value, value.Interface()); err != nil {
+					return fmt.Errorf("failed to decode %s as %s: %w", key, tagged.Type, err)
+				}
+				state[key] = value.Elem().Interface()
+				continue
+			}
+		}
+		var value any
+		if err := decode(data, &value); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", key, err)
+		}
+		state[key] = value
+	}
+	*target = state
+	return nil
+}
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) Name() string { return "json" }
+
+func (jsonSerializer) Marshal(state any) ([]byte, error) {
+	return json.Marshal(tagValues(state))
+}
+
+func (jsonSerializer) Unmarshal(data []byte, state any) error {
+	target, ok := state.(*map[string]any)
+	if !ok {
+		return json.Unmarshal(data, state)
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	return untagValues(entries, target, func(data json.RawMessage, v any) error {
+		return json.Unmarshal(data, v)
+	})
+}
+
+type gobSerializer struct{}
+
+func (gobSerializer) Name() string { return "gob" }
+
+func (gobSerializer) Marshal(state any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobSerializer) Unmarshal(data []byte, state any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(state)
+}
+
+type msgpackSerializer struct{}
+
+func (msgpackSerializer) Name() string { return "msgpack" }
+
+func (msgpackSerializer) Marshal(state any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(tagValues(state)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackSerializer) Unmarshal(data []byte, state any) error {
+	decode := func(data []byte, v any) error {
+		dec := msgpack.NewDecoder(bytes.NewReader(data))
+		dec.SetCustomStructTag("json")
+		return dec.Decode(v)
+	}
+	target, ok := state.(*map[string]any)
+	if !ok {
+		return decode(data, state)
+	}
+	var entries map[string]msgpack.RawMessage
+	if err := decode(data, &entries); err != nil {
+		return err
+	}
+	return untagValues(entries, target, func(data msgpack.RawMessage, v any) error {
+		return decode(data, v)
+	})
+}
+
+// serializingStore saves the states of checkpoints encoded by a Serializer in
+// another store, and decodes them into S when loading
+type serializingStore[S any] struct {
+	store.CheckpointStore
+	serializer Serializer
+}
+
+// newSerializingStore wraps inner to serialize states with serializer
+func newSerializingStore[S any](inner store.CheckpointStore, serializer Serializer) *serializingStore[S] {
+	return &serializingStore[S]{CheckpointStore: inner, serializer: serializer}
+}
+
+func (s *serializingStore[S]) Save(ctx context.Context, checkpoint *store.Checkpoint) error {
+	data, err := s.serializer.Marshal(checkpoint.State)
+	if err != nil {
+		return fmt.Errorf("failed to serialize state: %w", err)
+	}
+	saved := *checkpoint
+	saved.State = data
+	saved.Metadata = maps.Clone(checkpoint.Metadata)
+	if saved.Metadata == nil {
+		saved.Metadata = make(map[string]any)
+	}
+	saved.Metadata[serializerMetadataKey] = s.serializer.Name()
+	return s.CheckpointStore.Save(ctx, &saved)
+}
+
+func (s *serializingStore[S]) Load(ctx context.Context, checkpointID string) (*store.Checkpoint, error) {
+	checkpoint, err := s.CheckpointStore.Load(ctx, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(checkpoint)
+}
+
+func (s *serializingStore[S]) List(ctx context.Context, executionID string) ([]*store.Checkpoint, error) {
+	checkpoints, err := s.CheckpointStore.List(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeAll(checkpoints)
+}
+
+func (s *serializingStore[S]) ListPage(ctx context.Context, executionID string, opts store.ListOptions) ([]*store.Checkpoint, error) {
+	checkpoints, err := store.ListPage(ctx, s.CheckpointStore, executionID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeAll(checkpoints)
+}
+
+func (s *serializingStore[S]) ListByThread(ctx context.Context, threadID string) ([]*store.Checkpoint, error) {
+	checkpoints, err := s.CheckpointStore.ListByThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeAll(checkpoints)
+}
+
+func (s *serializingStore[S]) GetLatestByThread(ctx context.Context, threadID string) (*store.Checkpoint, error) {
+	checkpoint, err := s.CheckpointStore.GetLatestByThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(checkpoint)
+}
+
+func (s *serializingStore[S]) ListByMetadata(ctx context.Context, filters map[string]any, opts store.ListOptions) ([]*store.Checkpoint, error) {
+	checkpoints, err := store.ListByMetadata(ctx, s.CheckpointStore, filters, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeAll(checkpoints)
+}
+
+func (s *serializingStore[S]) ListThreads(ctx context.Context, opts store.ListThreadsOptions) ([]store.ThreadInfo, error) {
+	return store.ListThreads(ctx, s.CheckpointStore, opts)
+}
+
+func (s *serializingStore[S]) DeleteThread(ctx context.Context, threadID string) error {
+	return store.DeleteThread(ctx, s.CheckpointStore, threadID)
+}
+
+func (s *serializingStore[S]) Ping(ctx context.Context) error {
+	return store.Ping(ctx, s.CheckpointStore)
+}
+
+func (s *serializingStore[S]) decodeAll(checkpoints []*store.Checkpoint) ([]*store.Checkpoint, error) {
+	decoded := make([]*store.Checkpoint, len(checkpoints))
+	for i, checkpoint := range checkpoints {
+		var err error
+		if decoded[i], err = s.decode(checkpoint); err != nil {
+			return nil, err
+		}
+	}
+	return decoded, nil
+}
+
+// decode returns a copy of checkpoint with its state decoded into S and the
+// serializer metadata removed. Checkpoints saved without a serializer are returned
+// as they are, and those saved with another state type than S fail with
+// ErrStateTypeMismatch rather than being decoded into S.
+func (s *serializingStore[S]) decode(checkpoint *store.Checkpoint) (*store.Checkpoint, error) {
+	name, ok := checkpoint.Metadata[serializerMetadataKey].(string)
+	if !ok {
+		return checkpoint, nil
+	}
+	if err := checkStateType[S](checkpoint); err != nil {
+		return nil, err
+	}
+	state, err := s.unmarshal(name, checkpoint.State)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", checkpoint.ID, err)
+	}
+
+	loaded := *checkpoint
+	loaded.State = state
+	loaded.Metadata = maps.Clone(checkpoint.Metadata)
+	delete(loaded.Metadata, serializerMetadataKey)
+	return &loaded, nil
+}
+
+// unmarshal decodes into S a state serialized by the serializer called name
+func (s *serializingStore[S]) unmarshal(name string, serialized any) (S, error) {
+	var state S
+	serializer := s.serializer
+	if name != serializer.Name() {
+		var ok bool
+		if serializer, ok = serializers[name]; !ok {
+			return state, fmt.Errorf("unknown state serializer %q", name)
+		}
+	}
+
+	var data []byte
+	switch serialized := serialized.(type) {
+	case []byte:
+		data = serialized
+	case string:
+		// Stores that serialize checkpoints to JSON save the bytes as base64
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(serialized); err != nil {
+			return state, fmt.Errorf("failed to decode serialized state: %w", err)
+		}
+	default:
+		return state, fmt.Errorf("serialized state has type %T", serialized)
+	}
+
+	if err := serializer.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to deserialize state: %w", err)
+	}
+	return state, nil
+}
+
+// SaveWrite saves a pending write with its value serialized like checkpoint states
+func (s *serializingStore[S]) SaveWrite(ctx context.Context, write *store.PendingWrite) error {
+	data, err := s.serializer.Marshal(write.Value)
+	if err != nil {
+		return fmt.Errorf("failed to serialize pending write: %w", err)
+	}
+	saved := *write
+	saved.Value = data
+	saved.Metadata = maps.Clone(write.Metadata)
+	if saved.Metadata == nil {
+		saved.Metadata = make(map[string]any)
+	}
+	saved.Metadata[serializerMetadataKey] = s.serializer.Name()
+	return store.SaveWrite(ctx, s.CheckpointStore, &saved)
+}
+
+// ListWrites returns the pending writes of checkpointID with their values
+// decoded into S
+func (s *serializingStore[S]) ListWrites(ctx context.Context, checkpointID string) ([]*store.PendingWrite, error) {
+	writes, err := store.ListWrites(ctx, s.CheckpointStore, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	decoded := make([]*store.PendingWrite, len(writes))
+	for i, write := range writes {
+		name, ok := write.Metadata[serializerMetadataKey].(string)
+		if !ok {
+			decoded[i] = write
+			continue
+		}
+		value, err := s.unmarshal(name, write.Value)
+		if err != nil {
+			return nil, fmt.Errorf("pending write of node %s: %w", write.Node, err)
+		}
+		loaded := *write
+		loaded.Value = value
+		loaded.Metadata = maps.Clone(write.Metadata)
+		delete(loaded.Metadata, serializerMetadataKey)
+		decoded[i] = &loaded
+	}
+	return decoded, nil
+}
+
+func (s *serializingStore[S]) DeleteWrites(ctx context.Context, checkpointID string) error {
+	return store.DeleteWrites(ctx, s.CheckpointStore, checkpointID)
+}
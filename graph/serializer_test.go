@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/store/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+type serializerAgentState struct {
+	Messages []llms.MessageContent `json:"messages"`
+	Step     int                   `json:"step"`
+}
+
+func serializerMessages() []llms.MessageContent {
+	return []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "What's the weather in Paris?"),
+		{
+			Role: llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{
+				ID:           "call-1",
+				Type:         "function",
+				FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+			}},
+		},
+	}
+}
+
+func TestSerializers(t *testing.T) {
+	for _, serializer := range []Serializer{JSONSerializer, GobSerializer, MsgpackSerializer} {
+		t.Run(serializer.Name(), func(t *testing.T) {
+			state := map[string]any{"messages": serializerMessages(), "query": "weather"}
+			data, err := serializer.Marshal(state)
+			require.NoError(t, err)
+
+			var loaded map[string]any
+			require.NoError(t, serializer.Unmarshal(data, &loaded))
+			assert.Equal(t, serializerMessages(), loaded["messages"])
+			assert.Equal(t, "weather", loaded["query"])
+
+			data, err = serializer.Marshal(serializerAgentState{Messages: serializerMessages(), Step: 2})
+			require.NoError(t, err)
+			var agent serializerAgentState
+			require.NoError(t, serializer.Unmarshal(data, &agent))
+			assert.Equal(t, serializerAgentState{Messages: serializerMessages(), Step: 2}, agent)
+		})
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	type document struct{ Title string }
+	require.NoError(t, RegisterType[document]("graph_test.document"))
+	require.NoError(t, RegisterType[document]("graph_test.document"))
+	assert.ErrorContains(t, RegisterType[document]("graph_test.other"), "already registered as graph_test.document")
+	assert.ErrorContains(t, RegisterType[int]("graph_test.document"), "already registered")
+
+	data, err := JSONSerializer.Marshal(map[string]any{"doc": document{Title: "notes"}})
+	require.NoError(t, err)
+	var loaded map[string]any
+	require.NoError(t, JSONSerializer.Unmarshal(data, &loaded))
+	assert.Equal(t, document{Title: "notes"}, loaded["doc"])
+}
+
+// TestCheckpointSerializer_AgentResume resumes an agent's thread from the file store
+// in a fresh runnable, whose tool node needs the messages as []llms.MessageContent
+func TestCheckpointSerializer_AgentResume(t *testing.T) {
+	for _, serializer := range []Serializer{JSONSerializer, GobSerializer, MsgpackSerializer} {
+		t.Run(serializer.Name(), func(t *testing.T) {
+			ctx := context.Background()
+			checkpoints, err := file.NewFileCheckpointStore(t.TempDir())
+			require.NoError(t, err)
+
+			newRunnable := func() *CheckpointableRunnable[map[string]any] {
+				g := NewCheckpointableStateGraph[map[string]any]()
+				schema := NewMapSchema()
+				schema.RegisterReducer("messages", AddMessages)
+				g.SetSchema(schema)
+				g.AddNode("agent", "agent", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+					return map[string]any{"messages": serializerMessages()[1:]}, nil
+				})
+				g.AddNode("tools", "tools", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+					messages, ok := state["messages"].([]llms.MessageContent)
+					if !ok {
+						return nil, fmt.Errorf("messages have type %T", state["messages"])
+					}
+					call := messages[len(messages)-1].Parts[0].(llms.ToolCall)
+					return map[string]any{"messages": []llms.MessageContent{{
+						Role:  llms.ChatMessageTypeTool,
+						Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: call.ID, Name: call.FunctionCall.Name, Content: "sunny"}},
+					}}}, nil
+				})
+				g.SetEntryPoint("agent")
+				g.AddEdge("agent", "tools")
+				g.AddEdge("tools", END)
+				g.SetCheckpointConfig(CheckpointConfig{Store: checkpoints, AutoSave: true, Serializer: serializer})
+				runnable, err := g.CompileCheckpointable()
+				require.NoError(t, err)
+				return runnable
+			}
+
+			config := WithThreadID("thread-1")
+			config.InterruptAfter = []string{"agent"}
+			_, err = newRunnable().InvokeWithConfig(ctx, map[string]any{"messages": serializerMessages()[:1]}, config)
+			var interrupt *GraphInterrupt
+			require.ErrorAs(t, err, &interrupt)
+
+			res, err := newRunnable().InvokeCommand(ctx, &Command{}, WithThreadID("thread-1"))
+			require.NoError(t, err)
+			messages := res["messages"].([]llms.MessageContent)
+			require.Len(t, messages, 3)
+			assert.Equal(t, "sunny", messages[2].Parts[0].(llms.ToolCallResponse).Content)
+
+			// The saved checkpoints record their serializer, and load without it
+			raw, err := checkpoints.GetLatestByThread(ctx, "thread-1")
+			require.NoError(t, err)
+			assert.Equal(t, serializer.Name(), raw.Metadata["state_serializer"])
+			snapshot, err := newRunnable().GetState(ctx, WithThreadID("thread-1"))
+			require.NoError(t, err)
+			assert.NotContains(t, snapshot.Metadata, "state_serializer")
+		})
+	}
+}
+
+func TestCheckpointSerializer_MixedCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	checkpoints := NewMemoryCheckpointStore()
+	require.NoError(t, checkpoints.Save(ctx, &Checkpoint{
+		ID:       "old",
+		State:    map[string]any{"step": "one"},
+		Metadata: map[string]any{"thread_id": "thread-1"},
+		Version:  1,
+	}))
+
+	s := newSerializingStore[map[string]any](checkpoints, GobSerializer)
+	require.NoError(t, s.Save(ctx, &Checkpoint{
+		ID:       "new",
+		State:    map[string]any{"step": "two"},
+		Metadata: map[string]any{"thread_id": "thread-1"},
+		Version:  2,
+	}))
+
+	// Checkpoints from before the serializer was set, or written by another one, load
+	list, err := newSerializingStore[map[string]any](checkpoints, JSONSerializer).ListByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, map[string]any{"step": "one"}, list[0].State)
+	assert.Equal(t, map[string]any{"step": "two"}, list[1].State)
+}
@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type startState struct {
+	Kind  string
+	Trail []string
+}
+
+func newStartGraph() *StateGraph[startState] {
+	g := NewStateGraph[startState]()
+	g.SetSchema(NewStructSchema(startState{}, func(current, new startState) (startState, error) {
+		if new.Kind != "" {
+			current.Kind = new.Kind
+		}
+		current.Trail = append(current.Trail, new.Trail...)
+		return current, nil
+	}))
+	for _, name := range []string{"question", "chat", "answer"} {
+		g.AddNode(name, name, func(ctx context.Context, s startState) (startState, error) {
+			return startState{Trail: []string{name}}, nil
+		})
+	}
+	g.AddEdge("question", "answer")
+	g.AddEdge("chat", "answer")
+	g.AddEdge("answer", END)
+	return g
+}
+
+func TestStartEdge(t *testing.T) {
+	t.Run("AliasesSetEntryPoint", func(t *testing.T) {
+		g := newStartGraph()
+		g.AddEdge(START, "chat")
+
+		assert.Equal(t, "chat", g.entryPoint)
+		assert.Len(t, g.edges, 3, "no edge is stored for START")
+
+		_, err := g.Compile()
+		assert.ErrorIs(t, err, ErrUnreachableNode, "START has a single static edge")
+
+		g.AddEdge("question", "chat")
+		g.AddEdge(START, "question")
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+		res, err := runnable.Invoke(context.Background(), startState{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"question", "answer", "chat", "answer"}, res.Trail)
+	})
+
+	t.Run("ConditionalEntry", func(t *testing.T) {
+		g := newStartGraph()
+		g.AddConditionalEdge(START, func(ctx context.Context, s startState) string {
+			if s.Kind == "question" {
+				return "question"
+			}
+			return "chat"
+		})
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(context.Background(), startState{Kind: "question"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"question", "answer"}, res.Trail)
+
+		res, err = runnable.Invoke(context.Background(), startState{Kind: "smalltalk"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"chat", "answer"}, res.Trail)
+
+		plan, err := runnable.DryRun(context.Background(), startState{Kind: "question"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"question", "answer"}, plan)
+	})
+
+	t.Run("ConditionalEntryWithMapping", func(t *testing.T) {
+		g := newStartGraph()
+		g.SetEntryPoint("chat")
+		g.AddConditionalEdgeWithMapping(START, func(ctx context.Context, s startState) string {
+			return s.Kind
+		}, map[string]string{"q": "question", "c": "chat"})
+
+		runnable, err := g.Compile()
+		require.NoError(t, err)
+
+		res, err := runnable.Invoke(context.Background(), startState{Kind: "q"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"question", "answer"}, res.Trail, "the conditional entry takes precedence")
+
+		_, err = runnable.Invoke(context.Background(), startState{Kind: "x"})
+		assert.ErrorIs(t, err, ErrUnknownPathKey)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		g := newStartGraph()
+		assert.ErrorIs(t, g.Validate(), ErrEntryPointNotSet)
+
+		g.AddConditionalEdgeWithMapping(START, func(ctx context.Context, s startState) string {
+			return s.Kind
+		}, map[string]string{"q": "question", "x": "missing"})
+		err := g.Validate()
+		assert.ErrorIs(t, err, ErrNodeNotFound)
+		assert.ErrorIs(t, err, ErrUnreachableNode, "chat is not a mapped entry")
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		for _, issue := range validationErr.Issues {
+			assert.NotEqual(t, START, issue.Node)
+		}
+	})
+
+	t.Run("Exporters", func(t *testing.T) {
+		g := newStartGraph()
+		g.AddConditionalEdgeWithMapping(START, func(ctx context.Context, s startState) string {
+			return s.Kind
+		}, map[string]string{"q": "question", "c": "chat"})
+		exporter := NewExporter(g)
+
+		mermaid := exporter.DrawMermaid()
+		assert.Contains(t, mermaid, "    START([\"START\"])\n")
+		assert.Contains(t, mermaid, "    START -.->|c| chat\n")
+		assert.Contains(t, mermaid, "    START -.->|q| question\n")
+		assert.NotContains(t, mermaid, "fill:#87CEEB")
+
+		dot := exporter.DrawDOT()
+		assert.Contains(t, dot, "    START [label=\"START\", shape=ellipse, style=filled, fillcolor=lightgreen];\n")
+		assert.Contains(t, dot, "    START -> chat [style=dashed, label=\"c\"];\n")
+		assert.Contains(t, dot, "    START -> question [style=dashed, label=\"q\"];\n")
+
+		ascii := exporter.DrawASCII()
+		assert.Contains(t, ascii, "START")
+		assert.Contains(t, ascii, "question")
+	})
+}
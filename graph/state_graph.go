@@ -1,12 +1,17 @@
 package graph
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"runtime/debug"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -35,15 +40,48 @@ type StateGraph[S any] struct {
 	// conditionalEdges contains a map between "From" node, while "To" node is derived based on the condition
 	conditionalEdges map[string]func(ctx context.Context, state S) string
 
+	// conditionalPathMaps maps the keys returned by a conditional router to node names, per "From" node
+	conditionalPathMaps map[string]map[string]string
+
 	// entryPoint is the name of the entry point node in the graph
 	entryPoint string
 
 	// retryPolicy defines retry behavior for failed nodes
 	retryPolicy *RetryPolicy
 
+	// nodeRetryPolicies holds per-node retry policies, which take precedence over retryPolicy
+	nodeRetryPolicies map[string]*NodeRetryPolicy
+
+	// nodeCachePolicies holds the result caching configuration for cached nodes
+	nodeCachePolicies map[string]*nodeCachePolicy
+
+	// cache stores the results of cached nodes across invocations
+	cache Cache
+
+	// errorHandler is the node that failing nodes are routed to, if set
+	errorHandler string
+
+	// failFastNodes holds the nodes whose errors bypass the error handler
+	failFastNodes map[string]bool
+
+	// errorEdges maps a node to the node its failures are routed to
+	errorEdges map[string]string
+
+	// subgraphs holds the nested graphs added with AddSubgraph, keyed by node name, for visualization
+	subgraphs map[string]graphView
+
 	// stateMerger is an optional function to merge states from parallel execution
 	stateMerger TypedStateMerger[S]
 
+	// middleware wraps the execution of every node, see Use
+	middleware []Middleware[S]
+
+	// markedCycles are the groups of nodes declared with MarkCycle
+	markedCycles [][]string
+
+	// deferredNodes run after every run, in the order added with AddDeferredNode
+	deferredNodes []TypedNode[S]
+
 	// Schema defines the state structure and update logic
 	Schema StateSchema[S]
 }
@@ -53,6 +91,11 @@ type TypedNode[S any] struct {
 	Name        string
 	Description string
 	Function    func(ctx context.Context, state S) (S, error)
+
+	// Tags and Metadata are optional labels set with WithTags and WithMetadata.
+	// They are passed to callbacks and shown by the exporter.
+	Tags     []string
+	Metadata map[string]any
 }
 
 // StateMerger is a typed function to merge states from parallel execution.
@@ -66,8 +109,14 @@ type TypedStateMerger[S any] func(ctx context.Context, currentState S, newStates
 //	g := graph.NewStateGraph[MyState]()
 func NewStateGraph[S any]() *StateGraph[S] {
 	return &StateGraph[S]{
-		nodes:            make(map[string]TypedNode[S]),
-		conditionalEdges: make(map[string]func(ctx context.Context, state S) string),
+		nodes:               make(map[string]TypedNode[S]),
+		conditionalEdges:    make(map[string]func(ctx context.Context, state S) string),
+		conditionalPathMaps: make(map[string]map[string]string),
+		nodeRetryPolicies:   make(map[string]*NodeRetryPolicy),
+		nodeCachePolicies:   make(map[string]*nodeCachePolicy),
+		failFastNodes:       make(map[string]bool),
+		errorEdges:          make(map[string]string),
+		subgraphs:           make(map[string]graphView),
 	}
 }
 
@@ -88,14 +137,116 @@ func (g *StateGraph[S]) AddNode(name string, description string, fn func(ctx con
 	}
 }
 
+// NodeOption configures optional per-node behavior in AddNodeWithOptions.
+type NodeOption func(*nodeOptions)
+
+// nodeOptions collects the settings applied by NodeOption values.
+type nodeOptions struct {
+	retryPolicy *NodeRetryPolicy
+	failFast    bool
+	tags        []string
+	metadata    map[string]any
+}
+
+// AddNodeWithOptions adds a node like AddNode and applies the given options to it.
+//
+// Example:
+//
+//	g.AddNodeWithOptions("llm", "Call the model", callModel,
+//	    graph.WithRetry(graph.NodeRetryPolicy{
+//	        MaxAttempts: 3,
+//	        Backoff:     graph.ExponentialDelay(100*time.Millisecond, 2*time.Second),
+//	        RetryIf:     isRateLimitError,
+//	    }),
+//	)
+func (g *StateGraph[S]) AddNodeWithOptions(name string, description string, fn func(ctx context.Context, state S) (S, error), opts ...NodeOption) {
+	g.AddNode(name, description, fn)
+	g.applyNodeOptions(name, opts)
+}
+
+// applyNodeOptions stores the settings from opts for the named node.
+func (g *StateGraph[S]) applyNodeOptions(name string, opts []NodeOption) {
+	options := &nodeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.retryPolicy != nil {
+		g.nodeRetryPolicies[name] = options.retryPolicy
+	}
+	if options.failFast {
+		g.failFastNodes[name] = true
+	}
+	if node, ok := g.nodes[name]; ok && (options.tags != nil || options.metadata != nil) {
+		node.Tags = options.tags
+		node.Metadata = options.metadata
+		g.nodes[name] = node
+	}
+}
+
 // AddEdge adds a new edge to the state graph between the "from" and "to" nodes.
+// An edge from START sets the entry point, like SetEntryPoint.
 func (g *StateGraph[S]) AddEdge(from, to string) {
+	if from == START {
+		g.SetEntryPoint(to)
+		return
+	}
 	g.edges = append(g.edges, Edge{
 		From: from,
 		To:   to,
 	})
 }
 
+// AddEdgeWithPriority adds an edge like AddEdge, giving its target node a priority
+// within the steps it runs in. Nodes of a step start, when WithParallelBranches
+// limits how many run at once, and have their updates merged in order of
+// decreasing priority, and then in lexical order of name. A node's priority is the
+// highest of the edges to it; nodes only reached by AddEdge have priority 0.
+//
+// Example:
+//
+//	// Merge the cached data first, so the live data overwrites it
+//	g.AddEdgeWithPriority("plan", "fetch_cache", 10)
+//	g.AddEdge("plan", "fetch_live")
+func (g *StateGraph[S]) AddEdgeWithPriority(from, to string, priority int) {
+	if from == START {
+		g.SetEntryPoint(to)
+		return
+	}
+	g.edges = append(g.edges, Edge{
+		From:     from,
+		To:       to,
+		Priority: priority,
+	})
+}
+
+// nodePriorities returns the priority of the nodes targeted by prioritized edges
+func (g *StateGraph[S]) nodePriorities() map[string]int {
+	var priorities map[string]int
+	for _, edge := range g.edges {
+		if edge.Priority == 0 {
+			continue
+		}
+		if priorities == nil {
+			priorities = make(map[string]int)
+		}
+		if current, ok := priorities[edge.To]; !ok || edge.Priority > current {
+			priorities[edge.To] = edge.Priority
+		}
+	}
+	return priorities
+}
+
+// sortStepNodes orders the nodes of a step by decreasing priority, then by name
+func sortStepNodes(nodes []string, priorities map[string]int) {
+	slices.SortFunc(nodes, func(a, b string) int {
+		if c := cmp.Compare(priorities[b], priorities[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+}
+
 // AddConditionalEdge adds a conditional edge where the target node is determined at runtime.
 // The condition function is fully typed - no type assertions needed!
 //
@@ -109,18 +260,75 @@ func (g *StateGraph[S]) AddEdge(from, to string) {
 //	})
 func (g *StateGraph[S]) AddConditionalEdge(from string, condition func(ctx context.Context, state S) string) {
 	g.conditionalEdges[from] = condition
+	delete(g.conditionalPathMaps, from)
+}
+
+// AddConditionalEdgeWithMapping adds a conditional edge whose router returns a logical key
+// instead of a node name. pathMap binds each key to a target node (or END); the targets
+// are validated at Compile time and the keys are used as edge labels by the Exporter.
+// Returning a key that is not in pathMap fails the invocation with ErrUnknownPathKey.
+//
+// Example:
+//
+//	g.AddConditionalEdgeWithMapping("review", func(ctx context.Context, state MyState) string {
+//	    if state.Approved {
+//	        return "approve"
+//	    }
+//	    return "reject"
+//	}, map[string]string{
+//	    "approve": "publish",
+//	    "reject":  "revise",
+//	})
+func (g *StateGraph[S]) AddConditionalEdgeWithMapping(from string, router func(ctx context.Context, state S) string, pathMap map[string]string) {
+	g.conditionalEdges[from] = router
+	g.conditionalPathMaps[from] = maps.Clone(pathMap)
 }
 
 // SetEntryPoint sets the entry point node name for the state graph.
+// A conditional edge from START takes precedence over the entry point.
 func (g *StateGraph[S]) SetEntryPoint(name string) {
 	g.entryPoint = name
 }
 
+// SetConditionalEntryPoint chooses the entry node by evaluating router on the initial
+// state before any node runs, which is the same as a conditional edge from START.
+// router returns node names, or keys of pathMap when one is given. Checkpoints record
+// the chosen entry in their "entry_nodes" metadata, and resumed runs continue from
+// their checkpoint without routing again.
+//
+// Example:
+//
+//	g.SetConditionalEntryPoint(func(ctx context.Context, state MyState) string {
+//	    return state.Mode
+//	}, map[string]string{
+//	    "quick": "quick_node",
+//	    "full":  "full_pipeline",
+//	})
+func (g *StateGraph[S]) SetConditionalEntryPoint(router func(ctx context.Context, state S) string, pathMap map[string]string) {
+	if pathMap == nil {
+		g.AddConditionalEdge(START, router)
+		return
+	}
+	g.AddConditionalEdgeWithMapping(START, router, pathMap)
+}
+
+// hasConditionalEntry reports whether the entry node is chosen by a conditional edge from START
+func (g *StateGraph[S]) hasConditionalEntry() bool {
+	_, ok := g.conditionalEdges[START]
+	return ok
+}
+
 // SetRetryPolicy sets the retry policy for the graph.
 func (g *StateGraph[S]) SetRetryPolicy(policy *RetryPolicy) {
 	g.retryPolicy = policy
 }
 
+// SetNodeRetryPolicy sets the retry policy for a single node.
+// It overrides the graph-level policy set with SetRetryPolicy for that node.
+func (g *StateGraph[S]) SetNodeRetryPolicy(nodeName string, policy NodeRetryPolicy) {
+	g.nodeRetryPolicies[nodeName] = &policy
+}
+
 // SetStateMerger sets the state merger function for the state graph.
 func (g *StateGraph[S]) SetStateMerger(merger TypedStateMerger[S]) {
 	g.stateMerger = merger
@@ -132,24 +340,110 @@ func (g *StateGraph[S]) SetSchema(schema StateSchema[S]) {
 }
 
 // StateRunnable represents a compiled state graph that can be invoked with type safety.
+//
+// A StateRunnable is safe for concurrent use: it holds a snapshot of the graph taken
+// at Compile time, later changes to the graph don't affect it, and every invoke keeps
+// its execution state locally. The initial state is copied first (see WithStateCloner),
+// but states returned by nodes are not, so nodes must not modify values shared with
+// other runs. SetTracer is not safe to call while the runnable is in use.
 type StateRunnable[S any] struct {
-	graph      *StateGraph[S]
-	tracer     *Tracer
-	nodeRunner func(ctx context.Context, nodeName string, state S) (S, error)
+	graph  *StateGraph[S]
+	tracer *Tracer
+
+	// nodeRunner, when set, runs a node; fn is the node function wrapped by the middleware
+	nodeRunner func(ctx context.Context, nodeName string, state S, fn NodeFunc[S]) (S, error)
+
+	// nodeNotifier forwards runnable-level node events (such as retries) to listeners
+	nodeNotifier func(ctx context.Context, event NodeEvent, nodeName string, state S, err error)
+
+	// interruptBefore and interruptAfter are the static breakpoints set at compile time
+	interruptBefore []string
+	interruptAfter  []string
+
+	// middleware is the graph's middleware at compile time
+	middleware []Middleware[S]
+
+	// report holds the warnings found at compile time
+	report *CompileReport
+
+	// stateCloner copies the initial state of every invoke, if set with WithStateCloner
+	stateCloner func(S) S
+
+	// maxSteps and parallelBranches are the limits set with WithMaxSteps and
+	// WithParallelBranches, zero when unlimited
+	maxSteps         int
+	parallelBranches int
+
+	// propagatePanics lets node panics crash the process, set with WithPanicPropagation
+	propagatePanics bool
+
+	// strictResume rejects checkpoints of other graphs, set with WithStrictResume
+	strictResume bool
+
+	// stats holds the counters of every node when compiled with WithStats
+	stats map[string]*nodeCounters
+
+	// listeners are the listeners added with AddListener, nil until the first;
+	// listenersMu guards them and is shared by the copies of the runnable
+	listeners   *ListenableNode[S]
+	listenersMu *sync.Mutex
 }
 
-// Compile compiles the state graph and returns a StateRunnable instance.
-func (g *StateGraph[S]) Compile() (*StateRunnable[S], error) {
-	if g.entryPoint == "" {
-		return nil, ErrEntryPointNotSet
+// Compile validates and compiles the state graph and returns a StateRunnable instance.
+// See Validate for the checks performed; a *ValidationError is returned when they fail.
+// Warnings that don't prevent compilation, such as cycles not declared with MarkCycle,
+// are available from the runnable's CompileReport.
+// See CompileOptions for the available settings.
+func (g *StateGraph[S]) Compile(opts ...CompileOption) (*StateRunnable[S], error) {
+	options := newCompileOptions(opts)
+	if err := g.validate(options); err != nil {
+		return nil, err
+	}
+	stateCloner, err := stateClonerFor[S](options)
+	if err != nil {
+		return nil, err
+	}
+	var stats map[string]*nodeCounters
+	if options.Stats {
+		stats = newNodeStats(g)
 	}
 
 	return &StateRunnable[S]{
-		graph:  g,
-		tracer: nil, // Initialize with no tracer
+		graph:            g.snapshot(),
+		tracer:           nil, // Initialize with no tracer
+		interruptBefore:  slices.Clone(options.InterruptBefore),
+		interruptAfter:   slices.Clone(options.InterruptAfter),
+		middleware:       slices.Clone(g.middleware),
+		report:           g.compileReport(),
+		stateCloner:      stateCloner,
+		maxSteps:         options.MaxSteps,
+		parallelBranches: options.ParallelBranches,
+		propagatePanics:  options.PanicPropagation,
+		strictResume:     options.StrictResume,
+		stats:            stats,
+		listenersMu:      &sync.Mutex{},
 	}, nil
 }
 
+// snapshot returns a copy of the graph whose maps and slices are not shared with g,
+// so that a compiled runnable is not affected by later changes to the graph.
+func (g *StateGraph[S]) snapshot() *StateGraph[S] {
+	snapshot := *g
+	snapshot.nodes = maps.Clone(g.nodes)
+	snapshot.edges = slices.Clone(g.edges)
+	snapshot.conditionalEdges = maps.Clone(g.conditionalEdges)
+	snapshot.conditionalPathMaps = maps.Clone(g.conditionalPathMaps)
+	snapshot.nodeRetryPolicies = maps.Clone(g.nodeRetryPolicies)
+	snapshot.nodeCachePolicies = maps.Clone(g.nodeCachePolicies)
+	snapshot.failFastNodes = maps.Clone(g.failFastNodes)
+	snapshot.errorEdges = maps.Clone(g.errorEdges)
+	snapshot.subgraphs = maps.Clone(g.subgraphs)
+	snapshot.middleware = slices.Clone(g.middleware)
+	snapshot.markedCycles = slices.Clone(g.markedCycles)
+	snapshot.deferredNodes = slices.Clone(g.deferredNodes)
+	return &snapshot
+}
+
 // SetTracer sets a tracer for observability.
 func (r *StateRunnable[S]) SetTracer(tracer *Tracer) {
 	r.tracer = tracer
@@ -163,8 +457,22 @@ func (r *StateRunnable[S]) GetTracer() *Tracer {
 // WithTracer returns a new StateRunnable with the given tracer.
 func (r *StateRunnable[S]) WithTracer(tracer *Tracer) *StateRunnable[S] {
 	return &StateRunnable[S]{
-		graph:  r.graph,
-		tracer: tracer,
+		graph:            r.graph,
+		tracer:           tracer,
+		nodeRunner:       r.nodeRunner,
+		nodeNotifier:     r.nodeNotifier,
+		interruptBefore:  r.interruptBefore,
+		interruptAfter:   r.interruptAfter,
+		middleware:       r.middleware,
+		report:           r.report,
+		stateCloner:      r.stateCloner,
+		maxSteps:         r.maxSteps,
+		parallelBranches: r.parallelBranches,
+		propagatePanics:  r.propagatePanics,
+		strictResume:     r.strictResume,
+		stats:            r.stats,
+		listeners:        r.listeners,
+		listenersMu:      r.listenersMu,
 	}
 }
 
@@ -181,50 +489,88 @@ func (r *StateRunnable[S]) Invoke(ctx context.Context, initialState S) (S, error
 }
 
 // InvokeWithConfig executes the compiled state graph with the given input state and config.
+// Called from a node with a nil config, it runs with the NestedConfig of the node, as
+// a child run of the node.
 func (r *StateRunnable[S]) InvokeWithConfig(ctx context.Context, initialState S, config *Config) (S, error) {
-	state := initialState
-
-	// If schema is defined, merge initialState into schema's initial state
-	if r.graph.Schema != nil {
-		schemaInit := r.graph.Schema.Init()
-		var err error
-		state, err = r.graph.Schema.Update(schemaInit, initialState)
-		if err != nil {
-			var zero S
-			return zero, fmt.Errorf("failed to initialize state with schema: %w", err)
-		}
+	if _, nested := NodeRunIDFromContext(ctx); nested && config == nil {
+		config = NestedConfig(ctx)
+	}
+	// Every run has a config carrying its run ID, so that nodes, middleware and
+	// listeners can read the run's ID, name, tags and metadata from the context
+	config = runConfig(config)
+	ctx = WithConfig(ctx, config)
+	ctx, completed := takeCompletedWrites[S](ctx)
+
+	if len(r.graph.deferredNodes) == 0 {
+		return r.invoke(ctx, initialState, config, nil, completed)
 	}
+	var last S
+	result, err := r.invoke(ctx, initialState, config, &last, completed)
+	return r.runDeferred(ctx, config, last, result, err)
+}
 
-	currentNodes := []string{r.graph.entryPoint}
+// invoke runs the graph for InvokeWithConfig. When last is set, it receives the
+// state of the last completed step, even if the run fails. The nodes of the first
+// step with a result in completed, saved by a previous attempt at the step, don't
+// run again.
+func (r *StateRunnable[S]) invoke(ctx context.Context, initialState S, config *Config, last *S, completed map[string]S) (S, error) {
+	state, err := r.initState(initialState)
+	if err != nil {
+		var zero S
+		return zero, err
+	}
+	if last != nil {
+		*last = state
+	}
 
 	// Handle ResumeFrom
+	var currentNodes, resumedNodes []string
 	if config != nil && len(config.ResumeFrom) > 0 {
 		currentNodes = config.ResumeFrom
+		resumedNodes = config.ResumeFrom
+	} else {
+		if currentNodes, err = r.entryNodes(ctx, state); err != nil {
+			var zero S
+			return zero, err
+		}
+		ctx = withEntryNodes(ctx, currentNodes)
 	}
 
-	// Generate run ID for callbacks
-	runID := generateRunID()
+	interruptBefore, interruptAfter := r.breakpoints(config)
+	runID := config.RunID
+
+	// The resume value is for the nodes that raised the interrupt being resumed,
+	// otherwise the nodes the run resumes from, in this run only. A nested run
+	// resuming its own interrupt takes over the unused value of the node running it.
+	inherited, _ := ctx.Value(resumeValueKey{}).(*resumeValue)
+	handOver := inherited != nil && inherited.value != nil && inherited.appliesTo(ctx) && !inherited.used.Load()
+	ctx = withRunScope(ctx)
+	switch {
+	case config.ResumeValue != nil:
+		nodes := config.resumeNodes
+		if len(nodes) == 0 {
+			nodes = config.ResumeFrom
+		}
+		ctx = withScopedResumeValue(ctx, config.ResumeValue, nodes, new(atomic.Bool))
+	case handOver && len(config.resumeNodes) > 0:
+		ctx = withScopedResumeValue(ctx, inherited.value, config.resumeNodes, inherited.used)
+	}
+	successors := config.resumeSuccessors
 
 	// Notify callbacks of graph start
-	if config != nil {
-		// Inject config into context
-		ctx = WithConfig(ctx, config)
-
-		// Inject ResumeValue
-		if config.ResumeValue != nil {
-			ctx = WithResumeValue(ctx, config.ResumeValue)
+	if len(config.Callbacks) > 0 {
+		serialized := map[string]any{
+			"name": "graph",
+			"type": "chain",
 		}
+		if config.RunName != "" {
+			serialized["name"] = config.RunName
+		}
+		inputs := convertStateToMap(initialState)
+		parentRunID := parentRunIDFromContext(ctx)
 
-		if len(config.Callbacks) > 0 {
-			serialized := map[string]any{
-				"name": "graph",
-				"type": "chain",
-			}
-			inputs := convertStateToMap(initialState)
-
-			for _, cb := range config.Callbacks {
-				cb.OnChainStart(ctx, serialized, inputs, runID, nil, config.Tags, config.Metadata)
-			}
+		for _, cb := range config.Callbacks {
+			cb.OnChainStart(ctx, serialized, inputs, runID, parentRunID, config.Tags, config.Metadata)
 		}
 	}
 
@@ -235,6 +581,13 @@ func (r *StateRunnable[S]) InvokeWithConfig(ctx context.Context, initialState S,
 		graphSpan.State = initialState
 	}
 
+	// handledErr is the failure passed to the recovery node that runs in the next
+	// step, and recovering is the name of that node
+	var handledErr *NodeError
+	var recovering string
+
+	priorities := r.graph.nodePriorities()
+	steps := 0
 	for len(currentNodes) > 0 {
 		// Filter out END nodes
 		activeNodes := make([]string, 0, len(currentNodes))
@@ -243,47 +596,110 @@ func (r *StateRunnable[S]) InvokeWithConfig(ctx context.Context, initialState S,
 				activeNodes = append(activeNodes, node)
 			}
 		}
+		// Nodes of a step run concurrently, but their updates are always merged in
+		// order of priority, then lexical order of node name, so runs are reproducible
+		sortStepNodes(activeNodes, priorities)
 		currentNodes = activeNodes
 
 		if len(currentNodes) == 0 {
 			break
 		}
 
+		// Stop a cancelled run before starting the next step, returning the state of
+		// the last completed step, which checkpointing has already saved
+		if err := ctx.Err(); err != nil {
+			err = fmt.Errorf("run cancelled before node %s: %w", strings.Join(currentNodes, ", "), err)
+			r.notifyChainError(ctx, config, runID, err)
+			return state, err
+		}
+
+		// Stop a run that exceeds the compiled step limit, returning the state so far
+		steps++
+		if r.maxSteps > 0 && steps > r.maxSteps {
+			err := fmt.Errorf("%w: limit of %d reached before running %v", ErrMaxStepsExceeded, r.maxSteps, currentNodes)
+			r.notifyChainError(ctx, config, runID, err)
+			return state, err
+		}
+
 		// Check InterruptBefore
-		if config != nil && len(config.InterruptBefore) > 0 {
-			for _, node := range currentNodes {
-				if slices.Contains(config.InterruptBefore, node) {
-					return state, &GraphInterrupt{Node: node, State: state}
+		for _, node := range currentNodes {
+			if slices.Contains(interruptBefore, node) && !slices.Contains(resumedNodes, node) {
+				return state, &GraphInterrupt{Node: node, State: state}
+			}
+		}
+
+		// Notify callbacks of the step about to run (and save pending checkpoints)
+		if config != nil && len(config.Callbacks) > 0 {
+			for _, cb := range config.Callbacks {
+				if scb, ok := cb.(StepStartCallbackHandler); ok {
+					scb.OnGraphStepStart(ctx, slices.Clone(currentNodes), r.cloneState(state))
 				}
 			}
 		}
 
-		// Execute nodes in parallel
-		results, errorsList := r.executeNodesParallel(ctx, currentNodes, state, config, runID)
+		// Execute nodes in parallel; the error handler sees the failure in its context
+		nodeCtx := ctx
+		if handledErr != nil {
+			nodeCtx = context.WithValue(ctx, nodeErrorKey{}, handledErr)
+			handledErr = nil
+		}
+		results, errorsList := r.executeNodesParallel(nodeCtx, currentNodes, state, config, runID, steps, completed)
+		completed = nil
+
+		// A step whose nodes failed because the run was cancelled is not merged, and
+		// its failures are not routed to error handlers. A step that completed despite
+		// the cancellation is merged and checkpointed, and the run stops before the next.
+		if err := ctx.Err(); err != nil && slices.ContainsFunc(errorsList, func(err error) bool { return err != nil }) {
+			err = fmt.Errorf("run cancelled before merging node %s: %w", strings.Join(currentNodes, ", "), err)
+			r.notifyChainError(ctx, config, runID, err)
+			return state, err
+		}
 
 		// Process results (including results from interrupted nodes)
-		processedResults, nextNodesFromCommands := r.processNodeResults(results)
-
-		// Merge results into state (this preserves state updates from interrupted nodes)
+		processedResults, gotos := r.processNodeResults(results)
+
+		// Merge results into state (this preserves state updates from interrupted
+		// nodes). The results of failed nodes are left out, so their errors are routed
+		// with the state the step started from and the updates of their siblings.
+		mergeResults, mergedNodes := processedResults, currentNodes
+		if slices.ContainsFunc(errorsList, isNodeFailure) {
+			mergeResults, mergedNodes = nil, nil
+			for i, err := range errorsList {
+				if !isNodeFailure(err) {
+					mergeResults = append(mergeResults, processedResults[i])
+					mergedNodes = append(mergedNodes, currentNodes[i])
+				}
+			}
+		}
 		var mergeErr error
-		state, mergeErr = r.mergeState(ctx, state, processedResults)
+		state, mergeErr = r.mergeState(ctx, state, mergeResults, mergedNodes)
 		if mergeErr != nil {
+			r.notifyChainError(ctx, config, runID, mergeErr)
 			var zero S
 			return zero, mergeErr
 		}
+		// The failed nodes were left out of the merge, so this is the last good state
+		// even for a step with errors, which the deferred nodes receive
+		if last != nil {
+			*last = state
+		}
 
 		// Now check for errors after merging state
 		// We check here to determine if we should save checkpoints (for interrupts) or not (for regular errors)
-		var hasNodeInterrupt bool
-		var nodeInterrupt *NodeInterrupt
-		for _, err := range errorsList {
-			if err != nil {
-				if errors.As(err, &nodeInterrupt) {
-					hasNodeInterrupt = true
-					break
-				}
+		var interrupts []*NodeInterrupt
+		var completedNodes []string
+		var completedGotos []any
+		for i, err := range errorsList {
+			var nodeInterrupt *NodeInterrupt
+			switch {
+			case err == nil:
+				completedNodes = append(completedNodes, currentNodes[i])
+				completedGotos = append(completedGotos, gotos[i])
+			case errors.As(err, &nodeInterrupt):
+				interrupts = append(interrupts, nodeInterrupt)
 			}
 		}
+		hasNodeInterrupt := len(interrupts) > 0
 
 		// Keep track of nodes that ran for callbacks and interrupts
 		nodesRan := make([]string, len(currentNodes))
@@ -294,54 +710,85 @@ func (r *StateRunnable[S]) InvokeWithConfig(ctx context.Context, initialState S,
 		// For regular errors: we DON'T want to save checkpoints
 		if config != nil && len(config.Callbacks) > 0 {
 			if hasNodeInterrupt {
-				// Save checkpoint before returning the interrupt
+				// Save checkpoint before returning the interrupt, recording the nodes
+				// that raised it so that resuming re-enters them, and the nodes the
+				// other nodes of the step lead to, which run after them
+				next, err := r.determineNextNodes(ctx, completedNodes, state, completedGotos)
+				if err != nil {
+					r.notifyChainError(ctx, config, runID, err)
+					var zero S
+					return zero, err
+				}
+				stepCtx := withInterrupt(ctx, &stepInterrupt{interrupts: interrupts, successors: mergeNodes(next, successors)})
 				for _, cb := range config.Callbacks {
 					if gcb, ok := cb.(GraphCallbackHandler); ok {
-						var nodeName string
-						if len(nodesRan) == 1 {
-							nodeName = nodesRan[0]
-						} else {
-							nodeName = fmt.Sprintf("step:%v", nodesRan)
-						}
-						gcb.OnGraphStep(ctx, nodeName, state)
+						gcb.OnGraphStep(stepCtx, interrupts[0].Node, state)
 					}
 				}
 			}
 		}
 
 		// Now handle the errors
-		for _, err := range errorsList {
+		recoveryNode := ""
+		for i, err := range errorsList {
 			if err != nil {
-				if hasNodeInterrupt && nodeInterrupt != nil {
+				if hasNodeInterrupt {
 					// Return GraphInterrupt with the merged state
 					// OnGraphStep has already been called, so checkpoint was saved
+					interrupted := make([]string, len(interrupts))
+					for j, interrupt := range interrupts {
+						interrupted[j] = interrupt.Node
+					}
 					return state, &GraphInterrupt{
-						Node:           nodeInterrupt.Node,
+						Node:           interrupts[0].Node,
 						State:          state,
-						InterruptValue: nodeInterrupt.Value,
-						NextNodes:      []string{nodeInterrupt.Node},
+						InterruptValue: interrupts[0].Value,
+						NextNodes:      interrupted,
 					}
 				}
 
+				// Route the failure along its error edge or to the error handler
+				if handlerState, nodeErr, target, ok := r.handleNodeError(state, currentNodes[i], err); ok {
+					state = handlerState
+					handledErr = nodeErr
+					recoveryNode = target
+					break
+				}
+
 				// For regular errors (not interrupts), don't save checkpoint
 				// Notify callbacks of error
-				if config != nil && len(config.Callbacks) > 0 {
-					for _, cb := range config.Callbacks {
-						cb.OnChainError(ctx, err, runID)
-					}
-				}
+				r.notifyChainError(ctx, config, runID, err)
 				var zero S
 				return zero, err
 			}
 		}
 
+		if recoveryNode != "" {
+			currentNodes = []string{recoveryNode}
+			recovering = recoveryNode
+			resumedNodes = nil
+			continue
+		}
+
+		// The recovery node succeeded, so the failure it handled is cleared
+		if recovering != "" {
+			state = clearErrorEdgeState(state)
+			recovering = ""
+		}
+
 		// Determine next nodes
-		nextNodesList, err := r.determineNextNodes(ctx, currentNodes, state, nextNodesFromCommands)
+		nextNodesList, err := r.determineNextNodes(ctx, currentNodes, state, gotos)
 		if err != nil {
+			r.notifyChainError(ctx, config, runID, err)
 			var zero S
 			return zero, err
 		}
 
+		// The nodes scheduled by the other nodes of a resumed interrupted step run
+		// with the nodes the resumed ones lead to
+		nextNodesList = mergeNodes(nextNodesList, successors)
+		successors = nil
+
 		// Update currentNodes
 		currentNodes = nextNodesList
 
@@ -349,29 +796,24 @@ func (r *StateRunnable[S]) InvokeWithConfig(ctx context.Context, initialState S,
 		if config != nil && len(config.Callbacks) > 0 {
 			for _, cb := range config.Callbacks {
 				if gcb, ok := cb.(GraphCallbackHandler); ok {
-					var nodeName string
-					if len(nodesRan) == 1 {
-						nodeName = nodesRan[0]
-					} else {
-						nodeName = fmt.Sprintf("step:%v", nodesRan)
-					}
-					gcb.OnGraphStep(ctx, nodeName, state)
+					gcb.OnGraphStep(withNextNodes(ctx, nextNodesList), stepNodeName(nodesRan), state)
 				}
 			}
 		}
 
 		// Check InterruptAfter
-		if config != nil && len(config.InterruptAfter) > 0 {
-			for _, node := range nodesRan {
-				if slices.Contains(config.InterruptAfter, node) {
-					return state, &GraphInterrupt{
-						Node:      node,
-						State:     state,
-						NextNodes: nextNodesList,
-					}
+		for _, node := range nodesRan {
+			if slices.Contains(interruptAfter, node) && !slices.Contains(resumedNodes, node) {
+				return state, &GraphInterrupt{
+					Node:      node,
+					State:     state,
+					NextNodes: nextNodesList,
 				}
 			}
 		}
+
+		// Breakpoints only skip the resumed nodes in the first step
+		resumedNodes = nil
 	}
 
 	// End graph tracing
@@ -390,8 +832,78 @@ func (r *StateRunnable[S]) InvokeWithConfig(ctx context.Context, initialState S,
 	return state, nil
 }
 
+// entryNodes returns the nodes a run starts with: the entry point, or the target of
+// the conditional edge from START evaluated on the initial state
+func (r *StateRunnable[S]) entryNodes(ctx context.Context, state S) ([]string, error) {
+	if r.graph.hasConditionalEntry() {
+		return r.determineNextNodes(ctx, []string{START}, state, nil)
+	}
+	return []string{r.graph.entryPoint}, nil
+}
+
+// initState copies initialState and merges it into the schema's initial state, if a schema is defined
+func (r *StateRunnable[S]) initState(initialState S) (S, error) {
+	initialState = r.cloneState(initialState)
+	if r.graph.Schema == nil {
+		return initialState, nil
+	}
+
+	state, err := r.graph.Schema.Update(r.graph.Schema.Init(), initialState)
+	if err != nil {
+		var zero S
+		return zero, fmt.Errorf("failed to initialize state with schema: %w", err)
+	}
+
+	// Fill in keys registered with MapSchema.RegisterDefault that are still unset
+	if defaulter, ok := r.graph.Schema.(interface{ ApplyDefaults(S) S }); ok {
+		state = defaulter.ApplyDefaults(state)
+	}
+	return state, nil
+}
+
+// stepNodeName names a step in callbacks and checkpoints: the name of its node, or
+// "step:[a b]" for a step of several nodes
+func stepNodeName(nodes []string) string {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	return fmt.Sprintf("step:%v", nodes)
+}
+
+// cloneState copies the initial state of a run with the cloner set by WithStateCloner,
+// or else deep-copies map and slice states, so concurrent runs with the same input
+// don't share it
+func (r *StateRunnable[S]) cloneState(state S) S {
+	if r.stateCloner != nil {
+		return r.stateCloner(state)
+	}
+	switch v := reflect.ValueOf(&state).Elem(); v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Interface:
+		v.Set(deepCopyValue(v))
+	}
+	return state
+}
+
+// breakpoints returns the effective InterruptBefore and InterruptAfter nodes for a run.
+// A non-nil list in config, even an empty one, overrides the compiled breakpoints.
+func (r *StateRunnable[S]) breakpoints(config *Config) (before []string, after []string) {
+	before, after = r.interruptBefore, r.interruptAfter
+	if config != nil && config.InterruptBefore != nil {
+		before = config.InterruptBefore
+	}
+	if config != nil && config.InterruptAfter != nil {
+		after = config.InterruptAfter
+	}
+	return before, after
+}
+
 // executeNodeWithRetry executes a node with retry logic based on the retry policy.
-func (r *StateRunnable[S]) executeNodeWithRetry(ctx context.Context, node TypedNode[S], state S) (S, error) {
+// A per-node policy takes precedence over the graph-level policy.
+func (r *StateRunnable[S]) executeNodeWithRetry(ctx context.Context, node TypedNode[S], state S, config *Config) (S, error) {
+	if policy, ok := r.graph.nodeRetryPolicies[node.Name]; ok {
+		return r.executeNodeWithPolicy(ctx, node, state, policy, config)
+	}
+
 	var lastErr error
 	var zero S
 
@@ -401,15 +913,7 @@ func (r *StateRunnable[S]) executeNodeWithRetry(ctx context.Context, node TypedN
 	}
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		var result S
-		var err error
-
-		if r.nodeRunner != nil {
-			result, err = r.nodeRunner(ctx, node.Name, state)
-		} else {
-			result, err = node.Function(ctx, state)
-		}
-
+		result, err := r.runNode(withAttempt(ctx, attempt+1), node, state)
 		if err == nil {
 			return result, nil
 		}
@@ -426,6 +930,8 @@ func (r *StateRunnable[S]) executeNodeWithRetry(ctx context.Context, node TypedN
 		// Check if error is retryable
 		if r.graph.retryPolicy != nil && attempt < maxRetries-1 {
 			if r.isRetryableError(err) {
+				r.notifyRetry(ctx, config, node.Name, attempt+1, state, err)
+
 				// Apply backoff strategy
 				delay := r.calculateBackoffDelay(attempt)
 				if delay > 0 {
@@ -448,6 +954,121 @@ func (r *StateRunnable[S]) executeNodeWithRetry(ctx context.Context, node TypedN
 	return zero, lastErr
 }
 
+// executeNodeWithPolicy executes a node, retrying failed attempts according to a per-node policy.
+func (r *StateRunnable[S]) executeNodeWithPolicy(ctx context.Context, node TypedNode[S], state S, policy *NodeRetryPolicy, config *Config) (S, error) {
+	var lastErr error
+	var zero S
+
+	maxAttempts := max(policy.MaxAttempts, 1)
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err := r.runNode(withAttempt(ctx, attempt), node, state)
+		if err == nil {
+			return result, nil
+		}
+
+		// Interrupts are control flow, not failures, so they are never retried
+		var nodeInterrupt *NodeInterrupt
+		if errors.As(err, &nodeInterrupt) {
+			return result, err
+		}
+
+		lastErr = err
+
+		if attempt == maxAttempts || (policy.RetryIf != nil && !policy.RetryIf(err)) {
+			break
+		}
+
+		r.notifyRetry(ctx, config, node.Name, attempt, state, err)
+
+		if policy.Backoff != nil {
+			if delay := policy.Backoff(attempt); delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return zero, ctx.Err()
+				}
+			}
+		}
+	}
+
+	return zero, lastErr
+}
+
+// runNode runs a single attempt of a node through the middleware, using the custom
+// node runner if one is configured.
+func (r *StateRunnable[S]) runNode(ctx context.Context, node TypedNode[S], state S) (result S, err error) {
+	ctx = withNodeInfo(ctx, node)
+	ctx = r.withTokenEmitter(ctx, node.Name, state)
+	ctx = r.withCustomEmitter(ctx, node.Name, state)
+	fn := applyMiddleware(node.Function, r.middleware)
+	if !r.propagatePanics {
+		fn = recoverNodePanic(node.Name, fn)
+	}
+	if counters, ok := r.stats[node.Name]; ok {
+		start := time.Now()
+		defer func() {
+			counters.record(time.Since(start), err)
+		}()
+	}
+	if r.nodeRunner != nil {
+		return r.nodeRunner(ctx, node.Name, state, fn)
+	}
+	return fn(ctx, state)
+}
+
+// recoverNodePanic makes fn fail with a NodePanicError when it panics, so that
+// listeners, retries and error edges handle the panic like any other node error.
+func recoverNodePanic[S any](name string, fn NodeFunc[S]) NodeFunc[S] {
+	return func(ctx context.Context, state S) (result S, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				var zero S
+				result, err = zero, &NodePanicError{Node: name, Value: p, Stack: debug.Stack()}
+			}
+		}()
+		return fn(ctx, state)
+	}
+}
+
+// runConfig returns a copy of config for a single run, with a generated RunID when
+// it has none. The caller's config is not modified.
+func runConfig(config *Config) *Config {
+	run := Config{}
+	if config != nil {
+		run = *config
+	}
+	if run.RunID == "" {
+		run.RunID = generateRunID()
+	}
+	return &run
+}
+
+// notifyChainError reports a failed run to the config's callbacks.
+func (r *StateRunnable[S]) notifyChainError(ctx context.Context, config *Config, runID string, err error) {
+	if config == nil {
+		return
+	}
+	for _, cb := range config.Callbacks {
+		cb.OnChainError(ctx, err, runID)
+	}
+}
+
+// notifyRetry reports a failed attempt that is about to be retried to listeners and callbacks.
+func (r *StateRunnable[S]) notifyRetry(ctx context.Context, config *Config, nodeName string, attempt int, state S, err error) {
+	if r.nodeNotifier != nil {
+		r.nodeNotifier(withAttempt(ctx, attempt), NodeEventRetry, nodeName, state, err)
+	}
+
+	if config != nil {
+		for _, cb := range config.Callbacks {
+			if rcb, ok := cb.(RetryCallbackHandler); ok {
+				rcb.OnNodeRetry(ctx, nodeName, attempt, err)
+			}
+		}
+	}
+}
+
 // isRetryableError checks if an error is retryable based on the retry policy.
 func (r *StateRunnable[S]) isRetryableError(err error) bool {
 	if r.graph.retryPolicy == nil {
@@ -487,11 +1108,26 @@ func (r *StateRunnable[S]) calculateBackoffDelay(attempt int) time.Duration {
 }
 
 // executeNodesParallel executes valid nodes in parallel and returns their results or errors.
-func (r *StateRunnable[S]) executeNodesParallel(ctx context.Context, nodes []string, state S, config *Config, runID string) ([]S, []error) {
+// Nodes with a result in completed return it without running.
+func (r *StateRunnable[S]) executeNodesParallel(ctx context.Context, nodes []string, state S, config *Config, runID string, step int, completed map[string]S) ([]S, []error) {
+	ctx = withStepIndex(ctx, step)
 	var wg sync.WaitGroup
 	results := make([]S, len(nodes))
 	errorsList := make([]error, len(nodes))
 
+	// Trace entries are collected per node and recorded in node order
+	var traceEntries []TraceEntry
+	if config != nil && config.Trace != nil {
+		traceEntries = make([]TraceEntry, len(nodes))
+		defer func() { config.Trace.record(traceEntries) }()
+	}
+
+	// Limit the number of nodes running at once, see WithParallelBranches
+	var slots chan struct{}
+	if r.parallelBranches > 0 {
+		slots = make(chan struct{}, r.parallelBranches)
+	}
+
 	for i, nodeName := range nodes {
 		node, ok := r.graph.nodes[nodeName]
 		if !ok {
@@ -504,7 +1140,33 @@ func (r *StateRunnable[S]) executeNodesParallel(ctx context.Context, nodes []str
 		n := node
 		name := nodeName
 
+		if res, ok := completed[name]; ok {
+			results[idx] = res
+			r.notifyNodeWrite(ctx, config, nodes, name, res)
+			continue
+		}
+
+		// Slots are taken in step order, so nodes waiting for one start in order
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				errorsList[idx] = fmt.Errorf("error in node %s: %w", name, ctx.Err())
+				continue
+			}
+		}
+
 		SafeGo(&wg, func() {
+			if slots != nil {
+				defer func() { <-slots }()
+			}
+
+			// Nodes waiting for a slot or started after a cancellation don't run
+			if err := ctx.Err(); err != nil {
+				errorsList[idx] = fmt.Errorf("run cancelled before node %s: %w", name, err)
+				return
+			}
+
 			// Start node tracing
 			var nodeSpan *TraceSpan
 			if r.tracer != nil {
@@ -515,8 +1177,21 @@ func (r *StateRunnable[S]) executeNodesParallel(ctx context.Context, nodes []str
 			var err error
 			var res S
 
-			// Execute node with retry logic
-			res, err = r.executeNodeWithRetry(ctx, n, state)
+			// Execute node with caching and retry logic. The node's runID, reported
+			// with its result, is the parent of the graphs it invokes.
+			nodeRunID := generateRunID()
+			start := time.Now()
+			res, err = r.executeNodeCached(withNodeRunID(ctx, nodeRunID), n, state, config)
+			if traceEntries != nil {
+				traceEntries[idx] = TraceEntry{
+					Node:           name,
+					Step:           step,
+					Start:          start,
+					Duration:       time.Since(start),
+					Error:          err,
+					StateDeltaKeys: stateDeltaKeys(state, res),
+				}
+			}
 
 			// End node tracing
 			if r.tracer != nil && nodeSpan != nil {
@@ -544,20 +1219,36 @@ func (r *StateRunnable[S]) executeNodesParallel(ctx context.Context, nodes []str
 			}
 
 			results[idx] = res
+			r.notifyNodeWrite(ctx, config, nodes, name, res)
 
 			// Notify callbacks of node execution (as tool)
 			if config != nil && len(config.Callbacks) > 0 {
-				nodeRunID := generateRunID()
 				serialized := map[string]any{
 					"name": name,
 					"type": "tool",
 				}
+				if n.Description != "" {
+					serialized["description"] = n.Description
+				}
+				if len(n.Tags) > 0 {
+					serialized["tags"] = n.Tags
+				}
+				if len(n.Metadata) > 0 {
+					serialized["metadata"] = n.Metadata
+				}
+				if config.RunName != "" {
+					serialized["run_name"] = config.RunName
+				}
+				tags, metadata := nodeCallbackLabels(n, config)
 				for _, cb := range config.Callbacks {
-					cb.OnToolStart(ctx, serialized, convertStateToString(res), nodeRunID, &runID, config.Tags, config.Metadata)
+					cb.OnToolStart(ctx, serialized, convertStateToString(res), nodeRunID, &runID, tags, metadata)
 					cb.OnToolEnd(ctx, convertStateToString(res), nodeRunID)
 				}
 			}
 		}, func(panicVal any) {
+			if r.propagatePanics {
+				panic(panicVal)
+			}
 			errorsList[idx] = fmt.Errorf("panic in node %s: %v", name, panicVal)
 		})
 	}
@@ -565,9 +1256,24 @@ func (r *StateRunnable[S]) executeNodesParallel(ctx context.Context, nodes []str
 	return results, errorsList
 }
 
+// notifyNodeWrite reports the result of a node of a step running several nodes
+// to the NodeWriteCallbackHandler callbacks of config
+func (r *StateRunnable[S]) notifyNodeWrite(ctx context.Context, config *Config, nodes []string, nodeName string, result S) {
+	if config == nil || len(nodes) < 2 {
+		return
+	}
+	for _, cb := range config.Callbacks {
+		if wcb, ok := cb.(NodeWriteCallbackHandler); ok {
+			wcb.OnNodeWrite(ctx, nodeName, result)
+		}
+	}
+}
+
 // processNodeResults processes the raw results from nodes, handling Commands.
-func (r *StateRunnable[S]) processNodeResults(results []S) ([]S, []string) {
-	var nextNodesFromCommands []string
+// It returns the state updates and, for each result, the Command.Goto value
+// (nil when the node did not return a Command or left Goto unset).
+func (r *StateRunnable[S]) processNodeResults(results []S) ([]S, []any) {
+	gotos := make([]any, len(results))
 	processedResults := make([]S, len(results))
 
 	for i, res := range results {
@@ -590,36 +1296,38 @@ func (r *StateRunnable[S]) processNodeResults(results []S) ([]S, []string) {
 				processedResults[i] = zero
 			}
 
-			// Extract Goto to determine next nodes
-			if cmd.Goto != nil {
-				switch g := cmd.Goto.(type) {
-				case string:
-					nextNodesFromCommands = append(nextNodesFromCommands, g)
-				case []string:
-					nextNodesFromCommands = append(nextNodesFromCommands, g...)
-				}
-			}
+			gotos[i] = cmd.Goto
 		} else {
 			// Regular result - not a Command
 			processedResults[i] = res
 		}
 	}
 
-	return processedResults, nextNodesFromCommands
+	return processedResults, gotos
 }
 
-// mergeState merges the processed results into the current state.
-func (r *StateRunnable[S]) mergeState(ctx context.Context, currentState S, results []S) (S, error) {
+// mergeState merges the processed results of nodes into the current state.
+func (r *StateRunnable[S]) mergeState(ctx context.Context, currentState S, results []S, nodes []string) (S, error) {
 	state := currentState
 	if r.graph.Schema != nil {
+		// Schemas with required keys (MapSchema.RegisterRequired) are checked after
+		// each node's update, so the error names the node that dropped the key
+		validator, validates := r.graph.Schema.(interface{ ValidateRequired(S) error })
+
 		// If Schema is defined, use it to update state with results
-		for _, res := range results {
+		for i, res := range results {
 			var err error
 			state, err = r.graph.Schema.Update(state, res)
 			if err != nil {
 				var zero S
 				return zero, fmt.Errorf("schema update failed: %w", err)
 			}
+			if validates && i < len(nodes) {
+				if err := validator.ValidateRequired(state); err != nil {
+					var zero S
+					return zero, fmt.Errorf("after node %s: %w", nodes[i], err)
+				}
+			}
 		}
 	} else if r.graph.stateMerger != nil {
 		var err error
@@ -637,55 +1345,108 @@ func (r *StateRunnable[S]) mergeState(ctx context.Context, currentState S, resul
 }
 
 // determineNextNodes determines the next nodes to execute based on static edges, conditional edges, or commands.
-func (r *StateRunnable[S]) determineNextNodes(ctx context.Context, currentNodes []string, state S, nextNodesFromCommands []string) ([]string, error) {
+// gotos holds the Command.Goto value returned by each of currentNodes; a node that
+// returned a Goto follows it instead of its own edges.
+func (r *StateRunnable[S]) determineNextNodes(ctx context.Context, currentNodes []string, state S, gotos []any) ([]string, error) {
 	var nextNodesList []string
+	seen := make(map[string]bool)
+	add := func(node string) {
+		if !seen[node] {
+			seen[node] = true
+			nextNodesList = append(nextNodesList, node)
+		}
+	}
 
-	if len(nextNodesFromCommands) > 0 {
-		// Command.Goto overrides static edges
-		// We deduplicate
-		seen := make(map[string]bool)
-		for _, n := range nextNodesFromCommands {
-			if !seen[n] && n != END {
-				seen[n] = true
-				nextNodesList = append(nextNodesList, n)
+	for i, nodeName := range currentNodes {
+		if i < len(gotos) && gotos[i] != nil {
+			targets, err := r.commandTargets(nodeName, gotos[i])
+			if err != nil {
+				return nil, err
 			}
-		}
-	} else {
-		// Use static edges
-		nextNodesSet := make(map[string]bool)
-
-		for _, nodeName := range currentNodes {
-			// First check for conditional edges
-			nextNodeFn, hasConditional := r.graph.conditionalEdges[nodeName]
-			if hasConditional {
-				nextNode := nextNodeFn(ctx, state)
-				if nextNode == "" {
-					var zero S
-					_ = zero
-					return nil, fmt.Errorf("conditional edge returned empty next node from %s", nodeName)
-				}
-				nextNodesSet[nextNode] = true
-			} else {
-				// Then check regular edges
-				foundNext := false
-				for _, edge := range r.graph.edges {
-					if edge.From == nodeName {
-						nextNodesSet[edge.To] = true
-						foundNext = true
-						// Do NOT break here, to allow fan-out (multiple edges from same node)
-					}
+			// END terminates this branch; the Update has already been merged
+			for _, target := range targets {
+				if target != END {
+					add(target)
 				}
+			}
+			continue
+		}
 
-				if !foundNext {
-					return nil, fmt.Errorf("%w: %s", ErrNoOutgoingEdge, nodeName)
+		// First check for conditional edges
+		nextNodeFn, hasConditional := r.graph.conditionalEdges[nodeName]
+		if hasConditional {
+			nextNode := nextNodeFn(ctx, state)
+			if pathMap, ok := r.graph.conditionalPathMaps[nodeName]; ok {
+				target, ok := pathMap[nextNode]
+				if !ok {
+					return nil, fmt.Errorf("%w: %q from %s", ErrUnknownPathKey, nextNode, nodeName)
 				}
+				nextNode = target
+			}
+			if nextNode == "" {
+				return nil, fmt.Errorf("conditional edge returned empty next node from %s", nodeName)
 			}
+			add(nextNode)
+			continue
 		}
 
-		// Update nextNodesList from set
-		for node := range nextNodesSet {
-			nextNodesList = append(nextNodesList, node)
+		// Then check regular edges
+		foundNext := false
+		for _, edge := range r.graph.edges {
+			if edge.From == nodeName {
+				add(edge.To)
+				foundNext = true
+				// Do NOT break here, to allow fan-out (multiple edges from same node)
+			}
+		}
+
+		if !foundNext {
+			return nil, fmt.Errorf("%w: %s", ErrNoOutgoingEdge, nodeName)
 		}
 	}
+
+	slices.Sort(nextNodesList)
 	return nextNodesList, nil
 }
+
+// isNodeFailure reports whether err is the failure of a node, as opposed to its
+// success or an interrupt, whose result is merged
+func isNodeFailure(err error) bool {
+	var nodeInterrupt *NodeInterrupt
+	return err != nil && !errors.As(err, &nodeInterrupt)
+}
+
+// mergeNodes returns the sorted union of the node lists a and b
+func mergeNodes(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	merged := slices.Concat(a, b)
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
+
+// commandTargets resolves a Command.Goto value returned by node into node names.
+// Targets are dynamic and can't be checked by Compile, so they are validated here:
+// unknown names are reported as ErrNodeNotFound.
+func (r *StateRunnable[S]) commandTargets(node string, goTo any) ([]string, error) {
+	var targets []string
+	switch g := goTo.(type) {
+	case string:
+		targets = []string{g}
+	case []string:
+		targets = g
+	default:
+		return nil, fmt.Errorf("unsupported Command.Goto type %T from %s", goTo, node)
+	}
+
+	for _, target := range targets {
+		if target == END {
+			continue
+		}
+		if _, ok := r.graph.nodes[target]; !ok {
+			return nil, fmt.Errorf("%w: %q (Command.Goto from %s)", ErrNodeNotFound, target, node)
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+// ErrStateTypeMismatch is returned when a thread is resumed, or a checkpoint loaded,
+// by a graph whose state type differs from the one the checkpoint was saved with.
+var ErrStateTypeMismatch = errors.New("checkpoint state type mismatch")
+
+// stateTypeMetadataKey is the checkpoint metadata naming the Go type of the state
+// of the graph that saved the checkpoint
+const stateTypeMetadataKey = "state_type"
+
+// stateTypeName names S in the state type metadata: the name S was registered
+// under with RegisterType, or its package path and name. Registering the type
+// keeps its checkpoints loadable after it is renamed or moved.
+func stateTypeName[S any]() string {
+	t := reflect.TypeFor[S]()
+	typesMu.RLock()
+	name, ok := typeNames[t]
+	typesMu.RUnlock()
+	if ok {
+		return name
+	}
+	if t.Name() != "" && t.PkgPath() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	return t.String()
+}
+
+// checkStateType returns ErrStateTypeMismatch if checkpoint was saved by a graph
+// with another state type than S. Checkpoints saved without the type are accepted,
+// and so is every checkpoint when S is an interface type.
+func checkStateType[S any](checkpoint *store.Checkpoint) error {
+	saved, _ := checkpoint.Metadata[stateTypeMetadataKey].(string)
+	if saved == "" || reflect.TypeFor[S]().Kind() == reflect.Interface {
+		return nil
+	}
+	if expected := stateTypeName[S](); saved != expected {
+		return fmt.Errorf("%w: checkpoint %s holds a %s state, the graph's state type is %s", ErrStateTypeMismatch, checkpoint.ID, saved, expected)
+	}
+	return nil
+}
+
+// checkpointState returns the state of checkpoint as S, or ErrStateTypeMismatch
+func (cr *CheckpointableRunnable[S]) checkpointState(checkpoint *store.Checkpoint) (S, error) {
+	var zero S
+	if err := checkStateType[S](checkpoint); err != nil {
+		return zero, err
+	}
+	state, ok := checkpoint.State.(S)
+	if !ok {
+		err := fmt.Errorf("%w: checkpoint state has type %T, expected %T", ErrStateTypeMismatch, checkpoint.State, zero)
+		if _, isMap := checkpoint.State.(map[string]any); isMap && cr.config.Serializer == nil {
+			// Stores that persist JSON load states without a serializer as maps
+			err = fmt.Errorf("%w; set CheckpointConfig.Serializer to load %T states from this store", err, zero)
+		}
+		return zero, err
+	}
+	return state, nil
+}
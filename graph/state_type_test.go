@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/store/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedThreadState struct {
+	Steps []string `json:"steps"`
+}
+
+type otherThreadState struct {
+	Steps []string `json:"steps"`
+}
+
+// newTypedThreadRunnable builds a chain a -> b of nodes appending their names to
+// the steps of S, checkpointed in config
+func newTypedThreadRunnable[S any](t *testing.T, config CheckpointConfig, steps func(*S) *[]string) *CheckpointableRunnable[S] {
+	t.Helper()
+	g := NewCheckpointableStateGraphWithConfig[S](config)
+	for _, name := range []string{"a", "b"} {
+		g.AddNode(name, name, func(ctx context.Context, state S) (S, error) {
+			*steps(&state) = append(*steps(&state), name)
+			return state, nil
+		})
+	}
+	g.SetEntryPoint("a")
+	g.AddEdge("a", "b")
+	g.AddEdge("b", END)
+	runnable, err := g.CompileCheckpointable()
+	require.NoError(t, err)
+	return runnable
+}
+
+func typedSteps(s *typedThreadState) *[]string { return &s.Steps }
+func otherSteps(s *otherThreadState) *[]string { return &s.Steps }
+
+func TestStateTypeName(t *testing.T) {
+	assert.Equal(t, "github.com/smallnest/langgraphgo/graph.typedThreadState", stateTypeName[typedThreadState]())
+	assert.Equal(t, "map[string]interface {}", stateTypeName[map[string]any]())
+	assert.Equal(t, "*graph.typedThreadState", stateTypeName[*typedThreadState]())
+
+	type renamedState struct{ Steps []string }
+	require.NoError(t, RegisterType[renamedState]("graph_test.renamed_state"))
+	assert.Equal(t, "graph_test.renamed_state", stateTypeName[renamedState]())
+}
+
+func TestCheckpointStateType(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ResumeStructState", func(t *testing.T) {
+		checkpoints, err := file.NewFileCheckpointStore(t.TempDir())
+		require.NoError(t, err)
+		config := CheckpointConfig{Store: checkpoints, AutoSave: true, Serializer: JSONSerializer}
+
+		runConfig := WithThreadID("thread")
+		runConfig.InterruptAfter = []string{"a"}
+		_, err = newTypedThreadRunnable(t, config, typedSteps).InvokeWithConfig(ctx, typedThreadState{}, runConfig)
+		var interrupt *GraphInterrupt
+		require.ErrorAs(t, err, &interrupt)
+
+		raw, err := checkpoints.GetLatestByThread(ctx, "thread")
+		require.NoError(t, err)
+		assert.Equal(t, stateTypeName[typedThreadState](), raw.Metadata["state_type"])
+
+		// A fresh runnable restores the typed state from the file store
+		result, err := newTypedThreadRunnable(t, config, typedSteps).InvokeCommand(ctx, &Command{}, WithThreadID("thread"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, result.Steps)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		checkpoints := NewMemoryCheckpointStore()
+		_, err := newTypedThreadRunnable(t, CheckpointConfig{Store: checkpoints, AutoSave: true}, typedSteps).
+			InvokeWithConfig(ctx, typedThreadState{}, WithThreadID("thread"))
+		require.NoError(t, err)
+
+		other := newTypedThreadRunnable(t, CheckpointConfig{Store: checkpoints, AutoSave: true}, otherSteps)
+		_, err = other.InvokeWithConfig(ctx, otherThreadState{}, WithThreadID("thread"))
+		assert.ErrorIs(t, err, ErrStateTypeMismatch)
+		assert.ErrorContains(t, err, "holds a github.com/smallnest/langgraphgo/graph.typedThreadState state")
+		_, err = other.ResumeLatest(ctx, "thread")
+		assert.ErrorIs(t, err, ErrStateTypeMismatch)
+
+		// The serializer refuses to decode the state into the other type
+		serialized := NewMemoryCheckpointStore()
+		config := CheckpointConfig{Store: serialized, AutoSave: true, Serializer: JSONSerializer}
+		_, err = newTypedThreadRunnable(t, config, typedSteps).InvokeWithConfig(ctx, typedThreadState{}, WithThreadID("thread"))
+		require.NoError(t, err)
+		_, err = newTypedThreadRunnable(t, config, otherSteps).InvokeWithConfig(ctx, otherThreadState{}, WithThreadID("thread"))
+		assert.ErrorIs(t, err, ErrStateTypeMismatch)
+	})
+
+	t.Run("JSONStoreWithoutSerializer", func(t *testing.T) {
+		checkpoints, err := file.NewFileCheckpointStore(t.TempDir())
+		require.NoError(t, err)
+		config := CheckpointConfig{Store: checkpoints, AutoSave: true}
+		_, err = newTypedThreadRunnable(t, config, typedSteps).InvokeWithConfig(ctx, typedThreadState{}, WithThreadID("thread"))
+		require.NoError(t, err)
+
+		_, err = newTypedThreadRunnable(t, config, typedSteps).InvokeWithConfig(ctx, typedThreadState{}, WithThreadID("thread"))
+		assert.ErrorIs(t, err, ErrStateTypeMismatch)
+		assert.ErrorContains(t, err, "checkpoint state has type map[string]interface {}, expected graph.typedThreadState")
+		assert.ErrorContains(t, err, "set CheckpointConfig.Serializer")
+	})
+}
@@ -0,0 +1,163 @@
+package graph
+
+import (
+	"errors"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// NodeStats are the cumulative execution statistics of a node, see WithStats.
+// Every attempt of a node counts as an invocation, including retries.
+type NodeStats struct {
+	// Invocations is the number of times the node ran, and Errors how many of them
+	// failed. Interrupts are not counted as errors.
+	Invocations int64
+	Errors      int64
+
+	// TotalDuration is the time spent in the node over all invocations, and
+	// MaxDuration the longest invocation
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+
+	// P50 and P95 are the median and 95th percentile durations, rounded up by
+	// at most 25%
+	P50 time.Duration
+	P95 time.Duration
+}
+
+// WithStats makes the runnable collect NodeStats for its nodes, read with Stats.
+func WithStats() CompileOption {
+	return func(o *CompileOptions) {
+		o.Stats = true
+	}
+}
+
+// Stats returns a snapshot of the statistics of every node, keyed by node name, since
+// the runnable was compiled. It returns nil unless the graph was compiled with
+// WithStats. The counters are shared by concurrent invokes and by copies of the
+// runnable, such as those returned by WithTracer.
+//
+// Example:
+//
+//	for name, stats := range runnable.Stats() {
+//	    fmt.Printf("%s: %d runs, %d errors, p95 %v\n", name, stats.Invocations, stats.Errors, stats.P95)
+//	}
+func (r *StateRunnable[S]) Stats() map[string]NodeStats {
+	if r.stats == nil {
+		return nil
+	}
+	snapshot := make(map[string]NodeStats, len(r.stats))
+	for name, counters := range r.stats {
+		snapshot[name] = counters.snapshot()
+	}
+	return snapshot
+}
+
+// Stats returns a snapshot of the statistics of every node; see StateRunnable.Stats.
+func (lr *ListenableRunnable[S]) Stats() map[string]NodeStats {
+	return lr.runnable.Stats()
+}
+
+// Stats returns a snapshot of the statistics of every node; see StateRunnable.Stats.
+func (cr *CheckpointableRunnable[S]) Stats() map[string]NodeStats {
+	return cr.runnable.Stats()
+}
+
+// newNodeStats returns the counters of the nodes and deferred nodes of g
+func newNodeStats[S any](g *StateGraph[S]) map[string]*nodeCounters {
+	stats := make(map[string]*nodeCounters, len(g.nodes)+len(g.deferredNodes))
+	for name := range g.nodes {
+		stats[name] = &nodeCounters{}
+	}
+	for _, node := range g.deferredNodes {
+		stats[node.Name] = &nodeCounters{}
+	}
+	return stats
+}
+
+// durationBuckets is the number of buckets of the duration histogram: four per
+// power of two of nanoseconds
+const durationBuckets = 64 * 4
+
+// nodeCounters holds the statistics of a node, updated atomically
+type nodeCounters struct {
+	invocations atomic.Int64
+	errors      atomic.Int64
+	total       atomic.Int64
+	max         atomic.Int64
+	histogram   [durationBuckets]atomic.Int64
+}
+
+// record adds an invocation that took d and returned err
+func (c *nodeCounters) record(d time.Duration, err error) {
+	c.invocations.Add(1)
+	var nodeInterrupt *NodeInterrupt
+	if err != nil && !errors.As(err, &nodeInterrupt) {
+		c.errors.Add(1)
+	}
+	c.total.Add(int64(d))
+	for current := c.max.Load(); int64(d) > current; current = c.max.Load() {
+		if c.max.CompareAndSwap(current, int64(d)) {
+			break
+		}
+	}
+	c.histogram[durationBucket(d)].Add(1)
+}
+
+func (c *nodeCounters) snapshot() NodeStats {
+	stats := NodeStats{
+		Invocations:   c.invocations.Load(),
+		Errors:        c.errors.Load(),
+		TotalDuration: time.Duration(c.total.Load()),
+		MaxDuration:   time.Duration(c.max.Load()),
+	}
+
+	var histogram [durationBuckets]int64
+	var count int64
+	for i := range c.histogram {
+		histogram[i] = c.histogram[i].Load()
+		count += histogram[i]
+	}
+	stats.P50 = min(percentile(histogram, count, 0.50), stats.MaxDuration)
+	stats.P95 = min(percentile(histogram, count, 0.95), stats.MaxDuration)
+	return stats
+}
+
+// percentile returns the upper bound of the bucket holding the p quantile of the
+// count durations of histogram
+func percentile(histogram [durationBuckets]int64, count int64, p float64) time.Duration {
+	if count == 0 {
+		return 0
+	}
+	rank := max(int64(p*float64(count)+0.5), 1)
+	var seen int64
+	for i, n := range histogram {
+		seen += n
+		if seen >= rank {
+			return bucketUpperBound(i)
+		}
+	}
+	return bucketUpperBound(durationBuckets - 1)
+}
+
+// durationBucket returns the histogram bucket of d. Durations under 4ns have a
+// bucket each; longer ones are bucketed by their highest bit and the two bits after it.
+func durationBucket(d time.Duration) int {
+	n := uint64(max(d, 0))
+	if n < 4 {
+		return int(n)
+	}
+	exp := bits.Len64(n) - 1
+	sub := (n >> (exp - 2)) & 3
+	return exp*4 + int(sub)
+}
+
+// bucketUpperBound returns the longest duration of bucket i
+func bucketUpperBound(i int) time.Duration {
+	if i < 4 {
+		return time.Duration(i)
+	}
+	exp, sub := i/4, uint64(i%4)
+	return time.Duration((4+sub+1)<<(exp-2) - 1)
+}
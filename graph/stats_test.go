@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	g := NewStateGraph[int]()
+	g.AddNode("fetch", "fetch", func(ctx context.Context, state int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return state, nil
+	})
+	g.AddNode("check", "check", func(ctx context.Context, state int) (int, error) {
+		if state%5 == 0 {
+			return state, errors.New("invalid input")
+		}
+		return state, nil
+	})
+	g.SetEntryPoint("fetch")
+	g.AddEdge("fetch", "check")
+	g.AddEdge("check", END)
+
+	plain, err := g.Compile()
+	require.NoError(t, err)
+	assert.Nil(t, plain.Stats(), "stats are opt-in")
+
+	runnable, err := g.Compile(WithStats())
+	require.NoError(t, err)
+
+	invoke := func(from, to int) {
+		var wg sync.WaitGroup
+		for i := from; i < to; i++ {
+			wg.Go(func() {
+				_, _ = runnable.Invoke(context.Background(), i)
+			})
+		}
+		wg.Wait()
+	}
+
+	invoke(0, 25)
+	first := runnable.Stats()
+	invoke(25, 50)
+	stats := runnable.Stats()
+
+	assert.Equal(t, int64(50), stats["fetch"].Invocations)
+	assert.Zero(t, stats["fetch"].Errors)
+	assert.Equal(t, int64(50), stats["check"].Invocations)
+	assert.Equal(t, int64(10), stats["check"].Errors)
+
+	fetch := stats["fetch"]
+	assert.GreaterOrEqual(t, fetch.TotalDuration, 50*time.Millisecond)
+	assert.GreaterOrEqual(t, fetch.P50, time.Millisecond)
+	assert.LessOrEqual(t, fetch.P50, fetch.P95)
+	assert.LessOrEqual(t, fetch.P95, fetch.MaxDuration)
+
+	// Durations only grow as the runnable is used
+	assert.Equal(t, int64(25), first["fetch"].Invocations)
+	assert.Less(t, first["fetch"].TotalDuration, fetch.TotalDuration)
+	assert.LessOrEqual(t, first["fetch"].MaxDuration, fetch.MaxDuration)
+
+	// Copies of the runnable share its counters
+	_, err = runnable.WithTracer(NewTracer()).Invoke(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(51), runnable.Stats()["fetch"].Invocations)
+}
+
+func TestStatsCountsRetries(t *testing.T) {
+	attempts := 0
+	g := NewStateGraph[int]()
+	g.AddNodeWithOptions("call", "call", func(ctx context.Context, state int) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return state, errors.New("transient")
+		}
+		return state, nil
+	}, WithRetry(NodeRetryPolicy{MaxAttempts: 3}))
+	g.SetEntryPoint("call")
+	g.AddEdge("call", END)
+
+	runnable, err := g.Compile(WithStats())
+	require.NoError(t, err)
+	_, err = runnable.Invoke(context.Background(), 0)
+	require.NoError(t, err)
+
+	stats := runnable.Stats()["call"]
+	assert.Equal(t, int64(3), stats.Invocations)
+	assert.Equal(t, int64(2), stats.Errors)
+}
+
+func TestDurationBuckets(t *testing.T) {
+	for _, d := range []time.Duration{0, 1, 3, 4, 7, 8, 100, time.Microsecond, 1500 * time.Millisecond, time.Hour} {
+		upper := bucketUpperBound(durationBucket(d))
+		assert.GreaterOrEqual(t, upper, d)
+		assert.LessOrEqual(t, float64(upper), float64(d)*1.25+1, d)
+	}
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"context"
+	"maps"
 	"sync"
 	"time"
 )
@@ -124,7 +125,7 @@ func (sl *StreamingListener[S]) shouldEmit(event StreamEvent[S]) bool {
 	case StreamModeMessages:
 		// Emit LLM events - this is tricky because generic S doesn't imply LLM events
 		// But if the event metadata says it's LLM...
-		return event.Event == EventLLMEnd || event.Event == EventLLMStart
+		return event.Event == EventLLMEnd || event.Event == EventLLMStart || event.Event == EventToken
 	default:
 		return true
 	}
@@ -132,14 +133,14 @@ func (sl *StreamingListener[S]) shouldEmit(event StreamEvent[S]) bool {
 
 // OnNodeEvent implements the NodeListener interface
 func (sl *StreamingListener[S]) OnNodeEvent(ctx context.Context, event NodeEvent, nodeName string, state S, err error) {
-	streamEvent := StreamEvent[S]{
-		Timestamp: time.Now(),
-		NodeName:  nodeName,
-		Event:     event,
-		State:     state,
-		Error:     err,
-		Metadata:  make(map[string]any),
-	}
+	sl.emitEvent(*newStreamEvent(ctx, event, nodeName, state, err))
+}
+
+// OnEvent implements the EventListener interface, streaming the duration, step
+// index and metadata of node events
+func (sl *StreamingListener[S]) OnEvent(ctx context.Context, event *StreamEvent[S]) {
+	streamEvent := *event
+	streamEvent.Metadata = maps.Clone(event.Metadata)
 	sl.emitEvent(streamEvent)
 }
 
@@ -272,8 +273,8 @@ func NewStreamingStateGraphWithConfig[S any](config StreamConfig) *StreamingStat
 }
 
 // CompileStreaming compiles the graph into a streaming runnable
-func (g *StreamingStateGraph[S]) CompileStreaming() (*StreamingRunnable[S], error) {
-	listenableRunnable, err := g.CompileListenable()
+func (g *StreamingStateGraph[S]) CompileStreaming(opts ...CompileOption) (*StreamingRunnable[S], error) {
+	listenableRunnable, err := g.CompileListenable(opts...)
 	if err != nil {
 		return nil, err
 	}
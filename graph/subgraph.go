@@ -57,9 +57,25 @@ func AddSubgraph[S, SubS any](g *StateGraph[S], name string, subgraph *StateGrap
 	}
 
 	g.AddNode(name, "Subgraph: "+name, wrappedFn)
+	g.subgraphs[name] = subgraph
 	return nil
 }
 
+// AddRunnableNode adds a node that invokes runnable, a compiled graph with the same
+// state type, such as an agent created by the prebuilt package. Like AddSubgraph, the
+// runnable's graph is drawn as a subgraph by the Exporter. The runnable is invoked
+// with the NestedConfig of the node, so callbacks see its run as a child of the node.
+//
+// Example:
+//
+//	g.AddRunnableNode("researcher", "Agent: researcher", researchAgent)
+func (g *StateGraph[S]) AddRunnableNode(name string, description string, runnable *StateRunnable[S]) {
+	g.AddNode(name, description, func(ctx context.Context, state S) (S, error) {
+		return runnable.InvokeWithConfig(ctx, state, NestedConfig(ctx))
+	})
+	g.subgraphs[name] = runnable.graph
+}
+
 // CreateSubgraph creates and adds a subgraph using a builder function
 func CreateSubgraph[S, SubS any](g *StateGraph[S], name string, builder func(*StateGraph[SubS]) error, converter func(S) SubS, resultConverter func(SubS) S) error {
 	subgraph := NewStateGraph[SubS]()
@@ -120,7 +136,8 @@ func (cg *CompositeGraph[S]) Compile() (*StateRunnable[S], error) {
 		}
 	}
 
-	return cg.main.Compile()
+	// Bridge nodes created by Connect are wired by the caller, so they may not have edges yet
+	return cg.main.Compile(WithAllowDeadEnds())
 }
 
 // RecursiveSubgraph allows a subgraph to call itself recursively
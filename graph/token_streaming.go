@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+type tokenEmitterKey struct{}
+
+type tokenKey struct{}
+
+// tokenEmitter reports a streamed chunk for the node whose context holds it
+type tokenEmitter func(chunk string)
+
+// StreamingFunc returns a call option streaming the chunks an LLM call generates
+// as EventToken events of the node running on ctx. Node functions pass it to
+// GenerateContent with the context they received:
+//
+//	resp, err := model.GenerateContent(ctx, messages, graph.StreamingFunc(ctx))
+//
+// Listeners receive each chunk with TokenFromContext, streams in the Metadata
+// "token" key of the event, and TokenCallbackHandler callbacks with OnToken.
+// Outside of a graph run the chunks are dropped.
+func StreamingFunc(ctx context.Context) llms.CallOption {
+	return llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		EmitToken(ctx, string(chunk))
+		return nil
+	})
+}
+
+// EmitToken reports chunk as a token of the node running on ctx, for node
+// functions that stream text without StreamingFunc. It does nothing outside of
+// a graph run.
+func EmitToken(ctx context.Context, chunk string) {
+	if emit, ok := ctx.Value(tokenEmitterKey{}).(tokenEmitter); ok && emit != nil {
+		emit(chunk)
+	}
+}
+
+// TokenFromContext returns the chunk of an EventToken event. It is set in the
+// context passed to node listeners for that event.
+func TokenFromContext(ctx context.Context) (string, bool) {
+	chunk, ok := ctx.Value(tokenKey{}).(string)
+	return chunk, ok
+}
+
+// withTokenEmitter makes the tokens node streams on ctx reach the listeners of
+// the runnable and the TokenCallbackHandler callbacks of the run
+func (r *StateRunnable[S]) withTokenEmitter(ctx context.Context, nodeName string, state S) context.Context {
+	config, _ := ConfigFromContext(ctx)
+	var handlers []TokenCallbackHandler
+	if config != nil {
+		for _, cb := range config.Callbacks {
+			if tcb, ok := cb.(TokenCallbackHandler); ok {
+				handlers = append(handlers, tcb)
+			}
+		}
+	}
+	if r.nodeNotifier == nil && len(handlers) == 0 {
+		return ctx
+	}
+
+	nodeCtx := ctx
+	return context.WithValue(ctx, tokenEmitterKey{}, tokenEmitter(func(chunk string) {
+		if r.nodeNotifier != nil {
+			r.nodeNotifier(context.WithValue(nodeCtx, tokenKey{}, chunk), EventToken, nodeName, state, nil)
+		}
+		for _, tcb := range handlers {
+			tcb.OnToken(nodeCtx, nodeName, chunk)
+		}
+	}))
+}
@@ -0,0 +1,98 @@
+package graph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// chunkedModel is a fake model streaming its answer in fixed chunks
+type chunkedModel struct {
+	chunks []string
+}
+
+func (m *chunkedModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	var content string
+	for _, chunk := range m.chunks {
+		content += chunk
+		if opts.StreamingFunc != nil {
+			if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: content}}}, nil
+}
+
+func (m *chunkedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func newChatGraph(model llms.Model) *graph.ListenableStateGraph[map[string]any] {
+	g := graph.NewListenableStateGraph[map[string]any]()
+	g.AddNode("chat", "chat", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		resp, err := model.GenerateContent(ctx, nil, graph.StreamingFunc(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"answer": resp.Choices[0].Content}, nil
+	})
+	g.AddEdge("chat", graph.END)
+	g.SetEntryPoint("chat")
+	return g
+}
+
+func TestStreamingFunc(t *testing.T) {
+	t.Parallel()
+	model := &chunkedModel{chunks: []string{"Hel", "lo", " world"}}
+
+	t.Run("Stream", func(t *testing.T) {
+		t.Parallel()
+		runnable, err := newChatGraph(model).CompileListenable()
+		require.NoError(t, err)
+
+		var tokens []string
+		for event := range runnable.Stream(context.Background(), map[string]any{}) {
+			if event.Event == graph.EventToken {
+				assert.Equal(t, "chat", event.NodeName)
+				tokens = append(tokens, event.Metadata["token"].(string))
+			}
+		}
+		assert.Equal(t, model.chunks, tokens)
+	})
+
+	t.Run("Listener", func(t *testing.T) {
+		t.Parallel()
+		g := newChatGraph(model)
+		var tokens []string
+		g.AddGlobalListener(graph.NodeListenerFunc[map[string]any](func(ctx context.Context, event graph.NodeEvent, nodeName string, _ map[string]any, _ error) {
+			if chunk, ok := graph.TokenFromContext(ctx); ok {
+				assert.Equal(t, graph.EventToken, event)
+				tokens = append(tokens, nodeName+":"+chunk)
+			}
+		}))
+		runnable, err := g.CompileListenable()
+		require.NoError(t, err)
+
+		result, err := runnable.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "Hello world", result["answer"])
+		assert.Equal(t, []string{"chat:Hel", "chat:lo", "chat: world"}, tokens)
+	})
+
+	t.Run("OutsideRun", func(t *testing.T) {
+		t.Parallel()
+		// Without a graph run the chunks are dropped
+		resp, err := model.GenerateContent(context.Background(), nil, graph.StreamingFunc(context.Background()))
+		require.NoError(t, err)
+		assert.Equal(t, "Hello world", resp.Choices[0].Content)
+	})
+}
@@ -2,6 +2,9 @@ package graph
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -81,8 +84,10 @@ func (f TraceHookFunc) OnEvent(ctx context.Context, span *TraceSpan) {
 	f(ctx, span)
 }
 
-// Tracer manages trace collection and hooks
+// Tracer manages trace collection and hooks. It is safe for concurrent use, so one
+// tracer can follow parallel nodes and concurrent runs.
 type Tracer struct {
+	mutex sync.RWMutex
 	hooks []TraceHook
 	spans map[string]*TraceSpan
 }
@@ -97,6 +102,8 @@ func NewTracer() *Tracer {
 
 // AddHook registers a new trace hook
 func (t *Tracer) AddHook(hook TraceHook) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.hooks = append(t.hooks, hook)
 }
 
@@ -115,12 +122,8 @@ func (t *Tracer) StartSpan(ctx context.Context, event TraceEvent, nodeName strin
 		span.ParentID = parentSpan.ID
 	}
 
-	t.spans[span.ID] = span
-
-	// Notify hooks
-	for _, hook := range t.hooks {
-		hook.OnEvent(ctx, span)
-	}
+	t.record(span)
+	t.notify(ctx, span)
 
 	return span
 }
@@ -141,10 +144,7 @@ func (t *Tracer) EndSpan(ctx context.Context, span *TraceSpan, state any, err er
 		span.Event = TraceEventGraphEnd
 	}
 
-	// Notify hooks
-	for _, hook := range t.hooks {
-		hook.OnEvent(ctx, span)
-	}
+	t.notify(ctx, span)
 }
 
 // TraceEdgeTraversal records an edge traversal event
@@ -165,21 +165,39 @@ func (t *Tracer) TraceEdgeTraversal(ctx context.Context, fromNode, toNode string
 		span.ParentID = parentSpan.ID
 	}
 
+	t.record(span)
+	t.notify(ctx, span)
+}
+
+// record adds span to the collected spans
+func (t *Tracer) record(span *TraceSpan) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.spans[span.ID] = span
+}
+
+// notify passes span to the registered hooks
+func (t *Tracer) notify(ctx context.Context, span *TraceSpan) {
+	t.mutex.RLock()
+	hooks := slices.Clone(t.hooks)
+	t.mutex.RUnlock()
 
-	// Notify hooks
-	for _, hook := range t.hooks {
+	for _, hook := range hooks {
 		hook.OnEvent(ctx, span)
 	}
 }
 
-// GetSpans returns all collected spans
+// GetSpans returns a copy of the collected spans
 func (t *Tracer) GetSpans() map[string]*TraceSpan {
-	return t.spans
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return maps.Clone(t.spans)
 }
 
 // Clear removes all collected spans
 func (t *Tracer) Clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.spans = make(map[string]*TraceSpan)
 }
 
@@ -64,6 +64,74 @@ func GetConfig(ctx context.Context) *Config {
 	return nil
 }
 
+// ConfigFromContext returns the config of the current invocation. It is set in the
+// context passed to nodes, middleware, listeners and callbacks, with the run's
+// RunID, RunName, Tags and Metadata, and is an empty config with a generated RunID
+// when the invocation had no config.
+func ConfigFromContext(ctx context.Context) (*Config, bool) {
+	config, ok := ctx.Value(configKey{}).(*Config)
+	return config, ok && config != nil
+}
+
+// RunIDFromContext returns the RunID of the current invocation, which callbacks
+// receive with its chain events. It is generated for every invocation whose
+// config has none.
+func RunIDFromContext(ctx context.Context) (string, bool) {
+	config, ok := ConfigFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return config.RunID, true
+}
+
+// NestedConfig returns the config to invoke a graph with from a node, so the
+// nested run is reported to the callbacks of the current run as a child of the
+// node: it has the Callbacks, Tags, Metadata and Configurable of the current run,
+// and no RunID, so that one is generated. It returns nil outside of a run.
+//
+// Graphs invoked from a node without a config use it, so it is only needed to
+// change the config of the nested run.
+//
+// Example:
+//
+//	g.AddNode("research", "research", func(ctx context.Context, state S) (S, error) {
+//	    config := graph.NestedConfig(ctx)
+//	    config.RunName = "research"
+//	    return researcher.InvokeWithConfig(ctx, state, config)
+//	})
+func NestedConfig(ctx context.Context) *Config {
+	config, ok := ConfigFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return &Config{
+		Callbacks:    config.Callbacks,
+		Tags:         config.Tags,
+		Metadata:     config.Metadata,
+		Configurable: config.Configurable,
+	}
+}
+
+// ConfigValue returns the value stored under key in Config.Configurable of the
+// current invocation. It reports false when there is no config, the key is not
+// set, or its value is not a T.
+//
+// Example:
+//
+//	userID, ok := graph.ConfigValue[string](ctx, "user_id")
+func ConfigValue[T any](ctx context.Context, key string) (T, bool) {
+	var zero T
+	config, ok := ConfigFromContext(ctx)
+	if !ok {
+		return zero, false
+	}
+	value, ok := config.Configurable[key].(T)
+	if !ok {
+		return zero, false
+	}
+	return value, true
+}
+
 // SafeGo runs a function in a goroutine with panic recovery.
 // It uses a WaitGroup (if provided) and supports a custom panic handler.
 func SafeGo(wg *sync.WaitGroup, fn func(), onPanic func(any)) {
@@ -0,0 +1,242 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrUnreachableNode is reported when a node cannot be reached from the entry point.
+var ErrUnreachableNode = errors.New("unreachable node")
+
+// ErrUnmarkedCycle is reported with WithStrictValidation for a cycle that was not
+// declared with MarkCycle.
+var ErrUnmarkedCycle = errors.New("cycle not declared with MarkCycle")
+
+// ValidationIssue describes a single problem found while validating a graph.
+type ValidationIssue struct {
+	// Err is the kind of issue: ErrNodeNotFound, ErrUnreachableNode, ErrNoOutgoingEdge,
+	// ErrDuplicateNode, ErrUnboundRouter or ErrUnmarkedCycle
+	Err error
+
+	// Node is the offending node (for missing nodes, the name that was referenced;
+	// for cycles, the comma-separated nodes of the cycle)
+	Node string
+
+	// Edge is the offending edge, if the issue was found on an edge
+	Edge *Edge
+}
+
+// Error implements the error interface.
+func (i ValidationIssue) Error() string {
+	if i.Edge != nil {
+		return fmt.Sprintf("%v: %s (edge %s -> %s)", i.Err, i.Node, i.Edge.From, i.Edge.To)
+	}
+	return fmt.Sprintf("%v: %s", i.Err, i.Node)
+}
+
+// Unwrap returns the kind of issue so errors.Is works on individual issues.
+func (i ValidationIssue) Unwrap() error {
+	return i.Err
+}
+
+// ValidationError is returned by Validate and Compile when the graph structure is invalid.
+// It lists every issue found, so errors.Is(err, ErrNoOutgoingEdge) reports whether any
+// dead end was found.
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.Error()
+	}
+	return fmt.Sprintf("invalid graph: %s", strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual issues.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Issues))
+	for i, issue := range e.Issues {
+		errs[i] = issue
+	}
+	return errs
+}
+
+// Validate checks the graph structure without compiling it. It reports edges that
+// reference unknown nodes, nodes unreachable from the entry point, and nodes that
+// have no outgoing static or conditional edge. When the state type can hold a
+// *Command (for example StateGraph[any]), nodes may route dynamically and only
+// edge references are checked. With WithStrictValidation, cycles not declared with
+// MarkCycle are reported too. The returned error is a *ValidationError listing
+// every issue, or ErrEntryPointNotSet.
+func (g *StateGraph[S]) Validate(opts ...CompileOption) error {
+	return g.validate(newCompileOptions(opts))
+}
+
+func (g *StateGraph[S]) validate(options *CompileOptions) error {
+	if g.entryPoint == "" && !g.hasConditionalEntry() {
+		return ErrEntryPointNotSet
+	}
+
+	var issues []ValidationIssue
+
+	if _, ok := g.nodes[g.entryPoint]; !ok && g.entryPoint != "" {
+		issues = append(issues, ValidationIssue{Err: ErrNodeNotFound, Node: g.entryPoint})
+	}
+
+	if g.errorHandler != "" {
+		if _, ok := g.nodes[g.errorHandler]; !ok {
+			issues = append(issues, ValidationIssue{Err: ErrNodeNotFound, Node: g.errorHandler})
+		}
+	}
+
+	for _, from := range sortedKeys(g.errorEdges) {
+		edge := Edge{From: from, To: g.errorEdges[from]}
+		if _, ok := g.nodes[edge.From]; !ok {
+			issues = append(issues, ValidationIssue{Err: ErrNodeNotFound, Node: edge.From, Edge: &edge})
+		}
+		if _, ok := g.nodes[edge.To]; !ok {
+			issues = append(issues, ValidationIssue{Err: ErrNodeNotFound, Node: edge.To, Edge: &edge})
+		}
+	}
+
+	for _, node := range g.deferredNodes {
+		if _, ok := g.nodes[node.Name]; ok {
+			issues = append(issues, ValidationIssue{Err: ErrDuplicateNode, Node: node.Name})
+		}
+	}
+
+	for _, nodes := range g.markedCycles {
+		for _, name := range nodes {
+			if _, ok := g.nodes[name]; !ok {
+				issues = append(issues, ValidationIssue{Err: ErrNodeNotFound, Node: name})
+			}
+		}
+	}
+
+	if options.StrictValidation {
+		for _, cycle := range g.unmarkedCycles() {
+			issues = append(issues, ValidationIssue{Err: ErrUnmarkedCycle, Node: strings.Join(cycle, ", ")})
+		}
+	}
+
+	outgoing := make(map[string][]string)
+	for i := range g.edges {
+		edge := g.edges[i]
+		if _, ok := g.nodes[edge.From]; !ok {
+			issues = append(issues, ValidationIssue{Err: ErrNodeNotFound, Node: edge.From, Edge: &edge})
+		}
+		if _, ok := g.nodes[edge.To]; !ok && edge.To != END {
+			issues = append(issues, ValidationIssue{Err: ErrNodeNotFound, Node: edge.To, Edge: &edge})
+		}
+		outgoing[edge.From] = append(outgoing[edge.From], edge.To)
+	}
+
+	for _, from := range sortedKeys(g.conditionalEdges) {
+		if _, ok := g.nodes[from]; !ok && from != START {
+			issues = append(issues, ValidationIssue{Err: ErrNodeNotFound, Node: from})
+		}
+		if g.conditionalEdges[from] == nil {
+			issues = append(issues, ValidationIssue{Err: ErrUnboundRouter, Node: from})
+		}
+
+		pathMap := g.conditionalPathMaps[from]
+		for _, key := range sortedKeys(pathMap) {
+			edge := Edge{From: from, To: pathMap[key]}
+			if _, ok := g.nodes[edge.To]; !ok && edge.To != END {
+				issues = append(issues, ValidationIssue{Err: ErrNodeNotFound, Node: edge.To, Edge: &edge})
+			}
+		}
+	}
+
+	// Nodes can route with a Command when the state type can hold one, which makes
+	// the graph's shape dynamic; only edge references can be checked in that case.
+	if g.routesWithCommands() {
+		if len(issues) > 0 {
+			return &ValidationError{Issues: issues}
+		}
+		return nil
+	}
+
+	nodeNames := sortedKeys(g.nodes)
+
+	if !options.AllowDeadEnds {
+		for _, name := range nodeNames {
+			_, hasConditional := g.conditionalEdges[name]
+			if len(outgoing[name]) == 0 && !hasConditional {
+				issues = append(issues, ValidationIssue{Err: ErrNoOutgoingEdge, Node: name})
+			}
+		}
+	}
+
+	// An unmapped conditional router may return any node name, so reachability can
+	// only be proven when every reachable conditional edge has a path map.
+	reachable := make(map[string]bool)
+	queue := []string{g.entryPoint}
+	if g.hasConditionalEntry() {
+		// A conditional edge from START takes precedence over the entry point
+		queue = []string{START}
+	}
+	if g.errorHandler != "" {
+		// Any failing node can route to the error handler
+		queue = append(queue, g.errorHandler)
+	}
+	dynamic := false
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if reachable[name] || name == END {
+			continue
+		}
+		if name != START {
+			reachable[name] = true
+		}
+		if _, ok := g.conditionalEdges[name]; ok {
+			pathMap, mapped := g.conditionalPathMaps[name]
+			if !mapped {
+				dynamic = true
+			}
+			for _, target := range pathMap {
+				queue = append(queue, target)
+			}
+		}
+		queue = append(queue, outgoing[name]...)
+		if to, ok := g.errorEdges[name]; ok {
+			queue = append(queue, to)
+		}
+	}
+
+	if !dynamic {
+		for _, name := range nodeNames {
+			if !reachable[name] {
+				issues = append(issues, ValidationIssue{Err: ErrUnreachableNode, Node: name})
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// routesWithCommands reports whether node results of type S can be a *Command.
+func (g *StateGraph[S]) routesWithCommands() bool {
+	stateType := reflect.TypeFor[S]()
+	return stateType.Kind() == reflect.Interface && reflect.TypeFor[*Command]().Implements(stateType)
+}
+
+// sortedKeys returns the keys of m in sorted order for deterministic reporting.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
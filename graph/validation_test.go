@@ -0,0 +1,168 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+)
+
+func passthrough(_ context.Context, state map[string]any) (map[string]any, error) {
+	return state, nil
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		build      func(g *graph.StateGraph[map[string]any])
+		opts       []graph.CompileOption
+		wantErrs   []error
+		wantInMsgs []string
+	}{
+		{
+			name: "ValidGraph",
+			build: func(g *graph.StateGraph[map[string]any]) {
+				g.AddNode("a", "a", passthrough)
+				g.AddNode("b", "b", passthrough)
+				g.AddEdge("a", "b")
+				g.AddEdge("b", graph.END)
+				g.SetEntryPoint("a")
+			},
+		},
+		{
+			name: "UnknownEdgeTarget",
+			build: func(g *graph.StateGraph[map[string]any]) {
+				g.AddNode("a", "a", passthrough)
+				g.AddEdge("a", "missing")
+				g.SetEntryPoint("a")
+			},
+			wantErrs:   []error{graph.ErrNodeNotFound},
+			wantInMsgs: []string{"node not found: missing (edge a -> missing)"},
+		},
+		{
+			name: "UnknownEntryPoint",
+			build: func(g *graph.StateGraph[map[string]any]) {
+				g.AddNode("a", "a", passthrough)
+				g.AddEdge("a", graph.END)
+				g.SetEntryPoint("start")
+			},
+			wantErrs:   []error{graph.ErrNodeNotFound},
+			wantInMsgs: []string{"node not found: start"},
+		},
+		{
+			name: "UnreachableNode",
+			build: func(g *graph.StateGraph[map[string]any]) {
+				g.AddNode("a", "a", passthrough)
+				g.AddNode("orphan", "orphan", passthrough)
+				g.AddEdge("a", graph.END)
+				g.AddEdge("orphan", graph.END)
+				g.SetEntryPoint("a")
+			},
+			wantErrs:   []error{graph.ErrUnreachableNode},
+			wantInMsgs: []string{"unreachable node: orphan"},
+		},
+		{
+			name: "DeadEnd",
+			build: func(g *graph.StateGraph[map[string]any]) {
+				g.AddNode("a", "a", passthrough)
+				g.AddNode("b", "b", passthrough)
+				g.AddEdge("a", "b")
+				g.SetEntryPoint("a")
+			},
+			wantErrs:   []error{graph.ErrNoOutgoingEdge},
+			wantInMsgs: []string{"no outgoing edge found for node: b"},
+		},
+		{
+			name: "AllowDeadEnds",
+			build: func(g *graph.StateGraph[map[string]any]) {
+				g.AddNode("a", "a", passthrough)
+				g.AddNode("b", "b", passthrough)
+				g.AddEdge("a", "b")
+				g.SetEntryPoint("a")
+			},
+			opts: []graph.CompileOption{graph.WithAllowDeadEnds()},
+		},
+		{
+			name: "ConditionalEdgeMakesTargetsReachable",
+			build: func(g *graph.StateGraph[map[string]any]) {
+				g.AddNode("router", "router", passthrough)
+				g.AddNode("x", "x", passthrough)
+				g.AddConditionalEdge("router", func(_ context.Context, _ map[string]any) string { return "x" })
+				g.AddEdge("x", graph.END)
+				g.SetEntryPoint("router")
+			},
+		},
+		{
+			name: "ReportsAllIssues",
+			build: func(g *graph.StateGraph[map[string]any]) {
+				g.AddNode("a", "a", passthrough)
+				g.AddNode("orphan", "orphan", passthrough)
+				g.AddEdge("a", "missing")
+				g.SetEntryPoint("a")
+			},
+			wantErrs: []error{graph.ErrNodeNotFound, graph.ErrNoOutgoingEdge, graph.ErrUnreachableNode},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := graph.NewStateGraph[map[string]any]()
+			tc.build(g)
+
+			err := g.Validate(tc.opts...)
+			_, compileErr := g.Compile(tc.opts...)
+
+			if len(tc.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected validation error: %v", err)
+				}
+				if compileErr != nil {
+					t.Fatalf("unexpected compile error: %v", compileErr)
+				}
+				return
+			}
+
+			var validationErr *graph.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected *ValidationError, got %v", err)
+			}
+			if !errors.As(compileErr, &validationErr) {
+				t.Fatalf("expected Compile to return *ValidationError, got %v", compileErr)
+			}
+			for _, want := range tc.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("expected error to match %v, got %v", want, err)
+				}
+			}
+			for _, msg := range tc.wantInMsgs {
+				if !strings.Contains(err.Error(), msg) {
+					t.Errorf("expected error message to contain %q, got %q", msg, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_CommandRoutingSkipsStructuralChecks(t *testing.T) {
+	t.Parallel()
+
+	g := graph.NewStateGraph[any]()
+	g.AddNode("a", "a", func(_ context.Context, _ any) (any, error) {
+		return &graph.Command{Goto: "b"}, nil
+	})
+	g.AddNode("b", "b", func(_ context.Context, state any) (any, error) {
+		return state, nil
+	})
+	g.AddEdge("b", graph.END)
+	g.SetEntryPoint("a")
+
+	if err := g.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+}
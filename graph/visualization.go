@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"unicode"
 )
 
 // Exporter provides methods to export graphs in different formats
@@ -20,6 +21,11 @@ func NewExporter[S any](graph *StateGraph[S]) *Exporter[S] {
 type MermaidOptions struct {
 	// Direction of the flowchart (e.g., "TD", "LR")
 	Direction string
+
+	// Depth is the number of levels of subgraphs added with AddSubgraph that are
+	// expanded into subgraph blocks. Subgraphs below it are drawn as a single node,
+	// and a negative depth expands every level.
+	Depth int
 }
 
 // DrawMermaid generates a Mermaid diagram representation of the graph
@@ -29,7 +35,28 @@ func (ge *Exporter[S]) DrawMermaid() string {
 	})
 }
 
-// DrawMermaidWithOptions generates a Mermaid diagram with custom options
+// DrawMermaidWithDepth generates a Mermaid diagram that expands depth levels of
+// subgraphs into subgraph blocks showing their nodes and edges. Depth 0 draws each
+// subgraph as a single node like DrawMermaid, and a negative depth expands them all.
+//
+// Example:
+//
+//	// The supervisor and the internal loop of each of its workers
+//	diagram := graph.NewExporter(g).DrawMermaidWithDepth(1)
+func (ge *Exporter[S]) DrawMermaidWithDepth(depth int) string {
+	return ge.DrawMermaidWithOptions(MermaidOptions{
+		Direction: "TD",
+		Depth:     depth,
+	})
+}
+
+// DrawMermaidWithOptions generates a Mermaid diagram with custom options.
+// Static edges are drawn as solid arrows and conditional edges as dashed arrows,
+// labeled with the path map keys when one was provided, or pointing to a "?"
+// marker otherwise. Error edges are drawn as red dashed arrows. Node names that are not valid Mermaid identifiers are
+// replaced by safe IDs and kept as labels. Node tags are assigned as classes, which can
+// be styled with classDef. Expanded subgraphs are subgraph blocks holding the
+// subgraph's node, linked with a dotted arrow to the nodes it runs.
 func (ge *Exporter[S]) DrawMermaidWithOptions(opts MermaidOptions) string {
 	var sb strings.Builder
 
@@ -40,18 +67,72 @@ func (ge *Exporter[S]) DrawMermaidWithOptions(opts MermaidOptions) string {
 	}
 	sb.WriteString(fmt.Sprintf("flowchart %s\n", direction))
 
-	// Add entry point styling
-	if ge.graph.entryPoint != "" {
-		sb.WriteString(fmt.Sprintf("    %s[[\"%s\"]]\n", ge.graph.entryPoint, ge.graph.entryPoint))
-		sb.WriteString(fmt.Sprintf("    %s --> %s\n", "START", ge.graph.entryPoint))
-		sb.WriteString("    START([\"START\"])\n")
-		sb.WriteString("    style START fill:#90EE90\n")
+	ge.graph.writeMermaid(&mermaidWriter{sb: &sb, ids: newMermaidIDs()}, "", "    ", START, opts.Depth)
+	return sb.String()
+}
+
+// mermaidWriter holds the state shared by a graph and its subgraphs while writing a diagram
+type mermaidWriter struct {
+	sb  *strings.Builder
+	ids *mermaidIDs
+
+	// links counts the links written so far, which linkStyle refers to by index
+	links int
+}
+
+func (w *mermaidWriter) printf(format string, args ...any) {
+	fmt.Fprintf(w.sb, format, args...)
+}
+
+func (g *StateGraph[S]) writeMermaid(w *mermaidWriter, prefix string, indent string, from string, depth int) {
+	id := func(name string) string {
+		if name == START {
+			return w.ids.get(from)
+		}
+		return w.ids.get(prefix + name)
+	}
+
+	// writeNode writes a node, or the block of its subgraph when it is expanded
+	writeNode := func(name string, format string) {
+		sub, ok := g.subgraphs[name]
+		if !ok || depth == 0 {
+			w.printf(indent+format+"\n", id(name), mermaidLabel(name))
+			return
+		}
+		w.printf("%ssubgraph %s_subgraph [\"%s\"]\n", indent, id(name), mermaidLabel(name))
+		w.printf(indent+"    "+format+"\n", id(name), mermaidLabel(name))
+		sub.writeMermaid(w, prefix+name+"/", indent+"    ", prefix+name, depth-1)
+		w.printf("%send\n", indent)
+	}
+
+	// Add entry point styling. A conditional entry is drawn with the conditional edges.
+	entry := ""
+	if g.hasConditionalEntry() {
+		if prefix == "" {
+			w.printf("%sSTART([\"START\"])\n", indent)
+			w.printf("%sstyle START fill:#90EE90\n", indent)
+		}
+	} else if g.entryPoint != "" {
+		entry = id(g.entryPoint)
+		writeNode(g.entryPoint, "%s[[\"%s\"]]")
+		if prefix == "" {
+			w.printf("%s%s --> %s\n", indent, START, entry)
+			w.printf("%sSTART([\"START\"])\n", indent)
+			w.printf("%sstyle START fill:#90EE90\n", indent)
+		} else {
+			w.printf("%s%s -.-> %s\n", indent, id(START), entry)
+		}
+		w.links++
 	}
 
 	// Get sorted node names for consistent output
-	nodeNames := make([]string, 0, len(ge.graph.nodes))
-	for name := range ge.graph.nodes {
-		if name != ge.graph.entryPoint && name != END {
+	entryName := ""
+	if entry != "" {
+		entryName = g.entryPoint
+	}
+	nodeNames := make([]string, 0, len(g.nodes))
+	for name := range g.nodes {
+		if name != entryName && name != END {
 			nodeNames = append(nodeNames, name)
 		}
 	}
@@ -59,164 +140,311 @@ func (ge *Exporter[S]) DrawMermaidWithOptions(opts MermaidOptions) string {
 
 	// Add regular nodes
 	for _, name := range nodeNames {
-		sb.WriteString(fmt.Sprintf("    %s[\"%s\"]\n", name, name))
+		writeNode(name, "%s[\"%s\"]")
 	}
 
 	// Add END node if referenced
-	hasEnd := false
-	for _, edge := range ge.graph.edges {
-		if edge.To == END {
-			hasEnd = true
-			break
-		}
+	if g.referencesEnd() {
+		w.printf("%s%s([\"END\"])\n", indent, id(END))
+		w.printf("%sstyle %s fill:#FFB6C1\n", indent, id(END))
 	}
 
-	if hasEnd {
-		sb.WriteString("    END([\"END\"])\n")
-		sb.WriteString("    style END fill:#FFB6C1\n")
+	// Add node tags as classes
+	byTag := map[string][]string{}
+	for _, name := range sortedKeys(g.nodes) {
+		for _, tag := range g.nodes[name].Tags {
+			byTag[tag] = append(byTag[tag], id(name))
+		}
+	}
+	for _, tag := range sortedKeys(byTag) {
+		w.printf("%sclass %s %s\n", indent, strings.Join(byTag[tag], ","), mermaidClass(tag))
 	}
 
 	// Add edges
-	for _, edge := range ge.graph.edges {
-		sb.WriteString(fmt.Sprintf("    %s --> %s\n", edge.From, edge.To))
+	for _, edge := range g.edges {
+		w.printf("%s%s --> %s\n", indent, id(edge.From), id(edge.To))
+		w.links++
 	}
 
 	// Add conditional edges
-	for from := range ge.graph.conditionalEdges {
-		sb.WriteString(fmt.Sprintf("    %s -.-> %s_condition((?))\n", from, from))
-		sb.WriteString(fmt.Sprintf("    style %s_condition fill:#FFFFE0,stroke:#333,stroke-dasharray: 5 5\n", from))
+	for _, name := range sortedKeys(g.conditionalEdges) {
+		fromID := id(name)
+		if pathMap, ok := g.conditionalPathMaps[name]; ok {
+			for _, key := range sortedKeys(pathMap) {
+				w.printf("%s%s -.->|%s| %s\n", indent, fromID, mermaidEdgeLabel(key), id(pathMap[key]))
+				w.links++
+			}
+			continue
+		}
+		w.printf("%s%s -.-> %s_condition((?))\n", indent, fromID, fromID)
+		w.printf("%sstyle %s_condition fill:#FFFFE0,stroke:#333,stroke-dasharray: 5 5\n", indent, fromID)
+		w.links++
 	}
 
-	// Style entry point
-	if ge.graph.entryPoint != "" {
-		sb.WriteString(fmt.Sprintf("    style %s fill:#87CEEB\n", ge.graph.entryPoint))
+	// Add error edges
+	for _, name := range sortedKeys(g.errorEdges) {
+		w.printf("%s%s -.->|error| %s\n", indent, id(name), id(g.errorEdges[name]))
+		w.printf("%slinkStyle %d stroke:red,color:red\n", indent, w.links)
+		w.links++
 	}
 
-	return sb.String()
+	// Style entry point
+	if entry != "" {
+		w.printf("%sstyle %s fill:#87CEEB\n", indent, entry)
+	}
 }
 
-// DrawDOT generates a DOT (Graphviz) representation of the graph
-func (ge *Exporter[S]) DrawDOT() string {
-	var sb strings.Builder
+// mermaidReserved lists words that cannot be used as Mermaid node IDs
+var mermaidReserved = map[string]bool{
+	"end":       true,
+	"graph":     true,
+	"subgraph":  true,
+	"flowchart": true,
+	"style":     true,
+	"class":     true,
+	"classDef":  true,
+	"click":     true,
+	"linkStyle": true,
+	"direction": true,
+}
 
-	sb.WriteString("digraph G {\n")
-	sb.WriteString("    rankdir=TD;\n")
-	sb.WriteString("    node [shape=box];\n")
+// mermaidIDs assigns stable, unique Mermaid node IDs to node names
+type mermaidIDs struct {
+	byName map[string]string
+	used   map[string]bool
+}
 
-	// Add START node if there's an entry point
-	if ge.graph.entryPoint != "" {
-		sb.WriteString("    START [label=\"START\", shape=ellipse, style=filled, fillcolor=lightgreen];\n")
-		sb.WriteString(fmt.Sprintf("    START -> %s;\n", ge.graph.entryPoint))
+func newMermaidIDs() *mermaidIDs {
+	return &mermaidIDs{
+		byName: map[string]string{START: START, END: END},
+		used:   map[string]bool{START: true, END: true},
 	}
+}
 
-	// Add entry point styling
-	if ge.graph.entryPoint != "" {
-		sb.WriteString(fmt.Sprintf("    %s [style=filled, fillcolor=lightblue];\n", ge.graph.entryPoint))
+// get returns the ID for name, allocating one if needed
+func (m *mermaidIDs) get(name string) string {
+	if id, ok := m.byName[name]; ok {
+		return id
 	}
 
-	// Add END node styling if referenced
-	hasEnd := false
-	for _, edge := range ge.graph.edges {
-		if edge.To == END {
-			hasEnd = true
-			break
+	var sb strings.Builder
+	for _, r := range name {
+		if r == '_' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
 		}
 	}
-
-	if hasEnd {
-		sb.WriteString("    END [label=\"END\", shape=ellipse, style=filled, fillcolor=lightpink];\n")
+	base := sb.String()
+	if base == "" || mermaidReserved[base] {
+		base = "node_" + base
 	}
 
-	// Add edges
-	for _, edge := range ge.graph.edges {
-		sb.WriteString(fmt.Sprintf("    %s -> %s;\n", edge.From, edge.To))
+	id := base
+	for i := 2; m.used[id]; i++ {
+		id = fmt.Sprintf("%s_%d", base, i)
 	}
 
-	// Add conditional edges
-	for from := range ge.graph.conditionalEdges {
-		sb.WriteString(fmt.Sprintf("    %s -> %s_condition [style=dashed, label=\"?\"];\n", from, from))
-		sb.WriteString(fmt.Sprintf("    %s_condition [label=\"?\", shape=diamond, style=filled, fillcolor=lightyellow];\n", from))
-	}
+	m.byName[name] = id
+	m.used[id] = true
+	return id
+}
 
-	sb.WriteString("}\n")
-	return sb.String()
+// mermaidLabel escapes text for use inside a quoted Mermaid label
+func mermaidLabel(text string) string {
+	return strings.ReplaceAll(text, "\"", "#quot;")
+}
+
+// mermaidClass returns tag as a Mermaid class name
+func mermaidClass(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			return r
+		}
+		return '_'
+	}, tag)
 }
 
-// DrawASCII generates an ASCII tree representation of the graph
-func (ge *Exporter[S]) DrawASCII() string {
-	if ge.graph.entryPoint == "" {
-		return "No entry point set\n"
+// mermaidEdgeLabel escapes text for use as an edge label, quoting it when needed
+func mermaidEdgeLabel(text string) string {
+	if strings.ContainsAny(text, "|\"[](){}<> ") {
+		return "\"" + mermaidLabel(text) + "\""
 	}
+	return text
+}
+
+// DrawDOT generates a DOT (Graphviz) representation of the graph.
+// Node descriptions, tags and metadata become tooltips and tags become classes, conditional edges are dashed and labeled with
+// their path map key (or "?" when unmapped), error edges are red and dashed, and subgraphs added with AddSubgraph
+// are drawn as clusters. Identifiers are quoted where DOT requires it.
+func (ge *Exporter[S]) DrawDOT() string {
+	return ge.DrawDOTWithDepth(-1)
+}
 
+// DrawDOTWithDepth generates a DOT representation like DrawDOT, expanding depth
+// levels of subgraphs into clusters. Depth 0 draws each subgraph as a single node,
+// and a negative depth expands them all.
+func (ge *Exporter[S]) DrawDOTWithDepth(depth int) string {
 	var sb strings.Builder
-	visited := make(map[string]bool)
 
-	sb.WriteString("Graph Execution Flow:\n")
-	sb.WriteString("├── START\n")
+	sb.WriteString("digraph G {\n")
+	sb.WriteString("    rankdir=TD;\n")
+	sb.WriteString("    node [shape=box];\n")
+
+	// Add START node if there's an entry point
+	if ge.graph.entryPoint != "" || ge.graph.hasConditionalEntry() {
+		sb.WriteString("    START [label=\"START\", shape=ellipse, style=filled, fillcolor=lightgreen];\n")
+	}
 
-	ge.drawASCIINode(ge.graph.entryPoint, "│   ", true, visited, &sb)
+	ge.graph.writeDOT(&sb, "", "    ", START, depth)
 
+	sb.WriteString("}\n")
 	return sb.String()
 }
 
-// drawASCIINode recursively draws ASCII representation of nodes
-func (ge *Exporter[S]) drawASCIINode(nodeName string, prefix string, isLast bool, visited map[string]bool, sb *strings.Builder) {
-	if visited[nodeName] {
-		// Handle cycles
-		connector := "├──"
-		if isLast {
-			connector = "└──"
+// graphView draws a graph independently of its state type, so that subgraphs with
+// a different state type can be nested in the visualization of their parent.
+type graphView interface {
+	// writeDOT writes the nodes and edges of the graph, prefixing node ids with prefix
+	// and linking the from node to the entry point, or routing from it when the entry
+	// is conditional
+	writeDOT(sb *strings.Builder, prefix string, indent string, from string, depth int)
+
+	// writeMermaid is the Mermaid equivalent of writeDOT. Subgraphs are expanded
+	// depth more levels, or all levels when depth is negative.
+	writeMermaid(w *mermaidWriter, prefix string, indent string, from string, depth int)
+}
+
+func (g *StateGraph[S]) writeDOT(sb *strings.Builder, prefix string, indent string, from string, depth int) {
+	id := func(name string) string {
+		return dotID(prefix + name)
+	}
+
+	if g.entryPoint != "" && !g.hasConditionalEntry() {
+		style := ""
+		if prefix != "" {
+			style = " [style=dotted]"
 		}
-		sb.WriteString(fmt.Sprintf("%s%s %s (cycle)\n", prefix, connector, nodeName))
-		return
+		fmt.Fprintf(sb, "%s%s -> %s%s;\n", indent, dotID(from), id(g.entryPoint), style)
 	}
 
-	visited[nodeName] = true
+	// Add nodes, with the entry point highlighted
+	for _, name := range sortedKeys(g.nodes) {
+		node := g.nodes[name]
+		attrs := fmt.Sprintf("label=%s, tooltip=%s", dotString(name), dotString(dotTooltip(node.Description, node.Tags, node.Metadata)))
+		if len(node.Tags) > 0 {
+			attrs += fmt.Sprintf(", class=%s", dotString(strings.Join(node.Tags, " ")))
+		}
+		if name == g.entryPoint && !g.hasConditionalEntry() {
+			attrs += ", style=filled, fillcolor=lightblue"
+		}
 
-	connector := "├──"
-	nextPrefix := prefix + "│   "
-	if isLast {
-		connector = "└──"
-		nextPrefix = prefix + "    "
+		sub, ok := g.subgraphs[name]
+		if !ok || depth == 0 {
+			fmt.Fprintf(sb, "%s%s [%s];\n", indent, id(name), attrs)
+			continue
+		}
+
+		fmt.Fprintf(sb, "%ssubgraph %s {\n", indent, dotString("cluster_"+prefix+name))
+		fmt.Fprintf(sb, "%s    label=%s;\n", indent, dotString(name))
+		fmt.Fprintf(sb, "%s    style=dashed;\n", indent)
+		fmt.Fprintf(sb, "%s    %s [%s];\n", indent, id(name), attrs)
+		sub.writeDOT(sb, prefix+name+"/", indent+"    ", prefix+name, depth-1)
+		fmt.Fprintf(sb, "%s}\n", indent)
 	}
 
-	sb.WriteString(fmt.Sprintf("%s%s %s\n", prefix, connector, nodeName))
+	// Add END node styling if referenced
+	if g.referencesEnd() {
+		fmt.Fprintf(sb, "%s%s [label=\"END\", shape=ellipse, style=filled, fillcolor=lightpink];\n", indent, id(END))
+	}
 
-	if nodeName == END {
-		return
+	// Add edges
+	for _, edge := range g.edges {
+		fmt.Fprintf(sb, "%s%s -> %s;\n", indent, id(edge.From), id(edge.To))
 	}
 
-	// Find outgoing edges
-	outgoingEdges := make([]string, 0)
-	for _, edge := range ge.graph.edges {
-		if edge.From == nodeName {
-			outgoingEdges = append(outgoingEdges, edge.To)
+	// Add conditional edges
+	for _, name := range sortedKeys(g.conditionalEdges) {
+		source := id(name)
+		if name == START {
+			source = dotID(from)
+		}
+		if pathMap, ok := g.conditionalPathMaps[name]; ok {
+			for _, key := range sortedKeys(pathMap) {
+				fmt.Fprintf(sb, "%s%s -> %s [style=dashed, label=%s];\n", indent, source, id(pathMap[key]), dotString(key))
+			}
+			continue
 		}
+		fmt.Fprintf(sb, "%s%s -> %s [style=dashed, label=\"?\"];\n", indent, source, id(name+"_condition"))
+		fmt.Fprintf(sb, "%s%s [label=\"?\", shape=diamond, style=filled, fillcolor=lightyellow];\n", indent, id(name+"_condition"))
 	}
 
-	// Check for conditional edge
-	if _, ok := ge.graph.conditionalEdges[nodeName]; ok {
-		outgoingEdges = append(outgoingEdges, "(Conditional)")
+	// Add error edges
+	for _, name := range sortedKeys(g.errorEdges) {
+		fmt.Fprintf(sb, "%s%s -> %s [style=dashed, color=red, fontcolor=red, label=\"error\"];\n", indent, id(name), id(g.errorEdges[name]))
 	}
+}
 
-	// Sort for consistent output
-	sort.Strings(outgoingEdges)
+// dotTooltip returns the tooltip of a node: its description followed by its tags
+// and metadata, one per line
+func dotTooltip(description string, tags []string, metadata map[string]any) string {
+	lines := []string{description}
+	if len(tags) > 0 {
+		lines = append(lines, "tags: "+strings.Join(tags, ", "))
+	}
+	for _, key := range sortedKeys(metadata) {
+		lines = append(lines, fmt.Sprintf("%s: %v", key, metadata[key]))
+	}
+	return strings.Join(lines, "\n")
+}
 
-	// Draw child nodes
-	for i, target := range outgoingEdges {
-		isLastChild := i == len(outgoingEdges)-1
+// dotKeywords are reserved by the DOT language and can't be used as unquoted ids
+var dotKeywords = map[string]bool{
+	"node": true, "edge": true, "graph": true, "digraph": true, "subgraph": true, "strict": true,
+}
 
-		if target == "(Conditional)" {
-			// Draw conditional indicator
-			condConnector := "├──"
-			if isLastChild {
-				condConnector = "└──"
+// dotID returns name as a DOT identifier, quoting it unless it is a plain ASCII identifier
+func dotID(name string) string {
+	if name == "" || dotKeywords[strings.ToLower(name)] {
+		return dotString(name)
+	}
+	for i, r := range name {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return dotString(name)
+		}
+	}
+	return name
+}
+
+// dotString returns text as a quoted DOT string
+func dotString(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"`, `\"`)
+	text = strings.ReplaceAll(text, "\n", `\n`)
+	return `"` + text + `"`
+}
+
+// referencesEnd reports whether any static edge or conditional path map targets END
+func (g *StateGraph[S]) referencesEnd() bool {
+	for _, edge := range g.edges {
+		if edge.To == END {
+			return true
+		}
+	}
+	for _, pathMap := range g.conditionalPathMaps {
+		for _, target := range pathMap {
+			if target == END {
+				return true
 			}
-			sb.WriteString(fmt.Sprintf("%s%s (?)\n", nextPrefix, condConnector))
-		} else {
-			ge.drawASCIINode(target, nextPrefix, isLastChild, visited, sb)
 		}
 	}
+	return false
+}
+
+// GetGraph returns an Exporter for the compiled graph's visualization
+func (r *StateRunnable[S]) GetGraph() *Exporter[S] {
+	return NewExporter(r.graph)
 }
 
 // GetGraphForRunnable returns a Exporter for the compiled graph's visualization
@@ -2,6 +2,11 @@ package graph
 
 import (
 	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -49,3 +54,237 @@ func TestVisualization(t *testing.T) {
 	// C is not reachable via static edges from B, so it won't be shown under B.
 	// This is expected behavior for static visualization of dynamic graphs.
 }
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares got with the golden file testdata/<name>, rewriting it when -update is set
+func assertGolden(t *testing.T, name string, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	assert.Equal(t, string(want), got)
+}
+
+func newMixedEdgesGraph() *StateGraph[map[string]any] {
+	noop := func(ctx context.Context, state map[string]any) (map[string]any, error) { return state, nil }
+
+	g := NewStateGraph[map[string]any]()
+	g.AddNode("fetch data", "Fetch the documents", noop)
+	g.AddNode("grade", "Grade relevance", noop)
+	g.AddNode("generate", "Generate the answer", noop)
+	g.AddNode("rewrite-query", "Rewrite the query", noop)
+	g.AddNode("end", "Lowercase end is reserved in Mermaid", noop)
+
+	g.SetEntryPoint("fetch data")
+	g.AddEdge("fetch data", "grade")
+	g.AddConditionalEdgeWithMapping("grade", func(ctx context.Context, state map[string]any) string { return "relevant" }, map[string]string{
+		"relevant":   "generate",
+		"irrelevant": "rewrite-query",
+	})
+	g.AddConditionalEdge("rewrite-query", func(ctx context.Context, state map[string]any) string { return "fetch data" })
+	g.AddEdge("generate", "end")
+	g.AddEdge("end", END)
+	return g
+}
+
+func TestDrawMermaid_Golden(t *testing.T) {
+	exporter := NewExporter(newMixedEdgesGraph())
+
+	assertGolden(t, "mermaid_mixed_edges.golden", exporter.DrawMermaid())
+	assertGolden(t, "mermaid_mixed_edges_lr.golden", exporter.DrawMermaidWithOptions(MermaidOptions{Direction: "LR"}))
+}
+
+// newNestedGraph returns a supervisor graph whose worker is a subgraph with an
+// agent loop, which itself calls a search subgraph
+func newNestedGraph(t *testing.T) *StateGraph[map[string]any] {
+	noop := func(ctx context.Context, state map[string]any) (map[string]any, error) { return state, nil }
+	identity := func(state map[string]any) map[string]any { return state }
+
+	search := NewStateGraph[map[string]any]()
+	search.AddNode("query", "Query the index", noop)
+	search.SetEntryPoint("query")
+	search.AddEdge("query", END)
+
+	worker := NewStateGraph[map[string]any]()
+	worker.AddNode("agent", "Call the model", noop)
+	worker.AddNode("recover", "Recover from tool errors", noop)
+	assert.NoError(t, AddSubgraph(worker, "tools", search, identity, identity))
+	worker.SetEntryPoint("agent")
+	worker.AddConditionalEdgeWithMapping("agent", func(ctx context.Context, state map[string]any) string {
+		return "done"
+	}, map[string]string{"tools": "tools", "done": END})
+	worker.AddEdge("tools", "agent")
+	worker.AddErrorEdge("tools", "recover")
+	worker.AddEdge("recover", "agent")
+
+	g := NewStateGraph[map[string]any]()
+	g.AddNode("supervisor", "Route to a worker", noop)
+	assert.NoError(t, AddSubgraph(g, "researcher", worker, identity, identity))
+	g.SetEntryPoint("supervisor")
+	g.AddConditionalEdgeWithMapping("supervisor", func(ctx context.Context, state map[string]any) string {
+		return "FINISH"
+	}, map[string]string{"researcher": "researcher", "FINISH": END})
+	g.AddEdge("researcher", "supervisor")
+	return g
+}
+
+func TestDrawMermaidWithDepth(t *testing.T) {
+	exporter := NewExporter(newNestedGraph(t))
+
+	collapsed := exporter.DrawMermaidWithDepth(0)
+	assert.Equal(t, exporter.DrawMermaid(), collapsed)
+	assert.NotContains(t, collapsed, "subgraph")
+	assert.Contains(t, collapsed, `researcher["researcher"]`)
+
+	oneLevel := exporter.DrawMermaidWithDepth(1)
+	assertGolden(t, "mermaid_nested.golden", oneLevel)
+	assert.NotContains(t, oneLevel, "researcher_tools_subgraph")
+
+	all := exporter.DrawMermaidWithDepth(-1)
+	assert.Contains(t, all, `        subgraph researcher_tools_subgraph ["tools"]`)
+	assert.Contains(t, all, "            researcher_tools -.-> researcher_tools_query")
+	assert.Contains(t, all, "            researcher_tools_query --> researcher_tools_END")
+	assert.Equal(t, all, exporter.DrawMermaidWithDepth(2))
+
+	// linkStyle indexes count the links of subgraphs written before the error edge
+	assert.Contains(t, oneLevel, "researcher_tools -.->|error| researcher_recover\n        linkStyle 6 ")
+	assert.Contains(t, all, "researcher_tools -.->|error| researcher_recover\n        linkStyle 8 ")
+}
+
+func TestDrawDOTWithDepth(t *testing.T) {
+	exporter := NewExporter(newNestedGraph(t))
+
+	assert.Equal(t, exporter.DrawDOT(), exporter.DrawDOTWithDepth(-1))
+	assert.Contains(t, exporter.DrawDOT(), `"cluster_researcher/tools"`)
+
+	collapsed := exporter.DrawDOTWithDepth(0)
+	assertValidDOT(t, collapsed)
+	assert.NotContains(t, collapsed, "cluster_")
+	assert.Contains(t, collapsed, "researcher -> supervisor;")
+
+	oneLevel := exporter.DrawDOTWithDepth(1)
+	assertValidDOT(t, oneLevel)
+	assert.Contains(t, oneLevel, `"cluster_researcher"`)
+	assert.NotContains(t, oneLevel, `"cluster_researcher/tools"`)
+	assert.NotContains(t, oneLevel, `"researcher/tools/query"`)
+}
+
+func TestDrawMermaid_EscapesNames(t *testing.T) {
+	noop := func(ctx context.Context, state map[string]any) (map[string]any, error) { return state, nil }
+
+	g := NewStateGraph[map[string]any]()
+	g.AddNode(`say "hi"`, "quoted", noop)
+	g.AddNode("a b", "space", noop)
+	g.AddNode("a_b", "underscore", noop)
+	g.SetEntryPoint(`say "hi"`)
+	g.AddEdge(`say "hi"`, "a b")
+	g.AddEdge("a b", "a_b")
+	g.AddEdge("a_b", END)
+
+	mermaid := NewExporter(g).DrawMermaid()
+	assert.Contains(t, mermaid, `say__hi_[["say #quot;hi#quot;"]]`)
+	assert.Contains(t, mermaid, `a_b["a b"]`)
+	assert.Contains(t, mermaid, `a_b_2["a_b"]`)
+	assert.Contains(t, mermaid, "say__hi_ --> a_b")
+	assert.Contains(t, mermaid, "a_b --> a_b_2")
+}
+
+// assertValidDOT checks that braces and brackets are balanced outside of quoted
+// strings and that every quoted string is terminated.
+func assertValidDOT(t *testing.T, dot string) {
+	t.Helper()
+
+	depth := map[rune]int{}
+	inQuote := false
+	escaped := false
+	for _, r := range dot {
+		if inQuote {
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inQuote = false
+			case r == '\n':
+				t.Fatalf("unterminated quoted string in DOT output:\n%s", dot)
+			}
+			continue
+		}
+		switch r {
+		case '"':
+			inQuote = true
+		case '{', '[':
+			depth[r]++
+		case '}':
+			depth['{']--
+		case ']':
+			depth['[']--
+		}
+		if depth['{'] < 0 || depth['['] < 0 {
+			t.Fatalf("unbalanced braces in DOT output:\n%s", dot)
+		}
+	}
+	assert.False(t, inQuote, "unterminated quoted string")
+	assert.Zero(t, depth['{'], "unbalanced braces")
+	assert.Zero(t, depth['['], "unbalanced brackets")
+
+	if _, err := exec.LookPath("dot"); err == nil {
+		cmd := exec.Command("dot", "-Tsvg")
+		cmd.Stdin = strings.NewReader(dot)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, "dot -Tsvg failed: %s", out)
+	}
+}
+
+func TestDrawDOT_Golden(t *testing.T) {
+	g := newMixedEdgesGraph()
+
+	sub := NewStateGraph[map[string]any]()
+	sub.AddNode("retrieve", "Retrieve from the index", func(ctx context.Context, state map[string]any) (map[string]any, error) { return state, nil })
+	sub.SetEntryPoint("retrieve")
+	sub.AddEdge("retrieve", END)
+	identity := func(state map[string]any) map[string]any { return state }
+	assert.NoError(t, AddSubgraph(g, "research_team", sub, identity, identity))
+	g.AddEdge("research_team", END)
+
+	dot := NewExporter(g).DrawDOT()
+	assertValidDOT(t, dot)
+	assertGolden(t, "dot_mixed_edges.golden", dot)
+}
+
+func TestDrawDOT_QuotesIdentifiers(t *testing.T) {
+	noop := func(ctx context.Context, state map[string]any) (map[string]any, error) { return state, nil }
+
+	g := NewStateGraph[map[string]any]()
+	g.AddNode("fetch-data", `Fetch "raw" data`, noop)
+	g.AddNode("clean_data", "Clean data", noop)
+	g.AddNode("résumé", "Unicode name", noop)
+	g.AddNode("graph", "DOT keyword", noop)
+	g.SetEntryPoint("fetch-data")
+	g.AddEdge("fetch-data", "clean_data")
+	g.AddEdge("clean_data", "résumé")
+	g.AddEdge("résumé", "graph")
+	g.AddEdge("graph", END)
+
+	dot := NewExporter(g).DrawDOT()
+	assertValidDOT(t, dot)
+	assert.Contains(t, dot, `START -> "fetch-data";`)
+	assert.Contains(t, dot, `"fetch-data" -> clean_data;`)
+	assert.Contains(t, dot, `clean_data -> "résumé";`)
+	assert.Contains(t, dot, `"résumé" -> "graph";`)
+	assert.Contains(t, dot, `tooltip="Fetch \"raw\" data"`)
+}
@@ -0,0 +1,185 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ANSI escape codes WriterListener colors its lines with
+const (
+	ansiReset   = "\033[0m"
+	ansiDim     = "\033[2m"
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiMagenta = "\033[35m"
+	ansiCyan    = "\033[36m"
+)
+
+// WriterListenerOptions configures a WriterListener
+type WriterListenerOptions struct {
+	// Color colors the lines with ANSI escape codes, for terminals
+	Color bool
+
+	// Compact prints short lines without timestamps or icons, for logs
+	Compact bool
+
+	// Events lists the events to print. All events are printed when it is empty.
+	Events []NodeEvent
+
+	// TimeFormat is the layout of the timestamps of the lines, "15:04:05.000"
+	// by default. It is ignored in compact mode.
+	TimeFormat string
+}
+
+// WriterListener prints a human-friendly line for every node event to a writer:
+// node starts, completions with their duration, retries, errors, tool events and
+// the tokens streamed with StreamingFunc, which are written as they arrive on a
+// line of their own. It lets agents report their progress in a terminal without
+// printing from their node functions.
+type WriterListener struct {
+	writer     io.Writer
+	opts       WriterListenerOptions
+	mutex      sync.Mutex
+	startTimes map[string]time.Time
+	tokenNode  string // node whose tokens the current line holds, if any
+	now        func() time.Time
+}
+
+// NewWriterListener creates a listener printing node events to w.
+//
+// Example:
+//
+//	g.AddGlobalListener(graph.NewWriterListener(os.Stdout, graph.WriterListenerOptions{
+//	    Color:  true,
+//	    Events: []graph.NodeEvent{graph.NodeEventComplete, graph.NodeEventError, graph.EventToken},
+//	}))
+func NewWriterListener(w io.Writer, opts WriterListenerOptions) *WriterListener {
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = "15:04:05.000"
+	}
+	return &WriterListener{
+		writer:     w,
+		opts:       opts,
+		startTimes: make(map[string]time.Time),
+		now:        time.Now,
+	}
+}
+
+// OnNodeEvent implements the NodeListener[map[string]any] interface
+func (wl *WriterListener) OnNodeEvent(ctx context.Context, event NodeEvent, nodeName string, _ map[string]any, err error) {
+	wl.mutex.Lock()
+	defer wl.mutex.Unlock()
+
+	// Track durations even for the events that are filtered out
+	now := wl.now()
+	var duration time.Duration
+	switch event {
+	case NodeEventStart:
+		wl.startTimes[nodeName] = now
+	case NodeEventComplete, NodeEventError:
+		if start, ok := wl.startTimes[nodeName]; ok {
+			duration = now.Sub(start)
+			delete(wl.startTimes, nodeName)
+		}
+	}
+
+	if len(wl.opts.Events) > 0 && !slices.Contains(wl.opts.Events, event) {
+		// A node that streamed tokens has finished its line even if its end isn't printed
+		if (event == NodeEventComplete || event == NodeEventError) && nodeName == wl.tokenNode {
+			wl.endTokenLine()
+		}
+		return
+	}
+
+	if event == EventToken {
+		chunk, _ := TokenFromContext(ctx)
+		if wl.tokenNode != nodeName {
+			wl.endTokenLine()
+			fmt.Fprint(wl.writer, wl.prefix(now, ansiCyan, "💬", nodeName+":")+" ")
+			wl.tokenNode = nodeName
+		}
+		fmt.Fprint(wl.writer, chunk)
+		return
+	}
+	wl.endTokenLine()
+
+	var color, icon, message string
+	switch event {
+	case NodeEventStart:
+		color, icon, message = ansiCyan, "▶", "started"
+	case NodeEventComplete:
+		color, icon, message = ansiGreen, "✔", "completed"
+		if duration > 0 {
+			message = fmt.Sprintf("completed in %s", formatDuration(duration))
+		}
+	case NodeEventError:
+		color, icon, message = ansiRed, "✖", "failed"
+		if duration > 0 {
+			message = fmt.Sprintf("failed after %s", formatDuration(duration))
+		}
+	case NodeEventRetry:
+		color, icon, message = ansiYellow, "↻", "retrying"
+	case NodeEventCacheHit:
+		color, icon, message = ansiGreen, "⚡", "served from cache"
+	case NodeEventCheckpointSkipped:
+		color, icon, message = ansiYellow, "⏭", "checkpoint skipped"
+	case NodeEventCheckpointDropped:
+		color, icon, message = ansiYellow, "🗑", "checkpoint dropped"
+	case EventToolStart:
+		color, icon, message = ansiMagenta, "🔧", "tool started"
+	case EventToolEnd:
+		color, icon, message = ansiMagenta, "🔧", "tool finished"
+	case EventCustom:
+		color, icon, message = ansiMagenta, "★", string(event)
+		if custom, ok := CustomEventFromContext(ctx); ok {
+			message = fmt.Sprintf("%s: %v", custom.Name, custom.Payload)
+		}
+	default:
+		color, icon, message = ansiDim, "•", string(event)
+	}
+	if err != nil {
+		message = fmt.Sprintf("%s: %v", message, err)
+	}
+	fmt.Fprintln(wl.writer, wl.prefix(now, color, icon, nodeName)+" "+message)
+}
+
+// prefix returns the start of a line about node: its timestamp, icon and name,
+// or just the name in compact mode
+func (wl *WriterListener) prefix(now time.Time, color, icon, node string) string {
+	if wl.opts.Compact {
+		return wl.paint(color, node)
+	}
+	return wl.paint(ansiDim, "["+now.Format(wl.opts.TimeFormat)+"]") + " " + wl.paint(color, icon+" "+node)
+}
+
+func (wl *WriterListener) paint(color, text string) string {
+	if !wl.opts.Color {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// endTokenLine ends the line of streamed tokens being written, if any
+func (wl *WriterListener) endTokenLine() {
+	if wl.tokenNode != "" {
+		fmt.Fprintln(wl.writer)
+		wl.tokenNode = ""
+	}
+}
+
+// formatDuration rounds d to a precision that reads well in a log line
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.String()
+	}
+}
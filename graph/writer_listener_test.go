@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock returns times advancing by step on every call
+func fakeClock(step time.Duration) func() time.Time {
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestWriterListener(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	token := func(chunk string) context.Context {
+		return context.WithValue(ctx, tokenKey{}, chunk)
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		wl := NewWriterListener(&buf, WriterListenerOptions{})
+		wl.now = fakeClock(10 * time.Millisecond)
+
+		wl.OnNodeEvent(ctx, NodeEventStart, "chat", nil, nil)
+		wl.OnNodeEvent(token("Hel"), EventToken, "chat", nil, nil)
+		wl.OnNodeEvent(token("lo"), EventToken, "chat", nil, nil)
+		wl.OnNodeEvent(ctx, NodeEventComplete, "chat", nil, nil)
+		wl.OnNodeEvent(ctx, EventToolStart, "tools", nil, nil)
+		wl.OnNodeEvent(ctx, NodeEventStart, "tools", nil, nil)
+		wl.OnNodeEvent(ctx, NodeEventError, "tools", nil, errors.New("boom"))
+
+		assert.Equal(t, `[15:04:05.010] ▶ chat started
+[15:04:05.020] 💬 chat: Hello
+[15:04:05.040] ✔ chat completed in 30ms
+[15:04:05.050] 🔧 tools tool started
+[15:04:05.060] ▶ tools started
+[15:04:05.070] ✖ tools failed after 10ms: boom
+`, buf.String())
+	})
+
+	t.Run("Compact", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		wl := NewWriterListener(&buf, WriterListenerOptions{Compact: true})
+		wl.now = fakeClock(time.Millisecond)
+
+		wl.OnNodeEvent(ctx, NodeEventStart, "a", nil, nil)
+		wl.OnNodeEvent(ctx, NodeEventRetry, "a", nil, errors.New("flaky"))
+		wl.OnNodeEvent(ctx, NodeEventComplete, "a", nil, nil)
+
+		assert.Equal(t, "a started\na retrying: flaky\na completed in 2ms\n", buf.String())
+	})
+
+	t.Run("Color", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		wl := NewWriterListener(&buf, WriterListenerOptions{Compact: true, Color: true})
+
+		wl.OnNodeEvent(ctx, NodeEventError, "a", nil, errors.New("boom"))
+
+		assert.Equal(t, ansiRed+"a"+ansiReset+" failed: boom\n", buf.String())
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		wl := NewWriterListener(&buf, WriterListenerOptions{Compact: true, Events: []NodeEvent{EventToken, NodeEventError}})
+
+		wl.OnNodeEvent(ctx, NodeEventStart, "chat", nil, nil)
+		wl.OnNodeEvent(token("Hi"), EventToken, "chat", nil, nil)
+		wl.OnNodeEvent(token(" there"), EventToken, "chat", nil, nil)
+		wl.OnNodeEvent(ctx, NodeEventComplete, "chat", nil, nil)
+		wl.OnNodeEvent(token("Bye"), EventToken, "summary", nil, nil)
+		wl.OnNodeEvent(ctx, NodeEventError, "summary", nil, errors.New("cut off"))
+
+		assert.Equal(t, "chat: Hi there\nsummary: Bye\nsummary failed: cut off\n", buf.String())
+	})
+
+	t.Run("Graph", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		g := NewListenableStateGraph[map[string]any]()
+		g.AddNode("chat", "chat", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			EmitToken(ctx, "streamed")
+			return state, nil
+		})
+		g.AddEdge("chat", END)
+		g.SetEntryPoint("chat")
+		g.AddGlobalListener(NewWriterListener(&buf, WriterListenerOptions{Compact: true, Events: []NodeEvent{NodeEventStart, EventToken}}))
+		runnable, err := g.CompileListenable()
+		assert.NoError(t, err)
+
+		_, err = runnable.Invoke(ctx, map[string]any{})
+		assert.NoError(t, err)
+		assert.Equal(t, "chat started\nchat: streamed\n", buf.String())
+	})
+}
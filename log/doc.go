@@ -68,7 +68,8 @@
 //
 // # Integration with LangGraph
 //
-// The logger is designed to work with the graph package's listener system:
+// graph.NewLoggingCallbackHandler logs graph runs to a Logger, as a Config
+// callback, a node listener, or both:
 //
 //	import (
 //		"github.com/smallnest/langgraphgo/graph"
@@ -76,13 +77,16 @@
 //	)
 //
 //	logger := log.NewDefaultLogger(log.LogLevelInfo)
+//	handler := graph.NewLoggingCallbackHandler(logger, log.LogLevelInfo)
 //
-//	g := graph.NewStateGraph()
+//	g := graph.NewListenableStateGraph[map[string]any]()
 //	// ... configure graph ...
+//	g.AddGlobalListener(handler)
 //
-//	// Add a logging listener
-//	listener := graph.NewLoggingListener(logger, log.LogLevelInfo, false)
-//	g.AddListener(listener)
+//	runnable, _ := g.CompileListenable()
+//	result, err := runnable.InvokeWithConfig(ctx, input, &graph.Config{
+//		Callbacks: []graph.CallbackHandler{handler},
+//	})
 //
 // # Performance Considerations
 //
@@ -0,0 +1,29 @@
+// Package otel traces graph runs with OpenTelemetry. It is a module of its own,
+// so that applications that don't use OpenTelemetry don't depend on it.
+//
+// # Basic Usage
+//
+//	import (
+//		"github.com/smallnest/langgraphgo/graph"
+//		lgotel "github.com/smallnest/langgraphgo/otel"
+//		"go.opentelemetry.io/otel"
+//	)
+//
+//	handler := lgotel.NewOTelCallbackHandler(otel.Tracer("my-agent"))
+//	result, err := runnable.InvokeWithConfig(ctx, input, &graph.Config{
+//		Callbacks: []graph.CallbackHandler{handler},
+//	})
+//
+// # Spans
+//
+// Each invocation becomes a span named after the graph, or Config.RunName, child
+// of the span active in the context of the invocation. Its children are:
+//
+//   - one span per node execution, from the start of its step until it returns,
+//     with the node's name, description and tags as attributes and its error
+//     recorded when it fails
+//   - one span per tool, LLM and retriever call reported to the handler from a
+//     node, child of the node's span, with start and end events
+//   - the spans of the runs whose OnChainStart has the run as parentRunID, such
+//     as the items of a batch
+package otel
@@ -0,0 +1,327 @@
+package otel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys of the spans of an OTelCallbackHandler
+const (
+	AttrRunID           = attribute.Key("langgraph.run_id")
+	AttrRunType         = attribute.Key("langgraph.run_type")
+	AttrTags            = attribute.Key("langgraph.tags")
+	AttrNodeName        = attribute.Key("langgraph.node.name")
+	AttrNodeDescription = attribute.Key("langgraph.node.description")
+	AttrNodeTags        = attribute.Key("langgraph.node.tags")
+	AttrInput           = attribute.Key("langgraph.input")
+	AttrOutput          = attribute.Key("langgraph.output")
+)
+
+// nodeKey identifies the execution of a node in a run
+type nodeKey struct {
+	runID string
+	node  string
+}
+
+// OTelCallbackHandler is a graph.GraphCallbackHandler creating OpenTelemetry
+// spans for graph runs, their nodes and the tool, LLM and retriever calls of the
+// nodes. It can trace concurrent runs.
+type OTelCallbackHandler struct {
+	tracer trace.Tracer
+
+	mu sync.Mutex
+	// spans are the open spans of runs and calls, by runID
+	spans map[string]trace.Span
+	// nodes are the open spans of node executions
+	nodes map[nodeKey]trace.Span
+	// nodeRuns maps the runID the graph reports a node's completion with to the node
+	nodeRuns map[string]nodeKey
+}
+
+// NewOTelCallbackHandler returns a handler creating spans with tracer
+func NewOTelCallbackHandler(tracer trace.Tracer) *OTelCallbackHandler {
+	return &OTelCallbackHandler{
+		tracer:   tracer,
+		spans:    make(map[string]trace.Span),
+		nodes:    make(map[nodeKey]trace.Span),
+		nodeRuns: make(map[string]nodeKey),
+	}
+}
+
+var (
+	_ graph.GraphCallbackHandler     = (*OTelCallbackHandler)(nil)
+	_ graph.StepStartCallbackHandler = (*OTelCallbackHandler)(nil)
+)
+
+// parent returns the context to start a span reported with parentRunID in: the
+// span of the node running on ctx, else the span of parentRunID, else the span of
+// ctx. The caller holds h.mu.
+func (h *OTelCallbackHandler) parent(ctx context.Context, parentRunID *string) context.Context {
+	if node, ok := graph.NodeNameFromContext(ctx); ok {
+		if config, ok := graph.ConfigFromContext(ctx); ok {
+			if span, ok := h.nodes[nodeKey{runID: config.RunID, node: node}]; ok {
+				return trace.ContextWithSpan(ctx, span)
+			}
+		}
+	}
+	if parentRunID != nil {
+		if span, ok := h.spans[*parentRunID]; ok {
+			return trace.ContextWithSpan(ctx, span)
+		}
+	}
+	return ctx
+}
+
+// start opens the span of the run or call runID
+func (h *OTelCallbackHandler) start(ctx context.Context, runType string, serialized map[string]any, runID string, parentRunID *string, tags []string, attrs ...attribute.KeyValue) trace.Span {
+	name, _ := serialized["name"].(string)
+	if name == "" {
+		name = runType
+	}
+	attrs = append(attrs, AttrRunID.String(runID), AttrRunType.String(runType))
+	if len(tags) > 0 {
+		attrs = append(attrs, AttrTags.StringSlice(tags))
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, span := h.tracer.Start(h.parent(ctx, parentRunID), name, trace.WithAttributes(attrs...))
+	h.spans[runID] = span
+	return span
+}
+
+// take removes the span of runID, and returns nil if it has none
+func (h *OTelCallbackHandler) take(runID string) trace.Span {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	span, ok := h.spans[runID]
+	if !ok {
+		return nil
+	}
+	delete(h.spans, runID)
+	return span
+}
+
+// fail records err on span and ends it
+func fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+}
+
+// endNodes ends the node spans of runID still open, recording err on them if it
+// is set: when a run fails, they are the nodes that failed or didn't finish
+func (h *OTelCallbackHandler) endNodes(runID string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, span := range h.nodes {
+		if key.runID != runID {
+			continue
+		}
+		delete(h.nodes, key)
+		if err != nil {
+			fail(span, err)
+		} else {
+			span.End()
+		}
+	}
+	for nodeRunID, key := range h.nodeRuns {
+		if key.runID == runID {
+			delete(h.nodeRuns, nodeRunID)
+		}
+	}
+}
+
+// OnChainStart opens the span of a graph run
+func (h *OTelCallbackHandler) OnChainStart(ctx context.Context, serialized map[string]any, inputs map[string]any, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	h.start(ctx, "chain", serialized, runID, parentRunID, tags)
+}
+
+// OnChainEnd ends the span of a graph run
+func (h *OTelCallbackHandler) OnChainEnd(ctx context.Context, outputs map[string]any, runID string) {
+	h.endNodes(runID, nil)
+	if span := h.take(runID); span != nil {
+		span.End()
+	}
+}
+
+// OnChainError records the error of a graph run on its span, and on the spans of
+// the nodes that didn't complete
+func (h *OTelCallbackHandler) OnChainError(ctx context.Context, err error, runID string) {
+	h.endNodes(runID, err)
+	if span := h.take(runID); span != nil {
+		fail(span, err)
+	}
+}
+
+// OnGraphStepStart opens the spans of the nodes of a step, children of the span
+// of the run
+func (h *OTelCallbackHandler) OnGraphStepStart(ctx context.Context, nodes []string, state any) {
+	config, ok := graph.ConfigFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	run, ok := h.spans[config.RunID]
+	if !ok {
+		return
+	}
+	parent := trace.ContextWithSpan(ctx, run)
+	for _, node := range nodes {
+		key := nodeKey{runID: config.RunID, node: node}
+		if previous, ok := h.nodes[key]; ok {
+			// A node that was scheduled again without reporting its completion
+			previous.End()
+		}
+		_, span := h.tracer.Start(parent, node, trace.WithAttributes(
+			AttrRunID.String(config.RunID),
+			AttrRunType.String("node"),
+			AttrNodeName.String(node),
+		))
+		h.nodes[key] = span
+	}
+}
+
+// OnGraphStep adds an event for the completed step to the span of the run
+func (h *OTelCallbackHandler) OnGraphStep(ctx context.Context, stepNode string, state any) {
+	config, ok := graph.ConfigFromContext(ctx)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if run, ok := h.spans[config.RunID]; ok {
+		run.AddEvent("step", trace.WithAttributes(AttrNodeName.String(stepNode)))
+	}
+}
+
+// claimNode returns the span of the node whose completion the graph reports as a
+// tool run with runID, and false if the tool run is not a node of a run
+func (h *OTelCallbackHandler) claimNode(ctx context.Context, serialized map[string]any, runID string, parentRunID *string) (trace.Span, bool) {
+	name, _ := serialized["name"].(string)
+	if parentRunID == nil || name == "" {
+		return nil, false
+	}
+	if node, ok := graph.NodeNameFromContext(ctx); ok && node == name {
+		// A call made by the node itself
+		return nil, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := nodeKey{runID: *parentRunID, node: name}
+	span, ok := h.nodes[key]
+	if !ok {
+		return nil, false
+	}
+	if _, claimed := h.nodeRuns[runID]; claimed {
+		return nil, false
+	}
+	h.nodeRuns[runID] = key
+	return span, true
+}
+
+// OnToolStart opens the span of a tool call. The graph reports the completion of
+// each node as a tool run too, which completes the node's span instead.
+func (h *OTelCallbackHandler) OnToolStart(ctx context.Context, serialized map[string]any, inputStr string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	if span, ok := h.claimNode(ctx, serialized, runID, parentRunID); ok {
+		if description, _ := serialized["description"].(string); description != "" {
+			span.SetAttributes(AttrNodeDescription.String(description))
+		}
+		if nodeTags, ok := serialized["tags"].([]string); ok && len(nodeTags) > 0 {
+			span.SetAttributes(AttrNodeTags.StringSlice(nodeTags))
+		}
+		return
+	}
+	span := h.start(ctx, "tool", serialized, runID, parentRunID, tags)
+	span.AddEvent("tool.start", trace.WithAttributes(AttrInput.String(inputStr)))
+}
+
+// OnToolEnd ends the span of a tool call, or of the node it reports
+func (h *OTelCallbackHandler) OnToolEnd(ctx context.Context, output string, runID string) {
+	if span := h.takeNode(runID); span != nil {
+		span.End()
+		return
+	}
+	if span := h.take(runID); span != nil {
+		span.AddEvent("tool.end", trace.WithAttributes(AttrOutput.String(output)))
+		span.End()
+	}
+}
+
+// OnToolError records the error of a tool call on its span
+func (h *OTelCallbackHandler) OnToolError(ctx context.Context, err error, runID string) {
+	if span := h.takeNode(runID); span != nil {
+		fail(span, err)
+		return
+	}
+	if span := h.take(runID); span != nil {
+		fail(span, err)
+	}
+}
+
+// takeNode removes the span of the node reported as the tool run runID, and
+// returns nil if runID is not a node
+func (h *OTelCallbackHandler) takeNode(runID string) trace.Span {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key, ok := h.nodeRuns[runID]
+	if !ok {
+		return nil
+	}
+	delete(h.nodeRuns, runID)
+	span, ok := h.nodes[key]
+	if !ok {
+		return nil
+	}
+	delete(h.nodes, key)
+	return span
+}
+
+// OnLLMStart opens the span of an LLM call
+func (h *OTelCallbackHandler) OnLLMStart(ctx context.Context, serialized map[string]any, prompts []string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	span := h.start(ctx, "llm", serialized, runID, parentRunID, tags)
+	span.AddEvent("llm.start", trace.WithAttributes(attribute.Int("langgraph.llm.prompts", len(prompts))))
+}
+
+// OnLLMEnd ends the span of an LLM call
+func (h *OTelCallbackHandler) OnLLMEnd(ctx context.Context, response any, runID string) {
+	if span := h.take(runID); span != nil {
+		span.AddEvent("llm.end")
+		span.End()
+	}
+}
+
+// OnLLMError records the error of an LLM call on its span
+func (h *OTelCallbackHandler) OnLLMError(ctx context.Context, err error, runID string) {
+	if span := h.take(runID); span != nil {
+		fail(span, err)
+	}
+}
+
+// OnRetrieverStart opens the span of a retrieval
+func (h *OTelCallbackHandler) OnRetrieverStart(ctx context.Context, serialized map[string]any, query string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	span := h.start(ctx, "retriever", serialized, runID, parentRunID, tags)
+	span.AddEvent("retriever.start", trace.WithAttributes(AttrInput.String(query)))
+}
+
+// OnRetrieverEnd ends the span of a retrieval
+func (h *OTelCallbackHandler) OnRetrieverEnd(ctx context.Context, documents []any, runID string) {
+	if span := h.take(runID); span != nil {
+		span.AddEvent("retriever.end", trace.WithAttributes(attribute.Int("langgraph.retriever.documents", len(documents))))
+		span.End()
+	}
+}
+
+// OnRetrieverError records the error of a retrieval on its span
+func (h *OTelCallbackHandler) OnRetrieverError(ctx context.Context, err error, runID string) {
+	if span := h.take(runID); span != nil {
+		fail(span, err)
+	}
+}
@@ -0,0 +1,118 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTracedGraph builds a graph agent -> answer whose agent node reports a call
+// to a search tool, and answer fails if failAnswer is set
+func newTracedGraph(t *testing.T, failAnswer bool) *graph.StateRunnable[map[string]any] {
+	t.Helper()
+	g := graph.NewStateGraph[map[string]any]()
+	g.AddNodeWithOptions("agent", "Decides what to search", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		config, _ := graph.ConfigFromContext(ctx)
+		for _, cb := range config.Callbacks {
+			cb.OnToolStart(ctx, map[string]any{"name": "search"}, "weather", "search-1", &config.RunID, nil, nil)
+			cb.OnToolEnd(ctx, "sunny", "search-1")
+		}
+		return map[string]any{"found": "sunny"}, nil
+	}, graph.WithTags("llm"))
+	g.AddNode("answer", "Answers", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		if failAnswer {
+			return nil, errors.New("no answer")
+		}
+		return map[string]any{"answer": "It is sunny"}, nil
+	})
+	g.AddEdge("agent", "answer")
+	g.AddEdge("answer", graph.END)
+	g.SetEntryPoint("agent")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+	return runnable
+}
+
+// tracedSpans runs runnable with an OTelCallbackHandler and returns the spans it
+// created by name
+func tracedSpans(t *testing.T, runnable *graph.StateRunnable[map[string]any]) (map[string]tracetest.SpanStub, error) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	handler := NewOTelCallbackHandler(provider.Tracer("test"))
+
+	_, err := runnable.InvokeWithConfig(context.Background(), map[string]any{}, &graph.Config{
+		RunName:   "weather",
+		Callbacks: []graph.CallbackHandler{handler},
+	})
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	return spans, err
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func eventNames(span tracetest.SpanStub) []string {
+	var names []string
+	for _, event := range span.Events {
+		names = append(names, event.Name)
+	}
+	return names
+}
+
+func TestOTelCallbackHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Hierarchy", func(t *testing.T) {
+		t.Parallel()
+		spans, err := tracedSpans(t, newTracedGraph(t, false))
+		require.NoError(t, err)
+		require.Len(t, spans, 4)
+
+		run, agent, search, answer := spans["weather"], spans["agent"], spans["search"], spans["answer"]
+		assert.False(t, run.Parent.IsValid())
+		assert.Equal(t, run.SpanContext.SpanID(), agent.Parent.SpanID())
+		assert.Equal(t, agent.SpanContext.SpanID(), search.Parent.SpanID())
+		assert.Equal(t, run.SpanContext.SpanID(), answer.Parent.SpanID())
+		for _, span := range spans {
+			assert.Equal(t, run.SpanContext.TraceID(), span.SpanContext.TraceID())
+		}
+
+		attrs := attributes(agent)
+		assert.Equal(t, "agent", attrs[AttrNodeName].AsString())
+		assert.Equal(t, "Decides what to search", attrs[AttrNodeDescription].AsString())
+		assert.Equal(t, []string{"llm"}, attrs[AttrNodeTags].AsStringSlice())
+		assert.Equal(t, []string{"tool.start", "tool.end"}, eventNames(search))
+		assert.Equal(t, []string{"step", "step"}, eventNames(run))
+		assert.Equal(t, codes.Unset, run.Status.Code)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+		spans, err := tracedSpans(t, newTracedGraph(t, true))
+		require.ErrorContains(t, err, "no answer")
+		require.Len(t, spans, 4)
+
+		assert.Equal(t, codes.Unset, spans["agent"].Status.Code)
+		assert.Equal(t, codes.Error, spans["answer"].Status.Code)
+		assert.Equal(t, []string{"exception"}, eventNames(spans["answer"]))
+		assert.Equal(t, codes.Error, spans["weather"].Status.Code)
+	})
+}
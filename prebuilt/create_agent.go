@@ -2,7 +2,7 @@ package prebuilt
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -20,6 +20,25 @@ type CreateAgentOptions struct {
 	SystemMessage string
 	StateModifier func(messages []llms.MessageContent) []llms.MessageContent
 	MaxIterations int
+
+	// IterationLimit is what the agent does on reaching MaxIterations, see
+	// WithIterationLimitBehavior
+	IterationLimit IterationLimitBehavior
+
+	// Trim limits the messages sent to the model, see WithMessageWindow and
+	// WithTokenBudget. The state keeps the whole conversation.
+	Trim TrimOptions
+
+	// structuredOutput makes the agent end with a "respond" node, see
+	// WithStructuredOutput
+	structuredOutput *structuredOutputOptions
+
+	// Streaming makes the agent stream the text of its model calls as graph
+	// token events, see WithStreaming
+	Streaming bool
+
+	// Checkpointer is the store CreateCheckpointableReactAgent saves its threads in
+	Checkpointer graph.CheckpointStore
 }
 
 type CreateAgentOption func(*CreateAgentOptions)
@@ -44,6 +63,95 @@ func WithMaxIterations(maxIterations int) CreateAgentOption {
 	return func(o *CreateAgentOptions) { o.MaxIterations = maxIterations }
 }
 
+// WithMessageWindow makes the agent send the model at most the n most recent
+// messages of the conversation, besides the system messages, with TrimMessages
+func WithMessageWindow(n int) CreateAgentOption {
+	return func(o *CreateAgentOptions) { o.Trim.MaxMessages = n }
+}
+
+// WithTokenBudget makes the agent send the model the most recent messages of the
+// conversation whose tokens, as counted by counter, fit maxTokens, with
+// TrimMessages. A nil counter is ApproximateTokenCount.
+func WithTokenBudget(maxTokens int, counter func(messages []llms.MessageContent) int) CreateAgentOption {
+	return func(o *CreateAgentOptions) {
+		o.Trim.MaxTokens = maxTokens
+		o.Trim.TokenCounter = counter
+	}
+}
+
+// ErrMaxIterations is the error of the agents set to IterationLimitError when
+// the model is still calling tools after their maximum number of iterations
+var ErrMaxIterations = errors.New("maximum iterations reached")
+
+// IterationLimitBehavior is what an agent does when the model is still calling
+// tools after the maximum number of iterations, each an agent→tools→agent cycle
+type IterationLimitBehavior int
+
+const (
+	// IterationLimitMessage ends the run with a final AI message saying that the
+	// limit was reached. It is the default.
+	IterationLimitMessage IterationLimitBehavior = iota
+
+	// IterationLimitError fails the run with ErrMaxIterations
+	IterationLimitError
+)
+
+// WithIterationLimitBehavior sets what the agent does on reaching its maximum
+// number of iterations, IterationLimitMessage by default
+func WithIterationLimitBehavior(behavior IterationLimitBehavior) CreateAgentOption {
+	return func(o *CreateAgentOptions) { o.IterationLimit = behavior }
+}
+
+// iterationLimitReached returns the final message of an agent reaching
+// maxIterations, or ErrMaxIterations for IterationLimitError
+func iterationLimitReached(behavior IterationLimitBehavior, maxIterations int) (llms.MessageContent, error) {
+	if behavior == IterationLimitError {
+		return llms.MessageContent{}, fmt.Errorf("%w: %d", ErrMaxIterations, maxIterations)
+	}
+	return llms.TextParts(llms.ChatMessageTypeAI, "Maximum iterations reached. Please try a simpler query."), nil
+}
+
+// mapIterationCount returns the iteration count of a map state, which is a float64
+// once the state went through JSON, such as in a checkpoint
+func mapIterationCount(state map[string]any) int {
+	switch count := state["iteration_count"].(type) {
+	case int:
+		return count
+	case int64:
+		return int(count)
+	case float64:
+		return int(count)
+	}
+	return 0
+}
+
+// turnIterationCount returns the iterations of the current turn of a conversation,
+// which are the AI messages after its last human message, for the agents whose
+// state has no iteration count
+func turnIterationCount(messages []llms.MessageContent) int {
+	count := 0
+	for i := len(messages) - 1; i >= 0 && messages[i].Role != llms.ChatMessageTypeHuman; i-- {
+		if messages[i].Role == llms.ChatMessageTypeAI {
+			count++
+		}
+	}
+	return count
+}
+
+// WithStreaming makes the agent node stream the text its model generates with
+// graph.StreamingFunc, so a chat UI can render it as it arrives: listeners of a
+// listenable graph receive EventToken events, and the Config callbacks
+// implementing graph.TokenCallbackHandler receive OnToken calls.
+func WithStreaming(streaming bool) CreateAgentOption {
+	return func(o *CreateAgentOptions) { o.Streaming = streaming }
+}
+
+// WithCheckpointer sets the store CreateCheckpointableReactAgent saves the
+// checkpoints of its threads in
+func WithCheckpointer(checkpoints graph.CheckpointStore) CreateAgentOption {
+	return func(o *CreateAgentOptions) { o.Checkpointer = checkpoints }
+}
+
 // CreateAgentMap creates a new agent graph with map[string]any state
 func CreateAgentMap(model llms.Model, inputTools []tools.Tool, maxIterations int, opts ...CreateAgentOption) (*graph.StateRunnable[map[string]any], error) {
 	options := &CreateAgentOptions{}
@@ -57,6 +165,12 @@ func CreateAgentMap(model llms.Model, inputTools []tools.Tool, maxIterations int
 		maxIterations = options.MaxIterations
 	}
 
+	if options.structuredOutput != nil {
+		if err := options.structuredOutput.validate(false); err != nil {
+			return nil, err
+		}
+	}
+
 	workflow := graph.NewStateGraph[map[string]any]()
 	agentSchema := graph.NewMapSchema()
 	agentSchema.RegisterReducer("messages", graph.AppendReducer)
@@ -110,17 +224,11 @@ func CreateAgentMap(model llms.Model, inputTools []tools.Tool, maxIterations int
 		}
 
 		// Check iteration count
-		iterationCount := 0
-		if count, ok := state["iteration_count"].(int); ok {
-			iterationCount = count
-		}
+		iterationCount := mapIterationCount(state)
 		if iterationCount >= maxIterations {
-			// Max iterations reached, return final message
-			finalMsg := llms.MessageContent{
-				Role: llms.ChatMessageTypeAI,
-				Parts: []llms.ContentPart{
-					llms.TextPart("Maximum iterations reached. Please try a simpler query."),
-				},
+			finalMsg, err := iterationLimitReached(options.IterationLimit, maxIterations)
+			if err != nil {
+				return nil, err
 			}
 			return map[string]any{
 				"messages": []llms.MessageContent{finalMsg},
@@ -146,8 +254,13 @@ func CreateAgentMap(model llms.Model, inputTools []tools.Tool, maxIterations int
 		if options.StateModifier != nil {
 			msgsToSend = options.StateModifier(msgsToSend)
 		}
+		msgsToSend = TrimMessages(msgsToSend, options.Trim)
 
-		resp, err := model.GenerateContent(ctx, msgsToSend, llms.WithTools(toolDefs))
+		callOpts := []llms.CallOption{llms.WithTools(toolDefs)}
+		if options.Streaming {
+			callOpts = append(callOpts, graph.StreamingFunc(ctx))
+		}
+		resp, err := model.GenerateContent(ctx, msgsToSend, callOpts...)
 		if err != nil {
 			return nil, err
 		}
@@ -180,31 +293,7 @@ func CreateAgentMap(model llms.Model, inputTools []tools.Tool, maxIterations int
 		var toolMessages []llms.MessageContent
 		for _, part := range lastMsg.Parts {
 			if tc, ok := part.(llms.ToolCall); ok {
-				// Get the tool to check if it has a custom schema
-				tool, hasTool := toolExecutor.Tools[tc.FunctionCall.Name]
-
-				var inputVal string
-				if hasTool {
-					// Check if tool has custom schema
-					if _, hasCustomSchema := tool.(ToolWithSchema); hasCustomSchema {
-						// Tool has custom schema, pass JSON arguments directly
-						inputVal = tc.FunctionCall.Arguments
-					} else {
-						// Tool uses default schema, try to extract "input" field
-						var args map[string]any
-						_ = json.Unmarshal([]byte(tc.FunctionCall.Arguments), &args)
-						if val, ok := args["input"].(string); ok {
-							inputVal = val
-						} else {
-							inputVal = tc.FunctionCall.Arguments
-						}
-					}
-				} else {
-					// Tool not found, use arguments as-is
-					inputVal = tc.FunctionCall.Arguments
-				}
-
-				res, err := toolExecutor.Execute(ctx, ToolInvocation{Tool: tc.FunctionCall.Name, ToolInput: inputVal})
+				res, err := toolExecutor.Execute(ctx, toolCallInvocation(toolExecutor, tc))
 				if err != nil {
 					res = fmt.Sprintf("Error: %v", err)
 				}
@@ -224,22 +313,30 @@ func CreateAgentMap(model llms.Model, inputTools []tools.Tool, maxIterations int
 		workflow.SetEntryPoint("agent")
 	}
 
-	workflow.AddConditionalEdge("agent", func(ctx context.Context, state map[string]any) string {
-		messages := state["messages"].([]llms.MessageContent)
-		lastMsg := messages[len(messages)-1]
-		for _, part := range lastMsg.Parts {
-			if _, ok := part.(llms.ToolCall); ok {
-				return "tools"
+	endTarget := graph.END
+	if options.structuredOutput != nil {
+		workflow.AddNode("respond", "Structured output node", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+			messages, _ := state["messages"].([]llms.MessageContent)
+			output, err := options.structuredOutput.respond(ctx, model, messages)
+			if err != nil {
+				return nil, err
 			}
-		}
-		return graph.END
-	})
+			return map[string]any{"structured_output": output}, nil
+		})
+		workflow.AddEdge("respond", graph.END)
+		endTarget = "respond"
+	}
+	workflow.AddConditionalEdge("agent", ToolsCondition("tools", endTarget))
 	workflow.AddEdge("tools", "agent")
 
 	return workflow.Compile()
 }
 
-// CreateAgent creates a generic agent graph
+// CreateAgent creates a generic agent graph. S has no iteration count, so with
+// WithMaxIterations the agent counts the AI messages since the last human message
+// as the iterations of the turn, and applies WithIterationLimitBehavior on
+// reaching the maximum; without it the agent calls the model until it stops
+// calling tools.
 func CreateAgent[S any](
 	model llms.Model,
 	inputTools []tools.Tool,
@@ -258,6 +355,13 @@ func CreateAgent[S any](
 
 	workflow.AddNode("agent", "Agent decision node", func(ctx context.Context, state S) (S, error) {
 		messages := getMessages(state)
+		if options.MaxIterations > 0 && turnIterationCount(messages) >= options.MaxIterations {
+			finalMsg, err := iterationLimitReached(options.IterationLimit, options.MaxIterations)
+			if err != nil {
+				return state, err
+			}
+			return setMessages(state, append(messages, finalMsg)), nil
+		}
 		allTools := append(inputTools, getExtraTools(state)...)
 
 		var toolDefs []llms.Tool
@@ -279,8 +383,13 @@ func CreateAgent[S any](
 		if options.StateModifier != nil {
 			msgsToSend = options.StateModifier(msgsToSend)
 		}
+		msgsToSend = TrimMessages(msgsToSend, options.Trim)
 
-		resp, err := model.GenerateContent(ctx, msgsToSend, llms.WithTools(toolDefs))
+		callOpts := []llms.CallOption{llms.WithTools(toolDefs)}
+		if options.Streaming {
+			callOpts = append(callOpts, graph.StreamingFunc(ctx))
+		}
+		resp, err := model.GenerateContent(ctx, msgsToSend, callOpts...)
 		if err != nil {
 			return state, err
 		}
@@ -305,31 +414,7 @@ func CreateAgent[S any](
 		var toolMessages []llms.MessageContent
 		for _, part := range lastMsg.Parts {
 			if tc, ok := part.(llms.ToolCall); ok {
-				// Get the tool to check if it has a custom schema
-				tool, hasTool := toolExecutor.Tools[tc.FunctionCall.Name]
-
-				var inputVal string
-				if hasTool {
-					// Check if tool has custom schema
-					if _, hasCustomSchema := tool.(ToolWithSchema); hasCustomSchema {
-						// Tool has custom schema, pass JSON arguments directly
-						inputVal = tc.FunctionCall.Arguments
-					} else {
-						// Tool uses default schema, try to extract "input" field
-						var args map[string]any
-						_ = json.Unmarshal([]byte(tc.FunctionCall.Arguments), &args)
-						if val, ok := args["input"].(string); ok {
-							inputVal = val
-						} else {
-							inputVal = tc.FunctionCall.Arguments
-						}
-					}
-				} else {
-					// Tool not found, use arguments as-is
-					inputVal = tc.FunctionCall.Arguments
-				}
-
-				res, err := toolExecutor.Execute(ctx, ToolInvocation{Tool: tc.FunctionCall.Name, ToolInput: inputVal})
+				res, err := toolExecutor.Execute(ctx, toolCallInvocation(toolExecutor, tc))
 				if err != nil {
 					res = fmt.Sprintf("Error: %v", err)
 				}
@@ -343,16 +428,7 @@ func CreateAgent[S any](
 	})
 
 	workflow.SetEntryPoint("agent")
-	workflow.AddConditionalEdge("agent", func(ctx context.Context, state S) string {
-		messages := getMessages(state)
-		lastMsg := messages[len(messages)-1]
-		for _, part := range lastMsg.Parts {
-			if _, ok := part.(llms.ToolCall); ok {
-				return "tools"
-			}
-		}
-		return graph.END
-	})
+	workflow.AddConditionalEdge("agent", ToolsConditionTyped(getMessages, "tools", graph.END))
 	workflow.AddEdge("tools", "agent")
 
 	return workflow.Compile()
@@ -4,11 +4,22 @@ import (
 	"context"
 	"testing"
 
+	"github.com/smallnest/langgraphgo/graph"
 	"github.com/stretchr/testify/assert"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/tools"
 )
 
+// tokenRecorder records the chunks streamed to it as node:chunk
+type tokenRecorder struct {
+	graph.NoOpCallbackHandler
+	chunks []string
+}
+
+func (r *tokenRecorder) OnToken(_ context.Context, nodeName string, chunk string) {
+	r.chunks = append(r.chunks, nodeName+":"+chunk)
+}
+
 func TestCreateAgentMap(t *testing.T) {
 	mockLLM := &MockLLM{}
 	inputTools := []tools.Tool{}
@@ -69,6 +80,33 @@ func TestCreateAgentMap(t *testing.T) {
 		assert.NotNil(t, agent)
 	})
 
+	t.Run("Agent with Streaming", func(t *testing.T) {
+		model := &MockModel{responses: []string{"Hello there friend"}}
+		agent, err := CreateAgentMap(model, inputTools, 0, WithStreaming(true))
+		assert.NoError(t, err)
+
+		tokens := &tokenRecorder{}
+		_, err = agent.InvokeWithConfig(context.Background(),
+			map[string]any{"messages": []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")}},
+			&graph.Config{Callbacks: []graph.CallbackHandler{tokens}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"agent:Hello ", "agent:there ", "agent:friend"}, tokens.chunks)
+	})
+
+	t.Run("Agent with listener", func(t *testing.T) {
+		agent, err := CreateAgentMap(&MockModel{responses: []string{"Hi"}}, inputTools, 0)
+		assert.NoError(t, err)
+
+		var events []string
+		agent.AddListener(graph.NodeListenerFunc[map[string]any](func(ctx context.Context, event graph.NodeEvent, nodeName string, state map[string]any, err error) {
+			events = append(events, nodeName+":"+string(event))
+		}))
+		_, err = agent.Invoke(context.Background(),
+			map[string]any{"messages": []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"agent:start", "agent:complete"}, events)
+	})
+
 	t.Run("Agent Invoke with messages", func(t *testing.T) {
 		mockLLM := &MockLLMWithInputCapture{}
 		agent, err := CreateAgentMap(mockLLM, inputTools, 0)
@@ -276,6 +314,98 @@ func TestCreateAgentWithToolCalls(t *testing.T) {
 	})
 }
 
+func TestCreateAgentMapMaxIterations(t *testing.T) {
+	input := func() map[string]any {
+		return map[string]any{"messages": []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Beijing?")}}
+	}
+
+	t.Run("Message", func(t *testing.T) {
+		mockLLM := &LoopingMockLLM{}
+		agent, err := CreateAgentMap(mockLLM, []tools.Tool{NewWeatherTool(25)}, 4)
+		assert.NoError(t, err)
+
+		res, err := agent.Invoke(context.Background(), input())
+		assert.NoError(t, err)
+		assert.Equal(t, 4, mockLLM.callCount)
+
+		messages := res["messages"].([]llms.MessageContent)
+		assert.Equal(t, 4, toolMessageCount(messages))
+		last := messages[len(messages)-1]
+		assert.Equal(t, llms.ChatMessageTypeAI, last.Role)
+		assert.Equal(t, "Maximum iterations reached. Please try a simpler query.", last.Parts[0].(llms.TextContent).Text)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockLLM := &LoopingMockLLM{}
+		agent, err := CreateAgentMap(mockLLM, []tools.Tool{NewWeatherTool(25)}, 4, WithIterationLimitBehavior(IterationLimitError))
+		assert.NoError(t, err)
+
+		_, err = agent.Invoke(context.Background(), input())
+		assert.ErrorIs(t, err, ErrMaxIterations)
+		assert.Equal(t, 4, mockLLM.callCount)
+	})
+
+	t.Run("Decoded count", func(t *testing.T) {
+		// The count of a state decoded from JSON, such as a checkpoint, is a float64
+		mockLLM := &LoopingMockLLM{}
+		agent, err := CreateAgentMap(mockLLM, []tools.Tool{NewWeatherTool(25)}, 4)
+		assert.NoError(t, err)
+
+		state := input()
+		state["iteration_count"] = float64(3)
+		_, err = agent.Invoke(context.Background(), state)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, mockLLM.callCount)
+	})
+}
+
+func TestCreateAgentGenericMaxIterations(t *testing.T) {
+	newAgent := func(model llms.Model, opts ...CreateAgentOption) *graph.StateRunnable[AgentState] {
+		agent, err := CreateAgent[AgentState](
+			model,
+			[]tools.Tool{NewWeatherTool(25)},
+			func(s AgentState) []llms.MessageContent { return s.Messages },
+			func(s AgentState, msgs []llms.MessageContent) AgentState {
+				s.Messages = msgs
+				return s
+			},
+			func(s AgentState) []tools.Tool { return s.ExtraTools },
+			func(s AgentState, tools []tools.Tool) AgentState {
+				s.ExtraTools = tools
+				return s
+			},
+			opts...,
+		)
+		assert.NoError(t, err)
+		return agent
+	}
+	input := func() AgentState {
+		return AgentState{Messages: []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+			llms.TextParts(llms.ChatMessageTypeAI, "Hello!"),
+			llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Beijing?"),
+		}}
+	}
+
+	t.Run("Message", func(t *testing.T) {
+		mockLLM := &LoopingMockLLM{}
+		res, err := newAgent(mockLLM, WithMaxIterations(3)).Invoke(context.Background(), input())
+		assert.NoError(t, err)
+		assert.Equal(t, 3, mockLLM.callCount, "the AI messages of earlier turns don't count")
+		assert.Equal(t, 3, toolMessageCount(res.Messages))
+		last := res.Messages[len(res.Messages)-1]
+		assert.Equal(t, llms.ChatMessageTypeAI, last.Role)
+		assert.Equal(t, "Maximum iterations reached. Please try a simpler query.", last.Parts[0].(llms.TextContent).Text)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockLLM := &LoopingMockLLM{}
+		_, err := newAgent(mockLLM, WithMaxIterations(3), WithIterationLimitBehavior(IterationLimitError)).Invoke(context.Background(), input())
+		assert.ErrorIs(t, err, ErrMaxIterations)
+		assert.Equal(t, 3, mockLLM.callCount)
+	})
+}
+
 // Mock structures for testing
 type MockLLM struct {
 	llms.Model
@@ -368,3 +498,82 @@ func (t *MockToolWithResponse) Call(ctx context.Context, input string) (string,
 	}
 	return "Mock tool response", nil
 }
+
+// MockLLMWithToolsCapture records the tools it is called with, and answers with
+// the tool calls of its first response, then with a final message
+type MockLLMWithToolsCapture struct {
+	llms.Model
+	toolCalls []llms.ToolCall
+	tools     []llms.Tool
+	callCount int
+}
+
+func (m *MockLLMWithToolsCapture) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var opts llms.CallOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	m.tools = opts.Tools
+
+	m.callCount++
+	if m.callCount == 1 && len(m.toolCalls) > 0 {
+		return &llms.ContentResponse{Choices: []*llms.ContentChoice{{ToolCalls: m.toolCalls}}}, nil
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "Done"}}}, nil
+}
+
+// MockToolWithSchema is a tool with a custom parameter schema, recording its input
+type MockToolWithSchema struct {
+	MockToolWithResponse
+	schema map[string]any
+	input  string
+}
+
+func (t *MockToolWithSchema) Schema() map[string]any {
+	return t.schema
+}
+
+func (t *MockToolWithSchema) Call(ctx context.Context, input string) (string, error) {
+	t.input = input
+	return "Booked", nil
+}
+
+func TestCreateAgentMapToolSchemas(t *testing.T) {
+	booking := &MockToolWithSchema{
+		MockToolWithResponse: MockToolWithResponse{name: "book_hotel", description: "Books a hotel"},
+		schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city":   map[string]any{"type": "string"},
+				"nights": map[string]any{"type": "integer"},
+			},
+			"required": []string{"city", "nights"},
+		},
+	}
+	search := &MockToolWithResponse{name: "search", description: "Searches the web"}
+	mockLLM := &MockLLMWithToolsCapture{toolCalls: []llms.ToolCall{{
+		ID:           "call_1",
+		Type:         "function",
+		FunctionCall: &llms.FunctionCall{Name: "book_hotel", Arguments: `{"city":"Paris","nights":2}`},
+	}}}
+
+	agent, err := CreateAgentMap(mockLLM, []tools.Tool{booking, search}, 0)
+	assert.NoError(t, err)
+	_, err = agent.Invoke(context.Background(), map[string]any{
+		"messages": []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Book two nights in Paris")},
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, mockLLM.tools, 2) {
+		assert.Equal(t, "book_hotel", mockLLM.tools[0].Function.Name)
+		assert.Equal(t, booking.schema, mockLLM.tools[0].Function.Parameters)
+
+		// A tool without a schema takes a single "input" string
+		params := mockLLM.tools[1].Function.Parameters.(map[string]any)
+		assert.Equal(t, []string{"input"}, params["required"])
+		assert.Contains(t, params["properties"], "input")
+	}
+
+	// A tool with a schema receives the JSON arguments of the call
+	assert.Equal(t, `{"city":"Paris","nights":2}`, booking.input)
+}
@@ -47,13 +47,24 @@
 //		IterationCount int                    `json:"iteration_count"`
 //	}
 //
-//	agent, err := prebuilt.CreateReactAgentTyped[AgentState](
-//		llm,
-//		tools,
+//	agent, err := prebuilt.CreateReactAgent(llm, tools,
+//		func(s AgentState) []llms.MessageContent { return s.Messages },
+//		func(s AgentState, m []llms.MessageContent) AgentState { s.Messages = m; return s },
+//		func(s AgentState) int { return s.IterationCount },
+//		func(s AgentState, n int) AgentState { s.IterationCount = n; return s },
 //		10,
-//		func() AgentState { return AgentState{} },
 //	)
 //
+// CreateCheckpointableReactAgent takes the same arguments and checkpoints the
+// agent's threads, so that invoking it again with a thread_id continues the
+// conversation, across restarts with a persistent store:
+//
+//	agent, err := prebuilt.CreateCheckpointableReactAgent(llm, tools,
+//		getMessages, setMessages, getIterationCount, setIterationCount, 10,
+//		prebuilt.WithCheckpointer(store),
+//	)
+//	state, err := agent.InvokeWithConfig(ctx, AgentState{Messages: question}, graph.WithThreadID("user-1"))
+//
 // ## Supervisor Agent
 // Orchestrates multiple specialized agents, routing tasks to the appropriate agent:
 //
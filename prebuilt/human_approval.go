@@ -0,0 +1,229 @@
+package prebuilt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// HumanApprovalOptions configures NewHumanApprovalNode
+type HumanApprovalOptions struct {
+	// Tools are the names of the tools whose calls need an approval, all of them
+	// when empty
+	Tools []string
+
+	// ReplaceHistory makes the node return the whole history with the decision
+	// applied, for graphs whose "messages" key has graph.OverwriteReducer or no
+	// reducer, and whose nodes return the whole history too, as ToolNode does. It
+	// is needed to edit the arguments of tool calls, which rewrites the AI message.
+	ReplaceHistory bool
+}
+
+// ErrApprovalEditNeedsHistory is returned by a human approval node resumed with
+// edited arguments when HumanApprovalOptions.ReplaceHistory is not set
+var ErrApprovalEditNeedsHistory = errors.New("editing tool call arguments needs HumanApprovalOptions.ReplaceHistory")
+
+// ApprovalRequest is the value of the interrupt raised by a human approval node,
+// listing the tool calls waiting for a decision
+type ApprovalRequest struct {
+	ToolCalls []PendingToolCall `json:"tool_calls"`
+}
+
+// PendingToolCall is a tool call of the model waiting for an approval
+type PendingToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCallDecision is the decision on a tool call
+type ToolCallDecision struct {
+	// Approved lets the tools node execute the call
+	Approved bool `json:"approved"`
+
+	// Arguments, when set, replaces the JSON arguments of an approved call
+	Arguments string `json:"arguments,omitempty"`
+
+	// Reason tells the model why a call was denied
+	Reason string `json:"reason,omitempty"`
+}
+
+// ApprovalDecision is the value a thread interrupted by a human approval node is
+// resumed with. The embedded decision applies to the tool calls without a
+// decision of their own in ToolCalls, which is keyed by tool call ID. A bool
+// approves or denies every call, and a map decoded from JSON, such as the resume
+// value sent to graph/httpserve, is read as an ApprovalDecision.
+type ApprovalDecision struct {
+	ToolCallDecision
+	ToolCalls map[string]ToolCallDecision `json:"tool_calls,omitempty"`
+}
+
+// NewHumanApprovalNode returns a node for map[string]any state that pauses the
+// run before the tool calls of the last AI message of "messages" are executed.
+// It raises a graph interrupt with an ApprovalRequest listing the calls needing
+// an approval, and, when the thread is resumed with an ApprovalDecision, applies
+// the edited arguments of the approved calls and answers the denied ones with a
+// tool message carrying the reason. The tools node then only executes the calls
+// left unanswered. The node returns nothing when no call needs an approval.
+//
+// Resuming needs a checkpointable graph. The node returns only the messages it
+// adds under "messages", the tool messages answering the denied calls, like
+// ToolNodeMap, so the key takes graph.AppendReducer or graph.AddMessages. Reducers
+// that append can't replace the AI message, so editing arguments needs
+// HumanApprovalOptions.ReplaceHistory and an overwriting "messages" key. Route
+// from the node with ApprovalCondition.
+//
+// Example:
+//
+//	workflow.AddNode("approve", "Human approval", prebuilt.NewHumanApprovalNode(prebuilt.HumanApprovalOptions{Tools: []string{"shell"}}))
+//	workflow.AddConditionalEdge("agent", prebuilt.ToolsCondition("approve", graph.END))
+//	workflow.AddConditionalEdge("approve", prebuilt.ApprovalCondition("tools", "agent"))
+//
+//	_, err := runnable.InvokeWithConfig(ctx, input, graph.WithThreadID("t1"))
+//	// err is a *graph.GraphInterrupt whose InterruptValue is a prebuilt.ApprovalRequest
+//	decision := prebuilt.ApprovalDecision{ToolCallDecision: prebuilt.ToolCallDecision{Approved: true}}
+//	state, err := runnable.InvokeCommand(ctx, &graph.Command{Resume: decision}, graph.WithThreadID("t1"))
+func NewHumanApprovalNode(opts HumanApprovalOptions) func(context.Context, map[string]any) (map[string]any, error) {
+	needsApproval := func(name string) bool {
+		return len(opts.Tools) == 0 || slices.Contains(opts.Tools, name)
+	}
+
+	return func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		messages, _ := state["messages"].([]llms.MessageContent)
+		calls, _ := pendingToolCalls(messages)
+
+		var request ApprovalRequest
+		for _, tc := range calls {
+			if tc.FunctionCall != nil && needsApproval(tc.FunctionCall.Name) {
+				request.ToolCalls = append(request.ToolCalls, PendingToolCall{
+					ID:        tc.ID,
+					Name:      tc.FunctionCall.Name,
+					Arguments: tc.FunctionCall.Arguments,
+				})
+			}
+		}
+		if len(request.ToolCalls) == 0 {
+			return nil, nil
+		}
+
+		resume, err := graph.Interrupt(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		decision, err := approvalDecision(resume)
+		if err != nil {
+			return nil, err
+		}
+
+		history, denied, edited := approvalMessages(messages, request, decision)
+		if opts.ReplaceHistory {
+			return map[string]any{"messages": append(history, denied...)}, nil
+		}
+		if edited {
+			return nil, ErrApprovalEditNeedsHistory
+		}
+		if len(denied) == 0 {
+			return nil, nil
+		}
+		return map[string]any{"messages": denied}, nil
+	}
+}
+
+// approvalDecision reads the value a human approval node was resumed with
+func approvalDecision(resume any) (ApprovalDecision, error) {
+	var decision ApprovalDecision
+	switch v := resume.(type) {
+	case ApprovalDecision:
+		return v, nil
+	case *ApprovalDecision:
+		if v != nil {
+			return *v, nil
+		}
+		return decision, nil
+	case bool:
+		decision.Approved = v
+		return decision, nil
+	}
+
+	data, err := json.Marshal(resume)
+	if err != nil {
+		return decision, fmt.Errorf("invalid approval decision %T: %w", resume, err)
+	}
+	if err := json.Unmarshal(data, &decision); err != nil {
+		return decision, fmt.Errorf("invalid approval decision %s: %w", data, err)
+	}
+	return decision, nil
+}
+
+// approvalMessages applies the decision on the calls of request. It returns a
+// copy of messages whose last AI message has the edited arguments of the approved
+// calls, a tool message answering each denied call, and whether arguments were
+// edited.
+func approvalMessages(messages []llms.MessageContent, request ApprovalRequest, decision ApprovalDecision) (history, denied []llms.MessageContent, edited bool) {
+	decisions := make(map[string]ToolCallDecision, len(request.ToolCalls))
+	for _, call := range request.ToolCalls {
+		d, ok := decision.ToolCalls[call.ID]
+		if !ok {
+			d = decision.ToolCallDecision
+		}
+		decisions[call.ID] = d
+	}
+
+	history = slices.Clone(messages)
+	ai := len(history) - 1
+	for ai >= 0 && history[ai].Role != llms.ChatMessageTypeAI {
+		ai--
+	}
+
+	parts := slices.Clone(history[ai].Parts)
+	for i, part := range parts {
+		tc, ok := part.(llms.ToolCall)
+		if !ok {
+			continue
+		}
+		d, ok := decisions[tc.ID]
+		switch {
+		case !ok:
+		case !d.Approved:
+			content := "Tool call denied by the user"
+			if d.Reason != "" {
+				content += ": " + d.Reason
+			}
+			denied = append(denied, llms.MessageContent{
+				Role: llms.ChatMessageTypeTool,
+				Parts: []llms.ContentPart{llms.ToolCallResponse{
+					ToolCallID: tc.ID,
+					Name:       tc.FunctionCall.Name,
+					Content:    content,
+				}},
+			})
+		case d.Arguments != "":
+			call := *tc.FunctionCall
+			call.Arguments = d.Arguments
+			tc.FunctionCall = &call
+			parts[i] = tc
+			edited = true
+		}
+	}
+	history[ai].Parts = parts
+
+	return history, denied, edited
+}
+
+// ApprovalCondition returns the router of a human approval node: it routes to
+// toolsNode while tool calls of the last AI message are left to execute, and to
+// agentNode, which answers the denied calls, otherwise
+func ApprovalCondition(toolsNode, agentNode string) func(context.Context, map[string]any) string {
+	return func(ctx context.Context, state map[string]any) string {
+		messages, _ := state["messages"].([]llms.MessageContent)
+		if calls, _ := pendingToolCalls(messages); len(calls) > 0 {
+			return toolsNode
+		}
+		return agentNode
+	}
+}
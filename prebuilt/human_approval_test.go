@@ -0,0 +1,186 @@
+package prebuilt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// RecordingTool records the inputs it is called with
+type RecordingTool struct {
+	name   string
+	inputs []string
+}
+
+func (t *RecordingTool) Name() string        { return t.name }
+func (t *RecordingTool) Description() string { return "Records its input" }
+
+func (t *RecordingTool) Call(ctx context.Context, input string) (string, error) {
+	t.inputs = append(t.inputs, input)
+	return t.name + " done", nil
+}
+
+// newApprovalGraph returns an agent whose model calls calculator and search
+// once, with the calculator calls needing an approval. The nodes return the
+// messages they add, which reducer merges into the history, or with a nil
+// reducer the whole history, as the approval node does with ReplaceHistory.
+func newApprovalGraph(t *testing.T, reducer graph.Reducer, calculator, search *RecordingTool) *graph.CheckpointableRunnable[map[string]any] {
+	t.Helper()
+	executor := NewToolExecutor([]tools.Tool{calculator, search})
+	replace := reducer == nil
+
+	schema := graph.NewMapSchema()
+	if !replace {
+		schema.RegisterReducer("messages", reducer)
+	}
+	workflow := graph.NewCheckpointableStateGraph[map[string]any]()
+	workflow.SetSchema(schema)
+	workflow.AddNode("agent", "agent", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		messages := state["messages"].([]llms.MessageContent)
+		reply := llms.MessageContent{
+			Role: llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{
+				llms.ToolCall{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "calculator", Arguments: `{"input": "2+2"}`}},
+				llms.ToolCall{ID: "call_2", Type: "function", FunctionCall: &llms.FunctionCall{Name: "search", Arguments: `{"input": "news"}`}},
+			},
+		}
+		if messages[len(messages)-1].Role == llms.ChatMessageTypeTool {
+			reply = llms.TextParts(llms.ChatMessageTypeAI, "done")
+		}
+		if replace {
+			return map[string]any{"messages": append(messages, reply)}, nil
+		}
+		return map[string]any{"messages": []llms.MessageContent{reply}}, nil
+	})
+	workflow.AddNode("approve", "approve", NewHumanApprovalNode(HumanApprovalOptions{Tools: []string{"calculator"}, ReplaceHistory: replace}))
+	if replace {
+		workflow.AddNode("tools", "tools", ToolNode(executor,
+			func(s map[string]any) []llms.MessageContent { return s["messages"].([]llms.MessageContent) },
+			func(s map[string]any, messages []llms.MessageContent) map[string]any {
+				return map[string]any{"messages": messages}
+			},
+		))
+	} else {
+		workflow.AddNode("tools", "tools", ToolNodeMap(executor))
+	}
+	workflow.SetEntryPoint("agent")
+	workflow.AddConditionalEdge("agent", ToolsCondition("approve", graph.END))
+	workflow.AddConditionalEdge("approve", ApprovalCondition("tools", "agent"))
+	workflow.AddEdge("tools", "agent")
+
+	runnable, err := workflow.CompileCheckpointable()
+	require.NoError(t, err)
+	return runnable
+}
+
+func TestHumanApprovalNode(t *testing.T) {
+	ctx := context.Background()
+	input := func() map[string]any {
+		return map[string]any{"messages": []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "2+2?")}}
+	}
+	// run starts a thread, checks its approval request, and resumes it with resume
+	run := func(t *testing.T, reducer graph.Reducer, thread string, resume any) (map[string]any, *RecordingTool, *RecordingTool) {
+		calculator, search := &RecordingTool{name: "calculator"}, &RecordingTool{name: "search"}
+		runnable := newApprovalGraph(t, reducer, calculator, search)
+
+		var interrupt *graph.GraphInterrupt
+		_, err := runnable.InvokeWithConfig(ctx, input(), graph.WithThreadID(thread))
+		require.ErrorAs(t, err, &interrupt)
+		assert.Equal(t, "approve", interrupt.Node)
+		assert.Equal(t, ApprovalRequest{ToolCalls: []PendingToolCall{
+			{ID: "call_1", Name: "calculator", Arguments: `{"input": "2+2"}`},
+		}}, interrupt.InterruptValue)
+		assert.Empty(t, calculator.inputs, "no tool runs before the approval")
+		assert.Empty(t, search.inputs)
+
+		res, err := runnable.InvokeCommand(ctx, &graph.Command{Resume: resume}, graph.WithThreadID(thread))
+		require.NoError(t, err)
+		return res, calculator, search
+	}
+
+	t.Run("Approved", func(t *testing.T) {
+		res, calculator, search := run(t, graph.AppendReducer, "approved", ApprovalDecision{ToolCallDecision: ToolCallDecision{Approved: true}})
+		assert.Equal(t, []string{"2+2"}, calculator.inputs)
+		assert.Equal(t, []string{"news"}, search.inputs)
+		assert.Equal(t, []string{"human:2+2?", "ai:call_1", "tool:call_1", "tool:call_2", "ai:done"},
+			messageTexts(res["messages"].([]llms.MessageContent)))
+	})
+
+	for name, reducer := range map[string]graph.Reducer{
+		"Denied":                     graph.AppendReducer,
+		"Denied with AddMessages":    graph.AddMessages,
+		"Denied with ReplaceHistory": nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			res, calculator, search := run(t, reducer, "denied", ApprovalDecision{ToolCallDecision: ToolCallDecision{Reason: "not now"}})
+			assert.Empty(t, calculator.inputs)
+			assert.Equal(t, []string{"news"}, search.inputs, "the calls without an approval still run")
+
+			messages := res["messages"].([]llms.MessageContent)
+			assert.Equal(t, []string{"human:2+2?", "ai:call_1", "tool:call_1", "tool:call_2", "ai:done"}, messageTexts(messages))
+			assert.Equal(t, llms.ToolCallResponse{ToolCallID: "call_1", Name: "calculator", Content: "Tool call denied by the user: not now"},
+				messages[2].Parts[0])
+		})
+	}
+
+	t.Run("Edited", func(t *testing.T) {
+		// The decision decoded from JSON, as sent to graph/httpserve
+		res, calculator, _ := run(t, nil, "edited", map[string]any{
+			"tool_calls": map[string]any{"call_1": map[string]any{"approved": true, "arguments": `{"input": "3+3"}`}},
+		})
+		assert.Equal(t, []string{"3+3"}, calculator.inputs)
+		messages := res["messages"].([]llms.MessageContent)
+		assert.Equal(t, []string{"human:2+2?", "ai:call_1", "tool:call_1", "tool:call_2", "ai:done"}, messageTexts(messages),
+			"the edited AI message replaces the original")
+		call := messages[1].Parts[0].(llms.ToolCall)
+		assert.Equal(t, `{"input": "3+3"}`, call.FunctionCall.Arguments)
+	})
+
+	t.Run("Edited without ReplaceHistory", func(t *testing.T) {
+		node := NewHumanApprovalNode(HumanApprovalOptions{})
+		state := map[string]any{"messages": []llms.MessageContent{{
+			Role:  llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "calculator"}}},
+		}}}
+
+		_, err := node(graph.WithResumeValue(ctx, ApprovalDecision{ToolCallDecision: ToolCallDecision{Approved: true, Arguments: `{"input": "3+3"}`}}), state)
+		assert.ErrorIs(t, err, ErrApprovalEditNeedsHistory, "appending reducers can't replace the AI message")
+	})
+
+	t.Run("Every call denied", func(t *testing.T) {
+		node := NewHumanApprovalNode(HumanApprovalOptions{})
+		state := map[string]any{"messages": []llms.MessageContent{{
+			Role:  llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "calculator"}}},
+		}}}
+
+		update, err := node(ctx, state)
+		var interrupt *graph.NodeInterrupt
+		require.ErrorAs(t, err, &interrupt)
+		assert.Nil(t, update, "the interrupted node changes nothing")
+
+		update, err = node(graph.WithResumeValue(ctx, false), state)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tool:call_1"}, messageTexts(update["messages"].([]llms.MessageContent)), "only the denial is returned")
+
+		merged, err := graph.AppendReducer(state["messages"], update["messages"])
+		require.NoError(t, err)
+		assert.Equal(t, "agent", ApprovalCondition("tools", "agent")(ctx, map[string]any{"messages": merged}))
+		assert.Equal(t, "tools", ApprovalCondition("tools", "agent")(ctx, state))
+	})
+
+	t.Run("No approval needed", func(t *testing.T) {
+		node := NewHumanApprovalNode(HumanApprovalOptions{Tools: []string{"shell"}})
+		update, err := node(ctx, map[string]any{"messages": []llms.MessageContent{{
+			Role:  llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "search"}}},
+		}}})
+		require.NoError(t, err)
+		assert.Nil(t, update)
+	})
+}
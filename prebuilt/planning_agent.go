@@ -120,7 +120,7 @@ func CreatePlanningAgentMap(model llms.Model, availableNodes []graph.TypedNode[m
 			return nil, err
 		}
 
-		return runnable.Invoke(ctx, state)
+		return runnable.InvokeWithConfig(ctx, state, graph.NestedConfig(ctx))
 	})
 
 	workflow.SetEntryPoint("planner")
@@ -242,7 +242,7 @@ func CreatePlanningAgent[S any](
 			return state, err
 		}
 
-		return runnable.Invoke(ctx, state)
+		return runnable.InvokeWithConfig(ctx, state, graph.NestedConfig(ctx))
 	})
 
 	workflow.SetEntryPoint("planner")
@@ -2,6 +2,7 @@ package prebuilt
 
 import (
 	"context"
+	"sync"
 	"testing"
 
 	"github.com/smallnest/langgraphgo/graph"
@@ -120,3 +121,68 @@ func TestCreatePlanningAgentMap_SimpleWorkflow(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, res)
 }
+
+// runRecorder records the chain runs and the nodes reported to it
+type runRecorder struct {
+	graph.NoOpCallbackHandler
+	mu      sync.Mutex
+	chains  map[string]*string // parent runID by runID
+	nodes   map[string]string  // runID by node name
+	parents map[string]string  // parent runID by node name
+}
+
+func newRunRecorder() *runRecorder {
+	return &runRecorder{chains: map[string]*string{}, nodes: map[string]string{}, parents: map[string]string{}}
+}
+
+func (r *runRecorder) OnChainStart(ctx context.Context, serialized map[string]any, inputs map[string]any, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chains[runID] = parentRunID
+}
+
+func (r *runRecorder) OnToolStart(ctx context.Context, serialized map[string]any, inputStr string, runID string, parentRunID *string, tags []string, metadata map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name, _ := serialized["name"].(string)
+	r.nodes[name] = runID
+	r.parents[name] = *parentRunID
+}
+
+func TestCreatePlanningAgentMap_NestedCallbacks(t *testing.T) {
+	step := func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		return map[string]any{}, nil
+	}
+	testNodes := []graph.TypedNode[map[string]any]{
+		{Name: "research", Description: "Research", Function: step},
+		{Name: "analyze", Description: "Analyze", Function: step},
+	}
+	mockLLM := &MockPlanningLLM{planJSON: `{
+		"nodes": [{"name": "research"}, {"name": "analyze"}],
+		"edges": [
+			{"from": "START", "to": "research"},
+			{"from": "research", "to": "analyze"},
+			{"from": "analyze", "to": "END"}
+		]
+	}`}
+
+	agent, err := CreatePlanningAgentMap(mockLLM, testNodes, []tools.Tool{})
+	assert.NoError(t, err)
+
+	recorder := newRunRecorder()
+	_, err = agent.InvokeWithConfig(context.Background(),
+		map[string]any{"messages": []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Go")}},
+		&graph.Config{RunID: "outer", Callbacks: []graph.CallbackHandler{recorder}, Tags: []string{"planning"}})
+	assert.NoError(t, err)
+
+	// The planned nodes are reported in a run nested in the executor node
+	assert.Equal(t, "outer", recorder.parents["planner"])
+	assert.Equal(t, "outer", recorder.parents["executor"])
+	inner := recorder.parents["research"]
+	assert.NotEqual(t, "outer", inner)
+	assert.Equal(t, inner, recorder.parents["analyze"])
+	if assert.Contains(t, recorder.chains, inner) && assert.NotNil(t, recorder.chains[inner]) {
+		assert.Equal(t, recorder.nodes["executor"], *recorder.chains[inner])
+	}
+	assert.Nil(t, recorder.chains["outer"])
+}
@@ -2,8 +2,8 @@ package prebuilt
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/smallnest/langgraphgo/graph"
 	"github.com/tmc/langchaingo/llms"
@@ -37,18 +37,9 @@ func CreateReactAgentMap(model llms.Model, inputTools []tools.Tool, maxIteration
 		}
 
 		// Check iteration count
-		iterationCount := 0
-		if count, ok := state["iteration_count"].(int); ok {
-			iterationCount = count
-		}
+		iterationCount := mapIterationCount(state)
 		if iterationCount >= maxIterations {
-			// Max iterations reached, return final message
-			finalMsg := llms.MessageContent{
-				Role: llms.ChatMessageTypeAI,
-				Parts: []llms.ContentPart{
-					llms.TextPart("Maximum iterations reached. Please try a simpler query."),
-				},
-			}
+			finalMsg, _ := iterationLimitReached(IterationLimitMessage, maxIterations)
 			return map[string]any{
 				"messages": []llms.MessageContent{finalMsg},
 			}, nil
@@ -102,34 +93,7 @@ func CreateReactAgentMap(model llms.Model, inputTools []tools.Tool, maxIteration
 		var toolMessages []llms.MessageContent
 		for _, part := range lastMsg.Parts {
 			if tc, ok := part.(llms.ToolCall); ok {
-				// Get the tool to check if it has a custom schema
-				tool, hasTool := toolExecutor.Tools[tc.FunctionCall.Name]
-
-				var inputVal string
-				if hasTool {
-					// Check if tool has custom schema
-					if _, hasCustomSchema := tool.(ToolWithSchema); hasCustomSchema {
-						// Tool has custom schema, pass JSON arguments directly
-						inputVal = tc.FunctionCall.Arguments
-					} else {
-						// Tool uses default schema, try to extract "input" field
-						var args map[string]any
-						_ = json.Unmarshal([]byte(tc.FunctionCall.Arguments), &args)
-						if val, ok := args["input"].(string); ok {
-							inputVal = val
-						} else {
-							inputVal = tc.FunctionCall.Arguments
-						}
-					}
-				} else {
-					// Tool not found, use arguments as-is
-					inputVal = tc.FunctionCall.Arguments
-				}
-
-				res, err := toolExecutor.Execute(ctx, ToolInvocation{
-					Tool:      tc.FunctionCall.Name,
-					ToolInput: inputVal,
-				})
+				res, err := toolExecutor.Execute(ctx, toolCallInvocation(toolExecutor, tc))
 				if err != nil {
 					res = fmt.Sprintf("Error: %v", err)
 				}
@@ -154,16 +118,7 @@ func CreateReactAgentMap(model llms.Model, inputTools []tools.Tool, maxIteration
 	})
 
 	workflow.SetEntryPoint("agent")
-	workflow.AddConditionalEdge("agent", func(ctx context.Context, state map[string]any) string {
-		messages := state["messages"].([]llms.MessageContent)
-		lastMsg := messages[len(messages)-1]
-		for _, part := range lastMsg.Parts {
-			if _, ok := part.(llms.ToolCall); ok {
-				return "tools"
-			}
-		}
-		return graph.END
-	})
+	workflow.AddConditionalEdge("agent", ToolsCondition("tools", graph.END))
 	workflow.AddEdge("tools", "agent")
 
 	return workflow.Compile()
@@ -178,23 +133,131 @@ func CreateReactAgent[S any](
 	getIterationCount func(S) int,
 	setIterationCount func(S, int) S,
 	maxIterations int,
+	opts ...CreateAgentOption,
 ) (*graph.StateRunnable[S], error) {
+	options := &CreateAgentOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.MaxIterations > 0 {
+		maxIterations = options.MaxIterations
+	}
+	workflow := graph.NewStateGraph[S]()
+	if err := addReactAgent(workflow, workflow.AddNode, reactAgentState[S]{
+		getMessages: getMessages,
+		addMessages: func(state S, messages ...llms.MessageContent) S {
+			return setMessages(state, append(getMessages(state), messages...))
+		},
+		getIterationCount: getIterationCount,
+		setIterationCount: setIterationCount,
+	}, model, inputTools, maxIterations, options); err != nil {
+		return nil, err
+	}
+	return workflow.Compile()
+}
+
+// CreateCheckpointableReactAgent creates a typed ReAct agent like CreateReactAgent
+// whose runs are checkpointed in the store set with WithCheckpointer, or in memory
+// without it. Invoking the agent with the thread_id of an earlier run continues
+// that conversation: the messages of the input are appended to the thread's, and
+// the iteration count and the other fields of S take their values from the input.
+//
+// Example:
+//
+//	agent, _ := prebuilt.CreateCheckpointableReactAgent(model, tools,
+//	    getMessages, setMessages, getIterationCount, setIterationCount, 10,
+//	    prebuilt.WithCheckpointer(checkpoints))
+//	state, _ := agent.InvokeWithConfig(ctx, prebuilt.ReactAgentState{Messages: question}, graph.WithThreadID("user-1"))
+func CreateCheckpointableReactAgent[S any](
+	model llms.Model,
+	inputTools []tools.Tool,
+	getMessages func(S) []llms.MessageContent,
+	setMessages func(S, []llms.MessageContent) S,
+	getIterationCount func(S) int,
+	setIterationCount func(S, int) S,
+	maxIterations int,
+	opts ...CreateAgentOption,
+) (*graph.CheckpointableRunnable[S], error) {
+	options := &CreateAgentOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.MaxIterations > 0 {
+		maxIterations = options.MaxIterations
+	}
+	checkpoints := options.Checkpointer
+	if checkpoints == nil {
+		checkpoints = graph.NewMemoryCheckpointStore()
+	}
+
+	// The state is serialized so that the messages survive stores that persist JSON
+	workflow := graph.NewCheckpointableStateGraphWithConfig[S](graph.CheckpointConfig{
+		Store:      checkpoints,
+		AutoSave:   true,
+		Serializer: graph.JSONSerializer,
+	})
+
+	// The nodes return only the messages they add, which the schema appends to the
+	// conversation like the messages of the input resuming a thread
+	var initial S
+	workflow.SetSchema(graph.NewStructSchema(initial, func(current, update S) (S, error) {
+		messages := append(slices.Clone(getMessages(current)), getMessages(update)...)
+		return setMessages(update, messages), nil
+	}))
+	addNode := func(name, description string, fn func(context.Context, S) (S, error)) {
+		workflow.AddNode(name, description, fn)
+	}
+	if err := addReactAgent(workflow.StateGraph, addNode, reactAgentState[S]{
+		getMessages: getMessages,
+		addMessages: func(state S, messages ...llms.MessageContent) S {
+			return setMessages(state, messages)
+		},
+		getIterationCount: getIterationCount,
+		setIterationCount: setIterationCount,
+	}, model, inputTools, maxIterations, options); err != nil {
+		return nil, err
+	}
+	return workflow.CompileCheckpointable()
+}
+
+// reactAgentState accesses the typed state of a ReAct agent. addMessages returns
+// the state a node returns to add messages to the conversation.
+type reactAgentState[S any] struct {
+	getMessages       func(S) []llms.MessageContent
+	addMessages       func(S, ...llms.MessageContent) S
+	getIterationCount func(S) int
+	setIterationCount func(S, int) S
+}
+
+// addReactAgent adds the nodes and edges of a typed ReAct agent to workflow,
+// adding the nodes with addNode
+func addReactAgent[S any](
+	workflow *graph.StateGraph[S],
+	addNode func(name, description string, fn func(context.Context, S) (S, error)),
+	agentState reactAgentState[S],
+	model llms.Model,
+	inputTools []tools.Tool,
+	maxIterations int,
+	options *CreateAgentOptions,
+) error {
 	if maxIterations == 0 {
 		maxIterations = 20
 	}
+	if options.structuredOutput != nil {
+		if err := options.structuredOutput.validate(true); err != nil {
+			return err
+		}
+	}
 	toolExecutor := NewToolExecutor(inputTools)
-	workflow := graph.NewStateGraph[S]()
 
-	workflow.AddNode("agent", "ReAct agent decision maker", func(ctx context.Context, state S) (S, error) {
-		iterationCount := getIterationCount(state)
+	addNode("agent", "ReAct agent decision maker", func(ctx context.Context, state S) (S, error) {
+		iterationCount := agentState.getIterationCount(state)
 		if iterationCount >= maxIterations {
-			finalMsg := llms.MessageContent{
-				Role: llms.ChatMessageTypeAI,
-				Parts: []llms.ContentPart{
-					llms.TextPart("Maximum iterations reached. Please try a simpler query."),
-				},
+			finalMsg, err := iterationLimitReached(options.IterationLimit, maxIterations)
+			if err != nil {
+				return state, err
 			}
-			return setMessages(state, append(getMessages(state), finalMsg)), nil
+			return agentState.addMessages(state, finalMsg), nil
 		}
 
 		var toolDefs []llms.Tool
@@ -209,7 +272,7 @@ func CreateReactAgent[S any](
 			})
 		}
 
-		messages := getMessages(state)
+		messages := TrimMessages(agentState.getMessages(state), options.Trim)
 		resp, err := model.GenerateContent(ctx, messages, llms.WithTools(toolDefs))
 		if err != nil {
 			return state, err
@@ -226,46 +289,19 @@ func CreateReactAgent[S any](
 			aiMsg.Parts = append(aiMsg.Parts, tc)
 		}
 
-		state = setMessages(state, append(messages, aiMsg))
-		state = setIterationCount(state, iterationCount+1)
+		state = agentState.addMessages(state, aiMsg)
+		state = agentState.setIterationCount(state, iterationCount+1)
 		return state, nil
 	})
 
-	workflow.AddNode("tools", "Tool execution node", func(ctx context.Context, state S) (S, error) {
-		messages := getMessages(state)
+	addNode("tools", "Tool execution node", func(ctx context.Context, state S) (S, error) {
+		messages := agentState.getMessages(state)
 		lastMsg := messages[len(messages)-1]
 
 		var toolMessages []llms.MessageContent
 		for _, part := range lastMsg.Parts {
 			if tc, ok := part.(llms.ToolCall); ok {
-				// Get the tool to check if it has a custom schema
-				tool, hasTool := toolExecutor.Tools[tc.FunctionCall.Name]
-
-				var inputVal string
-				if hasTool {
-					// Check if tool has custom schema
-					if _, hasCustomSchema := tool.(ToolWithSchema); hasCustomSchema {
-						// Tool has custom schema, pass JSON arguments directly
-						inputVal = tc.FunctionCall.Arguments
-					} else {
-						// Tool uses default schema, try to extract "input" field
-						var args map[string]any
-						_ = json.Unmarshal([]byte(tc.FunctionCall.Arguments), &args)
-						if val, ok := args["input"].(string); ok {
-							inputVal = val
-						} else {
-							inputVal = tc.FunctionCall.Arguments
-						}
-					}
-				} else {
-					// Tool not found, use arguments as-is
-					inputVal = tc.FunctionCall.Arguments
-				}
-
-				res, err := toolExecutor.Execute(ctx, ToolInvocation{
-					Tool:      tc.FunctionCall.Name,
-					ToolInput: inputVal,
-				})
+				res, err := toolExecutor.Execute(ctx, toolCallInvocation(toolExecutor, tc))
 				if err != nil {
 					res = fmt.Sprintf("Error: %v", err)
 				}
@@ -284,21 +320,32 @@ func CreateReactAgent[S any](
 			}
 		}
 
-		return setMessages(state, append(getMessages(state), toolMessages...)), nil
+		return agentState.addMessages(state, toolMessages...), nil
 	})
 
-	workflow.SetEntryPoint("agent")
-	workflow.AddConditionalEdge("agent", func(ctx context.Context, state S) string {
-		messages := getMessages(state)
-		lastMsg := messages[len(messages)-1]
-		for _, part := range lastMsg.Parts {
-			if _, ok := part.(llms.ToolCall); ok {
-				return "tools"
+	endTarget := graph.END
+	if so := options.structuredOutput; so != nil {
+		addNode("respond", "Structured output node", func(ctx context.Context, state S) (S, error) {
+			output, err := so.respond(ctx, model, agentState.getMessages(state))
+			if err != nil {
+				return state, err
 			}
-		}
-		return graph.END
+			updated, ok := so.set(agentState.addMessages(state), output)
+			if !ok {
+				return state, fmt.Errorf("structured output setter doesn't take the state %T", state)
+			}
+			return updated.(S), nil
+		})
+		workflow.AddEdge("respond", graph.END)
+		endTarget = "respond"
+	}
+
+	workflow.SetEntryPoint("agent")
+	workflow.AddConditionalEdgeWithMapping("agent", ToolsConditionTyped(agentState.getMessages, "tools", "end"), map[string]string{
+		"tools": "tools",
+		"end":   endTarget,
 	})
 	workflow.AddEdge("tools", "agent")
-
-	return workflow.Compile()
+	workflow.MarkCycle("agent", "tools")
+	return nil
 }
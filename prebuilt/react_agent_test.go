@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store/file"
 	"github.com/stretchr/testify/assert"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/tools"
@@ -63,3 +65,160 @@ func TestReactAgentWithWeatherTool(t *testing.T) {
 	messages := res["messages"].([]llms.MessageContent)
 	assert.True(t, len(messages) >= 2)
 }
+
+func TestCreateReactAgentTyped(t *testing.T) {
+	type agentState struct {
+		Messages   []llms.MessageContent
+		Iterations int
+	}
+
+	mockLLM := &ReactMockLLM{
+		responses: []llms.ContentResponse{
+			{Choices: []*llms.ContentChoice{{ToolCalls: []llms.ToolCall{{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "get_weather", Arguments: `{"input": "beijing"}`}}}}}},
+			{Choices: []*llms.ContentChoice{{Content: "Beijing is 25°C."}}},
+		},
+	}
+	agent, err := CreateReactAgent(mockLLM, []tools.Tool{NewWeatherTool(25)},
+		func(s agentState) []llms.MessageContent { return s.Messages },
+		func(s agentState, messages []llms.MessageContent) agentState {
+			s.Messages = messages
+			return s
+		},
+		func(s agentState) int { return s.Iterations },
+		func(s agentState, n int) agentState {
+			s.Iterations = n
+			return s
+		},
+		5,
+	)
+	assert.NoError(t, err)
+	assert.False(t, agent.CompileReport().HasWarnings(), "the agent loop is declared with MarkCycle")
+
+	res, err := agent.Invoke(context.Background(), agentState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Beijing?")}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Beijing is 25°C.", res.Messages[len(res.Messages)-1].Parts[0].(llms.TextContent).Text)
+}
+
+// LoopingMockLLM calls the weather tool on every call
+type LoopingMockLLM struct {
+	llms.Model
+	callCount int
+}
+
+func (m *LoopingMockLLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.callCount++
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{ToolCalls: []llms.ToolCall{{
+		ID:           fmt.Sprintf("call-%d", m.callCount),
+		Type:         "function",
+		FunctionCall: &llms.FunctionCall{Name: "get_weather", Arguments: `{"input": "beijing"}`},
+	}}}}}, nil
+}
+
+// toolMessageCount returns the number of tool messages of messages
+func toolMessageCount(messages []llms.MessageContent) int {
+	count := 0
+	for _, msg := range messages {
+		if msg.Role == llms.ChatMessageTypeTool {
+			count++
+		}
+	}
+	return count
+}
+
+func TestCreateReactAgentMaxIterations(t *testing.T) {
+	type agentState struct {
+		Messages   []llms.MessageContent
+		Iterations int
+	}
+	newAgent := func(model llms.Model, opts ...CreateAgentOption) *graph.StateRunnable[agentState] {
+		agent, err := CreateReactAgent(model, []tools.Tool{NewWeatherTool(25)},
+			func(s agentState) []llms.MessageContent { return s.Messages },
+			func(s agentState, messages []llms.MessageContent) agentState {
+				s.Messages = messages
+				return s
+			},
+			func(s agentState) int { return s.Iterations },
+			func(s agentState, n int) agentState {
+				s.Iterations = n
+				return s
+			},
+			3,
+			opts...,
+		)
+		assert.NoError(t, err)
+		return agent
+	}
+	input := agentState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Beijing?")}}
+
+	t.Run("Message", func(t *testing.T) {
+		mockLLM := &LoopingMockLLM{}
+		res, err := newAgent(mockLLM).Invoke(context.Background(), input)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, mockLLM.callCount)
+		assert.Equal(t, 3, toolMessageCount(res.Messages))
+
+		last := res.Messages[len(res.Messages)-1]
+		assert.Equal(t, llms.ChatMessageTypeAI, last.Role)
+		assert.Equal(t, "Maximum iterations reached. Please try a simpler query.", last.Parts[0].(llms.TextContent).Text)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		mockLLM := &LoopingMockLLM{}
+		_, err := newAgent(mockLLM, WithIterationLimitBehavior(IterationLimitError)).Invoke(context.Background(), input)
+		assert.ErrorIs(t, err, ErrMaxIterations)
+		assert.Equal(t, 3, mockLLM.callCount)
+	})
+
+	t.Run("Option", func(t *testing.T) {
+		mockLLM := &LoopingMockLLM{}
+		_, err := newAgent(mockLLM, WithMaxIterations(2)).Invoke(context.Background(), input)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, mockLLM.callCount)
+	})
+}
+
+func TestCreateCheckpointableReactAgent(t *testing.T) {
+	ctx := context.Background()
+	checkpoints, err := file.NewFileCheckpointStore(t.TempDir())
+	assert.NoError(t, err)
+
+	mockLLM := &ReactMockLLM{
+		responses: []llms.ContentResponse{
+			{Choices: []*llms.ContentChoice{{ToolCalls: []llms.ToolCall{{ID: "call-1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "get_weather", Arguments: `{"input": "beijing"}`}}}}}},
+			{Choices: []*llms.ContentChoice{{Content: "Beijing is 25°C."}}},
+			{Choices: []*llms.ContentChoice{{Content: "You asked about Beijing."}}},
+		},
+	}
+	// Every turn builds a new agent, as a restarted process would
+	newAgent := func() *graph.CheckpointableRunnable[ReactAgentState] {
+		agent, err := CreateCheckpointableReactAgent(mockLLM, []tools.Tool{NewWeatherTool(25)},
+			func(s ReactAgentState) []llms.MessageContent { return s.Messages },
+			func(s ReactAgentState, messages []llms.MessageContent) ReactAgentState {
+				s.Messages = messages
+				return s
+			},
+			func(s ReactAgentState) int { return s.IterationCount },
+			func(s ReactAgentState, n int) ReactAgentState {
+				s.IterationCount = n
+				return s
+			},
+			5,
+			WithCheckpointer(checkpoints),
+		)
+		assert.NoError(t, err)
+		return agent
+	}
+
+	res, err := newAgent().InvokeWithConfig(ctx, ReactAgentState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Beijing?")}}, graph.WithThreadID("user-1"))
+	assert.NoError(t, err)
+	assert.Len(t, res.Messages, 4)
+	assert.Equal(t, 2, res.IterationCount)
+
+	// The second turn continues the conversation of the thread
+	res, err = newAgent().InvokeWithConfig(ctx, ReactAgentState{Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "What did I ask?")}}, graph.WithThreadID("user-1"))
+	assert.NoError(t, err)
+	assert.Len(t, res.Messages, 6)
+	assert.Equal(t, "call-1", res.Messages[1].Parts[0].(llms.ToolCall).ID)
+	assert.Equal(t, "You asked about Beijing.", res.Messages[5].Parts[0].(llms.TextContent).Text)
+	assert.Equal(t, 1, res.IterationCount, "the iteration count restarts with each turn")
+}
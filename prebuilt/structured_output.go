@@ -0,0 +1,240 @@
+package prebuilt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// DefaultStructuredOutputRetries is the number of times the "respond" node of an
+// agent asks the model again for an answer that doesn't match the schema
+const DefaultStructuredOutputRetries = 2
+
+// respondToolName is the name of the function the "respond" node forces the
+// model to call with the final answer
+const respondToolName = "respond"
+
+// structuredOutputOptions configures the "respond" node of an agent with
+// structured output
+type structuredOutputOptions struct {
+	schema  any
+	retries int
+
+	// decode decodes an answer valid against the schema into the output
+	decode func(data []byte) (any, error)
+
+	// set stores the output in the state of a typed agent, reporting whether the
+	// state has the type of the setter
+	set func(state, output any) (any, bool)
+}
+
+// WithStructuredOutput makes the agent end its runs with a "respond" node, which
+// asks the model for the final answer as JSON matching schema, and stores it in
+// the "structured_output" key of the state. schema is either a JSON schema map,
+// and the answer is a map[string]any, or a value of the Go type of the answer,
+// such as Answer{} or &Answer{}, whose schema is generated from its fields and
+// json tags. Typed agents store the answer with WithStructuredOutputSetter.
+//
+// Example:
+//
+//	type Answer struct {
+//	    Answer  string   `json:"answer" description:"The answer to the question"`
+//	    Sources []string `json:"sources"`
+//	}
+//	agent, _ := prebuilt.CreateAgentMap(model, tools, 10, prebuilt.WithStructuredOutput(Answer{}))
+//	state, _ := agent.Invoke(ctx, input)
+//	answer := state["structured_output"].(Answer)
+func WithStructuredOutput(schema any) CreateAgentOption {
+	return func(o *CreateAgentOptions) {
+		if o.structuredOutput == nil {
+			o.structuredOutput = &structuredOutputOptions{retries: DefaultStructuredOutputRetries}
+		}
+		o.structuredOutput.schema = schema
+	}
+}
+
+// WithStructuredOutputRetries sets the number of times the "respond" node asks
+// the model again when its answer isn't valid JSON matching the schema,
+// DefaultStructuredOutputRetries by default
+func WithStructuredOutputRetries(retries int) CreateAgentOption {
+	return func(o *CreateAgentOptions) {
+		if o.structuredOutput == nil {
+			o.structuredOutput = &structuredOutputOptions{}
+		}
+		o.structuredOutput.retries = max(retries, 0)
+	}
+}
+
+// WithStructuredOutputSetter sets the function a typed agent with
+// WithStructuredOutput stores the answer in its state S with, decoded into a
+// T. Without a schema set with WithStructuredOutput, the schema is the one of T.
+func WithStructuredOutputSetter[S, T any](set func(S, T) S) CreateAgentOption {
+	return func(o *CreateAgentOptions) {
+		if o.structuredOutput == nil {
+			o.structuredOutput = &structuredOutputOptions{retries: DefaultStructuredOutputRetries}
+		}
+		o.structuredOutput.decode = func(data []byte) (any, error) {
+			var output T
+			err := json.Unmarshal(data, &output)
+			return output, err
+		}
+		o.structuredOutput.set = func(state, output any) (any, bool) {
+			s, ok := state.(S)
+			if !ok {
+				return state, false
+			}
+			return set(s, output.(T)), true
+		}
+		if o.structuredOutput.schema == nil {
+			var output T
+			o.structuredOutput.schema = output
+		}
+	}
+}
+
+// validate reports the errors of the structured output options of an agent.
+// Typed agents need a setter.
+func (so *structuredOutputOptions) validate(typed bool) error {
+	if so.schema == nil {
+		return errors.New("structured output needs a schema, set with WithStructuredOutput")
+	}
+	if typed && so.set == nil {
+		return errors.New("structured output of a typed agent needs WithStructuredOutputSetter")
+	}
+	return nil
+}
+
+// jsonSchema returns the JSON schema of the answer
+func (so *structuredOutputOptions) jsonSchema() (jsonschema.Definition, error) {
+	var definition jsonschema.Definition
+	if _, ok := so.schema.(map[string]any); !ok {
+		generated, err := jsonschema.GenerateSchemaForType(so.schema)
+		if err != nil {
+			return definition, fmt.Errorf("failed to generate the schema of %T: %w", so.schema, err)
+		}
+		return *generated, nil
+	}
+	data, err := json.Marshal(so.schema)
+	if err != nil {
+		return definition, fmt.Errorf("failed to encode the structured output schema: %w", err)
+	}
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return definition, fmt.Errorf("failed to read the structured output schema: %w", err)
+	}
+	return definition, nil
+}
+
+// decodeOutput decodes data into the output: a value of the type of the schema,
+// a map[string]any for a schema map, or the type of the setter
+func (so *structuredOutputOptions) decodeOutput(data []byte) (any, error) {
+	if so.decode != nil {
+		return so.decode(data)
+	}
+	if _, ok := so.schema.(map[string]any); ok {
+		var output map[string]any
+		err := json.Unmarshal(data, &output)
+		return output, err
+	}
+	t := reflect.TypeOf(so.schema)
+	if t.Kind() == reflect.Pointer {
+		output := reflect.New(t.Elem())
+		err := json.Unmarshal(data, output.Interface())
+		return output.Interface(), err
+	}
+	output := reflect.New(t)
+	err := json.Unmarshal(data, output.Interface())
+	return output.Elem().Interface(), err
+}
+
+// respond asks model for the final answer of the conversation of messages,
+// forcing a call of the respond function with the schema as parameters, and
+// returns it decoded. An answer that isn't valid JSON matching the schema is
+// sent back to the model with the error, up to so.retries times.
+func (so *structuredOutputOptions) respond(ctx context.Context, model llms.Model, messages []llms.MessageContent) (any, error) {
+	definition, err := so.jsonSchema()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := json.Marshal(&definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the structured output schema: %w", err)
+	}
+	var parameters map[string]any
+	if err := json.Unmarshal(schema, &parameters); err != nil {
+		return nil, fmt.Errorf("failed to encode the structured output schema: %w", err)
+	}
+
+	respondTool := llms.Tool{
+		Type: "function",
+		Function: &llms.FunctionDefinition{
+			Name:        respondToolName,
+			Description: "Respond to the user with the final answer",
+			Parameters:  parameters,
+		},
+	}
+	callOpts := []llms.CallOption{
+		llms.WithTools([]llms.Tool{respondTool}),
+		llms.WithToolChoice(llms.ToolChoice{Type: "function", Function: &llms.FunctionReference{Name: respondToolName}}),
+	}
+
+	conversation := append(slices.Clip(messages), llms.TextParts(llms.ChatMessageTypeHuman,
+		"Respond with your final answer as a JSON object matching this JSON schema, without any other text:\n"+string(schema)))
+	for attempt := 0; ; attempt++ {
+		resp, err := model.GenerateContent(ctx, conversation, callOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Choices) == 0 {
+			return nil, errors.New("no structured output returned by the model")
+		}
+		answer := structuredAnswer(resp.Choices[0])
+
+		output, err := so.parse(definition, answer)
+		if err == nil {
+			return output, nil
+		}
+		if attempt >= so.retries {
+			return nil, fmt.Errorf("invalid structured output after %d attempts: %w", attempt+1, err)
+		}
+		conversation = append(conversation,
+			llms.TextParts(llms.ChatMessageTypeAI, answer),
+			llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprintf(
+				"That answer is invalid: %v. Respond again with only a JSON object matching the schema.", err)))
+	}
+}
+
+// parse decodes answer after validating it against definition
+func (so *structuredOutputOptions) parse(definition jsonschema.Definition, answer string) (any, error) {
+	var data any
+	if err := json.Unmarshal([]byte(answer), &data); err != nil {
+		return nil, err
+	}
+	if !jsonschema.Validate(definition, data) {
+		return nil, errors.New("the JSON doesn't match the schema")
+	}
+	return so.decodeOutput([]byte(answer))
+}
+
+// structuredAnswer returns the arguments of the call of the respond function of
+// choice, or else its content without a Markdown code fence
+func structuredAnswer(choice *llms.ContentChoice) string {
+	for _, tc := range choice.ToolCalls {
+		if tc.FunctionCall != nil && tc.FunctionCall.Name == respondToolName {
+			return tc.FunctionCall.Arguments
+		}
+	}
+	answer := strings.TrimSpace(choice.Content)
+	if strings.HasPrefix(answer, "```") {
+		answer = strings.TrimPrefix(answer, "```json")
+		answer = strings.TrimPrefix(answer, "```")
+		answer = strings.TrimSuffix(strings.TrimSpace(answer), "```")
+	}
+	return strings.TrimSpace(answer)
+}
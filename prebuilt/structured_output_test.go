@@ -0,0 +1,163 @@
+package prebuilt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+type structuredAnswerOutput struct {
+	Answer  string   `json:"answer"`
+	Sources []string `json:"sources"`
+}
+
+// StructuredMockLLM answers the agent with text, and the "respond" node with the
+// arguments of its respond calls in turn, recording the options of these calls
+type StructuredMockLLM struct {
+	llms.Model
+	answers     []string
+	respondOpts []llms.CallOptions
+}
+
+func (m *StructuredMockLLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var opts llms.CallOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	if opts.ToolChoice == nil {
+		return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "Paris is the capital of France."}}}, nil
+	}
+
+	m.respondOpts = append(m.respondOpts, opts)
+	answer := m.answers[min(len(m.respondOpts), len(m.answers))-1]
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{ToolCalls: []llms.ToolCall{{
+		ID:           "call_respond",
+		Type:         "function",
+		FunctionCall: &llms.FunctionCall{Name: "respond", Arguments: answer},
+	}}}}}, nil
+}
+
+func structuredInput() map[string]any {
+	return map[string]any{"messages": []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "What is the capital of France?")}}
+}
+
+func TestCreateAgentMapStructuredOutput(t *testing.T) {
+	t.Run("Retry", func(t *testing.T) {
+		mockLLM := &StructuredMockLLM{answers: []string{
+			`{"answer": "Paris", "sources": [`,
+			`{"answer": "Paris"}`,
+			`{"answer": "Paris", "sources": ["wikipedia"]}`,
+		}}
+		agent, err := CreateAgentMap(mockLLM, []tools.Tool{}, 0, WithStructuredOutput(structuredAnswerOutput{}))
+		require.NoError(t, err)
+
+		res, err := agent.Invoke(context.Background(), structuredInput())
+		require.NoError(t, err)
+		assert.Equal(t, structuredAnswerOutput{Answer: "Paris", Sources: []string{"wikipedia"}}, res["structured_output"])
+
+		// The malformed JSON and the answer missing a required field were retried
+		require.Len(t, mockLLM.respondOpts, 3)
+		opts := mockLLM.respondOpts[0]
+		assert.Equal(t, llms.ToolChoice{Type: "function", Function: &llms.FunctionReference{Name: "respond"}}, opts.ToolChoice)
+		require.Len(t, opts.Tools, 1)
+		assert.Equal(t, []any{"answer", "sources"}, opts.Tools[0].Function.Parameters.(map[string]any)["required"])
+	})
+
+	t.Run("Retries exhausted", func(t *testing.T) {
+		mockLLM := &StructuredMockLLM{answers: []string{`not json`}}
+		agent, err := CreateAgentMap(mockLLM, []tools.Tool{}, 0,
+			WithStructuredOutput(structuredAnswerOutput{}), WithStructuredOutputRetries(1))
+		require.NoError(t, err)
+
+		_, err = agent.Invoke(context.Background(), structuredInput())
+		assert.ErrorContains(t, err, "invalid structured output after 2 attempts")
+		assert.Len(t, mockLLM.respondOpts, 2)
+	})
+
+	t.Run("Schema map", func(t *testing.T) {
+		mockLLM := &StructuredMockLLM{answers: []string{`{"city": "Paris"}`}}
+		agent, err := CreateAgentMap(mockLLM, []tools.Tool{}, 0, WithStructuredOutput(map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+			"required":   []string{"city"},
+		}))
+		require.NoError(t, err)
+
+		res, err := agent.Invoke(context.Background(), structuredInput())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"city": "Paris"}, res["structured_output"])
+	})
+
+	t.Run("Pointer", func(t *testing.T) {
+		mockLLM := &StructuredMockLLM{answers: []string{`{"answer": "Paris", "sources": []}`}}
+		agent, err := CreateAgentMap(mockLLM, []tools.Tool{}, 0, WithStructuredOutput(&structuredAnswerOutput{}))
+		require.NoError(t, err)
+
+		res, err := agent.Invoke(context.Background(), structuredInput())
+		require.NoError(t, err)
+		assert.Equal(t, &structuredAnswerOutput{Answer: "Paris", Sources: []string{}}, res["structured_output"])
+	})
+}
+
+func TestCreateReactAgentStructuredOutput(t *testing.T) {
+	type agentState struct {
+		Messages   []llms.MessageContent
+		Iterations int
+		Output     structuredAnswerOutput
+	}
+	newAgent := func(model llms.Model, opts ...CreateAgentOption) (*graph.StateRunnable[agentState], error) {
+		return CreateReactAgent(model, []tools.Tool{},
+			func(s agentState) []llms.MessageContent { return s.Messages },
+			func(s agentState, messages []llms.MessageContent) agentState {
+				s.Messages = messages
+				return s
+			},
+			func(s agentState) int { return s.Iterations },
+			func(s agentState, n int) agentState {
+				s.Iterations = n
+				return s
+			},
+			5,
+			opts...,
+		)
+	}
+
+	// A typed agent needs a setter
+	_, err := newAgent(&StructuredMockLLM{}, WithStructuredOutput(structuredAnswerOutput{}))
+	assert.Error(t, err)
+
+	mockLLM := &StructuredMockLLM{answers: []string{
+		"```json\n{\"answer\": \"Paris\"",
+		`{"answer": "Paris", "sources": ["atlas"]}`,
+	}}
+	agent, err := newAgent(mockLLM, WithStructuredOutputSetter(func(s agentState, output structuredAnswerOutput) agentState {
+		s.Output = output
+		return s
+	}))
+	require.NoError(t, err)
+	assert.False(t, agent.CompileReport().HasWarnings())
+
+	res, err := agent.Invoke(context.Background(), agentState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "What is the capital of France?")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, structuredAnswerOutput{Answer: "Paris", Sources: []string{"atlas"}}, res.Output)
+	assert.Len(t, mockLLM.respondOpts, 2)
+
+	// The exchange of the respond node isn't added to the conversation
+	assert.Len(t, res.Messages, 2)
+}
+
+func TestStructuredAnswer(t *testing.T) {
+	assert.Equal(t, `{"a": 1}`, structuredAnswer(&llms.ContentChoice{Content: "```json\n{\"a\": 1}\n```"}))
+	assert.Equal(t, `{"a": 1}`, structuredAnswer(&llms.ContentChoice{Content: " {\"a\": 1} "}))
+	assert.Equal(t, `{"b": 2}`, structuredAnswer(&llms.ContentChoice{
+		Content:   "ignored",
+		ToolCalls: []llms.ToolCall{{FunctionCall: &llms.FunctionCall{Name: "respond", Arguments: `{"b": 2}`}}},
+	}))
+}
@@ -0,0 +1,187 @@
+package prebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	// DefaultSummarizationThreshold is the number of messages of the history
+	// above which a summarization node summarizes the older ones
+	DefaultSummarizationThreshold = 20
+
+	// DefaultSummarizationKeepTurns is the number of most recent turns a
+	// summarization node keeps verbatim
+	DefaultSummarizationKeepTurns = 3
+
+	// DefaultSummaryKey is the state key a summarization node keeps the running
+	// summary under
+	DefaultSummaryKey = "summary"
+
+	// SummaryPrefix starts the text of the system message holding the summary
+	SummaryPrefix = "Conversation summary: "
+)
+
+// SummarizationOptions configures NewSummarizationNode. The fields left at their
+// zero value take their default.
+type SummarizationOptions struct {
+	// MaxMessages is the number of messages of the history, not counting the
+	// system messages, above which the older ones are summarized,
+	// DefaultSummarizationThreshold by default
+	MaxMessages int
+
+	// KeepTurns is the number of most recent turns kept verbatim, a turn being a
+	// human message and the messages answering it, DefaultSummarizationKeepTurns
+	// by default
+	KeepTurns int
+
+	// SummaryKey is the state key of the running summary, DefaultSummaryKey by
+	// default
+	SummaryKey string
+
+	// Prompt is the instruction of the model summarizing the conversation
+	Prompt string
+}
+
+const defaultSummarizationPrompt = "Summarize the conversation below, keeping the facts, decisions and open questions " +
+	"needed to carry it on. Extend the previous summary, if any, with the new messages. Reply with the summary only."
+
+// NewSummarizationNode returns a node for map[string]any state that, when the
+// "messages" history exceeds opts.MaxMessages, has model summarize the messages
+// older than the last opts.KeepTurns turns, and replaces them with a system
+// message starting with SummaryPrefix. The summary is kept under
+// opts.SummaryKey, and extended by the next summarizations rather than
+// rewritten from the whole history. The node returns nothing while the history
+// is short enough.
+//
+// The node returns the whole summarized history under "messages", which
+// replaces the history with OverwriteReducer, or without a reducer for the key.
+// With AppendReducer, as in CreateAgentMap, the history would be appended to
+// instead: call the node between the runs of the agent, on the state it
+// returned, and pass the update as the input of the next run.
+//
+// Example:
+//
+//	summarize := prebuilt.NewSummarizationNode(model, prebuilt.SummarizationOptions{MaxMessages: 30})
+//	update, err := summarize(ctx, state)
+//	if update != nil {
+//	    maps.Copy(state, update)
+//	}
+func NewSummarizationNode(model llms.Model, opts SummarizationOptions) func(context.Context, map[string]any) (map[string]any, error) {
+	if opts.MaxMessages <= 0 {
+		opts.MaxMessages = DefaultSummarizationThreshold
+	}
+	if opts.KeepTurns <= 0 {
+		opts.KeepTurns = DefaultSummarizationKeepTurns
+	}
+	if opts.SummaryKey == "" {
+		opts.SummaryKey = DefaultSummaryKey
+	}
+	if opts.Prompt == "" {
+		opts.Prompt = defaultSummarizationPrompt
+	}
+
+	return func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		messages, _ := state["messages"].([]llms.MessageContent)
+
+		// The system messages stay in front, but the one of the previous summary,
+		// which is replaced
+		var system, history []llms.MessageContent
+		previous, _ := state[opts.SummaryKey].(string)
+		for _, msg := range messages {
+			switch {
+			case isSummaryMessage(msg):
+				if previous == "" {
+					previous = strings.TrimPrefix(msg.Parts[0].(llms.TextContent).Text, SummaryPrefix)
+				}
+			case msg.Role == llms.ChatMessageTypeSystem:
+				system = append(system, msg)
+			default:
+				history = append(history, msg)
+			}
+		}
+		if len(history) <= opts.MaxMessages {
+			return nil, nil
+		}
+
+		// The older messages end where the kept turns start, so that an AI message
+		// and the tool messages answering it are never split
+		split := len(history)
+		for turns := 0; split > 0 && turns < opts.KeepTurns; {
+			split--
+			if history[split].Role == llms.ChatMessageTypeHuman {
+				turns++
+			}
+		}
+		if split == 0 {
+			return nil, nil
+		}
+
+		summary, err := summarize(ctx, model, opts.Prompt, previous, history[:split])
+		if err != nil {
+			return nil, err
+		}
+
+		summarized := append(system, llms.TextParts(llms.ChatMessageTypeSystem, SummaryPrefix+summary))
+		summarized = append(summarized, history[split:]...)
+		return map[string]any{
+			"messages":      summarized,
+			opts.SummaryKey: summary,
+		}, nil
+	}
+}
+
+// summarize has model extend the previous summary with messages
+func summarize(ctx context.Context, model llms.Model, prompt, previous string, messages []llms.MessageContent) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	if previous != "" {
+		sb.WriteString("\n\nPrevious summary:\n")
+		sb.WriteString(previous)
+	}
+	sb.WriteString("\n\nMessages:\n")
+	for _, msg := range messages {
+		sb.WriteString(messageText(msg))
+		sb.WriteString("\n")
+	}
+
+	resp, err := model.GenerateContent(ctx, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, sb.String())})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize the conversation: %w", err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Content) == "" {
+		return "", errors.New("failed to summarize the conversation: empty summary")
+	}
+	return strings.TrimSpace(resp.Choices[0].Content), nil
+}
+
+// messageText renders msg as a line of the transcript summarized
+func messageText(msg llms.MessageContent) string {
+	var parts []string
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			parts = append(parts, p.Text)
+		case llms.ToolCall:
+			if p.FunctionCall != nil {
+				parts = append(parts, fmt.Sprintf("[calls %s(%s)]", p.FunctionCall.Name, p.FunctionCall.Arguments))
+			}
+		case llms.ToolCallResponse:
+			parts = append(parts, fmt.Sprintf("[%s returned %s]", p.Name, p.Content))
+		}
+	}
+	return fmt.Sprintf("%s: %s", msg.Role, strings.Join(parts, " "))
+}
+
+// isSummaryMessage reports whether msg is the system message of a summary
+func isSummaryMessage(msg llms.MessageContent) bool {
+	if msg.Role != llms.ChatMessageTypeSystem || len(msg.Parts) == 0 {
+		return false
+	}
+	text, ok := msg.Parts[0].(llms.TextContent)
+	return ok && strings.HasPrefix(text.Text, SummaryPrefix)
+}
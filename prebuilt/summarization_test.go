@@ -0,0 +1,103 @@
+package prebuilt
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"testing"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// SummarizerMockLLM answers with numbered summaries, recording its prompts
+type SummarizerMockLLM struct {
+	llms.Model
+	prompts []string
+}
+
+func (m *SummarizerMockLLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.prompts = append(m.prompts, messages[0].Parts[0].(llms.TextContent).Text)
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: fmt.Sprintf("summary %d", len(m.prompts))}}}, nil
+}
+
+// conversationTurns returns a conversation of n turns, each a question and its
+// answer, the first one using a tool
+func conversationTurns(n int) []llms.MessageContent {
+	messages := []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeSystem, "be brief")}
+	for i := 1; i <= n; i++ {
+		messages = append(messages, llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprintf("question %d", i)))
+		if i == 1 {
+			messages = append(messages, toolExchange("call_1")...)
+		}
+		messages = append(messages, llms.TextParts(llms.ChatMessageTypeAI, fmt.Sprintf("answer %d", i)))
+	}
+	return messages
+}
+
+func TestSummarizationNode(t *testing.T) {
+	mockLLM := &SummarizerMockLLM{}
+	summarize := NewSummarizationNode(mockLLM, SummarizationOptions{MaxMessages: 6, KeepTurns: 2})
+
+	// Short histories aren't summarized
+	update, err := summarize(context.Background(), map[string]any{"messages": conversationTurns(2)})
+	require.NoError(t, err)
+	assert.Nil(t, update)
+	assert.Empty(t, mockLLM.prompts)
+
+	state := map[string]any{"messages": conversationTurns(4)}
+	update, err = summarize(context.Background(), state)
+	require.NoError(t, err)
+	assert.Equal(t, "summary 1", update[DefaultSummaryKey])
+	assert.Equal(t, []string{
+		"system:be brief",
+		"system:Conversation summary: summary 1",
+		"human:question 3", "ai:answer 3",
+		"human:question 4", "ai:answer 4",
+	}, messageTexts(update["messages"].([]llms.MessageContent)))
+
+	// The tool exchange of the first turn was summarized with it
+	assert.Contains(t, mockLLM.prompts[0], "human: question 1\nai: [calls search()]\ntool: [search returned result]\nai: answer 1\n")
+	assert.NotContains(t, mockLLM.prompts[0], "question 3")
+
+	// The next summarization extends the running summary with the new messages
+	maps.Copy(state, update)
+	messages := state["messages"].([]llms.MessageContent)
+	for i := 5; i <= 7; i++ {
+		messages = append(messages,
+			llms.TextParts(llms.ChatMessageTypeHuman, fmt.Sprintf("question %d", i)),
+			llms.TextParts(llms.ChatMessageTypeAI, fmt.Sprintf("answer %d", i)))
+	}
+	state["messages"] = messages
+	update, err = summarize(context.Background(), state)
+	require.NoError(t, err)
+	assert.Contains(t, mockLLM.prompts[1], "Previous summary:\nsummary 1\n")
+	assert.Contains(t, mockLLM.prompts[1], "human: question 3\n")
+	assert.NotContains(t, mockLLM.prompts[1], "question 1")
+	assert.Equal(t, []string{
+		"system:be brief",
+		"system:Conversation summary: summary 2",
+		"human:question 6", "ai:answer 6",
+		"human:question 7", "ai:answer 7",
+	}, messageTexts(update["messages"].([]llms.MessageContent)))
+}
+
+func TestSummarizationNodeInGraph(t *testing.T) {
+	g := graph.NewStateGraph[map[string]any]()
+	schema := graph.NewMapSchema()
+	schema.RegisterReducer("messages", graph.OverwriteReducer)
+	g.SetSchema(schema)
+	g.AddNode("summarize", "Summarizes the older messages", NewSummarizationNode(&SummarizerMockLLM{}, SummarizationOptions{MaxMessages: 4, KeepTurns: 1}))
+	g.AddEdge("summarize", graph.END)
+	g.SetEntryPoint("summarize")
+	runnable, err := g.Compile()
+	require.NoError(t, err)
+
+	res, err := runnable.Invoke(context.Background(), map[string]any{"messages": conversationTurns(3)})
+	require.NoError(t, err)
+	assert.Equal(t, "summary 1", res["summary"])
+	assert.Equal(t, []string{"system:be brief", "system:Conversation summary: summary 1", "human:question 3", "ai:answer 3"},
+		messageTexts(res["messages"].([]llms.MessageContent)))
+}
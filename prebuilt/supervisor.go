@@ -76,11 +76,7 @@ func CreateSupervisorMap(model llms.Model, members map[string]*graph.StateRunnab
 	})
 
 	for name, agent := range members {
-		agentName := name
-		agentRunnable := agent
-		workflow.AddNode(agentName, "Agent: "+agentName, func(ctx context.Context, state map[string]any) (map[string]any, error) {
-			return agentRunnable.Invoke(ctx, state)
-		})
+		workflow.AddRunnableNode(name, "Agent: "+name, agent)
 	}
 
 	workflow.SetEntryPoint("supervisor")
@@ -164,25 +160,28 @@ func CreateSupervisor[S any](
 	})
 
 	for name, runnable := range members {
-		agentName := name
-		agentRunnable := runnable
-		workflow.AddNode(agentName, "Agent: "+agentName, func(ctx context.Context, state S) (S, error) {
-			return agentRunnable.Invoke(ctx, state)
-		})
+		workflow.AddRunnableNode(name, "Agent: "+name, runnable)
+	}
+
+	// Route through a path map so the member names are validated at Compile time
+	routes := map[string]string{"FINISH": graph.END}
+	for name := range members {
+		routes[name] = name
 	}
 
 	workflow.SetEntryPoint("supervisor")
-	workflow.AddConditionalEdge("supervisor", func(ctx context.Context, state S) string {
+	workflow.AddConditionalEdgeWithMapping("supervisor", func(ctx context.Context, state S) string {
 		next := getNext(state)
-		if next == "FINISH" || next == "" {
-			return graph.END
+		if next == "" {
+			return "FINISH"
 		}
 		return next
-	})
+	}, routes)
 
 	for name := range members {
 		workflow.AddEdge(name, "supervisor")
 	}
+	workflow.MarkCycle(append([]string{"supervisor"}, memberNames...)...)
 
 	return workflow.Compile()
 }
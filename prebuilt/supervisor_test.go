@@ -642,3 +642,89 @@ func TestCreateSupervisor_SingleAgent(t *testing.T) {
 	}
 	assert.True(t, found, "Worker response should be in messages")
 }
+
+func TestCreateSupervisor_DrawsMembers(t *testing.T) {
+	agentRunnable, err := NewMockAgent("Worker", "Task completed").Compile()
+	require.NoError(t, err)
+	supervisor, err := CreateSupervisorMap(&SupervisorMockLLM{}, map[string]*graph.StateRunnable[map[string]any]{
+		"Worker": agentRunnable,
+	})
+	require.NoError(t, err)
+
+	exporter := supervisor.GetGraph()
+	assert.NotContains(t, exporter.DrawMermaid(), "Worker_run")
+
+	diagram := exporter.DrawMermaidWithDepth(1)
+	assert.Contains(t, diagram, `subgraph Worker_subgraph ["Worker"]`)
+	assert.Contains(t, diagram, "Worker -.-> Worker_run")
+	assert.Contains(t, diagram, "Worker_run --> Worker_END")
+	assert.Contains(t, diagram, "Worker --> supervisor")
+}
+
+type typedSupervisorState struct {
+	Messages []llms.MessageContent
+	Next     string
+}
+
+func routeResponse(next string) llms.ContentResponse {
+	return llms.ContentResponse{
+		Choices: []*llms.ContentChoice{
+			{
+				ToolCalls: []llms.ToolCall{
+					{FunctionCall: &llms.FunctionCall{Name: "route", Arguments: `{"next": "` + next + `"}`}},
+				},
+			},
+		},
+	}
+}
+
+func newTypedSupervisor(t *testing.T, mockLLM llms.Model) *graph.StateRunnable[typedSupervisorState] {
+	worker := graph.NewStateGraph[typedSupervisorState]()
+	worker.AddNode("run", "run", func(ctx context.Context, state typedSupervisorState) (typedSupervisorState, error) {
+		state.Messages = append(state.Messages, llms.TextParts(llms.ChatMessageTypeAI, "Task completed"))
+		return state, nil
+	})
+	worker.SetEntryPoint("run")
+	worker.AddEdge("run", graph.END)
+	workerRunnable, err := worker.Compile()
+	require.NoError(t, err)
+
+	supervisor, err := CreateSupervisor(mockLLM,
+		map[string]*graph.StateRunnable[typedSupervisorState]{"Worker": workerRunnable},
+		func(s typedSupervisorState) []llms.MessageContent { return s.Messages },
+		func(s typedSupervisorState) string { return s.Next },
+		func(s typedSupervisorState, next string) typedSupervisorState {
+			s.Next = next
+			return s
+		},
+	)
+	require.NoError(t, err)
+	return supervisor
+}
+
+func TestCreateSupervisorTyped(t *testing.T) {
+	initialState := typedSupervisorState{
+		Messages: []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Single task")},
+	}
+
+	t.Run("RoutesToMembers", func(t *testing.T) {
+		supervisor := newTypedSupervisor(t, &SupervisorMockLLM{
+			responses: []llms.ContentResponse{routeResponse("Worker"), routeResponse("FINISH")},
+		})
+		assert.False(t, supervisor.CompileReport().HasWarnings())
+
+		res, err := supervisor.Invoke(context.Background(), initialState)
+		require.NoError(t, err)
+		require.Len(t, res.Messages, 2)
+		assert.Equal(t, "Task completed", res.Messages[1].Parts[0].(llms.TextContent).Text)
+	})
+
+	t.Run("UnknownAgent", func(t *testing.T) {
+		supervisor := newTypedSupervisor(t, &SupervisorMockLLM{
+			responses: []llms.ContentResponse{routeResponse("UnknownAgent")},
+		})
+
+		_, err := supervisor.Invoke(context.Background(), initialState)
+		assert.ErrorIs(t, err, graph.ErrUnknownPathKey)
+	})
+}
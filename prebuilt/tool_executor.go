@@ -2,8 +2,11 @@ package prebuilt
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
+	"github.com/google/uuid"
+	"github.com/smallnest/langgraphgo/graph"
 	"github.com/tmc/langchaingo/tools"
 )
 
@@ -12,10 +15,23 @@ type ToolWithSchema interface {
 	Schema() map[string]any
 }
 
+// StructuredTool is an optional interface of the tools taking the arguments of a
+// tool call as a map, rather than the string input of tools.Tool. The tool
+// executor calls CallStructured instead of Call when a tool implements it.
+type StructuredTool interface {
+	tools.Tool
+	CallStructured(ctx context.Context, args map[string]any) (string, error)
+}
+
 // ToolInvocation represents a request to execute a tool
 type ToolInvocation struct {
 	Tool      string `json:"tool"`
 	ToolInput string `json:"tool_input"`
+
+	// Args are the decoded arguments of the tool call, passed to a
+	// StructuredTool. When nil, a StructuredTool receives ToolInput decoded as a
+	// JSON object, and is called with ToolInput if it isn't one.
+	Args map[string]any `json:"args,omitempty"`
 }
 
 // ToolExecutor executes tools based on invocations
@@ -34,13 +50,51 @@ func NewToolExecutor(inputTools []tools.Tool) *ToolExecutor {
 	}
 }
 
-// Execute executes a single tool invocation
+// Execute executes a single tool invocation. Called from a node, the call is
+// reported to the callbacks of the run as a tool run whose parent is the node.
 func (te *ToolExecutor) Execute(ctx context.Context, invocation ToolInvocation) (string, error) {
 	tool, ok := te.Tools[invocation.Tool]
 	if !ok {
 		return "", fmt.Errorf("tool not found: %s", invocation.Tool)
 	}
 
+	config, _ := graph.ConfigFromContext(ctx)
+	parentRunID, inNode := graph.NodeRunIDFromContext(ctx)
+	if config == nil || !inNode || len(config.Callbacks) == 0 {
+		return callTool(ctx, tool, invocation)
+	}
+
+	runID := uuid.NewString()
+	serialized := map[string]any{
+		"name":        tool.Name(),
+		"type":        "tool",
+		"description": tool.Description(),
+	}
+	for _, cb := range config.Callbacks {
+		cb.OnToolStart(ctx, serialized, invocation.ToolInput, runID, &parentRunID, config.Tags, config.Metadata)
+	}
+	output, err := callTool(ctx, tool, invocation)
+	for _, cb := range config.Callbacks {
+		if err != nil {
+			cb.OnToolError(ctx, err, runID)
+		} else {
+			cb.OnToolEnd(ctx, output, runID)
+		}
+	}
+	return output, err
+}
+
+// callTool calls tool with the arguments of invocation
+func callTool(ctx context.Context, tool tools.Tool, invocation ToolInvocation) (string, error) {
+	if structured, ok := tool.(StructuredTool); ok {
+		args := invocation.Args
+		if args == nil {
+			_ = json.Unmarshal([]byte(invocation.ToolInput), &args)
+		}
+		if args != nil {
+			return structured.CallStructured(ctx, args)
+		}
+	}
 	return tool.Call(ctx, invocation.ToolInput)
 }
 
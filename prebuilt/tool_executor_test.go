@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/smallnest/langgraphgo/graph"
 	"github.com/stretchr/testify/assert"
 	"github.com/tmc/langchaingo/tools"
 )
@@ -52,3 +53,27 @@ func TestToolExecutor(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Executed test-tool with map-input", resMap)
 }
+
+func TestToolExecutorCallbacks(t *testing.T) {
+	executor := NewToolExecutor([]tools.Tool{&MockTool{name: "test-tool"}})
+
+	g := graph.NewStateGraph[map[string]any]()
+	g.AddNode("tools", "tools", func(ctx context.Context, state map[string]any) (map[string]any, error) {
+		res, err := executor.Execute(ctx, ToolInvocation{Tool: "test-tool", ToolInput: "input"})
+		return map[string]any{"result": res}, err
+	})
+	g.AddEdge("tools", graph.END)
+	g.SetEntryPoint("tools")
+	runnable, err := g.Compile()
+	assert.NoError(t, err)
+
+	recorder := newRunRecorder()
+	_, err = runnable.InvokeWithConfig(context.Background(), map[string]any{},
+		&graph.Config{RunID: "run", Callbacks: []graph.CallbackHandler{recorder}})
+	assert.NoError(t, err)
+
+	// The tool call is a child of the node calling it
+	assert.Equal(t, "run", recorder.parents["tools"])
+	assert.NotEmpty(t, recorder.nodes["test-tool"])
+	assert.Equal(t, recorder.nodes["tools"], recorder.parents["test-tool"])
+}
@@ -4,56 +4,55 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/tmc/langchaingo/llms"
 )
 
+// ToolNodeOption configures the execution of the tool calls of a tool node
+type ToolNodeOption func(*toolNodeOptions)
+
+type toolNodeOptions struct {
+	maxConcurrency int
+	failFast       bool
+}
+
+// WithMaxConcurrency sets the number of tool calls of an AI message executed at
+// once. By default, they are all executed concurrently; 1 executes them one
+// after the other.
+func WithMaxConcurrency(n int) ToolNodeOption {
+	return func(o *toolNodeOptions) {
+		o.maxConcurrency = n
+	}
+}
+
+// WithFailFast makes the first failing tool call cancel the others, and the node
+// fail with its error. By default, a failing call doesn't affect the others, and
+// its error is the content of its tool message.
+func WithFailFast() ToolNodeOption {
+	return func(o *toolNodeOptions) {
+		o.failFast = true
+	}
+}
+
 // ToolNodeMap is a reusable node that executes tool calls from the last AI message
 // for map[string]any state.
-func ToolNodeMap(executor *ToolExecutor) func(context.Context, map[string]any) (map[string]any, error) {
+func ToolNodeMap(executor *ToolExecutor, opts ...ToolNodeOption) func(context.Context, map[string]any) (map[string]any, error) {
+	o := newToolNodeOptions(opts)
 	return func(ctx context.Context, state map[string]any) (map[string]any, error) {
 		messages, ok := state["messages"].([]llms.MessageContent)
 		if !ok || len(messages) == 0 {
 			return nil, fmt.Errorf("no messages found in state")
 		}
 
-		lastMsg := messages[len(messages)-1]
-		if lastMsg.Role != llms.ChatMessageTypeAI {
+		calls, ok := pendingToolCalls(messages)
+		if !ok {
 			return nil, fmt.Errorf("last message is not an AI message")
 		}
 
-		var toolMessages []llms.MessageContent
-		for _, part := range lastMsg.Parts {
-			if tc, ok := part.(llms.ToolCall); ok {
-				var args map[string]any
-				_ = json.Unmarshal([]byte(tc.FunctionCall.Arguments), &args)
-
-				inputVal := ""
-				if val, ok := args["input"].(string); ok {
-					inputVal = val
-				} else {
-					inputVal = tc.FunctionCall.Arguments
-				}
-
-				res, err := executor.Execute(ctx, ToolInvocation{
-					Tool:      tc.FunctionCall.Name,
-					ToolInput: inputVal,
-				})
-				if err != nil {
-					res = fmt.Sprintf("Error: %v", err)
-				}
-
-				toolMessages = append(toolMessages, llms.MessageContent{
-					Role: llms.ChatMessageTypeTool,
-					Parts: []llms.ContentPart{
-						llms.ToolCallResponse{
-							ToolCallID: tc.ID,
-							Name:       tc.FunctionCall.Name,
-							Content:    res,
-						},
-					},
-				})
-			}
+		toolMessages, err := executeToolCalls(ctx, executor, calls, o)
+		if err != nil {
+			return nil, err
 		}
 
 		return map[string]any{
@@ -67,52 +66,175 @@ func ToolNode[S any](
 	executor *ToolExecutor,
 	getMessages func(S) []llms.MessageContent,
 	setMessages func(S, []llms.MessageContent) S,
+	opts ...ToolNodeOption,
 ) func(context.Context, S) (S, error) {
+	o := newToolNodeOptions(opts)
 	return func(ctx context.Context, state S) (S, error) {
 		messages := getMessages(state)
 		if len(messages) == 0 {
 			return state, fmt.Errorf("no messages")
 		}
 
-		lastMsg := messages[len(messages)-1]
-		if lastMsg.Role != llms.ChatMessageTypeAI {
+		calls, ok := pendingToolCalls(messages)
+		if !ok {
 			return state, fmt.Errorf("not an AI message")
 		}
 
-		var toolMessages []llms.MessageContent
-		for _, part := range lastMsg.Parts {
-			if tc, ok := part.(llms.ToolCall); ok {
-				var args map[string]any
-				_ = json.Unmarshal([]byte(tc.FunctionCall.Arguments), &args)
-
-				inputVal := ""
-				if val, ok := args["input"].(string); ok {
-					inputVal = val
-				} else {
-					inputVal = tc.FunctionCall.Arguments
-				}
+		toolMessages, err := executeToolCalls(ctx, executor, calls, o)
+		if err != nil {
+			return state, err
+		}
+
+		return setMessages(state, append(messages, toolMessages...)), nil
+	}
+}
+
+func newToolNodeOptions(opts []ToolNodeOption) toolNodeOptions {
+	var o toolNodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// pendingToolCalls returns the tool calls of the last AI message of messages
+// that the tool messages following it don't answer yet, such as the calls a
+// human approval node denied. It reports false when the last message is neither
+// an AI message nor a tool message following one.
+func pendingToolCalls(messages []llms.MessageContent) ([]llms.ToolCall, bool) {
+	answered := make(map[string]bool)
+	i := len(messages) - 1
+	for ; i >= 0 && messages[i].Role == llms.ChatMessageTypeTool; i-- {
+		for _, part := range messages[i].Parts {
+			if resp, ok := part.(llms.ToolCallResponse); ok {
+				answered[resp.ToolCallID] = true
+			}
+		}
+	}
+	if i < 0 || messages[i].Role != llms.ChatMessageTypeAI {
+		return nil, false
+	}
 
-				res, err := executor.Execute(ctx, ToolInvocation{
-					Tool:      tc.FunctionCall.Name,
-					ToolInput: inputVal,
-				})
-				if err != nil {
-					res = fmt.Sprintf("Error: %v", err)
+	var calls []llms.ToolCall
+	for _, part := range messages[i].Parts {
+		if tc, ok := part.(llms.ToolCall); ok && !answered[tc.ID] {
+			calls = append(calls, tc)
+		}
+	}
+	return calls, true
+}
+
+// executeToolCalls executes calls concurrently, and returns their tool messages
+// in the order of the calls
+func executeToolCalls(ctx context.Context, executor *ToolExecutor, calls []llms.ToolCall, o toolNodeOptions) ([]llms.MessageContent, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	limit := o.maxConcurrency
+	if limit <= 0 || limit > len(calls) {
+		limit = len(calls)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	toolMessages := make([]llms.MessageContent, len(calls))
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, tc := range calls {
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if o.failFast && ctx.Err() != nil {
+				return
+			}
+
+			res, err := executor.Execute(ctx, toolCallInvocation(executor, tc))
+			if err != nil {
+				if o.failFast {
+					cancel(fmt.Errorf("tool %s failed: %w", tc.FunctionCall.Name, err))
 				}
+				res = fmt.Sprintf("Error: %v", err)
+			}
 
-				toolMessages = append(toolMessages, llms.MessageContent{
-					Role: llms.ChatMessageTypeTool,
-					Parts: []llms.ContentPart{
-						llms.ToolCallResponse{
-							ToolCallID: tc.ID,
-							Name:       tc.FunctionCall.Name,
-							Content:    res,
-						},
+			toolMessages[i] = llms.MessageContent{
+				Role: llms.ChatMessageTypeTool,
+				Parts: []llms.ContentPart{
+					llms.ToolCallResponse{
+						ToolCallID: tc.ID,
+						Name:       tc.FunctionCall.Name,
+						Content:    res,
 					},
-				})
+				},
 			}
+		})
+	}
+	wg.Wait()
+
+	if o.failFast {
+		if err := context.Cause(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return toolMessages, nil
+}
+
+// toolCallInvocation returns the invocation of the tool of tc, with the decoded
+// arguments for a StructuredTool. The other tools receive the JSON arguments
+// when they have a ToolWithSchema, and the "input" argument of the default
+// schema otherwise, if it is a string.
+func toolCallInvocation(executor *ToolExecutor, tc llms.ToolCall) ToolInvocation {
+	invocation := ToolInvocation{Tool: tc.FunctionCall.Name, ToolInput: tc.FunctionCall.Arguments}
+	_ = json.Unmarshal([]byte(tc.FunctionCall.Arguments), &invocation.Args)
+	if _, hasSchema := executor.Tools[tc.FunctionCall.Name].(ToolWithSchema); hasSchema {
+		return invocation
+	}
+	if val, ok := invocation.Args["input"].(string); ok {
+		invocation.ToolInput = val
+	}
+	return invocation
+}
+
+// ToolsCondition returns a router for AddConditionalEdge in map[string]any state
+// graphs, going to toolsNode when the last message is an AI message with tool
+// calls, and to endTarget otherwise, such as when there are no messages. Tool
+// calls without a name are ignored.
+//
+// Example:
+//
+//	workflow.AddConditionalEdge("agent", prebuilt.ToolsCondition("tools", graph.END))
+func ToolsCondition(toolsNode, endTarget string) func(context.Context, map[string]any) string {
+	return ToolsConditionTyped(func(state map[string]any) []llms.MessageContent {
+		messages, _ := state["messages"].([]llms.MessageContent)
+		return messages
+	}, toolsNode, endTarget)
+}
+
+// ToolsConditionTyped is ToolsCondition for the graphs of state S, whose
+// messages getMessages returns
+func ToolsConditionTyped[S any](getMessages func(S) []llms.MessageContent, toolsNode, endTarget string) func(context.Context, S) string {
+	return func(_ context.Context, state S) string {
+		if hasToolCalls(getMessages(state)) {
+			return toolsNode
 		}
+		return endTarget
+	}
+}
 
-		return setMessages(state, append(messages, toolMessages...)), nil
+// hasToolCalls reports whether the last message of messages is an AI message
+// with named tool calls
+func hasToolCalls(messages []llms.MessageContent) bool {
+	if len(messages) == 0 {
+		return false
+	}
+	lastMsg := messages[len(messages)-1]
+	if lastMsg.Role != llms.ChatMessageTypeAI {
+		return false
+	}
+	for _, part := range lastMsg.Parts {
+		if tc, ok := part.(llms.ToolCall); ok && tc.FunctionCall != nil && tc.FunctionCall.Name != "" {
+			return true
+		}
 	}
+	return false
 }
@@ -2,9 +2,15 @@ package prebuilt
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/smallnest/langgraphgo/graph"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/tools"
 )
@@ -53,3 +59,219 @@ func TestToolNodeMap(t *testing.T) {
 	assert.Equal(t, "test-tool", toolResp.Name)
 	assert.Equal(t, "Executed test-tool with test-input", toolResp.Content)
 }
+
+// slowTool sleeps for the number of milliseconds of its input before answering
+type slowTool struct {
+	running, peak atomic.Int32
+}
+
+func (t *slowTool) Name() string        { return "slow" }
+func (t *slowTool) Description() string { return "sleeps for input milliseconds" }
+
+func (t *slowTool) Call(ctx context.Context, input string) (string, error) {
+	running := t.running.Add(1)
+	defer t.running.Add(-1)
+	for {
+		peak := t.peak.Load()
+		if running <= peak || t.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+
+	ms, err := strconv.Atoi(input)
+	if err != nil {
+		return "", fmt.Errorf("invalid delay %q", input)
+	}
+	select {
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+		return "slept " + input, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func toolCallsMessage(inputs ...string) llms.MessageContent {
+	msg := llms.MessageContent{Role: llms.ChatMessageTypeAI}
+	for i, input := range inputs {
+		msg.Parts = append(msg.Parts, llms.ToolCall{
+			ID:           fmt.Sprintf("call_%d", i),
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: "slow", Arguments: fmt.Sprintf(`{"input": %q}`, input)},
+		})
+	}
+	return msg
+}
+
+func toolContents(t *testing.T, messages []llms.MessageContent) []string {
+	t.Helper()
+	var contents []string
+	for i, msg := range messages {
+		resp, ok := msg.Parts[0].(llms.ToolCallResponse)
+		require.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("call_%d", i), resp.ToolCallID)
+		contents = append(contents, resp.Content)
+	}
+	return contents
+}
+
+func TestToolNodeConcurrency(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+		tool := &slowTool{}
+		node := ToolNodeMap(NewToolExecutor([]tools.Tool{tool}))
+
+		start := time.Now()
+		res, err := node(context.Background(), map[string]any{
+			"messages": []llms.MessageContent{toolCallsMessage("120", "80", "40", "10")},
+		})
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 240*time.Millisecond)
+		assert.Equal(t, int32(4), tool.peak.Load())
+
+		// The tool messages follow the order of the calls, not of their completion
+		assert.Equal(t, []string{"slept 120", "slept 80", "slept 40", "slept 10"},
+			toolContents(t, res["messages"].([]llms.MessageContent)))
+	})
+
+	t.Run("MaxConcurrency", func(t *testing.T) {
+		t.Parallel()
+		tool := &slowTool{}
+		node := ToolNodeMap(NewToolExecutor([]tools.Tool{tool}), WithMaxConcurrency(2))
+
+		_, err := node(context.Background(), map[string]any{
+			"messages": []llms.MessageContent{toolCallsMessage("20", "20", "20", "20", "20")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), tool.peak.Load())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+		node := ToolNodeMap(NewToolExecutor([]tools.Tool{&slowTool{}}))
+
+		res, err := node(context.Background(), map[string]any{
+			"messages": []llms.MessageContent{toolCallsMessage("30", "oops", "10")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"slept 30", `Error: invalid delay "oops"`, "slept 10"},
+			toolContents(t, res["messages"].([]llms.MessageContent)))
+	})
+
+	t.Run("FailFast", func(t *testing.T) {
+		t.Parallel()
+		type state struct{ messages []llms.MessageContent }
+		node := ToolNode(NewToolExecutor([]tools.Tool{&slowTool{}}),
+			func(s state) []llms.MessageContent { return s.messages },
+			func(s state, messages []llms.MessageContent) state { return state{messages: messages} },
+			WithFailFast())
+
+		start := time.Now()
+		_, err := node(context.Background(), state{messages: []llms.MessageContent{toolCallsMessage("5000", "oops")}})
+		assert.EqualError(t, err, `tool slow failed: invalid delay "oops"`)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+// weatherTool is a StructuredTool recording the arguments it was called with
+type weatherTool struct {
+	args map[string]any
+}
+
+func (t *weatherTool) Name() string        { return "weather" }
+func (t *weatherTool) Description() string { return "forecasts the weather" }
+
+func (t *weatherTool) Call(ctx context.Context, input string) (string, error) {
+	return "", fmt.Errorf("unexpected call with %q", input)
+}
+
+func (t *weatherTool) CallStructured(ctx context.Context, args map[string]any) (string, error) {
+	t.args = args
+	return fmt.Sprintf("%v days in %v", args["days"], args["city"]), nil
+}
+
+func TestToolNodeStructuredArgs(t *testing.T) {
+	weather := &weatherTool{}
+	legacy := &MockTool{name: "legacy"}
+	node := ToolNodeMap(NewToolExecutor([]tools.Tool{weather, legacy}))
+
+	call := func(i int, name, arguments string) llms.ToolCall {
+		return llms.ToolCall{
+			ID:           fmt.Sprintf("call_%d", i),
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: name, Arguments: arguments},
+		}
+	}
+	res, err := node(context.Background(), map[string]any{
+		"messages": []llms.MessageContent{{
+			Role: llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{
+				call(0, "weather", `{"city":"Paris","days":3,"units":{"temp":"C"}}`),
+				call(1, "legacy", `{"city":"Paris"}`),
+				call(2, "legacy", `{"input":"plain"}`),
+			},
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{"city": "Paris", "days": float64(3), "units": map[string]any{"temp": "C"}}, weather.args)
+	assert.Equal(t, []string{
+		"3 days in Paris",
+		`Executed legacy with {"city":"Paris"}`,
+		"Executed legacy with plain",
+	}, toolContents(t, res["messages"].([]llms.MessageContent)))
+}
+
+func TestToolsCondition(t *testing.T) {
+	router := ToolsCondition("tools", graph.END)
+	route := func(messages ...llms.MessageContent) string {
+		return router(context.Background(), map[string]any{"messages": messages})
+	}
+
+	assert.Equal(t, "tools", route(llms.TextParts(llms.ChatMessageTypeHuman, "hi"), toolCallsMessage("10")))
+	assert.Equal(t, graph.END, route())
+	assert.Equal(t, graph.END, router(context.Background(), map[string]any{}))
+	assert.Equal(t, graph.END, route(llms.TextParts(llms.ChatMessageTypeAI, "done")))
+
+	// A tool call in a message that isn't from the AI doesn't route to the tools
+	notAI := toolCallsMessage("10")
+	notAI.Role = llms.ChatMessageTypeHuman
+	assert.Equal(t, graph.END, route(notAI))
+
+	unnamed := llms.MessageContent{
+		Role: llms.ChatMessageTypeAI,
+		Parts: []llms.ContentPart{
+			llms.ToolCall{ID: "call_0", Type: "function", FunctionCall: &llms.FunctionCall{Arguments: "{}"}},
+			llms.ToolCall{ID: "call_1", Type: "function"},
+		},
+	}
+	assert.Equal(t, graph.END, route(unnamed))
+
+	type state struct{ messages []llms.MessageContent }
+	typed := ToolsConditionTyped(func(s state) []llms.MessageContent { return s.messages }, "act", "end")
+	assert.Equal(t, "act", typed(context.Background(), state{messages: []llms.MessageContent{toolCallsMessage("10")}}))
+	assert.Equal(t, "end", typed(context.Background(), state{}))
+}
+
+func TestToolNodeToolWithSchema(t *testing.T) {
+	tool := &MockToolWithSchema{
+		MockToolWithResponse: MockToolWithResponse{name: "book_hotel"},
+		schema:               map[string]any{"type": "object"},
+	}
+	node := ToolNodeMap(NewToolExecutor([]tools.Tool{tool}))
+
+	// The "input" argument is only extracted for the tools with the default schema
+	_, err := node(context.Background(), map[string]any{
+		"messages": []llms.MessageContent{{
+			Role: llms.ChatMessageTypeAI,
+			Parts: []llms.ContentPart{llms.ToolCall{
+				ID:           "call_0",
+				Type:         "function",
+				FunctionCall: &llms.FunctionCall{Name: "book_hotel", Arguments: `{"input":"Paris","nights":2}`},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"input":"Paris","nights":2}`, tool.input)
+}
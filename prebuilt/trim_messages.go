@@ -0,0 +1,148 @@
+package prebuilt
+
+import (
+	"slices"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// TrimOptions configures TrimMessages. The limits left at zero don't apply.
+type TrimOptions struct {
+	// MaxMessages is the number of messages kept, not counting the system
+	// messages
+	MaxMessages int
+
+	// MaxTokens is the number of tokens of the messages kept, as counted by
+	// TokenCounter
+	MaxTokens int
+
+	// TokenCounter counts the tokens of messages, ApproximateTokenCount by
+	// default
+	TokenCounter func(messages []llms.MessageContent) int
+}
+
+// TrimMessages returns the messages fitting the limits of opts, dropping the
+// oldest ones first. The system messages and the most recent human message are
+// always kept, and an AI message with tool calls is kept or dropped together
+// with the tool messages responding to it, so the conversation stays valid for
+// the model. messages isn't modified.
+//
+// Example:
+//
+//	msgs := prebuilt.TrimMessages(messages, prebuilt.TrimOptions{MaxMessages: 20})
+//	resp, err := model.GenerateContent(ctx, msgs)
+func TrimMessages(messages []llms.MessageContent, opts TrimOptions) []llms.MessageContent {
+	if opts.MaxMessages <= 0 && opts.MaxTokens <= 0 {
+		return messages
+	}
+	counter := opts.TokenCounter
+	if counter == nil {
+		counter = ApproximateTokenCount
+	}
+
+	// The other messages are split into the groups kept or dropped together
+	var groups [][]int
+	keep := make([]bool, len(messages))
+	lastHuman := -1
+	for i, msg := range messages {
+		switch {
+		case msg.Role == llms.ChatMessageTypeSystem:
+			keep[i] = true
+			continue
+		case msg.Role == llms.ChatMessageTypeTool && len(groups) > 0 && respondsTo(messages, groups[len(groups)-1]):
+			groups[len(groups)-1] = append(groups[len(groups)-1], i)
+		default:
+			groups = append(groups, []int{i})
+		}
+		if msg.Role == llms.ChatMessageTypeHuman {
+			lastHuman = len(groups) - 1
+		}
+	}
+
+	kept := 0
+	fits := func(group []int) bool {
+		if opts.MaxMessages > 0 && kept+len(group) > opts.MaxMessages {
+			return false
+		}
+		if opts.MaxTokens > 0 {
+			for _, i := range group {
+				keep[i] = true
+			}
+			tokens := counter(keptMessages(messages, keep))
+			for _, i := range group {
+				keep[i] = false
+			}
+			if tokens > opts.MaxTokens {
+				return false
+			}
+		}
+		return true
+	}
+	add := func(group []int) {
+		for _, i := range group {
+			keep[i] = true
+		}
+		kept += len(group)
+	}
+
+	// After the most recent human message, the groups are kept newest first until
+	// one doesn't fit, so that only the oldest messages are dropped
+	if lastHuman >= 0 {
+		add(groups[lastHuman])
+	}
+	for g := len(groups) - 1; g >= 0; g-- {
+		if g == lastHuman {
+			continue
+		}
+		if !fits(groups[g]) {
+			break
+		}
+		add(groups[g])
+	}
+	return keptMessages(messages, keep)
+}
+
+// respondsTo reports whether a tool message following group responds to it, as
+// group starts with an AI message with tool calls
+func respondsTo(messages []llms.MessageContent, group []int) bool {
+	first := messages[group[0]]
+	if first.Role != llms.ChatMessageTypeAI {
+		return false
+	}
+	return slices.ContainsFunc(first.Parts, func(part llms.ContentPart) bool {
+		_, ok := part.(llms.ToolCall)
+		return ok
+	})
+}
+
+// keptMessages returns the messages whose keep flag is set, in order
+func keptMessages(messages []llms.MessageContent, keep []bool) []llms.MessageContent {
+	var result []llms.MessageContent
+	for i, msg := range messages {
+		if keep[i] {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// ApproximateTokenCount estimates the tokens of messages as one per four
+// characters of their text, tool calls and tool responses
+func ApproximateTokenCount(messages []llms.MessageContent) int {
+	chars := 0
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			switch p := part.(type) {
+			case llms.TextContent:
+				chars += len(p.Text)
+			case llms.ToolCall:
+				if p.FunctionCall != nil {
+					chars += len(p.FunctionCall.Name) + len(p.FunctionCall.Arguments)
+				}
+			case llms.ToolCallResponse:
+				chars += len(p.Content)
+			}
+		}
+	}
+	return (chars + 3) / 4
+}
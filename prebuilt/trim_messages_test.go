@@ -0,0 +1,105 @@
+package prebuilt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// messageTexts returns the roles and texts of messages as role:text, the ID of the
+// call for tool calls and responses
+func messageTexts(messages []llms.MessageContent) []string {
+	var texts []string
+	for _, msg := range messages {
+		switch part := msg.Parts[0].(type) {
+		case llms.TextContent:
+			texts = append(texts, string(msg.Role)+":"+part.Text)
+		case llms.ToolCall:
+			texts = append(texts, string(msg.Role)+":"+part.ID)
+		case llms.ToolCallResponse:
+			texts = append(texts, string(msg.Role)+":"+part.ToolCallID)
+		}
+	}
+	return texts
+}
+
+func toolExchange(id string) []llms.MessageContent {
+	return []llms.MessageContent{
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.ToolCall{ID: id, Type: "function", FunctionCall: &llms.FunctionCall{Name: "search"}}}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: id, Name: "search", Content: "result"}}},
+	}
+}
+
+func TestTrimMessages(t *testing.T) {
+	conversation := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "be brief"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "first"),
+		llms.TextParts(llms.ChatMessageTypeAI, "one"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "second"),
+	}
+	conversation = append(conversation, toolExchange("call_1")...)
+	conversation = append(conversation, toolExchange("call_2")...)
+
+	t.Run("No limits", func(t *testing.T) {
+		assert.Equal(t, conversation, TrimMessages(conversation, TrimOptions{}))
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		assert.Equal(t, []string{"system:be brief", "human:second", "ai:call_1", "tool:call_1", "ai:call_2", "tool:call_2"},
+			messageTexts(TrimMessages(conversation, TrimOptions{MaxMessages: 5})))
+
+		// The pair of call_1 doesn't fit with the one of call_2, and the older
+		// messages are dropped with it
+		assert.Equal(t, []string{"system:be brief", "human:second", "ai:call_2", "tool:call_2"},
+			messageTexts(TrimMessages(conversation, TrimOptions{MaxMessages: 4})))
+
+		// The most recent human message is kept whatever the limit
+		assert.Equal(t, []string{"system:be brief", "human:second"},
+			messageTexts(TrimMessages(conversation, TrimOptions{MaxMessages: 1})))
+		assert.Len(t, conversation, 8, "the messages aren't modified")
+	})
+
+	t.Run("Token budget", func(t *testing.T) {
+		count := func(messages []llms.MessageContent) int { return len(messages) * 10 }
+		assert.Equal(t, []string{"system:be brief", "human:second", "ai:call_2", "tool:call_2"},
+			messageTexts(TrimMessages(conversation, TrimOptions{MaxTokens: 45, TokenCounter: count})))
+		assert.Equal(t, []string{"system:be brief", "human:first", "ai:one", "human:second", "ai:call_1", "tool:call_1", "ai:call_2", "tool:call_2"},
+			messageTexts(TrimMessages(conversation, TrimOptions{MaxTokens: 80, TokenCounter: count})))
+	})
+
+	t.Run("Approximate tokens", func(t *testing.T) {
+		messages := []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeHuman, "an older question of 40 characters......"),
+			llms.TextParts(llms.ChatMessageTypeHuman, "a question of 24 chars.."),
+		}
+		assert.Equal(t, 16, ApproximateTokenCount(messages))
+		assert.Equal(t, []string{"human:a question of 24 chars.."},
+			messageTexts(TrimMessages(messages, TrimOptions{MaxTokens: 10})))
+	})
+}
+
+func TestCreateAgentMapMessageWindow(t *testing.T) {
+	mockLLM := &MockLLMWithInputCapture{}
+	agent, err := CreateAgentMap(mockLLM, []tools.Tool{}, 0,
+		WithSystemMessage("You are a helpful assistant."), WithMessageWindow(2))
+	require.NoError(t, err)
+
+	history := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+		llms.TextParts(llms.ChatMessageTypeAI, "Hello!"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "How are you?"),
+		llms.TextParts(llms.ChatMessageTypeAI, "Fine."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Good."),
+	}
+	res, err := agent.Invoke(context.Background(), map[string]any{"messages": history})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"system:You are a helpful assistant.", "ai:Fine.", "human:Good."}, messageTexts(mockLLM.lastMessages))
+
+	// The state keeps the whole conversation
+	assert.Len(t, res["messages"].([]llms.MessageContent), len(history)+1)
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// archiveFormat identifies checkpoint archives in their header
+	archiveFormat = "langgraphgo-checkpoints"
+
+	// archiveVersion is the version of the archive format Export writes. Import
+	// reads archives up to this version.
+	archiveVersion = 1
+
+	// serializerMetadataKey is the checkpoint metadata naming the graph Serializer
+	// of a serialized state
+	serializerMetadataKey = "state_serializer"
+)
+
+// archiveHeader is the first line of a checkpoint archive
+type archiveHeader struct {
+	Format   string `json:"format"`
+	Version  int    `json:"version"`
+	ThreadID string `json:"thread_id"`
+
+	// Count is the number of checkpoints that follow, so truncated archives are detected
+	Count int `json:"count"`
+}
+
+// archiveRecord is a line of a checkpoint archive after the header
+type archiveRecord struct {
+	// Serializer and Codec name the graph Serializer and the Codec the state was
+	// encoded with, empty when it was saved as it is
+	Serializer string `json:"serializer,omitempty"`
+	Codec      string `json:"codec,omitempty"`
+
+	Checkpoint *Checkpoint `json:"checkpoint"`
+}
+
+// Export writes the checkpoints of threadID in src to w as an archive that Import
+// reads into another store, for example to load a production thread into a local
+// file store. The archive is JSON Lines: a header naming the format, its version,
+// the thread and the number of checkpoints, followed by a line per checkpoint,
+// sorted by version. Each line records the serializer and codec of the state, and
+// the state is written as the store loaded it, so export from the store the
+// checkpoints were saved in rather than from a wrapper that decodes them.
+//
+// Example:
+//
+//	f, err := os.Create("thread-1.jsonl")
+//	if err != nil {
+//	    return err
+//	}
+//	defer f.Close()
+//	if err := store.Export(ctx, redisStore, "thread-1", f); err != nil {
+//	    return err
+//	}
+func Export(ctx context.Context, src CheckpointStore, threadID string, w io.Writer) error {
+	checkpoints, err := src.ListByThread(ctx, threadID)
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints for thread %s: %w", threadID, err)
+	}
+	if len(checkpoints) == 0 {
+		return fmt.Errorf("thread %s has no checkpoints", threadID)
+	}
+
+	enc := json.NewEncoder(w)
+	header := archiveHeader{Format: archiveFormat, Version: archiveVersion, ThreadID: threadID, Count: len(checkpoints)}
+	if err := enc.Encode(header); err != nil {
+		return fmt.Errorf("failed to write archive header: %w", err)
+	}
+	for _, cp := range checkpoints {
+		record := archiveRecord{Checkpoint: cp}
+		record.Serializer, _ = cp.Metadata[serializerMetadataKey].(string)
+		record.Codec, _ = cp.Metadata[codecMetadataKey].(string)
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to write checkpoint %s: %w", cp.ID, err)
+		}
+	}
+	return nil
+}
+
+// Import saves the checkpoints of an archive written by Export in dst, keeping
+// their IDs, versions and metadata. It returns an error if the archive is of an
+// unknown format, or if it is truncated, after saving the checkpoints before the
+// truncation. Checkpoints with the IDs of checkpoints already in dst replace them.
+// Import into the kind of store the checkpoints were exported from, such as a
+// CompressedStore's inner store for compressed checkpoints, and wrap it as in
+// production to load them.
+//
+// States are decoded from JSON, so map states come back as map[string]any like
+// the states of the file and database stores.
+//
+// Example:
+//
+//	f, err := os.Open("thread-1.jsonl")
+//	if err != nil {
+//	    return err
+//	}
+//	defer f.Close()
+//	local, err := file.NewFileCheckpointStore("./checkpoints")
+//	if err != nil {
+//	    return err
+//	}
+//	if err := store.Import(ctx, local, f); err != nil {
+//	    return err
+//	}
+func Import(ctx context.Context, dst CheckpointStore, r io.Reader) error {
+	dec := json.NewDecoder(r)
+
+	var header archiveHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("failed to read archive header: %w", err)
+	}
+	if header.Format != archiveFormat {
+		return fmt.Errorf("not a checkpoint archive: format %q", header.Format)
+	}
+	if header.Version < 1 || header.Version > archiveVersion {
+		return fmt.Errorf("unsupported checkpoint archive version %d", header.Version)
+	}
+
+	imported := 0
+	for {
+		var record archiveRecord
+		err := dec.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read checkpoint %d of %d: %w", imported+1, header.Count, err)
+		}
+		if record.Checkpoint == nil || record.Checkpoint.ID == "" {
+			return fmt.Errorf("checkpoint %d of %d has no id", imported+1, header.Count)
+		}
+
+		cp := record.Checkpoint
+		if record.Serializer != "" || record.Codec != "" {
+			if cp.Metadata == nil {
+				cp.Metadata = make(map[string]any)
+			}
+			if record.Serializer != "" {
+				cp.Metadata[serializerMetadataKey] = record.Serializer
+			}
+			if record.Codec != "" {
+				cp.Metadata[codecMetadataKey] = record.Codec
+			}
+		}
+		if err := dst.Save(ctx, cp); err != nil {
+			return fmt.Errorf("failed to save checkpoint %s: %w", cp.ID, err)
+		}
+		imported++
+	}
+
+	if imported != header.Count {
+		return fmt.Errorf("checkpoint archive of thread %s is truncated: %d of %d checkpoints", header.ThreadID, imported, header.Count)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/file"
+	"github.com/smallnest/langgraphgo/store/memory"
+	"github.com/smallnest/langgraphgo/store/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// saveArchiveThread saves checkpoints with nested states for the archive tests
+func saveArchiveThread(t *testing.T, s store.CheckpointStore, threadID string) {
+	t.Helper()
+	for v := 1; v <= 3; v++ {
+		cp := &store.Checkpoint{
+			ID:       fmt.Sprintf("%s-cp-%d", threadID, v),
+			NodeName: fmt.Sprintf("node-%d", v),
+			State: map[string]any{
+				"step":     v,
+				"ratio":    0.25 * float64(v),
+				"messages": []any{"hi", map[string]any{"role": "ai", "content": "<b>ok</b>"}},
+				"user":     map[string]any{"name": "ada", "tags": []any{"admin"}},
+			},
+			Metadata:  map[string]any{"thread_id": threadID, "execution_id": threadID, "event": "step"},
+			Timestamp: time.Date(2024, 1, 2, 3, 4, v, 0, time.UTC),
+			Version:   v,
+		}
+		require.NoError(t, s.Save(context.Background(), cp))
+	}
+}
+
+// requireSameThread checks that both stores hold the checkpoints of threadID with
+// byte-equal states
+func requireSameThread(t *testing.T, want, got store.CheckpointStore, threadID string) {
+	t.Helper()
+	ctx := context.Background()
+
+	expected, err := want.ListByThread(ctx, threadID)
+	require.NoError(t, err)
+	actual, err := got.ListByThread(ctx, threadID)
+	require.NoError(t, err)
+	require.Len(t, actual, len(expected))
+
+	for i, cp := range expected {
+		assert.Equal(t, cp.ID, actual[i].ID)
+		assert.Equal(t, cp.NodeName, actual[i].NodeName)
+		assert.Equal(t, cp.Version, actual[i].Version)
+		assert.True(t, cp.Timestamp.Equal(actual[i].Timestamp))
+		assert.Equal(t, cp.Metadata, actual[i].Metadata)
+
+		wantState, err := json.Marshal(cp.State)
+		require.NoError(t, err)
+		gotState, err := json.Marshal(actual[i].State)
+		require.NoError(t, err)
+		assert.Equal(t, string(wantState), string(gotState), "state of %s", cp.ID)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	t.Parallel()
+
+	newFile := func(t *testing.T) store.CheckpointStore {
+		s, err := file.NewFileCheckpointStore(t.TempDir())
+		require.NoError(t, err)
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		src, dst func(t *testing.T) store.CheckpointStore
+	}{
+		{
+			name: "RedisToFile",
+			src: func(t *testing.T) store.CheckpointStore {
+				return redis.NewRedisCheckpointStore(redis.RedisOptions{Addr: miniredis.RunT(t).Addr()})
+			},
+			dst: newFile,
+		},
+		{
+			name: "FileToMemory",
+			src:  newFile,
+			dst: func(t *testing.T) store.CheckpointStore {
+				return memory.NewMemoryCheckpointStore()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			src := tt.src(t)
+			saveArchiveThread(t, src, "thread")
+			saveArchiveThread(t, src, "other")
+
+			var archive bytes.Buffer
+			require.NoError(t, store.Export(ctx, src, "thread", &archive))
+			lines := strings.Split(strings.TrimSpace(archive.String()), "\n")
+			require.Len(t, lines, 4, "a header and a line per checkpoint")
+			assert.Contains(t, lines[0], `"format":"langgraphgo-checkpoints"`)
+
+			dst := tt.dst(t)
+			require.NoError(t, store.Import(ctx, dst, bytes.NewReader(archive.Bytes())))
+			requireSameThread(t, src, dst, "thread")
+
+			others, err := dst.ListByThread(ctx, "other")
+			require.NoError(t, err)
+			assert.Empty(t, others, "only the exported thread is imported")
+
+			latest, err := dst.GetLatestByThread(ctx, "thread")
+			require.NoError(t, err)
+			assert.Equal(t, "thread-cp-3", latest.ID)
+		})
+	}
+}
+
+func TestExportImport_Compressed(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	src := redis.NewRedisCheckpointStore(redis.RedisOptions{Addr: miniredis.RunT(t).Addr()})
+	saveArchiveThread(t, store.NewCompressedStore(src, store.Gzip), "thread")
+
+	// The archive records the codec of the states it holds compressed
+	var archive bytes.Buffer
+	require.NoError(t, store.Export(ctx, src, "thread", &archive))
+	lines := strings.Split(strings.TrimSpace(archive.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[1], `"codec":"gzip"`)
+
+	dst, err := file.NewFileCheckpointStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, store.Import(ctx, dst, &archive))
+
+	requireSameThread(t, store.NewCompressedStore(src, store.Gzip), store.NewCompressedStore(dst, store.Gzip), "thread")
+}
+
+func TestExportImport_Errors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	src := memory.NewMemoryCheckpointStore()
+	saveArchiveThread(t, src, "thread")
+
+	var empty bytes.Buffer
+	assert.ErrorContains(t, store.Export(ctx, src, "missing", &empty), "has no checkpoints")
+
+	var archive bytes.Buffer
+	require.NoError(t, store.Export(ctx, src, "thread", &archive))
+	lines := strings.SplitAfter(archive.String(), "\n")
+
+	tests := []struct {
+		name    string
+		archive string
+		want    string
+	}{
+		{"Empty", "", "failed to read archive header"},
+		{"NotAnArchive", `{"id":"cp-1"}` + "\n", "not a checkpoint archive"},
+		{"NewerVersion", `{"format":"langgraphgo-checkpoints","version":99}` + "\n", "unsupported checkpoint archive version 99"},
+		{"MissingLines", strings.Join(lines[:3], ""), "truncated: 2 of 3 checkpoints"},
+		{"TruncatedLine", strings.Join(lines[:3], "") + lines[3][:len(lines[3])/2], "failed to read checkpoint 3 of 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dst := memory.NewMemoryCheckpointStore()
+			assert.ErrorContains(t, store.Import(ctx, dst, strings.NewReader(tt.archive)), tt.want)
+		})
+	}
+}
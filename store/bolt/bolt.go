@@ -0,0 +1,331 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	// checkpointsBucket maps checkpoint IDs to checkpoint JSON
+	checkpointsBucket = []byte("checkpoints")
+
+	// threadsBucket has a bucket per thread_id, keyed by version and checkpoint ID
+	threadsBucket = []byte("threads")
+
+	// groupsBucket has a bucket for every execution_id, thread_id, session_id and
+	// workflow_id, keyed like the thread buckets. List reads them.
+	groupsBucket = []byte("groups")
+)
+
+// groupKeys are the metadata keys checkpoints are grouped by for List
+var groupKeys = []string{"execution_id", "thread_id", "session_id", "workflow_id"}
+
+// BoltCheckpointStore implements store.CheckpointStore with an embedded bbolt
+// database. Checkpoints are indexed by thread and by execution in keys ordered by
+// version, so the latest checkpoint of a thread is found by seeking to the end of
+// its bucket and List reads only the checkpoints it returns.
+type BoltCheckpointStore struct {
+	db *bbolt.DB
+}
+
+// BoltOptions configuration for the bbolt database
+type BoltOptions struct {
+	Path    string
+	Timeout time.Duration // Time to wait for the file lock held by another process, default 1s
+}
+
+// NewBoltCheckpointStore creates a checkpoint store in the bbolt database at path,
+// creating the file and its directory if needed. It is a drop-in replacement for
+// the file store.
+//
+// Example:
+//
+//	store, err := bolt.NewBoltCheckpointStore("./checkpoints/checkpoints.db")
+//	if err != nil {
+//	    return err
+//	}
+//	defer store.Close()
+func NewBoltCheckpointStore(path string) (*BoltCheckpointStore, error) {
+	return NewBoltCheckpointStoreWithOptions(BoltOptions{Path: path})
+}
+
+// NewBoltCheckpointStoreWithOptions creates a checkpoint store with custom options
+func NewBoltCheckpointStoreWithOptions(opts BoltOptions) (*BoltCheckpointStore, error) {
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = time.Second
+	}
+
+	db, err := bbolt.Open(opts.Path, 0600, &bbolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{checkpointsBucket, threadsBucket, groupsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create buckets: %w", err)
+	}
+
+	return &BoltCheckpointStore{db: db}, nil
+}
+
+// Close closes the database
+func (s *BoltCheckpointStore) Close() error {
+	return s.db.Close()
+}
+
+// Save stores a checkpoint, replacing one with the same ID
+func (s *BoltCheckpointStore) Save(_ context.Context, checkpoint *store.Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		if err := removeFromIndexes(tx, []byte(checkpoint.ID)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(checkpointsBucket).Put([]byte(checkpoint.ID), data); err != nil {
+			return err
+		}
+		return addToIndexes(tx, checkpoint)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Load retrieves a checkpoint by ID
+func (s *BoltCheckpointStore) Load(_ context.Context, checkpointID string) (*store.Checkpoint, error) {
+	var checkpoint *store.Checkpoint
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		checkpoint, err = loadCheckpoint(tx, []byte(checkpointID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return nil, fmt.Errorf("checkpoint not found: %s", checkpointID)
+	}
+	return checkpoint, nil
+}
+
+// List returns the checkpoints whose execution_id, thread_id, session_id or
+// workflow_id is executionID, sorted by version
+func (s *BoltCheckpointStore) List(_ context.Context, executionID string) ([]*store.Checkpoint, error) {
+	return s.listIndex(groupsBucket, executionID)
+}
+
+// ListByThread returns all checkpoints for a specific thread_id, sorted by version
+func (s *BoltCheckpointStore) ListByThread(_ context.Context, threadID string) ([]*store.Checkpoint, error) {
+	return s.listIndex(threadsBucket, threadID)
+}
+
+// GetLatestByThread returns the checkpoint of a thread_id with the highest version,
+// the last key of the thread's bucket
+func (s *BoltCheckpointStore) GetLatestByThread(_ context.Context, threadID string) (*store.Checkpoint, error) {
+	var checkpoint *store.Checkpoint
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		index := tx.Bucket(threadsBucket).Bucket([]byte(threadID))
+		if index == nil {
+			return nil
+		}
+		key, _ := index.Cursor().Last()
+		if key == nil {
+			return nil
+		}
+		var err error
+		checkpoint, err = loadCheckpoint(tx, key[8:])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint == nil {
+		return nil, fmt.Errorf("no checkpoints found for thread: %s", threadID)
+	}
+	return checkpoint, nil
+}
+
+// Delete removes a checkpoint
+func (s *BoltCheckpointStore) Delete(_ context.Context, checkpointID string) error {
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		if err := removeFromIndexes(tx, []byte(checkpointID)); err != nil {
+			return err
+		}
+		return tx.Bucket(checkpointsBucket).Delete([]byte(checkpointID))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Clear removes the checkpoints List returns for executionID, in one transaction
+func (s *BoltCheckpointStore) Clear(_ context.Context, executionID string) error {
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		index := tx.Bucket(groupsBucket).Bucket([]byte(executionID))
+		if index == nil {
+			return nil
+		}
+		var ids [][]byte
+		err := index.ForEach(func(key, _ []byte) error {
+			ids = append(ids, bytes.Clone(key[8:]))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := removeFromIndexes(tx, id); err != nil {
+				return err
+			}
+			if err := tx.Bucket(checkpointsBucket).Delete(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear checkpoints: %w", err)
+	}
+	return nil
+}
+
+// listIndex returns the checkpoints of the bucket named name in the index bucket,
+// in key order
+func (s *BoltCheckpointStore) listIndex(bucket []byte, name string) ([]*store.Checkpoint, error) {
+	checkpoints := []*store.Checkpoint{}
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		index := tx.Bucket(bucket).Bucket([]byte(name))
+		if index == nil {
+			return nil
+		}
+		return index.ForEach(func(key, _ []byte) error {
+			checkpoint, err := loadCheckpoint(tx, key[8:])
+			if err != nil {
+				return err
+			}
+			if checkpoint != nil {
+				checkpoints = append(checkpoints, checkpoint)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+// loadCheckpoint returns the checkpoint with id, or nil if there is none
+func loadCheckpoint(tx *bbolt.Tx, id []byte) (*store.Checkpoint, error) {
+	data := tx.Bucket(checkpointsBucket).Get(id)
+	if data == nil {
+		return nil, nil
+	}
+	var checkpoint store.Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// addToIndexes adds checkpoint to the bucket of its thread and of its groups
+func addToIndexes(tx *bbolt.Tx, checkpoint *store.Checkpoint) error {
+	key := indexKey(checkpoint.Version, checkpoint.ID)
+	return forEachIndex(tx, checkpoint, true, func(index *bbolt.Bucket) error {
+		return index.Put(key, []byte{})
+	})
+}
+
+// removeFromIndexes removes the saved checkpoint with id from the index buckets,
+// dropping buckets left empty
+func removeFromIndexes(tx *bbolt.Tx, id []byte) error {
+	checkpoint, err := loadCheckpoint(tx, id)
+	if err != nil || checkpoint == nil {
+		return err
+	}
+	key := indexKey(checkpoint.Version, checkpoint.ID)
+	return forEachIndex(tx, checkpoint, false, func(index *bbolt.Bucket) error {
+		return index.Delete(key)
+	})
+}
+
+// forEachIndex calls fn with the index buckets checkpoint belongs to. Missing
+// buckets are created when create is set, and skipped otherwise; buckets that are
+// empty after fn are deleted.
+func forEachIndex(tx *bbolt.Tx, checkpoint *store.Checkpoint, create bool, fn func(index *bbolt.Bucket) error) error {
+	visit := func(parent *bbolt.Bucket, name string) error {
+		if name == "" {
+			return nil
+		}
+		index := parent.Bucket([]byte(name))
+		if index == nil {
+			if !create {
+				return nil
+			}
+			var err error
+			if index, err = parent.CreateBucket([]byte(name)); err != nil {
+				return err
+			}
+		}
+		if err := fn(index); err != nil {
+			return err
+		}
+		if key, _ := index.Cursor().First(); key == nil {
+			return parent.DeleteBucket([]byte(name))
+		}
+		return nil
+	}
+
+	threadID, _ := checkpoint.Metadata["thread_id"].(string)
+	if err := visit(tx.Bucket(threadsBucket), threadID); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(groupKeys))
+	for _, key := range groupKeys {
+		group, _ := checkpoint.Metadata[key].(string)
+		if seen[group] {
+			continue
+		}
+		seen[group] = true
+		if err := visit(tx.Bucket(groupsBucket), group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexKey returns the index key of a checkpoint: its version, big endian with the
+// sign bit flipped so that keys sort in version order, followed by its ID
+func indexKey(version int, id string) []byte {
+	key := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(int64(version))^(1<<63))
+	return append(key, id...)
+}
@@ -0,0 +1,200 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ store.CheckpointStore = (*BoltCheckpointStore)(nil)
+
+func newTestStore(t testing.TB) *BoltCheckpointStore {
+	s, err := NewBoltCheckpointStore(filepath.Join(t.TempDir(), "checkpoints.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func newCheckpoint(id string, version int, metadata map[string]any) *store.Checkpoint {
+	return &store.Checkpoint{
+		ID:        id,
+		NodeName:  "node",
+		State:     map[string]any{"version": float64(version)},
+		Metadata:  metadata,
+		Timestamp: time.Now(),
+		Version:   version,
+	}
+}
+
+func TestBoltCheckpointStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	cp := newCheckpoint("cp-1", 1, map[string]any{"execution_id": "exec-1", "thread_id": "thread-1"})
+	require.NoError(t, s.Save(ctx, cp))
+
+	loaded, err := s.Load(ctx, "cp-1")
+	require.NoError(t, err)
+	assert.Equal(t, "node", loaded.NodeName)
+	assert.Equal(t, map[string]any{"version": float64(1)}, loaded.State)
+	assert.Equal(t, "thread-1", loaded.Metadata["thread_id"])
+
+	_, err = s.Load(ctx, "missing")
+	assert.ErrorContains(t, err, "checkpoint not found: missing")
+
+	require.NoError(t, s.Delete(ctx, "cp-1"))
+	_, err = s.Load(ctx, "cp-1")
+	assert.Error(t, err)
+	list, err := s.ListByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestBoltCheckpointStore_VersionOrder(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	for _, version := range []int{3, 1, 10, 2} {
+		id := fmt.Sprintf("cp-%d", version)
+		require.NoError(t, s.Save(ctx, newCheckpoint(id, version, map[string]any{"thread_id": "thread-1"})))
+	}
+	require.NoError(t, s.Save(ctx, newCheckpoint("other", 99, map[string]any{"thread_id": "thread-2"})))
+
+	list, err := s.ListByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	var versions []int
+	for _, cp := range list {
+		versions = append(versions, cp.Version)
+	}
+	assert.Equal(t, []int{1, 2, 3, 10}, versions)
+
+	latest, err := s.GetLatestByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cp-10", latest.ID)
+
+	// Saving again under the same ID moves the checkpoint in the index
+	require.NoError(t, s.Save(ctx, newCheckpoint("cp-10", 0, map[string]any{"thread_id": "thread-1"})))
+	latest, err = s.GetLatestByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cp-3", latest.ID)
+	list, err = s.ListByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Len(t, list, 4)
+
+	_, err = s.GetLatestByThread(ctx, "missing")
+	assert.ErrorContains(t, err, "no checkpoints found for thread: missing")
+}
+
+func TestBoltCheckpointStore_ListAndClear(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+
+	require.NoError(t, s.Save(ctx, newCheckpoint("a", 1, map[string]any{"execution_id": "exec-1", "thread_id": "thread-1"})))
+	require.NoError(t, s.Save(ctx, newCheckpoint("b", 2, map[string]any{"execution_id": "exec-1", "thread_id": "thread-1"})))
+	require.NoError(t, s.Save(ctx, newCheckpoint("c", 1, map[string]any{"session_id": "session-1"})))
+
+	// Like the file store, List matches the execution, thread, session or workflow ID
+	for _, id := range []string{"exec-1", "thread-1"} {
+		list, err := s.List(ctx, id)
+		require.NoError(t, err)
+		assert.Len(t, list, 2, id)
+	}
+	list, err := s.List(ctx, "session-1")
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	require.NoError(t, s.Clear(ctx, "exec-1"))
+	list, err = s.List(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	_, err = s.Load(ctx, "c")
+	assert.NoError(t, err)
+}
+
+func TestBoltCheckpointStore_Resume(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "checkpoints.db")
+
+	run := func(config *graph.Config) map[string]any {
+		s, err := NewBoltCheckpointStore(path)
+		require.NoError(t, err)
+		defer s.Close()
+
+		g := graph.NewCheckpointableStateGraph[map[string]any]()
+		for _, name := range []string{"step1", "step2"} {
+			g.AddNode(name, name, func(ctx context.Context, state map[string]any) (map[string]any, error) {
+				state[name] = "done"
+				return state, nil
+			})
+		}
+		g.SetEntryPoint("step1")
+		g.AddEdge("step1", "step2")
+		g.AddEdge("step2", graph.END)
+		g.SetCheckpointConfig(graph.CheckpointConfig{Store: s, AutoSave: true})
+		runnable, err := g.CompileCheckpointable()
+		require.NoError(t, err)
+
+		if config.InterruptAfter != nil {
+			_, err := runnable.InvokeWithConfig(ctx, map[string]any{}, config)
+			require.Error(t, err)
+			return nil
+		}
+		res, err := runnable.InvokeCommand(ctx, &graph.Command{}, graph.WithThreadID("thread-1"))
+		require.NoError(t, err)
+		return res
+	}
+
+	// The second run reopens the database and resumes the thread
+	run(&graph.Config{Configurable: map[string]any{"thread_id": "thread-1"}, InterruptAfter: []string{"step1"}})
+	res := run(&graph.Config{})
+	assert.Equal(t, map[string]any{"step1": "done", "step2": "done"}, res)
+}
+
+// BenchmarkCheckpointStores compares the bolt and file stores holding 10k
+// checkpoints of 100 threads
+func BenchmarkCheckpointStores(b *testing.B) {
+	const threads, perThread = 100, 100
+	ctx := context.Background()
+
+	stores := map[string]func(b *testing.B) store.CheckpointStore{
+		"bolt": func(b *testing.B) store.CheckpointStore { return newTestStore(b) },
+		"file": func(b *testing.B) store.CheckpointStore {
+			s, err := file.NewFileCheckpointStore(b.TempDir())
+			require.NoError(b, err)
+			return s
+		},
+	}
+
+	for _, name := range []string{"bolt", "file"} {
+		s := stores[name](b)
+		for i := range threads * perThread {
+			thread := fmt.Sprintf("thread-%d", i%threads)
+			metadata := map[string]any{"thread_id": thread, "execution_id": thread}
+			require.NoError(b, s.Save(ctx, newCheckpoint(fmt.Sprintf("cp-%d", i), i/threads, metadata)))
+		}
+
+		b.Run(name+"/GetLatestByThread", func(b *testing.B) {
+			for i := 0; b.Loop(); i++ {
+				if _, err := s.GetLatestByThread(ctx, fmt.Sprintf("thread-%d", i%threads)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/List", func(b *testing.B) {
+			for i := 0; b.Loop(); i++ {
+				list, err := s.List(ctx, fmt.Sprintf("thread-%d", i%threads))
+				if err != nil || len(list) != perThread {
+					b.Fatal(err, len(list))
+				}
+			}
+		})
+	}
+}
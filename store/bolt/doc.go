@@ -0,0 +1,46 @@
+// Package bolt provides embedded bbolt-backed storage for LangGraph Go checkpoints.
+//
+// The file store writes a JSON file per checkpoint, and listing checkpoints reads
+// every file in its directory. This store keeps checkpoints in a single bbolt
+// database file and indexes them by thread and by execution, so lookups read only
+// the checkpoints they return.
+//
+// # Layout
+//
+// The database has three buckets:
+//
+//   - checkpoints maps checkpoint IDs to checkpoint JSON
+//   - threads has a bucket per thread_id
+//   - groups has a bucket per execution_id, thread_id, session_id and workflow_id,
+//     the IDs List matches like the file store does
+//
+// Keys in the thread and group buckets are the checkpoint version followed by its
+// ID, so they iterate in version order. GetLatestByThread seeks to the last key of
+// the thread's bucket, and List and ListByThread scan a single bucket.
+//
+// # Basic Usage
+//
+//	import (
+//		"github.com/smallnest/langgraphgo/graph"
+//		"github.com/smallnest/langgraphgo/store/bolt"
+//	)
+//
+//	// A drop-in replacement for graph.NewFileCheckpointStore(dir)
+//	store, err := bolt.NewBoltCheckpointStore(filepath.Join(dir, "checkpoints.db"))
+//	if err != nil {
+//		return err
+//	}
+//	defer store.Close()
+//
+//	g.SetCheckpointConfig(graph.CheckpointConfig{
+//		Store:    store,
+//		AutoSave: true,
+//	})
+//
+// # Concurrency
+//
+// bbolt allows a single process to open a database, and other processes wait for
+// its file lock for BoltOptions.Timeout before failing. Within a process the store
+// is safe for concurrent use: reads run in parallel, and every Save, Delete and
+// Clear is one transaction.
+package bolt
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"slices"
 	"time"
 )
 
@@ -40,3 +41,57 @@ type CheckpointStore interface {
 	// Clear removes all checkpoints for an execution
 	Clear(ctx context.Context, executionID string) error
 }
+
+// ListOptions selects a page of the checkpoints List returns
+type ListOptions struct {
+	// Offset is the number of checkpoints to skip
+	Offset int
+
+	// Limit is the maximum number of checkpoints to return, 0 for no limit
+	Limit int
+
+	// Descending orders the checkpoints by version from the latest, so that
+	// Limit: 1 returns only the latest checkpoint
+	Descending bool
+}
+
+// Page returns the page of checkpoints, sorted by version ascending, the options
+// select. The input slice is not modified.
+func (o ListOptions) Page(checkpoints []*Checkpoint) []*Checkpoint {
+	page := slices.Clone(checkpoints)
+	if o.Descending {
+		slices.Reverse(page)
+	}
+	page = page[min(max(o.Offset, 0), len(page)):]
+	if o.Limit > 0 && o.Limit < len(page) {
+		page = page[:o.Limit]
+	}
+	return page
+}
+
+// PageLister is implemented by stores that can fetch a page of an execution's
+// checkpoints without loading the others, see ListPage.
+type PageLister interface {
+	// ListPage returns the page of the checkpoints List returns for executionID
+	// that opts selects
+	ListPage(ctx context.Context, executionID string, opts ListOptions) ([]*Checkpoint, error)
+}
+
+// ListPage returns a page of the checkpoints of an execution. Stores implementing
+// PageLister fetch only the page; for others every checkpoint is listed and the
+// page is taken from the result.
+//
+// Example:
+//
+//	// The latest checkpoint of thread-1
+//	page, err := store.ListPage(ctx, checkpoints, "thread-1", store.ListOptions{Limit: 1, Descending: true})
+func ListPage(ctx context.Context, s CheckpointStore, executionID string, opts ListOptions) ([]*Checkpoint, error) {
+	if lister, ok := s.(PageLister); ok {
+		return lister.ListPage(ctx, executionID, opts)
+	}
+	checkpoints, err := s.List(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	return opts.Page(checkpoints), nil
+}
@@ -0,0 +1,196 @@
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/file"
+	"github.com/smallnest/langgraphgo/store/memory"
+	"github.com/smallnest/langgraphgo/store/redis"
+)
+
+// testStores are the stores the shared store tests run against
+func testStores() map[string]func(t *testing.T) store.CheckpointStore {
+	return map[string]func(t *testing.T) store.CheckpointStore{
+		"Memory": func(t *testing.T) store.CheckpointStore {
+			return memory.NewMemoryCheckpointStore()
+		},
+		"File": func(t *testing.T) store.CheckpointStore {
+			s, err := file.NewFileCheckpointStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("Failed to create file store: %v", err)
+			}
+			return s
+		},
+		"FileThreads": func(t *testing.T) store.CheckpointStore {
+			s, err := file.NewFileCheckpointStoreWithOptions(file.FileOptions{Path: t.TempDir(), Layout: file.LayoutThreads})
+			if err != nil {
+				t.Fatalf("Failed to create file store: %v", err)
+			}
+			return s
+		},
+		"Redis": func(t *testing.T) store.CheckpointStore {
+			mr := miniredis.RunT(t)
+			return redis.NewRedisCheckpointStore(redis.RedisOptions{Addr: mr.Addr()})
+		},
+		"RedisCluster": func(t *testing.T) store.CheckpointStore {
+			mr := miniredis.RunT(t)
+			client := goredis.NewClusterClient(&goredis.ClusterOptions{Addrs: []string{mr.Addr()}})
+			t.Cleanup(func() { client.Close() })
+			return redis.NewRedisCheckpointStoreWithClient(client, redis.RedisOptions{})
+		},
+		"RedisClusterHashTag": func(t *testing.T) store.CheckpointStore {
+			mr := miniredis.RunT(t)
+			client := goredis.NewClusterClient(&goredis.ClusterOptions{Addrs: []string{mr.Addr()}})
+			t.Cleanup(func() { client.Close() })
+			return redis.NewRedisCheckpointStoreWithClient(client, redis.RedisOptions{Prefix: "{langgraph}:"})
+		},
+	}
+}
+
+func saveThread(t *testing.T, s store.CheckpointStore, threadID string, versions int, timestamp func(v int) time.Time) {
+	t.Helper()
+	for v := 1; v <= versions; v++ {
+		cp := &store.Checkpoint{
+			ID:        fmt.Sprintf("%s-cp-%d", threadID, v),
+			NodeName:  fmt.Sprintf("node-%d", v),
+			State:     map[string]any{"step": v},
+			Metadata:  map[string]any{"thread_id": threadID, "execution_id": threadID},
+			Timestamp: timestamp(v),
+			Version:   v,
+		}
+		if err := s.Save(context.Background(), cp); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+}
+
+// listOnly hides the optional interfaces, such as PageLister, of the store it wraps
+type listOnly struct {
+	store.CheckpointStore
+}
+
+func TestListPage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts store.ListOptions
+		want []int
+	}{
+		{"All", store.ListOptions{}, []int{1, 2, 3, 4, 5}},
+		{"Limit", store.ListOptions{Limit: 2}, []int{1, 2}},
+		{"Offset", store.ListOptions{Offset: 3}, []int{4, 5}},
+		{"OffsetAndLimit", store.ListOptions{Offset: 1, Limit: 3}, []int{2, 3, 4}},
+		{"Latest", store.ListOptions{Limit: 1, Descending: true}, []int{5}},
+		{"DescendingPage", store.ListOptions{Offset: 2, Limit: 2, Descending: true}, []int{3, 2}},
+		{"OffsetPastEnd", store.ListOptions{Offset: 10}, []int{}},
+	}
+
+	for name, newStore := range testStores() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			s := newStore(t)
+			if _, ok := s.(store.PageLister); !ok {
+				t.Fatalf("%T does not implement store.PageLister", s)
+			}
+			saveThread(t, s, "thread", 5, func(int) time.Time { return time.Now() })
+
+			for _, tt := range tests {
+				for _, lister := range []store.CheckpointStore{s, listOnly{s}} {
+					page, err := store.ListPage(ctx, lister, "thread", tt.opts)
+					if err != nil {
+						t.Fatalf("ListPage failed: %v", err)
+					}
+					versions := make([]int, len(page))
+					for i, cp := range page {
+						versions[i] = cp.Version
+					}
+					if fmt.Sprint(versions) != fmt.Sprint(tt.want) {
+						t.Errorf("%s (%T): expected versions %v, got %v", tt.name, lister, tt.want, versions)
+					}
+				}
+			}
+
+			all, err := s.List(ctx, "thread")
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(all) != 5 {
+				t.Errorf("Expected List to return all 5 checkpoints, got %d", len(all))
+			}
+		})
+	}
+}
+
+func TestGetLatestByThread(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range testStores() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			s := newStore(t)
+
+			if _, err := s.GetLatestByThread(ctx, "thread"); err == nil {
+				t.Error("Expected an error for a thread without checkpoints")
+			}
+
+			save := func(id, threadID string, version int) {
+				t.Helper()
+				cp := &store.Checkpoint{
+					ID:        id,
+					State:     map[string]any{"version": version},
+					Metadata:  map[string]any{"thread_id": threadID, "execution_id": threadID},
+					Timestamp: time.Now(),
+					Version:   version,
+				}
+				if err := s.Save(ctx, cp); err != nil {
+					t.Fatalf("Save failed: %v", err)
+				}
+			}
+			expectLatest := func(want string) {
+				t.Helper()
+				latest, err := s.GetLatestByThread(ctx, "thread")
+				if err != nil {
+					t.Fatalf("GetLatestByThread failed: %v", err)
+				}
+				if latest.ID != want {
+					t.Errorf("Expected latest checkpoint %s, got %s (version %d)", want, latest.ID, latest.Version)
+				}
+			}
+
+			// Highest version wins, whatever the save order
+			save("cp-3", "thread", 3)
+			save("cp-1", "thread", 1)
+			save("cp-2", "thread", 2)
+			save("other-9", "other", 9)
+			expectLatest("cp-3")
+
+			if err := s.Delete(ctx, "cp-3"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			expectLatest("cp-2")
+
+			save("cp-4", "thread", 4)
+			expectLatest("cp-4")
+
+			for _, id := range []string{"cp-1", "cp-2", "cp-4"} {
+				if err := s.Delete(ctx, id); err != nil {
+					t.Fatalf("Delete failed: %v", err)
+				}
+			}
+			if _, err := s.GetLatestByThread(ctx, "thread"); err == nil {
+				t.Error("Expected an error once every checkpoint of the thread is deleted")
+			}
+		})
+	}
+}
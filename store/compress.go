@@ -0,0 +1,257 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+)
+
+// codecMetadataKey is the checkpoint metadata naming the codec of a compressed state
+const codecMetadataKey = "state_codec"
+
+// Codec compresses the serialized state of checkpoints, see NewCompressedStore.
+type Codec interface {
+	// Name identifies the codec in the metadata of the checkpoints it compressed
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// Gzip is the gzip Codec, at the default compression level.
+var Gzip Codec = gzipCodec{}
+
+// codecs are the codecs checkpoints are decompressed with when their codec is not
+// the store's
+var codecs = map[string]Codec{Gzip.Name(): Gzip}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return "gzip" }
+
+func (gzipCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// CompressedStore is a CheckpointStore that compresses the state of checkpoints
+// before saving them in another store.
+type CompressedStore struct {
+	inner CheckpointStore
+	codec Codec
+}
+
+// NewCompressedStore returns a store that saves checkpoints in inner with their
+// state serialized to JSON and compressed by codec, and decompresses them again
+// when they are loaded or listed. The codec is recorded in the "state_codec"
+// metadata of each checkpoint, so checkpoints saved before compression was turned
+// on, or with another built-in codec, still load.
+//
+// Decompressed states are decoded from JSON like the states of the file and
+// database stores: map states come back as map[string]any.
+//
+// Example:
+//
+//	inner, err := file.NewFileCheckpointStore("./checkpoints")
+//	if err != nil {
+//	    return err
+//	}
+//	checkpoints := store.NewCompressedStore(inner, store.Gzip)
+func NewCompressedStore(inner CheckpointStore, codec Codec) *CompressedStore {
+	return &CompressedStore{inner: inner, codec: codec}
+}
+
+// Save compresses the state of checkpoint and saves it in the inner store. The
+// checkpoint itself is not modified.
+func (s *CompressedStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	data, err := json.Marshal(checkpoint.State)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	compressed, err := s.codec.Compress(data)
+	if err != nil {
+		return fmt.Errorf("failed to compress state: %w", err)
+	}
+
+	saved := *checkpoint
+	saved.State = compressed
+	saved.Metadata = maps.Clone(checkpoint.Metadata)
+	if saved.Metadata == nil {
+		saved.Metadata = make(map[string]any)
+	}
+	saved.Metadata[codecMetadataKey] = s.codec.Name()
+	return s.inner.Save(ctx, &saved)
+}
+
+// Load loads a checkpoint from the inner store and decompresses its state
+func (s *CompressedStore) Load(ctx context.Context, checkpointID string) (*Checkpoint, error) {
+	checkpoint, err := s.inner.Load(ctx, checkpointID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decompress(checkpoint)
+}
+
+// List returns the checkpoints of an execution with their states decompressed
+func (s *CompressedStore) List(ctx context.Context, executionID string) ([]*Checkpoint, error) {
+	checkpoints, err := s.inner.List(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decompressAll(checkpoints)
+}
+
+// ListPage returns a page of the checkpoints of an execution with their states
+// decompressed, see the package-level ListPage
+func (s *CompressedStore) ListPage(ctx context.Context, executionID string, opts ListOptions) ([]*Checkpoint, error) {
+	checkpoints, err := ListPage(ctx, s.inner, executionID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.decompressAll(checkpoints)
+}
+
+// ListByThread returns the checkpoints of a thread with their states decompressed
+func (s *CompressedStore) ListByThread(ctx context.Context, threadID string) ([]*Checkpoint, error) {
+	checkpoints, err := s.inner.ListByThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decompressAll(checkpoints)
+}
+
+// GetLatestByThread returns the latest checkpoint of a thread with its state decompressed
+func (s *CompressedStore) GetLatestByThread(ctx context.Context, threadID string) (*Checkpoint, error) {
+	checkpoint, err := s.inner.GetLatestByThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.decompress(checkpoint)
+}
+
+// Delete removes a checkpoint from the inner store
+func (s *CompressedStore) Delete(ctx context.Context, checkpointID string) error {
+	return s.inner.Delete(ctx, checkpointID)
+}
+
+// Clear removes all checkpoints for an execution from the inner store
+func (s *CompressedStore) Clear(ctx context.Context, executionID string) error {
+	return s.inner.Clear(ctx, executionID)
+}
+
+// ListByMetadata returns the checkpoints of the inner store matching filters with
+// their states decompressed, see the package-level ListByMetadata
+func (s *CompressedStore) ListByMetadata(ctx context.Context, filters map[string]any, opts ListOptions) ([]*Checkpoint, error) {
+	checkpoints, err := ListByMetadata(ctx, s.inner, filters, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.decompressAll(checkpoints)
+}
+
+// ListThreads returns the threads of the inner store, see the package-level ListThreads
+func (s *CompressedStore) ListThreads(ctx context.Context, opts ListThreadsOptions) ([]ThreadInfo, error) {
+	return ListThreads(ctx, s.inner, opts)
+}
+
+// DeleteThread removes every checkpoint of a thread from the inner store
+func (s *CompressedStore) DeleteThread(ctx context.Context, threadID string) error {
+	return DeleteThread(ctx, s.inner, threadID)
+}
+
+// Ping checks that the inner store can reach its backend, see the package-level Ping
+func (s *CompressedStore) Ping(ctx context.Context) error {
+	return Ping(ctx, s.inner)
+}
+
+// SaveWrite saves a pending write in the inner store uncompressed, see the
+// package-level SaveWrite
+func (s *CompressedStore) SaveWrite(ctx context.Context, write *PendingWrite) error {
+	return SaveWrite(ctx, s.inner, write)
+}
+
+// ListWrites returns the pending writes of a checkpoint from the inner store
+func (s *CompressedStore) ListWrites(ctx context.Context, checkpointID string) ([]*PendingWrite, error) {
+	return ListWrites(ctx, s.inner, checkpointID)
+}
+
+// DeleteWrites removes the pending writes of a checkpoint from the inner store
+func (s *CompressedStore) DeleteWrites(ctx context.Context, checkpointID string) error {
+	return DeleteWrites(ctx, s.inner, checkpointID)
+}
+
+func (s *CompressedStore) decompressAll(checkpoints []*Checkpoint) ([]*Checkpoint, error) {
+	result := make([]*Checkpoint, len(checkpoints))
+	for i, checkpoint := range checkpoints {
+		decompressed, err := s.decompress(checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = decompressed
+	}
+	return result, nil
+}
+
+// decompress returns a copy of checkpoint with its state decompressed and the codec
+// metadata removed. Checkpoints without a codec are returned as they are.
+func (s *CompressedStore) decompress(checkpoint *Checkpoint) (*Checkpoint, error) {
+	name, ok := checkpoint.Metadata[codecMetadataKey].(string)
+	if !ok {
+		return checkpoint, nil
+	}
+	codec := s.codec
+	if name != codec.Name() {
+		if codec, ok = codecs[name]; !ok {
+			return nil, fmt.Errorf("checkpoint %s: unknown state codec %q", checkpoint.ID, name)
+		}
+	}
+
+	var compressed []byte
+	switch state := checkpoint.State.(type) {
+	case []byte:
+		compressed = state
+	case string:
+		// Stores that serialize checkpoints to JSON save the bytes as base64
+		var err error
+		if compressed, err = base64.StdEncoding.DecodeString(state); err != nil {
+			return nil, fmt.Errorf("checkpoint %s: failed to decode compressed state: %w", checkpoint.ID, err)
+		}
+	default:
+		return nil, fmt.Errorf("checkpoint %s: compressed state has type %T", checkpoint.ID, checkpoint.State)
+	}
+
+	data, err := codec.Decompress(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint %s: failed to decompress state: %w", checkpoint.ID, err)
+	}
+	var state any
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: failed to unmarshal state: %w", checkpoint.ID, err)
+	}
+
+	loaded := *checkpoint
+	loaded.State = state
+	loaded.Metadata = maps.Clone(checkpoint.Metadata)
+	delete(loaded.Metadata, codecMetadataKey)
+	return &loaded, nil
+}
@@ -0,0 +1,148 @@
+package store_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/file"
+	"github.com/smallnest/langgraphgo/store/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestCompressedStore(t *testing.T) {
+	t.Parallel()
+
+	stores := map[string]func(t *testing.T) store.CheckpointStore{
+		"Memory": func(t *testing.T) store.CheckpointStore {
+			return memory.NewMemoryCheckpointStore()
+		},
+		"File": func(t *testing.T) store.CheckpointStore {
+			s, err := file.NewFileCheckpointStore(t.TempDir())
+			require.NoError(t, err)
+			return s
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			inner := newStore(t)
+			s := store.NewCompressedStore(inner, store.Gzip)
+
+			// A checkpoint saved before compression was turned on
+			require.NoError(t, inner.Save(ctx, &store.Checkpoint{
+				ID:       "old",
+				State:    map[string]any{"step": "one"},
+				Metadata: map[string]any{"thread_id": "thread-1"},
+				Version:  1,
+			}))
+
+			cp := &store.Checkpoint{
+				ID:        "new",
+				State:     map[string]any{"step": "two", "messages": []any{"hi", "there"}},
+				Metadata:  map[string]any{"thread_id": "thread-1"},
+				Timestamp: time.Now(),
+				Version:   2,
+			}
+			require.NoError(t, s.Save(ctx, cp))
+			assert.Equal(t, map[string]any{"thread_id": "thread-1"}, cp.Metadata, "Save doesn't modify the checkpoint")
+
+			raw, err := inner.Load(ctx, "new")
+			require.NoError(t, err)
+			assert.Equal(t, "gzip", raw.Metadata["state_codec"])
+
+			loaded, err := s.Load(ctx, "new")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]any{"step": "two", "messages": []any{"hi", "there"}}, loaded.State)
+			assert.Equal(t, map[string]any{"thread_id": "thread-1"}, loaded.Metadata)
+
+			list, err := s.ListByThread(ctx, "thread-1")
+			require.NoError(t, err)
+			require.Len(t, list, 2)
+			assert.Equal(t, map[string]any{"step": "one"}, list[0].State)
+			assert.Equal(t, "two", list[1].State.(map[string]any)["step"])
+
+			list, err = s.List(ctx, "thread-1")
+			require.NoError(t, err)
+			assert.Len(t, list, 2)
+
+			latest, err := s.GetLatestByThread(ctx, "thread-1")
+			require.NoError(t, err)
+			assert.Equal(t, "two", latest.State.(map[string]any)["step"])
+
+			require.NoError(t, s.Delete(ctx, "old"))
+			list, err = s.ListByThread(ctx, "thread-1")
+			require.NoError(t, err)
+			assert.Len(t, list, 1)
+		})
+	}
+}
+
+func TestCompressedStore_UnknownCodec(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	inner := memory.NewMemoryCheckpointStore()
+	require.NoError(t, inner.Save(ctx, &store.Checkpoint{
+		ID:       "cp",
+		State:    []byte("data"),
+		Metadata: map[string]any{"state_codec": "zstd"},
+	}))
+
+	_, err := store.NewCompressedStore(inner, store.Gzip).Load(ctx, "cp")
+	assert.ErrorContains(t, err, `checkpoint cp: unknown state codec "zstd"`)
+}
+
+// newConversation returns the state of an agent after a 50 message conversation
+func newConversation() map[string]any {
+	messages := make([]llms.MessageContent, 0, 50)
+	for i := range 50 {
+		role, text := llms.ChatMessageTypeHuman, fmt.Sprintf("Question %d: what's in the quarterly report for the EMEA region? ", i)
+		if i%2 == 1 {
+			role, text = llms.ChatMessageTypeAI, fmt.Sprintf("Answer %d: the report shows revenue grew in most markets. ", i)
+		}
+		messages = append(messages, llms.TextParts(role, strings.Repeat(text, 20)))
+	}
+	return map[string]any{"messages": messages}
+}
+
+// BenchmarkCompressedStore saves a 50 message state and reports the size of the
+// saved checkpoint with and without compression
+func BenchmarkCompressedStore(b *testing.B) {
+	ctx := context.Background()
+	state := newConversation()
+
+	for _, compress := range []bool{false, true} {
+		name := "uncompressed"
+		if compress {
+			name = "gzip"
+		}
+		b.Run(name, func(b *testing.B) {
+			inner := memory.NewMemoryCheckpointStore()
+			var s store.CheckpointStore = inner
+			if compress {
+				s = store.NewCompressedStore(inner, store.Gzip)
+			}
+
+			for i := 0; b.Loop(); i++ {
+				cp := &store.Checkpoint{ID: fmt.Sprintf("cp-%d", i), State: state, Version: i}
+				if err := s.Save(ctx, cp); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			saved, err := inner.Load(ctx, "cp-0")
+			require.NoError(b, err)
+			data, err := json.Marshal(saved)
+			require.NoError(b, err)
+			b.ReportMetric(float64(len(data)), "bytes/checkpoint")
+		})
+	}
+}
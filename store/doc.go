@@ -24,25 +24,40 @@
 //
 // ## Store Interface
 //
-// All store implementations follow the same interface defined in the graph package:
+// All store implementations follow the CheckpointStore interface of this package.
+// The graph package declares its Checkpoint, CheckpointStore and option types as
+// aliases of these, so code written against either package works with every
+// store and with graph.CheckpointConfig:
 //
 //	type CheckpointStore interface {
-//	    // Save a checkpoint
-//	    Put(ctx context.Context, checkpoint *Checkpoint) error
+//	    // Save stores a checkpoint
+//	    Save(ctx context.Context, checkpoint *Checkpoint) error
 //
-//	    // Retrieve a specific checkpoint
-//	    Get(ctx context.Context, threadID, checkpointID string) (*Checkpoint, error)
+//	    // Load retrieves a checkpoint by ID
+//	    Load(ctx context.Context, checkpointID string) (*Checkpoint, error)
 //
-//	    // List all checkpoints for a thread
-//	    List(ctx context.Context, threadID string) ([]*Checkpoint, error)
+//	    // List returns all checkpoints for an execution, sorted by version
+//	    List(ctx context.Context, executionID string) ([]*Checkpoint, error)
 //
-//	    // Delete a checkpoint
-//	    Delete(ctx context.Context, threadID, checkpointID string) error
+//	    // ListByThread returns all checkpoints for a thread, sorted by version
+//	    ListByThread(ctx context.Context, threadID string) ([]*Checkpoint, error)
 //
-//	    // Clear all checkpoints for a thread
-//	    Clear(ctx context.Context, threadID string) error
+//	    // GetLatestByThread returns the checkpoint of a thread with the highest
+//	    // version, or an error when the thread has none
+//	    GetLatestByThread(ctx context.Context, threadID string) (*Checkpoint, error)
+//
+//	    // Delete removes a checkpoint
+//	    Delete(ctx context.Context, checkpointID string) error
+//
+//	    // Clear removes all checkpoints for an execution
+//	    Clear(ctx context.Context, executionID string) error
 //	}
 //
+// Every store answers GetLatestByThread without listing the thread: the memory
+// store keeps a pointer to the latest checkpoint of each thread, the file store
+// keeps each thread's checkpoints sorted by version in an index file, and the
+// other stores look it up in their own indexes.
+//
 // # Available Implementations
 //
 // ## SQLite Store (store/sqlite)
@@ -186,14 +201,18 @@
 //
 // ## Migration Between Stores
 //
-// The package provides utilities to migrate between different store implementations:
+// Export writes the checkpoints of a thread to a portable JSON Lines archive, and
+// Import saves them in another store, for example to reproduce a production thread
+// locally:
+//
+//	// In production
+//	err := store.Export(ctx, redisStore, "thread-id", archive)
 //
-//	// Migrate from SQLite to PostgreSQL
-//	migrator := store.NewMigrator(sqliteStore, postgresStore)
-//	err := migrator.MigrateAll(ctx)
+//	// Locally
+//	err := store.Import(ctx, fileStore, archive)
 //
-//	// Or migrate specific checkpoints
-//	err := migrator.MigrateThread(ctx, "thread-id")
+// The archive keeps the IDs, versions and metadata of the checkpoints, and names
+// the serializer and codec their states were encoded with.
 //
 // # Performance Considerations
 //
@@ -204,6 +223,16 @@
 //   - Avoid storing large binary data in checkpoints
 //   - Consider compression for large state objects
 //
+// ## Pagination
+//
+// List returns every checkpoint of an execution. ListPage fetches a page of them,
+// which the memory, file and Redis stores do without loading the rest:
+//
+//	latest, err := store.ListPage(ctx, checkpoints, threadID, store.ListOptions{
+//	    Limit:      1,
+//	    Descending: true,
+//	})
+//
 // ## Batch Operations
 //
 // Some stores support batch operations for better performance:
@@ -277,10 +306,82 @@
 //
 // ## Checkpoint Compression
 //
-// For large state objects, consider compression:
+// For large state objects, such as long message histories, wrap any store to
+// gzip the serialized state. Checkpoints saved without compression still load:
+//
+//	compressedStore := store.NewCompressedStore(inner, store.Gzip)
+//	err := compressedStore.Save(ctx, checkpoint)
+//
+// ## Checkpoint Retention
+//
+// A RetentionPolicy keeps the most recent checkpoints of each thread, or those
+// younger than a maximum age. Set it on the CheckpointConfig to prune after every
+// automatic save, or prune threads offline. The latest checkpoint of a thread is
+// never deleted:
+//
+//	policy := store.RetentionPolicy{MaxPerThread: 20, MaxAge: 7 * 24 * time.Hour}
+//	deleted, err := store.Prune(ctx, checkpoints, policy, "thread-1", "thread-2")
+//
+// ## Metadata Queries
+//
+// Checkpoints can be found by their metadata across threads. Set
+// CheckpointConfig.Metadata to tag every automatically saved checkpoint, then:
+//
+//	latest, err := store.ListByMetadata(ctx, checkpoints,
+//	    map[string]any{"user_id": 42, "graph_name": "onboarding"},
+//	    store.ListOptions{Limit: 1, Descending: true})
+//
+// Redis keeps a secondary index per metadata value, SQLite and PostgreSQL filter
+// in SQL, and the memory and file stores scan every checkpoint.
+//
+// ## Thread Management
+//
+// The memory, file and Redis stores implement ThreadStore, which lists the threads
+// of a store and deletes a thread, such as a conversation a user asked to erase,
+// with all of its checkpoints:
+//
+//	threads, err := store.ListThreads(ctx, checkpoints, store.ListThreadsOptions{Limit: 50})
+//	err = store.DeleteThread(ctx, checkpoints, "thread-1")
+//
+// ## Checkpoint Namespaces
+//
+// A checkpointed graph invoked by a node of another checkpointed graph, with the
+// node's context and config, saves its checkpoints on the same thread under the
+// namespace of that node, such as "root/executor". Each graph resumes from the
+// latest checkpoint of its own namespace:
+//
+//	nested, err := store.ListByNamespace(ctx, checkpoints, "thread-1", "root/executor")
+//	latest, err := store.GetLatestByNamespace(ctx, checkpoints, "thread-1", store.RootNamespace)
+//
+// ## Pending Writes
+//
+// Stores implementing WriteStore, such as the memory and file stores, also keep
+// the results of the nodes of a parallel step as they complete, keyed to the
+// checkpoint the step started from (see graph.CheckpointConfig.SaveWrites).
+// Resuming from that checkpoint runs only the nodes without a write. Deleting a
+// checkpoint deletes its writes:
+//
+//	writes, err := store.ListWrites(ctx, checkpoints, checkpoint.ID)
+//
+// ## Metrics
+//
+// NewInstrumentedStore wraps any store to report the duration and error of
+// every operation to a MetricsRecorder, such as the Prometheus recorder of the
+// store/prometheus package:
+//
+//	checkpoints := store.NewInstrumentedStore(inner, recorder)
+//
+// ## Health Checks
+//
+// The Redis, file and SQLite stores implement Pinger. Ping checks that a store can
+// reach its backend, and CompileCheckpointable calls it when AutoSave is enabled:
+//
+//	if err := store.Ping(ctx, checkpoints); err != nil {
+//	    return err // for example "redis checkpoint store at localhost:6379 is unreachable: ..."
+//	}
 //
-//	compressedStore := store.NewCompressedWrapper(store, gzip.BestCompression)
-//	err := compressedStore.Put(ctx, checkpoint)
+// Stores that own connections, such as the Redis, SQLite, PostgreSQL and bolt
+// stores, have a Close method that releases them.
 //
 // ## Checkpoint Encryption
 //
@@ -1,2 +1,37 @@
 // Package file provides file-based checkpoint storage implementation.
+//
+// Each checkpoint is stored as <id>.json in the store directory. Index files in
+// by_thread and by_execution list the checkpoints of each thread and execution
+// by version, so List, ListByThread and GetLatestByThread read only the files
+// they return. Files are written to a temporary file and renamed into place, so
+// a crash never leaves a torn checkpoint; checkpoint files that are corrupt
+// anyway are skipped when listing, and a corrupt index is rebuilt from the
+// checkpoint files.
+//
+// # Layout
+//
+// By default every checkpoint file is in the store directory. With LayoutThreads
+// the checkpoints of a thread go to threads/<thread_id>/<version>-<id>.json, which
+// keeps directories small with many threads and lists a thread's history in
+// order:
+//
+//	store, err := file.NewFileCheckpointStoreWithOptions(file.FileOptions{
+//	    Path:    "./checkpoints",
+//	    Layout:  file.LayoutThreads,
+//	    Migrate: true, // move existing flat files into thread directories
+//	})
+//
+// Either layout reads checkpoint files of the other, so Migrate can be left off
+// to move only checkpoints saved from then on.
+//
+// # Expiry
+//
+// With a TTL, checkpoints expire that long after their Timestamp and are no
+// longer returned. Their files are removed when a List or ListByThread reads them
+// and, for the rest, the next time the store is opened:
+//
+//	store, err := file.NewFileCheckpointStoreWithOptions(file.FileOptions{
+//	    Path: "./checkpoints",
+//	    TTL:  24 * time.Hour,
+//	})
 package file
@@ -4,41 +4,203 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/smallnest/langgraphgo/store"
 )
 
-// FileCheckpointStore provides file-based checkpoint storage
+const (
+	// threadIndexDir holds an index file per thread_id
+	threadIndexDir = "by_thread"
+
+	// executionIndexDir holds an index file per execution_id, thread_id,
+	// session_id and workflow_id, which List looks checkpoints up by
+	executionIndexDir = "by_execution"
+
+	// threadsDir holds a directory per thread_id in LayoutThreads
+	threadsDir = "threads"
+
+	// writesDir holds a directory per checkpoint with pending writes, with a file
+	// per node
+	writesDir = "writes"
+
+	// indexVersionFile records the format of the index files. Indexes written in
+	// another format are rebuilt when the store is opened.
+	indexVersionFile = ".index_version"
+	indexVersion     = "2"
+)
+
+// Layout selects where FileCheckpointStore puts checkpoint files
+type Layout int
+
+const (
+	// LayoutFlat stores every checkpoint as <path>/<id>.json
+	LayoutFlat Layout = iota
+
+	// LayoutThreads stores the checkpoints of a thread as
+	// <path>/threads/<thread_id>/<version>-<id>.json, with the thread ID path
+	// escaped. Checkpoints without a thread_id stay in <path>.
+	LayoutThreads
+)
+
+// FileOptions configuration for the file store
+type FileOptions struct {
+	Path   string
+	Layout Layout // Where new checkpoint files go, default LayoutFlat
+
+	// Migrate moves the checkpoint files found in another layout to Layout when the
+	// store is opened. Without it they stay where they are and are still read.
+	Migrate bool
+
+	// TTL expires checkpoints that long after their Timestamp, default 0 (no
+	// expiration). Expired checkpoints are never returned. Their files are removed
+	// when the store is opened and when a List or ListByThread reads them.
+	TTL time.Duration
+
+	// Now returns the current time, time.Now if nil. Tests set it to a fake clock.
+	Now func() time.Time
+}
+
+// FileCheckpointStore provides file-based checkpoint storage. Every file is
+// written to a temporary file and renamed into place, so a crash never leaves a
+// partially written checkpoint or index behind.
 type FileCheckpointStore struct {
-	path  string
-	mutex sync.RWMutex
+	path   string
+	layout Layout
+	mutex  sync.RWMutex
+	ttl    time.Duration
+	now    func() time.Time
+
+	// threaded maps the IDs of checkpoints stored in thread directories to their
+	// files; the others are found in path
+	threaded map[string]string
+}
+
+// checkpointIndex lists the checkpoints of a thread or execution by version
+type checkpointIndex struct {
+	ID          string       `json:"id"`
+	Checkpoints []indexEntry `json:"checkpoints"`
 }
 
-// threadIndex represents the in-memory index for thread_id -> checkpoint IDs
-type threadIndex struct {
-	Threads map[string][]string // thread_id -> []checkpoint IDs
+// indexEntry identifies a checkpoint in an index
+type indexEntry struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
 }
 
-// NewFileCheckpointStore creates a new file-based checkpoint store
+// put adds the checkpoint to the index, replacing an entry with the same ID, and
+// keeps the entries sorted by version
+func (idx *checkpointIndex) put(id string, version int) {
+	idx.remove(id)
+	i := sort.Search(len(idx.Checkpoints), func(i int) bool {
+		return idx.Checkpoints[i].Version > version
+	})
+	idx.Checkpoints = slices.Insert(idx.Checkpoints, i, indexEntry{ID: id, Version: version})
+}
+
+// remove removes the checkpoint from the index
+func (idx *checkpointIndex) remove(id string) {
+	idx.Checkpoints = slices.DeleteFunc(idx.Checkpoints, func(e indexEntry) bool {
+		return e.ID == id
+	})
+}
+
+// NewFileCheckpointStore creates a new file-based checkpoint store in the flat
+// layout. Temporary files left by interrupted writes are removed, and indexes
+// written by earlier versions are rebuilt from the checkpoint files.
 func NewFileCheckpointStore(path string) (store.CheckpointStore, error) {
+	return NewFileCheckpointStoreWithOptions(FileOptions{Path: path})
+}
+
+// NewFileCheckpointStoreWithOptions creates a file-based checkpoint store with
+// custom options.
+//
+// Example:
+//
+//	// Keep each thread in its own directory, moving existing flat files there
+//	store, err := file.NewFileCheckpointStoreWithOptions(file.FileOptions{
+//	    Path:    "./checkpoints",
+//	    Layout:  file.LayoutThreads,
+//	    Migrate: true,
+//	})
+//
+//	// Remove checkpoints a day after they were saved
+//	store, err := file.NewFileCheckpointStoreWithOptions(file.FileOptions{
+//	    Path: "./checkpoints",
+//	    TTL:  24 * time.Hour,
+//	})
+func NewFileCheckpointStoreWithOptions(opts FileOptions) (store.CheckpointStore, error) {
+	path := opts.Path
+
 	// Ensure directory exists
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create checkpoint directory: %w", err)
 	}
 
-	// Ensure index directory exists
-	indexDir := filepath.Join(path, "by_thread")
-	if err := os.MkdirAll(indexDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	// Ensure index directories exist
+	for _, dir := range []string{threadIndexDir, executionIndexDir} {
+		if err := os.MkdirAll(filepath.Join(path, dir), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create index directory: %w", err)
+		}
+	}
+
+	f := &FileCheckpointStore{
+		path:     path,
+		layout:   opts.Layout,
+		ttl:      opts.TTL,
+		now:      opts.Now,
+		threaded: make(map[string]string),
+	}
+	if f.now == nil {
+		f.now = time.Now
+	}
+
+	if err := f.removeTempFiles(); err != nil {
+		return nil, fmt.Errorf("failed to remove temporary files: %w", err)
+	}
+
+	if err := f.loadThreaded(); err != nil {
+		return nil, fmt.Errorf("failed to read thread directories: %w", err)
+	}
+
+	if opts.Migrate {
+		if err := f.migrate(); err != nil {
+			return nil, fmt.Errorf("failed to migrate checkpoint files: %w", err)
+		}
+	}
+
+	version, err := os.ReadFile(filepath.Join(path, indexVersionFile))
+	if err != nil || string(version) != indexVersion {
+		if err := f.rebuildIndexes(); err != nil {
+			return nil, fmt.Errorf("failed to rebuild indexes: %w", err)
+		}
+	}
+
+	if f.ttl > 0 {
+		if err := f.removeAllExpired(); err != nil {
+			return nil, fmt.Errorf("failed to remove expired checkpoints: %w", err)
+		}
 	}
 
-	return &FileCheckpointStore{
-		path: path,
-	}, nil
+	return f, nil
+}
+
+// Ping checks that the checkpoint directory exists and is writable, returning an
+// error naming it if not
+func (f *FileCheckpointStore) Ping(_ context.Context) error {
+	tmp, err := os.CreateTemp(f.path, ".ping.*.tmp")
+	if err != nil {
+		return fmt.Errorf("file checkpoint store at %s is not writable: %w", f.path, err)
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
 }
 
 // Save implements CheckpointStore interface for file storage
@@ -46,26 +208,35 @@ func (f *FileCheckpointStore) Save(_ context.Context, checkpoint *store.Checkpoi
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
-	// Create filename from ID
-	filename := filepath.Join(f.path, fmt.Sprintf("%s.json", checkpoint.ID))
-
 	data, err := json.Marshal(checkpoint)
 	if err != nil {
 		return fmt.Errorf("failed to marshal checkpoint: %w", err)
 	}
 
-	if err := os.WriteFile(filename, data, 0600); err != nil {
+	// The checkpoint saved before under this ID, whose index entries may change
+	previous, _ := f.readCheckpoint(checkpoint.ID)
+	previousPath := f.checkpointPath(checkpoint.ID)
+
+	filename := f.layoutPath(checkpoint)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create thread directory: %w", err)
+	}
+	if err := writeFileAtomic(filename, data); err != nil {
 		return fmt.Errorf("failed to write checkpoint file: %w", err)
 	}
+	f.setCheckpointPath(checkpoint.ID, filename)
 
-	// Update thread_id index
-	if threadID, ok := checkpoint.Metadata["thread_id"].(string); ok && threadID != "" {
-		if err := f.addToThreadIndex(threadID, checkpoint.ID); err != nil {
-			// Log error but don't fail the save
-			_ = fmt.Errorf("failed to update thread index: %w", err)
+	// A new version or thread moves the file in LayoutThreads
+	if previousPath != filename {
+		if err := f.removeCheckpointFile(previousPath); err != nil {
+			return fmt.Errorf("failed to delete previous checkpoint file: %w", err)
 		}
 	}
 
+	if err := f.reindex(checkpoint.ID, previous, checkpoint); err != nil {
+		return fmt.Errorf("failed to update checkpoint indexes: %w", err)
+	}
+
 	return nil
 }
 
@@ -74,9 +245,7 @@ func (f *FileCheckpointStore) Load(_ context.Context, checkpointID string) (*sto
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
 
-	filename := filepath.Join(f.path, fmt.Sprintf("%s.json", checkpointID))
-
-	data, err := os.ReadFile(filename)
+	data, err := os.ReadFile(f.checkpointPath(checkpointID))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("checkpoint not found: %s", checkpointID)
@@ -89,78 +258,91 @@ func (f *FileCheckpointStore) Load(_ context.Context, checkpointID string) (*sto
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
 	}
+	if f.expired(&checkpoint) {
+		return nil, fmt.Errorf("checkpoint not found: %s", checkpointID)
+	}
 
 	return &checkpoint, nil
 }
 
 // List implements CheckpointStore interface for file storage
-func (f *FileCheckpointStore) List(_ context.Context, executionID string) ([]*store.Checkpoint, error) {
+func (f *FileCheckpointStore) List(ctx context.Context, executionID string) ([]*store.Checkpoint, error) {
+	return f.ListPage(ctx, executionID, store.ListOptions{})
+}
+
+// ListPage returns the page of the checkpoints List returns that opts selects.
+// Only the checkpoint files of the page are read; unreadable ones are skipped.
+func (f *FileCheckpointStore) ListPage(_ context.Context, executionID string, opts store.ListOptions) ([]*store.Checkpoint, error) {
 	f.mutex.RLock()
-	defer f.mutex.RUnlock()
+	checkpoints, expired, err := f.listPage(executionID, opts)
+	f.mutex.RUnlock()
+
+	f.removeExpired(expired)
+	return checkpoints, err
+}
 
-	files, err := os.ReadDir(f.path)
+// listPage returns the page of the checkpoints of executionID that opts selects,
+// and the IDs of the expired checkpoints it read
+func (f *FileCheckpointStore) listPage(executionID string, opts store.ListOptions) ([]*store.Checkpoint, []string, error) {
+	idx, err := f.loadIndex(executionIndexDir, executionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read checkpoint directory: %w", err)
+		return nil, nil, err
 	}
 
-	var checkpoints []*store.Checkpoint
+	entries := slices.Clone(idx.Checkpoints)
+	if opts.Descending {
+		slices.Reverse(entries)
+	}
 
-	for _, file := range files {
-		// Skip directories and non-JSON files
-		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
-			continue
+	skip := max(opts.Offset, 0)
+	var checkpoints []*store.Checkpoint
+	var expired []string
+	for _, entry := range entries {
+		if opts.Limit > 0 && len(checkpoints) == opts.Limit {
+			break
 		}
-
-		data, err := os.ReadFile(filepath.Join(f.path, file.Name()))
-		if err != nil {
-			// Skip unreadable files
+		checkpoint, ok := f.readIndexed(executionIndexDir, executionID, entry.ID)
+		if !ok {
 			continue
 		}
-
-		var checkpoint store.Checkpoint
-		if err := json.Unmarshal(data, &checkpoint); err != nil {
-			// Skip invalid files
+		if f.expired(checkpoint) {
+			expired = append(expired, checkpoint.ID)
 			continue
 		}
-
-		// Filter by executionID, threadID, sessionID, or workflowID
-		execID, _ := checkpoint.Metadata["execution_id"].(string)
-		threadID, _ := checkpoint.Metadata["thread_id"].(string)
-		sessionID, _ := checkpoint.Metadata["session_id"].(string)
-		workflowID, _ := checkpoint.Metadata["workflow_id"].(string)
-
-		if execID == executionID || threadID == executionID || sessionID == executionID || workflowID == executionID {
-			checkpoints = append(checkpoints, &checkpoint)
+		if skip > 0 {
+			skip--
+			continue
 		}
+		checkpoints = append(checkpoints, checkpoint)
 	}
 
-	// Sort by version (ascending order) so latest is last
-	sort.Slice(checkpoints, func(i, j int) bool {
-		return checkpoints[i].Version < checkpoints[j].Version
-	})
-
-	return checkpoints, nil
+	return checkpoints, expired, nil
 }
 
-// ListByThread returns all checkpoints for a specific thread_id using index
-func (f *FileCheckpointStore) ListByThread(_ context.Context, threadID string) ([]*store.Checkpoint, error) {
+// ListByMetadata returns the checkpoints whose metadata matches filters, sorted by
+// timestamp. It reads every checkpoint file.
+func (f *FileCheckpointStore) ListByMetadata(_ context.Context, filters map[string]any, opts store.ListOptions) ([]*store.Checkpoint, error) {
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
 
-	// Load thread index
-	checkpointIDs, err := f.loadThreadIndex(threadID)
+	checkpoints, err := f.readCheckpoints()
 	if err != nil {
-		// Fallback to scanning all files if index doesn't exist
-		return f.listByThreadScan(threadID)
+		return nil, err
 	}
+	checkpoints = slices.DeleteFunc(checkpoints, f.expired)
 
-	if len(checkpointIDs) == 0 {
-		return []*store.Checkpoint{}, nil
+	return store.FilterByMetadata(checkpoints, filters, opts), nil
+}
+
+// readCheckpoints reads every checkpoint file, skipping unreadable and invalid ones
+func (f *FileCheckpointStore) readCheckpoints() ([]*store.Checkpoint, error) {
+	files, err := f.checkpointFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint directory: %w", err)
 	}
 
 	var checkpoints []*store.Checkpoint
-	for _, id := range checkpointIDs {
-		filename := filepath.Join(f.path, fmt.Sprintf("%s.json", id))
+	for _, filename := range files {
 		data, err := os.ReadFile(filename)
 		if err != nil {
 			// Skip unreadable files
@@ -176,27 +358,60 @@ func (f *FileCheckpointStore) ListByThread(_ context.Context, threadID string) (
 		checkpoints = append(checkpoints, &checkpoint)
 	}
 
-	// Sort by version (ascending order)
-	sort.Slice(checkpoints, func(i, j int) bool {
-		return checkpoints[i].Version < checkpoints[j].Version
-	})
-
 	return checkpoints, nil
 }
 
-// GetLatestByThread returns the latest checkpoint for a thread_id
-func (f *FileCheckpointStore) GetLatestByThread(ctx context.Context, threadID string) (*store.Checkpoint, error) {
-	checkpoints, err := f.ListByThread(ctx, threadID)
+// ListByThread returns all checkpoints for a specific thread_id using index
+func (f *FileCheckpointStore) ListByThread(_ context.Context, threadID string) ([]*store.Checkpoint, error) {
+	f.mutex.RLock()
+	checkpoints, expired, err := f.listByThread(threadID)
+	f.mutex.RUnlock()
+
+	f.removeExpired(expired)
+	return checkpoints, err
+}
+
+// listByThread returns the checkpoints of threadID, and the IDs of its expired
+// checkpoints
+func (f *FileCheckpointStore) listByThread(threadID string) ([]*store.Checkpoint, []string, error) {
+	idx, err := f.loadIndex(threadIndexDir, threadID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	checkpoints := make([]*store.Checkpoint, 0, len(idx.Checkpoints))
+	var expired []string
+	for _, entry := range idx.Checkpoints {
+		checkpoint, ok := f.readIndexed(threadIndexDir, threadID, entry.ID)
+		if !ok {
+			continue
+		}
+		if f.expired(checkpoint) {
+			expired = append(expired, checkpoint.ID)
+			continue
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, expired, nil
+}
+
+// GetLatestByThread returns the latest checkpoint for a thread_id. The thread
+// index is sorted by version, so only the last readable checkpoint is read.
+func (f *FileCheckpointStore) GetLatestByThread(_ context.Context, threadID string) (*store.Checkpoint, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	idx, err := f.loadIndex(threadIndexDir, threadID)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(checkpoints) == 0 {
-		return nil, fmt.Errorf("no checkpoints found for thread: %s", threadID)
+	if checkpoint := f.latestOf(threadID, idx); checkpoint != nil {
+		return checkpoint, nil
 	}
 
-	// Return the last one (highest version due to sorting)
-	return checkpoints[len(checkpoints)-1], nil
+	return nil, fmt.Errorf("no checkpoints found for thread: %s", threadID)
 }
 
 // Delete implements CheckpointStore interface for file storage
@@ -204,8 +419,17 @@ func (f *FileCheckpointStore) Delete(_ context.Context, checkpointID string) err
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
-	// Load checkpoint first to get thread_id
-	filename := filepath.Join(f.path, fmt.Sprintf("%s.json", checkpointID))
+	return f.delete(checkpointID)
+}
+
+// delete removes the file and index entries of checkpointID, with the lock held
+func (f *FileCheckpointStore) delete(checkpointID string) error {
+	if err := os.RemoveAll(f.writesPath(checkpointID)); err != nil {
+		return fmt.Errorf("failed to delete pending writes: %w", err)
+	}
+
+	// Load checkpoint first to get the IDs it is indexed by
+	filename := f.checkpointPath(checkpointID)
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -215,22 +439,21 @@ func (f *FileCheckpointStore) Delete(_ context.Context, checkpointID string) err
 		return fmt.Errorf("failed to read checkpoint file: %w", err)
 	}
 
-	var checkpoint store.Checkpoint
-	if err := json.Unmarshal(data, &checkpoint); err != nil {
-		return fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	// A corrupt checkpoint is removed too; index entries left pointing at it are
+	// skipped when read
+	var previous *store.Checkpoint
+	if err := json.Unmarshal(data, &previous); err != nil {
+		previous = nil
 	}
 
 	// Remove the checkpoint file
-	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+	if err := f.removeCheckpointFile(filename); err != nil {
 		return fmt.Errorf("failed to delete checkpoint file: %w", err)
 	}
+	delete(f.threaded, checkpointID)
 
-	// Remove from thread index
-	if threadID, ok := checkpoint.Metadata["thread_id"].(string); ok && threadID != "" {
-		if err := f.removeFromThreadIndex(threadID, checkpointID); err != nil {
-			// Log error but don't fail the delete
-			_ = fmt.Errorf("failed to update thread index: %w", err)
-		}
+	if err := f.reindex(checkpointID, previous, nil); err != nil {
+		return fmt.Errorf("failed to update checkpoint indexes: %w", err)
 	}
 
 	return nil
@@ -257,148 +480,554 @@ func (f *FileCheckpointStore) Clear(ctx context.Context, executionID string) err
 	return nil
 }
 
-// Helper functions for thread index management
+// ListThreads returns the threads that have checkpoints, most recently updated
+// first, from the thread index files
+func (f *FileCheckpointStore) ListThreads(_ context.Context, opts store.ListThreadsOptions) ([]store.ThreadInfo, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	entries, err := os.ReadDir(filepath.Join(f.path, threadIndexDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []store.ThreadInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to read index directory: %w", err)
+	}
+
+	threads := []store.ThreadInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		threadID, err := url.PathUnescape(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			// Not an index file
+			continue
+		}
+		idx, err := f.loadIndex(threadIndexDir, threadID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Threads whose checkpoints are all unreadable are skipped
+		latest := f.latestOf(threadID, idx)
+		if latest == nil {
+			continue
+		}
+
+		threads = append(threads, store.ThreadInfo{
+			ThreadID:        threadID,
+			CheckpointCount: len(idx.Checkpoints),
+			LatestVersion:   latest.Version,
+			LastUpdated:     latest.Timestamp,
+		})
+	}
 
-func (f *FileCheckpointStore) getThreadIndexPath(threadID string) string {
-	return filepath.Join(f.path, "by_thread", fmt.Sprintf("%s.json", threadID))
+	return store.SortThreads(threads, opts), nil
 }
 
-func (f *FileCheckpointStore) loadThreadIndex(threadID string) ([]string, error) {
-	indexPath := f.getThreadIndexPath(threadID)
+// DeleteThread removes every checkpoint of threadID and its index file. The
+// index file goes first, so the thread is gone even if removing a checkpoint
+// file fails.
+func (f *FileCheckpointStore) DeleteThread(_ context.Context, threadID string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 
-	data, err := os.ReadFile(indexPath)
+	idx, err := f.loadIndex(threadIndexDir, threadID)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []string{}, nil
-		}
-		return nil, err
+		return err
 	}
 
-	var index threadIndex
-	if err := json.Unmarshal(data, &index); err != nil {
-		return nil, err
+	if err := os.Remove(f.indexPath(threadIndexDir, threadID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete thread index: %w", err)
+	}
+
+	var errs []error
+	executions := make(map[string][]string)
+	for _, entry := range idx.Checkpoints {
+		checkpoint, err := f.readCheckpoint(entry.ID)
+		if err == nil {
+			for _, key := range indexKeys(executionIndexDir, checkpoint) {
+				executions[key] = append(executions[key], entry.ID)
+			}
+		}
+		if err := f.removeCheckpointFile(f.checkpointPath(entry.ID)); err != nil {
+			errs = append(errs, err)
+		}
+		if err := os.RemoveAll(f.writesPath(entry.ID)); err != nil {
+			errs = append(errs, err)
+		}
+		delete(f.threaded, entry.ID)
 	}
 
-	if index.Threads == nil {
-		return []string{}, nil
+	for key, ids := range executions {
+		err := f.updateIndex(executionIndexDir, key, func(idx *checkpointIndex) {
+			for _, id := range ids {
+				idx.remove(id)
+			}
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	ids, ok := index.Threads[threadID]
-	if !ok {
-		return []string{}, nil
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to delete some checkpoints of thread %s: %v", threadID, errs)
 	}
 
-	return ids, nil
+	return nil
 }
 
-func (f *FileCheckpointStore) addToThreadIndex(threadID, checkpointID string) error {
-	indexPath := f.getThreadIndexPath(threadID)
+// SaveWrite saves the pending write of a node as
+// <path>/writes/<checkpoint_id>/<node>.json, replacing its previous write for the
+// same checkpoint
+func (f *FileCheckpointStore) SaveWrite(_ context.Context, write *store.PendingWrite) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 
-	// Load existing index
-	var index threadIndex
-	if data, err := os.ReadFile(indexPath); err == nil {
-		_ = json.Unmarshal(data, &index)
+	data, err := json.Marshal(write)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending write: %w", err)
 	}
 
-	if index.Threads == nil {
-		index.Threads = make(map[string][]string)
+	dir := f.writesPath(write.CheckpointID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create pending writes directory: %w", err)
 	}
+	filename := filepath.Join(dir, fmt.Sprintf("%s.json", url.PathEscape(write.Node)))
+	if err := writeFileAtomic(filename, data); err != nil {
+		return fmt.Errorf("failed to write pending write file: %w", err)
+	}
+	return nil
+}
 
-	// Add checkpoint ID to index
-	index.Threads[threadID] = append(index.Threads[threadID], checkpointID)
+// ListWrites returns the pending writes of checkpointID sorted by node name.
+// Corrupt write files are skipped, so their nodes run again.
+func (f *FileCheckpointStore) ListWrites(_ context.Context, checkpointID string) ([]*store.PendingWrite, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
 
-	// Write index back to disk
-	data, err := json.Marshal(index)
+	files, err := filepath.Glob(filepath.Join(f.writesPath(checkpointID), "*.json"))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to list pending writes: %w", err)
 	}
 
-	return os.WriteFile(indexPath, data, 0600)
+	writes := make([]*store.PendingWrite, 0, len(files))
+	for _, filename := range files {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pending write file: %w", err)
+		}
+		var write store.PendingWrite
+		if err := json.Unmarshal(data, &write); err != nil {
+			continue
+		}
+		writes = append(writes, &write)
+	}
+
+	sort.Slice(writes, func(i, j int) bool {
+		return writes[i].Node < writes[j].Node
+	})
+	return writes, nil
+}
+
+// DeleteWrites removes the pending writes directory of checkpointID
+func (f *FileCheckpointStore) DeleteWrites(_ context.Context, checkpointID string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := os.RemoveAll(f.writesPath(checkpointID)); err != nil {
+		return fmt.Errorf("failed to delete pending writes: %w", err)
+	}
+	return nil
+}
+
+// Helper functions for index management
+
+// writesPath returns the directory of the pending writes of checkpointID, with
+// the ID path escaped
+func (f *FileCheckpointStore) writesPath(checkpointID string) string {
+	return filepath.Join(f.path, writesDir, url.PathEscape(checkpointID))
+}
+
+// checkpointPath returns the file checkpointID is stored in
+func (f *FileCheckpointStore) checkpointPath(checkpointID string) string {
+	if filename, ok := f.threaded[checkpointID]; ok {
+		return filename
+	}
+	return filepath.Join(f.path, fmt.Sprintf("%s.json", checkpointID))
+}
+
+// layoutPath returns the file the layout of the store puts checkpoint in
+func (f *FileCheckpointStore) layoutPath(checkpoint *store.Checkpoint) string {
+	threadID, _ := checkpoint.Metadata["thread_id"].(string)
+	if f.layout != LayoutThreads || threadID == "" {
+		return filepath.Join(f.path, fmt.Sprintf("%s.json", checkpoint.ID))
+	}
+	return filepath.Join(f.path, threadsDir, url.PathEscape(threadID),
+		fmt.Sprintf("%d-%s.json", checkpoint.Version, checkpoint.ID))
+}
+
+// setCheckpointPath records that checkpointID is stored in filename
+func (f *FileCheckpointStore) setCheckpointPath(checkpointID, filename string) {
+	if filepath.Dir(filename) == f.path {
+		delete(f.threaded, checkpointID)
+	} else {
+		f.threaded[checkpointID] = filename
+	}
 }
 
-func (f *FileCheckpointStore) removeFromThreadIndex(threadID, checkpointID string) error {
-	indexPath := f.getThreadIndexPath(threadID)
+// removeCheckpointFile removes a checkpoint file, and its thread directory once
+// it is empty
+func (f *FileCheckpointStore) removeCheckpointFile(filename string) error {
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if dir := filepath.Dir(filename); dir != f.path {
+		// Fails while the directory has other files
+		_ = os.Remove(dir)
+	}
+	return nil
+}
 
-	// Load existing index
-	var index threadIndex
-	data, err := os.ReadFile(indexPath)
+// checkpointFiles returns the checkpoint files of both layouts
+func (f *FileCheckpointStore) checkpointFiles() ([]string, error) {
+	flat, err := filepath.Glob(filepath.Join(f.path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	threaded, err := filepath.Glob(filepath.Join(f.path, threadsDir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	return append(flat, threaded...), nil
+}
+
+// loadThreaded finds the checkpoints stored in thread directories by their file
+// names, <version>-<id>.json
+func (f *FileCheckpointStore) loadThreaded() error {
+	files, err := filepath.Glob(filepath.Join(f.path, threadsDir, "*", "*.json"))
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
 		return err
 	}
+	for _, filename := range files {
+		name := strings.TrimSuffix(filepath.Base(filename), ".json")
+		// The version may be negative
+		if i := strings.Index(name[min(1, len(name)):], "-"); i >= 0 {
+			f.threaded[name[i+2:]] = filename
+		}
+	}
+	return nil
+}
 
-	if err := json.Unmarshal(data, &index); err != nil {
+// migrate moves the checkpoint files that aren't where the layout of the store
+// puts them. Files are renamed, so an interrupted migration leaves every
+// checkpoint readable and the next one finishes it.
+func (f *FileCheckpointStore) migrate() error {
+	files, err := f.checkpointFiles()
+	if err != nil {
 		return err
 	}
+	for _, filename := range files {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		var checkpoint store.Checkpoint
+		if err := json.Unmarshal(data, &checkpoint); err != nil {
+			// Corrupt files stay where they are
+			continue
+		}
 
-	if index.Threads == nil {
-		return nil
+		target := f.layoutPath(&checkpoint)
+		if target == filename {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(filename, target); err != nil {
+			return err
+		}
+		f.setCheckpointPath(checkpoint.ID, target)
+		if dir := filepath.Dir(filename); dir != f.path {
+			_ = os.Remove(dir)
+		}
 	}
+	return nil
+}
 
-	// Remove checkpoint ID from index
-	ids, ok := index.Threads[threadID]
-	if !ok {
+// indexPath returns the index file of id in dir, with id path escaped
+func (f *FileCheckpointStore) indexPath(dir, id string) string {
+	return filepath.Join(f.path, dir, fmt.Sprintf("%s.json", url.PathEscape(id)))
+}
+
+// indexKeys returns the IDs the index directory dir lists checkpoint under
+func indexKeys(dir string, checkpoint *store.Checkpoint) []string {
+	if checkpoint == nil {
 		return nil
 	}
 
-	for i, id := range ids {
-		if id == checkpointID {
-			index.Threads[threadID] = append(ids[:i], ids[i+1:]...)
-			break
+	fields := []string{"thread_id"}
+	if dir == executionIndexDir {
+		fields = []string{"execution_id", "thread_id", "session_id", "workflow_id"}
+	}
+
+	var keys []string
+	for _, field := range fields {
+		if key, ok := checkpoint.Metadata[field].(string); ok && key != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// readCheckpoint reads the checkpoint file of checkpointID
+func (f *FileCheckpointStore) readCheckpoint(checkpointID string) (*store.Checkpoint, error) {
+	data, err := os.ReadFile(f.checkpointPath(checkpointID))
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint store.Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, err
+	}
+
+	return &checkpoint, nil
+}
+
+// readIndexed reads a checkpoint the index id of dir lists. It reports false if
+// the file is missing or corrupt, or was saved again under another ID.
+func (f *FileCheckpointStore) readIndexed(dir, id, checkpointID string) (*store.Checkpoint, bool) {
+	checkpoint, err := f.readCheckpoint(checkpointID)
+	if err != nil || !slices.Contains(indexKeys(dir, checkpoint), id) {
+		return nil, false
+	}
+	return checkpoint, true
+}
+
+// latestOf returns the readable checkpoint of idx with the highest version, or
+// nil if there is none
+func (f *FileCheckpointStore) latestOf(threadID string, idx *checkpointIndex) *store.Checkpoint {
+	for _, entry := range slices.Backward(idx.Checkpoints) {
+		if checkpoint, ok := f.readIndexed(threadIndexDir, threadID, entry.ID); ok && !f.expired(checkpoint) {
+			return checkpoint
 		}
 	}
+	return nil
+}
+
+// expired reports whether the TTL of the store has passed since the Timestamp of
+// checkpoint
+func (f *FileCheckpointStore) expired(checkpoint *store.Checkpoint) bool {
+	return f.ttl > 0 && f.now().Sub(checkpoint.Timestamp) >= f.ttl
+}
 
-	// Write index back to disk
-	data, err = json.Marshal(index)
+// removeExpired deletes the checkpoints among ids that are still expired. A
+// failure leaves the checkpoint to the next List that reads it.
+func (f *FileCheckpointStore) removeExpired(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, id := range ids {
+		if checkpoint, err := f.readCheckpoint(id); err == nil && f.expired(checkpoint) {
+			_ = f.delete(id)
+		}
+	}
+}
+
+// removeAllExpired deletes every expired checkpoint
+func (f *FileCheckpointStore) removeAllExpired() error {
+	checkpoints, err := f.readCheckpoints()
 	if err != nil {
 		return err
 	}
+	for _, checkpoint := range checkpoints {
+		if f.expired(checkpoint) {
+			if err := f.delete(checkpoint.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readIndex reads the index id of dir, which is empty if it doesn't exist
+func (f *FileCheckpointStore) readIndex(dir, id string) (*checkpointIndex, error) {
+	idx := &checkpointIndex{ID: id}
+	if id == "" {
+		return idx, nil
+	}
+
+	data, err := os.ReadFile(f.indexPath(dir, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, err
+	}
 
-	return os.WriteFile(indexPath, data, 0600)
+	return idx, nil
 }
 
-// listByThreadScan is a fallback method that scans all files
-func (f *FileCheckpointStore) listByThreadScan(threadID string) ([]*store.Checkpoint, error) {
-	files, err := os.ReadDir(f.path)
+// loadIndex reads the index id of dir. An index that can't be read is rebuilt
+// in memory from the checkpoint files; the next write to it repairs the file.
+func (f *FileCheckpointStore) loadIndex(dir, id string) (*checkpointIndex, error) {
+	if idx, err := f.readIndex(dir, id); err == nil {
+		return idx, nil
+	}
+	return f.scanIndex(dir, id)
+}
+
+// scanIndex builds the index id of dir from every checkpoint file
+func (f *FileCheckpointStore) scanIndex(dir, id string) (*checkpointIndex, error) {
+	checkpoints, err := f.readCheckpoints()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read checkpoint directory: %w", err)
+		return nil, err
 	}
 
-	var checkpoints []*store.Checkpoint
+	idx := &checkpointIndex{ID: id}
+	for _, checkpoint := range checkpoints {
+		if slices.Contains(indexKeys(dir, checkpoint), id) {
+			idx.put(checkpoint.ID, checkpoint.Version)
+		}
+	}
+	return idx, nil
+}
 
-	for _, file := range files {
-		// Skip directories and non-JSON files
-		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
-			continue
+// writeIndex writes the index id of dir, removing it once it is empty
+func (f *FileCheckpointStore) writeIndex(dir string, idx *checkpointIndex) error {
+	path := f.indexPath(dir, idx.ID)
+	if len(idx.Checkpoints) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
 		}
+		return nil
+	}
 
-		// Skip index directory
-		if file.Name() == "by_thread" {
-			continue
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// updateIndex applies update to the index id of dir and writes it back
+func (f *FileCheckpointStore) updateIndex(dir, id string, update func(*checkpointIndex)) error {
+	idx, err := f.loadIndex(dir, id)
+	if err != nil {
+		return err
+	}
+	update(idx)
+	return f.writeIndex(dir, idx)
+}
+
+// reindex moves the index entries of checkpointID from the indexes of previous to
+// those of current; either may be nil
+func (f *FileCheckpointStore) reindex(checkpointID string, previous, current *store.Checkpoint) error {
+	for _, dir := range []string{threadIndexDir, executionIndexDir} {
+		keys := indexKeys(dir, current)
+		for _, key := range indexKeys(dir, previous) {
+			if slices.Contains(keys, key) {
+				continue
+			}
+			if err := f.updateIndex(dir, key, func(idx *checkpointIndex) { idx.remove(checkpointID) }); err != nil {
+				return err
+			}
+		}
+		for _, key := range keys {
+			if err := f.updateIndex(dir, key, func(idx *checkpointIndex) { idx.put(checkpointID, current.Version) }); err != nil {
+				return err
+			}
 		}
+	}
+	return nil
+}
+
+// rebuildIndexes replaces every index file with indexes built from the
+// checkpoint files, then records the index format
+func (f *FileCheckpointStore) rebuildIndexes() error {
+	checkpoints, err := f.readCheckpoints()
+	if err != nil {
+		return err
+	}
 
-		data, err := os.ReadFile(filepath.Join(f.path, file.Name()))
+	for _, dir := range []string{threadIndexDir, executionIndexDir} {
+		indexes := make(map[string]*checkpointIndex)
+		for _, checkpoint := range checkpoints {
+			for _, key := range indexKeys(dir, checkpoint) {
+				if indexes[key] == nil {
+					indexes[key] = &checkpointIndex{ID: key}
+				}
+				indexes[key].put(checkpoint.ID, checkpoint.Version)
+			}
+		}
+
+		files, err := filepath.Glob(filepath.Join(f.path, dir, "*.json"))
 		if err != nil {
-			continue
+			return err
+		}
+		for _, file := range files {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return err
+			}
 		}
 
-		var checkpoint store.Checkpoint
-		if err := json.Unmarshal(data, &checkpoint); err != nil {
-			continue
+		for _, idx := range indexes {
+			if err := f.writeIndex(dir, idx); err != nil {
+				return err
+			}
 		}
+	}
 
-		// Filter by thread_id
-		if cpThreadID, ok := checkpoint.Metadata["thread_id"].(string); ok && cpThreadID == threadID {
-			checkpoints = append(checkpoints, &checkpoint)
+	return writeFileAtomic(filepath.Join(f.path, indexVersionFile), []byte(indexVersion))
+}
+
+// removeTempFiles removes the temporary files of writes interrupted by a crash
+func (f *FileCheckpointStore) removeTempFiles() error {
+	for _, dir := range []string{"", threadIndexDir, executionIndexDir, filepath.Join(threadsDir, "*"), filepath.Join(writesDir, "*")} {
+		files, err := filepath.Glob(filepath.Join(f.path, dir, ".*.tmp"))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return err
+			}
 		}
 	}
+	return nil
+}
 
-	// Sort by version (ascending order)
-	sort.Slice(checkpoints, func(i, j int) bool {
-		return checkpoints[i].Version < checkpoints[j].Version
-	})
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so readers see either the old or the new content
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Fails once the file has been renamed
+	defer os.Remove(tmp.Name())
 
-	return checkpoints, nil
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
 }
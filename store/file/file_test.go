@@ -2,9 +2,13 @@ package file
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -582,3 +586,567 @@ func TestFileCheckpointStore_Concurrent(t *testing.T) {
 		t.Errorf("Expected %d checkpoint files, got %d", expectedTotal, jsonCount)
 	}
 }
+
+func TestFileCheckpointStore_GetLatestByThread(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	save := func(t *testing.T, s store.CheckpointStore, id string, version int) {
+		t.Helper()
+		err := s.Save(ctx, &store.Checkpoint{
+			ID:        id,
+			Metadata:  map[string]any{"thread_id": "thread"},
+			Timestamp: time.Now(),
+			Version:   version,
+		})
+		if err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	t.Run("reads only the latest checkpoint", func(t *testing.T) {
+		t.Parallel()
+		tempDir := t.TempDir()
+		s, err := NewFileCheckpointStore(tempDir)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		save(t, s, "cp-1", 1)
+		save(t, s, "cp-2", 2)
+
+		// An unreadable older checkpoint doesn't matter to the latest
+		if err := os.WriteFile(filepath.Join(tempDir, "cp-1.json"), []byte("not json"), 0600); err != nil {
+			t.Fatalf("Failed to corrupt checkpoint: %v", err)
+		}
+
+		latest, err := s.GetLatestByThread(ctx, "thread")
+		if err != nil {
+			t.Fatalf("GetLatestByThread failed: %v", err)
+		}
+		if latest.ID != "cp-2" {
+			t.Errorf("Expected cp-2, got %s", latest.ID)
+		}
+	})
+
+	t.Run("rebuilds indexes of an earlier format", func(t *testing.T) {
+		t.Parallel()
+		tempDir := t.TempDir()
+		s, err := NewFileCheckpointStore(tempDir)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		save(t, s, "cp-2", 2)
+		save(t, s, "cp-1", 1)
+
+		// Rewrite the index as earlier versions wrote it
+		legacy := []byte(`{"Threads":{"thread":["cp-2","cp-1"]}}`)
+		if err := os.WriteFile(filepath.Join(tempDir, "by_thread", "thread.json"), legacy, 0600); err != nil {
+			t.Fatalf("Failed to write index: %v", err)
+		}
+		if err := os.Remove(filepath.Join(tempDir, indexVersionFile)); err != nil {
+			t.Fatalf("Failed to remove index version: %v", err)
+		}
+
+		s, err = NewFileCheckpointStore(tempDir)
+		if err != nil {
+			t.Fatalf("Failed to reopen store: %v", err)
+		}
+		latest, err := s.GetLatestByThread(ctx, "thread")
+		if err != nil {
+			t.Fatalf("GetLatestByThread failed: %v", err)
+		}
+		if latest.ID != "cp-2" {
+			t.Errorf("Expected cp-2, got %s", latest.ID)
+		}
+
+		data, err := os.ReadFile(filepath.Join(tempDir, "by_thread", "thread.json"))
+		if err != nil {
+			t.Fatalf("Failed to read index: %v", err)
+		}
+		var idx checkpointIndex
+		if err := json.Unmarshal(data, &idx); err != nil {
+			t.Fatalf("Failed to unmarshal index: %v", err)
+		}
+		want := []indexEntry{{ID: "cp-1", Version: 1}, {ID: "cp-2", Version: 2}}
+		if !slices.Equal(idx.Checkpoints, want) {
+			t.Errorf("Expected index entries %v, got %v", want, idx.Checkpoints)
+		}
+	})
+}
+
+func TestFileCheckpointStore_Crash(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	open := func(t *testing.T, dir string) store.CheckpointStore {
+		t.Helper()
+		s, err := NewFileCheckpointStore(dir)
+		if err != nil {
+			t.Fatalf("Failed to open store: %v", err)
+		}
+		return s
+	}
+	saveVersions := func(t *testing.T, s store.CheckpointStore, versions int) {
+		t.Helper()
+		for v := 1; v <= versions; v++ {
+			err := s.Save(ctx, &store.Checkpoint{
+				ID:        fmt.Sprintf("cp-%d", v),
+				State:     map[string]any{"step": v},
+				Metadata:  map[string]any{"thread_id": "thread", "execution_id": "exec"},
+				Timestamp: time.Now(),
+				Version:   v,
+			})
+			if err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+		}
+	}
+	listIDs := func(t *testing.T, s store.CheckpointStore) []string {
+		t.Helper()
+		checkpoints, err := s.List(ctx, "exec")
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		ids := make([]string, len(checkpoints))
+		for i, cp := range checkpoints {
+			ids[i] = cp.ID
+		}
+		return ids
+	}
+
+	t.Run("interrupted write leaves the checkpoint intact", func(t *testing.T) {
+		t.Parallel()
+		tempDir := t.TempDir()
+		s := open(t, tempDir)
+		saveVersions(t, s, 2)
+
+		// A crash while rewriting cp-2 leaves a truncated temporary file behind
+		data, err := os.ReadFile(filepath.Join(tempDir, "cp-2.json"))
+		if err != nil {
+			t.Fatalf("Failed to read checkpoint: %v", err)
+		}
+		tmp := filepath.Join(tempDir, ".cp-2.json.123.tmp")
+		if err := os.WriteFile(tmp, data[:len(data)/2], 0600); err != nil {
+			t.Fatalf("Failed to write temporary file: %v", err)
+		}
+
+		s = open(t, tempDir)
+		if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+			t.Error("Expected the temporary file to be removed")
+		}
+		loaded, err := s.Load(ctx, "cp-2")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if loaded.Version != 2 {
+			t.Errorf("Expected version 2, got %d", loaded.Version)
+		}
+		if got := listIDs(t, s); !slices.Equal(got, []string{"cp-1", "cp-2"}) {
+			t.Errorf("Expected [cp-1 cp-2], got %v", got)
+		}
+	})
+
+	t.Run("truncated checkpoint is skipped", func(t *testing.T) {
+		t.Parallel()
+		tempDir := t.TempDir()
+		s := open(t, tempDir)
+		saveVersions(t, s, 3)
+
+		filename := filepath.Join(tempDir, "cp-3.json")
+		if err := os.Truncate(filename, 10); err != nil {
+			t.Fatalf("Failed to truncate checkpoint: %v", err)
+		}
+
+		if _, err := s.Load(ctx, "cp-3"); err == nil {
+			t.Error("Expected loading the truncated checkpoint to fail")
+		}
+		if _, err := s.Load(ctx, "cp-2"); err != nil {
+			t.Errorf("Load of an intact checkpoint failed: %v", err)
+		}
+		if got := listIDs(t, s); !slices.Equal(got, []string{"cp-1", "cp-2"}) {
+			t.Errorf("Expected [cp-1 cp-2], got %v", got)
+		}
+		latest, err := s.GetLatestByThread(ctx, "thread")
+		if err != nil {
+			t.Fatalf("GetLatestByThread failed: %v", err)
+		}
+		if latest.ID != "cp-2" {
+			t.Errorf("Expected cp-2, got %s", latest.ID)
+		}
+
+		// Deleting the truncated checkpoint repairs the store
+		if err := s.Delete(ctx, "cp-3"); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if _, err := os.Stat(filename); !os.IsNotExist(err) {
+			t.Error("Expected the truncated checkpoint to be removed")
+		}
+	})
+
+	t.Run("corrupt index is repaired", func(t *testing.T) {
+		t.Parallel()
+		tempDir := t.TempDir()
+		s := open(t, tempDir)
+		saveVersions(t, s, 2)
+
+		for _, dir := range []string{threadIndexDir, executionIndexDir} {
+			name := "thread.json"
+			if dir == executionIndexDir {
+				name = "exec.json"
+			}
+			if err := os.Truncate(filepath.Join(tempDir, dir, name), 5); err != nil {
+				t.Fatalf("Failed to truncate index: %v", err)
+			}
+		}
+
+		if got := listIDs(t, s); !slices.Equal(got, []string{"cp-1", "cp-2"}) {
+			t.Errorf("Expected [cp-1 cp-2], got %v", got)
+		}
+		latest, err := s.GetLatestByThread(ctx, "thread")
+		if err != nil {
+			t.Fatalf("GetLatestByThread failed: %v", err)
+		}
+		if latest.ID != "cp-2" {
+			t.Errorf("Expected cp-2, got %s", latest.ID)
+		}
+
+		// The next save rewrites the index from the checkpoint files
+		saveVersions(t, s, 3)
+		data, err := os.ReadFile(filepath.Join(tempDir, threadIndexDir, "thread.json"))
+		if err != nil {
+			t.Fatalf("Failed to read index: %v", err)
+		}
+		var idx checkpointIndex
+		if err := json.Unmarshal(data, &idx); err != nil {
+			t.Fatalf("Expected a repaired index, got %q: %v", data, err)
+		}
+		if len(idx.Checkpoints) != 3 {
+			t.Errorf("Expected 3 index entries, got %v", idx.Checkpoints)
+		}
+	})
+}
+
+func TestFileCheckpointStore_LayoutThreads(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	save := func(t *testing.T, s store.CheckpointStore, id, threadID string, version int) {
+		t.Helper()
+		metadata := map[string]any{"execution_id": "exec"}
+		if threadID != "" {
+			metadata["thread_id"] = threadID
+		}
+		err := s.Save(ctx, &store.Checkpoint{
+			ID:        id,
+			Metadata:  metadata,
+			Timestamp: time.Now(),
+			Version:   version,
+		})
+		if err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	exists := func(t *testing.T, path string) bool {
+		t.Helper()
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	t.Run("stores each thread in its own directory", func(t *testing.T) {
+		t.Parallel()
+		tempDir := t.TempDir()
+		s, err := NewFileCheckpointStoreWithOptions(FileOptions{Path: tempDir, Layout: LayoutThreads})
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		save(t, s, "cp-1", "user/1", 1)
+		save(t, s, "cp-2", "user/1", 2)
+		save(t, s, "cp-3", "", 3)
+
+		threadDir := filepath.Join(tempDir, "threads", "user%2F1")
+		for _, name := range []string{"1-cp-1.json", "2-cp-2.json"} {
+			if !exists(t, filepath.Join(threadDir, name)) {
+				t.Errorf("Expected %s in the thread directory", name)
+			}
+		}
+		if !exists(t, filepath.Join(tempDir, "cp-3.json")) {
+			t.Error("Expected the checkpoint without a thread to stay flat")
+		}
+
+		latest, err := s.GetLatestByThread(ctx, "user/1")
+		if err != nil {
+			t.Fatalf("GetLatestByThread failed: %v", err)
+		}
+		if latest.ID != "cp-2" {
+			t.Errorf("Expected cp-2, got %s", latest.ID)
+		}
+
+		// Saving a new version moves the file
+		save(t, s, "cp-2", "user/1", 5)
+		if exists(t, filepath.Join(threadDir, "2-cp-2.json")) || !exists(t, filepath.Join(threadDir, "5-cp-2.json")) {
+			t.Error("Expected cp-2 to move to 5-cp-2.json")
+		}
+
+		// A reopened store finds the checkpoints by their file names
+		s, err = NewFileCheckpointStoreWithOptions(FileOptions{Path: tempDir, Layout: LayoutThreads})
+		if err != nil {
+			t.Fatalf("Failed to reopen store: %v", err)
+		}
+		loaded, err := s.Load(ctx, "cp-2")
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if loaded.Version != 5 {
+			t.Errorf("Expected version 5, got %d", loaded.Version)
+		}
+
+		if err := store.DeleteThread(ctx, s, "user/1"); err != nil {
+			t.Fatalf("DeleteThread failed: %v", err)
+		}
+		if exists(t, threadDir) {
+			t.Error("Expected the thread directory to be removed")
+		}
+		checkpoints, err := s.List(ctx, "exec")
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(checkpoints) != 1 || checkpoints[0].ID != "cp-3" {
+			t.Errorf("Expected only cp-3 to remain, got %v", checkpoints)
+		}
+	})
+
+	t.Run("migrates flat files when asked to", func(t *testing.T) {
+		t.Parallel()
+		tempDir := t.TempDir()
+		s, err := NewFileCheckpointStore(tempDir)
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		save(t, s, "cp-1", "thread", 1)
+		save(t, s, "cp-2", "thread", 2)
+
+		// Without Migrate the flat files stay, and are still read
+		s, err = NewFileCheckpointStoreWithOptions(FileOptions{Path: tempDir, Layout: LayoutThreads})
+		if err != nil {
+			t.Fatalf("Failed to reopen store: %v", err)
+		}
+		if !exists(t, filepath.Join(tempDir, "cp-1.json")) {
+			t.Error("Expected cp-1 to stay flat without Migrate")
+		}
+		save(t, s, "cp-3", "thread", 3)
+		checkpoints, err := s.ListByThread(ctx, "thread")
+		if err != nil {
+			t.Fatalf("ListByThread failed: %v", err)
+		}
+		if len(checkpoints) != 3 {
+			t.Errorf("Expected 3 checkpoints across both layouts, got %d", len(checkpoints))
+		}
+
+		s, err = NewFileCheckpointStoreWithOptions(FileOptions{Path: tempDir, Layout: LayoutThreads, Migrate: true})
+		if err != nil {
+			t.Fatalf("Failed to migrate store: %v", err)
+		}
+		threadDir := filepath.Join(tempDir, "threads", "thread")
+		for _, name := range []string{"1-cp-1.json", "2-cp-2.json", "3-cp-3.json"} {
+			if !exists(t, filepath.Join(threadDir, name)) {
+				t.Errorf("Expected %s to be migrated", name)
+			}
+		}
+		if exists(t, filepath.Join(tempDir, "cp-1.json")) {
+			t.Error("Expected the flat file of cp-1 to be moved")
+		}
+		if _, err := s.Load(ctx, "cp-1"); err != nil {
+			t.Errorf("Load after migration failed: %v", err)
+		}
+
+		// Migrating back to the flat layout
+		s, err = NewFileCheckpointStoreWithOptions(FileOptions{Path: tempDir, Migrate: true})
+		if err != nil {
+			t.Fatalf("Failed to migrate store: %v", err)
+		}
+		if exists(t, threadDir) || !exists(t, filepath.Join(tempDir, "cp-3.json")) {
+			t.Error("Expected the checkpoints to move back to the flat layout")
+		}
+		latest, err := s.GetLatestByThread(ctx, "thread")
+		if err != nil {
+			t.Fatalf("GetLatestByThread failed: %v", err)
+		}
+		if latest.ID != "cp-3" {
+			t.Errorf("Expected cp-3, got %s", latest.ID)
+		}
+	})
+}
+
+func TestFileCheckpointStore_Ping(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tempDir := filepath.Join(t.TempDir(), "checkpoints")
+	s, err := NewFileCheckpointStore(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if err := store.Ping(ctx, s); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".ping") {
+			t.Errorf("Expected Ping to leave no files behind, got %s", entry.Name())
+		}
+	}
+
+	if err := os.RemoveAll(tempDir); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	err = store.Ping(ctx, s)
+	if err == nil || !strings.Contains(err.Error(), "file checkpoint store at "+tempDir+" is not writable") {
+		t.Errorf("Expected an error naming the missing directory, got %v", err)
+	}
+}
+
+func TestFileCheckpointStore_TTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var clock sync.Mutex
+	clockNow := func() time.Time {
+		clock.Lock()
+		defer clock.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		clock.Lock()
+		defer clock.Unlock()
+		now = now.Add(d)
+	}
+	open := func(t *testing.T) store.CheckpointStore {
+		t.Helper()
+		s, err := NewFileCheckpointStoreWithOptions(FileOptions{Path: tempDir, TTL: time.Hour, Now: clockNow})
+		if err != nil {
+			t.Fatalf("Failed to open store: %v", err)
+		}
+		return s
+	}
+	saveAt := func(t *testing.T, s store.CheckpointStore, id, thread string, version int) {
+		t.Helper()
+		cp := &store.Checkpoint{
+			ID:        id,
+			Timestamp: clockNow(),
+			Version:   version,
+			Metadata:  map[string]any{"thread_id": thread, "execution_id": thread},
+		}
+		if err := s.Save(ctx, cp); err != nil {
+			t.Fatalf("Failed to save %s: %v", id, err)
+		}
+	}
+	exists := func(id string) bool {
+		_, err := os.Stat(filepath.Join(tempDir, id+".json"))
+		return err == nil
+	}
+
+	s := open(t)
+	saveAt(t, s, "cp-1", "thread", 1)
+	saveAt(t, s, "old-1", "old", 1)
+	advance(30 * time.Minute)
+	saveAt(t, s, "cp-2", "thread", 2)
+	advance(40 * time.Minute)
+
+	// Expired checkpoints are not returned
+	if _, err := s.Load(ctx, "cp-1"); err == nil {
+		t.Error("Expected expired checkpoint cp-1 not to load")
+	}
+	if _, err := s.GetLatestByThread(ctx, "old"); err == nil {
+		t.Error("Expected thread old to have no latest checkpoint")
+	}
+	if checkpoints, _ := store.ListByMetadata(ctx, s, map[string]any{}, store.ListOptions{}); len(checkpoints) != 1 {
+		t.Errorf("Expected ListByMetadata to return only cp-2, got %d checkpoints", len(checkpoints))
+	}
+
+	// and List removes the files of the ones it reads
+	checkpoints, err := s.List(ctx, "thread")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(checkpoints) != 1 || checkpoints[0].ID != "cp-2" {
+		t.Errorf("Expected only cp-2, got %v", checkpoints)
+	}
+	if exists("cp-1") {
+		t.Error("Expected List to remove the file of cp-1")
+	}
+	latest, err := s.GetLatestByThread(ctx, "thread")
+	if err != nil || latest.ID != "cp-2" {
+		t.Errorf("Expected cp-2 to be the latest checkpoint, got %v (%v)", latest, err)
+	}
+
+	// Opening the store removes the expired checkpoints no List read
+	if !exists("old-1") {
+		t.Fatal("Expected old-1 to be left until the store is opened")
+	}
+	s = open(t)
+	if exists("old-1") {
+		t.Error("Expected opening the store to remove old-1")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, threadIndexDir, "old.json")); !os.IsNotExist(err) {
+		t.Errorf("Expected the index of thread old to be removed, got %v", err)
+	}
+
+	advance(time.Hour)
+	if checkpoints, _ := s.ListByThread(ctx, "thread"); len(checkpoints) != 0 {
+		t.Errorf("Expected every checkpoint to have expired, got %d", len(checkpoints))
+	}
+	if exists("cp-2") {
+		t.Error("Expected ListByThread to remove the file of cp-2")
+	}
+}
+
+func TestFileCheckpointStore_Writes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	cp := &store.Checkpoint{ID: "cp-1", Version: 1, Metadata: map[string]any{"thread_id": "thread"}}
+	if err := s.Save(ctx, cp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	for _, node := range []string{"b", "a/x", "b"} {
+		write := &store.PendingWrite{CheckpointID: "cp-1", Node: node, Value: map[string]any{"node": node}}
+		if err := store.SaveWrite(ctx, s, write); err != nil {
+			t.Fatalf("SaveWrite failed: %v", err)
+		}
+	}
+
+	// Writes survive reopening the store, and aren't read as checkpoints
+	s, err = NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if checkpoints, err := s.ListByThread(ctx, "thread"); err != nil || len(checkpoints) != 1 {
+		t.Errorf("Expected one checkpoint, got %v (%v)", checkpoints, err)
+	}
+	writes, err := store.ListWrites(ctx, s, "cp-1")
+	if err != nil {
+		t.Fatalf("ListWrites failed: %v", err)
+	}
+	if len(writes) != 2 || writes[0].Node != "a/x" || writes[1].Value.(map[string]any)["node"] != "b" {
+		t.Errorf("Expected the writes of a/x and b, got %v", writes)
+	}
+
+	// Deleting a checkpoint deletes its writes
+	if err := s.Delete(ctx, "cp-1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if writes, _ := store.ListWrites(ctx, s, "cp-1"); len(writes) != 0 {
+		t.Errorf("Expected no writes for a deleted checkpoint, got %v", writes)
+	}
+}
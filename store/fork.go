@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ForkThread copies the checkpoints of sourceThreadID, up to and including checkpointID,
+// into newThreadID. The copies get new IDs and their thread_id metadata is rewritten;
+// "forked_from" and "source_thread_id" record where each copy came from. Versions are
+// kept, so resuming newThreadID continues from the forked checkpoint. The source thread
+// is not modified. It returns the copies sorted by version.
+func ForkThread(ctx context.Context, s CheckpointStore, sourceThreadID, checkpointID, newThreadID string) ([]*Checkpoint, error) {
+	if newThreadID == "" || newThreadID == sourceThreadID {
+		return nil, fmt.Errorf("invalid fork thread id %q: must differ from source thread %q", newThreadID, sourceThreadID)
+	}
+
+	existing, err := s.ListByThread(ctx, newThreadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints for thread %s: %w", newThreadID, err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("thread %s already has %d checkpoints", newThreadID, len(existing))
+	}
+
+	chain, err := s.ListByThread(ctx, sourceThreadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints for thread %s: %w", sourceThreadID, err)
+	}
+
+	end := -1
+	for i, cp := range chain {
+		if cp.ID == checkpointID {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, fmt.Errorf("checkpoint %s not found in thread %s", checkpointID, sourceThreadID)
+	}
+
+	forked := make([]*Checkpoint, 0, end+1)
+	for _, cp := range chain[:end+1] {
+		metadata := maps.Clone(cp.Metadata)
+		if metadata == nil {
+			metadata = make(map[string]any)
+		}
+		metadata["thread_id"] = newThreadID
+		metadata["execution_id"] = newThreadID
+		metadata["forked_from"] = cp.ID
+		metadata["source_thread_id"] = sourceThreadID
+
+		state := cp.State
+		if m, ok := state.(map[string]any); ok {
+			// Don't share map states with the source thread
+			state = maps.Clone(m)
+		}
+
+		fork := &Checkpoint{
+			ID:        fmt.Sprintf("checkpoint_%s", uuid.New().String()),
+			NodeName:  cp.NodeName,
+			State:     state,
+			Metadata:  metadata,
+			Timestamp: time.Now(),
+			Version:   cp.Version,
+		}
+		if err := s.Save(ctx, fork); err != nil {
+			return nil, fmt.Errorf("failed to save forked checkpoint: %w", err)
+		}
+		forked = append(forked, fork)
+	}
+
+	return forked, nil
+}
@@ -0,0 +1,113 @@
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/file"
+	"github.com/smallnest/langgraphgo/store/memory"
+)
+
+func TestForkThread(t *testing.T) {
+	t.Parallel()
+
+	stores := map[string]func(t *testing.T) store.CheckpointStore{
+		"Memory": func(t *testing.T) store.CheckpointStore {
+			return memory.NewMemoryCheckpointStore()
+		},
+		"File": func(t *testing.T) store.CheckpointStore {
+			s, err := file.NewFileCheckpointStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("Failed to create file store: %v", err)
+			}
+			return s
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			s := newStore(t)
+
+			var source []*store.Checkpoint
+			for v := 1; v <= 4; v++ {
+				cp := &store.Checkpoint{
+					ID:        fmt.Sprintf("cp-%d", v),
+					NodeName:  fmt.Sprintf("step%d", v),
+					State:     map[string]any{"step": fmt.Sprint(v)},
+					Metadata:  map[string]any{"thread_id": "thread-a", "execution_id": "exec-a"},
+					Timestamp: time.Now(),
+					Version:   v,
+				}
+				if err := s.Save(ctx, cp); err != nil {
+					t.Fatalf("Failed to save checkpoint: %v", err)
+				}
+				source = append(source, cp)
+			}
+
+			forked, err := store.ForkThread(ctx, s, "thread-a", "cp-3", "thread-b")
+			if err != nil {
+				t.Fatalf("ForkThread failed: %v", err)
+			}
+			if len(forked) != 3 {
+				t.Fatalf("Expected 3 forked checkpoints, got %d", len(forked))
+			}
+
+			chain, err := s.ListByThread(ctx, "thread-b")
+			if err != nil {
+				t.Fatalf("Failed to list forked thread: %v", err)
+			}
+			if len(chain) != 3 {
+				t.Fatalf("Expected 3 checkpoints in forked thread, got %d", len(chain))
+			}
+			for i, cp := range chain {
+				if cp.ID == source[i].ID {
+					t.Errorf("Forked checkpoint should have a new ID, got %s", cp.ID)
+				}
+				if cp.Version != source[i].Version || cp.NodeName != source[i].NodeName {
+					t.Errorf("Forked checkpoint %d = (%d, %s), want (%d, %s)", i, cp.Version, cp.NodeName, source[i].Version, source[i].NodeName)
+				}
+				if cp.Metadata["thread_id"] != "thread-b" || cp.Metadata["forked_from"] != source[i].ID || cp.Metadata["source_thread_id"] != "thread-a" {
+					t.Errorf("Unexpected forked metadata: %v", cp.Metadata)
+				}
+			}
+
+			latest, err := s.GetLatestByThread(ctx, "thread-b")
+			if err != nil {
+				t.Fatalf("Failed to get latest forked checkpoint: %v", err)
+			}
+			if latest.NodeName != "step3" {
+				t.Errorf("Expected fork to end at step3, got %s", latest.NodeName)
+			}
+
+			// The source thread is untouched
+			original, err := s.ListByThread(ctx, "thread-a")
+			if err != nil {
+				t.Fatalf("Failed to list source thread: %v", err)
+			}
+			if len(original) != 4 {
+				t.Fatalf("Expected source thread to keep 4 checkpoints, got %d", len(original))
+			}
+			for i, cp := range original {
+				if cp.ID != source[i].ID || cp.Metadata["thread_id"] != "thread-a" {
+					t.Errorf("Source checkpoint %d was modified: %+v", i, cp)
+				}
+			}
+
+			if _, err := store.ForkThread(ctx, s, "thread-a", "cp-2", "thread-b"); err == nil {
+				t.Error("Expected error when forking into a thread that has checkpoints")
+			}
+			if _, err := store.ForkThread(ctx, s, "thread-a", "missing", "thread-c"); err == nil {
+				t.Error("Expected error for unknown checkpoint")
+			}
+			if _, err := store.ForkThread(ctx, s, "thread-a", "cp-2", "thread-a"); err == nil {
+				t.Error("Expected error when forking into the source thread")
+			}
+		})
+	}
+}
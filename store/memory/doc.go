@@ -1,2 +1,9 @@
 // Package memory provides in-memory checkpoint storage implementation.
+//
+// With MemoryOptions.TTL, checkpoints expire that long after their Timestamp.
+// Expired checkpoints are removed when accessed and by a periodic sweep, which
+// Close stops.
+//
+// NewMemoryCheckpointStoreWithCapacity bounds the number of checkpoints and
+// threads the store keeps, evicting the least recently used threads wholesale.
 package memory
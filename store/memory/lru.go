@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+// EvictionStats counts what a memory store with a capacity has evicted
+type EvictionStats struct {
+	// Threads is the number of threads evicted
+	Threads int64
+
+	// Checkpoints is the number of checkpoints evicted with them
+	Checkpoints int64
+}
+
+// lruKey identifies the group of checkpoints evicted together: the checkpoints of
+// a thread, or the checkpoints of an execution saved without a thread_id
+type lruKey struct {
+	thread    string
+	execution string
+}
+
+func keyOf(checkpoint *store.Checkpoint) lruKey {
+	if threadID, _ := checkpoint.Metadata["thread_id"].(string); threadID != "" {
+		return lruKey{thread: threadID}
+	}
+	execID, _ := checkpoint.Metadata["execution_id"].(string)
+	return lruKey{execution: execID}
+}
+
+// lruEntry is a group of checkpoints in the recently used list
+type lruEntry struct {
+	key lruKey
+	ids map[string]struct{}
+}
+
+// threadLRU orders the threads of a store from the most to the least recently
+// used. Reads touch threads with the store's read lock held, so it has a lock of
+// its own, which is always taken after the store's.
+type threadLRU struct {
+	mu      sync.Mutex
+	order   *list.List // of *lruEntry, most recently used first
+	entries map[lruKey]*list.Element
+	stats   EvictionStats
+}
+
+func newThreadLRU() *threadLRU {
+	return &threadLRU{order: list.New(), entries: make(map[lruKey]*list.Element)}
+}
+
+// add records checkpoint in its thread and makes the thread the most recently used
+func (l *threadLRU) add(checkpoint *store.Checkpoint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := keyOf(checkpoint)
+	element, ok := l.entries[key]
+	if !ok {
+		element = l.order.PushFront(&lruEntry{key: key, ids: make(map[string]struct{})})
+		l.entries[key] = element
+	}
+	element.Value.(*lruEntry).ids[checkpoint.ID] = struct{}{}
+	l.order.MoveToFront(element)
+}
+
+// remove forgets checkpoint, and its thread once it has no checkpoints left
+func (l *threadLRU) remove(checkpoint *store.Checkpoint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := keyOf(checkpoint)
+	element, ok := l.entries[key]
+	if !ok {
+		return
+	}
+	entry := element.Value.(*lruEntry)
+	delete(entry.ids, checkpoint.ID)
+	if len(entry.ids) == 0 {
+		l.order.Remove(element)
+		delete(l.entries, key)
+	}
+}
+
+// touch makes the thread of key the most recently used, if it has checkpoints
+func (l *threadLRU) touch(key lruKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		l.order.MoveToFront(element)
+	}
+}
+
+// len returns the number of threads
+func (l *threadLRU) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// oldest returns the IDs of the checkpoints of the least recently used thread
+// other than keep, and false if there is none
+func (l *threadLRU) oldest(keep lruKey) ([]string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for element := l.order.Back(); element != nil; element = element.Prev() {
+		entry := element.Value.(*lruEntry)
+		if entry.key == keep {
+			continue
+		}
+		ids := make([]string, 0, len(entry.ids))
+		for id := range entry.ids {
+			ids = append(ids, id)
+		}
+		return ids, true
+	}
+	return nil, false
+}
+
+// evicted counts a thread of checkpoints checkpoints as evicted
+func (l *threadLRU) evicted(checkpoints int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Threads++
+	l.stats.Checkpoints += int64(checkpoints)
+}
+
+func (l *threadLRU) evictions() EvictionStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
@@ -3,27 +3,170 @@ package memory
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/smallnest/langgraphgo/store"
 )
 
+// MemoryOptions configuration for the memory store
+type MemoryOptions struct {
+	// TTL expires checkpoints that long after their Timestamp, default 0 (no
+	// expiration). Expired checkpoints are never returned; they are removed when
+	// accessed and by a sweep every SweepInterval.
+	TTL time.Duration
+
+	// SweepInterval is how often expired checkpoints are removed, TTL if zero
+	SweepInterval time.Duration
+
+	// Now returns the current time, time.Now if nil. Tests set it to a fake clock.
+	Now func() time.Time
+
+	// MaxCheckpoints and MaxThreads bound the number of checkpoints and threads
+	// the store keeps, default 0 (no bound). Past a bound the least recently used
+	// threads are evicted, see NewMemoryCheckpointStoreWithCapacity.
+	MaxCheckpoints int
+	MaxThreads     int
+}
+
 // MemoryCheckpointStore provides in-memory checkpoint storage
 type MemoryCheckpointStore struct {
-	checkpoints    map[string]*store.Checkpoint // id -> checkpoint
-	threadIndex    map[string][]string          // thread_id -> []checkpoint IDs
-	executionIndex map[string][]string          // execution_id -> []checkpoint IDs
+	checkpoints    map[string]*store.Checkpoint              // id -> checkpoint
+	threadIndex    map[string][]string                       // thread_id -> []checkpoint IDs
+	executionIndex map[string][]string                       // execution_id -> []checkpoint IDs
+	latestIndex    map[string]string                         // thread_id -> ID of the checkpoint with the highest version
+	writes         map[string]map[string]*store.PendingWrite // checkpoint ID -> node -> pending write
 	mutex          sync.RWMutex
+
+	ttl time.Duration
+	now func() time.Time
+
+	// lru orders the threads for eviction, which happens only when a bound is set
+	lru            *threadLRU
+	maxCheckpoints int
+	maxThreads     int
+
+	// stop ends the sweep goroutine, which runs when ttl is set
+	stop      chan struct{}
+	closeOnce sync.Once
 }
 
 // NewMemoryCheckpointStore creates a new in-memory checkpoint store
 func NewMemoryCheckpointStore() store.CheckpointStore {
-	return &MemoryCheckpointStore{
+	return NewMemoryCheckpointStoreWithOptions(MemoryOptions{})
+}
+
+// NewMemoryCheckpointStoreWithCapacity creates an in-memory checkpoint store that
+// keeps at most maxCheckpoints checkpoints in at most maxThreads threads, where
+// zero means no bound. Saving past a bound evicts the least recently saved or
+// read threads, each with all of its checkpoints, so the threads that remain can
+// still be resumed. The thread being saved to is never evicted, so a single
+// thread with more than maxCheckpoints checkpoints exceeds the bound. Checkpoints
+// without a thread_id are evicted with the others of their execution. Evictions
+// counts what was evicted.
+//
+// Example:
+//
+//	checkpoints := memory.NewMemoryCheckpointStoreWithCapacity(10000, 1000)
+//	stats := checkpoints.(*memory.MemoryCheckpointStore).Evictions()
+func NewMemoryCheckpointStoreWithCapacity(maxCheckpoints, maxThreads int) store.CheckpointStore {
+	return NewMemoryCheckpointStoreWithOptions(MemoryOptions{MaxCheckpoints: maxCheckpoints, MaxThreads: maxThreads})
+}
+
+// NewMemoryCheckpointStoreWithOptions creates an in-memory checkpoint store with
+// custom options. With a TTL, Close stops the sweep of expired checkpoints.
+//
+// Example:
+//
+//	// Forget checkpoints an hour after they were saved
+//	store := memory.NewMemoryCheckpointStoreWithOptions(memory.MemoryOptions{
+//	    TTL: time.Hour,
+//	})
+//	defer store.(*memory.MemoryCheckpointStore).Close()
+func NewMemoryCheckpointStoreWithOptions(opts MemoryOptions) store.CheckpointStore {
+	m := &MemoryCheckpointStore{
 		checkpoints:    make(map[string]*store.Checkpoint),
 		threadIndex:    make(map[string][]string),
 		executionIndex: make(map[string][]string),
+		latestIndex:    make(map[string]string),
+		writes:         make(map[string]map[string]*store.PendingWrite),
+		ttl:            opts.TTL,
+		now:            opts.Now,
+		maxCheckpoints: opts.MaxCheckpoints,
+		maxThreads:     opts.MaxThreads,
+	}
+	if m.maxCheckpoints > 0 || m.maxThreads > 0 {
+		m.lru = newThreadLRU()
 	}
+	if m.now == nil {
+		m.now = time.Now
+	}
+
+	if m.ttl > 0 {
+		interval := opts.SweepInterval
+		if interval <= 0 {
+			interval = m.ttl
+		}
+		m.stop = make(chan struct{})
+		go m.sweep(interval, m.stop)
+	}
+
+	return m
+}
+
+// Close stops the sweep of expired checkpoints. The store stays usable, and
+// expired checkpoints are still removed when accessed.
+func (m *MemoryCheckpointStore) Close() error {
+	if m.stop != nil {
+		m.closeOnce.Do(func() { close(m.stop) })
+	}
+	return nil
+}
+
+// sweep removes the expired checkpoints every interval until stop is closed
+func (m *MemoryCheckpointStore) sweep(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.expire(m.checkpointIDs)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// expire removes the expired checkpoints among the IDs that ids returns, which
+// is called with the lock held. It does nothing without a TTL.
+func (m *MemoryCheckpointStore) expire(ids func() []string) {
+	if m.ttl <= 0 {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.now()
+	// Deleting changes the index slices ids may return
+	for _, id := range slices.Clone(ids()) {
+		if checkpoint, ok := m.checkpoints[id]; ok && now.Sub(checkpoint.Timestamp) >= m.ttl {
+			m.delete(id)
+		}
+	}
+}
+
+// checkpointIDs returns the IDs of every checkpoint
+func (m *MemoryCheckpointStore) checkpointIDs() []string {
+	return slices.Collect(maps.Keys(m.checkpoints))
+}
+
+// threadIDs returns a function returning the IDs of the checkpoints of threadID,
+// for expire
+func (m *MemoryCheckpointStore) threadIDs(threadID string) func() []string {
+	return func() []string { return m.threadIndex[threadID] }
 }
 
 // Save implements CheckpointStore interface
@@ -31,6 +174,13 @@ func (m *MemoryCheckpointStore) Save(_ context.Context, checkpoint *store.Checkp
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	if m.lru != nil {
+		if previous, ok := m.checkpoints[checkpoint.ID]; ok {
+			m.lru.remove(previous)
+		}
+		m.lru.add(checkpoint)
+	}
+
 	// Store checkpoint
 	m.checkpoints[checkpoint.ID] = checkpoint
 
@@ -42,13 +192,49 @@ func (m *MemoryCheckpointStore) Save(_ context.Context, checkpoint *store.Checkp
 	// Update thread_id index
 	if threadID, ok := checkpoint.Metadata["thread_id"].(string); ok && threadID != "" {
 		m.threadIndex[threadID] = append(m.threadIndex[threadID], checkpoint.ID)
+
+		if latestID := m.latestIndex[threadID]; latestID == checkpoint.ID {
+			// Saved again, possibly with a lower version
+			m.updateLatest(threadID)
+		} else if latest, ok := m.checkpoints[latestID]; !ok || checkpoint.Version >= latest.Version {
+			m.latestIndex[threadID] = checkpoint.ID
+		}
 	}
 
+	if m.lru != nil {
+		m.evict(keyOf(checkpoint))
+	}
 	return nil
 }
 
+// evict removes the least recently used threads other than keep, the thread
+// being saved to, until the store is within its bounds, with the lock held
+func (m *MemoryCheckpointStore) evict(keep lruKey) {
+	for (m.maxCheckpoints > 0 && len(m.checkpoints) > m.maxCheckpoints) || (m.maxThreads > 0 && m.lru.len() > m.maxThreads) {
+		ids, ok := m.lru.oldest(keep)
+		if !ok {
+			return
+		}
+		for _, id := range ids {
+			m.delete(id)
+		}
+		m.lru.evicted(len(ids))
+	}
+}
+
+// Evictions returns the number of threads and checkpoints evicted to keep the
+// store within the bounds of NewMemoryCheckpointStoreWithCapacity
+func (m *MemoryCheckpointStore) Evictions() EvictionStats {
+	if m.lru == nil {
+		return EvictionStats{}
+	}
+	return m.lru.evictions()
+}
+
 // Load implements CheckpointStore interface
 func (m *MemoryCheckpointStore) Load(_ context.Context, checkpointID string) (*store.Checkpoint, error) {
+	m.expire(func() []string { return []string{checkpointID} })
+
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -56,12 +242,22 @@ func (m *MemoryCheckpointStore) Load(_ context.Context, checkpointID string) (*s
 	if !exists {
 		return nil, fmt.Errorf("checkpoint not found: %s", checkpointID)
 	}
+	if m.lru != nil {
+		m.lru.touch(keyOf(checkpoint))
+	}
 
 	return checkpoint, nil
 }
 
 // List implements CheckpointStore interface
-func (m *MemoryCheckpointStore) List(_ context.Context, executionID string) ([]*store.Checkpoint, error) {
+func (m *MemoryCheckpointStore) List(ctx context.Context, executionID string) ([]*store.Checkpoint, error) {
+	return m.ListPage(ctx, executionID, store.ListOptions{})
+}
+
+// ListPage returns the page of the checkpoints List returns that opts selects
+func (m *MemoryCheckpointStore) ListPage(_ context.Context, executionID string, opts store.ListOptions) ([]*store.Checkpoint, error) {
+	m.expire(m.checkpointIDs)
+
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -83,11 +279,13 @@ func (m *MemoryCheckpointStore) List(_ context.Context, executionID string) ([]*
 		return checkpoints[i].Version < checkpoints[j].Version
 	})
 
-	return checkpoints, nil
+	return opts.Page(checkpoints), nil
 }
 
 // ListByThread returns all checkpoints for a specific thread_id
 func (m *MemoryCheckpointStore) ListByThread(_ context.Context, threadID string) ([]*store.Checkpoint, error) {
+	m.expire(m.threadIDs(threadID))
+
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -95,6 +293,9 @@ func (m *MemoryCheckpointStore) ListByThread(_ context.Context, threadID string)
 	if !exists {
 		return []*store.Checkpoint{}, nil
 	}
+	if m.lru != nil {
+		m.lru.touch(lruKey{thread: threadID})
+	}
 
 	checkpoints := make([]*store.Checkpoint, 0, len(ids))
 	for _, id := range ids {
@@ -113,23 +314,36 @@ func (m *MemoryCheckpointStore) ListByThread(_ context.Context, threadID string)
 
 // GetLatestByThread returns the latest checkpoint for a thread_id
 func (m *MemoryCheckpointStore) GetLatestByThread(_ context.Context, threadID string) (*store.Checkpoint, error) {
+	m.expire(m.threadIDs(threadID))
+
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	ids, exists := m.threadIndex[threadID]
-	if !exists || len(ids) == 0 {
+	latest, exists := m.checkpoints[m.latestIndex[threadID]]
+	if !exists {
 		return nil, fmt.Errorf("no checkpoints found for thread: %s", threadID)
 	}
+	if m.lru != nil {
+		m.lru.touch(lruKey{thread: threadID})
+	}
 
+	return latest, nil
+}
+
+// updateLatest points the latest index of threadID at its checkpoint with the
+// highest version, or removes it when the thread has no checkpoints left
+func (m *MemoryCheckpointStore) updateLatest(threadID string) {
 	var latest *store.Checkpoint
-	for _, id := range ids {
-		cp := m.checkpoints[id]
-		if latest == nil || cp.Version > latest.Version {
+	for _, id := range m.threadIndex[threadID] {
+		if cp, ok := m.checkpoints[id]; ok && (latest == nil || cp.Version >= latest.Version) {
 			latest = cp
 		}
 	}
-
-	return latest, nil
+	if latest == nil {
+		delete(m.latestIndex, threadID)
+		return
+	}
+	m.latestIndex[threadID] = latest.ID
 }
 
 // Delete implements CheckpointStore interface
@@ -137,9 +351,18 @@ func (m *MemoryCheckpointStore) Delete(_ context.Context, checkpointID string) e
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	m.delete(checkpointID)
+	return nil
+}
+
+// delete removes checkpointID and its index entries, with the lock held
+func (m *MemoryCheckpointStore) delete(checkpointID string) {
 	checkpoint, exists := m.checkpoints[checkpointID]
 	if !exists {
-		return nil
+		return
+	}
+	if m.lru != nil {
+		m.lru.remove(checkpoint)
 	}
 
 	// Remove from indexes
@@ -154,19 +377,21 @@ func (m *MemoryCheckpointStore) Delete(_ context.Context, checkpointID string) e
 		}
 	}
 
-	if threadID, ok := checkpoint.Metadata["thread_id"].(string); ok {
-		if ids, ok := m.threadIndex[threadID]; ok {
-			for i, id := range ids {
-				if id == checkpointID {
-					m.threadIndex[threadID] = append(ids[:i], ids[i+1:]...)
-					break
-				}
+	threadID, _ := checkpoint.Metadata["thread_id"].(string)
+	if ids, ok := m.threadIndex[threadID]; ok {
+		for i, id := range ids {
+			if id == checkpointID {
+				m.threadIndex[threadID] = append(ids[:i], ids[i+1:]...)
+				break
 			}
 		}
 	}
 
 	delete(m.checkpoints, checkpointID)
-	return nil
+	delete(m.writes, checkpointID)
+	if m.latestIndex[threadID] == checkpointID {
+		m.updateLatest(threadID)
+	}
 }
 
 // Clear implements CheckpointStore interface
@@ -191,6 +416,9 @@ func (m *MemoryCheckpointStore) Clear(_ context.Context, executionID string) err
 	// Delete from indexes and main map
 	for _, id := range idsToDelete {
 		checkpoint := m.checkpoints[id]
+		if m.lru != nil {
+			m.lru.remove(checkpoint)
+		}
 
 		// Remove from execution_index
 		if execID, ok := checkpoint.Metadata["execution_id"].(string); ok {
@@ -205,19 +433,134 @@ func (m *MemoryCheckpointStore) Clear(_ context.Context, executionID string) err
 		}
 
 		// Remove from thread_index
-		if threadID, ok := checkpoint.Metadata["thread_id"].(string); ok {
-			if ids, ok := m.threadIndex[threadID]; ok {
-				for i, cid := range ids {
-					if cid == id {
-						m.threadIndex[threadID] = append(ids[:i], ids[i+1:]...)
-						break
-					}
+		threadID, _ := checkpoint.Metadata["thread_id"].(string)
+		if ids, ok := m.threadIndex[threadID]; ok {
+			for i, cid := range ids {
+				if cid == id {
+					m.threadIndex[threadID] = append(ids[:i], ids[i+1:]...)
+					break
 				}
 			}
 		}
 
 		delete(m.checkpoints, id)
+		delete(m.writes, id)
+		if m.latestIndex[threadID] == id {
+			m.updateLatest(threadID)
+		}
+	}
+
+	return nil
+}
+
+// ListThreads returns the threads that have checkpoints, most recently updated first
+func (m *MemoryCheckpointStore) ListThreads(_ context.Context, opts store.ListThreadsOptions) ([]store.ThreadInfo, error) {
+	m.expire(m.checkpointIDs)
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	threads := make([]store.ThreadInfo, 0, len(m.latestIndex))
+	for threadID, latestID := range m.latestIndex {
+		latest := m.checkpoints[latestID]
+		count := 0
+		seen := make(map[string]bool)
+		for _, id := range m.threadIndex[threadID] {
+			if _, ok := m.checkpoints[id]; ok && !seen[id] {
+				seen[id] = true
+				count++
+			}
+		}
+		threads = append(threads, store.ThreadInfo{
+			ThreadID:        threadID,
+			CheckpointCount: count,
+			LatestVersion:   latest.Version,
+			LastUpdated:     latest.Timestamp,
+		})
 	}
 
+	return store.SortThreads(threads, opts), nil
+}
+
+// DeleteThread removes every checkpoint of threadID and its index entries
+func (m *MemoryCheckpointStore) DeleteThread(_ context.Context, threadID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, id := range m.threadIndex[threadID] {
+		checkpoint, ok := m.checkpoints[id]
+		if !ok {
+			continue
+		}
+		if m.lru != nil {
+			m.lru.remove(checkpoint)
+		}
+
+		if execID, ok := checkpoint.Metadata["execution_id"].(string); ok {
+			m.executionIndex[execID] = slices.DeleteFunc(m.executionIndex[execID], func(cid string) bool {
+				return cid == id
+			})
+		}
+
+		delete(m.checkpoints, id)
+		delete(m.writes, id)
+	}
+
+	delete(m.threadIndex, threadID)
+	delete(m.latestIndex, threadID)
+	return nil
+}
+
+// ListByMetadata returns the checkpoints whose metadata matches filters, sorted by
+// timestamp. It scans every checkpoint.
+func (m *MemoryCheckpointStore) ListByMetadata(_ context.Context, filters map[string]any, opts store.ListOptions) ([]*store.Checkpoint, error) {
+	m.expire(m.checkpointIDs)
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	checkpoints := make([]*store.Checkpoint, 0, len(m.checkpoints))
+	for _, checkpoint := range m.checkpoints {
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return store.FilterByMetadata(checkpoints, filters, opts), nil
+}
+
+// SaveWrite saves the pending write of a node, replacing its previous write for
+// the same checkpoint
+func (m *MemoryCheckpointStore) SaveWrite(_ context.Context, write *store.PendingWrite) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	writes, ok := m.writes[write.CheckpointID]
+	if !ok {
+		writes = make(map[string]*store.PendingWrite)
+		m.writes[write.CheckpointID] = writes
+	}
+	writes[write.Node] = write
+	return nil
+}
+
+// ListWrites returns the pending writes of checkpointID sorted by node name
+func (m *MemoryCheckpointStore) ListWrites(_ context.Context, checkpointID string) ([]*store.PendingWrite, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	writes := m.writes[checkpointID]
+	nodes := slices.Sorted(maps.Keys(writes))
+	result := make([]*store.PendingWrite, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, writes[node])
+	}
+	return result, nil
+}
+
+// DeleteWrites removes the pending writes of checkpointID
+func (m *MemoryCheckpointStore) DeleteWrites(_ context.Context, checkpointID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.writes, checkpointID)
 	return nil
 }
@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -495,3 +496,299 @@ func TestMemoryCheckpointStore_ThreadSafety(t *testing.T) {
 		}
 	}
 }
+
+func TestMemoryCheckpointStore_GetLatestByThread(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ms := NewMemoryCheckpointStore()
+	save := func(id string, version int) {
+		t.Helper()
+		err := ms.Save(ctx, &store.Checkpoint{
+			ID:        id,
+			Metadata:  map[string]any{"thread_id": "thread"},
+			Timestamp: time.Now(),
+			Version:   version,
+		})
+		if err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	save("cp-1", 1)
+	save("cp-2", 2)
+
+	// Saving the latest checkpoint again with a lower version moves the pointer
+	save("cp-2", 0)
+	latest, err := ms.GetLatestByThread(ctx, "thread")
+	if err != nil {
+		t.Fatalf("GetLatestByThread failed: %v", err)
+	}
+	if latest.ID != "cp-1" {
+		t.Errorf("Expected cp-1, got %s (version %d)", latest.ID, latest.Version)
+	}
+
+	if err := ms.Clear(ctx, "thread"); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, err := ms.GetLatestByThread(ctx, "thread"); err == nil {
+		t.Error("Expected an error after clearing the thread")
+	}
+}
+
+// fakeClock is a clock the tests advance by hand
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestMemoryCheckpointStore_TTL(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// saveAt saves a checkpoint of thread with the current time of clock
+	saveAt := func(t *testing.T, s store.CheckpointStore, clock *fakeClock, id, thread string, version int) {
+		t.Helper()
+		cp := &store.Checkpoint{
+			ID:        id,
+			Timestamp: clock.Now(),
+			Version:   version,
+			Metadata:  map[string]any{"thread_id": thread, "execution_id": thread},
+		}
+		if err := s.Save(ctx, cp); err != nil {
+			t.Fatalf("Failed to save %s: %v", id, err)
+		}
+	}
+	count := func(m *MemoryCheckpointStore) int {
+		m.mutex.RLock()
+		defer m.mutex.RUnlock()
+		return len(m.checkpoints)
+	}
+
+	t.Run("expires on access", func(t *testing.T) {
+		t.Parallel()
+		clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		s := NewMemoryCheckpointStoreWithOptions(MemoryOptions{TTL: time.Hour, SweepInterval: time.Hour, Now: clock.Now})
+		m := s.(*MemoryCheckpointStore)
+		defer m.Close()
+
+		saveAt(t, s, clock, "cp-1", "thread", 1)
+		saveAt(t, s, clock, "old-1", "old", 1)
+		clock.Advance(30 * time.Minute)
+		saveAt(t, s, clock, "cp-2", "thread", 2)
+		clock.Advance(40 * time.Minute)
+
+		if _, err := s.Load(ctx, "cp-1"); err == nil {
+			t.Error("Expected expired checkpoint cp-1 not to load")
+		}
+		checkpoints, err := s.ListByThread(ctx, "thread")
+		if err != nil {
+			t.Fatalf("ListByThread failed: %v", err)
+		}
+		if len(checkpoints) != 1 || checkpoints[0].ID != "cp-2" {
+			t.Errorf("Expected only cp-2, got %v", checkpoints)
+		}
+		if checkpoints, _ := s.List(ctx, "old"); len(checkpoints) != 0 {
+			t.Errorf("Expected no checkpoints of thread old, got %d", len(checkpoints))
+		}
+		if _, err := s.GetLatestByThread(ctx, "old"); err == nil {
+			t.Error("Expected thread old to have no latest checkpoint")
+		}
+		if got := count(m); got != 1 {
+			t.Errorf("Expected expired checkpoints to be removed, %d left", got)
+		}
+
+		clock.Advance(time.Hour)
+		if _, err := s.GetLatestByThread(ctx, "thread"); err == nil {
+			t.Error("Expected every checkpoint of thread to have expired")
+		}
+		threads, err := store.ListThreads(ctx, s, store.ListThreadsOptions{})
+		if err != nil || len(threads) != 0 {
+			t.Errorf("Expected no threads, got %v (%v)", threads, err)
+		}
+	})
+
+	t.Run("sweeps in the background", func(t *testing.T) {
+		t.Parallel()
+		clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		s := NewMemoryCheckpointStoreWithOptions(MemoryOptions{TTL: time.Hour, SweepInterval: time.Millisecond, Now: clock.Now})
+		m := s.(*MemoryCheckpointStore)
+		defer m.Close()
+
+		saveAt(t, s, clock, "cp-1", "thread", 1)
+		saveAt(t, s, clock, "cp-2", "thread", 2)
+		time.Sleep(10 * time.Millisecond)
+		if got := count(m); got != 2 {
+			t.Fatalf("Expected checkpoints within the TTL to be kept, %d left", got)
+		}
+
+		clock.Advance(time.Hour)
+		deadline := time.Now().Add(time.Second)
+		for count(m) > 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := count(m); got != 0 {
+			t.Errorf("Expected the sweep to remove expired checkpoints, %d left", got)
+		}
+	})
+
+	t.Run("no TTL", func(t *testing.T) {
+		t.Parallel()
+		clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		s := NewMemoryCheckpointStoreWithOptions(MemoryOptions{Now: clock.Now})
+		saveAt(t, s, clock, "cp-1", "thread", 1)
+		clock.Advance(365 * 24 * time.Hour)
+		if _, err := s.Load(ctx, "cp-1"); err != nil {
+			t.Errorf("Expected checkpoints never to expire without a TTL: %v", err)
+		}
+	})
+}
+
+func TestMemoryCheckpointStore_Writes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := NewMemoryCheckpointStore()
+
+	for _, id := range []string{"cp-1", "cp-2"} {
+		cp := &store.Checkpoint{ID: id, Version: 1, Metadata: map[string]any{"thread_id": "thread"}}
+		if err := s.Save(ctx, cp); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	for _, node := range []string{"b", "a", "b"} {
+		write := &store.PendingWrite{CheckpointID: "cp-1", Node: node, Value: node + "-result"}
+		if err := store.SaveWrite(ctx, s, write); err != nil {
+			t.Fatalf("SaveWrite failed: %v", err)
+		}
+	}
+	if err := store.SaveWrite(ctx, s, &store.PendingWrite{CheckpointID: "cp-2", Node: "a"}); err != nil {
+		t.Fatalf("SaveWrite failed: %v", err)
+	}
+
+	writes, err := store.ListWrites(ctx, s, "cp-1")
+	if err != nil {
+		t.Fatalf("ListWrites failed: %v", err)
+	}
+	if len(writes) != 2 || writes[0].Node != "a" || writes[1].Value != "b-result" {
+		t.Errorf("Expected the writes of a and b, got %v", writes)
+	}
+
+	// Deleting a checkpoint deletes its writes
+	if err := s.Delete(ctx, "cp-1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if writes, _ := store.ListWrites(ctx, s, "cp-1"); len(writes) != 0 {
+		t.Errorf("Expected no writes for a deleted checkpoint, got %v", writes)
+	}
+
+	if err := store.DeleteWrites(ctx, s, "cp-2"); err != nil {
+		t.Fatalf("DeleteWrites failed: %v", err)
+	}
+	if writes, _ := store.ListWrites(ctx, s, "cp-2"); len(writes) != 0 {
+		t.Errorf("Expected no writes after DeleteWrites, got %v", writes)
+	}
+}
+
+func TestMemoryCheckpointStore_Capacity(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	save := func(t *testing.T, s store.CheckpointStore, threadID string, version int) {
+		t.Helper()
+		cp := &store.Checkpoint{
+			ID:       fmt.Sprintf("%s-%d", threadID, version),
+			Version:  version,
+			Metadata: map[string]any{"thread_id": threadID, "execution_id": "exec"},
+		}
+		if err := s.Save(ctx, cp); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	t.Run("evicts least recently used threads", func(t *testing.T) {
+		t.Parallel()
+		s := NewMemoryCheckpointStoreWithCapacity(0, 2)
+		save(t, s, "a", 1)
+		save(t, s, "b", 1)
+		// Reading a makes b the least recently used
+		if _, err := s.GetLatestByThread(ctx, "a"); err != nil {
+			t.Fatalf("GetLatestByThread failed: %v", err)
+		}
+		save(t, s, "c", 1)
+
+		if _, err := s.GetLatestByThread(ctx, "b"); err == nil {
+			t.Error("Expected thread b to be evicted")
+		}
+		for _, threadID := range []string{"a", "c"} {
+			if _, err := s.GetLatestByThread(ctx, threadID); err != nil {
+				t.Errorf("Expected thread %s to be kept: %v", threadID, err)
+			}
+		}
+		if stats := s.(*MemoryCheckpointStore).Evictions(); stats != (EvictionStats{Threads: 1, Checkpoints: 1}) {
+			t.Errorf("Expected one eviction, got %+v", stats)
+		}
+	})
+
+	t.Run("never evicts the thread being saved to", func(t *testing.T) {
+		t.Parallel()
+		s := NewMemoryCheckpointStoreWithCapacity(3, 0)
+		save(t, s, "a", 1)
+		for version := 1; version <= 5; version++ {
+			save(t, s, "b", version)
+		}
+
+		checkpoints, err := s.ListByThread(ctx, "b")
+		if err != nil || len(checkpoints) != 5 {
+			t.Errorf("Expected the 5 checkpoints of b, got %d (%v)", len(checkpoints), err)
+		}
+		if checkpoints, _ := s.ListByThread(ctx, "a"); len(checkpoints) != 0 {
+			t.Errorf("Expected thread a to be evicted, got %v", checkpoints)
+		}
+	})
+
+	t.Run("soak", func(t *testing.T) {
+		t.Parallel()
+		s := NewMemoryCheckpointStoreWithCapacity(0, 1000)
+		for i := range 10000 {
+			threadID := fmt.Sprintf("thread-%d", i)
+			for version := 1; version <= 3; version++ {
+				save(t, s, threadID, version)
+			}
+		}
+
+		m := s.(*MemoryCheckpointStore)
+		threads, err := m.ListThreads(ctx, store.ListThreadsOptions{})
+		if err != nil {
+			t.Fatalf("ListThreads failed: %v", err)
+		}
+		if len(threads) != 1000 {
+			t.Errorf("Expected 1000 threads, got %d", len(threads))
+		}
+		// Threads are kept whole
+		for _, thread := range threads {
+			if thread.CheckpointCount != 3 {
+				t.Fatalf("Expected thread %s to keep its 3 checkpoints, got %d", thread.ThreadID, thread.CheckpointCount)
+			}
+		}
+		if len(m.checkpoints) != 3000 {
+			t.Errorf("Expected 3000 checkpoints, got %d", len(m.checkpoints))
+		}
+		if stats := m.Evictions(); stats != (EvictionStats{Threads: 9000, Checkpoints: 27000}) {
+			t.Errorf("Expected 9000 threads evicted, got %+v", stats)
+		}
+		if _, err := m.GetLatestByThread(ctx, "thread-9999"); err != nil {
+			t.Errorf("Expected the latest thread to be kept: %v", err)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// MetadataLister is implemented by stores that can query checkpoints by metadata,
+// see ListByMetadata.
+type MetadataLister interface {
+	// ListByMetadata returns the page opts selects of the checkpoints whose
+	// metadata has every key of filters with an equal value, sorted by timestamp.
+	// Descending lists them from the most recent.
+	ListByMetadata(ctx context.Context, filters map[string]any, opts ListOptions) ([]*Checkpoint, error)
+}
+
+// ListByMetadata returns the checkpoints of any thread or execution whose metadata
+// matches filters, sorted by timestamp, and the page of them opts selects. Values
+// are compared as JSON, so 42 matches a 42 loaded from a JSON store as float64.
+//
+// The Redis, SQLite and Postgres stores query their indexes. The memory and file
+// stores, and other stores implementing ThreadStore, scan every checkpoint; other
+// stores return an error.
+//
+// Example:
+//
+//	// The latest checkpoint of user 42 on the onboarding graph
+//	page, err := store.ListByMetadata(ctx, checkpoints,
+//	    map[string]any{"user_id": 42, "graph_name": "onboarding"},
+//	    store.ListOptions{Limit: 1, Descending: true})
+func ListByMetadata(ctx context.Context, s CheckpointStore, filters map[string]any, opts ListOptions) ([]*Checkpoint, error) {
+	if lister, ok := s.(MetadataLister); ok {
+		return lister.ListByMetadata(ctx, filters, opts)
+	}
+	if _, ok := s.(ThreadStore); !ok {
+		return nil, fmt.Errorf("store %T cannot list checkpoints by metadata", s)
+	}
+
+	threads, err := ListThreads(ctx, s, ListThreadsOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints []*Checkpoint
+	for _, thread := range threads {
+		threadCheckpoints, err := s.ListByThread(ctx, thread.ThreadID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list checkpoints for thread %s: %w", thread.ThreadID, err)
+		}
+		checkpoints = append(checkpoints, threadCheckpoints...)
+	}
+	return FilterByMetadata(checkpoints, filters, opts), nil
+}
+
+// FilterByMetadata returns the checkpoints matching filters sorted by timestamp,
+// then version and ID, and the page of them opts selects. Stores without metadata
+// indexes implement ListByMetadata with it.
+func FilterByMetadata(checkpoints []*Checkpoint, filters map[string]any, opts ListOptions) []*Checkpoint {
+	matched := make([]*Checkpoint, 0, len(checkpoints))
+	for _, cp := range checkpoints {
+		if MatchMetadata(cp.Metadata, filters) {
+			matched = append(matched, cp)
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b *Checkpoint) int {
+		return cmp.Or(
+			a.Timestamp.Compare(b.Timestamp),
+			cmp.Compare(a.Version, b.Version),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+	return opts.Page(matched)
+}
+
+// MatchMetadata reports whether metadata has every key of filters with an equal
+// value. Values that are not deeply equal are compared by their JSON encoding.
+func MatchMetadata(metadata, filters map[string]any) bool {
+	for key, want := range filters {
+		got, ok := metadata[key]
+		if !ok || !metadataValueEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+func metadataValueEqual(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(aJSON) == string(bJSON)
+}
@@ -0,0 +1,123 @@
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/sqlite"
+)
+
+// threadsOnly exposes the ThreadStore of a store but not its MetadataLister
+type threadsOnly struct {
+	store.CheckpointStore
+	store.ThreadStore
+}
+
+func TestListByMetadata(t *testing.T) {
+	t.Parallel()
+
+	stores := testStores()
+	stores["SQLite"] = func(t *testing.T) store.CheckpointStore {
+		s, err := sqlite.NewSqliteCheckpointStore(sqlite.SqliteOptions{Path: filepath.Join(t.TempDir(), "checkpoints.db")})
+		if err != nil {
+			t.Fatalf("Failed to create sqlite store: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+	stores["Scan"] = func(t *testing.T) store.CheckpointStore {
+		s := testStores()["Memory"](t)
+		return threadsOnly{s, s.(store.ThreadStore)}
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			s := newStore(t)
+
+			now := time.Now().Truncate(time.Millisecond)
+			saved := []struct {
+				id     string
+				thread string
+				user   int
+				graph  string
+				offset time.Duration
+			}{
+				{"a-1", "a", 42, "onboarding", 0},
+				{"b-1", "b", 42, "onboarding", time.Minute},
+				{"c-1", "c", 42, "billing", 2 * time.Minute},
+				{"d-1", "d", 7, "onboarding", 3 * time.Minute},
+				{"a-2", "a", 42, "onboarding", 4 * time.Minute},
+			}
+			for i, cp := range saved {
+				err := s.Save(ctx, &store.Checkpoint{
+					ID:    cp.id,
+					State: map[string]any{"step": i},
+					Metadata: map[string]any{
+						"thread_id":    cp.thread,
+						"execution_id": cp.thread,
+						"user_id":      cp.user,
+						"graph_name":   cp.graph,
+					},
+					Timestamp: now.Add(cp.offset),
+					Version:   i + 1,
+				})
+				if err != nil {
+					t.Fatalf("Save failed: %v", err)
+				}
+			}
+
+			ids := func(filters map[string]any, opts store.ListOptions) string {
+				t.Helper()
+				checkpoints, err := store.ListByMetadata(ctx, s, filters, opts)
+				if err != nil {
+					t.Fatalf("ListByMetadata failed: %v", err)
+				}
+				var ids []string
+				for _, cp := range checkpoints {
+					ids = append(ids, cp.ID)
+				}
+				return fmt.Sprint(ids)
+			}
+
+			onboarding42 := map[string]any{"user_id": 42, "graph_name": "onboarding"}
+			if got := ids(onboarding42, store.ListOptions{}); got != "[a-1 b-1 a-2]" {
+				t.Errorf("Expected [a-1 b-1 a-2], got %s", got)
+			}
+			if got := ids(onboarding42, store.ListOptions{Limit: 1, Descending: true}); got != "[a-2]" {
+				t.Errorf("Expected the latest to be [a-2], got %s", got)
+			}
+			if got := ids(map[string]any{"user_id": 42.0}, store.ListOptions{Offset: 1, Limit: 2}); got != "[b-1 c-1]" {
+				t.Errorf("Expected a float filter to match with the page [b-1 c-1], got %s", got)
+			}
+			if got := ids(map[string]any{"user_id": "42"}, store.ListOptions{}); got != "[]" {
+				t.Errorf("Expected a string filter not to match numbers, got %s", got)
+			}
+			if got := ids(map[string]any{"user_id": 42, "graph_name": "missing"}, store.ListOptions{}); got != "[]" {
+				t.Errorf("Expected no matches, got %s", got)
+			}
+
+			if err := s.Delete(ctx, "a-2"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if got := ids(onboarding42, store.ListOptions{Limit: 1, Descending: true}); got != "[b-1]" {
+				t.Errorf("Expected the latest after deleting a-2 to be [b-1], got %s", got)
+			}
+		})
+	}
+}
+
+func TestListByMetadata_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	s := listOnly{testStores()["Memory"](t)}
+	if _, err := store.ListByMetadata(context.Background(), s, map[string]any{"user_id": 42}, store.ListOptions{}); err == nil {
+		t.Error("Expected an error for a store that can neither query metadata nor list threads")
+	}
+}
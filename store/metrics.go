@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// MetricsRecorder receives the duration and outcome of every operation of an
+// InstrumentedStore. The store/prometheus package provides one exporting
+// Prometheus metrics.
+type MetricsRecorder interface {
+	// ObserveDuration records that operation op took d and failed with err, nil
+	// if it succeeded
+	ObserveDuration(op string, d time.Duration, err error)
+}
+
+// Operation names an InstrumentedStore reports to its MetricsRecorder
+const (
+	OpSave              = "save"
+	OpLoad              = "load"
+	OpList              = "list"
+	OpListPage          = "list_page"
+	OpListByThread      = "list_by_thread"
+	OpGetLatestByThread = "get_latest_by_thread"
+	OpListByMetadata    = "list_by_metadata"
+	OpListThreads       = "list_threads"
+	OpDelete            = "delete"
+	OpDeleteThread      = "delete_thread"
+	OpClear             = "clear"
+	OpPing              = "ping"
+	OpSaveWrite         = "save_write"
+	OpListWrites        = "list_writes"
+	OpDeleteWrites      = "delete_writes"
+)
+
+// InstrumentedStore is a CheckpointStore that reports the duration and error of
+// every operation of another store to a MetricsRecorder.
+type InstrumentedStore struct {
+	inner    CheckpointStore
+	recorder MetricsRecorder
+}
+
+// NewInstrumentedStore returns a store that passes every operation on to inner
+// and reports it to recorder under one of the Op names. Operations inner doesn't
+// implement, such as ListThreads, fall back like the package-level functions of
+// the same name, and their errors are reported too.
+//
+// Example:
+//
+//	recorder, err := prometheus.NewRecorder(nil)
+//	if err != nil {
+//	    return err
+//	}
+//	checkpoints := store.NewInstrumentedStore(inner, recorder)
+func NewInstrumentedStore(inner CheckpointStore, recorder MetricsRecorder) *InstrumentedStore {
+	return &InstrumentedStore{inner: inner, recorder: recorder}
+}
+
+// observe reports to the recorder that op, started at start, failed with err
+func (s *InstrumentedStore) observe(op string, start time.Time, err error) {
+	s.recorder.ObserveDuration(op, time.Since(start), err)
+}
+
+// Save saves checkpoint in the inner store
+func (s *InstrumentedStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	start := time.Now()
+	err := s.inner.Save(ctx, checkpoint)
+	s.observe(OpSave, start, err)
+	return err
+}
+
+// Load loads a checkpoint from the inner store
+func (s *InstrumentedStore) Load(ctx context.Context, checkpointID string) (*Checkpoint, error) {
+	start := time.Now()
+	checkpoint, err := s.inner.Load(ctx, checkpointID)
+	s.observe(OpLoad, start, err)
+	return checkpoint, err
+}
+
+// List returns the checkpoints of an execution from the inner store
+func (s *InstrumentedStore) List(ctx context.Context, executionID string) ([]*Checkpoint, error) {
+	start := time.Now()
+	checkpoints, err := s.inner.List(ctx, executionID)
+	s.observe(OpList, start, err)
+	return checkpoints, err
+}
+
+// ListPage returns a page of the checkpoints of an execution from the inner
+// store, see the package-level ListPage
+func (s *InstrumentedStore) ListPage(ctx context.Context, executionID string, opts ListOptions) ([]*Checkpoint, error) {
+	start := time.Now()
+	checkpoints, err := ListPage(ctx, s.inner, executionID, opts)
+	s.observe(OpListPage, start, err)
+	return checkpoints, err
+}
+
+// ListByThread returns the checkpoints of a thread from the inner store
+func (s *InstrumentedStore) ListByThread(ctx context.Context, threadID string) ([]*Checkpoint, error) {
+	start := time.Now()
+	checkpoints, err := s.inner.ListByThread(ctx, threadID)
+	s.observe(OpListByThread, start, err)
+	return checkpoints, err
+}
+
+// GetLatestByThread returns the latest checkpoint of a thread from the inner store
+func (s *InstrumentedStore) GetLatestByThread(ctx context.Context, threadID string) (*Checkpoint, error) {
+	start := time.Now()
+	checkpoint, err := s.inner.GetLatestByThread(ctx, threadID)
+	s.observe(OpGetLatestByThread, start, err)
+	return checkpoint, err
+}
+
+// Delete removes a checkpoint from the inner store
+func (s *InstrumentedStore) Delete(ctx context.Context, checkpointID string) error {
+	start := time.Now()
+	err := s.inner.Delete(ctx, checkpointID)
+	s.observe(OpDelete, start, err)
+	return err
+}
+
+// Clear removes all checkpoints for an execution from the inner store
+func (s *InstrumentedStore) Clear(ctx context.Context, executionID string) error {
+	start := time.Now()
+	err := s.inner.Clear(ctx, executionID)
+	s.observe(OpClear, start, err)
+	return err
+}
+
+// ListByMetadata returns the checkpoints of the inner store matching filters, see
+// the package-level ListByMetadata
+func (s *InstrumentedStore) ListByMetadata(ctx context.Context, filters map[string]any, opts ListOptions) ([]*Checkpoint, error) {
+	start := time.Now()
+	checkpoints, err := ListByMetadata(ctx, s.inner, filters, opts)
+	s.observe(OpListByMetadata, start, err)
+	return checkpoints, err
+}
+
+// ListThreads returns the threads of the inner store, see the package-level ListThreads
+func (s *InstrumentedStore) ListThreads(ctx context.Context, opts ListThreadsOptions) ([]ThreadInfo, error) {
+	start := time.Now()
+	threads, err := ListThreads(ctx, s.inner, opts)
+	s.observe(OpListThreads, start, err)
+	return threads, err
+}
+
+// DeleteThread removes every checkpoint of a thread from the inner store
+func (s *InstrumentedStore) DeleteThread(ctx context.Context, threadID string) error {
+	start := time.Now()
+	err := DeleteThread(ctx, s.inner, threadID)
+	s.observe(OpDeleteThread, start, err)
+	return err
+}
+
+// Ping checks that the inner store can reach its backend, see the package-level Ping
+func (s *InstrumentedStore) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := Ping(ctx, s.inner)
+	s.observe(OpPing, start, err)
+	return err
+}
+
+// SaveWrite saves a pending write in the inner store, see the package-level SaveWrite
+func (s *InstrumentedStore) SaveWrite(ctx context.Context, write *PendingWrite) error {
+	start := time.Now()
+	err := SaveWrite(ctx, s.inner, write)
+	s.observe(OpSaveWrite, start, err)
+	return err
+}
+
+// ListWrites returns the pending writes of a checkpoint from the inner store
+func (s *InstrumentedStore) ListWrites(ctx context.Context, checkpointID string) ([]*PendingWrite, error) {
+	start := time.Now()
+	writes, err := ListWrites(ctx, s.inner, checkpointID)
+	s.observe(OpListWrites, start, err)
+	return writes, err
+}
+
+// DeleteWrites removes the pending writes of a checkpoint from the inner store
+func (s *InstrumentedStore) DeleteWrites(ctx context.Context, checkpointID string) error {
+	start := time.Now()
+	err := DeleteWrites(ctx, s.inner, checkpointID)
+	s.observe(OpDeleteWrites, start, err)
+	return err
+}
@@ -0,0 +1,79 @@
+package store_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/memory"
+)
+
+type observation struct {
+	op  string
+	err error
+}
+
+type fakeRecorder struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (r *fakeRecorder) ObserveDuration(op string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observations = append(r.observations, observation{op: op, err: err})
+}
+
+func (r *fakeRecorder) ops() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ops []string
+	for _, o := range r.observations {
+		ops = append(ops, o.op)
+	}
+	return ops
+}
+
+func TestInstrumentedStore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	recorder := &fakeRecorder{}
+	s := store.NewInstrumentedStore(memory.NewMemoryCheckpointStore(), recorder)
+
+	cp := &store.Checkpoint{ID: "cp-1", Version: 1, Metadata: map[string]any{"execution_id": "exec", "thread_id": "thread"}}
+	if err := s.Save(ctx, cp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := s.Load(ctx, "cp-1"); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := s.List(ctx, "exec"); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if _, err := s.GetLatestByThread(ctx, "thread"); err != nil {
+		t.Fatalf("GetLatestByThread failed: %v", err)
+	}
+	if _, err := store.ListThreads(ctx, s, store.ListThreadsOptions{}); err != nil {
+		t.Fatalf("ListThreads failed: %v", err)
+	}
+	_, loadErr := s.Load(ctx, "missing")
+	if loadErr == nil {
+		t.Fatal("Expected an error loading a missing checkpoint")
+	}
+
+	want := []string{store.OpSave, store.OpLoad, store.OpList, store.OpGetLatestByThread, store.OpListThreads, store.OpLoad}
+	if ops := recorder.ops(); !slices.Equal(ops, want) {
+		t.Errorf("Expected operations %v, got %v", want, ops)
+	}
+	for i, o := range recorder.observations {
+		if (o.err != nil) != (i == len(want)-1) {
+			t.Errorf("Unexpected error %v for operation %d (%s)", o.err, i, o.op)
+		}
+	}
+	if recorder.observations[len(want)-1].err != loadErr {
+		t.Errorf("Expected the error of Load to be recorded, got %v", recorder.observations[len(want)-1].err)
+	}
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// NamespaceMetadataKey is the checkpoint metadata naming the checkpoint namespace:
+// the path of nodes from the root graph to the graph that saved the checkpoint,
+// such as "root/executor" for a graph invoked by the executor node of the root
+// graph. Graphs nested in the nodes of a checkpointed graph save their checkpoints
+// on the same thread under their own namespace.
+const NamespaceMetadataKey = "checkpoint_ns"
+
+// RootNamespace is the namespace of the checkpoints of graphs that are not nested
+// in another checkpointed graph, and of checkpoints saved without a namespace.
+const RootNamespace = "root"
+
+// Namespace returns the namespace of checkpoint, RootNamespace if it has none
+func Namespace(checkpoint *Checkpoint) string {
+	if ns, ok := checkpoint.Metadata[NamespaceMetadataKey].(string); ok && ns != "" {
+		return ns
+	}
+	return RootNamespace
+}
+
+// ChildNamespace returns the namespace of a graph nested in node of the graph
+// with namespace parent
+func ChildNamespace(parent, node string) string {
+	return parent + "/" + node
+}
+
+// ListByNamespace returns the checkpoints of threadID in namespace, in the order
+// ListByThread returns them.
+//
+// Example:
+//
+//	// The checkpoints of the graph nested in the executor node
+//	checkpoints, err := store.ListByNamespace(ctx, s, "thread-1", "root/executor")
+func ListByNamespace(ctx context.Context, s CheckpointStore, threadID, namespace string) ([]*Checkpoint, error) {
+	checkpoints, err := s.ListByThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]*Checkpoint, 0, len(checkpoints))
+	for _, cp := range checkpoints {
+		if Namespace(cp) == namespace {
+			matched = append(matched, cp)
+		}
+	}
+	return matched, nil
+}
+
+// GetLatestByNamespace returns the checkpoint of threadID in namespace with the
+// highest version. It lists the thread only when its latest checkpoint is in
+// another namespace.
+func GetLatestByNamespace(ctx context.Context, s CheckpointStore, threadID, namespace string) (*Checkpoint, error) {
+	latest, err := s.GetLatestByThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if Namespace(latest) == namespace {
+		return latest, nil
+	}
+
+	checkpoints, err := ListByNamespace(ctx, s, threadID, namespace)
+	if err != nil {
+		return nil, err
+	}
+	latest = nil
+	for _, cp := range checkpoints {
+		if latest == nil || cp.Version > latest.Version {
+			latest = cp
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no checkpoints found for thread %s in namespace %s", threadID, namespace)
+	}
+	return latest, nil
+}
@@ -0,0 +1,65 @@
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/memory"
+)
+
+func TestNamespaces(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := memory.NewMemoryCheckpointStore()
+
+	// Version 1 predates namespaces, so it belongs to the root namespace
+	namespaces := []string{"", store.RootNamespace, "root/executor", store.RootNamespace, "root/executor"}
+	for i, ns := range namespaces {
+		metadata := map[string]any{"thread_id": "thread"}
+		if ns != "" {
+			metadata[store.NamespaceMetadataKey] = ns
+		}
+		cp := &store.Checkpoint{
+			ID:        fmt.Sprintf("cp-%d", i+1),
+			Version:   i + 1,
+			Timestamp: time.Now(),
+			Metadata:  metadata,
+		}
+		if err := s.Save(ctx, cp); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	root, err := store.ListByNamespace(ctx, s, "thread", store.RootNamespace)
+	if err != nil {
+		t.Fatalf("ListByNamespace failed: %v", err)
+	}
+	if len(root) != 3 || root[0].ID != "cp-1" || root[2].ID != "cp-4" {
+		t.Errorf("Expected cp-1, cp-2 and cp-4 in the root namespace, got %v", root)
+	}
+
+	latest, err := store.GetLatestByNamespace(ctx, s, "thread", "root/executor")
+	if err != nil {
+		t.Fatalf("GetLatestByNamespace failed: %v", err)
+	}
+	if latest.ID != "cp-5" {
+		t.Errorf("Expected cp-5, got %s", latest.ID)
+	}
+	latest, err = store.GetLatestByNamespace(ctx, s, "thread", store.RootNamespace)
+	if err != nil {
+		t.Fatalf("GetLatestByNamespace failed: %v", err)
+	}
+	if latest.ID != "cp-4" {
+		t.Errorf("Expected cp-4, got %s", latest.ID)
+	}
+
+	if _, err := store.GetLatestByNamespace(ctx, s, "thread", "root/other"); err == nil {
+		t.Error("Expected an error for a namespace without checkpoints")
+	}
+	if ns := store.ChildNamespace(store.RootNamespace, "executor"); ns != "root/executor" {
+		t.Errorf("Expected root/executor, got %s", ns)
+	}
+}
@@ -0,0 +1,26 @@
+package store
+
+import "context"
+
+// Pinger is implemented by stores that can check that their backend is
+// reachable, see Ping.
+type Pinger interface {
+	// Ping returns an error naming the backend if the store cannot reach it
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that s can reach its backend, for example before starting a long
+// workflow. Stores that don't implement Pinger, such as the memory store, are
+// assumed to be reachable.
+//
+// Example:
+//
+//	if err := store.Ping(ctx, checkpoints); err != nil {
+//	    log.Fatalf("checkpoints unavailable: %v", err)
+//	}
+func Ping(ctx context.Context, s CheckpointStore) error {
+	if pinger, ok := s.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
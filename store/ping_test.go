@@ -0,0 +1,47 @@
+package store_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/redis"
+)
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range testStores() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			s := newStore(t)
+
+			for _, wrapped := range []store.CheckpointStore{s, store.NewCompressedStore(s, store.Gzip), listOnly{s}} {
+				if err := store.Ping(ctx, wrapped); err != nil {
+					t.Errorf("Ping of %T failed: %v", wrapped, err)
+				}
+			}
+		})
+	}
+
+	t.Run("Unreachable", func(t *testing.T) {
+		t.Parallel()
+		mr := miniredis.RunT(t)
+		addr := mr.Addr()
+		s := redis.NewRedisCheckpointStore(redis.RedisOptions{Addr: addr})
+		defer s.Close()
+		mr.Close()
+
+		// The wrapper forwards the ping to the store it wraps
+		err := store.Ping(context.Background(), store.NewCompressedStore(s, store.Gzip))
+		if err == nil {
+			t.Fatal("Expected Ping to fail once the server is gone")
+		}
+		if want := "redis checkpoint store at " + addr + " is unreachable"; !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to contain %q, got %q", want, err)
+		}
+	})
+}
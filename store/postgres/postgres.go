@@ -4,11 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store"
 )
 
 // DBPool defines the interface for database connection pool
@@ -19,23 +20,48 @@ type DBPool interface {
 	Close()
 }
 
-// PostgresCheckpointStore implements graph.CheckpointStore using PostgreSQL
+// PostgresCheckpointStore implements store.CheckpointStore using PostgreSQL
 type PostgresCheckpointStore struct {
 	pool      DBPool
 	tableName string
+
+	// sharedPool is set when the pool belongs to the application, so Close leaves it open
+	sharedPool bool
+
+	// migrate creates or migrates the schema on first use; schemaReady records that
+	// it was done
+	migrate     bool
+	schemaMu    sync.Mutex
+	schemaReady bool
 }
 
 // PostgresOptions configuration for Postgres connection
 type PostgresOptions struct {
 	ConnString string
 	TableName  string // Default "checkpoints"
+
+	// Pool is an existing pool to use instead of connecting with ConnString, for
+	// example the application's own pool. Close doesn't close it.
+	Pool *pgxpool.Pool
 }
 
-// NewPostgresCheckpointStore creates a new Postgres checkpoint store
+// NewPostgresCheckpointStore creates a new Postgres checkpoint store. The table and
+// its indexes are created, or migrated from earlier versions of the schema, the
+// first time the store is used.
+//
+// Example:
+//
+//	store, err := postgres.NewPostgresCheckpointStore(ctx, postgres.PostgresOptions{
+//	    Pool: appPool,
+//	})
 func NewPostgresCheckpointStore(ctx context.Context, opts PostgresOptions) (*PostgresCheckpointStore, error) {
-	pool, err := pgxpool.New(ctx, opts.ConnString)
-	if err != nil {
-		return nil, fmt.Errorf("unable to create connection pool: %w", err)
+	var pool DBPool = opts.Pool
+	if opts.Pool == nil {
+		created, err := pgxpool.New(ctx, opts.ConnString)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create connection pool: %w", err)
+		}
+		pool = created
 	}
 
 	tableName := opts.TableName
@@ -44,8 +70,10 @@ func NewPostgresCheckpointStore(ctx context.Context, opts PostgresOptions) (*Pos
 	}
 
 	return &PostgresCheckpointStore{
-		pool:      pool,
-		tableName: tableName,
+		pool:       pool,
+		tableName:  tableName,
+		sharedPool: opts.Pool != nil,
+		migrate:    true,
 	}, nil
 }
 
@@ -77,7 +105,10 @@ func (s *PostgresCheckpointStore) InitSchema(ctx context.Context) error {
 		CREATE INDEX IF NOT EXISTS idx_%s_execution_id ON %s (execution_id);
 		CREATE INDEX IF NOT EXISTS idx_%s_thread_id ON %s (thread_id);
 		CREATE INDEX IF NOT EXISTS idx_%s_execution_thread ON %s (execution_id, thread_id);
-	`, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName)
+		CREATE INDEX IF NOT EXISTS idx_%s_thread_version ON %s (thread_id, version DESC);
+		CREATE INDEX IF NOT EXISTS idx_%s_metadata ON %s USING GIN (metadata);
+	`, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName,
+		s.tableName, s.tableName)
 
 	_, err := s.pool.Exec(ctx, query)
 	if err != nil {
@@ -110,8 +141,21 @@ func (s *PostgresCheckpointStore) MigrateSchema(ctx context.Context) error {
 			) THEN
 				CREATE INDEX idx_%s_execution_thread ON %s (execution_id, thread_id);
 			END IF;
+
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_indexes WHERE indexname = 'idx_%s_thread_version'
+			) THEN
+				CREATE INDEX idx_%s_thread_version ON %s (thread_id, version DESC);
+			END IF;
+
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_indexes WHERE indexname = 'idx_%s_metadata'
+			) THEN
+				CREATE INDEX idx_%s_metadata ON %s USING GIN (metadata);
+			END IF;
 		END $$;
-	`, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName)
+	`, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName,
+		s.tableName, s.tableName, s.tableName, s.tableName, s.tableName, s.tableName)
 
 	_, err := s.pool.Exec(ctx, migrationQuery)
 	if err != nil {
@@ -120,13 +164,41 @@ func (s *PostgresCheckpointStore) MigrateSchema(ctx context.Context) error {
 	return nil
 }
 
-// Close closes the connection pool
+// ensureSchema creates and migrates the schema the first time a store created with
+// NewPostgresCheckpointStore is used. A failed attempt is retried on the next call.
+func (s *PostgresCheckpointStore) ensureSchema(ctx context.Context) error {
+	if !s.migrate {
+		return nil
+	}
+	s.schemaMu.Lock()
+	defer s.schemaMu.Unlock()
+	if s.schemaReady {
+		return nil
+	}
+
+	if err := s.InitSchema(ctx); err != nil {
+		return err
+	}
+	if err := s.MigrateSchema(ctx); err != nil {
+		return err
+	}
+	s.schemaReady = true
+	return nil
+}
+
+// Close closes the connection pool, unless it was passed in PostgresOptions.Pool
 func (s *PostgresCheckpointStore) Close() {
-	s.pool.Close()
+	if !s.sharedPool {
+		s.pool.Close()
+	}
 }
 
 // Save stores a checkpoint
-func (s *PostgresCheckpointStore) Save(ctx context.Context, checkpoint *graph.Checkpoint) error {
+func (s *PostgresCheckpointStore) Save(ctx context.Context, checkpoint *store.Checkpoint) error {
+	if err := s.ensureSchema(ctx); err != nil {
+		return err
+	}
+
 	stateJSON, err := json.Marshal(checkpoint.State)
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
@@ -179,14 +251,18 @@ func (s *PostgresCheckpointStore) Save(ctx context.Context, checkpoint *graph.Ch
 }
 
 // Load retrieves a checkpoint by ID
-func (s *PostgresCheckpointStore) Load(ctx context.Context, checkpointID string) (*graph.Checkpoint, error) {
+func (s *PostgresCheckpointStore) Load(ctx context.Context, checkpointID string) (*store.Checkpoint, error) {
+	if err := s.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+
 	query := fmt.Sprintf(`
 		SELECT id, node_name, state, metadata, timestamp, version
 		FROM %s
 		WHERE id = $1
 	`, s.tableName)
 
-	var cp graph.Checkpoint
+	var cp store.Checkpoint
 	var stateJSON []byte
 	var metadataJSON []byte
 
@@ -220,7 +296,11 @@ func (s *PostgresCheckpointStore) Load(ctx context.Context, checkpointID string)
 }
 
 // List returns all checkpoints for a given execution
-func (s *PostgresCheckpointStore) List(ctx context.Context, executionID string) ([]*graph.Checkpoint, error) {
+func (s *PostgresCheckpointStore) List(ctx context.Context, executionID string) ([]*store.Checkpoint, error) {
+	if err := s.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+
 	query := fmt.Sprintf(`
 		SELECT id, node_name, state, metadata, timestamp, version
 		FROM %s
@@ -234,9 +314,9 @@ func (s *PostgresCheckpointStore) List(ctx context.Context, executionID string)
 	}
 	defer rows.Close()
 
-	var checkpoints []*graph.Checkpoint
+	var checkpoints []*store.Checkpoint
 	for rows.Next() {
-		var cp graph.Checkpoint
+		var cp store.Checkpoint
 		var stateJSON []byte
 		var metadataJSON []byte
 
@@ -272,13 +352,94 @@ func (s *PostgresCheckpointStore) List(ctx context.Context, executionID string)
 	return checkpoints, nil
 }
 
-// ListByThread returns all checkpoints for a specific thread_id
-func (s *PostgresCheckpointStore) ListByThread(ctx context.Context, threadID string) ([]*graph.Checkpoint, error) {
+// ListByMetadata returns the checkpoints whose metadata matches filters, sorted by
+// timestamp. The filters are matched with JSONB containment (metadata @> filters),
+// which the GIN index on metadata serves.
+func (s *PostgresCheckpointStore) ListByMetadata(ctx context.Context, filters map[string]any, opts store.ListOptions) ([]*store.Checkpoint, error) {
+	if err := s.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+
+	if filters == nil {
+		filters = map[string]any{}
+	}
+	filtersJSON, err := json.Marshal(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata filters: %w", err)
+	}
+
+	order := "ASC"
+	if opts.Descending {
+		order = "DESC"
+	}
+	var limit any // NULL is no limit
+	if opts.Limit > 0 {
+		limit = opts.Limit
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, node_name, state, metadata, timestamp, version
+		FROM %s
+		WHERE metadata @> $1::jsonb
+		ORDER BY timestamp %s, version %s, id %s
+		LIMIT $2 OFFSET $3
+	`, s.tableName, order, order, order)
+
+	rows, err := s.pool.Query(ctx, query, string(filtersJSON), limit, max(opts.Offset, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints by metadata: %w", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []*store.Checkpoint
+	for rows.Next() {
+		var cp store.Checkpoint
+		var stateJSON []byte
+		var metadataJSON []byte
+
+		err := rows.Scan(
+			&cp.ID,
+			&cp.NodeName,
+			&stateJSON,
+			&metadataJSON,
+			&cp.Timestamp,
+			&cp.Version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan checkpoint row: %w", err)
+		}
+
+		if err := json.Unmarshal(stateJSON, &cp.State); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+		}
+
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &cp.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+
+		checkpoints = append(checkpoints, &cp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating checkpoint rows: %w", err)
+	}
+
+	return checkpoints, nil
+}
+
+// ListByThread returns all checkpoints for a specific thread_id, sorted by version
+func (s *PostgresCheckpointStore) ListByThread(ctx context.Context, threadID string) ([]*store.Checkpoint, error) {
+	if err := s.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+
 	query := fmt.Sprintf(`
 		SELECT id, node_name, state, metadata, timestamp, version
 		FROM %s
 		WHERE thread_id = $1
-		ORDER BY timestamp ASC
+		ORDER BY version ASC
 	`, s.tableName)
 
 	rows, err := s.pool.Query(ctx, query, threadID)
@@ -287,9 +448,9 @@ func (s *PostgresCheckpointStore) ListByThread(ctx context.Context, threadID str
 	}
 	defer rows.Close()
 
-	var checkpoints []*graph.Checkpoint
+	var checkpoints []*store.Checkpoint
 	for rows.Next() {
-		var cp graph.Checkpoint
+		var cp store.Checkpoint
 		var stateJSON []byte
 		var metadataJSON []byte
 
@@ -325,8 +486,13 @@ func (s *PostgresCheckpointStore) ListByThread(ctx context.Context, threadID str
 	return checkpoints, nil
 }
 
-// GetLatestByThread returns the latest checkpoint for a thread_id
-func (s *PostgresCheckpointStore) GetLatestByThread(ctx context.Context, threadID string) (*graph.Checkpoint, error) {
+// GetLatestByThread returns the latest checkpoint for a thread_id, found with the
+// (thread_id, version DESC) index
+func (s *PostgresCheckpointStore) GetLatestByThread(ctx context.Context, threadID string) (*store.Checkpoint, error) {
+	if err := s.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+
 	query := fmt.Sprintf(`
 		SELECT id, node_name, state, metadata, timestamp, version
 		FROM %s
@@ -335,7 +501,7 @@ func (s *PostgresCheckpointStore) GetLatestByThread(ctx context.Context, threadI
 		LIMIT 1
 	`, s.tableName)
 
-	var cp graph.Checkpoint
+	var cp store.Checkpoint
 	var stateJSON []byte
 	var metadataJSON []byte
 
@@ -370,6 +536,10 @@ func (s *PostgresCheckpointStore) GetLatestByThread(ctx context.Context, threadI
 
 // Delete removes a checkpoint
 func (s *PostgresCheckpointStore) Delete(ctx context.Context, checkpointID string) error {
+	if err := s.ensureSchema(ctx); err != nil {
+		return err
+	}
+
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", s.tableName)
 	_, err := s.pool.Exec(ctx, query, checkpointID)
 	if err != nil {
@@ -378,8 +548,12 @@ func (s *PostgresCheckpointStore) Delete(ctx context.Context, checkpointID strin
 	return nil
 }
 
-// Clear removes all checkpoints for an execution
+// Clear removes all checkpoints for an execution with a single DELETE
 func (s *PostgresCheckpointStore) Clear(ctx context.Context, executionID string) error {
+	if err := s.ensureSchema(ctx); err != nil {
+		return err
+	}
+
 	query := fmt.Sprintf("DELETE FROM %s WHERE execution_id = $1", s.tableName)
 	_, err := s.pool.Exec(ctx, query, executionID)
 	if err != nil {
@@ -714,3 +714,77 @@ func TestNewPostgresCheckpointStore_InvalidConnection(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unable to create connection pool")
 }
+
+func TestPostgresCheckpointStore_MigratesOnFirstUse(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	// Stores from NewPostgresCheckpointStore set up the schema before the first query
+	store := NewPostgresCheckpointStoreWithPool(mock, "checkpoints")
+	store.migrate = true
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS checkpoints")).
+		WillReturnError(errors.New("database is starting up"))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX IF NOT EXISTS idx_checkpoints_thread_version ON checkpoints (thread_id, version DESC)")).
+		WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectExec(regexp.QuoteMeta("SELECT 1 FROM pg_indexes WHERE indexname = 'idx_checkpoints_thread_version'")).
+		WillReturnResult(pgxmock.NewResult("DO", 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM checkpoints WHERE execution_id = $1")).
+		WithArgs("exec-1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM checkpoints WHERE execution_id = $1")).
+		WithArgs("exec-2").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	// A failed migration is retried by the next call, and only runs once
+	err = store.Clear(context.Background(), "exec-1")
+	assert.ErrorContains(t, err, "failed to create schema")
+	assert.NoError(t, store.Clear(context.Background(), "exec-1"))
+	assert.NoError(t, store.Clear(context.Background(), "exec-2"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCheckpointStore_ListByThread_VersionOrder(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	store := NewPostgresCheckpointStoreWithPool(mock, "checkpoints")
+
+	rows := pgxmock.NewRows([]string{"id", "node_name", "state", "metadata", "timestamp", "version"}).
+		AddRow("cp-1", "a", []byte(`{}`), []byte(`{}`), time.Now(), 1).
+		AddRow("cp-2", "b", []byte(`{}`), []byte(`{}`), time.Now().Add(-time.Minute), 2)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, node_name, state, metadata, timestamp, version FROM checkpoints WHERE thread_id = $1 ORDER BY version ASC")).
+		WithArgs("thread-1").
+		WillReturnRows(rows)
+
+	checkpoints, err := store.ListByThread(context.Background(), "thread-1")
+	assert.NoError(t, err)
+	assert.Len(t, checkpoints, 2)
+	assert.Equal(t, "cp-2", checkpoints[1].ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresCheckpointStore_ListByMetadata(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	store := NewPostgresCheckpointStoreWithPool(mock, "checkpoints")
+
+	rows := pgxmock.NewRows([]string{"id", "node_name", "state", "metadata", "timestamp", "version"}).
+		AddRow("cp-2", "b", []byte(`{}`), []byte(`{"user_id":42}`), time.Now(), 2)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE metadata @> $1::jsonb ORDER BY timestamp DESC, version DESC, id DESC LIMIT $2 OFFSET $3")).
+		WithArgs(`{"user_id":42}`, 1, 0).
+		WillReturnRows(rows)
+
+	checkpoints, err := store.ListByMetadata(context.Background(), map[string]any{"user_id": 42}, graph.ListOptions{Limit: 1, Descending: true})
+	assert.NoError(t, err)
+	assert.Len(t, checkpoints, 1)
+	assert.Equal(t, float64(42), checkpoints[0].Metadata["user_id"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,31 @@
+// Package prometheus exports the metrics of checkpoint store operations to
+// Prometheus. It is a separate package so that the store package doesn't depend
+// on the Prometheus client.
+//
+// # Basic Usage
+//
+//	import (
+//		"github.com/smallnest/langgraphgo/store"
+//		"github.com/smallnest/langgraphgo/store/prometheus"
+//	)
+//
+//	// Register the metrics with the default registry
+//	recorder, err := prometheus.NewRecorder(nil)
+//	if err != nil {
+//		return err
+//	}
+//	checkpoints := store.NewInstrumentedStore(inner, recorder)
+//
+//	g := graph.NewCheckpointableStateGraphWithConfig[map[string]any](graph.CheckpointConfig{
+//		Store:    checkpoints,
+//		AutoSave: true,
+//	})
+//
+// # Metrics
+//
+//   - langgraphgo_checkpoint_store_operation_duration_seconds: a histogram of the
+//     duration of operations, labelled with op, such as "save" or "load" (see the
+//     Op constants of the store package), and status, "ok" or "error"
+//   - langgraphgo_checkpoint_store_operation_errors_total: a counter of the
+//     failed operations, labelled with op
+package prometheus
@@ -0,0 +1,92 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+// RecorderOptions configures a Recorder
+type RecorderOptions struct {
+	// Registerer registers the metrics, prometheus.DefaultRegisterer if nil
+	Registerer prometheus.Registerer
+
+	// Namespace prefixes the metric names, "langgraphgo" if empty
+	Namespace string
+
+	// Buckets of the duration histogram in seconds, prometheus.DefBuckets if nil
+	Buckets []float64
+}
+
+// Recorder is a store.MetricsRecorder exporting Prometheus metrics
+type Recorder struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+var _ store.MetricsRecorder = (*Recorder)(nil)
+
+// NewRecorder creates a Recorder registering its metrics with registerer, or with
+// prometheus.DefaultRegisterer if nil
+func NewRecorder(registerer prometheus.Registerer) (*Recorder, error) {
+	return NewRecorderWithOptions(RecorderOptions{Registerer: registerer})
+}
+
+// NewRecorderWithOptions creates a Recorder with custom options. It fails when
+// the metrics are already registered, for example by another Recorder with the
+// same namespace.
+//
+// Example:
+//
+//	recorder, err := prometheus.NewRecorderWithOptions(prometheus.RecorderOptions{
+//	    Registerer: registry,
+//	    Buckets:    []float64{0.001, 0.01, 0.1, 1},
+//	})
+func NewRecorderWithOptions(opts RecorderOptions) (*Recorder, error) {
+	registerer := opts.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "langgraphgo"
+	}
+	buckets := opts.Buckets
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+
+	r := &Recorder{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "checkpoint_store",
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of checkpoint store operations.",
+			Buckets:   buckets,
+		}, []string{"op", "status"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "checkpoint_store",
+			Name:      "operation_errors_total",
+			Help:      "Number of failed checkpoint store operations.",
+		}, []string{"op"}),
+	}
+	for _, collector := range []prometheus.Collector{r.duration, r.errors} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// ObserveDuration implements store.MetricsRecorder
+func (r *Recorder) ObserveDuration(op string, d time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+		r.errors.WithLabelValues(op).Inc()
+	}
+	r.duration.WithLabelValues(op, status).Observe(d.Seconds())
+}
@@ -0,0 +1,54 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/smallnest/langgraphgo/store/memory"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	registry := prometheus.NewRegistry()
+	recorder, err := NewRecorder(registry)
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	s := store.NewInstrumentedStore(memory.NewMemoryCheckpointStore(), recorder)
+
+	cp := &store.Checkpoint{ID: "cp-1", Version: 1, Metadata: map[string]any{"execution_id": "exec"}}
+	if err := s.Save(ctx, cp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := s.Load(ctx, "cp-1"); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if _, err := s.Load(ctx, "missing"); err == nil {
+		t.Fatal("Expected an error loading a missing checkpoint")
+	}
+	if _, err := s.List(ctx, "exec"); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	// One series per op and status
+	if n := testutil.CollectAndCount(recorder.duration); n != 4 {
+		t.Errorf("Expected 4 duration series, got %d", n)
+	}
+	if got := testutil.ToFloat64(recorder.errors.WithLabelValues(store.OpLoad)); got != 1 {
+		t.Errorf("Expected 1 load error, got %v", got)
+	}
+	if got := testutil.ToFloat64(recorder.errors.WithLabelValues(store.OpSave)); got != 0 {
+		t.Errorf("Expected no save errors, got %v", got)
+	}
+
+	// The same metrics can't be registered twice
+	if _, err := NewRecorder(registry); !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		t.Errorf("Expected an AlreadyRegisteredError, got %v", err)
+	}
+}
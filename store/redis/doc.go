@@ -30,19 +30,22 @@
 //		Prefix:   "langgraph:",         // Optional key prefix
 //		TTL:      24 * time.Hour,       // Optional TTL for checkpoints
 //	})
+//	defer store.Close()
 //
 //	// Use with a graph
-//	g := graph.NewStateGraph()
+//	g := graph.NewCheckpointableStateGraphWithConfig[map[string]any](graph.CheckpointConfig{
+//		Store:    store,
+//		AutoSave: true,
+//	})
 //	// ... configure graph ...
 //
-//	// Enable checkpointing
-//	compileConfig := graph.CompileConfig{
-//		CheckpointConfig: graph.CheckpointConfig{
-//			Store: store,
-//		},
-//	}
+//	// Fails if the server can't be reached
+//	runnable, err := g.CompileCheckpointable()
 //
-//	runnable, err := g.CompileWithOptions(compileConfig)
+// Ping checks that the server is reachable. CompileCheckpointable pings the store
+// when AutoSave is enabled, so a misconfigured address fails before a run starts.
+// Close closes the client NewRedisCheckpointStore created; stores created with
+// NewRedisCheckpointStoreWithClient leave closing the client to the application.
 //
 // # Configuration
 //
@@ -87,6 +90,10 @@
 //
 // ## Custom Redis Client
 //
+// NewRedisCheckpointStoreWithClient takes any redis.UniversalClient of
+// github.com/redis/go-redis/v9, such as the application's own client. In these
+// examples this package is imported as redisstore.
+//
 //	// Use a custom Redis client for more control
 //	rdb := redis.NewClient(&redis.Options{
 //		Addr:         "localhost:6379",
@@ -101,7 +108,10 @@
 //		PoolTimeout:  4 * time.Second,
 //	})
 //
-//	store := redis.NewCheckpointStoreFromClient(rdb, "langgraph:", time.Hour)
+//	store := redisstore.NewRedisCheckpointStoreWithClient(rdb, redisstore.RedisOptions{
+//		Prefix: "langgraph:",
+//		TTL:    time.Hour,
+//	})
 //
 // ## Clustering Support
 //
@@ -115,7 +125,15 @@
 //		Password: "cluster-password",
 //	})
 //
-//	store := redis.NewCheckpointStoreFromCluster(rdb, "langgraph:", time.Hour)
+//	// The hash tag keeps every key on one slot, so DeleteThread is a transaction
+//	store := redisstore.NewRedisCheckpointStoreWithClient(rdb, redisstore.RedisOptions{
+//		Prefix: "{langgraph}:",
+//		TTL:    time.Hour,
+//	})
+//
+// Without a hash tag the keys spread over the cluster: reads and writes are
+// pipelined to each node, ListThreads and ListByMetadata scan every master, and
+// DeleteThread deletes the keys of a thread without a transaction.
 //
 // ## Sentinel Support
 //
@@ -130,7 +148,9 @@
 //		Password: "sentinel-password",
 //	})
 //
-//	store := redis.NewCheckpointStoreFromClient(rdb, "langgraph:", time.Hour)
+//	store := redisstore.NewRedisCheckpointStoreWithClient(rdb, redisstore.RedisOptions{
+//		Prefix: "langgraph:",
+//	})
 //
 // # Key Management
 //
@@ -138,12 +158,23 @@
 //	// Format: {prefix}checkpoint:{checkpoint_id}
 //	// Example: "langgraph:checkpoint:abc123"
 //
-//	// Thread-specific checkpoints
-//	// Format: {prefix}thread:{thread_id}:checkpoint:{checkpoint_id}
-//	// Example: "langgraph:thread:xyz789:checkpoint:def456"
+//	// Checkpoint IDs of a thread, in a sorted set scored by version
+//	// Format: {prefix}thread:{thread_id}:checkpoints
+//	// Example: "langgraph:thread:xyz789:checkpoints"
+//
+//	// Checkpoint IDs of an execution, in a sorted set scored by version
+//	// Format: {prefix}execution:{execution_id}:checkpoints
+//
+// ## Version Ranges
+//
+// The thread sorted sets are scored by version, so ranges of a thread's history
+// are read without fetching the rest:
+//
+//	// Versions 10 to 20, oldest first
+//	checkpoints, err := store.ListByThreadRange(ctx, threadID, 10, 20, 0, false)
 //
-//	// List all checkpoints for a thread
-//	keys, err := store.client.Keys(ctx, "langgraph:thread:xyz789:checkpoint:*")
+//	// The last 5 checkpoints, latest first
+//	recent, err := store.LatestByThread(ctx, threadID, 5)
 //
 // ## Custom TTL per Checkpoint
 //
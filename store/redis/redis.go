@@ -1,21 +1,30 @@
 package redis
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
-	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store"
 )
 
-// RedisCheckpointStore implements graph.CheckpointStore using Redis
+// RedisCheckpointStore implements store.CheckpointStore using Redis
 type RedisCheckpointStore struct {
-	client *redis.Client
+	client redis.UniversalClient
 	prefix string
 	ttl    time.Duration
+
+	// sharedClient is set when the client belongs to the application, so Close leaves it open
+	sharedClient bool
 }
 
 // RedisOptions configuration for Redis connection
@@ -35,16 +44,82 @@ func NewRedisCheckpointStore(opts RedisOptions) *RedisCheckpointStore {
 		DB:       opts.DB,
 	})
 
+	store := NewRedisCheckpointStoreWithClient(client, opts)
+	store.sharedClient = false
+	return store
+}
+
+// NewRedisCheckpointStoreWithClient creates a Redis checkpoint store using client,
+// such as a *redis.Client shared with the application, a *redis.ClusterClient or
+// a Sentinel *redis.FailoverClient. Only the Prefix and TTL of opts are used. The
+// store doesn't close client, not even in Close.
+//
+// In a cluster, the keys of a checkpoint and its indexes hash to different slots,
+// so writes are pipelined to each node and DeleteThread isn't a transaction. A
+// prefix with a hash tag, such as "{langgraph}:", keeps every key of the store on
+// one slot and makes DeleteThread a transaction again, at the cost of storing all
+// checkpoints on one node.
+//
+// Example:
+//
+//	rdb := redis.NewClusterClient(&redis.ClusterOptions{
+//	    Addrs: []string{"redis-node-1:6379", "redis-node-2:6379", "redis-node-3:6379"},
+//	})
+//	store := redisstore.NewRedisCheckpointStoreWithClient(rdb, redisstore.RedisOptions{
+//	    Prefix: "{langgraph}:",
+//	    TTL:    time.Hour,
+//	})
+func NewRedisCheckpointStoreWithClient(client redis.UniversalClient, opts RedisOptions) *RedisCheckpointStore {
 	prefix := opts.Prefix
 	if prefix == "" {
 		prefix = "langgraph:"
 	}
 
 	return &RedisCheckpointStore{
-		client: client,
-		prefix: prefix,
-		ttl:    opts.TTL,
+		client:       client,
+		prefix:       prefix,
+		ttl:          opts.TTL,
+		sharedClient: true,
+	}
+}
+
+// Ping checks that the Redis server is reachable, returning an error naming its
+// address if not
+func (s *RedisCheckpointStore) Ping(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis checkpoint store at %s is unreachable: %w", s.addr(), err)
 	}
+	return nil
+}
+
+// Close closes the Redis client, unless it was passed to NewRedisCheckpointStoreWithClient
+func (s *RedisCheckpointStore) Close() error {
+	if s.sharedClient {
+		return nil
+	}
+	return s.client.Close()
+}
+
+// addr describes the server or servers of the client for error messages
+func (s *RedisCheckpointStore) addr() string {
+	switch client := s.client.(type) {
+	case *redis.Client:
+		return client.Options().Addr
+	case *redis.ClusterClient:
+		return strings.Join(client.Options().Addrs, ",")
+	}
+	return fmt.Sprintf("%T", s.client)
+}
+
+// singleSlot reports whether every key of the store can be used in one
+// transaction: it isn't a cluster client, or the prefix has a hash tag
+func (s *RedisCheckpointStore) singleSlot() bool {
+	if _, ok := s.client.(*redis.ClusterClient); !ok {
+		return true
+	}
+	_, tag, ok := strings.Cut(s.prefix, "{")
+	tag, _, ok2 := strings.Cut(tag, "}")
+	return ok && ok2 && tag != ""
 }
 
 func (s *RedisCheckpointStore) checkpointKey(id string) string {
@@ -59,8 +134,24 @@ func (s *RedisCheckpointStore) threadKey(id string) string {
 	return fmt.Sprintf("%sthread:%s:checkpoints", s.prefix, id)
 }
 
+// metadataKey returns the key of the index of checkpoints whose metadata has key
+// set to value. Values are encoded as JSON so that 42 and 42.0 share an index.
+// Only scalar values are indexed.
+func (s *RedisCheckpointStore) metadataKey(key string, value any) (string, bool) {
+	switch value.(type) {
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+	default:
+		return "", false
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%smeta:%s:%s", s.prefix, key, encoded), true
+}
+
 // Save stores a checkpoint
-func (s *RedisCheckpointStore) Save(ctx context.Context, checkpoint *graph.Checkpoint) error {
+func (s *RedisCheckpointStore) Save(ctx context.Context, checkpoint *store.Checkpoint) error {
 	data, err := json.Marshal(checkpoint)
 	if err != nil {
 		return fmt.Errorf("failed to marshal checkpoint: %w", err)
@@ -89,6 +180,18 @@ func (s *RedisCheckpointStore) Save(ctx context.Context, checkpoint *graph.Check
 		}
 	}
 
+	// Index by metadata values for ListByMetadata, scored by timestamp
+	for k, v := range checkpoint.Metadata {
+		metaKey, ok := s.metadataKey(k, v)
+		if !ok {
+			continue
+		}
+		pipe.ZAdd(ctx, metaKey, redis.Z{Score: float64(checkpoint.Timestamp.UnixMicro()), Member: checkpoint.ID})
+		if s.ttl > 0 {
+			pipe.Expire(ctx, metaKey, s.ttl)
+		}
+	}
+
 	_, err = pipe.Exec(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to save checkpoint to redis: %w", err)
@@ -98,7 +201,7 @@ func (s *RedisCheckpointStore) Save(ctx context.Context, checkpoint *graph.Check
 }
 
 // Load retrieves a checkpoint by ID
-func (s *RedisCheckpointStore) Load(ctx context.Context, checkpointID string) (*graph.Checkpoint, error) {
+func (s *RedisCheckpointStore) Load(ctx context.Context, checkpointID string) (*store.Checkpoint, error) {
 	key := s.checkpointKey(checkpointID)
 	data, err := s.client.Get(ctx, key).Bytes()
 	if err != nil {
@@ -108,7 +211,7 @@ func (s *RedisCheckpointStore) Load(ctx context.Context, checkpointID string) (*
 		return nil, fmt.Errorf("failed to load checkpoint from redis: %w", err)
 	}
 
-	var checkpoint graph.Checkpoint
+	var checkpoint store.Checkpoint
 	if err := json.Unmarshal(data, &checkpoint); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
 	}
@@ -117,93 +220,146 @@ func (s *RedisCheckpointStore) Load(ctx context.Context, checkpointID string) (*
 }
 
 // List returns all checkpoints for a given execution
-func (s *RedisCheckpointStore) List(ctx context.Context, executionID string) ([]*graph.Checkpoint, error) {
-	execKey := s.executionKey(executionID)
-	checkpointIDs, err := s.client.ZRange(ctx, execKey, 0, -1).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list checkpoints for execution %s: %w", executionID, err)
-	}
+func (s *RedisCheckpointStore) List(ctx context.Context, executionID string) ([]*store.Checkpoint, error) {
+	return s.ListPage(ctx, executionID, store.ListOptions{})
+}
 
-	if len(checkpointIDs) == 0 {
-		return []*graph.Checkpoint{}, nil
+// ListPage returns the page of the checkpoints List returns that opts selects,
+// fetching only the checkpoints on the page
+func (s *RedisCheckpointStore) ListPage(ctx context.Context, executionID string, opts store.ListOptions) ([]*store.Checkpoint, error) {
+	execKey := s.executionKey(executionID)
+	start := int64(max(opts.Offset, 0))
+	stop := int64(-1)
+	if opts.Limit > 0 {
+		stop = start + int64(opts.Limit) - 1
 	}
 
-	// Fetch all checkpoints
-	var keys []string
-	for _, id := range checkpointIDs {
-		keys = append(keys, s.checkpointKey(id))
+	var checkpointIDs []string
+	var err error
+	if opts.Descending {
+		checkpointIDs, err = s.client.ZRevRange(ctx, execKey, start, stop).Result()
+	} else {
+		checkpointIDs, err = s.client.ZRange(ctx, execKey, start, stop).Result()
 	}
-
-	// MGet might fail if some keys are missing (expired), so we handle them individually or filter results
-	// But MGet returns nil for missing keys, which is fine.
-	results, err := s.client.MGet(ctx, keys...).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch checkpoints: %w", err)
+		return nil, fmt.Errorf("failed to list checkpoints for execution %s: %w", executionID, err)
 	}
 
-	var checkpoints []*graph.Checkpoint
-	for i, result := range results {
-		if result == nil {
-			continue
-		}
-
-		strData, ok := result.(string)
-		if !ok {
-			continue
-		}
-
-		var checkpoint graph.Checkpoint
-		if err := json.Unmarshal([]byte(strData), &checkpoint); err != nil {
-			// Log error or skip? Skipping for now
-			continue
-		}
-		checkpoints = append(checkpoints, &checkpoint)
+	return s.fetchSorted(ctx, checkpointIDs, opts.Descending)
+}
 
-		// Sanity check ID - should match if order is preserved
-		// If mismatch occurs, it indicates a Redis ordering issue
-		_ = checkpointIDs[i] // Acknowledge ID is available for future validation
+// ListByThread returns all checkpoints for a specific thread_id
+func (s *RedisCheckpointStore) ListByThread(ctx context.Context, threadID string) ([]*store.Checkpoint, error) {
+	threadKey := s.threadKey(threadID)
+	checkpointIDs, err := s.client.ZRange(ctx, threadKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints for thread %s: %w", threadID, err)
 	}
-	//
 
-	return checkpoints, nil
+	return s.fetchSorted(ctx, checkpointIDs, false)
 }
 
-// ListByThread returns all checkpoints for a specific thread_id
-func (s *RedisCheckpointStore) ListByThread(ctx context.Context, threadID string) ([]*graph.Checkpoint, error) {
+// ListByThreadRange returns the checkpoints of threadID with a version from
+// fromVersion to toVersion inclusive, sorted by version, the highest first if desc.
+// A limit above 0 returns at most limit of them. Only the checkpoints returned are
+// fetched; math.MinInt64 and math.MaxInt64 leave the range open.
+//
+// Example:
+//
+//	// Versions 10 to 20 of a thread
+//	checkpoints, err := store.ListByThreadRange(ctx, threadID, 10, 20, 0, false)
+func (s *RedisCheckpointStore) ListByThreadRange(ctx context.Context, threadID string, fromVersion, toVersion int64, limit int, desc bool) ([]*store.Checkpoint, error) {
 	threadKey := s.threadKey(threadID)
-	checkpointIDs, err := s.client.ZRange(ctx, threadKey, 0, -1).Result()
+	by := &redis.ZRangeBy{
+		Min: scoreBound(fromVersion),
+		Max: scoreBound(toVersion),
+	}
+	if limit > 0 {
+		by.Count = int64(limit)
+	}
+
+	var checkpointIDs []string
+	var err error
+	if desc {
+		checkpointIDs, err = s.client.ZRevRangeByScore(ctx, threadKey, by).Result()
+	} else {
+		checkpointIDs, err = s.client.ZRangeByScore(ctx, threadKey, by).Result()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list checkpoints for thread %s: %w", threadID, err)
 	}
 
-	if len(checkpointIDs) == 0 {
-		return []*graph.Checkpoint{}, nil
+	return s.fetchSorted(ctx, checkpointIDs, desc)
+}
+
+// LatestByThread returns the n checkpoints of threadID with the highest versions,
+// the latest first, such as the recent history a debugging UI shows
+func (s *RedisCheckpointStore) LatestByThread(ctx context.Context, threadID string, n int) ([]*store.Checkpoint, error) {
+	if n <= 0 {
+		return []*store.Checkpoint{}, nil
 	}
+	return s.ListByThreadRange(ctx, threadID, math.MinInt64, math.MaxInt64, n, true)
+}
 
-	// Fetch all checkpoints
-	var keys []string
-	for _, id := range checkpointIDs {
-		keys = append(keys, s.checkpointKey(id))
+// scoreBound formats a version as a ZRANGEBYSCORE bound, the extreme values as
+// infinities
+func scoreBound(version int64) string {
+	switch version {
+	case math.MinInt64:
+		return "-inf"
+	case math.MaxInt64:
+		return "+inf"
 	}
+	return strconv.FormatInt(version, 10)
+}
 
-	results, err := s.client.MGet(ctx, keys...).Result()
+// fetchSorted fetches the checkpoints with checkpointIDs sorted by version, the
+// highest first if desc. The order comes from the checkpoints rather than the
+// index scores, which are stale when a checkpoint key was rewritten without its
+// indexes.
+func (s *RedisCheckpointStore) fetchSorted(ctx context.Context, checkpointIDs []string, desc bool) ([]*store.Checkpoint, error) {
+	checkpoints, err := s.fetchCheckpoints(ctx, checkpointIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch checkpoints: %w", err)
+		return nil, err
+	}
+	slices.SortStableFunc(checkpoints, func(a, b *store.Checkpoint) int {
+		if desc {
+			return cmp.Compare(b.Version, a.Version)
+		}
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return checkpoints, nil
+}
+
+// fetchCheckpoints loads the checkpoints with checkpointIDs in order, skipping
+// those that expired
+func (s *RedisCheckpointStore) fetchCheckpoints(ctx context.Context, checkpointIDs []string) ([]*store.Checkpoint, error) {
+	if len(checkpointIDs) == 0 {
+		return []*store.Checkpoint{}, nil
 	}
 
-	var checkpoints []*graph.Checkpoint
-	for _, result := range results {
-		if result == nil {
-			continue
+	// A GET per key rather than MGET, whose keys must share a slot in a cluster
+	cmds := make([]*redis.StringCmd, len(checkpointIDs))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range checkpointIDs {
+			cmds[i] = pipe.Get(ctx, s.checkpointKey(id))
 		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to fetch checkpoints: %w", err)
+	}
 
-		strData, ok := result.(string)
-		if !ok {
+	var checkpoints []*store.Checkpoint
+	for _, cmd := range cmds {
+		// Missing (expired) keys are skipped
+		data, err := cmd.Bytes()
+		if err != nil {
 			continue
 		}
 
-		var checkpoint graph.Checkpoint
-		if err := json.Unmarshal([]byte(strData), &checkpoint); err != nil {
+		var checkpoint store.Checkpoint
+		if err := json.Unmarshal(data, &checkpoint); err != nil {
 			continue
 		}
 		checkpoints = append(checkpoints, &checkpoint)
@@ -212,8 +368,40 @@ func (s *RedisCheckpointStore) ListByThread(ctx context.Context, threadID string
 	return checkpoints, nil
 }
 
+// scanKeys returns the keys matching pattern. A cluster is scanned on each of
+// its masters.
+func (s *RedisCheckpointStore) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	cluster, ok := s.client.(*redis.ClusterClient)
+	if !ok {
+		return scanClient(ctx, s.client, pattern)
+	}
+
+	var mu sync.Mutex
+	var keys []string
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		nodeKeys, err := scanClient(ctx, node, pattern)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, nodeKeys...)
+		return nil
+	})
+	return keys, err
+}
+
+func scanClient(ctx context.Context, client redis.UniversalClient, pattern string) ([]string, error) {
+	var keys []string
+	iter := client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
 // GetLatestByThread returns the latest checkpoint for a thread_id
-func (s *RedisCheckpointStore) GetLatestByThread(ctx context.Context, threadID string) (*graph.Checkpoint, error) {
+func (s *RedisCheckpointStore) GetLatestByThread(ctx context.Context, threadID string) (*store.Checkpoint, error) {
 	threadKey := s.threadKey(threadID)
 
 	// get latest checkpoint
@@ -236,7 +424,7 @@ func (s *RedisCheckpointStore) GetLatestByThread(ctx context.Context, threadID s
 		return nil, fmt.Errorf("failed to load checkpoint %s: %w", latestCheckpointID, err)
 	}
 
-	var checkpoint graph.Checkpoint
+	var checkpoint store.Checkpoint
 	if err := json.Unmarshal([]byte(data), &checkpoint); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
 	}
@@ -249,7 +437,11 @@ func (s *RedisCheckpointStore) Delete(ctx context.Context, checkpointID string)
 	// First load to get execution ID and thread ID for cleanup
 	checkpoint, err := s.Load(ctx, checkpointID)
 	if err != nil {
-		return err // Or ignore if not found?
+		if exists, existsErr := s.client.Exists(ctx, s.checkpointKey(checkpointID)).Result(); existsErr == nil && exists == 0 {
+			// Already deleted
+			return nil
+		}
+		return err
 	}
 
 	key := s.checkpointKey(checkpointID)
@@ -267,6 +459,8 @@ func (s *RedisCheckpointStore) Delete(ctx context.Context, checkpointID string)
 		pipe.ZRem(ctx, threadKey, checkpointID)
 	}
 
+	s.removeFromMetadataIndexes(ctx, pipe, checkpoint)
+
 	_, err = pipe.Exec(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to delete checkpoint: %w", err)
@@ -304,3 +498,137 @@ func (s *RedisCheckpointStore) Clear(ctx context.Context, executionID string) er
 
 	return nil
 }
+
+// ListThreads returns the threads that have checkpoints, most recently updated
+// first. Threads are found by scanning the thread ZSETs.
+func (s *RedisCheckpointStore) ListThreads(ctx context.Context, opts store.ListThreadsOptions) ([]store.ThreadInfo, error) {
+	threadPrefix := s.prefix + "thread:"
+	const threadSuffix = ":checkpoints"
+
+	keys, err := s.scanKeys(ctx, threadPrefix+"*"+threadSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan threads: %w", err)
+	}
+
+	threads := []store.ThreadInfo{}
+	for _, key := range keys {
+		threadID := strings.TrimSuffix(strings.TrimPrefix(key, threadPrefix), threadSuffix)
+
+		count, err := s.client.ZCard(ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to count checkpoints for thread %s: %w", threadID, err)
+		}
+		latest, err := s.GetLatestByThread(ctx, threadID)
+		if err != nil {
+			// The latest checkpoint expired before its thread ZSET
+			continue
+		}
+
+		threads = append(threads, store.ThreadInfo{
+			ThreadID:        threadID,
+			CheckpointCount: int(count),
+			LatestVersion:   latest.Version,
+			LastUpdated:     latest.Timestamp,
+		})
+	}
+
+	return store.SortThreads(threads, opts), nil
+}
+
+// DeleteThread removes every checkpoint of threadID, its execution index entries
+// and its thread ZSET in one MULTI/EXEC transaction. In a cluster the keys must
+// share a hash tag for that, otherwise they are deleted in a plain pipeline.
+func (s *RedisCheckpointStore) DeleteThread(ctx context.Context, threadID string) error {
+	threadKey := s.threadKey(threadID)
+	checkpointIDs, err := s.client.ZRange(ctx, threadKey, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints for thread %s: %w", threadID, err)
+	}
+	checkpoints, err := s.fetchCheckpoints(ctx, checkpointIDs)
+	if err != nil {
+		return err
+	}
+
+	pipelined := s.client.Pipelined
+	if s.singleSlot() {
+		pipelined = s.client.TxPipelined
+	}
+	_, err = pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range checkpointIDs {
+			pipe.Del(ctx, s.checkpointKey(id))
+		}
+		for _, checkpoint := range checkpoints {
+			if execID, ok := checkpoint.Metadata["execution_id"].(string); ok && execID != "" {
+				pipe.ZRem(ctx, s.executionKey(execID), checkpoint.ID)
+			}
+			s.removeFromMetadataIndexes(ctx, pipe, checkpoint)
+		}
+		pipe.Del(ctx, threadKey)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete thread %s: %w", threadID, err)
+	}
+
+	return nil
+}
+
+// removeFromMetadataIndexes queues the removal of checkpoint from its metadata indexes
+func (s *RedisCheckpointStore) removeFromMetadataIndexes(ctx context.Context, pipe redis.Pipeliner, checkpoint *store.Checkpoint) {
+	for k, v := range checkpoint.Metadata {
+		if metaKey, ok := s.metadataKey(k, v); ok {
+			pipe.ZRem(ctx, metaKey, checkpoint.ID)
+		}
+	}
+}
+
+// ListByMetadata returns the checkpoints whose metadata matches filters, sorted by
+// timestamp. Candidates are the intersection of the metadata indexes of the scalar
+// filters; without any, every checkpoint key is scanned. Checkpoints saved before
+// the metadata indexes existed are only found by the scan.
+func (s *RedisCheckpointStore) ListByMetadata(ctx context.Context, filters map[string]any, opts store.ListOptions) ([]*store.Checkpoint, error) {
+	var candidates []string
+	indexed := false
+	for k, v := range filters {
+		metaKey, ok := s.metadataKey(k, v)
+		if !ok {
+			continue
+		}
+		ids, err := s.client.ZRange(ctx, metaKey, 0, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metadata index %s: %w", k, err)
+		}
+		if indexed {
+			inIndex := make(map[string]bool, len(ids))
+			for _, id := range ids {
+				inIndex[id] = true
+			}
+			candidates = slices.DeleteFunc(candidates, func(id string) bool { return !inIndex[id] })
+		} else {
+			candidates, indexed = ids, true
+		}
+		if len(candidates) == 0 {
+			return []*store.Checkpoint{}, nil
+		}
+	}
+
+	if !indexed {
+		checkpointPrefix := s.prefix + "checkpoint:"
+		keys, err := s.scanKeys(ctx, checkpointPrefix+"*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan checkpoints: %w", err)
+		}
+		for _, key := range keys {
+			candidates = append(candidates, strings.TrimPrefix(key, checkpointPrefix))
+		}
+	}
+
+	checkpoints, err := s.fetchCheckpoints(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	// Also drops index entries left behind when a checkpoint was saved again
+	// with other metadata
+	return store.FilterByMetadata(checkpoints, filters, opts), nil
+}
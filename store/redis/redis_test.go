@@ -2,10 +2,13 @@ package redis
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/smallnest/langgraphgo/graph"
 	"github.com/stretchr/testify/assert"
 )
@@ -86,3 +89,131 @@ func TestRedisCheckpointStore(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, list, 0)
 }
+
+func TestNewRedisCheckpointStoreWithClient(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	// The application's client, on a database of its choosing
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 2})
+	defer client.Close()
+
+	store := NewRedisCheckpointStoreWithClient(client, RedisOptions{
+		Addr:   "ignored:6379",
+		Prefix: "app:",
+		TTL:    time.Hour,
+	})
+
+	ctx := context.Background()
+	cp := &graph.Checkpoint{
+		ID:        "cp-1",
+		Timestamp: time.Now(),
+		Version:   1,
+		Metadata:  map[string]any{"thread_id": "thread-1"},
+	}
+	assert.NoError(t, store.Save(ctx, cp))
+
+	assert.True(t, mr.DB(2).Exists("app:checkpoint:cp-1"))
+	assert.True(t, mr.DB(2).Exists("app:thread:thread-1:checkpoints"))
+	assert.Equal(t, time.Hour, mr.DB(2).TTL("app:checkpoint:cp-1"))
+	assert.False(t, mr.Exists("app:checkpoint:cp-1"))
+
+	latest, err := store.GetLatestByThread(ctx, "thread-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "cp-1", latest.ID)
+
+	// The store leaves the client open
+	assert.NoError(t, client.Ping(ctx).Err())
+}
+
+func TestListByThreadRange(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewRedisCheckpointStore(RedisOptions{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	for v := 1; v <= 10; v++ {
+		cp := &graph.Checkpoint{
+			ID:        fmt.Sprintf("cp-%d", v),
+			Timestamp: time.Now(),
+			Version:   v,
+			Metadata:  map[string]any{"thread_id": "thread", "execution_id": "exec"},
+		}
+		assert.NoError(t, store.Save(ctx, cp))
+	}
+
+	versions := func(checkpoints []*graph.Checkpoint, err error) []int {
+		t.Helper()
+		assert.NoError(t, err)
+		result := make([]int, len(checkpoints))
+		for i, cp := range checkpoints {
+			result[i] = cp.Version
+		}
+		return result
+	}
+
+	assert.Equal(t, []int{3, 4, 5, 6}, versions(store.ListByThreadRange(ctx, "thread", 3, 6, 0, false)))
+	assert.Equal(t, []int{3, 4}, versions(store.ListByThreadRange(ctx, "thread", 3, 6, 2, false)))
+	assert.Equal(t, []int{6, 5}, versions(store.ListByThreadRange(ctx, "thread", 3, 6, 2, true)))
+	assert.Equal(t, []int{8, 9, 10}, versions(store.ListByThreadRange(ctx, "thread", 8, math.MaxInt64, 0, false)))
+	assert.Equal(t, []int{1, 2}, versions(store.ListByThreadRange(ctx, "thread", math.MinInt64, 2, 0, false)))
+	assert.Empty(t, versions(store.ListByThreadRange(ctx, "thread", 11, 20, 0, false)))
+	assert.Empty(t, versions(store.ListByThreadRange(ctx, "missing", math.MinInt64, math.MaxInt64, 0, false)))
+
+	assert.Equal(t, []int{10, 9, 8, 7, 6}, versions(store.LatestByThread(ctx, "thread", 5)))
+	assert.Empty(t, versions(store.LatestByThread(ctx, "thread", 0)))
+}
+
+func TestRedisCheckpointStore_SortsByVersion(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewRedisCheckpointStore(RedisOptions{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	for v := 1; v <= 3; v++ {
+		cp := &graph.Checkpoint{
+			ID:       fmt.Sprintf("cp-%d", v),
+			Version:  v,
+			Metadata: map[string]any{"thread_id": "thread", "execution_id": "exec"},
+		}
+		assert.NoError(t, store.Save(ctx, cp))
+	}
+
+	// Stale scores put cp-3 first in both indexes
+	_, err := mr.ZAdd("langgraph:thread:thread:checkpoints", 0, "cp-3")
+	assert.NoError(t, err)
+	_, err = mr.ZAdd("langgraph:execution:exec:checkpoints", 0, "cp-3")
+	assert.NoError(t, err)
+
+	list, err := store.List(ctx, "exec")
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+	for i, cp := range list {
+		assert.Equal(t, i+1, cp.Version)
+	}
+
+	list, err = store.ListByThread(ctx, "thread")
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+	for i, cp := range list {
+		assert.Equal(t, i+1, cp.Version)
+	}
+}
+
+func TestRedisCheckpointStore_PingAndClose(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+
+	owned := NewRedisCheckpointStore(RedisOptions{Addr: mr.Addr()})
+	assert.NoError(t, owned.Ping(ctx))
+	assert.NoError(t, owned.Close())
+	assert.ErrorIs(t, owned.Ping(ctx), redis.ErrClosed, "Close closes the client the store created")
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	shared := NewRedisCheckpointStoreWithClient(client, RedisOptions{})
+	assert.NoError(t, shared.Close())
+	assert.NoError(t, client.Ping(ctx).Err(), "Close leaves an injected client open")
+
+	addr := mr.Addr()
+	mr.Close()
+	err := shared.Ping(ctx)
+	assert.ErrorContains(t, err, "redis checkpoint store at "+addr+" is unreachable")
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// RetentionPolicy limits the checkpoints kept for a thread. The latest checkpoint of
+// a thread is always kept, whatever the policy.
+type RetentionPolicy struct {
+	// MaxPerThread is the number of most recent checkpoints to keep per thread,
+	// 0 for no limit
+	MaxPerThread int
+
+	// MaxAge removes checkpoints whose Timestamp is older than this, 0 for no limit
+	MaxAge time.Duration
+}
+
+// IsZero reports whether the policy keeps every checkpoint
+func (p RetentionPolicy) IsZero() bool {
+	return p.MaxPerThread <= 0 && p.MaxAge <= 0
+}
+
+// Expired returns the checkpoints of a thread the policy removes at now, oldest
+// first. Checkpoints are ordered by version, then timestamp and ID, and the last of
+// them is never expired. The input slice is not modified.
+func (p RetentionPolicy) Expired(checkpoints []*Checkpoint, now time.Time) []*Checkpoint {
+	if p.IsZero() || len(checkpoints) <= 1 {
+		return nil
+	}
+
+	sorted := slices.Clone(checkpoints)
+	slices.SortStableFunc(sorted, func(a, b *Checkpoint) int {
+		return cmp.Or(
+			cmp.Compare(a.Version, b.Version),
+			a.Timestamp.Compare(b.Timestamp),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	// MaxPerThread keeps the checkpoints from keep on
+	keep := 0
+	if p.MaxPerThread > 0 {
+		keep = max(len(sorted)-p.MaxPerThread, 0)
+	}
+	var expired []*Checkpoint
+	for i, cp := range sorted[:len(sorted)-1] {
+		if i < keep || (p.MaxAge > 0 && now.Sub(cp.Timestamp) > p.MaxAge) {
+			expired = append(expired, cp)
+		}
+	}
+	return expired
+}
+
+// PruneThread deletes the checkpoints of threadID that policy expires and returns
+// how many it deleted.
+//
+// It is safe to call while checkpoints are being saved on the thread, or while
+// another PruneThread runs on it: only checkpoints older than the latest one it
+// listed are deleted, checkpoints saved after the listing are left for the next
+// prune, and stores treat deleting a checkpoint that is already gone as success.
+func PruneThread(ctx context.Context, s CheckpointStore, threadID string, policy RetentionPolicy) (int, error) {
+	checkpoints, err := s.ListByThread(ctx, threadID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list checkpoints for thread %s: %w", threadID, err)
+	}
+	return deleteCheckpoints(ctx, s, policy.Expired(checkpoints, time.Now()))
+}
+
+// Prune applies policy to each of threadIDs, for cleaning up a store offline, and
+// returns the number of checkpoints deleted. Without threadIDs it prunes every
+// thread of a store implementing ThreadStore. It stops at the first error.
+//
+// Example:
+//
+//	deleted, err := store.Prune(ctx, checkpoints, store.RetentionPolicy{
+//	    MaxPerThread: 20,
+//	    MaxAge:       7 * 24 * time.Hour,
+//	}, "thread-1", "thread-2")
+func Prune(ctx context.Context, s CheckpointStore, policy RetentionPolicy, threadIDs ...string) (int, error) {
+	if len(threadIDs) == 0 {
+		threads, err := ListThreads(ctx, s, ListThreadsOptions{})
+		if err != nil {
+			return 0, err
+		}
+		for _, thread := range threads {
+			threadIDs = append(threadIDs, thread.ThreadID)
+		}
+	}
+
+	total := 0
+	for _, threadID := range threadIDs {
+		deleted, err := PruneThread(ctx, s, threadID, policy)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func deleteCheckpoints(ctx context.Context, s CheckpointStore, checkpoints []*Checkpoint) (int, error) {
+	for i, cp := range checkpoints {
+		if err := s.Delete(ctx, cp.ID); err != nil {
+			return i, fmt.Errorf("failed to delete checkpoint %s: %w", cp.ID, err)
+		}
+	}
+	return len(checkpoints), nil
+}
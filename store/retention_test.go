@@ -0,0 +1,136 @@
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+func threadVersions(t *testing.T, s store.CheckpointStore, threadID string) []int {
+	t.Helper()
+	checkpoints, err := s.ListByThread(context.Background(), threadID)
+	if err != nil {
+		t.Fatalf("ListByThread failed: %v", err)
+	}
+	versions := make([]int, len(checkpoints))
+	for i, cp := range checkpoints {
+		versions[i] = cp.Version
+	}
+	return versions
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	hourly := func(v int) time.Time { return now.Add(time.Duration(v-10) * time.Hour) }
+
+	tests := []struct {
+		name    string
+		policy  store.RetentionPolicy
+		deleted int
+		want    []int
+	}{
+		{"MaxPerThread", store.RetentionPolicy{MaxPerThread: 3}, 7, []int{8, 9, 10}},
+		{"MaxAge", store.RetentionPolicy{MaxAge: 150 * time.Minute}, 7, []int{8, 9, 10}},
+		{"Both", store.RetentionPolicy{MaxPerThread: 5, MaxAge: 90 * time.Minute}, 8, []int{9, 10}},
+		{"MaxAgeKeepsLatest", store.RetentionPolicy{MaxAge: time.Minute}, 9, []int{10}},
+		{"Zero", store.RetentionPolicy{}, 0, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	}
+
+	for name, newStore := range testStores() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					ctx := context.Background()
+					s := newStore(t)
+					saveThread(t, s, "thread-a", 10, hourly)
+					saveThread(t, s, "thread-b", 2, hourly)
+
+					deleted, err := store.Prune(ctx, s, tt.policy, "thread-a")
+					if err != nil {
+						t.Fatalf("Prune failed: %v", err)
+					}
+					if deleted != tt.deleted {
+						t.Errorf("Expected %d deleted, got %d", tt.deleted, deleted)
+					}
+					if got := threadVersions(t, s, "thread-a"); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+						t.Errorf("Expected versions %v, got %v", tt.want, got)
+					}
+					if got := threadVersions(t, s, "thread-b"); len(got) != 2 {
+						t.Errorf("Expected thread-b to be untouched, got versions %v", got)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestPrune_ConcurrentSaves(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range testStores() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			s := newStore(t)
+			policy := store.RetentionPolicy{MaxPerThread: 2}
+
+			var wg sync.WaitGroup
+			errs := make(chan error, 100)
+			wg.Go(func() {
+				for v := 1; v <= 30; v++ {
+					cp := &store.Checkpoint{
+						ID:        fmt.Sprintf("cp-%d", v),
+						State:     map[string]any{"step": v},
+						Metadata:  map[string]any{"thread_id": "thread"},
+						Timestamp: time.Now(),
+						Version:   v,
+					}
+					if err := s.Save(ctx, cp); err != nil {
+						errs <- err
+						return
+					}
+					latest, err := s.GetLatestByThread(ctx, "thread")
+					if err != nil {
+						errs <- fmt.Errorf("latest checkpoint missing after saving version %d: %w", v, err)
+						return
+					}
+					if latest.Version != v {
+						errs <- fmt.Errorf("expected latest version %d, got %d", v, latest.Version)
+						return
+					}
+				}
+			})
+			for range 3 {
+				wg.Go(func() {
+					for range 20 {
+						if _, err := store.PruneThread(ctx, s, "thread", policy); err != nil {
+							errs <- err
+							return
+						}
+					}
+				})
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Error(err)
+			}
+
+			if _, err := store.PruneThread(ctx, s, "thread", policy); err != nil {
+				t.Fatalf("PruneThread failed: %v", err)
+			}
+			if got := threadVersions(t, s, "thread"); fmt.Sprint(got) != "[29 30]" {
+				t.Errorf("Expected versions [29 30], got %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package s3 provides checkpoint storage on S3 and S3-compatible object storage
+// such as MinIO, for workers without local disk, like serverless functions.
+//
+// # Key Layout
+//
+// Checkpoints are grouped by thread_id, or by execution_id when they have no
+// thread, and stored under the store's prefix:
+//
+//	prefix/thread_id/0000000003-checkpointID.json   the checkpoint
+//	prefix/thread_id/latest.json                    newest checkpoint, with LatestPointer
+//	prefix/_ids/checkpointID                        key of the checkpoint, for Load
+//
+// Versions are zero-padded, so ListObjectsV2 on a thread's prefix returns its
+// checkpoints in version order. GetLatestByThread lists the thread and loads the
+// last checkpoint, or reads latest.json when LatestPointer is set.
+//
+// # Basic Usage
+//
+//	import (
+//		"github.com/aws/aws-sdk-go-v2/config"
+//		awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+//		"github.com/smallnest/langgraphgo/store/s3"
+//	)
+//
+//	cfg, err := config.LoadDefaultConfig(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	store := s3.NewS3CheckpointStore(s3.S3Options{
+//		Client:        awss3.NewFromConfig(cfg),
+//		Bucket:        "my-checkpoints",
+//		Prefix:        "agents/support",
+//		LatestPointer: true,
+//	})
+//
+// For MinIO or localstack, point the client at the service:
+//
+//	client := awss3.NewFromConfig(cfg, func(o *awss3.Options) {
+//		o.BaseEndpoint = aws.String("http://localhost:9000")
+//		o.UsePathStyle = true
+//	})
+//
+// Any type with the methods of Client can be used instead of *s3.Client, such as
+// an in-memory fake in tests.
+//
+// # Consistency
+//
+// Stores with eventually consistent reads may not find an object right after it
+// was written. Load retries objects that are not found, MissingRetries times with
+// exponential backoff from RetryDelay. The latest.json pointer is updated without
+// locking, so concurrent saves to one thread may leave it at an older checkpoint
+// for a while; a pointer to a deleted checkpoint falls back to listing.
+//
+// # Limitations
+//
+// List, ListByThread and Clear take a thread ID, or the execution ID of
+// checkpoints saved without a thread. A threaded checkpoint is not listed by its
+// execution ID.
+package s3
@@ -0,0 +1,460 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/smallnest/langgraphgo/store"
+)
+
+// Client is the subset of the S3 API the store uses. *s3.Client from the AWS SDK
+// satisfies it, for AWS and S3-compatible services such as MinIO or localstack.
+// Useful for testing with fakes.
+type Client interface {
+	PutObject(ctx context.Context, params *awss3.PutObjectInput, optFns ...func(*awss3.Options)) (*awss3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *awss3.GetObjectInput, optFns ...func(*awss3.Options)) (*awss3.GetObjectOutput, error)
+	DeleteObject(ctx context.Context, params *awss3.DeleteObjectInput, optFns ...func(*awss3.Options)) (*awss3.DeleteObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *awss3.ListObjectsV2Input, optFns ...func(*awss3.Options)) (*awss3.ListObjectsV2Output, error)
+}
+
+// S3CheckpointStore implements store.CheckpointStore on S3-compatible object storage.
+//
+// Checkpoints are grouped by their thread_id, or their execution_id when they have
+// no thread, and stored as prefix/group/version-checkpointID.json with the version
+// zero-padded, so that listing a group returns its checkpoints in version order.
+// A small object prefix/_ids/checkpointID records the key of each checkpoint for Load.
+type S3CheckpointStore struct {
+	client        Client
+	bucket        string
+	prefix        string
+	latestPointer bool
+	retries       int
+	retryDelay    time.Duration
+}
+
+// S3Options configuration for the S3 checkpoint store
+type S3Options struct {
+	Client Client
+	Bucket string
+	Prefix string // Key prefix, default "checkpoints"
+
+	// LatestPointer makes Save write prefix/group/latest.json naming the newest
+	// checkpoint of the group, so GetLatestByThread reads it instead of listing
+	LatestPointer bool
+
+	// MissingRetries is how many times Load retries an object that is not found,
+	// for stores that don't see their own writes at once, default 3, or none when
+	// negative. RetryDelay is the wait before the first retry, doubled for each
+	// retry after it, default 100ms.
+	MissingRetries int
+	RetryDelay     time.Duration
+}
+
+// latestPointer is the content of a group's latest.json object
+type latestPointer struct {
+	Key     string `json:"key"`
+	Version int    `json:"version"`
+}
+
+// NewS3CheckpointStore creates a new S3 checkpoint store
+//
+// Example:
+//
+//	cfg, err := config.LoadDefaultConfig(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	store := s3.NewS3CheckpointStore(s3.S3Options{
+//	    Client: awss3.NewFromConfig(cfg),
+//	    Bucket: "my-checkpoints",
+//	})
+func NewS3CheckpointStore(opts S3Options) *S3CheckpointStore {
+	prefix := strings.Trim(opts.Prefix, "/")
+	if prefix == "" {
+		prefix = "checkpoints"
+	}
+
+	retries := opts.MissingRetries
+	if retries == 0 {
+		retries = 3
+	}
+	retryDelay := opts.RetryDelay
+	if retryDelay == 0 {
+		retryDelay = 100 * time.Millisecond
+	}
+
+	return &S3CheckpointStore{
+		client:        opts.Client,
+		bucket:        opts.Bucket,
+		prefix:        prefix,
+		latestPointer: opts.LatestPointer,
+		retries:       max(retries, 0),
+		retryDelay:    retryDelay,
+	}
+}
+
+// groupPrefix returns the prefix of the checkpoint objects of a thread or execution
+func (s *S3CheckpointStore) groupPrefix(group string) string {
+	return path.Join(s.prefix, url.PathEscape(group)) + "/"
+}
+
+func (s *S3CheckpointStore) checkpointKey(group string, version int, id string) string {
+	return fmt.Sprintf("%s%010d-%s.json", s.groupPrefix(group), version, url.PathEscape(id))
+}
+
+func (s *S3CheckpointStore) idKey(id string) string {
+	return path.Join(s.prefix, "_ids", url.PathEscape(id))
+}
+
+func (s *S3CheckpointStore) latestKey(group string) string {
+	return s.groupPrefix(group) + "latest.json"
+}
+
+// checkpointGroup returns the thread_id of checkpoint, or its execution_id
+func checkpointGroup(checkpoint *store.Checkpoint) string {
+	if threadID, ok := checkpoint.Metadata["thread_id"].(string); ok && threadID != "" {
+		return threadID
+	}
+	executionID, _ := checkpoint.Metadata["execution_id"].(string)
+	return executionID
+}
+
+// Save stores a checkpoint. A checkpoint saved again with another version or
+// thread replaces the object it was saved in before.
+func (s *S3CheckpointStore) Save(ctx context.Context, checkpoint *store.Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	group := checkpointGroup(checkpoint)
+	key := s.checkpointKey(group, checkpoint.Version, checkpoint.ID)
+
+	previous, err := s.getObject(ctx, s.idKey(checkpoint.ID))
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	if err := s.putObject(ctx, key, data); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := s.putObject(ctx, s.idKey(checkpoint.ID), []byte(key)); err != nil {
+		return fmt.Errorf("failed to save checkpoint index: %w", err)
+	}
+	if previous != nil && string(previous) != key {
+		if err := s.deleteObject(ctx, string(previous)); err != nil {
+			return fmt.Errorf("failed to delete replaced checkpoint: %w", err)
+		}
+	}
+
+	if s.latestPointer {
+		if err := s.updateLatest(ctx, group, key, checkpoint.Version); err != nil {
+			return fmt.Errorf("failed to update latest checkpoint pointer: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateLatest points the latest.json object of group at key, unless it already
+// names a higher version. Concurrent saves to the same group may race; the pointer
+// is checked when read, and listing is the fallback.
+func (s *S3CheckpointStore) updateLatest(ctx context.Context, group, key string, version int) error {
+	current, err := s.readLatest(ctx, group)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if current != nil && current.Version > version {
+		return nil
+	}
+	data, err := json.Marshal(latestPointer{Key: key, Version: version})
+	if err != nil {
+		return err
+	}
+	return s.putObject(ctx, s.latestKey(group), data)
+}
+
+func (s *S3CheckpointStore) readLatest(ctx context.Context, group string) (*latestPointer, error) {
+	data, err := s.getObject(ctx, s.latestKey(group))
+	if err != nil {
+		return nil, err
+	}
+	var pointer latestPointer
+	if err := json.Unmarshal(data, &pointer); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal latest checkpoint pointer: %w", err)
+	}
+	return &pointer, nil
+}
+
+// Load retrieves a checkpoint by ID. Objects that are not found are retried, so
+// that a checkpoint can be loaded right after it was saved on stores with
+// eventually consistent reads.
+func (s *S3CheckpointStore) Load(ctx context.Context, checkpointID string) (*store.Checkpoint, error) {
+	var checkpoint *store.Checkpoint
+	err := s.retryMissing(ctx, func() error {
+		key, err := s.getObject(ctx, s.idKey(checkpointID))
+		if err != nil {
+			return err
+		}
+		checkpoint, err = s.loadKey(ctx, string(key))
+		return err
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("checkpoint not found: %s", checkpointID)
+		}
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// retryMissing calls fn until it returns an error other than a missing object, or
+// the retries are used up
+func (s *S3CheckpointStore) retryMissing(ctx context.Context, fn func() error) error {
+	delay := s.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !isNotFound(err) || attempt >= s.retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (s *S3CheckpointStore) loadKey(ctx context.Context, key string) (*store.Checkpoint, error) {
+	data, err := s.getObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint store.Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// List returns the checkpoints of a thread, or of an execution without a thread,
+// sorted by version
+func (s *S3CheckpointStore) List(ctx context.Context, executionID string) ([]*store.Checkpoint, error) {
+	return s.ListByThread(ctx, executionID)
+}
+
+// ListByThread returns all checkpoints for a specific thread_id, sorted by version
+func (s *S3CheckpointStore) ListByThread(ctx context.Context, threadID string) ([]*store.Checkpoint, error) {
+	keys, err := s.listGroup(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	checkpoints := make([]*store.Checkpoint, 0, len(keys))
+	for _, key := range keys {
+		checkpoint, err := s.loadKey(ctx, key.key)
+		if err != nil {
+			if isNotFound(err) {
+				// Deleted since it was listed
+				continue
+			}
+			return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints, nil
+}
+
+// GetLatestByThread returns the checkpoint of a thread with the highest version.
+// With LatestPointer it reads the thread's latest.json, and lists the thread if the
+// pointer is missing or stale.
+func (s *S3CheckpointStore) GetLatestByThread(ctx context.Context, threadID string) (*store.Checkpoint, error) {
+	if s.latestPointer {
+		pointer, err := s.readLatest(ctx, threadID)
+		if err != nil && !isNotFound(err) {
+			return nil, fmt.Errorf("failed to get latest checkpoint: %w", err)
+		}
+		if pointer != nil {
+			checkpoint, err := s.loadKey(ctx, pointer.Key)
+			if err == nil {
+				return checkpoint, nil
+			}
+			if !isNotFound(err) {
+				return nil, fmt.Errorf("failed to get latest checkpoint: %w", err)
+			}
+		}
+	}
+
+	keys, err := s.listGroup(ctx, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest checkpoint: %w", err)
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		checkpoint, err := s.loadKey(ctx, keys[i].key)
+		if err == nil {
+			return checkpoint, nil
+		}
+		if !isNotFound(err) {
+			return nil, fmt.Errorf("failed to get latest checkpoint: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("no checkpoints found for thread: %s", threadID)
+}
+
+// Delete removes a checkpoint
+func (s *S3CheckpointStore) Delete(ctx context.Context, checkpointID string) error {
+	key, err := s.getObject(ctx, s.idKey(checkpointID))
+	if err != nil {
+		if isNotFound(err) {
+			// Already deleted
+			return nil
+		}
+		return fmt.Errorf("failed to delete checkpoint: %w", err)
+	}
+	if err := s.deleteCheckpoint(ctx, string(key), checkpointID); err != nil {
+		return fmt.Errorf("failed to delete checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Clear removes all checkpoints of a thread, or of an execution without a thread
+func (s *S3CheckpointStore) Clear(ctx context.Context, executionID string) error {
+	keys, err := s.listGroup(ctx, executionID)
+	if err != nil {
+		return fmt.Errorf("failed to clear checkpoints: %w", err)
+	}
+
+	var errs []error
+	for _, key := range keys {
+		if err := s.deleteCheckpoint(ctx, key.key, key.id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := s.deleteObject(ctx, s.latestKey(executionID)); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to clear checkpoints: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// deleteCheckpoint deletes the object of a checkpoint and its ids entry
+func (s *S3CheckpointStore) deleteCheckpoint(ctx context.Context, key, id string) error {
+	if err := s.deleteObject(ctx, key); err != nil {
+		return err
+	}
+	return s.deleteObject(ctx, s.idKey(id))
+}
+
+// groupObject is a checkpoint object found by listing a group
+type groupObject struct {
+	key     string
+	id      string
+	version int
+}
+
+// listGroup returns the checkpoint objects of group sorted by version, following
+// the pages of ListObjectsV2
+func (s *S3CheckpointStore) listGroup(ctx context.Context, group string) ([]groupObject, error) {
+	prefix := s.groupPrefix(group)
+	var objects []groupObject
+	var token *string
+	for {
+		out, err := s.client.ListObjectsV2(ctx, &awss3.ListObjectsV2Input{
+			Bucket:            aws.String(s.bucket),
+			Prefix:            aws.String(prefix),
+			ContinuationToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range out.Contents {
+			key := aws.ToString(obj.Key)
+			if object, ok := parseCheckpointKey(prefix, key); ok {
+				objects = append(objects, object)
+			}
+		}
+		if !aws.ToBool(out.IsTruncated) || out.NextContinuationToken == nil {
+			break
+		}
+		token = out.NextContinuationToken
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].version < objects[j].version
+	})
+	return objects, nil
+}
+
+// parseCheckpointKey parses a version-checkpointID.json key in the group prefix.
+// Other objects, such as latest.json, are skipped.
+func parseCheckpointKey(prefix, key string) (groupObject, bool) {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(key, prefix), ".json")
+	if !ok || strings.Contains(name, "/") {
+		return groupObject{}, false
+	}
+	versionPart, idPart, ok := strings.Cut(name, "-")
+	if !ok {
+		return groupObject{}, false
+	}
+	version, err := strconv.Atoi(versionPart)
+	if err != nil {
+		return groupObject{}, false
+	}
+	id, err := url.PathUnescape(idPart)
+	if err != nil {
+		return groupObject{}, false
+	}
+	return groupObject{key: key, id: id, version: version}, true
+}
+
+func (s *S3CheckpointStore) putObject(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &awss3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+func (s *S3CheckpointStore) getObject(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &awss3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *S3CheckpointStore) deleteObject(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &awss3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// isNotFound reports whether err is S3's error for a missing object
+func isNotFound(err error) bool {
+	var noSuchKey *s3types.NoSuchKey
+	var notFound *s3types.NotFound
+	return errors.As(err, &noSuchKey) || errors.As(err, &notFound)
+}
@@ -0,0 +1,234 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/smallnest/langgraphgo/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ store.CheckpointStore = (*S3CheckpointStore)(nil)
+
+// fakeClient is an in-memory bucket. Listing returns pages of two keys, and keys
+// in lagging are not found by the next reads, like an eventually consistent store.
+type fakeClient struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	lagging map[string]int
+	lists   int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{objects: map[string][]byte{}, lagging: map[string]int{}}
+}
+
+func (c *fakeClient) PutObject(_ context.Context, in *awss3.PutObjectInput, _ ...func(*awss3.Options)) (*awss3.PutObjectOutput, error) {
+	data, err := io.ReadAll(in.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.objects[aws.ToString(in.Key)] = data
+	return &awss3.PutObjectOutput{}, nil
+}
+
+func (c *fakeClient) GetObject(_ context.Context, in *awss3.GetObjectInput, _ ...func(*awss3.Options)) (*awss3.GetObjectOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := aws.ToString(in.Key)
+	if c.lagging[key] > 0 {
+		c.lagging[key]--
+		return nil, &s3types.NoSuchKey{}
+	}
+	data, ok := c.objects[key]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &awss3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (c *fakeClient) DeleteObject(_ context.Context, in *awss3.DeleteObjectInput, _ ...func(*awss3.Options)) (*awss3.DeleteObjectOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.objects, aws.ToString(in.Key))
+	return &awss3.DeleteObjectOutput{}, nil
+}
+
+func (c *fakeClient) ListObjectsV2(_ context.Context, in *awss3.ListObjectsV2Input, _ ...func(*awss3.Options)) (*awss3.ListObjectsV2Output, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lists++
+
+	var keys []string
+	for key := range c.objects {
+		if strings.HasPrefix(key, aws.ToString(in.Prefix)) && key > aws.ToString(in.ContinuationToken) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	out := &awss3.ListObjectsV2Output{}
+	for _, key := range keys {
+		if len(out.Contents) == 2 {
+			out.IsTruncated = aws.Bool(true)
+			out.NextContinuationToken = out.Contents[1].Key
+			break
+		}
+		out.Contents = append(out.Contents, s3types.Object{Key: aws.String(key)})
+	}
+	return out, nil
+}
+
+func (c *fakeClient) keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []string
+	for key := range c.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newCheckpoint(id string, version int, metadata map[string]any) *store.Checkpoint {
+	return &store.Checkpoint{
+		ID:        id,
+		NodeName:  "node",
+		State:     map[string]any{"version": float64(version)},
+		Metadata:  metadata,
+		Timestamp: time.Now(),
+		Version:   version,
+	}
+}
+
+func TestS3CheckpointStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	s := NewS3CheckpointStore(S3Options{Client: client, Bucket: "bucket", Prefix: "app", RetryDelay: time.Millisecond})
+
+	require.NoError(t, s.Save(ctx, newCheckpoint("cp-1", 1, map[string]any{"thread_id": "thread-1", "execution_id": "exec-1"})))
+	require.NoError(t, s.Save(ctx, newCheckpoint("cp-2", 1, map[string]any{"execution_id": "exec-2"})))
+	assert.Equal(t, []string{
+		"app/_ids/cp-1",
+		"app/_ids/cp-2",
+		"app/exec-2/0000000001-cp-2.json",
+		"app/thread-1/0000000001-cp-1.json",
+	}, client.keys())
+
+	loaded, err := s.Load(ctx, "cp-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"version": float64(1)}, loaded.State)
+	assert.Equal(t, "thread-1", loaded.Metadata["thread_id"])
+
+	// Checkpoints without a thread are listed by their execution
+	list, err := s.List(ctx, "exec-2")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "cp-2", list[0].ID)
+
+	require.NoError(t, s.Delete(ctx, "cp-1"))
+	_, err = s.Load(ctx, "cp-1")
+	assert.ErrorContains(t, err, "checkpoint not found: cp-1")
+	assert.Equal(t, []string{"app/_ids/cp-2", "app/exec-2/0000000001-cp-2.json"}, client.keys())
+}
+
+func TestS3CheckpointStore_VersionOrder(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	s := NewS3CheckpointStore(S3Options{Client: client, Bucket: "bucket"})
+
+	for _, version := range []int{3, 1, 10, 2, 7} {
+		require.NoError(t, s.Save(ctx, newCheckpoint(fmt.Sprintf("cp-%d", version), version, map[string]any{"thread_id": "thread-1"})))
+	}
+	require.NoError(t, s.Save(ctx, newCheckpoint("other", 99, map[string]any{"thread_id": "thread-10"})))
+
+	// Listing follows the pages of two keys
+	list, err := s.ListByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	var versions []int
+	for _, cp := range list {
+		versions = append(versions, cp.Version)
+	}
+	assert.Equal(t, []int{1, 2, 3, 7, 10}, versions)
+
+	latest, err := s.GetLatestByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cp-10", latest.ID)
+
+	// Saving again under another version replaces the object
+	require.NoError(t, s.Save(ctx, newCheckpoint("cp-10", 4, map[string]any{"thread_id": "thread-1"})))
+	latest, err = s.GetLatestByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cp-7", latest.ID)
+	list, err = s.ListByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Len(t, list, 5)
+
+	require.NoError(t, s.Clear(ctx, "thread-1"))
+	list, err = s.ListByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	_, err = s.GetLatestByThread(ctx, "thread-1")
+	assert.ErrorContains(t, err, "no checkpoints found for thread: thread-1")
+	assert.Equal(t, []string{"checkpoints/_ids/other", "checkpoints/thread-10/0000000099-other.json"}, client.keys())
+}
+
+func TestS3CheckpointStore_LatestPointer(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	s := NewS3CheckpointStore(S3Options{Client: client, Bucket: "bucket", LatestPointer: true})
+
+	for _, version := range []int{1, 3, 2} {
+		require.NoError(t, s.Save(ctx, newCheckpoint(fmt.Sprintf("cp-%d", version), version, map[string]any{"thread_id": "thread-1"})))
+	}
+
+	// The pointer keeps the highest version and is read without listing
+	latest, err := s.GetLatestByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cp-3", latest.ID)
+	assert.Zero(t, client.lists)
+
+	// A pointer to a deleted checkpoint falls back to listing
+	require.NoError(t, s.Delete(ctx, "cp-3"))
+	latest, err = s.GetLatestByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cp-2", latest.ID)
+	assert.Positive(t, client.lists)
+}
+
+func TestS3CheckpointStore_LoadRetriesMissing(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	s := NewS3CheckpointStore(S3Options{Client: client, Bucket: "bucket", RetryDelay: time.Millisecond})
+
+	require.NoError(t, s.Save(ctx, newCheckpoint("cp-1", 1, map[string]any{"thread_id": "thread-1"})))
+	client.lagging["checkpoints/_ids/cp-1"] = 1
+	client.lagging["checkpoints/thread-1/0000000001-cp-1.json"] = 2
+
+	loaded, err := s.Load(ctx, "cp-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cp-1", loaded.ID)
+
+	// Retries are limited
+	client.lagging["checkpoints/_ids/cp-1"] = 10
+	_, err = s.Load(ctx, "cp-1")
+	assert.ErrorContains(t, err, "checkpoint not found: cp-1")
+
+	none := NewS3CheckpointStore(S3Options{Client: client, Bucket: "bucket", MissingRetries: -1})
+	client.lagging["checkpoints/_ids/cp-1"] = 1
+	_, err = none.Load(ctx, "cp-1")
+	assert.Error(t, err)
+}
@@ -85,24 +85,16 @@
 //
 // ## WAL Mode for Concurrency
 //
-//	// Enable Write-Ahead Logging for better concurrent access
+// File databases are opened in Write-Ahead Logging mode with a busy timeout, so
+// readers don't block writers and concurrent writers, from the same process or
+// several, wait for each other instead of failing with SQLITE_BUSY:
+//
 //	store, err := sqlite.NewSqliteCheckpointStore(sqlite.SqliteOptions{
 //		Path: "./checkpoints.db",
 //	})
 //
-//	// Enable WAL mode
-//	if err := store.EnableWAL(context.Background()); err != nil {
-//		return err
-//	}
-//
-//	// Configure WAL checkpointing
-//	if err := store.SetWALCheckpointMode(
-//		context.Background(),
-//		sqlite.WALCheckpointPassive,
-//		1000, // WAL size threshold
-//	); err != nil {
-//		return err
-//	}
+// The store uses the pure Go modernc.org/sqlite driver, so binaries using it can be
+// built with CGO_ENABLED=0.
 //
 // ## Custom Schema
 //
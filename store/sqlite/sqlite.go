@@ -5,15 +5,18 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"sort"
+	"maps"
+	"slices"
+	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
-	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store"
+	_ "modernc.org/sqlite"
 )
 
-// SqliteCheckpointStore implements graph.CheckpointStore using SQLite
+// SqliteCheckpointStore implements store.CheckpointStore using SQLite
 type SqliteCheckpointStore struct {
 	db        *sql.DB
+	path      string
 	tableName string
 }
 
@@ -23,12 +26,22 @@ type SqliteOptions struct {
 	TableName string // Default "checkpoints"
 }
 
-// NewSqliteCheckpointStore creates a new SQLite checkpoint store
+// checkpointColumns are the columns a checkpoint is read from, in scan order
+const checkpointColumns = "id, node_name, state, metadata, timestamp, version"
+
+// NewSqliteCheckpointStore creates a new SQLite checkpoint store, using the pure Go
+// modernc.org/sqlite driver so that no cgo toolchain is needed. File databases are
+// opened in WAL mode with a busy timeout, so that concurrent writers, including other
+// processes, wait for each other instead of failing with SQLITE_BUSY.
 func NewSqliteCheckpointStore(opts SqliteOptions) (*SqliteCheckpointStore, error) {
-	db, err := sql.Open("sqlite3", opts.Path)
+	db, err := sql.Open("sqlite", sqliteDSN(opts.Path))
 	if err != nil {
 		return nil, fmt.Errorf("unable to open database: %w", err)
 	}
+	if isMemoryPath(opts.Path) {
+		// Every connection to an in-memory database opens a new, empty database
+		db.SetMaxOpenConns(1)
+	}
 
 	tableName := opts.TableName
 	if tableName == "" {
@@ -37,6 +50,7 @@ func NewSqliteCheckpointStore(opts SqliteOptions) (*SqliteCheckpointStore, error
 
 	store := &SqliteCheckpointStore{
 		db:        db,
+		path:      opts.Path,
 		tableName: tableName,
 	}
 
@@ -48,6 +62,25 @@ func NewSqliteCheckpointStore(opts SqliteOptions) (*SqliteCheckpointStore, error
 	return store, nil
 }
 
+// sqliteDSN returns the data source name of path, with the pragmas every connection
+// applies
+func sqliteDSN(path string) string {
+	pragmas := []string{"_pragma=busy_timeout(5000)"}
+	if !isMemoryPath(path) {
+		pragmas = append(pragmas, "_pragma=journal_mode(WAL)", "_pragma=synchronous(NORMAL)")
+	}
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + strings.Join(pragmas, "&")
+}
+
+// isMemoryPath reports whether path names an in-memory database
+func isMemoryPath(path string) bool {
+	return path == "" || path == ":memory:" || strings.Contains(path, "mode=memory")
+}
+
 // InitSchema creates the necessary table if it doesn't exist
 func (s *SqliteCheckpointStore) InitSchema(ctx context.Context) error {
 	query := fmt.Sprintf(`
@@ -77,8 +110,18 @@ func (s *SqliteCheckpointStore) Close() error {
 	return s.db.Close()
 }
 
+// Ping checks that the checkpoint table can be queried, returning an error naming
+// the database if not
+func (s *SqliteCheckpointStore) Ping(ctx context.Context) error {
+	query := fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", s.tableName)
+	if err := s.db.QueryRowContext(ctx, query).Scan(new(int)); err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("sqlite checkpoint store %s is unreachable: %w", s.path, err)
+	}
+	return nil
+}
+
 // Save stores a checkpoint
-func (s *SqliteCheckpointStore) Save(ctx context.Context, checkpoint *graph.Checkpoint) error {
+func (s *SqliteCheckpointStore) Save(ctx context.Context, checkpoint *store.Checkpoint) error {
 	stateJSON, err := json.Marshal(checkpoint.State)
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
@@ -132,98 +175,34 @@ func (s *SqliteCheckpointStore) Save(ctx context.Context, checkpoint *graph.Chec
 }
 
 // Load retrieves a checkpoint by ID
-func (s *SqliteCheckpointStore) Load(ctx context.Context, checkpointID string) (*graph.Checkpoint, error) {
+func (s *SqliteCheckpointStore) Load(ctx context.Context, checkpointID string) (*store.Checkpoint, error) {
 	// nolint:gosec // G201: Table name cannot be parameterized, but all values use parameterized queries
-	query := fmt.Sprintf(`
-		SELECT id, node_name, state, metadata, timestamp, version
-		FROM %s
-		WHERE id = ?
-	`, s.tableName)
-
-	var cp graph.Checkpoint
-	var stateJSON string
-	var metadataJSON string
-
-	err := s.db.QueryRowContext(ctx, query, checkpointID).Scan(
-		&cp.ID,
-		&cp.NodeName,
-		&stateJSON,
-		&metadataJSON,
-		&cp.Timestamp,
-		&cp.Version,
-	)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = ?", checkpointColumns, s.tableName)
 
+	cp, err := scanCheckpoint(s.db.QueryRowContext(ctx, query, checkpointID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("checkpoint not found: %s", checkpointID)
 		}
 		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
 	}
-
-	if err := json.Unmarshal([]byte(stateJSON), &cp.State); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
-	}
-
-	if len(metadataJSON) > 0 {
-		if err := json.Unmarshal([]byte(metadataJSON), &cp.Metadata); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-		}
-	}
-
-	return &cp, nil
+	return cp, nil
 }
 
-// List returns all checkpoints for a given execution
-func (s *SqliteCheckpointStore) List(ctx context.Context, executionID string) ([]*graph.Checkpoint, error) {
+// List returns all checkpoints for a given execution, sorted by version
+func (s *SqliteCheckpointStore) List(ctx context.Context, executionID string) ([]*store.Checkpoint, error) {
 	// nolint:gosec // G201: Table name cannot be parameterized, but all values use parameterized queries
 	query := fmt.Sprintf(`
-		SELECT id, node_name, state, metadata, timestamp, version
+		SELECT %s
 		FROM %s
 		WHERE execution_id = ?
-		ORDER BY timestamp ASC
-	`, s.tableName)
+		ORDER BY version ASC, timestamp ASC
+	`, checkpointColumns, s.tableName)
 
-	rows, err := s.db.QueryContext(ctx, query, executionID)
+	checkpoints, err := s.query(ctx, query, executionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
 	}
-	defer rows.Close()
-
-	var checkpoints []*graph.Checkpoint
-	for rows.Next() {
-		var cp graph.Checkpoint
-		var stateJSON string
-		var metadataJSON string
-
-		err := rows.Scan(
-			&cp.ID,
-			&cp.NodeName,
-			&stateJSON,
-			&metadataJSON,
-			&cp.Timestamp,
-			&cp.Version,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan checkpoint row: %w", err)
-		}
-
-		if err := json.Unmarshal([]byte(stateJSON), &cp.State); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal state: %w", err)
-		}
-
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal([]byte(metadataJSON), &cp.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
-		}
-
-		checkpoints = append(checkpoints, &cp)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating checkpoint rows: %w", err)
-	}
-
 	return checkpoints, nil
 }
 
@@ -249,76 +228,138 @@ func (s *SqliteCheckpointStore) Clear(ctx context.Context, executionID string) e
 	return nil
 }
 
-// ListByThread returns all checkpoints for a specific thread_id
-func (s *SqliteCheckpointStore) ListByThread(ctx context.Context, threadID string) ([]*graph.Checkpoint, error) {
+// ListByThread returns all checkpoints for a specific thread_id, sorted by version
+func (s *SqliteCheckpointStore) ListByThread(ctx context.Context, threadID string) ([]*store.Checkpoint, error) {
 	// nolint:gosec // G201: Table name cannot be parameterized, but all values use parameterized queries
 	query := fmt.Sprintf(`
-		SELECT id, node_name, state, metadata, timestamp, version
+		SELECT %s
 		FROM %s
 		WHERE thread_id = ?
-		ORDER BY timestamp ASC
-	`, s.tableName)
+		ORDER BY version ASC, timestamp ASC
+	`, checkpointColumns, s.tableName)
 
-	rows, err := s.db.QueryContext(ctx, query, threadID)
+	checkpoints, err := s.query(ctx, query, threadID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list checkpoints by thread: %w", err)
 	}
-	defer rows.Close()
+	return checkpoints, nil
+}
 
-	var checkpoints []*graph.Checkpoint
-	for rows.Next() {
-		var cp graph.Checkpoint
-		var stateJSON string
-		var metadataJSON string
-
-		err := rows.Scan(
-			&cp.ID,
-			&cp.NodeName,
-			&stateJSON,
-			&metadataJSON,
-			&cp.Timestamp,
-			&cp.Version,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan checkpoint row: %w", err)
-		}
+// GetLatestByThread returns the latest checkpoint for a thread_id
+func (s *SqliteCheckpointStore) GetLatestByThread(ctx context.Context, threadID string) (*store.Checkpoint, error) {
+	// nolint:gosec // G201: Table name cannot be parameterized, but all values use parameterized queries
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE thread_id = ?
+		ORDER BY version DESC, timestamp DESC
+		LIMIT 1
+	`, checkpointColumns, s.tableName)
 
-		if err := json.Unmarshal([]byte(stateJSON), &cp.State); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	cp, err := scanCheckpoint(s.db.QueryRowContext(ctx, query, threadID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no checkpoints found for thread: %s", threadID)
 		}
+		return nil, fmt.Errorf("failed to get latest checkpoint: %w", err)
+	}
+	return cp, nil
+}
 
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal([]byte(metadataJSON), &cp.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
+// ListByMetadata returns the checkpoints whose metadata matches filters, sorted by
+// timestamp. Each filter is a json_extract condition of the WHERE clause, so values
+// are compared by their JSON type and value.
+func (s *SqliteCheckpointStore) ListByMetadata(ctx context.Context, filters map[string]any, opts store.ListOptions) ([]*store.Checkpoint, error) {
+	conditions := []string{"1 = 1"}
+	var args []any
+	for _, key := range slices.Sorted(maps.Keys(filters)) {
+		if strings.Contains(key, `"`) {
+			return nil, fmt.Errorf("invalid metadata key %q", key)
+		}
+		value, err := json.Marshal(filters[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata filter %s: %w", key, err)
 		}
+		conditions = append(conditions, "json_extract(metadata, ?) = json_extract(?, '$')")
+		args = append(args, `$."`+key+`"`, string(value))
+	}
 
-		checkpoints = append(checkpoints, &cp)
+	order := "ASC"
+	if opts.Descending {
+		order = "DESC"
 	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit, max(opts.Offset, 0))
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating checkpoint rows: %w", err)
+	// nolint:gosec // G201: Table name cannot be parameterized, but all values use parameterized queries
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE %s
+		ORDER BY timestamp %s, version %s, id %s
+		LIMIT ? OFFSET ?
+	`, checkpointColumns, s.tableName, strings.Join(conditions, " AND "), order, order, order)
+
+	checkpoints, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints by metadata: %w", err)
 	}
+	return checkpoints, nil
+}
 
-	// Sort by version ascending
-	sort.Slice(checkpoints, func(i, j int) bool {
-		return checkpoints[i].Version < checkpoints[j].Version
-	})
+// query returns the checkpoints selected by query
+func (s *SqliteCheckpointStore) query(ctx context.Context, query string, args ...any) ([]*store.Checkpoint, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []*store.Checkpoint
+	for rows.Next() {
+		cp, err := scanCheckpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating checkpoint rows: %w", err)
+	}
 	return checkpoints, nil
 }
 
-// GetLatestByThread returns the latest checkpoint for a thread_id
-func (s *SqliteCheckpointStore) GetLatestByThread(ctx context.Context, threadID string) (*graph.Checkpoint, error) {
-	checkpoints, err := s.ListByThread(ctx, threadID)
+// scanCheckpoint reads a checkpoint from a row of checkpointColumns
+func scanCheckpoint(row interface{ Scan(dest ...any) error }) (*store.Checkpoint, error) {
+	var cp store.Checkpoint
+	var stateJSON string
+	var metadataJSON sql.NullString
+
+	err := row.Scan(
+		&cp.ID,
+		&cp.NodeName,
+		&stateJSON,
+		&metadataJSON,
+		&cp.Timestamp,
+		&cp.Version,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(checkpoints) == 0 {
-		return nil, fmt.Errorf("no checkpoints found for thread: %s", threadID)
+	if err := json.Unmarshal([]byte(stateJSON), &cp.State); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal state: %w", err)
+	}
+
+	if metadataJSON.Valid && metadataJSON.String != "" {
+		if err := json.Unmarshal([]byte(metadataJSON.String), &cp.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
 	}
 
-	// Return the last one (highest version due to sorting)
-	return checkpoints[len(checkpoints)-1], nil
+	return &cp, nil
 }
@@ -2,11 +2,15 @@ package sqlite
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/smallnest/langgraphgo/graph"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSqliteCheckpointStore(t *testing.T) {
@@ -80,3 +84,150 @@ func TestSqliteCheckpointStore(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, list, 0)
 }
+
+func newFileStore(t *testing.T) *SqliteCheckpointStore {
+	t.Helper()
+	store, err := NewSqliteCheckpointStore(SqliteOptions{Path: filepath.Join(t.TempDir(), "checkpoints.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSqliteCheckpointStore_RoundTrip(t *testing.T) {
+	store := newFileStore(t)
+	ctx := context.Background()
+
+	timestamp := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	require.NoError(t, store.Save(ctx, &graph.Checkpoint{
+		ID:        "cp-1",
+		NodeName:  "agent",
+		State:     map[string]any{"count": 2.0, "tags": []any{"a", "b"}},
+		Timestamp: timestamp,
+		Version:   3,
+		Metadata:  map[string]any{"execution_id": "exec-1", "thread_id": "thread-1", "source": "test"},
+	}))
+
+	loaded, err := store.Load(ctx, "cp-1")
+	require.NoError(t, err)
+	assert.Equal(t, "agent", loaded.NodeName)
+	assert.Equal(t, 3, loaded.Version)
+	assert.True(t, timestamp.Equal(loaded.Timestamp))
+	assert.Equal(t, map[string]any{"count": 2.0, "tags": []any{"a", "b"}}, loaded.State)
+	assert.Equal(t, "test", loaded.Metadata["source"])
+
+	// Saving the same ID again replaces the checkpoint
+	require.NoError(t, store.Save(ctx, &graph.Checkpoint{ID: "cp-1", NodeName: "tools", State: "done", Version: 4}))
+	loaded, err = store.Load(ctx, "cp-1")
+	require.NoError(t, err)
+	assert.Equal(t, "tools", loaded.NodeName)
+	assert.Equal(t, "done", loaded.State)
+
+	_, err = store.Load(ctx, "missing")
+	assert.ErrorContains(t, err, "checkpoint not found: missing")
+}
+
+func TestSqliteCheckpointStore_VersionOrder(t *testing.T) {
+	store := newFileStore(t)
+	ctx := context.Background()
+
+	// Saved out of order, with timestamps that disagree with the versions
+	now := time.Now()
+	for i, version := range []int{2, 3, 1} {
+		require.NoError(t, store.Save(ctx, &graph.Checkpoint{
+			ID:        fmt.Sprintf("cp-%d", version),
+			State:     version,
+			Timestamp: now.Add(time.Duration(i) * time.Second),
+			Version:   version,
+			Metadata:  map[string]any{"execution_id": "exec-1", "thread_id": "thread-1"},
+		}))
+	}
+
+	versions := func(checkpoints []*graph.Checkpoint) []int {
+		var result []int
+		for _, cp := range checkpoints {
+			result = append(result, cp.Version)
+		}
+		return result
+	}
+
+	list, err := store.List(ctx, "exec-1")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, versions(list))
+
+	list, err = store.ListByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, versions(list))
+
+	latest, err := store.GetLatestByThread(ctx, "thread-1")
+	require.NoError(t, err)
+	assert.Equal(t, "cp-3", latest.ID)
+
+	_, err = store.GetLatestByThread(ctx, "thread-2")
+	assert.ErrorContains(t, err, "no checkpoints found for thread: thread-2")
+
+	list, err = store.List(ctx, "exec-2")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestSqliteCheckpointStore_ConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.db")
+	ctx := context.Background()
+
+	// Two stores share the database file, like two processes would
+	var stores []*SqliteCheckpointStore
+	for range 2 {
+		store, err := NewSqliteCheckpointStore(SqliteOptions{Path: path})
+		require.NoError(t, err)
+		defer store.Close()
+		stores = append(stores, store)
+	}
+
+	var mode string
+	require.NoError(t, stores[0].db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode))
+	assert.Equal(t, "wal", mode)
+
+	const workers, checkpointsPerWorker = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*checkpointsPerWorker)
+	for w := range workers {
+		wg.Go(func() {
+			store := stores[w%len(stores)]
+			for i := range checkpointsPerWorker {
+				errs <- store.Save(ctx, &graph.Checkpoint{
+					ID:        fmt.Sprintf("worker-%d-%d", w, i),
+					State:     i,
+					Timestamp: time.Now(),
+					Version:   w*checkpointsPerWorker + i + 1,
+					Metadata:  map[string]any{"execution_id": "exec-1"},
+				})
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	list, err := stores[1].List(ctx, "exec-1")
+	require.NoError(t, err)
+	assert.Len(t, list, workers*checkpointsPerWorker)
+
+	require.NoError(t, stores[0].Clear(ctx, "exec-1"))
+	list, err = stores[1].List(ctx, "exec-1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestSqliteCheckpointStore_Ping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.db")
+	store, err := NewSqliteCheckpointStore(SqliteOptions{Path: path})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, store.Ping(ctx))
+
+	require.NoError(t, store.Close())
+	assert.ErrorContains(t, store.Ping(ctx), "sqlite checkpoint store "+path+" is unreachable")
+}
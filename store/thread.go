@@ -0,0 +1,74 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ThreadInfo summarizes the checkpoints of a thread
+type ThreadInfo struct {
+	ThreadID        string    `json:"thread_id"`
+	CheckpointCount int       `json:"checkpoint_count"`
+	LatestVersion   int       `json:"latest_version"`
+	LastUpdated     time.Time `json:"last_updated"` // Timestamp of the latest checkpoint
+}
+
+// ListThreadsOptions selects a page of the threads ListThreads returns
+type ListThreadsOptions struct {
+	// Offset is the number of threads to skip
+	Offset int
+
+	// Limit is the maximum number of threads to return, 0 for no limit
+	Limit int
+}
+
+// ThreadStore is implemented by stores that can enumerate and delete threads
+type ThreadStore interface {
+	// ListThreads returns the threads that have checkpoints, most recently
+	// updated first
+	ListThreads(ctx context.Context, opts ListThreadsOptions) ([]ThreadInfo, error)
+
+	// DeleteThread removes every checkpoint of threadID and its index entries,
+	// as one operation. Deleting a thread without checkpoints is not an error.
+	DeleteThread(ctx context.Context, threadID string) error
+}
+
+// SortThreads orders threads most recently updated first, then by thread ID, and
+// returns the page opts selects
+func SortThreads(threads []ThreadInfo, opts ListThreadsOptions) []ThreadInfo {
+	slices.SortFunc(threads, func(a, b ThreadInfo) int {
+		return cmp.Or(b.LastUpdated.Compare(a.LastUpdated), cmp.Compare(a.ThreadID, b.ThreadID))
+	})
+	threads = threads[min(max(opts.Offset, 0), len(threads)):]
+	if opts.Limit > 0 && opts.Limit < len(threads) {
+		threads = threads[:opts.Limit]
+	}
+	return threads
+}
+
+// ListThreads returns the threads of a store implementing ThreadStore
+func ListThreads(ctx context.Context, s CheckpointStore, opts ListThreadsOptions) ([]ThreadInfo, error) {
+	threads, ok := s.(ThreadStore)
+	if !ok {
+		return nil, fmt.Errorf("store %T cannot list threads", s)
+	}
+	return threads.ListThreads(ctx, opts)
+}
+
+// DeleteThread removes every checkpoint of threadID. Stores implementing
+// ThreadStore do so in one operation; for others the checkpoints of the thread
+// are deleted one by one.
+func DeleteThread(ctx context.Context, s CheckpointStore, threadID string) error {
+	if threads, ok := s.(ThreadStore); ok {
+		return threads.DeleteThread(ctx, threadID)
+	}
+	checkpoints, err := s.ListByThread(ctx, threadID)
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoints for thread %s: %w", threadID, err)
+	}
+	_, err = deleteCheckpoints(ctx, s, checkpoints)
+	return err
+}
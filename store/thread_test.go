@@ -0,0 +1,111 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smallnest/langgraphgo/store"
+)
+
+func TestThreads(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range testStores() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			s := newStore(t)
+			if _, ok := s.(store.ThreadStore); !ok {
+				t.Fatalf("%T does not implement store.ThreadStore", s)
+			}
+
+			now := time.Now().Truncate(time.Second)
+			saveThread(t, s, "older", 2, func(v int) time.Time { return now.Add(time.Duration(v-10) * time.Minute) })
+			saveThread(t, s, "newer", 3, func(v int) time.Time { return now.Add(time.Duration(v) * time.Minute) })
+			saveThread(t, s, "middle", 1, func(int) time.Time { return now })
+
+			threads, err := store.ListThreads(ctx, s, store.ListThreadsOptions{})
+			if err != nil {
+				t.Fatalf("ListThreads failed: %v", err)
+			}
+			want := []store.ThreadInfo{
+				{ThreadID: "newer", CheckpointCount: 3, LatestVersion: 3, LastUpdated: now.Add(3 * time.Minute)},
+				{ThreadID: "middle", CheckpointCount: 1, LatestVersion: 1, LastUpdated: now},
+				{ThreadID: "older", CheckpointCount: 2, LatestVersion: 2, LastUpdated: now.Add(-8 * time.Minute)},
+			}
+			if len(threads) != len(want) {
+				t.Fatalf("Expected %d threads, got %+v", len(want), threads)
+			}
+			for i, thread := range threads {
+				if thread.ThreadID != want[i].ThreadID || thread.CheckpointCount != want[i].CheckpointCount ||
+					thread.LatestVersion != want[i].LatestVersion || !thread.LastUpdated.Equal(want[i].LastUpdated) {
+					t.Errorf("Thread %d: expected %+v, got %+v", i, want[i], thread)
+				}
+			}
+
+			page, err := store.ListThreads(ctx, s, store.ListThreadsOptions{Offset: 1, Limit: 1})
+			if err != nil {
+				t.Fatalf("ListThreads failed: %v", err)
+			}
+			if len(page) != 1 || page[0].ThreadID != "middle" {
+				t.Errorf("Expected the page to hold middle, got %+v", page)
+			}
+
+			if err := store.DeleteThread(ctx, s, "newer"); err != nil {
+				t.Fatalf("DeleteThread failed: %v", err)
+			}
+			if err := store.DeleteThread(ctx, s, "missing"); err != nil {
+				t.Errorf("Expected deleting a thread without checkpoints to succeed, got %v", err)
+			}
+			if _, err := s.Load(ctx, "newer-cp-1"); err == nil {
+				t.Error("Expected the checkpoints of the deleted thread to be gone")
+			}
+			if checkpoints, _ := s.List(ctx, "newer"); len(checkpoints) != 0 {
+				t.Errorf("Expected no checkpoints for the deleted execution, got %d", len(checkpoints))
+			}
+			if _, err := s.GetLatestByThread(ctx, "newer"); err == nil {
+				t.Error("Expected no latest checkpoint for the deleted thread")
+			}
+			if got := threadVersions(t, s, "older"); len(got) != 2 {
+				t.Errorf("Expected the other threads to be untouched, got versions %v", got)
+			}
+
+			threads, err = store.ListThreads(ctx, s, store.ListThreadsOptions{})
+			if err != nil {
+				t.Fatalf("ListThreads failed: %v", err)
+			}
+			if len(threads) != 2 {
+				t.Errorf("Expected 2 threads after deleting one, got %+v", threads)
+			}
+
+			// Prune without thread IDs covers every thread
+			deleted, err := store.Prune(ctx, s, store.RetentionPolicy{MaxPerThread: 1})
+			if err != nil {
+				t.Fatalf("Prune failed: %v", err)
+			}
+			if deleted != 1 {
+				t.Errorf("Expected Prune to delete 1 checkpoint, got %d", deleted)
+			}
+		})
+	}
+}
+
+func TestDeleteThread_Fallback(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := listOnly{testStores()["Memory"](t)}
+	saveThread(t, s, "thread", 3, func(int) time.Time { return time.Now() })
+
+	if _, err := store.ListThreads(ctx, s, store.ListThreadsOptions{}); err == nil {
+		t.Error("Expected ListThreads to fail for a store that cannot list threads")
+	}
+	if err := store.DeleteThread(ctx, s, "thread"); err != nil {
+		t.Fatalf("DeleteThread failed: %v", err)
+	}
+	if got := threadVersions(t, s, "thread"); len(got) != 0 {
+		t.Errorf("Expected the thread to be empty, got versions %v", got)
+	}
+}
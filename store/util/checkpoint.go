@@ -6,11 +6,11 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/smallnest/langgraphgo/graph"
+	"github.com/smallnest/langgraphgo/store"
 )
 
-// Checkpoint wraps graph.Checkpoint for store implementations.
-type Checkpoint = graph.Checkpoint
+// Checkpoint wraps store.Checkpoint for store implementations.
+type Checkpoint = store.Checkpoint
 
 // ExtractMetadataIDs extracts execution_id and thread_id from checkpoint metadata.
 // Returns empty strings if the keys are not present or have wrong types.
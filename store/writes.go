@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWritesNotSupported is returned by SaveWrite for stores that don't implement
+// WriteStore
+var ErrWritesNotSupported = errors.New("store cannot save pending writes")
+
+// PendingWrite is the update a node of a parallel step returned, saved as soon as
+// the node completes. Writes are keyed to the checkpoint the step started from,
+// so that resuming from that checkpoint runs only the nodes of the step that have
+// no write.
+type PendingWrite struct {
+	CheckpointID string         `json:"checkpoint_id"`
+	Node         string         `json:"node"`
+	Value        any            `json:"value"`
+	Timestamp    time.Time      `json:"timestamp"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
+}
+
+// WriteStore is implemented by stores that can save pending writes, see SaveWrite
+type WriteStore interface {
+	// SaveWrite saves write, replacing the write of the same node for the same
+	// checkpoint
+	SaveWrite(ctx context.Context, write *PendingWrite) error
+
+	// ListWrites returns the writes of checkpointID sorted by node name, and none
+	// if it has none
+	ListWrites(ctx context.Context, checkpointID string) ([]*PendingWrite, error)
+
+	// DeleteWrites removes the writes of checkpointID. Deleting a checkpoint also
+	// deletes its writes.
+	DeleteWrites(ctx context.Context, checkpointID string) error
+}
+
+// SaveWrite saves a pending write in a store implementing WriteStore, such as the
+// memory and file stores, and returns ErrWritesNotSupported for others.
+func SaveWrite(ctx context.Context, s CheckpointStore, write *PendingWrite) error {
+	writes, ok := s.(WriteStore)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrWritesNotSupported, s)
+	}
+	return writes.SaveWrite(ctx, write)
+}
+
+// ListWrites returns the pending writes of checkpointID, and none for stores that
+// don't implement WriteStore.
+func ListWrites(ctx context.Context, s CheckpointStore, checkpointID string) ([]*PendingWrite, error) {
+	if writes, ok := s.(WriteStore); ok {
+		return writes.ListWrites(ctx, checkpointID)
+	}
+	return nil, nil
+}
+
+// DeleteWrites removes the pending writes of checkpointID from a store implementing
+// WriteStore; it does nothing for others.
+func DeleteWrites(ctx context.Context, s CheckpointStore, checkpointID string) error {
+	if writes, ok := s.(WriteStore); ok {
+		return writes.DeleteWrites(ctx, checkpointID)
+	}
+	return nil
+}